@@ -0,0 +1,172 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/pkg/api"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+)
+
+// NewCmdAPI returns a new cobra command
+func NewCmdAPI() *cobra.Command {
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Serve the local k3d API for IDE integrations",
+		Long:  `Serve the local k3d API for IDE integrations (see 'k3d api-info' for a description of the API)`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cmd.Help(); err != nil {
+				l.Log().Errorln("Couldn't get help text")
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// add subcommands
+	cmd.AddCommand(NewCmdAPIServe(), NewCmdAPIToken())
+
+	// done
+	return cmd
+}
+
+// NewCmdAPIServe returns a new cobra command
+func NewCmdAPIServe() *cobra.Command {
+
+	var socketPath string
+	var stateSpec string
+	var authFile string
+	var socketGroup string
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the local k3d API on a unix socket",
+		Long: `Serve the local k3d API on a unix socket (only accessible by the current user).
+
+The API allows IDE integrations (e.g. the k3d VS Code extension) to list clusters, create them from a config file
+and stream their status without spawning the k3d CLI for every request.
+It runs in the foreground until interrupted, so run it in the background (e.g. 'k3d api serve &') or as a service.
+
+By default, the API server keeps its background jobs in memory. With '--state file://PATH', it keeps them in a JSON file instead,
+which multiple API servers on the same host (e.g. one per user of a shared host) can share: they see the same jobs,
+but a single lock runs the cluster operations of all of them one after another, so more servers don't run more operations at once.
+The file has to be on a local filesystem (file locks). The unfinished jobs of servers that stopped sending heartbeats
+(e.g. because they crashed) are marked as failed.
+
+With '--auth-file', every request needs a token ('Authorization: Bearer TOKEN') of a user listed in the auth file
+(see 'k3d api token'), e.g. for a shared team server, and the socket is accessible by the group given via '--socket-group'.
+Users only see and manage the clusters they created via the API (and the jobs for them), while admins manage all clusters.
+Users can't mount host paths, set runtime options or use existing networks, volumes or registries, but the k3s nodes are
+privileged containers, so a token is still equivalent to root access on the host: only hand them out to trusted users.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if socketPath == "" {
+				var err error
+				socketPath, err = api.GetDefaultSocketPath()
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+			}
+
+			store, err := api.NewStateStore(stateSpec)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			defer store.Close()
+
+			var auth *api.Authenticator
+			if authFile != "" {
+				if auth, err = api.LoadAuthenticator(authFile); err != nil {
+					l.Log().Fatalln(err)
+				}
+			}
+
+			if err := api.Serve(ctx, runtimes.SelectedRuntime, api.ServeOpts{SocketPath: socketPath, StateStore: store, Auth: auth, SocketGroup: socketGroup}); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Path of the unix socket to serve the API on (default: $HOME/.k3d/api.sock)")
+	cmd.Flags().StringVar(&authFile, "auth-file", "", "Require tokens of the users listed in this file (YAML, see 'k3d api token') and let them manage only their own clusters")
+	if err := cmd.MarkFlagFilename("auth-file", "yaml", "yml", "json"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'auth-file' as filename flag")
+	}
+	cmd.Flags().StringVar(&socketGroup, "socket-group", "", "Let the members of this group access the socket (Format: `GROUP`, name or ID)\n - Example: `k3d api serve --auth-file auth.yaml --socket /run/k3d/api.sock --socket-group k3d`")
+	cmd.Flags().StringVar(&stateSpec, "state", api.StateBackendMemory, "Where to keep the state (background jobs) of the API server: 'memory' or a file shared with other API servers on the same host (Format: `memory|file://PATH`)\n - Example: `k3d api serve --state file:///srv/k3d/api-state.json`")
+
+	// done
+	return cmd
+}
+
+// NewCmdAPIToken returns a new cobra command
+func NewCmdAPIToken() *cobra.Command {
+
+	var role string
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "token USER",
+		Short: "Generate a token for a user of the k3d API",
+		Long: `Generate a random token for a user of the k3d API served with '--auth-file'.
+
+It prints the token (to be handed to the user) and the entry to add to the users of the auth file,
+which only contains the hash of the token, e.g.:
+  users:
+    - name: alice
+      role: user
+      tokenSha256: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if api.Role(role) != api.RoleUser && api.Role(role) != api.RoleAdmin {
+				l.Log().Fatalf("Invalid role '%s': must be one of %v", role, []api.Role{api.RoleUser, api.RoleAdmin})
+			}
+			token, err := api.GenerateToken()
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			entry, err := yaml.Marshal([]api.User{{Name: args[0], Role: api.Role(role), TokenSHA256: api.HashToken(token)}})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			fmt.Printf("Token: %s\n\nAuth file entry (below 'users:'):\n%s", token, entry)
+		},
+	}
+
+	cmd.Flags().StringVar(&role, "role", string(api.RoleUser), "Role of the user: 'user' (manages their own clusters) or 'admin' (manages all clusters)")
+
+	// done
+	return cmd
+}
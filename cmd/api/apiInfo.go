@@ -0,0 +1,102 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/pkg/api"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// Info describes the local k3d API
+type Info struct {
+	APIVersion string         `yaml:"apiVersion" json:"apiVersion"`
+	K3dVersion string         `yaml:"k3dVersion" json:"k3dVersion"`
+	Socket     string         `yaml:"socket" json:"socket"`
+	Running    bool           `yaml:"running" json:"running"`
+	Serve      string         `yaml:"serve" json:"serve"`
+	Endpoints  []api.Endpoint `yaml:"endpoints" json:"endpoints"`
+}
+
+// NewCmdAPIInfo returns a new cobra command
+func NewCmdAPIInfo() *cobra.Command {
+
+	var output string
+	var socketPath string
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "api-info",
+		Short: "Describe the local k3d API for IDE integrations",
+		Long: `Describe the local k3d API for IDE integrations: the socket it's served on, whether it's running and its endpoints.
+
+Requests are plain HTTP on the unix socket, e.g.
+	curl --unix-socket ~/.k3d/api.sock http://k3d/v1/clusters`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if socketPath == "" {
+				var err error
+				socketPath, err = api.GetDefaultSocketPath()
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+			}
+
+			info := Info{
+				APIVersion: api.Version,
+				K3dVersion: version.GetVersion(),
+				Socket:     socketPath,
+				Running:    api.IsRunning(socketPath),
+				Serve:      fmt.Sprintf("%s api serve --socket %s", os.Args[0], socketPath),
+				Endpoints:  api.Endpoints,
+			}
+
+			switch output {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(info); err != nil {
+					l.Log().Fatalln(err)
+				}
+			case "yaml":
+				if err := yaml.NewEncoder(os.Stdout).Encode(info); err != nil {
+					l.Log().Fatalln(err)
+				}
+			default:
+				l.Log().Fatalf("Unknown output format '%s' (supported: json, yaml)", output)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format. One of: json|yaml")
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Path of the unix socket the API is served on (default: $HOME/.k3d/api.sock)")
+
+	// done
+	return cmd
+}
@@ -0,0 +1,260 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	k3dcluster "github.com/rancher/k3d/v5/cmd/cluster"
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/config"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// DevcontainerHostRecord is the hostname under which the docker host is reachable from inside the devcontainer
+const DevcontainerHostRecord = "host.docker.internal"
+
+type devcontainerFlags struct {
+	outputDir   string
+	clusterName string
+	apiPort     string
+	ports       []string
+	baseImage   string
+	create      bool
+	force       bool
+}
+
+// devcontainerSpec is the subset of the devcontainer.json spec (https://containers.dev/implementors/json_reference/) that we generate
+type devcontainerSpec struct {
+	Name              string                            `json:"name"`
+	Image             string                            `json:"image"`
+	Features          map[string]map[string]interface{} `json:"features"`
+	RunArgs           []string                          `json:"runArgs,omitempty"`
+	ForwardPorts      []int                             `json:"forwardPorts,omitempty"`
+	PostCreateCommand string                            `json:"postCreateCommand,omitempty"`
+}
+
+// NewCmdBootstrapDevcontainer returns a new cobra command
+func NewCmdBootstrapDevcontainer() *cobra.Command {
+
+	flags := devcontainerFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "devcontainer",
+		Short: "Generate devcontainer configuration and a cluster suitable for DevContainers/Codespaces",
+		Long: `Generate devcontainer configuration and a cluster suitable for DevContainers/Codespaces.
+
+Inside a devcontainer, the docker daemon usually runs on the host (docker-outside-of-docker),
+so ports published by k3d are not reachable via 'localhost' from inside the devcontainer.
+This command generates
+  - a devcontainer.json, which wires up the docker socket and the 'host.docker.internal' host record
+  - a k3d config file, which exposes the Kubernetes API via 'host.docker.internal'
+and creates the cluster right away, if we're already running inside a devcontainer (or if '--create' is set).`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("create") {
+				flags.create = isDevcontainerEnvironment()
+			}
+
+			simpleCfg, err := devcontainerClusterConfig(flags)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if err := os.MkdirAll(flags.outputDir, 0755); err != nil {
+				l.Log().Fatalf("Failed to create output directory '%s': %v", flags.outputDir, err)
+			}
+
+			configPath := filepath.Join(flags.outputDir, "k3d.yaml")
+			configBytes, err := yaml.Marshal(simpleCfg)
+			if err != nil {
+				l.Log().Fatalf("Failed to marshal k3d config: %v", err)
+			}
+			if err := writeFileIfNotExists(configPath, configBytes, flags.force); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infof("Wrote k3d config to '%s'", configPath)
+
+			devcontainerPath := filepath.Join(flags.outputDir, "devcontainer.json")
+			var devcontainerBuf bytes.Buffer
+			encoder := json.NewEncoder(&devcontainerBuf)
+			encoder.SetEscapeHTML(false) // keep shell operators in postCreateCommand readable
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(devcontainerConfig(flags, simpleCfg)); err != nil {
+				l.Log().Fatalf("Failed to marshal devcontainer config: %v", err)
+			}
+			if err := writeFileIfNotExists(devcontainerPath, devcontainerBuf.Bytes(), flags.force); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infof("Wrote devcontainer config to '%s'", devcontainerPath)
+
+			if !flags.create {
+				l.Log().Infoln("Not creating the cluster now: it will be created when the devcontainer is built (use '--create' to override)")
+				return
+			}
+
+			/*************************
+			 * Create the cluster    *
+			 *************************/
+
+			if err := config.ProcessSimpleConfig(&simpleCfg); err != nil {
+				l.Log().Fatalf("error processing/sanitizing simple config: %v", err)
+			}
+
+			clusterConfig, err := config.TransformSimpleToClusterConfig(cmd.Context(), runtimes.SelectedRuntime, simpleCfg)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if err := config.ValidateClusterConfig(cmd.Context(), runtimes.SelectedRuntime, *clusterConfig); err != nil {
+				l.Log().Fatalln("Failed Cluster Configuration Validation: ", err)
+			}
+
+			if err := k3dcluster.CreateCluster(cmd.Context(), runtimes.SelectedRuntime, clusterConfig, "creation", nil); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infof("Cluster '%s' created successfully!", clusterConfig.Cluster.Name)
+		},
+	}
+
+	/*********
+	 * Flags *
+	 *********/
+	cmd.Flags().StringVarP(&flags.outputDir, "output-dir", "o", ".devcontainer", "Directory to write the devcontainer.json and k3d config file to")
+	cmd.Flags().StringVarP(&flags.clusterName, "cluster", "c", "devcontainer", "Name of the cluster")
+	cmd.Flags().StringVar(&flags.apiPort, "api-port", "6550", "Host port to expose the Kubernetes API on (fixed, so it can be forwarded)")
+	cmd.Flags().StringArrayVarP(&flags.ports, "port", "p", []string{"8080:80@loadbalancer"}, "Map ports from the node containers (via the serverlb) to the host (Format: `[HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]`)")
+	cmd.Flags().StringVar(&flags.baseImage, "devcontainer-image", "mcr.microsoft.com/devcontainers/base:ubuntu", "Base image for the generated devcontainer")
+	cmd.Flags().BoolVar(&flags.create, "create", false, "Create the cluster right away (default: only if running inside a devcontainer/Codespace)")
+	cmd.Flags().BoolVarP(&flags.force, "force", "f", false, "Force overwrite of existing files")
+
+	// done
+	return cmd
+}
+
+// isDevcontainerEnvironment checks whether we're running inside a devcontainer or GitHub Codespace
+func isDevcontainerEnvironment() bool {
+	for _, env := range []string{"CODESPACES", "REMOTE_CONTAINERS"} {
+		if strings.ToLower(os.Getenv(env)) == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// devcontainerClusterConfig generates a SimpleConfig with the API exposed via the docker host record
+func devcontainerClusterConfig(flags devcontainerFlags) (conf.SimpleConfig, error) {
+	simpleCfg := conf.SimpleConfig{
+		Name:    flags.clusterName,
+		Servers: 1,
+		Image:   fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion),
+		ExposeAPI: conf.SimpleExposureOpts{
+			Host:     DevcontainerHostRecord,
+			HostIP:   k3d.DefaultAPIHost,
+			HostPort: flags.apiPort,
+		},
+		Options: conf.SimpleConfigOptions{
+			K3dOptions: conf.SimpleConfigOptionsK3d{
+				Wait: true,
+			},
+			KubeconfigOptions: conf.SimpleConfigOptionsKubeconfig{
+				UpdateDefaultKubeconfig: true,
+				SwitchCurrentContext:    true,
+			},
+		},
+	}
+	simpleCfg.APIVersion = config.DefaultConfigApiVersion
+	simpleCfg.Kind = "Simple"
+
+	for _, portFlag := range flags.ports {
+		portmap, filters, err := cliutil.SplitFiltersFromFlag(portFlag)
+		if err != nil {
+			return simpleCfg, err
+		}
+		simpleCfg.Ports = append(simpleCfg.Ports, conf.PortWithNodeFilters{
+			Port:        portmap,
+			NodeFilters: filters,
+		})
+	}
+
+	return simpleCfg, nil
+}
+
+// devcontainerConfig generates the devcontainer.json content
+func devcontainerConfig(flags devcontainerFlags, simpleCfg conf.SimpleConfig) devcontainerSpec {
+	spec := devcontainerSpec{
+		Name:  fmt.Sprintf("%s-%s", k3d.ObjectNamePrefix, flags.clusterName),
+		Image: flags.baseImage,
+		Features: map[string]map[string]interface{}{
+			"ghcr.io/devcontainers/features/docker-outside-of-docker:1": {},
+			"ghcr.io/devcontainers/features/kubectl-helm-minikube:1": {
+				"minikube": "none",
+			},
+			"ghcr.io/rio/features/k3d:1": {},
+		},
+		RunArgs:           []string{fmt.Sprintf("--add-host=%s:host-gateway", DevcontainerHostRecord)},
+		PostCreateCommand: fmt.Sprintf("k3d cluster list %[1]s >/dev/null 2>&1 || k3d cluster create --config %[2]s/k3d.yaml", flags.clusterName, filepath.ToSlash(flags.outputDir)),
+	}
+
+	// forward the API port and all explicitly set host ports
+	forwardPorts := []string{simpleCfg.ExposeAPI.HostPort}
+	for _, p := range simpleCfg.Ports {
+		split := strings.Split(strings.Split(p.Port, "/")[0], ":")
+		if len(split) > 1 {
+			forwardPorts = append(forwardPorts, split[len(split)-2])
+		}
+	}
+	for _, p := range forwardPorts {
+		var port int
+		if _, err := fmt.Sscanf(p, "%d", &port); err == nil && port > 0 {
+			spec.ForwardPorts = append(spec.ForwardPorts, port)
+		}
+	}
+
+	return spec
+}
+
+// writeFileIfNotExists writes a file, but refuses to overwrite an existing one unless force is set
+func writeFileIfNotExists(path string, content []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("file '%s' exists and --force was not set", path)
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat file '%s': %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", path, err)
+	}
+	return nil
+}
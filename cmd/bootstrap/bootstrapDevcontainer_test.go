@@ -0,0 +1,96 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package bootstrap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDevcontainerConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		flags            devcontainerFlags
+		wantForwardPorts []int
+		wantPostCreate   string
+	}{
+		{
+			name: "defaults",
+			flags: devcontainerFlags{
+				outputDir:   ".devcontainer",
+				clusterName: "devcontainer",
+				apiPort:     "6550",
+				ports:       []string{"8080:80@loadbalancer"},
+				baseImage:   "mcr.microsoft.com/devcontainers/base:ubuntu",
+			},
+			wantForwardPorts: []int{6550, 8080},
+			wantPostCreate:   "k3d cluster list devcontainer >/dev/null 2>&1 || k3d cluster create --config .devcontainer/k3d.yaml",
+		},
+		{
+			name: "host IP, protocol and container-only ports",
+			flags: devcontainerFlags{
+				outputDir:   "dev/container",
+				clusterName: "mycluster",
+				apiPort:     "6443",
+				ports:       []string{"127.0.0.1:9090:90/udp@agent:0", "443@loadbalancer"},
+			},
+			wantForwardPorts: []int{6443, 9090},
+			wantPostCreate:   "k3d cluster list mycluster >/dev/null 2>&1 || k3d cluster create --config dev/container/k3d.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simpleCfg, err := devcontainerClusterConfig(tt.flags)
+			if err != nil {
+				t.Fatalf("devcontainerClusterConfig() error = %v", err)
+			}
+			if simpleCfg.Name != tt.flags.clusterName {
+				t.Errorf("cluster name = %q, want %q", simpleCfg.Name, tt.flags.clusterName)
+			}
+			if simpleCfg.ExposeAPI.Host != DevcontainerHostRecord || simpleCfg.ExposeAPI.HostPort != tt.flags.apiPort {
+				t.Errorf("API exposed as %s:%s, want %s:%s", simpleCfg.ExposeAPI.Host, simpleCfg.ExposeAPI.HostPort, DevcontainerHostRecord, tt.flags.apiPort)
+			}
+			if len(simpleCfg.Ports) != len(tt.flags.ports) {
+				t.Errorf("got %d port mappings, want %d", len(simpleCfg.Ports), len(tt.flags.ports))
+			}
+
+			spec := devcontainerConfig(tt.flags, simpleCfg)
+			if spec.Name != "k3d-"+tt.flags.clusterName {
+				t.Errorf("name = %q, want %q", spec.Name, "k3d-"+tt.flags.clusterName)
+			}
+			if spec.Image != tt.flags.baseImage {
+				t.Errorf("image = %q, want %q", spec.Image, tt.flags.baseImage)
+			}
+			if !reflect.DeepEqual(spec.ForwardPorts, tt.wantForwardPorts) {
+				t.Errorf("forwardPorts = %v, want %v", spec.ForwardPorts, tt.wantForwardPorts)
+			}
+			if !reflect.DeepEqual(spec.RunArgs, []string{"--add-host=host.docker.internal:host-gateway"}) {
+				t.Errorf("runArgs = %v", spec.RunArgs)
+			}
+			if spec.PostCreateCommand != tt.wantPostCreate {
+				t.Errorf("postCreateCommand = %q, want %q", spec.PostCreateCommand, tt.wantPostCreate)
+			}
+		})
+	}
+}
@@ -22,7 +22,12 @@ THE SOFTWARE.
 package cluster
 
 import (
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
 
 	"github.com/spf13/cobra"
 )
@@ -33,11 +38,11 @@ func NewCmdCluster() *cobra.Command {
 	// create new cobra command
 	cmd := &cobra.Command{
 		Use:   "cluster",
-		Short: "Manage cluster(s)",
-		Long:  `Manage cluster(s)`,
+		Short: i18n.T("cmd.cluster.short"),
+		Long:  i18n.T("cmd.cluster.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
@@ -49,10 +54,54 @@ func NewCmdCluster() *cobra.Command {
 		NewCmdClusterStop(),
 		NewCmdClusterDelete(),
 		NewCmdClusterList(),
-		NewCmdClusterEdit())
+		NewCmdClusterEdit(),
+		NewCmdClusterDiff(),
+		NewCmdClusterApply(),
+		NewCmdClusterResyncTime(),
+		NewCmdClusterSystemdInstall(),
+		NewCmdClusterEvents(),
+		NewCmdClusterStatus(),
+		NewCmdClusterPrune(),
+		NewCmdClusterPorts(),
+		NewCmdClusterBackup(),
+		NewCmdClusterRestore(),
+		NewCmdClusterRepair(),
+		NewCmdClusterUpgrade(),
+		NewCmdClusterNetpolTest())
 
 	// add flags
 
 	// done
 	return cmd
 }
+
+// addClusterSelectorFlag adds the --selector flag to select clusters by the runtime labels of their nodes
+func addClusterSelectorFlag(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringArrayP("selector", "l", nil, verb+" all clusters whose nodes have these runtime labels, e.g. set via '--runtime-label' on creation (Format: `KEY=VALUE[,KEY=VALUE...]`, can be used multiple times)")
+}
+
+// parseClusterSelectorFlag returns the clusters selected via the --selector flag and whether the flag was set
+func parseClusterSelectorFlag(cmd *cobra.Command, args []string) ([]*k3d.Cluster, bool) {
+	selectors, err := cmd.Flags().GetStringArray("selector")
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	if len(selectors) == 0 {
+		return nil, false
+	}
+
+	if all, _ := cmd.Flags().GetBool("all"); all || len(args) > 0 {
+		l.Log().Fatalln("Cannot use `--selector` together with `--all` or cluster names")
+	}
+
+	selector, err := util.ParseSelector(selectors)
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+
+	clusters, err := client.ClusterListBySelector(cmd.Context(), runtimes.SelectedRuntime, selector)
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	return clusters, true
+}
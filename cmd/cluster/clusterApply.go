@@ -0,0 +1,143 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/config"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterApplyFlags struct {
+	configFile string
+}
+
+// NewCmdClusterApply returns a new cobra command
+func NewCmdClusterApply() *cobra.Command {
+
+	flags := clusterApplyFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "apply [NAME] --config FILE",
+		Short: "Create a cluster from a config file or update an existing one to match it",
+		Long: `Create a cluster from a config file, if it doesn't exist yet, or update an existing cluster to match the config file:
+	- nodes are added or removed to match the server and agent counts
+	- agent nodes with changes (e.g. image, args or env) are recreated
+	- the loadbalancer is replaced to apply changed ports
+	- missing registries are created (if managed by the cluster) and connected to the cluster
+Changes that would require recreating server nodes or (en/dis)abling the loadbalancer are rejected, since they'd reset the cluster.
+Use 'k3d cluster diff' to preview the changes.`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: cliutil.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			simpleCfg, err := simpleConfigFromFile(flags.configFile, name)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			existingCluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: simpleCfg.Name})
+			if err != nil {
+				if !errors.Is(err, client.ClusterGetNoNodesFoundError) {
+					l.Log().Fatalf("Failed to get cluster '%s': %v", simpleCfg.Name, err)
+				}
+				l.Log().Infof("Cluster '%s' does not exist yet: creating it...", simpleCfg.Name)
+				if err := applyCreateCluster(cmd.Context(), simpleCfg); err != nil {
+					l.Log().Fatalln(err)
+				}
+				l.Log().Infoln(cliutil.Success(fmt.Sprintf("Cluster '%s' created successfully!", simpleCfg.Name)))
+				return
+			}
+
+			// keep the API port of the running cluster, if none is set explicitly
+			if isRandomPortSpec(simpleCfg.ExposeAPI.HostPort) {
+				simpleCfg.ExposeAPI.HostPort = ""
+			}
+			clusterConfig, err := clusterConfigFromSimpleConfig(cmd.Context(), simpleCfg)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			diff, err := client.ClusterApply(cmd.Context(), runtimes.SelectedRuntime, existingCluster, clusterConfig)
+			if err != nil {
+				l.Log().Fatalf("Failed to apply config to cluster '%s': %v", simpleCfg.Name, err)
+			}
+			if diff.IsEmpty() {
+				l.Log().Infof("Cluster '%s' already matches the config", simpleCfg.Name)
+				return
+			}
+			if err := printClusterDiff(diff, ""); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Cluster '%s' updated successfully!", simpleCfg.Name)))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.configFile, "config", "c", "", "Path of the config file to apply")
+	if err := cmd.MarkFlagRequired("config"); err != nil {
+		l.Log().Fatalln("Failed to mark required flag 'config'")
+	}
+	if err := cmd.MarkFlagFilename("config", "yaml", "yml"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'config' as filename flag")
+	}
+
+	// done
+	return cmd
+}
+
+// applyCreateCluster creates a new cluster from a simple config, just like `cluster create` does
+func applyCreateCluster(ctx context.Context, simpleCfg conf.SimpleConfig) error {
+	randomAPIPort := isRandomPortSpec(simpleCfg.ExposeAPI.HostPort)
+	if randomAPIPort {
+		simpleCfg.ExposeAPI.HostPort = getRandomAPIPort(simpleCfg.ExposeAPI.HostIP)
+	}
+
+	clusterConfig, err := clusterConfigFromSimpleConfig(ctx, simpleCfg)
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateClusterConfig(ctx, runtimes.SelectedRuntime, *clusterConfig); err != nil {
+		return fmt.Errorf("failed cluster configuration validation: %w", err)
+	}
+
+	if err := CreateCluster(ctx, runtimes.SelectedRuntime, clusterConfig, "creation", nil); err != nil {
+		return err
+	}
+	recordClusterCreate(simpleCfg, clusterConfig, randomAPIPort)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterBackupFlags struct {
+	output string
+	live   bool
+}
+
+// NewCmdClusterBackup returns a new cobra command
+func NewCmdClusterBackup() *cobra.Command {
+
+	flags := clusterBackupFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "backup NAME",
+		Short: "Backup a cluster to an archive",
+		Long: `Backup a cluster to a gzipped tar archive, which contains the data of each server and agent node, the cluster token, network settings and exposed ports.
+Use 'k3d cluster restore' to reconstruct the cluster from the archive on this or another machine.
+
+Running clusters are stopped during the backup (and started again afterwards), so that the node data is consistent. Use '--live' to skip that at your own risk.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			output := flags.output
+			if output == "" {
+				output = fmt.Sprintf("%s-%s-backup.tar.gz", k3d.ObjectNamePrefix, cluster.Name)
+			}
+
+			stopped := false
+			if !flags.live {
+				for _, node := range cluster.Nodes {
+					if node.State.Running {
+						stopped = true
+						break
+					}
+				}
+			}
+			if stopped {
+				if err := client.ClusterStop(cmd.Context(), runtimes.SelectedRuntime, cluster); err != nil {
+					l.Log().Fatalln(err)
+				}
+			}
+
+			backupErr := writeClusterBackup(cmd, cluster, output)
+			if stopped {
+				restartClusterAfterBackup(cluster)
+			}
+			if backupErr != nil {
+				l.Log().Fatalln(backupErr)
+			}
+			l.Log().Infof("Successfully backed up cluster '%s' to %s", cluster.Name, output)
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Path of the backup archive (default: k3d-NAME-backup.tar.gz)")
+	if err := cmd.MarkFlagFilename("output", "tar.gz", "tgz"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'output' as filename flag")
+	}
+	cmd.Flags().BoolVar(&flags.live, "live", false, "Don't stop the cluster during the backup (the node data may be inconsistent)")
+
+	// done
+	return cmd
+}
+
+// writeClusterBackup writes the backup to a temporary file next to the output, so that a failed backup doesn't leave a broken archive behind
+func writeClusterBackup(cmd *cobra.Command, cluster *k3d.Cluster, output string) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err := client.ClusterBackupCreate(cmd.Context(), runtimes.SelectedRuntime, cluster, tmpFile); err != nil {
+		return fmt.Errorf("failed to backup cluster '%s': %w", cluster.Name, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), output); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	return nil
+}
+
+// restartClusterAfterBackup starts the cluster again after it was stopped for the backup.
+// It doesn't use the command's context, so that the cluster is started again even if the backup was interrupted.
+func restartClusterAfterBackup(cluster *k3d.Cluster) {
+	ctx := context.Background()
+	envInfo, err := client.GatherEnvironmentInfo(ctx, runtimes.SelectedRuntime, cluster)
+	if err != nil {
+		l.Log().Fatalf("failed to gather info about cluster environment: %v", err)
+	}
+	startClusterOpts := k3d.ClusterStartOpts{
+		WaitForServer:   true,
+		EnvironmentInfo: envInfo,
+		Intent:          k3d.IntentClusterStart,
+	}
+	if err := client.ClusterStart(ctx, runtimes.SelectedRuntime, cluster, startClusterOpts); err != nil {
+		l.Log().Fatalf("Failed to start cluster '%s' again after the backup: %v", cluster.Name, err)
+	}
+	l.Log().Infof("Started cluster '%s' again", cluster.Name)
+}
@@ -23,9 +23,11 @@ THE SOFTWARE.
 package cluster
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"runtime"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -42,13 +44,19 @@ import (
 	k3dCluster "github.com/rancher/k3d/v5/pkg/client"
 	"github.com/rancher/k3d/v5/pkg/config"
 	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 	"github.com/rancher/k3d/v5/version"
 )
 
 var configFile string
+var emitConfigFile string
+
+var asyncCreate bool
+var envFile string
 
 const clusterCreateDescription = `
 Create a new k3s cluster with containerized nodes (k3s in docker).
@@ -90,11 +98,17 @@ func NewCmdClusterCreate() *cobra.Command {
 	// create new command
 	cmd := &cobra.Command{
 		Use:   "create NAME",
-		Short: "Create a new cluster",
+		Short: i18n.T("cmd.cluster.create.short"),
 		Long:  clusterCreateDescription,
 		Args:  cobra.RangeArgs(0, 1), // exactly one cluster name can be set (default: k3d.DefaultClusterName)
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return initConfig()
+			if err := initConfig(); err != nil {
+				l.Log().Fatalln(err)
+			}
+			if interactiveCreateOpts.enabled {
+				return runInteractiveCreate(cmd, args)
+			}
+			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 
@@ -124,6 +138,9 @@ func NewCmdClusterCreate() *cobra.Command {
 
 			l.Log().Debugf("========== Simple Config ==========\n%+v\n==========================\n", simpleCfg)
 
+			// remember whether the API port was chosen by the user, so we don't pin a random one in an emitted config
+			apiPortSet := !isRandomPortSpec(simpleCfg.ExposeAPI.HostPort) || (ppViper.IsSet("cli.api-port") && !isRandomPortSpec(ppViper.GetString("cli.api-port")))
+
 			simpleCfg, err = applyCLIOverrides(simpleCfg)
 			if err != nil {
 				l.Log().Fatalf("Failed to apply CLI overrides: %+v", err)
@@ -140,6 +157,64 @@ func NewCmdClusterCreate() *cobra.Command {
 				simpleCfg.Name = args[0]
 			}
 
+			// interactive mode: the user chose the name and may want to save the config without creating the cluster
+			if interactiveCreateOpts.enabled {
+				simpleCfg.Name = interactiveCreateOpts.name
+				if interactiveCreateOpts.configFile != "" {
+					emittedCfg := simpleCfg
+					if !apiPortSet {
+						emittedCfg.ExposeAPI.HostPort = ""
+					}
+					if err := writeSimpleConfig(emittedCfg, interactiveCreateOpts.configFile); err != nil {
+						l.Log().Fatalln(err)
+					}
+					l.Log().Infof("Wrote config to '%s'", interactiveCreateOpts.configFile)
+				}
+				if !interactiveCreateOpts.create {
+					l.Log().Infoln("Cluster was not created")
+					return
+				}
+			}
+
+			// only write the config file, if requested
+			if emitConfigFile != "" {
+				if !apiPortSet {
+					simpleCfg.ExposeAPI.HostPort = ""
+				}
+				if err := writeSimpleConfig(simpleCfg, emitConfigFile); err != nil {
+					l.Log().Fatalln(err)
+				}
+				if emitConfigFile != "-" {
+					l.Log().Infof("Wrote config to '%s' (cluster was not created)", emitConfigFile)
+				}
+				return
+			}
+
+			// create the cluster in a background process, if requested
+			if asyncCreate {
+				if interactiveCreateOpts.enabled {
+					l.Log().Fatalln("Cannot use '--async' together with '--interactive'")
+				}
+				if f := cmd.Flag("progress-file"); f != nil && f.Changed {
+					l.Log().Fatalln("Cannot use '--async' together with '--progress-file': the background creation writes its own progress file")
+				}
+				clusterName := simpleCfg.Name
+				if clusterName == "" {
+					clusterName = k3d.DefaultClusterName
+				}
+				if _, err := k3dCluster.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterName}); err == nil {
+					l.Log().Fatalf("Failed to create cluster '%s' because a cluster with that name already exists", clusterName)
+				}
+				job, err := startAsyncClusterCreate(clusterName)
+				if err != nil {
+					l.Log().Fatalf("Failed to start background creation of cluster '%s': %v", clusterName, err)
+				}
+				l.Log().Infof("Creating cluster '%s' in the background (PID %d, log: %s)", clusterName, job.PID, job.LogFile)
+				l.Log().Infof("Check its status with `%s cluster status %s` (add `--wait` to block until it's done)", os.Args[0], clusterName)
+				fmt.Println(clusterName)
+				return
+			}
+
 			if err := config.ProcessSimpleConfig(&simpleCfg); err != nil {
 				l.Log().Fatalf("error processing/sanitizing simple config: %v", err)
 			}
@@ -164,40 +239,46 @@ func NewCmdClusterCreate() *cobra.Command {
 			}
 
 			// create cluster
-			if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig {
-				l.Log().Debugln("'--kubeconfig-update-default set: enabling wait-for-server")
+			if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig || clusterConfig.KubeconfigOpts.Output != "" || envFile != "" {
+				if !clusterConfig.ClusterCreateOpts.WaitForServer && cmd.Flags().Changed("wait") {
+					l.Log().Infoln("--wait=false is overridden, as the kubeconfig can only be written once the server is ready: use '--kubeconfig-update-default=false' to not wait or '--async' to create the cluster in the background")
+				} else {
+					l.Log().Debugln("'--kubeconfig-update-default', '--kubeconfig-output' or '--env-file' set: enabling wait-for-server")
+				}
 				clusterConfig.ClusterCreateOpts.WaitForServer = true
 			}
-			//if err := k3dCluster.ClusterCreate(cmd.Context(), runtimes.SelectedRuntime, &clusterConfig.Cluster, &clusterConfig.ClusterCreateOpts); err != nil {
-			if err := k3dCluster.ClusterRun(cmd.Context(), runtimes.SelectedRuntime, clusterConfig); err != nil {
-				// rollback if creation failed
-				l.Log().Errorln(err)
-				if simpleCfg.Options.K3dOptions.NoRollback { // TODO: move rollback mechanics to pkg/
-					l.Log().Fatalln("Cluster creation FAILED, rollback deactivated.")
-				}
-				// rollback if creation failed
-				l.Log().Errorln("Failed to create cluster >>> Rolling Back")
-				if err := k3dCluster.ClusterDelete(cmd.Context(), runtimes.SelectedRuntime, &clusterConfig.Cluster, k3d.ClusterDeleteOpts{SkipRegistryCheck: true}); err != nil {
-					l.Log().Errorln(err)
-					l.Log().Fatalln("Cluster creation FAILED, also FAILED to rollback changes!")
-				}
-				l.Log().Fatalln("Cluster creation FAILED, all changes have been rolled back!")
+			if !clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig && clusterConfig.KubeconfigOpts.Output == "" && clusterConfig.KubeconfigOpts.SwitchCurrentContext {
+				l.Log().Infoln("--kubeconfig-update-default=false (without --kubeconfig-output) --> sets --kubeconfig-switch-context=false")
+				clusterConfig.KubeconfigOpts.SwitchCurrentContext = false
 			}
-			l.Log().Infof("Cluster '%s' created successfully!", clusterConfig.Cluster.Name)
 
-			/**************
-			 * Kubeconfig *
-			 **************/
-
-			if !clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig && clusterConfig.KubeconfigOpts.SwitchCurrentContext {
-				l.Log().Infoln("--kubeconfig-update-default=false --> sets --kubeconfig-switch-context=false")
-				clusterConfig.KubeconfigOpts.SwitchCurrentContext = false
+			// create the cluster (rolling back on failure) and write the kubeconfig
+			if err := CreateCluster(cmd.Context(), runtimes.SelectedRuntime, clusterConfig, "creation", nil); err != nil {
+				l.Log().Fatalln(err)
 			}
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Cluster '%s' created successfully!", clusterConfig.Cluster.Name)))
+			recordClusterCreate(simpleCfg, clusterConfig, !apiPortSet)
+
+			/************
+			 * Env File *
+			 ************/
 
-			if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig {
-				l.Log().Debugf("Updating default kubeconfig with a new context for cluster %s", clusterConfig.Cluster.Name)
-				if _, err := k3dCluster.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, &clusterConfig.Cluster, "", &k3dCluster.WriteKubeConfigOptions{UpdateExisting: true, OverwriteExisting: false, UpdateCurrentContext: simpleCfg.Options.KubeconfigOptions.SwitchCurrentContext}); err != nil {
+			if envFile != "" {
+				if err := writeClusterEnvFile(cmd.Context(), &clusterConfig.Cluster, clusterConfig.KubeconfigOpts, envFile); err != nil {
 					l.Log().Warningln(err)
+				} else {
+					l.Log().Infof("Wrote the cluster environment to '%s'", envFile)
+				}
+			}
+
+			/**********************
+			 * NetworkPolicy Test *
+			 **********************/
+
+			if simpleCfg.Options.K3dOptions.NetworkPolicyTest {
+				l.Log().Infof("Running the network policy test suite in cluster '%s'...", clusterConfig.Cluster.Name)
+				if !runNetpolTest(cmd.Context(), &clusterConfig.Cluster, k3d.NetpolTestOpts{}, "") {
+					l.Log().Fatalf("Network policies are not enforced as expected in cluster '%s' (re-run the checks with 'k3d cluster netpol-test %s')", clusterConfig.Cluster.Name, clusterConfig.Cluster.Name)
 				}
 			}
 
@@ -208,12 +289,24 @@ func NewCmdClusterCreate() *cobra.Command {
 			// print information on how to use the cluster with kubectl
 			l.Log().Infoln("You can now use it like this:")
 			if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig && !clusterConfig.KubeconfigOpts.SwitchCurrentContext {
-				fmt.Printf("kubectl config use-context %s\n", fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, clusterConfig.Cluster.Name))
+				fmt.Printf("kubectl config use-context %s\n", fmt.Sprintf("%s-%s", clusterConfig.Cluster.GetNamePrefix(), clusterConfig.Cluster.Name))
+			} else if !clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig && clusterConfig.KubeconfigOpts.Output != "" && clusterConfig.KubeconfigOpts.Output != "-" {
+				kubeconfigOutput := clusterConfig.KubeconfigOpts.Output
+				if abs, err := filepath.Abs(kubeconfigOutput); err == nil {
+					kubeconfigOutput = abs
+				}
+				fmt.Println(cliutil.ShellSetEnv(cliutil.DetectShell(), "KUBECONFIG", kubeconfigOutput))
+				if !clusterConfig.KubeconfigOpts.SwitchCurrentContext {
+					fmt.Printf("kubectl config use-context %s\n", fmt.Sprintf("%s-%s", clusterConfig.Cluster.GetNamePrefix(), clusterConfig.Cluster.Name))
+				}
 			} else if !clusterConfig.KubeconfigOpts.SwitchCurrentContext {
-				if runtime.GOOS == "windows" {
-					fmt.Printf("$env:KUBECONFIG=(%s kubeconfig write %s)\n", os.Args[0], clusterConfig.Cluster.Name)
-				} else {
-					fmt.Printf("export KUBECONFIG=$(%s kubeconfig write %s)\n", os.Args[0], clusterConfig.Cluster.Name)
+				shells := []string{cliutil.DetectShell()}
+				if shells[0] == cliutil.ShellPowershell {
+					// we cannot reliably tell PowerShell and cmd.exe apart, so we print both
+					shells = append(shells, cliutil.ShellCmd)
+				}
+				for _, shell := range shells {
+					fmt.Println(cliutil.ShellEval(shell, fmt.Sprintf("%s kubeconfig env %s --shell %s", os.Args[0], clusterConfig.Cluster.Name, shell)))
 				}
 			}
 			fmt.Println("kubectl cluster-info")
@@ -229,6 +322,13 @@ func NewCmdClusterCreate() *cobra.Command {
 		l.Log().Fatalln("Failed to mark flag 'config' as filename flag")
 	}
 
+	cmd.Flags().StringVar(&emitConfigFile, "emit-config", "", "Write the config resulting from config file and flags to the given path ('-' for stdout) instead of creating the cluster")
+	if err := cmd.MarkFlagFilename("emit-config", "yaml", "yml"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'emit-config' as filename flag")
+	}
+
+	cmd.Flags().BoolVar(&interactiveCreateOpts.enabled, "interactive", false, "Walk through the main choices (name, nodes, image, ports, registry) with prompts and print the equivalent command/config")
+
 	/***********************
 	 * Pre-Processed Flags *
 	 ***********************
@@ -245,9 +345,18 @@ func NewCmdClusterCreate() *cobra.Command {
 	 * Note: here we also use Slice-type flags instead of Array because of https://github.com/spf13/viper/issues/380
 	 */
 
-	cmd.Flags().String("api-port", "", "Specify the Kubernetes API server port exposed on the LoadBalancer (Format: `[HOST:]HOSTPORT`)\n - Example: `k3d cluster create --servers 3 --api-port 0.0.0.0:6550`")
+	cmd.Flags().String("api-port", "", "Specify the Kubernetes API server port exposed on the LoadBalancer (Format: `[HOST:]HOSTPORT`, use `random` or `0` as HOSTPORT to pick a free port)\n - Example: `k3d cluster create --servers 3 --api-port 0.0.0.0:6550`")
 	_ = ppViper.BindPFlag("cli.api-port", cmd.Flags().Lookup("api-port"))
 
+	cmd.Flags().String("api-host", "", "Host name or IP for the Kubernetes API in the kubeconfig and the server's TLS certificate (Format: `HOST`, default: the docker host, if it's remote, else the API port's host IP)\n - Example: `DOCKER_HOST=ssh://me@buildbox k3d cluster create --api-host buildbox.lan`")
+	_ = ppViper.BindPFlag("cli.api-host", cmd.Flags().Lookup("api-host"))
+
+	cmd.Flags().StringArray("tls-san", nil, "Add a host name or IP to the TLS certificate of the Kubernetes API on all servers, the first one is used in the kubeconfig, unless '--api-host' is set (Format: `HOST`, can be used multiple times)\n - Example: `k3d cluster create --api-port 0.0.0.0:6550 --tls-san k3d.example.com --tls-san 192.168.178.55`")
+	_ = ppViper.BindPFlag("cli.tls-sans", cmd.Flags().Lookup("tls-san"))
+
+	cmd.Flags().String("bind-address", "", "Host IP that the API port, port mappings and registries without an explicit one are bound to (Format: `IP`, default: 0.0.0.0 or $K3D_DEFAULT_BIND_ADDRESS)\n - Example: `k3d cluster create --bind-address 127.0.0.1 -p 8080:80@loadbalancer`")
+	_ = cfgViper.BindPFlag("options.k3d.defaultbindaddress", cmd.Flags().Lookup("bind-address"))
+
 	cmd.Flags().StringArrayP("env", "e", nil, "Add environment variables to nodes (Format: `KEY[=VALUE][@NODEFILTER[;NODEFILTER...]]`\n - Example: `k3d cluster create --agents 2 -e \"HTTP_PROXY=my.proxy.com@server:0\" -e \"SOME_KEY=SOME_VAL@server:0\"`")
 	_ = ppViper.BindPFlag("cli.env", cmd.Flags().Lookup("env"))
 
@@ -263,6 +372,24 @@ func NewCmdClusterCreate() *cobra.Command {
 	cmd.Flags().StringArrayP("runtime-label", "", nil, "Add label to container runtime (Format: `KEY[=VALUE][@NODEFILTER[;NODEFILTER...]]`\n - Example: `k3d cluster create --agents 2 --runtime-label \"my.label@agent:0,1\" --runtime-label \"other.label=somevalue@server:0\"`")
 	_ = ppViper.BindPFlag("cli.runtime-labels", cmd.Flags().Lookup("runtime-label"))
 
+	cmd.Flags().StringArray("label", nil, "Add label to both the container runtime and the k3s node, e.g. to select nodes in tests (Format: `KEY[=VALUE][@NODEFILTER[;NODEFILTER...]]`)\n - Same as setting the label via --runtime-label and --k3s-node-label\n - Example: `k3d cluster create --agents 2 --label \"tier=fast@agent:0\" --label \"tier=slow@agent:1\"`")
+	_ = ppViper.BindPFlag("cli.labels", cmd.Flags().Lookup("label"))
+
+	cmd.Flags().StringArrayP("runtime-opt", "", nil, "Pass a raw option on to the container runtime when creating the node containers (Format: `KEY=VALUE[@NODEFILTER[;NODEFILTER...]]`)\n - Supported keys (docker): shm-size, pids-limit, device, cap-add, cap-drop, security-opt, sysctl, ulimit, cpuset-cpus, cpuset-mems, oom-score-adj\n - Example: `k3d cluster create --agents 2 --runtime-opt \"shm-size=1g@agent:*\" --runtime-opt \"device=/dev/fuse@server:0\"`")
+	_ = ppViper.BindPFlag("cli.runtime-opts", cmd.Flags().Lookup("runtime-opt"))
+
+	cmd.Flags().StringArray("cpuset-cpus", nil, "Pin the matching nodes to these host CPUs, e.g. on shared servers (Format: `CPUSET[@NODEFILTER[;NODEFILTER...]]`) [From docker]\n - Same as setting the runtime opt 'cpuset-cpus'\n - Example: `k3d cluster create --agents 2 --cpuset-cpus \"0-3@server:0\" --cpuset-cpus \"4-7@agent:*\"`")
+	_ = ppViper.BindPFlag("cli.cpuset-cpus", cmd.Flags().Lookup("cpuset-cpus"))
+
+	cmd.Flags().StringArray("cpuset-mems", nil, "Restrict the matching nodes to these memory (NUMA) nodes of the host (Format: `MEMSET[@NODEFILTER[;NODEFILTER...]]`) [From docker]\n - Same as setting the runtime opt 'cpuset-mems'\n - Example: `k3d cluster create --agents 2 --cpuset-mems \"1@agent:*\"`")
+	_ = ppViper.BindPFlag("cli.cpuset-mems", cmd.Flags().Lookup("cpuset-mems"))
+
+	cmd.Flags().StringArray("memory", nil, "Memory limit for the matching nodes, also reported as node capacity by the kubelet (Format: `MEMORY[@NODEFILTER[;NODEFILTER...]]`) [From docker]\n - Example: `k3d cluster create --agents 2 --memory \"2g@agent:0\" --memory \"512m@agent:1\"`")
+	_ = ppViper.BindPFlag("cli.memory", cmd.Flags().Lookup("memory"))
+
+	cmd.Flags().StringArray("cpus", nil, "CPU limit for the matching nodes (Format: `CPUS[@NODEFILTER[;NODEFILTER...]]`) [From docker]\n - Example: `k3d cluster create --agents 2 --cpus \"0.5@agent:*\"`")
+	_ = ppViper.BindPFlag("cli.cpus", cmd.Flags().Lookup("cpus"))
+
 	cmd.Flags().String("registry-create", "", "Create a k3d-managed registry and connect it to the cluster (Format: `NAME[:HOST][:HOSTPORT]`\n - Example: `k3d cluster create --registry-create mycluster-registry:0.0.0.0:5432`")
 	_ = ppViper.BindPFlag("cli.registries.create", cmd.Flags().Lookup("registry-create"))
 
@@ -270,6 +397,9 @@ func NewCmdClusterCreate() *cobra.Command {
 	cmd.Flags().StringArray("k3s-arg", nil, "Additional args passed to k3s command (Format: `ARG@NODEFILTER[;@NODEFILTER]`)\n - Example: `k3d cluster create --k3s-arg \"--disable=traefik@server:0\"")
 	_ = ppViper.BindPFlag("cli.k3sargs", cmd.Flags().Lookup("k3s-arg"))
 
+	cmd.Flags().StringArray("kubelet-arg", nil, "Additional args passed to the kubelet of the matching nodes, e.g. max-pods, eviction thresholds or feature gates (Format: `ARG@NODEFILTER[;@NODEFILTER]`)\n - Example: `k3d cluster create --agents 2 --kubelet-arg \"max-pods=250@agent:*\" --kubelet-arg \"eviction-hard=memory.available<100Mi@server:*\"`")
+	_ = ppViper.BindPFlag("cli.kubeletargs", cmd.Flags().Lookup("kubelet-arg"))
+
 	/******************
 	 * "Normal" Flags *
 	 ******************
@@ -287,7 +417,7 @@ func NewCmdClusterCreate() *cobra.Command {
 	_ = cfgViper.BindPFlag("agents", cmd.Flags().Lookup("agents"))
 	cfgViper.SetDefault("agents", 0)
 
-	cmd.Flags().StringP("image", "i", "", "Specify k3s image that you want to use for the nodes")
+	cmd.Flags().StringP("image", "i", "", "Specify k3s image that you want to use for the nodes (a release channel like 'rancher/k3s:+stable', '+latest' or '+v1.21' resolves to its latest version)")
 	_ = cfgViper.BindPFlag("image", cmd.Flags().Lookup("image"))
 	cfgViper.SetDefault("image", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion))
 
@@ -297,43 +427,198 @@ func NewCmdClusterCreate() *cobra.Command {
 	cmd.Flags().String("subnet", "", "[Experimental: IPAM] Define a subnet for the newly created container network (Example: `172.28.0.0/16`)")
 	_ = cfgViper.BindPFlag("subnet", cmd.Flags().Lookup("subnet"))
 
+	cmd.Flags().String("gateway", "", "[Experimental: IPAM] Define the gateway of the newly created container network, e.g. for predictable node IPs (requires --subnet, Example: `172.28.0.254`)")
+	_ = cfgViper.BindPFlag("gateway", cmd.Flags().Lookup("gateway"))
+
+	cmd.Flags().String("ipv6", "", "[Experimental: IPAM] Enable IPv6 (dual-stack) on the newly created container network, using a random unique local subnet or the given one (Example: `--ipv6` or `--ipv6=fd00:28::/64`)")
+	cmd.Flags().Lookup("ipv6").NoOptDefVal = "auto"
+	_ = cfgViper.BindPFlag("ipv6", cmd.Flags().Lookup("ipv6"))
+
 	cmd.Flags().String("token", "", "Specify a cluster token. By default, we generate one.")
 	_ = cfgViper.BindPFlag("token", cmd.Flags().Lookup("token"))
 
 	cmd.Flags().Bool("wait", true, "Wait for the server(s) to be ready before returning. Use '--timeout DURATION' to not wait forever.")
 	_ = cfgViper.BindPFlag("options.k3d.wait", cmd.Flags().Lookup("wait"))
 
+	cmd.Flags().Bool("no-wait-agents", false, "Don't wait for the agents to register with the server(s) before returning.")
+	_ = cfgViper.BindPFlag("options.k3d.nowaitforagents", cmd.Flags().Lookup("no-wait-agents"))
+
+	cmd.Flags().BoolVar(&asyncCreate, "async", false, "Create the cluster in a background process and return immediately: check on it with 'k3d cluster status NAME'")
+
 	cmd.Flags().Duration("timeout", 0*time.Second, "Rollback changes if cluster couldn't be created in specified duration.")
 	_ = cfgViper.BindPFlag("options.k3d.timeout", cmd.Flags().Lookup("timeout"))
 
+	cmd.Flags().Duration("node-startup-timeout", 0*time.Second, "Maximum time for each node to start and get ready, independent of '--timeout' (e.g. for slow storage)")
+	_ = cfgViper.BindPFlag("options.k3d.nodeStartupTimeout", cmd.Flags().Lookup("node-startup-timeout"))
+
 	cmd.Flags().Bool("kubeconfig-update-default", true, "Directly update the default kubeconfig with the new cluster's context")
 	_ = cfgViper.BindPFlag("options.kubeconfig.updatedefaultkubeconfig", cmd.Flags().Lookup("kubeconfig-update-default"))
 
-	cmd.Flags().Bool("kubeconfig-switch-context", true, "Directly switch the default kubeconfig's current-context to the new cluster's context (requires --kubeconfig-update-default)")
+	cmd.Flags().Bool("kubeconfig-switch-context", true, "Directly switch the current-context of the written kubeconfig(s) to the new cluster's context (requires --kubeconfig-update-default or --kubeconfig-output)")
 	_ = cfgViper.BindPFlag("options.kubeconfig.switchcurrentcontext", cmd.Flags().Lookup("kubeconfig-switch-context"))
 
+	cmd.Flags().String("kubeconfig-output", "", "Additionally write/merge the new cluster's kubeconfig into this file ('-' for stdout), independent of --kubeconfig-update-default")
+	_ = cfgViper.BindPFlag("options.kubeconfig.output", cmd.Flags().Lookup("kubeconfig-output"))
+	if err := cmd.MarkFlagFilename("kubeconfig-output", "yaml", "yml"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'kubeconfig-output' as filename flag")
+	}
+
+	cmd.Flags().String("kubeconfig-role", string(k3d.KubeconfigRoleAdmin), fmt.Sprintf("Access granted by the written kubeconfig(s) (one of %v): non-admin roles use a ServiceAccount bound to the ClusterRole of the same name instead of cluster-admin credentials", k3d.KubeconfigRoles))
+	_ = cfgViper.BindPFlag("options.kubeconfig.role", cmd.Flags().Lookup("kubeconfig-role"))
+
+	cmd.Flags().String("kubeconfig-encrypt", "", fmt.Sprintf("Store the client credentials encrypted in a credential store %+v instead of in the written kubeconfig(s) (kubectl decrypts them on demand via 'k3d kubeconfig credential')", k3d.KubeconfigCredentialStores))
+	_ = cfgViper.BindPFlag("options.kubeconfig.encryption.store", cmd.Flags().Lookup("kubeconfig-encrypt"))
+
+	cmd.Flags().StringSlice("kubeconfig-encrypt-recipient", nil, "age recipient to encrypt the kubeconfig credentials for (required for --kubeconfig-encrypt=age, optional for --kubeconfig-encrypt=sops)")
+	_ = cfgViper.BindPFlag("options.kubeconfig.encryption.recipients", cmd.Flags().Lookup("kubeconfig-encrypt-recipient"))
+
+	cmd.Flags().String("kubeconfig-encrypt-identity", "", "age identity file to decrypt the kubeconfig credentials with (required for --kubeconfig-encrypt=age)")
+	_ = cfgViper.BindPFlag("options.kubeconfig.encryption.identity", cmd.Flags().Lookup("kubeconfig-encrypt-identity"))
+
+	cmd.Flags().StringVar(&envFile, "env-file", "", "Write a dotenv file with the new cluster's environment (KUBECONFIG, context, API endpoint, loadbalancer ports, registry), e.g. to include it in Makefiles or CI steps")
+	if err := cmd.MarkFlagFilename("env-file", "env"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'env-file' as filename flag")
+	}
+
 	cmd.Flags().Bool("no-lb", false, "Disable the creation of a LoadBalancer in front of the server nodes")
 	_ = cfgViper.BindPFlag("options.k3d.disableloadbalancer", cmd.Flags().Lookup("no-lb"))
 
-	cmd.Flags().Bool("no-rollback", false, "Disable the automatic rollback actions, if anything goes wrong")
+	cmd.Flags().Bool("no-rollback", false, "Disable the automatic rollback actions, if anything goes wrong (same as '--rollback=never')")
 	_ = cfgViper.BindPFlag("options.k3d.disablerollback", cmd.Flags().Lookup("no-rollback"))
 
+	cmd.Flags().String("rollback", string(k3d.RollbackPolicyAuto), fmt.Sprintf("Whether to roll back a failed creation: auto (a second interrupt aborts the rollback), never (keep everything, e.g. to debug it) or always (ignore further interrupts until the rollback is done) (one of %v)", k3d.RollbackPolicies))
+	_ = cfgViper.BindPFlag("options.k3d.rollback", cmd.Flags().Lookup("rollback"))
+	if err := cmd.RegisterFlagCompletionFunc("rollback", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		policies := []string{}
+		for _, policy := range k3d.RollbackPolicies {
+			policies = append(policies, string(policy))
+		}
+		return policies, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--rollback'", err)
+	}
+
+	cmd.Flags().String("on-node-failure", string(k3d.NodeFailurePolicyRollback), fmt.Sprintf("What to do if agents fail to be created or started: fail (and roll back) the whole cluster, continue without them (retry them later via 'k3d cluster repair') or retry them (one of %v)", k3d.NodeFailurePolicies))
+	_ = cfgViper.BindPFlag("options.k3d.onnodefailure", cmd.Flags().Lookup("on-node-failure"))
+	if err := cmd.RegisterFlagCompletionFunc("on-node-failure", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		policies := []string{}
+		for _, policy := range k3d.NodeFailurePolicies {
+			policies = append(policies, string(policy))
+		}
+		return policies, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--on-node-failure'", err)
+	}
+
+	cmd.Flags().String("hibernation-schedule", "", "Time windows during which the cluster should be running (Format: `[DAYS ]HH:MM-HH:MM[;...]`), enforced by 'k3d watch'\n - Example: `k3d cluster create --hibernation-schedule \"Mon-Fri 08:00-19:00\"`")
+	_ = cfgViper.BindPFlag("options.k3d.hibernationschedule", cmd.Flags().Lookup("hibernation-schedule"))
+
+	cmd.Flags().String("external-id", "", "ID of an external resource the cluster belongs to, e.g. the pull request or branch of a preview environment, so that 'k3d cluster prune --external-id-gone' can delete the cluster once it's gone (Format: `ID`)\n - Example: `k3d cluster create pr-42 --external-id 42`")
+	_ = cfgViper.BindPFlag("options.k3d.externalid", cmd.Flags().Lookup("external-id"))
+
+	cmd.Flags().Bool("netpol-test", false, "Validate NetworkPolicy enforcement: require the network policy controller, deploy a test suite once the cluster is up and report the results (see 'k3d cluster netpol-test')")
+	_ = cfgViper.BindPFlag("options.k3d.networkpolicytest", cmd.Flags().Lookup("netpol-test"))
+
+	cmd.Flags().StringSlice("check-profile", nil, fmt.Sprintf("Verify that the host kernel provides the modules and sysctls required by the given workload stack(s) before creating the cluster (Format: `PROFILE[,PROFILE...]`, one of: %s)\n - Example: `k3d cluster create --check-profile istio`", strings.Join(k3d.CheckProfileNames(), ", ")))
+	_ = cfgViper.BindPFlag("options.k3d.checkprofiles", cmd.Flags().Lookup("check-profile"))
+	if err := cmd.RegisterFlagCompletionFunc("check-profile", cliutil.ValidArgsCheckProfiles); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--check-profile'", err)
+	}
+
 	cmd.Flags().String("gpus", "", "GPU devices to add to the cluster node containers ('all' to pass all GPUs) [From docker]")
 	_ = cfgViper.BindPFlag("options.runtime.gpurequest", cmd.Flags().Lookup("gpus"))
 
+	cmd.Flags().Bool("http-proxy", false, "Forward HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the host into the k3s nodes, e.g. to pull images behind a corporate proxy (NO_PROXY is extended by the cluster network, the pod and service CIDRs and the node names)")
+	_ = cfgViper.BindPFlag("options.runtime.httpproxy", cmd.Flags().Lookup("http-proxy"))
+
 	cmd.Flags().String("servers-memory", "", "Memory limit imposed on the server nodes [From docker]")
 	_ = cfgViper.BindPFlag("options.runtime.serversmemory", cmd.Flags().Lookup("servers-memory"))
 
 	cmd.Flags().String("agents-memory", "", "Memory limit imposed on the agents nodes [From docker]")
 	_ = cfgViper.BindPFlag("options.runtime.agentsmemory", cmd.Flags().Lookup("agents-memory"))
 
+	cmd.Flags().String("servers-data", "", "Host directory to persist the k3s data of the server nodes in, so that the cluster state survives 'k3d cluster delete' and re-creating the cluster (one subdirectory per node) (Format: `DIR`)\n - Example: `k3d cluster create mycluster --servers-data ~/k3d-data --agents-data ~/k3d-data`")
+	_ = cfgViper.BindPFlag("options.runtime.serversdata", cmd.Flags().Lookup("servers-data"))
+
+	cmd.Flags().String("agents-data", "", "Host directory to persist the k3s data of the agent nodes in, e.g. for PV data of the local-path-provisioner (one subdirectory per node) (Format: `DIR`)")
+	_ = cfgViper.BindPFlag("options.runtime.agentsdata", cmd.Flags().Lookup("agents-data"))
+
+	cmd.Flags().String("timezone", "", "Timezone of the k3s nodes, so that CronJobs get scheduled and logs get timestamped in your wall clock time instead of UTC (Format: `TZ`, an IANA name or 'host' for the local timezone of the host)\n - Example: `k3d cluster create --timezone host`")
+	_ = cfgViper.BindPFlag("options.runtime.timezone", cmd.Flags().Lookup("timezone"))
+
+	cmd.Flags().String("cluster-cpu-limit", "", "Total number of CPUs (e.g. 1.5) that all server and agent nodes may use together - divided equally among the nodes [From docker]")
+	_ = cfgViper.BindPFlag("options.runtime.clustercpulimit", cmd.Flags().Lookup("cluster-cpu-limit"))
+
+	cmd.Flags().String("cluster-memory-limit", "", "Total memory (e.g. 4g) that all server and agent nodes may use together - divided equally among the nodes without --servers-memory/--agents-memory [From docker]")
+	_ = cfgViper.BindPFlag("options.runtime.clustermemorylimit", cmd.Flags().Lookup("cluster-memory-limit"))
+
+	cmd.Flags().String("node-tmpfs-root", "", "Back /var/lib/rancher of all server and agent nodes with a tmpfs of the given size (e.g. 2g) for fast, disposable clusters - uses host memory and loses all data when nodes are stopped [From docker]")
+	_ = cfgViper.BindPFlag("options.runtime.nodetmpfsroot", cmd.Flags().Lookup("node-tmpfs-root"))
+
 	/* Image Importing */
 	cmd.Flags().Bool("no-image-volume", false, "Disable the creation of a volume for importing images")
 	_ = cfgViper.BindPFlag("options.k3d.disableimagevolume", cmd.Flags().Lookup("no-image-volume"))
 
+	cmd.Flags().String("image-volume", "", "Name of the volume for importing images, reused if it already exists, e.g. to keep imported images across cluster recreations (default: k3d-CLUSTERNAME-images)")
+	_ = cfgViper.BindPFlag("options.k3d.imagevolume", cmd.Flags().Lookup("image-volume"))
+
+	cmd.Flags().Bool("keep-image-volume", false, "Retain the image volume (and the images imported into it) when deleting the cluster")
+	_ = cfgViper.BindPFlag("options.k3d.keepimagevolume", cmd.Flags().Lookup("keep-image-volume"))
+
+	cmd.Flags().Bool("fix-inotify", false, "Raise the inotify limits from inside the (privileged) nodes, e.g. if the host's limits are too low (auto-enabled on Docker Desktop)")
+	_ = cfgViper.BindPFlag("options.k3d.fixinotify", cmd.Flags().Lookup("fix-inotify"))
+
+	cmd.Flags().Bool("strict-preflight", false, "Fail instead of warning if a preflight check finds an issue (e.g. low inotify limits or insufficient resources)")
+	_ = cfgViper.BindPFlag("options.k3d.strictpreflight", cmd.Flags().Lookup("strict-preflight"))
+
+	/* Air-Gapped Environments */
+	cmd.Flags().Bool("no-pull", false, "Never pull images: the node, loadbalancer, tools and registry images have to exist locally (e.g. via 'docker load'), which gets verified before creating anything")
+	_ = cfgViper.BindPFlag("options.k3d.nopull", cmd.Flags().Lookup("no-pull"))
+
+	cmd.Flags().StringArray("images-archive", nil, "Pre-load an image archive into containerd of all server and agent nodes, imported by k3s before it starts anything, e.g. the k3s airgap images (Format: `FILE`, .tar or a compressed .tar.gz/.tar.zst/..., can be used multiple times)\n - Example: `k3d cluster create --no-pull --images-archive ./k3s-airgap-images-amd64.tar.zst`")
+	_ = cfgViper.BindPFlag("options.k3s.imagesarchives", cmd.Flags().Lookup("images-archive"))
+
+	/* Manifests */
+	cmd.Flags().StringArray("manifest", nil, "Deploy manifests on cluster creation by writing them into the manifests directory of the servers, from where k3s auto-applies them (Format: `PATH_OR_URL`, a file, a directory or an http(s) URL, can be used multiple times)\n - Example: `k3d cluster create --manifest ./crds/ --manifest https://example.com/ingress.yaml`")
+	_ = cfgViper.BindPFlag("options.k3s.manifests", cmd.Flags().Lookup("manifest"))
+
+	cmd.Flags().String("coredns-custom", "", "Inject a custom CoreDNS config (static hosts, stub domains, rewrites) on cluster creation, deployed as the coredns-custom ConfigMap via the manifests directory, so it survives redeploys of CoreDNS (Format: `FILE`, YAML with the keys hosts, stubDomains, rewrites, override, server)\n - Example: `k3d cluster create --coredns-custom ./coredns-custom.yaml`")
+	_ = cfgViper.BindPFlag("options.k3s.corednscustom", cmd.Flags().Lookup("coredns-custom"))
+	if err := cmd.MarkFlagFilename("coredns-custom", "yaml", "yml"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'coredns-custom' as filename flag")
+	}
+
+	/* Cluster Domain */
+	cmd.Flags().String("cluster-domain", "", "Cluster domain used for in-cluster DNS names instead of cluster.local, passed on to k3s' --cluster-domain (Format: `DOMAIN`)\n - Example: `k3d cluster create --cluster-domain my.local`")
+	_ = cfgViper.BindPFlag("options.k3s.clusterdomain", cmd.Flags().Lookup("cluster-domain"))
+
+	cmd.Flags().String("cluster-cidr", "", "Pod network CIDR, passed on to k3s' --cluster-cidr (comma-separated for dual-stack). Must not overlap the service CIDR or the cluster network subnet (Format: `CIDR[,CIDR]`)\n - Example: `k3d cluster create --cluster-cidr 10.52.0.0/16`")
+	_ = cfgViper.BindPFlag("options.k3s.clustercidr", cmd.Flags().Lookup("cluster-cidr"))
+
+	cmd.Flags().String("service-cidr", "", "Service network CIDR, passed on to k3s' --service-cidr (comma-separated for dual-stack). Must not overlap the cluster CIDR or the cluster network subnet (Format: `CIDR[,CIDR]`)\n - Example: `k3d cluster create --service-cidr 10.53.0.0/16`")
+	_ = cfgViper.BindPFlag("options.k3s.servicecidr", cmd.Flags().Lookup("service-cidr"))
+
+	cmd.Flags().String("kube-proxy-mode", "", fmt.Sprintf("How service traffic gets routed: kube-proxy with iptables (k3s default) or IPVS, or no kube-proxy at all, e.g. for Cilium's kube-proxy replacement (Format: `MODE`, one of %v). Also verifies the host kernel requirements of the mode (like --check-profile)\n - Example: `k3d cluster create --kube-proxy-mode ipvs`", k3d.KubeProxyModes))
+	_ = cfgViper.BindPFlag("options.k3s.kubeproxymode", cmd.Flags().Lookup("kube-proxy-mode"))
+	if err := cmd.RegisterFlagCompletionFunc("kube-proxy-mode", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		modes := []string{}
+		for _, mode := range k3d.KubeProxyModes {
+			modes = append(modes, string(mode))
+		}
+		return modes, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--kube-proxy-mode'", err)
+	}
+
 	/* Registry */
 	cmd.Flags().StringArray("registry-use", nil, "Connect to one or more k3d-managed registries running locally")
 	_ = cfgViper.BindPFlag("registries.use", cmd.Flags().Lookup("registry-use"))
+	if err := cmd.RegisterFlagCompletionFunc("registry-use", cliutil.ValidArgsAvailableRegistries); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--registry-use'", err)
+	}
+
+	cmd.Flags().StringArray("registry-mirror", nil, "Pull images via a registry mirror, e.g. a pull-through cache, on all nodes (Format: `[REGISTRY=]ENDPOINT[,ENDPOINT...]`, REGISTRY defaults to docker.io, can be used multiple times)\n - Example: `k3d cluster create --registry-mirror https://mirror.gcr.io --registry-mirror quay.io=http://host.k3d.internal:5001`")
+	_ = cfgViper.BindPFlag("registries.mirrors", cmd.Flags().Lookup("registry-mirror"))
 
 	cmd.Flags().String("registry-config", "", "Specify path to an extra registries.yaml file")
 	_ = cfgViper.BindPFlag("registries.config", cmd.Flags().Lookup("registry-config"))
@@ -345,12 +630,42 @@ func NewCmdClusterCreate() *cobra.Command {
 	cmd.Flags().StringSlice("lb-config-override", nil, "Use dotted YAML path syntax to override nginx loadbalancer settings")
 	_ = cfgViper.BindPFlag("options.k3d.loadbalancer.configoverrides", cmd.Flags().Lookup("lb-config-override"))
 
+	cmd.Flags().StringSlice("api-allow", nil, "Only allow these CIDRs or IPs to connect to the API port exposed via the loadbalancer (Format: `CIDR|IP[,CIDR|IP...]`, for connections from the docker host itself, allow the cluster network's gateway)\n - Example: `k3d cluster create --api-port 0.0.0.0:6550 --api-allow 192.168.1.0/24`")
+	_ = cfgViper.BindPFlag("options.k3d.loadbalancer.apiaccess.allowedsources", cmd.Flags().Lookup("api-allow"))
+
+	cmd.Flags().Bool("api-client-certs", false, "Require client certificates signed by the cluster's client CA (e.g. the one in the kubeconfig) on the API port exposed via the loadbalancer")
+	_ = cfgViper.BindPFlag("options.k3d.loadbalancer.apiaccess.clientcertificates", cmd.Flags().Lookup("api-client-certs"))
+
 	/* Subcommands */
 
 	// done
 	return cmd
 }
 
+// writeSimpleConfig writes the SimpleConfig as YAML to the given path or stdout ("-"), refusing to overwrite existing files
+func writeSimpleConfig(cfg conf.SimpleConfig, output string) error {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if output == "-" {
+		fmt.Print(string(content))
+		return nil
+	}
+
+	if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("output file '%s' exists already", output)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat output file '%s': %w", output, err)
+	}
+
+	if err := os.WriteFile(output, append([]byte("---\n"), content...), 0644); err != nil {
+		return fmt.Errorf("failed to write config to '%s': %w", output, err)
+	}
+	return nil
+}
+
 func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 
 	/****************************
@@ -384,25 +699,26 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 		if err != nil {
 			return cfg, fmt.Errorf("failed to parse API Port spec: %w", err)
 		}
+		if exposeAPI.Host == "" {
+			exposeAPI.Host = cfg.ExposeAPI.Host
+		}
 	}
 
-	// Set to random port if port is empty string
-	if len(exposeAPI.Binding.HostPort) == 0 {
-		var freePort string
-		port, err := cliutil.GetFreePort()
-		freePort = strconv.Itoa(port)
-		if err != nil || port == 0 {
-			l.Log().Warnf("Failed to get random free port: %+v", err)
-			l.Log().Warnf("Falling back to internal port %s (may be blocked though)...", k3d.DefaultAPIPort)
-			freePort = k3d.DefaultAPIPort
-		}
-		exposeAPI.Binding.HostPort = freePort
+	// Overwrite the host used to connect to the API (e.g. for remote docker daemons)
+	if ppViper.IsSet("cli.api-host") {
+		exposeAPI.Host = ppViper.GetString("cli.api-host")
+	}
+
+	// Set to random port if port is empty string (or explicitly set to random)
+	if isRandomPortSpec(exposeAPI.Binding.HostPort) {
+		exposeAPI.Binding.HostPort = getRandomAPIPort(exposeAPI.Binding.HostIP)
 	}
 
 	cfg.ExposeAPI = conf.SimpleExposureOpts{
 		Host:     exposeAPI.Host,
 		HostIP:   exposeAPI.Binding.HostIP,
 		HostPort: exposeAPI.Binding.HostPort,
+		TLSSANs:  append(cfg.ExposeAPI.TLSSANs, ppViper.GetStringSlice("cli.tls-sans")...),
 	}
 
 	// -> VOLUMES
@@ -416,7 +732,7 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 			l.Log().Fatalln(err)
 		}
 
-		if strings.Contains(volume, k3d.DefaultRegistriesFilePath) && (cfg.Registries.Create != nil || cfg.Registries.Config != "" || len(cfg.Registries.Use) != 0) {
+		if strings.Contains(volume, k3d.DefaultRegistriesFilePath) && (cfg.Registries.Create != nil || cfg.Registries.Config != "" || len(cfg.Registries.Use) != 0 || len(cfg.Registries.Mirrors) != 0 || len(cfg.Registries.Rewrites) != 0) {
 			l.Log().Warnf("Seems like you're mounting a file at '%s' while also using a referenced registries config or k3d-managed registries: Your mounted file will probably be overwritten!", k3d.DefaultRegistriesFilePath)
 		}
 
@@ -463,10 +779,10 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 
 	l.Log().Tracef("PortFilterMap: %+v", portFilterMap)
 
-	// --k3s-node-label
+	// --k3s-node-label (and --label)
 	// k3sNodeLabelFilterMap will add k3s node label to applied node filters
 	k3sNodeLabelFilterMap := make(map[string][]string, 1)
-	for _, labelFlag := range ppViper.GetStringSlice("cli.k3s-node-labels") {
+	for _, labelFlag := range append(ppViper.GetStringSlice("cli.k3s-node-labels"), ppViper.GetStringSlice("cli.labels")...) {
 
 		// split node filter from the specified label
 		label, nodeFilters, err := cliutil.SplitFiltersFromFlag(labelFlag)
@@ -491,10 +807,10 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 
 	l.Log().Tracef("K3sNodeLabelFilterMap: %+v", k3sNodeLabelFilterMap)
 
-	// --runtime-label
+	// --runtime-label (and --label)
 	// runtimeLabelFilterMap will add container runtime label to applied node filters
 	runtimeLabelFilterMap := make(map[string][]string, 1)
-	for _, labelFlag := range ppViper.GetStringSlice("cli.runtime-labels") {
+	for _, labelFlag := range append(ppViper.GetStringSlice("cli.runtime-labels"), ppViper.GetStringSlice("cli.labels")...) {
 
 		// split node filter from the specified label
 		label, nodeFilters, err := cliutil.SplitFiltersFromFlag(labelFlag)
@@ -502,7 +818,9 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 			l.Log().Fatalln(err)
 		}
 
-		cliutil.ValidateRuntimeLabelKey(strings.Split(label, "=")[0])
+		if err := util.ValidateRuntimeLabelKey(strings.Split(label, "=")[0]); err != nil {
+			l.Log().Fatalln(err)
+		}
 
 		// create new entry or append filter to existing entry
 		if _, exists := runtimeLabelFilterMap[label]; exists {
@@ -521,6 +839,96 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 
 	l.Log().Tracef("RuntimeLabelFilterMap: %+v", runtimeLabelFilterMap)
 
+	// --runtime-opt (and --cpuset-cpus, --cpuset-mems)
+	// runtimeOptFilterMap will add raw runtime options to applied node filters
+	runtimeOptFlags := ppViper.GetStringSlice("cli.runtime-opts")
+	for _, key := range []string{"cpuset-cpus", "cpuset-mems"} {
+		for _, cpusetFlag := range ppViper.GetStringSlice("cli." + key) {
+			runtimeOptFlags = append(runtimeOptFlags, fmt.Sprintf("%s=%s", key, cpusetFlag))
+		}
+	}
+	runtimeOptFilterMap := make(map[string][]string, 1)
+	for _, optFlag := range runtimeOptFlags {
+
+		// split node filter from the specified opt
+		opt, nodeFilters, err := cliutil.SplitFiltersFromFlag(optFlag)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+
+		// create new entry or append filter to existing entry
+		if _, exists := runtimeOptFilterMap[opt]; exists {
+			runtimeOptFilterMap[opt] = append(runtimeOptFilterMap[opt], nodeFilters...)
+		} else {
+			runtimeOptFilterMap[opt] = nodeFilters
+		}
+	}
+
+	for opt, nodeFilters := range runtimeOptFilterMap {
+		cfg.Options.Runtime.Opts = append(cfg.Options.Runtime.Opts, conf.OptWithNodeFilters{
+			Opt:         opt,
+			NodeFilters: nodeFilters,
+		})
+	}
+
+	l.Log().Tracef("RuntimeOptFilterMap: %+v", runtimeOptFilterMap)
+
+	// --memory
+	// memoryFilterMap will add memory limits to applied node filters
+	memoryFilterMap := make(map[string][]string, 1)
+	for _, memoryFlag := range ppViper.GetStringSlice("cli.memory") {
+
+		// split node filter from the specified memory limit
+		memory, nodeFilters, err := cliutil.SplitFiltersFromFlag(memoryFlag)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+
+		// create new entry or append filter to existing entry
+		if _, exists := memoryFilterMap[memory]; exists {
+			memoryFilterMap[memory] = append(memoryFilterMap[memory], nodeFilters...)
+		} else {
+			memoryFilterMap[memory] = nodeFilters
+		}
+	}
+
+	for memory, nodeFilters := range memoryFilterMap {
+		cfg.Options.Runtime.Memory = append(cfg.Options.Runtime.Memory, conf.MemoryWithNodeFilters{
+			Memory:      memory,
+			NodeFilters: nodeFilters,
+		})
+	}
+
+	l.Log().Tracef("MemoryFilterMap: %+v", memoryFilterMap)
+
+	// --cpus
+	// cpusFilterMap will add cpu limits to applied node filters
+	cpusFilterMap := make(map[string][]string, 1)
+	for _, cpusFlag := range ppViper.GetStringSlice("cli.cpus") {
+
+		// split node filter from the specified cpu limit
+		cpus, nodeFilters, err := cliutil.SplitFiltersFromFlag(cpusFlag)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+
+		// create new entry or append filter to existing entry
+		if _, exists := cpusFilterMap[cpus]; exists {
+			cpusFilterMap[cpus] = append(cpusFilterMap[cpus], nodeFilters...)
+		} else {
+			cpusFilterMap[cpus] = nodeFilters
+		}
+	}
+
+	for cpus, nodeFilters := range cpusFilterMap {
+		cfg.Options.Runtime.CPUs = append(cfg.Options.Runtime.CPUs, conf.CPUsWithNodeFilters{
+			CPUs:        cpus,
+			NodeFilters: nodeFilters,
+		})
+	}
+
+	l.Log().Tracef("CPUsFilterMap: %+v", cpusFilterMap)
+
 	// --env
 	// envFilterMap will add container env vars to applied node filters
 	envFilterMap := make(map[string][]string, 1)
@@ -574,6 +982,31 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 		})
 	}
 
+	// --kubelet-arg
+	kubeletArgFilterMap := make(map[string][]string, 1)
+	for _, argFlag := range ppViper.GetStringSlice("cli.kubeletargs") {
+
+		// split node filter from the specified arg
+		arg, filters, err := cliutil.SplitFiltersFromFlag(argFlag)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+
+		// create new entry or append filter to existing entry
+		if _, exists := kubeletArgFilterMap[arg]; exists {
+			kubeletArgFilterMap[arg] = append(kubeletArgFilterMap[arg], filters...)
+		} else {
+			kubeletArgFilterMap[arg] = filters
+		}
+	}
+
+	for arg, nodeFilters := range kubeletArgFilterMap {
+		cfg.Options.K3sOptions.Kubelet = append(cfg.Options.K3sOptions.Kubelet, conf.KubeletConfigWithNodeFilters{
+			KubeletConfig: k3d.KubeletConfig{Args: []string{arg}},
+			NodeFilters:   nodeFilters,
+		})
+	}
+
 	// --registry-create
 	if ppViper.IsSet("cli.registries.create") {
 		flagvalue := ppViper.GetString("cli.registries.create")
@@ -595,3 +1028,80 @@ func applyCLIOverrides(cfg conf.SimpleConfig) (conf.SimpleConfig, error) {
 
 	return cfg, nil
 }
+
+// isRandomPortSpec checks if a [HOST:]PORT spec asks for a random port (no port, "random" or 0)
+func isRandomPortSpec(spec string) bool {
+	port := spec[strings.LastIndex(spec, ":")+1:]
+	return port == "" || port == "random" || port == "0"
+}
+
+// getRandomAPIPort returns a free port on the host for the Kubernetes API, falling back to the default API port
+func getRandomAPIPort(hostIP string) string {
+	port, err := util.GetFreePortOnHost(hostIP)
+	if err != nil || port == 0 {
+		l.Log().Warnf("Failed to get random free port: %+v", err)
+		l.Log().Warnf("Falling back to internal port %s (may be blocked though)...", k3d.DefaultAPIPort)
+		return k3d.DefaultAPIPort
+	}
+	freePort := strconv.Itoa(port)
+	l.Log().Infof("Using random free port %s for the Kubernetes API", freePort)
+	return freePort
+}
+
+// writeClusterEnvFile writes the environment of the new cluster (see `k3d env`) to a dotenv file.
+// KUBECONFIG points to the file written via --kubeconfig-output or otherwise to the cluster-specific kubeconfig file, which is written here.
+func writeClusterEnvFile(ctx context.Context, cluster *k3d.Cluster, kubeconfigOpts conf.SimpleConfigOptionsKubeconfig, path string) error {
+	kubeconfigPath := kubeconfigOpts.Output
+	if kubeconfigPath == "" || kubeconfigPath == "-" {
+		clusterFilePath, err := k3dCluster.KubeconfigGetClusterFilePath(cluster)
+		if err != nil {
+			return fmt.Errorf("failed to get kubeconfig path for the env file: %w", err)
+		}
+		kubeconfigPath, err = k3dCluster.KubeconfigGetWrite(ctx, runtimes.SelectedRuntime, cluster, clusterFilePath, &k3dCluster.WriteKubeConfigOptions{UpdateExisting: true, UpdateCurrentContext: true, Encryption: &kubeconfigOpts.Encryption, Role: kubeconfigOpts.Role})
+		if err != nil {
+			return fmt.Errorf("failed to write kubeconfig for the env file: %w", err)
+		}
+	}
+	if abs, err := filepath.Abs(kubeconfigPath); err == nil {
+		kubeconfigPath = abs
+	}
+
+	// get the cluster as it's running now, e.g. with its loadbalancer ports
+	runningCluster, err := k3dCluster.ClusterGet(ctx, runtimes.SelectedRuntime, &k3d.Cluster{Name: cluster.Name})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster '%s' for the env file: %w", cluster.Name, err)
+	}
+	env, err := k3dCluster.ClusterGetEnv(ctx, runtimes.SelectedRuntime, runningCluster, kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to gather environment of cluster '%s': %w", cluster.Name, err)
+	}
+	return k3dCluster.EnvFileWrite(path, fmt.Sprintf("Environment of k3d cluster '%s'", cluster.Name), env)
+}
+
+// CreateCluster creates the cluster via the client SDK, rolling it back on failure as configured by its rollback policy (see cliutil.ProtectRollback).
+// operation names what failed in the returned error (e.g. "creation" or "restore"), verify is passed on as CreateClusterOpts.Verify.
+func CreateCluster(ctx context.Context, runtime runtimes.Runtime, clusterConfig *conf.ClusterConfig, operation string, verify func() error) error {
+	policy := clusterConfig.ClusterCreateOpts.Rollback
+	done := cliutil.ProtectRollback(policy)
+	_, err := k3dCluster.New(runtime).CreateCluster(ctx, clusterConfig, k3dCluster.CreateClusterOpts{
+		NoRollback: policy == k3d.RollbackPolicyNever,
+		Verify:     verify,
+	})
+	done()
+	if err == nil {
+		return nil
+	}
+
+	var createErr *k3dCluster.ClusterCreateError
+	if !errors.As(err, &createErr) {
+		return err
+	}
+	switch {
+	case createErr.RollbackErr != nil:
+		return fmt.Errorf("cluster %s FAILED (%v), also FAILED to rollback changes: %w", operation, createErr.Err, createErr.RollbackErr)
+	case createErr.RolledBack:
+		return fmt.Errorf("cluster %s FAILED, all changes have been rolled back: %w", operation, createErr.Err)
+	default:
+		return fmt.Errorf("cluster %s FAILED, rollback deactivated: %w", operation, createErr.Err)
+	}
+}
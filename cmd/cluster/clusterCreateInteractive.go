@@ -0,0 +1,176 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	k3dCluster "github.com/rancher/k3d/v5/pkg/client"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// interactiveCreateOpts holds the choices made in interactive mode (--interactive) that are not represented by other flags
+var interactiveCreateOpts struct {
+	enabled    bool
+	name       string
+	configFile string
+	create     bool
+}
+
+// runInteractiveCreate walks the user through the main choices of cluster creation and sets the corresponding flags.
+// Defaults are taken from the config file and flags, if provided.
+func runInteractiveCreate(cmd *cobra.Command, args []string) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("--interactive requires an interactive terminal")
+	}
+
+	p := cliutil.NewPrompter(os.Stdin, os.Stdout)
+	fmt.Println("Creating a new k3d cluster - press Enter to accept the [default]")
+
+	// name
+	name := k3d.DefaultClusterName
+	if len(args) != 0 {
+		name = args[0]
+	} else if cfgName := cfgViper.GetString("name"); cfgName != "" {
+		name = cfgName
+	}
+	name, err := p.String("Cluster name", name, k3dCluster.CheckName)
+	if err != nil {
+		return err
+	}
+	interactiveCreateOpts.name = name
+
+	// nodes
+	servers, err := p.Int("Number of server nodes", cfgViper.GetInt("servers"))
+	if err != nil {
+		return err
+	}
+	if err := cmd.Flags().Set("servers", fmt.Sprint(servers)); err != nil {
+		return err
+	}
+	agents, err := p.Int("Number of agent nodes", cfgViper.GetInt("agents"))
+	if err != nil {
+		return err
+	}
+	if err := cmd.Flags().Set("agents", fmt.Sprint(agents)); err != nil {
+		return err
+	}
+
+	// image
+	image, err := p.String("K3s image", cfgViper.GetString("image"), nil)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Flags().Set("image", image); err != nil {
+		return err
+	}
+
+	// ports
+	ports, err := p.String("Ports to expose via the loadbalancer, comma-separated (e.g. 8080:80@loadbalancer)", "", nil)
+	if err != nil {
+		return err
+	}
+	for _, port := range strings.Split(ports, ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			if err := cmd.Flags().Set("port", port); err != nil {
+				return err
+			}
+		}
+	}
+
+	// registry
+	createRegistry, err := p.Bool("Create a local registry for the cluster?", false)
+	if err != nil {
+		return err
+	}
+	if createRegistry {
+		registry, err := p.String("Registry name", fmt.Sprintf("%s-registry", name), k3dCluster.CheckName)
+		if err != nil {
+			return err
+		}
+		if err := cmd.Flags().Set("registry-create", registry); err != nil {
+			return err
+		}
+	}
+
+	// summary
+	fmt.Printf("\nThe equivalent command is:\n\n    %s\n\n", equivalentCreateCommand(cmd, name))
+
+	interactiveCreateOpts.configFile, err = p.String("Save the equivalent config file to (leave empty to skip)", "", func(path string) error {
+		if path == "" {
+			return nil
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("file '%s' exists already", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	interactiveCreateOpts.create, err = p.Bool("Create the cluster now?", true)
+	return err
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[a-zA-Z0-9._:/@=,+-]+$`)
+
+// equivalentCreateCommand returns the `k3d cluster create` command line that reproduces the flags set on the command
+func equivalentCreateCommand(cmd *cobra.Command, name string) string {
+	quote := func(s string) string {
+		if shellSafeRegexp.MatchString(s) {
+			return s
+		}
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+	}
+
+	command := []string{cmd.CommandPath(), quote(name)}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Name == "interactive" {
+			return
+		}
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range sliceValue.GetSlice() {
+				command = append(command, fmt.Sprintf("--%s", flag.Name), quote(value))
+			}
+			return
+		}
+		if flag.Value.Type() == "bool" {
+			if flag.Value.String() == "true" {
+				command = append(command, fmt.Sprintf("--%s", flag.Name))
+			} else {
+				command = append(command, fmt.Sprintf("--%s=false", flag.Name))
+			}
+			return
+		}
+		command = append(command, fmt.Sprintf("--%s", flag.Name), quote(flag.Value.String()))
+	})
+	return strings.Join(command, " ")
+}
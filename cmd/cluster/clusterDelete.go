@@ -22,18 +22,17 @@ THE SOFTWARE.
 package cluster
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"path"
 
 	"github.com/rancher/k3d/v5/cmd/util"
 	cliconfig "github.com/rancher/k3d/v5/cmd/util/config"
 	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
-	k3dutil "github.com/rancher/k3d/v5/pkg/util"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -47,44 +46,29 @@ func NewCmdClusterDelete() *cobra.Command {
 
 	// create new cobra command
 	cmd := &cobra.Command{
-		Use:               "delete [NAME [NAME ...] | --all]",
+		Use:               "delete [NAME [NAME ...] | --all | --selector KEY=VALUE]",
 		Aliases:           []string{"del", "rm"},
-		Short:             "Delete cluster(s).",
-		Long:              `Delete cluster(s).`,
+		Short:             i18n.T("cmd.cluster.delete.short"),
+		Long:              i18n.T("cmd.cluster.delete.short"),
 		Args:              cobra.MinimumNArgs(0), // 0 or n arguments; 0 = default cluster name
 		ValidArgsFunction: util.ValidArgsAvailableClusters,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return cliconfig.InitViperWithConfigFile(clusterDeleteCfgViper, clusterDeleteConfigFile)
+			if err := cliconfig.InitViperWithConfigFile(clusterDeleteCfgViper, clusterDeleteConfigFile); err != nil {
+				l.Log().Fatalln(err)
+			}
+			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			clusters := parseDeleteClusterCmd(cmd, args)
 
 			if len(clusters) == 0 {
-				l.Log().Infoln("No clusters found")
+				l.Log().Infoln(i18n.T("err.noClustersFound"))
 			} else {
 				for _, c := range clusters {
-					if err := client.ClusterDelete(cmd.Context(), runtimes.SelectedRuntime, c, k3d.ClusterDeleteOpts{SkipRegistryCheck: false}); err != nil {
+					if err := deleteCluster(cmd.Context(), c); err != nil {
 						l.Log().Fatalln(err)
 					}
-					l.Log().Infoln("Removing cluster details from default kubeconfig...")
-					if err := client.KubeconfigRemoveClusterFromDefaultConfig(cmd.Context(), c); err != nil {
-						l.Log().Warnln("Failed to remove cluster details from default kubeconfig")
-						l.Log().Warnln(err)
-					}
-					l.Log().Infoln("Removing standalone kubeconfig file (if there is one)...")
-					configDir, err := k3dutil.GetConfigDirOrCreate()
-					if err != nil {
-						l.Log().Warnf("Failed to delete kubeconfig file: %+v", err)
-					} else {
-						kubeconfigfile := path.Join(configDir, fmt.Sprintf("kubeconfig-%s.yaml", c.Name))
-						if err := os.Remove(kubeconfigfile); err != nil {
-							if !os.IsNotExist(err) {
-								l.Log().Warnf("Failed to delete kubeconfig file '%s'", kubeconfigfile)
-							}
-						}
-					}
-
-					l.Log().Infof("Successfully deleted cluster %s!", c.Name)
+					l.Log().Infoln(util.Success(fmt.Sprintf("Successfully deleted cluster %s!", c.Name)))
 				}
 			}
 
@@ -95,6 +79,7 @@ func NewCmdClusterDelete() *cobra.Command {
 
 	// add flags
 	cmd.Flags().BoolP("all", "a", false, "Delete all existing clusters")
+	addClusterSelectorFlag(cmd, "Delete")
 
 	/***************
 	 * Config File *
@@ -109,6 +94,18 @@ func NewCmdClusterDelete() *cobra.Command {
 	return cmd
 }
 
+// deleteCluster deletes a cluster and removes its details from the default and standalone kubeconfig
+func deleteCluster(ctx context.Context, c *k3d.Cluster) error {
+	if err := client.ClusterDelete(ctx, runtimes.SelectedRuntime, c, k3d.ClusterDeleteOpts{SkipRegistryCheck: false}); err != nil {
+		return err
+	}
+	client.KubeconfigRemoveClusterFromAll(ctx, c)
+	if err := client.ClusterJobDelete(c.Name); err != nil {
+		l.Log().Warnf("Failed to delete background creation state of cluster '%s': %v", c.Name, err)
+	}
+	return nil
+}
+
 // parseDeleteClusterCmd parses the command input into variables required to delete clusters
 func parseDeleteClusterCmd(cmd *cobra.Command, args []string) []*k3d.Cluster {
 
@@ -122,9 +119,9 @@ func parseDeleteClusterCmd(cmd *cobra.Command, args []string) []*k3d.Cluster {
 
 	// --config
 	if clusterDeleteConfigFile != "" {
-		// not allowed with --all or more args
-		if len(args) > 0 || all {
-			l.Log().Fatalln("failed to delete cluster: cannot use `--config` flag with additional arguments or `--all`")
+		// not allowed with --all, --selector or more args
+		if selectors, _ := cmd.Flags().GetStringArray("selector"); len(args) > 0 || all || len(selectors) > 0 {
+			l.Log().Fatalln("failed to delete cluster: cannot use `--config` flag with additional arguments, `--all` or `--selector`")
 		}
 
 		if clusterDeleteCfgViper.GetString("name") == "" {
@@ -141,6 +138,12 @@ func parseDeleteClusterCmd(cmd *cobra.Command, args []string) []*k3d.Cluster {
 		return clusters
 	}
 
+	// --selector was set
+	if selected, ok := parseClusterSelectorFlag(cmd, args); ok {
+		l.Log().Infof("Deleting %d clusters matching the selector...", len(selected))
+		return selected
+	}
+
 	// --all was set
 	if all {
 		l.Log().Infoln("Deleting all clusters...")
@@ -0,0 +1,219 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	cliconfig "github.com/rancher/k3d/v5/cmd/util/config"
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/config"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/version"
+)
+
+type clusterDiffFlags struct {
+	configFile string
+	output     string
+}
+
+// NewCmdClusterDiff returns a new cobra command
+func NewCmdClusterDiff() *cobra.Command {
+
+	flags := clusterDiffFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "diff [NAME] --config FILE",
+		Short: "Compare a running cluster with a config file",
+		Long: `Compare a running cluster with a config file and print the differences, i.e. the nodes that would have to be added or removed
+and the changed node fields (e.g. ports, args or image).
+The cluster name is taken from the config file, unless NAME is given.
+Note: for args, environment variables and labels, only values from the config file that are missing on the running nodes are reported,
+since k3d and the node image add their own values at creation time.`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			output := strings.ToLower(flags.output)
+			if output != "" && output != "json" && output != "yaml" {
+				l.Log().Fatalf("Unsupported output format '%s': must be one of json|yaml", flags.output)
+			}
+
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			simpleCfg, err := simpleConfigFromFile(flags.configFile, name)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			// a random API port matches any port of the running cluster
+			if isRandomPortSpec(simpleCfg.ExposeAPI.HostPort) {
+				simpleCfg.ExposeAPI.HostPort = ""
+			}
+
+			clusterConfig, err := clusterConfigFromSimpleConfig(cmd.Context(), simpleCfg)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			existingCluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterConfig.Cluster.Name})
+			if err != nil {
+				l.Log().Fatalf("Failed to get cluster '%s': %v", clusterConfig.Cluster.Name, err)
+			}
+
+			diff := client.ClusterGetDiff(existingCluster, &clusterConfig.Cluster)
+
+			if err := printClusterDiff(diff, output); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.configFile, "config", "c", "", "Path of the config file to compare the cluster with")
+	if err := cmd.MarkFlagRequired("config"); err != nil {
+		l.Log().Fatalln("Failed to mark required flag 'config'")
+	}
+	if err := cmd.MarkFlagFilename("config", "yaml", "yml"); err != nil {
+		l.Log().Fatalln("Failed to mark flag 'config' as filename flag")
+	}
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml (default: human readable)")
+
+	// done
+	return cmd
+}
+
+// simpleConfigFromFile reads a simple config file, just like `cluster create` does.
+// If name is not empty, it overrides the cluster name from the config file.
+func simpleConfigFromFile(configFile string, name string) (conf.SimpleConfig, error) {
+	v := viper.New()
+	if err := cliconfig.InitViperWithConfigFile(v, configFile); err != nil {
+		return conf.SimpleConfig{}, err
+	}
+	return simpleConfigFromViper(v, name)
+}
+
+// simpleConfigFromViper reads a simple config (migrating it if necessary) from a viper instance that has a config loaded
+func simpleConfigFromViper(v *viper.Viper, name string) (conf.SimpleConfig, error) {
+	// same defaults as for `cluster create`
+	v.SetDefault("servers", 1)
+	v.SetDefault("image", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion))
+
+	cfg, err := config.FromViper(v)
+	if err != nil {
+		return conf.SimpleConfig{}, err
+	}
+	if cfg.GetAPIVersion() != config.DefaultConfigApiVersion {
+		cfg, err = config.Migrate(cfg, config.DefaultConfigApiVersion)
+		if err != nil {
+			return conf.SimpleConfig{}, err
+		}
+	}
+	simpleCfg, ok := cfg.(conf.SimpleConfig)
+	if !ok {
+		return conf.SimpleConfig{}, fmt.Errorf("unsupported config kind '%s': only 'Simple' is supported", cfg.GetKind())
+	}
+
+	if name != "" {
+		simpleCfg.Name = name
+	}
+	if simpleCfg.Name == "" {
+		simpleCfg.Name = k3d.DefaultClusterName
+	}
+
+	return simpleCfg, nil
+}
+
+// clusterConfigFromSimpleConfig processes and transforms a simple config into a cluster config
+func clusterConfigFromSimpleConfig(ctx context.Context, simpleCfg conf.SimpleConfig) (*conf.ClusterConfig, error) {
+	if err := config.ProcessSimpleConfig(&simpleCfg); err != nil {
+		return nil, fmt.Errorf("error processing/sanitizing simple config: %w", err)
+	}
+
+	clusterConfig, err := config.TransformSimpleToClusterConfig(ctx, runtimes.SelectedRuntime, simpleCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.ProcessClusterConfig(*clusterConfig)
+}
+
+// printClusterDiff prints a cluster diff either human readable or as JSON/YAML
+func printClusterDiff(diff *client.ClusterDiff, output string) error {
+	switch output {
+	case "json":
+		b, err := json.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("failed to marshal diff: %w", err)
+		}
+		fmt.Println(string(b))
+		return nil
+	case "yaml":
+		b, err := yaml.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("failed to marshal diff: %w", err)
+		}
+		fmt.Print(string(b))
+		return nil
+	}
+
+	if diff.IsEmpty() {
+		fmt.Printf("Cluster '%s' matches the config\n", diff.Cluster)
+		return nil
+	}
+
+	fmt.Printf("Cluster '%s':\n", diff.Cluster)
+	for _, node := range diff.NodesToAdd {
+		fmt.Printf("+ %s (%s)\n", node.Name, node.Role)
+	}
+	for _, node := range diff.NodesToRemove {
+		fmt.Printf("- %s (%s)\n", node.Name, node.Role)
+	}
+	for _, node := range diff.NodeChanges {
+		fmt.Printf("~ %s (%s)\n", node.Name, node.Role)
+		for _, field := range node.Fields {
+			if field.Current != "" || field.Desired != "" {
+				fmt.Printf("    %s: %s -> %s\n", field.Field, field.Current, field.Desired)
+			}
+			for _, added := range field.Added {
+				fmt.Printf("    %s: + %s\n", field.Field, added)
+			}
+			for _, removed := range field.Removed {
+				fmt.Printf("    %s: - %s\n", field.Field, removed)
+			}
+		}
+	}
+	return nil
+}
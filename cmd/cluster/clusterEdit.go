@@ -37,9 +37,13 @@ func NewCmdClusterEdit() *cobra.Command {
 
 	// create new cobra command
 	cmd := &cobra.Command{
-		Use:               "edit CLUSTER",
-		Short:             "[EXPERIMENTAL] Edit cluster(s).",
-		Long:              `[EXPERIMENTAL] Edit cluster(s).`,
+		Use:   "edit CLUSTER",
+		Short: "[EXPERIMENTAL] Edit cluster(s).",
+		Long: `[EXPERIMENTAL] Edit cluster(s).
+
+Adding ports regenerates the loadbalancer configuration and replaces the loadbalancer container with one that has the new port mappings,
+so the cluster doesn't have to be recreated to expose another service. The k3s nodes are not touched.`,
+		Example:           `  k3d cluster edit mycluster --port-add 8080:80@loadbalancer`,
 		Args:              cobra.ExactArgs(1),
 		Aliases:           []string{"update"},
 		ValidArgsFunction: util.ValidArgsAvailableClusters,
@@ -61,7 +65,7 @@ func NewCmdClusterEdit() *cobra.Command {
 	// add subcommands
 
 	// add flags
-	cmd.Flags().StringArray("port-add", nil, "[EXPERIMENTAL] Map ports from the node containers (via the serverlb) to the host (Format: `[HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]`)\n - Example: `k3d node edit k3d-mycluster-serverlb --port-add 8080:80`")
+	cmd.Flags().StringArray("port-add", nil, "[EXPERIMENTAL] Map ports from the node containers (via the serverlb) to the host (Format: `[HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]`)\n - Example: `k3d cluster edit mycluster --port-add 8080:80@loadbalancer`")
 
 	// done
 	return cmd
@@ -76,8 +80,7 @@ func parseEditClusterCmd(cmd *cobra.Command, args []string) (*k3d.Cluster, *conf
 	}
 
 	if existingCluster == nil {
-		l.Log().Infof("Cluster %s not found", args[0])
-		return nil, nil
+		l.Log().Fatalf("Cluster %s not found", args[0])
 	}
 
 	changeset := conf.SimpleConfig{}
@@ -87,8 +90,10 @@ func parseEditClusterCmd(cmd *cobra.Command, args []string) (*k3d.Cluster, *conf
 	 */
 	portFlags, err := cmd.Flags().GetStringArray("port-add")
 	if err != nil {
-		l.Log().Errorln(err)
-		return nil, nil
+		l.Log().Fatalln(err)
+	}
+	if len(portFlags) == 0 {
+		l.Log().Fatalln("Nothing to change: specify at least one '--port-add'")
 	}
 
 	// init portmap
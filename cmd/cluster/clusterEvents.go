@@ -0,0 +1,119 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/liggitt/tabwriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterEventsFlags struct {
+	output   string
+	noHeader bool
+	since    time.Duration
+}
+
+// NewCmdClusterEvents returns a new cobra command
+func NewCmdClusterEvents() *cobra.Command {
+
+	flags := clusterEventsFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "events NAME",
+		Short: "Show the recorded events of a cluster",
+		Long: `Show the recorded events of a cluster.
+
+k3d records significant events (e.g. cluster created/started/stopped, nodes added/deleted/replaced,
+loadbalancer regenerated and all actions taken by 'k3d watch') in an event log per cluster in $HOME/.k3d/events/.
+The event log is deleted together with the cluster.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			events, err := client.ClusterEventList(args[0])
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if flags.since > 0 {
+				cutoff := time.Now().Add(-flags.since)
+				filtered := []k3d.ClusterEvent{}
+				for _, event := range events {
+					if event.Time.After(cutoff) {
+						filtered = append(filtered, event)
+					}
+				}
+				events = filtered
+			}
+
+			switch strings.ToLower(flags.output) {
+			case "json":
+				b, err := json.Marshal(events)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "yaml":
+				b, err := yaml.Marshal(events)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "":
+				tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
+				defer tabwriter.Flush()
+				if !flags.noHeader {
+					fmt.Fprintf(tabwriter, "%s\n", strings.Join([]string{"TIME", "TYPE", "NODE", "MESSAGE"}, "\t"))
+				}
+				for _, event := range events {
+					node := event.Node
+					if node == "" {
+						node = "-"
+					}
+					fmt.Fprintf(tabwriter, "%s\t%s\t%s\t%s\n", event.Time.Local().Format(time.RFC3339), event.Type, node, event.Message)
+				}
+			default:
+				l.Log().Fatalf("Unknown output format '%s': must be one of json|yaml", flags.output)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&flags.noHeader, "no-headers", false, "Disable headers")
+	cmd.Flags().DurationVar(&flags.since, "since", 0, "Only show events newer than this duration (e.g. 12h)")
+
+	// done
+	return cmd
+}
@@ -0,0 +1,232 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liggitt/tabwriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type historyFlags struct {
+	output   string
+	noHeader bool
+	cluster  string
+	clear    bool
+}
+
+// NewCmdHistory returns a new cobra command
+func NewCmdHistory() *cobra.Command {
+
+	flags := historyFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show previously created clusters, to re-create them with 'k3d redo'",
+		Long: `Show previously created clusters, to re-create them with 'k3d redo'.
+
+Every successful cluster creation is recorded in $HOME/.k3d/history.jsonl, together with the
+effective config (config file and flags applied, image channels like 'stable' resolved to the actual image).
+Use 'k3d redo ID' to create a cluster exactly like the one of the given history entry.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if flags.clear {
+				if err := client.HistoryClear(); err != nil {
+					l.Log().Fatalln(err)
+				}
+				l.Log().Infoln("Cleared history")
+				return
+			}
+
+			entries, err := client.HistoryList()
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if flags.cluster != "" {
+				filtered := []k3d.HistoryEntry{}
+				for _, entry := range entries {
+					if entry.Cluster == flags.cluster {
+						filtered = append(filtered, entry)
+					}
+				}
+				entries = filtered
+			}
+
+			switch strings.ToLower(flags.output) {
+			case "json":
+				b, err := json.Marshal(entries)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "yaml":
+				b, err := yaml.Marshal(entries)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "":
+				tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
+				defer tabwriter.Flush()
+				if !flags.noHeader {
+					fmt.Fprintf(tabwriter, "%s\n", strings.Join([]string{"ID", "TIME", "CLUSTER", "COMMAND"}, "\t"))
+				}
+				for _, entry := range entries {
+					fmt.Fprintf(tabwriter, "%d\t%s\t%s\t%s\n", entry.ID, entry.Time.Local().Format(time.RFC3339), entry.Cluster, entry.Command)
+				}
+			default:
+				l.Log().Fatalf("Unknown output format '%s': must be one of json|yaml", flags.output)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&flags.noHeader, "no-headers", false, "Disable headers")
+	cmd.Flags().StringVar(&flags.cluster, "cluster", "", "Only show entries of the cluster with this name")
+	if err := cmd.RegisterFlagCompletionFunc("cluster", util.ValidArgsAvailableClusters); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--cluster'", err)
+	}
+	cmd.Flags().BoolVar(&flags.clear, "clear", false, "Delete all history entries")
+
+	// done
+	return cmd
+}
+
+// NewCmdRedo returns a new cobra command
+func NewCmdRedo() *cobra.Command {
+
+	var printConfig bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "redo ID [NAME]",
+		Short: "Create a cluster exactly like the one of the given 'k3d history' entry",
+		Long: `Create a cluster exactly like the one of the given 'k3d history' entry.
+
+The cluster is created from the effective config recorded in the history entry, so flags and
+config files of the original command are not read again. Pass NAME to create the cluster under a different name.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				l.Log().Fatalf("Invalid history ID '%s': must be a number (see 'k3d history')", args[0])
+			}
+			entry, err := client.HistoryGet(id)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			name := ""
+			if len(args) > 1 {
+				name = args[1]
+			}
+			simpleCfg, err := simpleConfigFromHistoryEntry(entry, name)
+			if err != nil {
+				l.Log().Fatalf("Failed to read config of history entry %d: %v", id, err)
+			}
+
+			if printConfig {
+				if err := writeSimpleConfig(simpleCfg, "-"); err != nil {
+					l.Log().Fatalln(err)
+				}
+				return
+			}
+
+			if _, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: simpleCfg.Name}); err == nil {
+				l.Log().Fatalf("Failed to create cluster '%s' because a cluster with that name already exists (pass a different NAME or delete it first)", simpleCfg.Name)
+			}
+
+			l.Log().Infof("Re-creating cluster '%s' from history entry %d (%s)", simpleCfg.Name, id, entry.Command)
+			if err := applyCreateCluster(cmd.Context(), simpleCfg); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infof("Cluster '%s' created successfully!", simpleCfg.Name)
+		},
+	}
+
+	// add flags
+	cmd.Flags().BoolVar(&printConfig, "print-config", false, "Only print the recorded config instead of creating the cluster")
+
+	// done
+	return cmd
+}
+
+// simpleConfigFromHistoryEntry reads the config recorded in a history entry.
+// If name is not empty, it overrides the recorded cluster name.
+func simpleConfigFromHistoryEntry(entry *k3d.HistoryEntry, name string) (conf.SimpleConfig, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(entry.Spec)); err != nil {
+		return conf.SimpleConfig{}, fmt.Errorf("failed to parse recorded config: %w", err)
+	}
+	return simpleConfigFromViper(v, name)
+}
+
+// recordClusterCreate adds the creation of a cluster to the history.
+// The recorded config has the image resolved to the one actually used, while a random API port stays random.
+func recordClusterCreate(simpleCfg conf.SimpleConfig, clusterConfig *conf.ClusterConfig, randomAPIPort bool) {
+	simpleCfg.Name = clusterConfig.Cluster.Name
+	for _, node := range clusterConfig.Cluster.Nodes {
+		if node.Role == k3d.ServerRole && node.Image != "" {
+			simpleCfg.Image = node.Image
+			break
+		}
+	}
+	if randomAPIPort {
+		simpleCfg.ExposeAPI.HostPort = ""
+	}
+
+	spec, err := yaml.Marshal(simpleCfg)
+	if err != nil {
+		l.Log().Debugf("Failed to marshal config for history: %v", err)
+		return
+	}
+
+	command := []string{filepath.Base(os.Args[0])}
+	for _, arg := range os.Args[1:] {
+		if strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		command = append(command, arg)
+	}
+
+	client.HistoryRecord(clusterConfig.Cluster.Name, strings.Join(command, " "), string(spec))
+}
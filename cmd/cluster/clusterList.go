@@ -27,9 +27,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/rancher/k3d/v5/cmd/util"
 	k3cluster "github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
@@ -41,9 +43,11 @@ import (
 
 // TODO : deal with --all flag to manage differentiate started cluster and stopped cluster like `docker ps` and `docker ps -a`
 type clusterFlags struct {
-	noHeader bool
-	token    bool
-	output   string
+	noHeader     bool
+	token        bool
+	output       string
+	probe        bool
+	probeTimeout time.Duration
 }
 
 // NewCmdClusterList returns a new cobra command
@@ -54,11 +58,14 @@ func NewCmdClusterList() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "list [NAME [NAME...]]",
 		Aliases: []string{"ls", "get"},
-		Short:   "List cluster(s)",
-		Long:    `List cluster(s).`,
+		Short:   i18n.T("cmd.cluster.list.short"),
+		Long:    i18n.T("cmd.cluster.list.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			clusters := buildClusterList(cmd.Context(), args)
-			PrintClusters(clusters, clusterFlags)
+			if clusterFlags.probe {
+				k3cluster.ClusterProbeHealth(cmd.Context(), clusters, clusterFlags.probeTimeout)
+			}
+			PrintClusters(cmd.Context(), clusters, clusterFlags)
 		},
 		ValidArgsFunction: util.ValidArgsAvailableClusters,
 	}
@@ -67,6 +74,8 @@ func NewCmdClusterList() *cobra.Command {
 	cmd.Flags().BoolVar(&clusterFlags.noHeader, "no-headers", false, "Disable headers")
 	cmd.Flags().BoolVar(&clusterFlags.token, "token", false, "Print k3s cluster token")
 	cmd.Flags().StringVarP(&clusterFlags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&clusterFlags.probe, "probe", true, "Probe the health of the clusters (running nodes, Kubernetes API reachability, k3s version); use '--probe=false' to skip")
+	cmd.Flags().DurationVar(&clusterFlags.probeTimeout, "probe-timeout", k3d.DefaultClusterHealthProbeTimeout, "Maximum time to wait for the Kubernetes API of a cluster to answer the probe")
 
 	// add subcommands
 
@@ -99,15 +108,20 @@ func buildClusterList(ctx context.Context, args []string) []*k3d.Cluster {
 }
 
 // PrintPrintClusters : display list of cluster
-func PrintClusters(clusters []*k3d.Cluster, flags clusterFlags) {
+func PrintClusters(ctx context.Context, clusters []*k3d.Cluster, flags clusterFlags) {
 	// the output details printed when we dump JSON/YAML
+	// Note: this is consumed by external tools (e.g. Tilt, Skaffold) for local cluster detection, so only add fields here
 	type jsonOutput struct {
 		k3d.Cluster
-		ServersRunning int  `yaml:"servers_running" json:"serversRunning"`
-		ServersCount   int  `yaml:"servers_count" json:"serversCount"`
-		AgentsRunning  int  `yaml:"agents_running" json:"agentsRunning"`
-		AgentsCount    int  `yaml:"agents_count" json:"agentsCount"`
-		LoadBalancer   bool `yaml:"has_lb,omitempty" json:"hasLoadbalancer,omitempty"`
+		ServersRunning int             `yaml:"servers_running" json:"serversRunning"`
+		ServersCount   int             `yaml:"servers_count" json:"serversCount"`
+		AgentsRunning  int             `yaml:"agents_running" json:"agentsRunning"`
+		AgentsCount    int             `yaml:"agents_count" json:"agentsCount"`
+		LoadBalancer   bool            `yaml:"has_lb,omitempty" json:"hasLoadbalancer,omitempty"`
+		Registries     []*k3d.Registry `yaml:"registries,omitempty" json:"registries,omitempty"`
+		KubeconfigPath string          `yaml:"kubeconfig_path,omitempty" json:"kubeconfigPath,omitempty"`
+		APIHost        string          `yaml:"api_host,omitempty" json:"apiHost,omitempty"`
+		APIPort        string          `yaml:"api_port,omitempty" json:"apiPort,omitempty"`
 	}
 
 	jsonOutputEntries := []jsonOutput{}
@@ -119,7 +133,11 @@ func PrintClusters(clusters []*k3d.Cluster, flags clusterFlags) {
 
 	if outputFormat != "json" && outputFormat != "yaml" {
 		if !flags.noHeader {
-			headers := []string{"NAME", "SERVERS", "AGENTS", "LOADBALANCER"} // TODO: getCluster: add status column
+			// the colorized columns need colorized headers as well, to stay aligned
+			headers := []string{"NAME", util.Colorize(util.ColorDefault, "SERVERS"), util.Colorize(util.ColorDefault, "AGENTS"), "LOADBALANCER"}
+			if flags.probe {
+				headers = append(headers, util.Colorize(util.ColorDefault, "API"), "K3S")
+			}
 			if flags.token {
 				headers = append(headers, "TOKEN")
 			}
@@ -138,8 +156,13 @@ func PrintClusters(clusters []*k3d.Cluster, flags clusterFlags) {
 		hasLB := cluster.HasLoadBalancer()
 
 		if outputFormat == "json" || outputFormat == "yaml" {
+			printedCluster := cluster
+			if !flags.token {
+				printedCluster = cluster.WithMaskedToken()
+			}
+
 			entry := jsonOutput{
-				Cluster:        *cluster,
+				Cluster:        *printedCluster,
 				ServersRunning: serversRunning,
 				ServersCount:   serverCount,
 				AgentsRunning:  agentsRunning,
@@ -154,13 +177,36 @@ func PrintClusters(clusters []*k3d.Cluster, flags clusterFlags) {
 			// clear some things
 			entry.ExternalDatastore = nil
 
+			// add details required by external tools
+			registries, err := k3cluster.ClusterGetRegistries(ctx, runtimes.SelectedRuntime, cluster)
+			if err != nil {
+				l.Log().Warnf("Failed to get registries for cluster '%s': %v", cluster.Name, err)
+			}
+			entry.Registries = registries
+
+			kubeconfigPath, err := k3cluster.KubeconfigFindClusterPath(cluster)
+			if err != nil {
+				l.Log().Warnf("Failed to find kubeconfig for cluster '%s': %v", cluster.Name, err)
+			}
+			entry.KubeconfigPath = kubeconfigPath
+
+			if host, port, ok := k3cluster.ClusterGetAPIEndpoint(cluster); ok {
+				entry.APIHost = host
+				entry.APIPort = port
+			}
+
 			jsonOutputEntries = append(jsonOutputEntries, entry)
 		} else {
+			servers := util.Colorize(util.RunningColor(serversRunning, serverCount), fmt.Sprintf("%d/%d", serversRunning, serverCount))
+			agents := util.Colorize(util.RunningColor(agentsRunning, agentCount), fmt.Sprintf("%d/%d", agentsRunning, agentCount))
+			columns := []string{cluster.Name, servers, agents, fmt.Sprintf("%t", hasLB)}
+			if flags.probe && cluster.Health != nil {
+				columns = append(columns, apiHealth(cluster.Health), valueOrDash(cluster.Health.K3sVersion))
+			}
 			if flags.token {
-				fmt.Fprintf(tabwriter, "%s\t%d/%d\t%d/%d\t%t\t%s\n", cluster.Name, serversRunning, serverCount, agentsRunning, agentCount, hasLB, cluster.Token)
-			} else {
-				fmt.Fprintf(tabwriter, "%s\t%d/%d\t%d/%d\t%t\n", cluster.Name, serversRunning, serverCount, agentsRunning, agentCount, hasLB)
+				columns = append(columns, cluster.Token)
 			}
+			fmt.Fprintf(tabwriter, "%s\n", strings.Join(columns, "\t"))
 		}
 	}
 
@@ -180,3 +226,18 @@ func PrintClusters(clusters []*k3d.Cluster, flags clusterFlags) {
 		fmt.Println(string(b))
 	}
 }
+
+// apiHealth describes whether the Kubernetes API of a cluster is reachable
+func apiHealth(health *k3d.ClusterHealth) string {
+	if health.APIReachable {
+		return util.Colorize(util.ColorGreen, "ok")
+	}
+	return util.Colorize(util.ColorRed, "unreachable")
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liggitt/tabwriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterNetpolTestFlags struct {
+	output string
+	opts   k3d.NetpolTestOpts
+}
+
+// NewCmdClusterNetpolTest returns a new cobra command
+func NewCmdClusterNetpolTest() *cobra.Command {
+
+	flags := clusterNetpolTestFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "netpol-test NAME",
+		Short: "Validate the enforcement of NetworkPolicies in a cluster",
+		Long: fmt.Sprintf(`Validate the enforcement of NetworkPolicies in a cluster.
+
+Deploys a test suite (servers, NetworkPolicies and client jobs) to the namespaces '%s' and '%s',
+waits for all checks to report whether traffic was reachable or blocked as expected and prints the results.
+Run it against clusters with different k3s versions to compare their policy behavior.
+Exits with a non-zero exit code, if any check failed.`, k3d.DefaultNetpolTestNamespace, k3d.DefaultNetpolTestOtherNamespace),
+		ValidArgsFunction: util.ValidArgsRunningClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalf("Failed to get cluster '%s': %v", args[0], err)
+			}
+			if !runNetpolTest(cmd.Context(), cluster, flags.opts, flags.output) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().DurationVar(&flags.opts.Timeout, "timeout", k3d.DefaultNetpolTestTimeout, "Maximum time for all checks to report their results")
+	cmd.Flags().StringVar(&flags.opts.Image, "image", k3d.DefaultNetpolTestImage, "Image of the test servers and clients (must provide sh, httpd and wget, like busybox)")
+	cmd.Flags().BoolVar(&flags.opts.Keep, "keep", false, "Keep the test namespaces after the run, e.g. to inspect the pods and policies")
+
+	// done
+	return cmd
+}
+
+// runNetpolTest runs the network policy test suite against the cluster, prints the results and returns whether all checks passed
+func runNetpolTest(ctx context.Context, cluster *k3d.Cluster, opts k3d.NetpolTestOpts, output string) bool {
+	results, err := client.ClusterNetpolTest(ctx, runtimes.SelectedRuntime, cluster, opts)
+	if err != nil {
+		l.Log().Fatalf("Failed to run the network policy test suite in cluster '%s': %v", cluster.Name, err)
+	}
+
+	switch strings.ToLower(output) {
+	case "json":
+		b, err := json.Marshal(results)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+		fmt.Println(string(b))
+	case "yaml":
+		b, err := yaml.Marshal(results)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+		fmt.Print(string(b))
+	case "":
+		printNetpolTestResults(results)
+	default:
+		l.Log().Fatalf("Unknown output format '%s': must be one of json|yaml", output)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		l.Log().Errorf("%d of %d network policy checks failed in cluster '%s'", failed, len(results), cluster.Name)
+		return false
+	}
+	l.Log().Infoln(util.Success(fmt.Sprintf("All %d network policy checks passed in cluster '%s'", len(results), cluster.Name)))
+	return true
+}
+
+func printNetpolTestResults(results []k3d.NetpolTestResult) {
+	tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
+	defer tabwriter.Flush()
+
+	fmt.Fprintf(tabwriter, "%s\n", strings.Join([]string{"CHECK", "EXPECTED", "OBSERVED", "RESULT", "DESCRIPTION"}, "\t"))
+	for _, result := range results {
+		observed, status := result.Observed, "PASS"
+		if observed == "" {
+			observed = "-"
+		}
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(tabwriter, "%s\n", strings.Join([]string{result.Name, result.Expected, observed, status, result.Description}, "\t"))
+	}
+}
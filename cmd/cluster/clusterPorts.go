@@ -0,0 +1,132 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liggitt/tabwriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterPortsFlags struct {
+	output   string
+	noHeader bool
+	firewall string
+}
+
+// NewCmdClusterPorts returns a new cobra command
+func NewCmdClusterPorts() *cobra.Command {
+
+	flags := clusterPortsFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "ports NAME",
+		Short: "List the host ports bound for a cluster",
+		Long: `List every port that k3d bound on the host for a cluster (Kubernetes API, loadbalancer, node port mappings and connected registries) with its bind address and protocol.
+
+Use '--firewall ufw|firewalld' to print suggested firewall rules that allow access to those ports instead.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			ports, err := client.ClusterGetHostPorts(cmd.Context(), runtimes.SelectedRuntime, cluster)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if flags.firewall != "" {
+				rules, err := client.ClusterHostPortsFirewallRules(ports, strings.ToLower(flags.firewall))
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				for _, rule := range rules {
+					fmt.Println(rule)
+				}
+				return
+			}
+
+			switch strings.ToLower(flags.output) {
+			case "json":
+				b, err := json.Marshal(ports)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "yaml":
+				b, err := yaml.Marshal(ports)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Print(string(b))
+			case "":
+				printClusterPorts(ports, flags.noHeader)
+			default:
+				l.Log().Fatalf("Unknown output format '%s': must be one of json|yaml", flags.output)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&flags.noHeader, "no-headers", false, "Disable headers")
+	cmd.Flags().StringVar(&flags.firewall, "firewall", "", fmt.Sprintf("Print suggested rules for this firewall instead of the port list. One of: %s", strings.Join(client.Firewalls, "|")))
+	if err := cmd.RegisterFlagCompletionFunc("firewall", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return client.Firewalls, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--firewall'", err)
+	}
+
+	// done
+	return cmd
+}
+
+func printClusterPorts(ports []k3d.HostPort, noHeader bool) {
+	tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
+	defer tabwriter.Flush()
+
+	if !noHeader {
+		fmt.Fprintln(tabwriter, "NODE\tPURPOSE\tBIND ADDRESS\tHOST PORT\tCONTAINER PORT\tPROTOCOL")
+	}
+	for _, p := range ports {
+		hostPort := p.HostPort
+		if hostPort == "" || hostPort == "0" {
+			hostPort = "(random)"
+		}
+		fmt.Fprintf(tabwriter, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Node, p.Purpose, p.HostIP, hostPort, p.ContainerPort, p.Protocol)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdClusterPrune returns a new cobra command
+func NewCmdClusterPrune() *cobra.Command {
+
+	opts := k3d.ClusterPruneOpts{}
+	var runtimeLabels []string
+	var dryRun bool
+	var orphans bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "prune [--older-than DURATION] [--name PATTERN] [--runtime-label KEY=VALUE] [--external-id-gone CMD|URL] | --orphans [--older-than DURATION]",
+		Short: "Delete clusters matching age, name or label filters or orphaned k3d resources",
+		Long: `Delete clusters (including their networks and registries, like 'k3d cluster delete') matching all given filters.
+
+Meant for CI hosts to clean up leaked clusters, e.g. from a cron job:
+  k3d cluster prune --older-than 24h --name 'ci-*'
+
+The age is determined from the creation timestamp recorded by k3d
+(or the creation time of the oldest node for clusters created by older k3d versions).
+At least one filter is required.
+
+With --external-id-gone, only clusters created with '--external-id' are considered and they're only deleted
+if the given command or URL reports that their external resource (e.g. a pull request) is gone, e.g. for preview environments:
+  k3d cluster prune --external-id-gone 'state=$(gh pr view "$K3D_EXTERNAL_ID" --json state --jq .state) || exit 2; test "$state" = OPEN || exit 1'
+  k3d cluster prune --external-id-gone 'https://ci.example.com/api/pulls/$K3D_EXTERNAL_ID'
+A command has to exit with 0 if the resource exists and with 1 if it's gone, a URL has to respond with 2xx or 404/410.
+Clusters are kept on any other result, so that a failing check never deletes them.
+
+With --orphans, it deletes the k3d resources left behind by crashed creations or killed k3d processes instead:
+containers of clusters without any server node, k3d networks without k3d containers and volumes of clusters
+without any container (except image volumes kept via --keep-image-volume).
+Only resources created longer ago than --older-than (default for --orphans: 1h) are considered,
+so that cluster creations that are still in progress are left alone, e.g.:
+  k3d cluster prune --orphans --older-than 30m --dry-run`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if orphans {
+				if len(opts.NamePatterns) > 0 || len(runtimeLabels) > 0 || opts.ExternalIDGone != "" {
+					l.Log().Fatalln("--orphans cannot be combined with --name, --runtime-label or --external-id-gone")
+				}
+				olderThan := opts.OlderThan
+				if !cmd.Flags().Changed("older-than") {
+					olderThan = k3d.DefaultOrphansMinAge
+				}
+				pruneOrphans(cmd, olderThan, dryRun)
+				return
+			}
+
+			if opts.OlderThan <= 0 && len(opts.NamePatterns) == 0 && len(runtimeLabels) == 0 && opts.ExternalIDGone == "" {
+				l.Log().Fatalln("At least one of --older-than, --name, --runtime-label or --external-id-gone is required (use `k3d cluster delete --all` to delete all clusters)")
+			}
+
+			opts.RuntimeLabels = map[string]string{}
+			for _, label := range runtimeLabels {
+				key, value := cliutil.SplitKV(label)
+				opts.RuntimeLabels[key] = value
+			}
+
+			clusters, err := client.ClusterPruneList(cmd.Context(), runtimes.SelectedRuntime, opts)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			if len(clusters) == 0 {
+				l.Log().Infoln("No clusters to prune")
+				return
+			}
+
+			failed := 0
+			for _, c := range clusters {
+				if dryRun {
+					fmt.Printf("Would delete cluster %s (created: %s)\n", c.Name, c.Created)
+					continue
+				}
+				if err := deleteCluster(cmd.Context(), c); err != nil {
+					l.Log().Errorf("Failed to prune cluster '%s': %v", c.Name, err)
+					failed++
+					continue
+				}
+				l.Log().Infof("Pruned cluster %s", c.Name)
+			}
+
+			// return with non-zero exit code, if we failed to delete one of the clusters
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().DurationVar(&opts.OlderThan, "older-than", 0, "Only prune clusters (or orphans) created longer ago than this duration (e.g. 24h, default for --orphans: 1h)")
+	cmd.Flags().StringArrayVar(&opts.NamePatterns, "name", nil, "Only prune clusters whose name matches this glob pattern (Format: `PATTERN`, can be used multiple times)\n - Example: `k3d cluster prune --name 'ci-*'`")
+	cmd.Flags().StringArrayVar(&runtimeLabels, "runtime-label", nil, "Only prune clusters whose nodes have this runtime label (Format: `KEY=VALUE`, can be used multiple times)")
+	cmd.Flags().StringVar(&opts.ExternalIDGone, "external-id-gone", "", "Only prune clusters created with '--external-id', whose external resource this command or URL reports to be gone (Format: `CMD|URL`, $K3D_EXTERNAL_ID and $K3D_CLUSTER are set/replaced)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the clusters (or orphans) that would be deleted")
+	cmd.Flags().BoolVar(&orphans, "orphans", false, "Delete orphaned k3d containers, networks and volumes (e.g. left behind by crashed creations) instead of clusters")
+
+	// done
+	return cmd
+}
+
+func pruneOrphans(cmd *cobra.Command, olderThan time.Duration, dryRun bool) {
+	orphans, err := client.ClusterOrphansList(cmd.Context(), runtimes.SelectedRuntime, olderThan)
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	if orphans.Empty() {
+		l.Log().Infoln("No orphans to prune")
+		return
+	}
+
+	if dryRun {
+		for _, node := range orphans.Nodes {
+			fmt.Printf("Would delete container %s (cluster: %s)\n", node.Name, node.RuntimeLabels[k3d.LabelClusterName])
+		}
+		for _, network := range orphans.Networks {
+			fmt.Printf("Would delete network %s\n", network)
+		}
+		for _, volume := range orphans.Volumes {
+			fmt.Printf("Would delete volume %s\n", volume)
+		}
+		return
+	}
+
+	// return with non-zero exit code, if we failed to delete one of the orphans
+	if failed := client.ClusterOrphansDelete(cmd.Context(), runtimes.SelectedRuntime, orphans); failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdClusterRepair returns a new cobra command
+func NewCmdClusterRepair() *cobra.Command {
+
+	opts := k3d.ClusterRepairOpts{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "repair NAME",
+		Short: "Re-create the agents that failed during the cluster creation",
+		Long: `Re-create the agents that failed during the cluster creation.
+
+Agents that failed to be created or started while creating the cluster with '--on-node-failure continue'
+are left out of the cluster. This command re-creates them based on the remaining nodes of the cluster.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			repaired, err := client.ClusterRepair(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]}, opts)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			if len(repaired) == 0 {
+				l.Log().Infof("Cluster '%s' has no failed agents", args[0])
+				return
+			}
+			l.Log().Infof("Successfully repaired %d agent(s) of cluster '%s': %s", len(repaired), args[0], strings.Join(repaired, ", "))
+		},
+	}
+
+	// add flags
+	cmd.Flags().BoolVar(&opts.Wait, "wait", true, "Wait for the re-created agents to be ready before returning.")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0*time.Second, "Maximum waiting time for '--wait' per agent before canceling/returning.")
+
+	// add subcommands
+
+	// done
+	return cmd
+}
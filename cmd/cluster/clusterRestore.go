@@ -0,0 +1,152 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/config"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterRestoreFlags struct {
+	name                    string
+	subnet                  string
+	apiPort                 string
+	updateDefaultKubeconfig bool
+	switchContext           bool
+}
+
+// NewCmdClusterRestore returns a new cobra command
+func NewCmdClusterRestore() *cobra.Command {
+
+	flags := clusterRestoreFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "restore FILE",
+		Short: "Restore a cluster from a backup archive",
+		Long: `Restore a cluster from a backup archive created by 'k3d cluster backup' on this or another machine.
+The cluster is recreated with the same token, network settings, exposed ports and node setup, and the backed up data is imported into each node before it starts.
+
+Use '--name' to restore the cluster under a different name (e.g. next to the original cluster), together with '--api-port' and '--subnet' to avoid conflicts on the host.
+Note: the Kubernetes node objects of the original cluster are kept, so they show up as NotReady when restoring under a different name.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := restoreCluster(cmd.Context(), args[0], flags); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVar(&flags.name, "name", "", "Name of the restored cluster (default: name of the backed up cluster)")
+	cmd.Flags().StringVar(&flags.subnet, "subnet", "", "Subnet of the cluster network, overriding the backed up one ('auto' to let docker pick one)")
+	cmd.Flags().StringVar(&flags.apiPort, "api-port", "", "Host port for the Kubernetes API, overriding the backed up one ('random' for a free port)")
+	cmd.Flags().BoolVar(&flags.updateDefaultKubeconfig, "kubeconfig-update-default", true, "Directly update the default kubeconfig with the restored cluster's context")
+	cmd.Flags().BoolVar(&flags.switchContext, "kubeconfig-switch-context", true, "Directly switch the default kubeconfig's current-context to the restored cluster's context (requires --kubeconfig-update-default)")
+
+	// done
+	return cmd
+}
+
+// restoreCluster recreates the cluster from a backup archive and imports the node data
+func restoreCluster(ctx context.Context, file string, flags clusterRestoreFlags) error {
+	archive, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open backup archive: %w", err)
+	}
+	defer archive.Close()
+
+	dir, err := os.MkdirTemp("", "k3d-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	l.Log().Infof("Extracting backup archive %s...", file)
+	backup, err := client.ClusterBackupExtract(archive, dir)
+	if err != nil {
+		return err
+	}
+
+	name := backup.Cluster
+	if flags.name != "" {
+		name = flags.name
+	}
+	if _, err := client.ClusterGet(ctx, runtimes.SelectedRuntime, &k3d.Cluster{Name: name}); err == nil {
+		return fmt.Errorf("failed to restore cluster '%s' because a cluster with that name already exists (use --name to restore it under a different name)", name)
+	}
+
+	simpleCfg := client.ClusterBackupToSimpleConfig(backup, name)
+	if flags.subnet != "" {
+		simpleCfg.Subnet = flags.subnet
+	}
+	randomAPIPort := false
+	if flags.apiPort != "" {
+		randomAPIPort = isRandomPortSpec(flags.apiPort)
+		simpleCfg.ExposeAPI.HostPort = flags.apiPort
+		if randomAPIPort {
+			simpleCfg.ExposeAPI.HostPort = getRandomAPIPort(simpleCfg.ExposeAPI.HostIP)
+		}
+	}
+	simpleCfg.Options.KubeconfigOptions.UpdateDefaultKubeconfig = flags.updateDefaultKubeconfig
+	simpleCfg.Options.KubeconfigOptions.SwitchCurrentContext = flags.updateDefaultKubeconfig && flags.switchContext
+
+	clusterConfig, err := clusterConfigFromSimpleConfig(ctx, *simpleCfg)
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateClusterConfig(ctx, runtimes.SelectedRuntime, *clusterConfig); err != nil {
+		return fmt.Errorf("failed cluster configuration validation: %w", err)
+	}
+
+	restoreAction := client.ClusterBackupRestoreAction(runtimes.SelectedRuntime, backup, dir, name)
+	clusterConfig.ClusterCreateOpts.NodeHooks = append(clusterConfig.ClusterCreateOpts.NodeHooks, k3d.NodeHook{
+		Stage:  k3d.LifecycleStagePreStart,
+		Action: restoreAction,
+	})
+
+	l.Log().Infof("Restoring cluster '%s' from the backup of cluster '%s' (created %s with k3d %s)", name, backup.Cluster, backup.Created.Format("2006-01-02 15:04:05 MST"), backup.K3dVersion)
+	verifyRestore := func() error {
+		if failed := restoreAction.Failed(); len(failed) > 0 {
+			return fmt.Errorf("failed to import the backed up data into node(s) %s", strings.Join(failed, ", "))
+		}
+		return nil
+	}
+	if err := CreateCluster(ctx, runtimes.SelectedRuntime, clusterConfig, "restore", verifyRestore); err != nil {
+		return err
+	}
+	recordClusterCreate(*simpleCfg, clusterConfig, randomAPIPort)
+
+	l.Log().Infoln(cliutil.Success(fmt.Sprintf("Cluster '%s' restored successfully!", name)))
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdClusterResyncTime returns a new cobra command
+func NewCmdClusterResyncTime() *cobra.Command {
+
+	opts := k3d.ClusterResyncTimeOpts{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "resync-time [NAME [NAME...] | --all]",
+		Short: "Correct the clock skew of cluster nodes",
+		Long: `Correct the clock skew of cluster nodes.
+
+After the host (e.g. the Docker Desktop VM) was suspended, the clock inside the nodes may lag behind,
+so that certificates appear to be not yet valid. This command sets the clock of the nodes to the local time.
+Note: all nodes running on the same (VM) kernel share the same clock. Thus, the clock is only corrected
+if the nodes run in a VM (e.g. Docker Desktop), unless --force is given, which sets the clock of the docker host.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			clusters := parseStopClusterCmd(cmd, args)
+			if len(clusters) == 0 {
+				l.Log().Infoln("No clusters found")
+			} else {
+				for _, c := range clusters {
+					skew, err := client.ClusterResyncTime(cmd.Context(), runtimes.SelectedRuntime, c, opts)
+					if err != nil {
+						l.Log().Fatalln(err)
+					}
+					l.Log().Infof("Cluster '%s': clock skew %s", c.Name, skew)
+				}
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().BoolP("all", "a", false, "Correct the clocks of all existing clusters")
+	cmd.Flags().DurationVar(&opts.Threshold, "threshold", k3d.DefaultClockSkewThreshold, "Maximum tolerated clock skew")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only detect the clock skew, don't correct it")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Correct the clock even if the nodes don't run in a VM (e.g. Docker Desktop), i.e. set the clock of the docker host")
+
+	// add subcommands
+
+	// done
+	return cmd
+}
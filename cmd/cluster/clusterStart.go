@@ -30,6 +30,7 @@ import (
 	"github.com/rancher/k3d/v5/pkg/types"
 	"github.com/spf13/cobra"
 
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
@@ -43,16 +44,17 @@ func NewCmdClusterStart() *cobra.Command {
 
 	// create new command
 	cmd := &cobra.Command{
-		Use:               "start [NAME [NAME...] | --all]",
-		Long:              `Start existing k3d cluster(s)`,
-		Short:             "Start existing k3d cluster(s)",
-		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Use:               "start [NAME [NAME...] | --all | --selector KEY=VALUE]",
+		Long:              i18n.T("cmd.cluster.start.short"),
+		Short:             i18n.T("cmd.cluster.start.short"),
+		ValidArgsFunction: util.ValidArgsStoppedClusters,
 		Run: func(cmd *cobra.Command, args []string) {
 			clusters := parseStartClusterCmd(cmd, args)
 			if len(clusters) == 0 {
 				l.Log().Infoln("No clusters found")
 			} else {
 				for _, c := range clusters {
+					checkClusterClockSkew(cmd, c)
 					envInfo, err := client.GatherEnvironmentInfo(cmd.Context(), runtimes.SelectedRuntime, c)
 					if err != nil {
 						l.Log().Fatalf("failed to gather info about cluster environment: %v", err)
@@ -69,8 +71,11 @@ func NewCmdClusterStart() *cobra.Command {
 
 	// add flags
 	cmd.Flags().BoolP("all", "a", false, "Start all existing clusters")
+	addClusterSelectorFlag(cmd, "Start")
 	cmd.Flags().BoolVar(&startClusterOpts.WaitForServer, "wait", true, "Wait for the server(s) (and loadbalancer) to be ready before returning.")
+	cmd.Flags().BoolVar(&startClusterOpts.NoWaitForAgents, "no-wait-agents", false, "Don't wait for the agents to register with the server(s) before returning.")
 	cmd.Flags().DurationVar(&startClusterOpts.Timeout, "timeout", 0*time.Second, "Maximum waiting time for '--wait' before canceling/returning.")
+	cmd.Flags().DurationVar(&startClusterOpts.NodeStartupTimeout, "node-startup-timeout", 0*time.Second, "Maximum time for each node to start and get ready, independent of '--timeout' (e.g. for slow storage)")
 
 	// add subcommands
 
@@ -78,8 +83,27 @@ func NewCmdClusterStart() *cobra.Command {
 	return cmd
 }
 
+// checkClusterClockSkew detects clock skew (e.g. after suspend/resume) before starting the cluster, so nodes don't fail on not-yet-valid certificates.
+// It's only corrected automatically on Docker Desktop, where the nodes run in a VM: elsewhere, we'd change the clock of the docker host.
+func checkClusterClockSkew(cmd *cobra.Command, cluster *k3d.Cluster) {
+	opts := k3d.ClusterResyncTimeOpts{DryRun: !client.NodeClockResyncAllowed(runtimes.SelectedRuntime)}
+	skew, err := client.ClusterResyncTime(cmd.Context(), runtimes.SelectedRuntime, cluster, opts)
+	if err != nil {
+		l.Log().Warnf("Failed to check/correct clock skew of cluster '%s': %v", cluster.Name, err)
+		return
+	}
+	if opts.DryRun && (skew > k3d.DefaultClockSkewThreshold || skew < -k3d.DefaultClockSkewThreshold) {
+		l.Log().Warnf("Run `k3d cluster resync-time %s` to correct the clock skew of the nodes", cluster.Name)
+	}
+}
+
 // parseStartClusterCmd parses the command input into variables required to start clusters
 func parseStartClusterCmd(cmd *cobra.Command, args []string) []*k3d.Cluster {
+	// --selector
+	if clusters, ok := parseClusterSelectorFlag(cmd, args); ok {
+		return clusters
+	}
+
 	// --all
 	var clusters []*k3d.Cluster
 
@@ -0,0 +1,252 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type clusterStatusFlags struct {
+	output  string
+	wait    bool
+	timeout time.Duration
+}
+
+// clusterStatus is the output of `cluster status`
+type clusterStatus struct {
+	Cluster        string                `yaml:"cluster" json:"cluster"`
+	Exists         bool                  `yaml:"exists" json:"exists"`
+	ServersCount   int                   `yaml:"servers_count" json:"serversCount"`
+	ServersRunning int                   `yaml:"servers_running" json:"serversRunning"`
+	AgentsCount    int                   `yaml:"agents_count" json:"agentsCount"`
+	AgentsRunning  int                   `yaml:"agents_running" json:"agentsRunning"`
+	Job            *k3d.ClusterJob       `yaml:"job,omitempty" json:"job,omitempty"`
+	JobStatus      *k3d.ClusterJobStatus `yaml:"job_status,omitempty" json:"jobStatus,omitempty"`
+}
+
+// NewCmdClusterStatus returns a new cobra command
+func NewCmdClusterStatus() *cobra.Command {
+
+	flags := clusterStatusFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "status NAME",
+		Short: "Show the status of a cluster and of its background creation (--async)",
+		Long: `Show the status of a cluster and of its background creation ('k3d cluster create --async').
+
+Exits with a non-zero exit code, if the background creation failed or if there's neither a cluster nor a background creation with that name.
+Use '--wait' to block until a background creation finished, e.g. in CI after doing other setup in parallel.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var deadline time.Time
+			if flags.timeout > 0 {
+				deadline = time.Now().Add(flags.timeout)
+			}
+
+			var status *clusterStatus
+			for {
+				var err error
+				status, err = getClusterStatus(cmd, args[0])
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				if !flags.wait || status.JobStatus == nil || status.JobStatus.State != k3d.ClusterJobStateRunning {
+					break
+				}
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					l.Log().Errorf("Timed out after %s waiting for the creation of cluster '%s'", flags.timeout, args[0])
+					break
+				}
+				select {
+				case <-cmd.Context().Done():
+					l.Log().Fatalln(cmd.Context().Err())
+				case <-time.After(2 * time.Second):
+				}
+			}
+
+			switch strings.ToLower(flags.output) {
+			case "json":
+				b, err := json.Marshal(status)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Println(string(b))
+			case "yaml":
+				b, err := yaml.Marshal(status)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Print(string(b))
+			case "":
+				printClusterStatus(status)
+			default:
+				l.Log().Fatalf("Unknown output format '%s': must be one of json|yaml", flags.output)
+			}
+
+			if status.JobStatus != nil && status.JobStatus.State != k3d.ClusterJobStateSucceeded {
+				os.Exit(1)
+			}
+			if status.JobStatus == nil && !status.Exists {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&flags.wait, "wait", false, "Wait until the background creation of the cluster finished")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 0, "Stop waiting after this duration (with --wait)")
+
+	// done
+	return cmd
+}
+
+// getClusterStatus gathers the state of the cluster's nodes and of its background creation
+func getClusterStatus(cmd *cobra.Command, name string) (*clusterStatus, error) {
+	status := &clusterStatus{Cluster: name}
+
+	job, err := client.ClusterJobGet(name)
+	if err != nil {
+		return nil, err
+	}
+	if job != nil {
+		status.Job = job
+		status.JobStatus, err = client.ClusterJobGetStatus(job)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: name})
+	if err == nil {
+		status.Exists = true
+		status.ServersCount, status.ServersRunning = cluster.ServerCountRunning()
+		status.AgentsCount, status.AgentsRunning = cluster.AgentCountRunning()
+	}
+
+	return status, nil
+}
+
+func printClusterStatus(status *clusterStatus) {
+	fmt.Printf("Cluster:  %s\n", status.Cluster)
+	if status.JobStatus != nil {
+		details := status.JobStatus.Message
+		if status.JobStatus.Error != "" {
+			details = status.JobStatus.Error
+		}
+		if details != "" {
+			fmt.Printf("Creation: %s (%d%%: %s)\n", status.JobStatus.State, status.JobStatus.Percent, details)
+		} else {
+			fmt.Printf("Creation: %s\n", status.JobStatus.State)
+		}
+		fmt.Printf("Log:      %s\n", status.Job.LogFile)
+	}
+	if !status.Exists {
+		fmt.Println("Nodes:    cluster does not exist (yet)")
+		return
+	}
+	fmt.Printf("Servers:  %d/%d running\n", status.ServersRunning, status.ServersCount)
+	fmt.Printf("Agents:   %d/%d running\n", status.AgentsRunning, status.AgentsCount)
+}
+
+// startAsyncClusterCreate re-runs the current `cluster create` command (without --async) as a detached background process,
+// which writes its log and progress events to the job files of the cluster, so `cluster status` can report on it
+func startAsyncClusterCreate(name string) (*k3d.ClusterJob, error) {
+	if job, err := client.ClusterJobGet(name); err != nil {
+		return nil, err
+	} else if job != nil {
+		status, err := client.ClusterJobGetStatus(job)
+		if err != nil {
+			return nil, err
+		}
+		if status.State == k3d.ClusterJobStateRunning {
+			return nil, fmt.Errorf("cluster '%s' is already being created in the background (PID %d)", name, job.PID)
+		}
+	}
+	if err := client.ClusterJobDelete(name); err != nil {
+		return nil, err
+	}
+
+	_, logFile, progressFile, err := client.ClusterJobFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get path of the k3d executable: %w", err)
+	}
+
+	args := []string{}
+	for _, arg := range os.Args[1:] {
+		if arg == "--async" || arg == "--async=true" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	args = append(args, "--progress-file", progressFile)
+
+	logWriter, err := os.OpenFile(logFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file '%s': %w", logFile, err)
+	}
+	defer logWriter.Close()
+
+	process := exec.Command(executable, args...)
+	process.Stdout = logWriter
+	process.Stderr = logWriter
+	if err := process.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start background process: %w", err)
+	}
+
+	job := &k3d.ClusterJob{
+		Cluster:      name,
+		PID:          process.Process.Pid,
+		Started:      time.Now().UTC(),
+		Command:      strings.Join(append([]string{executable}, args...), " "),
+		LogFile:      logFile,
+		ProgressFile: progressFile,
+	}
+	if err := client.ClusterJobSave(job); err != nil {
+		return nil, err
+	}
+	if err := process.Process.Release(); err != nil {
+		l.Log().Debugf("Failed to release background process %d: %v", job.PID, err)
+	}
+	return job, nil
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/rancher/k3d/v5/cmd/util"
 	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
@@ -36,10 +37,10 @@ func NewCmdClusterStop() *cobra.Command {
 
 	// create new command
 	cmd := &cobra.Command{
-		Use:               "stop [NAME [NAME...] | --all]",
-		Short:             "Stop existing k3d cluster(s)",
-		Long:              `Stop existing k3d cluster(s).`,
-		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Use:               "stop [NAME [NAME...] | --all | --selector KEY=VALUE]",
+		Short:             i18n.T("cmd.cluster.stop.short"),
+		Long:              i18n.T("cmd.cluster.stop.short"),
+		ValidArgsFunction: util.ValidArgsRunningClusters,
 		Run: func(cmd *cobra.Command, args []string) {
 			clusters := parseStopClusterCmd(cmd, args)
 			if len(clusters) == 0 {
@@ -56,6 +57,7 @@ func NewCmdClusterStop() *cobra.Command {
 
 	// add flags
 	cmd.Flags().BoolP("all", "a", false, "Stop all existing clusters")
+	addClusterSelectorFlag(cmd, "Stop")
 
 	// add subcommands
 
@@ -65,6 +67,11 @@ func NewCmdClusterStop() *cobra.Command {
 
 // parseStopClusterCmd parses the command input into variables required to start clusters
 func parseStopClusterCmd(cmd *cobra.Command, args []string) []*k3d.Cluster {
+	// --selector
+	if clusters, ok := parseClusterSelectorFlag(cmd, args); ok {
+		return clusters
+	}
+
 	// --all
 	var clusters []*k3d.Cluster
 
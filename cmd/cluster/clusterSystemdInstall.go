@@ -0,0 +1,157 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+const clusterSystemdUnitTpl = `[Unit]
+Description=k3d cluster {{ .Name }}
+Documentation=https://k3d.io
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+{{- range $k, $v := .Env }}
+Environment={{ $k }}={{ $v }}
+{{- end }}
+ExecStart={{ .Executable }} cluster start {{ .Name }}
+ExecStop={{ .Executable }} cluster stop {{ .Name }}
+TimeoutStartSec=300
+
+[Install]
+WantedBy=default.target
+`
+
+type clusterSystemdUnit struct {
+	Name       string
+	Executable string
+	Env        map[string]string
+}
+
+// NewCmdClusterSystemdInstall returns a new cobra command
+func NewCmdClusterSystemdInstall() *cobra.Command {
+
+	var output string
+	var enable bool
+	var force bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "systemd-install NAME",
+		Short: "Install a systemd user unit that starts/stops the cluster with the host",
+		Long: `Install a systemd user unit that starts/stops the cluster with the host.
+
+The unit wraps 'k3d cluster start/stop' and is written to ~/.config/systemd/user/k3d-cluster-NAME.service by default.
+Use '--output -' to only print it. To start the cluster on boot without logging in, enable lingering for your user
+('loginctl enable-linger $USER').`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				l.Log().Fatalf("Failed to get path of the k3d executable: %v", err)
+			}
+
+			unit := clusterSystemdUnit{
+				Name:       cluster.Name,
+				Executable: executable,
+				Env:        map[string]string{},
+			}
+			for _, env := range []string{"DOCKER_HOST", "DOCKER_SOCK", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"} {
+				if v := os.Getenv(env); v != "" {
+					unit.Env[env] = v
+				}
+			}
+
+			var content bytes.Buffer
+			if err := template.Must(template.New("unit").Parse(clusterSystemdUnitTpl)).Execute(&content, unit); err != nil {
+				l.Log().Fatalf("Failed to generate systemd unit: %v", err)
+			}
+
+			if output == "-" {
+				fmt.Print(content.String())
+				return
+			}
+
+			unitName := fmt.Sprintf("%s-cluster-%s.service", k3d.ObjectNamePrefix, cluster.Name)
+			if output == "" {
+				configDir, err := os.UserConfigDir()
+				if err != nil {
+					l.Log().Fatalf("Failed to get user config directory: %v", err)
+				}
+				output = filepath.Join(configDir, "systemd", "user", unitName)
+			}
+
+			if _, err := os.Stat(output); err == nil && !force {
+				l.Log().Fatalf("Unit file '%s' exists and --force was not set", output)
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				l.Log().Fatalf("Failed to create directory for unit file: %v", err)
+			}
+			if err := os.WriteFile(output, content.Bytes(), 0644); err != nil {
+				l.Log().Fatalf("Failed to write unit file: %v", err)
+			}
+			l.Log().Infof("Wrote systemd unit to '%s'", output)
+
+			if !enable {
+				l.Log().Infof("Enable it via `systemctl --user daemon-reload && systemctl --user enable %s`", unitName)
+				return
+			}
+			for _, systemctlArgs := range [][]string{{"--user", "daemon-reload"}, {"--user", "enable", unitName}} {
+				if out, err := exec.CommandContext(cmd.Context(), "systemctl", systemctlArgs...).CombinedOutput(); err != nil {
+					l.Log().Fatalf("Failed to run `systemctl %v`: %v\n%s", systemctlArgs, err, out)
+				}
+			}
+			l.Log().Infof("Enabled systemd unit '%s'", unitName)
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path to write the unit file to ('-' for stdout) (default: ~/.config/systemd/user/k3d-cluster-NAME.service)")
+	cmd.Flags().BoolVar(&enable, "enable", true, "Enable the unit via 'systemctl --user' after writing it")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force overwrite of an existing unit file")
+
+	// done
+	return cmd
+}
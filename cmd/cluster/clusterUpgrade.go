@@ -0,0 +1,78 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdClusterUpgrade returns a new cobra command
+func NewCmdClusterUpgrade() *cobra.Command {
+
+	opts := k3d.ClusterUpgradeOpts{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "upgrade NAME",
+		Short: "Upgrade a cluster to a new k3s image, one node at a time",
+		Long: `Upgrade a cluster to a new k3s image, one node at a time.
+
+The server nodes are replaced first, then the agent nodes. Each node is replaced by a new container running the new image,
+that keeps the name, (static) IP, labels and volumes of the node, so the cluster state is preserved.
+The next node is only replaced once the previous one is ready in Kubernetes again (running the new version).
+Downgrades are rejected, since Kubernetes doesn't support them.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cliutil.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			upgraded, err := client.ClusterUpgrade(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]}, opts)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			if len(upgraded) == 0 {
+				l.Log().Infof("Cluster '%s' already runs image %s", args[0], opts.Image)
+				return
+			}
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Successfully upgraded %d node(s) of cluster '%s'", len(upgraded), args[0])))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&opts.Image, "image", "i", "", "New k3s image of the server and agent nodes (a release channel like 'rancher/k3s:+stable', '+latest' or '+v1.21' resolves to its latest version)")
+	if err := cmd.MarkFlagRequired("image"); err != nil {
+		l.Log().Fatalln("Failed to mark required flag 'image'")
+	}
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0*time.Second, "Maximum time per node to be replaced and ready again before canceling/returning.")
+
+	// add subcommands
+
+	// done
+	return cmd
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package compose
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdComposeAttach returns a new cobra command
+func NewCmdComposeAttach() *cobra.Command {
+
+	opts := k3d.ComposeAttachOpts{}
+	var clusterName string
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "attach PROJECT",
+		Short: "Attach the services of a docker compose project to a cluster",
+		Long: `Attach the services of a docker compose project to a cluster.
+
+All containers of the compose project get connected to the cluster network and their service
+and container names get injected into the cluster DNS (CoreDNS), so that workloads in the cluster
+can talk to the compose services and vice versa (e.g. during a migration from compose to Kubernetes).
+The containers stay connected to the cluster network, so their names get injected into the cluster DNS
+again whenever the cluster starts. Rerun this command after recreating the compose containers (e.g. 'docker compose up'
+after 'docker compose down'), as new containers are not connected to the cluster network.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := client.ClusterAttachCompose(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterName}, args[0], opts); err != nil {
+				l.Log().Fatalln(err)
+			}
+			l.Log().Infof("Attached compose project '%s' to cluster '%s'", args[0], clusterName)
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", k3d.DefaultClusterName, "Cluster to attach the compose project to")
+	if err := cmd.RegisterFlagCompletionFunc("cluster", util.ValidArgsAvailableClusters); err != nil {
+		l.Log().Fatalln("Failed to register flag completion for '--cluster'", err)
+	}
+	cmd.Flags().BoolVar(&opts.SkipDNS, "no-dns", false, "Don't inject the service names into the cluster DNS")
+
+	// done
+	return cmd
+}
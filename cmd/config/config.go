@@ -22,6 +22,7 @@ THE SOFTWARE.
 package config
 
 import (
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 
 	"github.com/spf13/cobra"
@@ -31,11 +32,11 @@ import (
 func NewCmdConfig() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
-		Short: "Work with config file(s)",
-		Long:  `Work with config file(s)`,
+		Short: i18n.T("cmd.config.short"),
+		Long:  i18n.T("cmd.config.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
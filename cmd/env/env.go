@@ -0,0 +1,129 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// Environment variables exported by `k3d env`
+const (
+	EnvKubeconfig        = k3d.EnvClusterKubeconfig
+	EnvCluster           = k3d.EnvClusterName
+	EnvContext           = k3d.EnvClusterContext
+	EnvAPIEndpoint       = k3d.EnvClusterAPIEndpoint
+	EnvLoadbalancerPorts = k3d.EnvClusterLoadbalancerPorts
+	EnvRegistry          = k3d.EnvClusterRegistry
+)
+
+// NewCmdEnv returns a new cobra command
+func NewCmdEnv() *cobra.Command {
+
+	var shell string
+	var unset bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "env [CLUSTER]",
+		Short: "Print the environment variables to use a cluster from your shell",
+		Long: fmt.Sprintf(`Print the environment variables to use a cluster from your shell.
+
+Exports %s (pointing to a cluster-specific kubeconfig file), %s, %s, %s,
+%s (the host port mappings of the loadbalancer, if any) and
+%s (the host address of the first registry connected to the cluster, if any).
+
+Evaluate the output in your shell, e.g.
+  bash/zsh:    eval "$(k3d env mycluster)"
+  fish:        k3d env mycluster --shell fish | source
+  PowerShell:  k3d env mycluster --shell powershell | Invoke-Expression`,
+			EnvKubeconfig, EnvCluster, EnvContext, EnvAPIEndpoint, EnvLoadbalancerPorts, EnvRegistry),
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := util.ValidateShell(shell); err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if unset {
+				for _, key := range []string{EnvKubeconfig, EnvCluster, EnvContext, EnvAPIEndpoint, EnvLoadbalancerPorts, EnvRegistry} {
+					fmt.Println(util.ShellUnsetEnv(shell, key))
+				}
+				return
+			}
+
+			clusterName := k3d.DefaultClusterName
+			if len(args) != 0 {
+				clusterName = args[0]
+			}
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterName})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			// write the cluster-specific kubeconfig, like `k3d kubeconfig write`
+			outputPath, err := client.KubeconfigGetClusterFilePath(cluster)
+			if err != nil {
+				l.Log().Errorln(err)
+				l.Log().Fatalln("Failed to save kubeconfig to local directory")
+			}
+			kubeconfigPath, err := client.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, cluster, outputPath, &client.WriteKubeConfigOptions{UpdateExisting: true, UpdateCurrentContext: true})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			env, err := client.ClusterGetEnv(cmd.Context(), runtimes.SelectedRuntime, cluster, kubeconfigPath)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			set := map[string]bool{}
+			for _, v := range env {
+				fmt.Println(util.ShellSetEnv(shell, v.Key, v.Value))
+				set[v.Key] = true
+			}
+			// unset optional variables left over from another cluster
+			for _, key := range []string{EnvLoadbalancerPorts, EnvRegistry} {
+				if !set[key] {
+					fmt.Println(util.ShellUnsetEnv(shell, key))
+				}
+			}
+			fmt.Println(util.ShellComment(shell, "To point your shell to this cluster, run:"))
+			fmt.Println(util.ShellComment(shell, util.ShellEval(shell, strings.Join(append([]string{os.Args[0], "env"}, args...), " ")+" --shell "+shell)))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVar(&shell, "shell", util.DetectShell(), fmt.Sprintf("Shell to print the commands for (one of %v)", util.Shells))
+	cmd.Flags().BoolVarP(&unset, "unset", "u", false, "Print the commands to unset the variables instead")
+
+	// done
+	return cmd
+}
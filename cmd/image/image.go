@@ -22,6 +22,7 @@ THE SOFTWARE.
 package image
 
 import (
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/spf13/cobra"
 )
@@ -33,18 +34,19 @@ func NewCmdImage() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "image",
 		Aliases: []string{"images"},
-		Short:   "Handle container images.",
-		Long:    `Handle container images.`,
+		Short:   i18n.T("cmd.image.short"),
+		Long:    i18n.T("cmd.image.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
 	}
 
 	// add subcommands
-	cmd.AddCommand(NewCmdImageImport())
+	cmd.AddCommand(NewCmdImageImport(),
+		NewCmdImageBuildNode())
 
 	// add flags
 
@@ -0,0 +1,97 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package image
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// NewCmdImageBuildNode returns a new cobra command
+func NewCmdImageBuildNode() *cobra.Command {
+
+	buildOpts := client.NodeImageBuildOpts{}
+	var copySpecs []string
+	var printDockerfile bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "build-node TAG",
+		Short: "Build a custom node image on top of a k3s image.",
+		Long: `Build a custom node image on top of a k3s image from a generated Dockerfile and tag it as TAG.
+
+Packages (--package) are installed from Alpine Linux, as the k3s image doesn't ship a package manager.
+Afterwards, files are copied (--copy) and commands are run (--run) in the given order.
+Unless --validate=false is set, a temporary server node is started from the new image to verify that k3s still boots.
+
+Use --print-dockerfile to get the generated Dockerfile, e.g. to maintain it yourself.`,
+		Example: `  k3d image build-node my-k3s:v1 --package open-iscsi --package nfs-utils --copy ./registries.yaml:/etc/rancher/k3s/registries.yaml
+  k3d cluster create --image my-k3s:v1`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, spec := range copySpecs {
+				file, err := client.ParseNodeBakeFile(spec)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				buildOpts.Files = append(buildOpts.Files, file)
+			}
+
+			if printDockerfile {
+				dockerfile, err := client.GenerateNodeImageDockerfile(&buildOpts)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Print(dockerfile)
+				return
+			}
+
+			if err := client.NodeImageBuild(cmd.Context(), runtimes.SelectedRuntime, args[0], buildOpts); err != nil {
+				l.Log().Fatalf("Failed to build node image '%s': %v", args[0], err)
+			}
+			l.Log().Infoln(util.Success(fmt.Sprintf("Successfully built node image '%s'!", args[0])))
+		},
+	}
+
+	/*********
+	 * Flags *
+	 *********/
+	cmd.Flags().StringVarP(&buildOpts.BaseImage, "image", "i", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion), "Specify the k3s image to build on")
+	cmd.Flags().StringArrayVarP(&buildOpts.Packages, "package", "p", nil, "Install an Alpine Linux package (including its dependencies) into the image")
+	cmd.Flags().StringVar(&buildOpts.PackageBase, "package-base", client.DefaultNodeImagePackageBase, "Specify the Alpine image used to install packages")
+	cmd.Flags().StringArrayVar(&copySpecs, "copy", nil, "Copy a file or directory from the host into the image (Format: SOURCE:DESTINATION)\n - Example: --copy ./registries.yaml:/etc/rancher/k3s/registries.yaml")
+	cmd.Flags().StringArrayVar(&buildOpts.Commands, "run", nil, "Run a shell command while building the image (can be repeated)")
+	cmd.Flags().BoolVar(&buildOpts.Validate, "validate", true, "Start a temporary server node from the new image to verify that k3s still boots")
+	cmd.Flags().DurationVar(&buildOpts.ValidateTimeout, "validate-timeout", client.DefaultNodeImageValidateTimeout, "Maximum time k3s may take to boot during validation")
+	cmd.Flags().BoolVar(&printDockerfile, "print-dockerfile", false, "Only print the generated Dockerfile instead of building the image")
+
+	// done
+	return cmd
+}
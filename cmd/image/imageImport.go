@@ -22,6 +22,7 @@ THE SOFTWARE.
 package image
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -33,6 +34,12 @@ import (
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
 
+// Exit codes of the image import command (stable, e.g. for usage by external tools like Tilt or Skaffold)
+const (
+	ImageImportExitCodeFailed       = 1 // importing failed for at least one cluster
+	ImageImportExitCodeInvalidInput = 2 // invalid input, e.g. unknown cluster or no images given
+)
+
 // NewCmdImageImport returns a new cobra command
 func NewCmdImageImport() *cobra.Command {
 
@@ -54,7 +61,12 @@ If an IMAGE does not have a version tag, then ':latest' is assumed.
 That is, 'rancher/k3d-tools' is treated as 'rancher/k3d-tools:latest'.
 
 A file ARCHIVE always takes precedence.
-So if a file './rancher/k3d-tools' exists, k3d will try to import it instead of the IMAGE of the same name.`,
+So if a file './rancher/k3d-tools' exists, k3d will try to import it instead of the IMAGE of the same name.
+
+Exit Codes:
+  0: all images were imported into all selected clusters
+  1: importing failed for at least one of the selected clusters
+  2: invalid input (e.g. unknown cluster or import mode)`,
 		Aliases: []string{"load"},
 		Args:    cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -66,15 +78,16 @@ So if a file './rancher/k3d-tools' exists, k3d will try to import it instead of
 				l.Log().Fatalln(err)
 			}
 			if mode, ok := k3d.ImportModes[loadModeStr]; !ok {
-				l.Log().Fatalf("Unknown image loading mode '%s'\n", loadModeStr)
+				l.Log().Errorf("Unknown image loading mode '%s'\n", loadModeStr)
+				os.Exit(ImageImportExitCodeInvalidInput)
 			} else {
 				loadImageOpts.Mode = mode
 			}
 
 			l.Log().Debugf("Importing image(s) [%+v] from runtime [%s] into cluster(s) [%+v]...", images, runtimes.SelectedRuntime, clusters)
 			errOccurred := false
-			for _, cluster := range clusters {
-				l.Log().Infof("Importing image(s) into cluster '%s'", cluster.Name)
+			for i, cluster := range clusters {
+				l.Log().Infof("Importing image(s) into cluster '%s' (%d/%d)", cluster.Name, i+1, len(clusters))
 				if err := client.ImageImportIntoClusterMulti(cmd.Context(), runtimes.SelectedRuntime, images, cluster, loadImageOpts); err != nil {
 					l.Log().Errorf("Failed to import image(s) into cluster '%s': %+v", cluster.Name, err)
 					errOccurred = true
@@ -82,9 +95,9 @@ So if a file './rancher/k3d-tools' exists, k3d will try to import it instead of
 			}
 			if errOccurred {
 				l.Log().Warnln("At least one error occured while trying to import the image(s) into the selected cluster(s)")
-				os.Exit(1)
+				os.Exit(ImageImportExitCodeFailed)
 			}
-			l.Log().Infof("Successfully imported %d image(s) into %d cluster(s)", len(images), len(clusters))
+			l.Log().Infoln(util.Success(fmt.Sprintf("Successfully imported %d image(s) into %d cluster(s)", len(images), len(clusters))))
 		},
 	}
 
@@ -117,7 +130,8 @@ func parseLoadImageCmd(cmd *cobra.Command, args []string) ([]string, []*k3d.Clus
 	for _, clusterName := range clusterNames {
 		cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterName})
 		if err != nil {
-			l.Log().Fatalf("failed to get cluster %s: %v", clusterName, err)
+			l.Log().Errorf("failed to get cluster %s: %v", clusterName, err)
+			os.Exit(ImageImportExitCodeInvalidInput)
 		}
 		clusters = append(clusters, cluster)
 	}
@@ -125,7 +139,8 @@ func parseLoadImageCmd(cmd *cobra.Command, args []string) ([]string, []*k3d.Clus
 	// images
 	images := args
 	if len(images) == 0 {
-		l.Log().Fatalln("No images specified!")
+		l.Log().Errorln("No images specified!")
+		os.Exit(ImageImportExitCodeInvalidInput)
 	}
 
 	return images, clusters
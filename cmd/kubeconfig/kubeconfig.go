@@ -22,6 +22,7 @@ THE SOFTWARE.
 package kubeconfig
 
 import (
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/spf13/cobra"
 )
@@ -32,18 +33,18 @@ func NewCmdKubeconfig() *cobra.Command {
 	// create new cobra command
 	cmd := &cobra.Command{
 		Use:   "kubeconfig",
-		Short: "Manage kubeconfig(s)",
-		Long:  `Manage kubeconfig(s)`,
+		Short: i18n.T("cmd.kubeconfig.short"),
+		Long:  i18n.T("cmd.kubeconfig.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
 	}
 
 	// add subcommands
-	cmd.AddCommand(NewCmdKubeconfigGet(), NewCmdKubeconfigMerge())
+	cmd.AddCommand(NewCmdKubeconfigGet(), NewCmdKubeconfigMerge(), NewCmdKubeconfigEnv(), NewCmdKubeconfigCredential())
 
 	// add flags
 
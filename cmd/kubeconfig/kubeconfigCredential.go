@@ -0,0 +1,74 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package kubeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdKubeconfigCredential returns a new cobra command
+func NewCmdKubeconfigCredential() *cobra.Command {
+
+	encryption := k3d.KubeconfigEncryption{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "credential CLUSTER",
+		Short: "Print the decrypted client credentials of a cluster as ExecCredential (kubectl credential plugin)",
+		Long: `Print the decrypted client credentials of a cluster as ExecCredential (kubectl credential plugin).
+
+This is called by kubectl for kubeconfigs written with encrypted credentials (e.g. 'k3d kubeconfig merge --encrypt age'),
+so usually there's no need to run it yourself.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			credential, err := client.KubeconfigCredentialGet(cmd.Context(), cluster, &encryption)
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			out, err := json.Marshal(credential)
+			if err != nil {
+				l.Log().Fatalf("Failed to marshal credential: %v", err)
+			}
+			fmt.Println(string(out))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVar((*string)(&encryption.Store), "store", string(k3d.KubeconfigCredentialStoreKeychain), fmt.Sprintf("Credential store the credentials are kept in (one of %v)", k3d.KubeconfigCredentialStores))
+	cmd.Flags().StringVar(&encryption.Identity, "identity", "", "age identity file to decrypt the credentials with (required for --store=age)")
+
+	// done
+	return cmd
+}
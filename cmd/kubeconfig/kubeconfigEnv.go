@@ -0,0 +1,97 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdKubeconfigEnv returns a new cobra command
+func NewCmdKubeconfigEnv() *cobra.Command {
+
+	var shell string
+	var useContext bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "env [CLUSTER]",
+		Short: "Print the shell command(s) to use the cluster's kubeconfig",
+		Long: `Print the shell command(s) to use the cluster's kubeconfig.
+
+By default, the kubeconfig is written to a cluster-specific file in k3d's config directory and the command sets KUBECONFIG to it.
+With --use-context, the cluster's context in the default kubeconfig is selected instead.
+
+Evaluate the output in your shell, e.g.
+  bash/zsh:    eval "$(k3d kubeconfig env mycluster)"
+  fish:        k3d kubeconfig env mycluster --shell fish | source
+  PowerShell:  k3d kubeconfig env mycluster --shell powershell | Invoke-Expression`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Args:              cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := util.ValidateShell(shell); err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			clusterName := k3d.DefaultClusterName
+			if len(args) != 0 {
+				clusterName = args[0]
+			}
+			cluster, err := client.ClusterGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Cluster{Name: clusterName})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if useContext {
+				if _, err := client.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, cluster, "", &client.WriteKubeConfigOptions{UpdateExisting: true, UpdateCurrentContext: true}); err != nil {
+					l.Log().Fatalln(err)
+				}
+				fmt.Printf("kubectl config use-context %s-%s\n", cluster.GetNamePrefix(), cluster.Name)
+				return
+			}
+
+			outputPath, err := client.KubeconfigGetClusterFilePath(cluster)
+			if err != nil {
+				l.Log().Errorln(err)
+				l.Log().Fatalln("Failed to save kubeconfig to local directory")
+			}
+			output, err := client.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, cluster, outputPath, &client.WriteKubeConfigOptions{UpdateExisting: true, UpdateCurrentContext: true})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+			fmt.Println(util.ShellSetEnv(shell, "KUBECONFIG", output))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVar(&shell, "shell", util.DetectShell(), fmt.Sprintf("Shell to print the command(s) for (one of %v)", util.Shells))
+	cmd.Flags().BoolVar(&useContext, "use-context", false, "Merge the kubeconfig into the default kubeconfig and print the command to switch to the cluster's context instead of setting KUBECONFIG")
+
+	// done
+	return cmd
+}
@@ -24,6 +24,7 @@ package kubeconfig
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rancher/k3d/v5/cmd/util"
 	"github.com/rancher/k3d/v5/pkg/client"
@@ -31,10 +32,14 @@ import (
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
 )
 
 type getKubeconfigFlags struct {
-	all bool
+	all    bool
+	output string
 }
 
 // NewCmdKubeconfigGet returns a new cobra command
@@ -59,6 +64,9 @@ func NewCmdKubeconfigGet() *cobra.Command {
 			if (len(args) < 1 && !getKubeconfigFlags.all) || (len(args) > 0 && getKubeconfigFlags.all) {
 				return fmt.Errorf("Need to specify one or more cluster names *or* set `--all` flag")
 			}
+			if o := strings.ToLower(getKubeconfigFlags.output); o != "yaml" && o != "json" {
+				return fmt.Errorf("unsupported output format '%s': must be one of json|yaml", getKubeconfigFlags.output)
+			}
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
@@ -81,6 +89,13 @@ func NewCmdKubeconfigGet() *cobra.Command {
 				}
 			}
 
+			if strings.ToLower(getKubeconfigFlags.output) == "json" {
+				if err := printKubeconfigsJSON(cmd, clusters); err != nil {
+					l.Log().Fatalln(err)
+				}
+				return
+			}
+
 			// get kubeconfigs from all clusters
 			errorGettingKubeconfig := false
 			for _, c := range clusters {
@@ -101,7 +116,44 @@ func NewCmdKubeconfigGet() *cobra.Command {
 
 	// add flags
 	cmd.Flags().BoolVarP(&getKubeconfigFlags.all, "all", "a", false, "Output kubeconfigs from all existing clusters")
+	cmd.Flags().StringVarP(&getKubeconfigFlags.output, "output", "o", "yaml", "Output format. One of: json|yaml")
 
 	// done
 	return cmd
 }
+
+// printKubeconfigsJSON merges the kubeconfigs of all given clusters into a single config and prints it as JSON
+func printKubeconfigsJSON(cmd *cobra.Command, clusters []*k3d.Cluster) error {
+	merged := clientcmdapi.NewConfig()
+	for _, c := range clusters {
+		l.Log().Debugf("Getting kubeconfig for cluster '%s'", c.Name)
+		kubeconfig, err := client.KubeconfigGet(cmd.Context(), runtimes.SelectedRuntime, c)
+		if err != nil {
+			return fmt.Errorf("failed to get kubeconfig for cluster '%s': %w", c.Name, err)
+		}
+		for name, cluster := range kubeconfig.Clusters {
+			merged.Clusters[name] = cluster
+		}
+		for name, authInfo := range kubeconfig.AuthInfos {
+			merged.AuthInfos[name] = authInfo
+		}
+		for name, context := range kubeconfig.Contexts {
+			merged.Contexts[name] = context
+		}
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = kubeconfig.CurrentContext
+		}
+	}
+
+	// clientcmd takes care of the kubeconfig-specific serialization (e.g. base64 encoded certificate data)
+	y, err := clientcmd.Write(*merged)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+	j, err := yaml.YAMLToJSON(y)
+	if err != nil {
+		return fmt.Errorf("failed to convert kubeconfig to JSON: %w", err)
+	}
+	fmt.Println(string(j))
+	return nil
+}
@@ -24,7 +24,6 @@ package kubeconfig
 import (
 	"fmt"
 	"os"
-	"path"
 	"strings"
 
 	"github.com/rancher/k3d/v5/cmd/util"
@@ -32,7 +31,6 @@ import (
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
-	k3dutil "github.com/rancher/k3d/v5/pkg/util"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -41,6 +39,7 @@ type mergeKubeconfigFlags struct {
 	all           bool
 	output        string
 	targetDefault bool
+	encryption    k3d.KubeconfigEncryption
 }
 
 // NewCmdKubeconfigMerge returns a new cobra command
@@ -66,6 +65,21 @@ func NewCmdKubeconfigMerge() *cobra.Command {
 				l.Log().Fatalln("Cannot use both '--output' and '--kubeconfig-merge-default' at the same time")
 			}
 
+			if mergeKubeconfigFlags.all && len(args) > 0 {
+				l.Log().Fatalln("Cannot use both '--all' and cluster names at the same time")
+			}
+
+			if err := client.KubeconfigRoleValidate(writeKubeConfigOptions.Role); err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			if mergeKubeconfigFlags.encryption.Store != "" {
+				if err := client.KubeconfigEncryptionValidate(&mergeKubeconfigFlags.encryption); err != nil {
+					l.Log().Fatalln(err)
+				}
+				writeKubeConfigOptions.Encryption = &mergeKubeconfigFlags.encryption
+			}
+
 			// generate list of clusters
 			if mergeKubeconfigFlags.all {
 				clusters, err = client.ClusterList(cmd.Context(), runtimes.SelectedRuntime)
@@ -91,18 +105,21 @@ func NewCmdKubeconfigMerge() *cobra.Command {
 			// get kubeconfigs from all clusters
 			errorGettingKubeconfig := false
 			var outputs []string
-			outputDir, err := k3dutil.GetConfigDirOrCreate()
-			if err != nil {
-				l.Log().Errorln(err)
-				l.Log().Fatalln("Failed to save kubeconfig to local directory")
-			}
-			for _, c := range clusters {
+			for i, c := range clusters {
 				l.Log().Debugf("Getting kubeconfig for cluster '%s'", c.Name)
 				output := mergeKubeconfigFlags.output
+				opts := writeKubeConfigOptions
 				if output == "" && !mergeKubeconfigFlags.targetDefault {
-					output = path.Join(outputDir, fmt.Sprintf("kubeconfig-%s.yaml", c.Name))
+					output, err = client.KubeconfigGetClusterFilePath(c)
+					if err != nil {
+						l.Log().Errorln(err)
+						l.Log().Fatalln("Failed to save kubeconfig to local directory")
+					}
+				} else if i > 0 {
+					// all clusters end up in the same file, so only the first one may overwrite it
+					opts.OverwriteExisting = false
 				}
-				output, err = client.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, c, output, &writeKubeConfigOptions)
+				output, err = client.KubeconfigGetWrite(cmd.Context(), runtimes.SelectedRuntime, c, output, &opts)
 				if err != nil {
 					l.Log().Errorln(err)
 					errorGettingKubeconfig = true
@@ -133,6 +150,10 @@ func NewCmdKubeconfigMerge() *cobra.Command {
 	cmd.Flags().BoolVarP(&writeKubeConfigOptions.UpdateCurrentContext, "kubeconfig-switch-context", "s", true, "Switch to new context")
 	cmd.Flags().BoolVar(&writeKubeConfigOptions.OverwriteExisting, "overwrite", false, "[Careful!] Overwrite existing file, ignoring its contents")
 	cmd.Flags().BoolVarP(&mergeKubeconfigFlags.all, "all", "a", false, "Get kubeconfigs from all existing clusters")
+	cmd.Flags().StringVar((*string)(&writeKubeConfigOptions.Role), "kubeconfig-role", string(k3d.KubeconfigRoleAdmin), fmt.Sprintf("Access granted by the written kubeconfig (one of %v): non-admin roles use a ServiceAccount bound to the ClusterRole of the same name instead of cluster-admin credentials", k3d.KubeconfigRoles))
+	cmd.Flags().StringVar((*string)(&mergeKubeconfigFlags.encryption.Store), "encrypt", "", fmt.Sprintf("Store the client credentials encrypted in a credential store %+v instead of in the kubeconfig (kubectl decrypts them on demand via 'k3d kubeconfig credential')", k3d.KubeconfigCredentialStores))
+	cmd.Flags().StringSliceVar(&mergeKubeconfigFlags.encryption.Recipients, "encrypt-recipient", nil, "age recipient to encrypt the credentials for (required for --encrypt=age, optional for --encrypt=sops)")
+	cmd.Flags().StringVar(&mergeKubeconfigFlags.encryption.Identity, "encrypt-identity", "", "age identity file to decrypt the credentials with (required for --encrypt=age)")
 
 	// done
 	return cmd
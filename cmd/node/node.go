@@ -22,6 +22,7 @@ THE SOFTWARE.
 package node
 
 import (
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/spf13/cobra"
 )
@@ -32,11 +33,11 @@ func NewCmdNode() *cobra.Command {
 	// create new cobra command
 	cmd := &cobra.Command{
 		Use:   "node",
-		Short: "Manage node(s)",
-		Long:  `Manage node(s)`,
+		Short: i18n.T("cmd.node.short"),
+		Long:  i18n.T("cmd.node.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
@@ -46,9 +47,14 @@ func NewCmdNode() *cobra.Command {
 	cmd.AddCommand(NewCmdNodeCreate(),
 		NewCmdNodeStart(),
 		NewCmdNodeStop(),
+		NewCmdNodePause(),
+		NewCmdNodeUnpause(),
 		NewCmdNodeDelete(),
 		NewCmdNodeList(),
-		NewCmdNodeEdit())
+		NewCmdNodeEdit(),
+		NewCmdNodeBake(),
+		NewCmdNodeExec(),
+		NewCmdNodeLogs())
 
 	// add flags
 
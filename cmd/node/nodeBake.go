@@ -0,0 +1,81 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package node
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	k3dc "github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// NewCmdNodeBake returns a new cobra command
+func NewCmdNodeBake() *cobra.Command {
+
+	bakeOpts := k3dc.NodeBakeOpts{}
+	var copySpecs []string
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "bake IMAGE",
+		Short: "Bake a custom node image",
+		Long: `Bake a custom node image by running provisioning steps in a temporary node container and committing the result as IMAGE.
+
+The steps are run in this order: copy files (--copy), add CA certificates (--ca-cert), add airgap images (--airgap-image), run commands (--run).
+Use the new image via '--image IMAGE' when creating clusters or nodes.
+
+Note: changes below /var/lib/rancher/k3s, /var/lib/kubelet, /var/lib/cni and /var/log are lost, as those are volumes in the k3s image.`,
+		Example: `  k3d node bake my-k3s:v1 --ca-cert ./corp-ca.pem --airgap-image nginx:1.21 --copy ./manifests/:/opt/manifests/
+  k3d cluster create --image my-k3s:v1`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, spec := range copySpecs {
+				file, err := k3dc.ParseNodeBakeFile(spec)
+				if err != nil {
+					l.Log().Fatalln(err)
+				}
+				bakeOpts.Files = append(bakeOpts.Files, file)
+			}
+
+			if err := k3dc.NodeBake(cmd.Context(), runtimes.SelectedRuntime, args[0], bakeOpts); err != nil {
+				l.Log().Fatalf("Failed to bake node image '%s': %v", args[0], err)
+			}
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Successfully baked node image '%s'!", args[0])))
+		},
+	}
+
+	// add flags
+	cmd.Flags().StringVarP(&bakeOpts.BaseImage, "image", "i", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion), "Specify the k3s image to start from")
+	cmd.Flags().StringArrayVar(&copySpecs, "copy", nil, "Copy a file or directory from the host into the image (Format: SOURCE:DESTINATION)\n - Example: --copy ./registries.yaml:/etc/rancher/k3s/registries.yaml")
+	cmd.Flags().StringArrayVar(&bakeOpts.CACerts, "ca-cert", nil, "Add a PEM encoded CA certificate to the trust bundle of the image")
+	cmd.Flags().StringArrayVar(&bakeOpts.AirgapImages, "airgap-image", nil, "Preload an image (present in the container runtime) when a node starts, like k3s airgap images")
+	cmd.Flags().StringArrayVar(&bakeOpts.Commands, "run", nil, "Run a shell command in the node container (can be repeated)")
+
+	// done
+	return cmd
+}
@@ -23,6 +23,7 @@ package node
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	k3dutil "github.com/rancher/k3d/v5/pkg/util"
 	"github.com/rancher/k3d/v5/version"
 )
 
@@ -62,7 +64,7 @@ func NewCmdNodeCreate() *cobra.Command {
 					l.Log().Fatalf("failed to add %d node(s) to the runtime local cluster '%s': %v", len(nodes), clusterName, err)
 				}
 			}
-			l.Log().Infof("Successfully created %d node(s)!", len(nodes))
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Successfully created %d node(s)!", len(nodes))))
 		},
 	}
 
@@ -77,14 +79,18 @@ func NewCmdNodeCreate() *cobra.Command {
 		l.Log().Fatalln("Failed to register flag completion for '--cluster'", err)
 	}
 
-	cmd.Flags().StringP("image", "i", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion), "Specify k3s image used for the node(s)")
+	cmd.Flags().StringP("image", "i", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion), "Specify k3s image used for the node(s) (a release channel like 'rancher/k3s:+stable' resolves to its latest version)")
 	cmd.Flags().String("memory", "", "Memory limit imposed on the node [From docker]")
+	cmd.Flags().String("cpus", "", "CPU limit imposed on the node (e.g. 1.5) [From docker]")
+	cmd.Flags().String("cpuset-cpus", "", "Host CPUs the node is pinned to (e.g. 0-3,8) [From docker]")
+	cmd.Flags().String("cpuset-mems", "", "Memory (NUMA) nodes of the host the node may use (e.g. 0) [From docker]")
 
 	cmd.Flags().BoolVar(&createNodeOpts.Wait, "wait", true, "Wait for the node(s) to be ready before returning.")
 	cmd.Flags().DurationVar(&createNodeOpts.Timeout, "timeout", 0*time.Second, "Maximum waiting time for '--wait' before canceling/returning.")
 
 	cmd.Flags().StringSliceP("runtime-label", "", []string{}, "Specify container runtime labels in format \"foo=bar\"")
 	cmd.Flags().StringSliceP("k3s-node-label", "", []string{}, "Specify k3s node labels in format \"foo=bar\"")
+	cmd.Flags().String("os-node", k3d.NodeOSLinux, fmt.Sprintf("[EXPERIMENTAL] Operating system of the node(s) (one of [%s %s]): '%s' creates agents simulating Windows workers (labels, taint) for scheduling tests, still running Linux containers", k3d.NodeOSLinux, k3d.NodeOSWindows, k3d.NodeOSWindows))
 
 	cmd.Flags().StringSliceP("network", "n", []string{}, "Add node to (another) runtime network")
 
@@ -110,6 +116,10 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 		l.Log().Errorln("No node role specified")
 		l.Log().Fatalln(err)
 	}
+	if role, ok := k3d.DeprecatedNodeRoles[roleStr]; ok {
+		l.Log().Warnf("Node role '%s' is deprecated and will be removed in a future release: use '%s' instead", roleStr, role)
+		roleStr = string(role)
+	}
 	if _, ok := k3d.NodeRoles[roleStr]; !ok {
 		l.Log().Fatalf("Unknown node role '%s'\n", roleStr)
 	}
@@ -121,6 +131,10 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 		l.Log().Errorln("No image specified")
 		l.Log().Fatalln(err)
 	}
+	image, err = k3dc.K3sImageResolve(image)
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
 
 	// --cluster
 	clusterName, err := cmd.Flags().GetString("cluster")
@@ -138,6 +152,30 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 		l.Log().Errorf("Provided memory limit value is invalid")
 	}
 
+	// --cpus
+	cpus, err := cmd.Flags().GetString("cpus")
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	if c, err := strconv.ParseFloat(cpus, 64); cpus != "" && (err != nil || c <= 0) {
+		l.Log().Fatalf("Provided cpu limit value '%s' is invalid: must be a positive number of CPUs", cpus)
+	}
+
+	// --cpuset-cpus, --cpuset-mems
+	var runtimeOpts []string
+	for _, key := range []string{"cpuset-cpus", "cpuset-mems"} {
+		cpuset, err := cmd.Flags().GetString(key)
+		if err != nil {
+			l.Log().Fatalln(err)
+		}
+		if cpuset != "" {
+			runtimeOpts = append(runtimeOpts, fmt.Sprintf("%s=%s", key, cpuset))
+		}
+	}
+	if err := runtimes.SelectedRuntime.ValidateRuntimeOpts(runtimeOpts); err != nil {
+		l.Log().Fatalln(err)
+	}
+
 	// --runtime-label
 	runtimeLabelsFlag, err := cmd.Flags().GetStringSlice("runtime-label")
 	if err != nil {
@@ -151,7 +189,9 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 		if len(labelSplitted) != 2 {
 			l.Log().Fatalf("unknown runtime-label format format: %s, use format \"foo=bar\"", label)
 		}
-		cliutil.ValidateRuntimeLabelKey(labelSplitted[0])
+		if err := k3dutil.ValidateRuntimeLabelKey(labelSplitted[0]); err != nil {
+			l.Log().Fatalln(err)
+		}
 		runtimeLabels[labelSplitted[0]] = labelSplitted[1]
 	}
 
@@ -174,6 +214,20 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 		k3sNodeLabels[labelSplitted[0]] = labelSplitted[1]
 	}
 
+	// --os-node
+	nodeOS, err := cmd.Flags().GetString("os-node")
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	if nodeOS == k3d.NodeOSWindows {
+		if role != k3d.AgentRole {
+			l.Log().Fatalf("--os-node %s is only supported for agent nodes", nodeOS)
+		}
+		l.Log().Warnf("Experimental: creating simulated %s node(s), which are labeled and tainted like %s workers, but run Linux containers", nodeOS, nodeOS)
+	} else if nodeOS != k3d.NodeOSLinux {
+		l.Log().Fatalf("Unsupported --os-node '%s' (supported: %s, %s)", nodeOS, k3d.NodeOSLinux, k3d.NodeOSWindows)
+	}
+
 	// --network
 	networks, err := cmd.Flags().GetStringSlice("network")
 	if err != nil {
@@ -184,13 +238,16 @@ func parseCreateNodeCmd(cmd *cobra.Command, args []string) ([]*k3d.Node, string)
 	nodes := []*k3d.Node{}
 	for i := 0; i < replicas; i++ {
 		node := &k3d.Node{
-			Name:          fmt.Sprintf("%s-%s-%d", k3d.DefaultObjectNamePrefix, args[0], i),
+			Name:          fmt.Sprintf("%s-%s-%d", k3d.ObjectNamePrefix, args[0], i),
 			Role:          role,
 			Image:         image,
 			K3sNodeLabels: k3sNodeLabels,
 			RuntimeLabels: runtimeLabels,
+			OS:            nodeOS,
 			Restart:       true,
 			Memory:        memory,
+			CPUs:          cpus,
+			RuntimeOpts:   runtimeOpts,
 			Networks:      networks,
 		}
 		nodes = append(nodes, node)
@@ -22,6 +22,8 @@ THE SOFTWARE.
 package node
 
 import (
+	"fmt"
+
 	"github.com/rancher/k3d/v5/cmd/util"
 	"github.com/rancher/k3d/v5/pkg/client"
 	l "github.com/rancher/k3d/v5/pkg/logger"
@@ -59,7 +61,7 @@ func NewCmdNodeDelete() *cobra.Command {
 						l.Log().Fatalln(err)
 					}
 				}
-				l.Log().Infof("Successfully deleted %d node(s)!", len(nodes))
+				l.Log().Infoln(util.Success(fmt.Sprintf("Successfully deleted %d node(s)!", len(nodes))))
 			}
 		},
 	}
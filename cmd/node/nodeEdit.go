@@ -22,6 +22,9 @@ THE SOFTWARE.
 package node
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/docker/go-connections/nat"
 	"github.com/rancher/k3d/v5/cmd/util"
 	"github.com/rancher/k3d/v5/pkg/client"
@@ -36,9 +39,13 @@ func NewCmdNodeEdit() *cobra.Command {
 
 	// create new cobra command
 	cmd := &cobra.Command{
-		Use:               "edit NODE",
-		Short:             "[EXPERIMENTAL] Edit node(s).",
-		Long:              `[EXPERIMENTAL] Edit node(s).`,
+		Use:   "edit NODE",
+		Short: "[EXPERIMENTAL] Edit node(s).",
+		Long: `[EXPERIMENTAL] Edit node(s).
+
+k3s node labels are applied to the running node.
+Other changes (ports, memory limit, environment variables, runtime labels) are applied by recreating the node container, preserving its volumes (and thus its state).
+The output shows which of the changes require the recreation.`,
 		Args:              cobra.ExactArgs(1),
 		Aliases:           []string{"update"},
 		ValidArgsFunction: util.ValidArgsAvailableNodes,
@@ -60,7 +67,11 @@ func NewCmdNodeEdit() *cobra.Command {
 	// add subcommands
 
 	// add flags
-	cmd.Flags().StringArray("port-add", nil, "[EXPERIMENTAL] (serverlb only!) Map ports from the node container to the host (Format: `[HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]`)\n - Example: `k3d node edit k3d-mycluster-serverlb --port-add 8080:80`")
+	cmd.Flags().StringArray("port-add", nil, "[EXPERIMENTAL] Map ports from the node container to the host (Format: `[HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]`)\n - Example: `k3d node edit k3d-mycluster-serverlb --port-add 8080:80`")
+	cmd.Flags().String("memory", "", "Memory limit imposed on the node (Format: `MEMORY`, e.g. 2g) [From docker]")
+	cmd.Flags().StringArrayP("env", "e", nil, "Set an environment variable in the node, replacing an existing value (Format: `KEY=VALUE`, can be used multiple times)")
+	cmd.Flags().StringArray("runtime-label", nil, "Set a container runtime label on the node (Format: `KEY=VALUE`, can be used multiple times)")
+	cmd.Flags().StringArray("k3s-node-label", nil, "Set a Kubernetes label on the node without recreating it (Format: `KEY=VALUE`, can be used multiple times)")
 
 	// done
 	return cmd
@@ -79,10 +90,6 @@ func parseEditNodeCmd(cmd *cobra.Command, args []string) (*k3d.Node, *k3d.Node)
 		return nil, nil
 	}
 
-	if existingNode.Role != k3d.LoadBalancerRole {
-		l.Log().Fatalln("Currently only the loadbalancer can be updated!")
-	}
-
 	changeset := &k3d.Node{}
 
 	/*
@@ -109,5 +116,46 @@ func parseEditNodeCmd(cmd *cobra.Command, args []string) (*k3d.Node, *k3d.Node)
 		}
 	}
 
+	/*
+	 * --memory
+	 */
+	if changeset.Memory, err = cmd.Flags().GetString("memory"); err != nil {
+		l.Log().Fatalln(err)
+	}
+
+	/*
+	 * --env
+	 */
+	if changeset.Env, err = cmd.Flags().GetStringArray("env"); err != nil {
+		l.Log().Fatalln(err)
+	}
+
+	/*
+	 * --runtime-label, --k3s-node-label
+	 */
+	if changeset.RuntimeLabels, err = parseEditNodeLabels(cmd, "runtime-label"); err != nil {
+		l.Log().Fatalln(err)
+	}
+	if changeset.K3sNodeLabels, err = parseEditNodeLabels(cmd, "k3s-node-label"); err != nil {
+		l.Log().Fatalln(err)
+	}
+
 	return existingNode, changeset
 }
+
+// parseEditNodeLabels parses the KEY=VALUE labels of the given flag
+func parseEditNodeLabels(cmd *cobra.Command, flag string) (map[string]string, error) {
+	labelFlags, err := cmd.Flags().GetStringArray(flag)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{}
+	for _, labelFlag := range labelFlags {
+		kv := strings.SplitN(labelFlag, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid --%s '%s': must be KEY=VALUE", flag, labelFlag)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package node
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type nodeExecFlags struct {
+	stdin bool
+	tty   bool
+	env   []string
+}
+
+// NewCmdNodeExec returns a new cobra command
+func NewCmdNodeExec() *cobra.Command {
+
+	flags := nodeExecFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:     "exec NODE [-- COMMAND [ARG...]]",
+		Aliases: []string{"shell"},
+		Short:   "Execute a command in a node (default: an interactive shell)",
+		Long: `Execute a command in a node, e.g. to debug k3s in it, without having to look up the node's container.
+
+Without a command, an interactive shell ('sh') is started.
+A TTY is allocated by default if both stdin and stdout are terminals.`,
+		Example: `  k3d node exec k3d-mycluster-server-0
+  k3d node exec k3d-mycluster-server-0 -- crictl ps
+  k3d node exec k3d-mycluster-agent-0 -- cat /var/lib/rancher/k3s/agent/etc/containerd/config.toml`,
+		ValidArgsFunction: util.ValidArgsAvailableNodes,
+		Args:              cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// os.Exit skips deferred functions, so the exec (and restoring the terminal) happens in a separate function
+			if exitCode := execInNode(cmd, args, flags); exitCode != 0 {
+				os.Exit(exitCode)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().BoolVarP(&flags.stdin, "stdin", "i", true, "Attach stdin to the command")
+	cmd.Flags().BoolVarP(&flags.tty, "tty", "t", false, "Allocate a TTY (default: if stdin and stdout are terminals)")
+	cmd.Flags().StringArrayVarP(&flags.env, "env", "e", nil, "Set an environment variable for the command (Format: `KEY=VALUE`)")
+
+	// done
+	return cmd
+}
+
+// execInNode runs the command given after the node name in the node, connected to the terminal, and returns its exit code
+func execInNode(cmd *cobra.Command, args []string, flags nodeExecFlags) int {
+	node, err := client.NodeGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Node{Name: args[0]})
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+
+	command := args[1:]
+	if len(command) == 0 {
+		command = []string{"sh"}
+	}
+
+	stdinIsTerminal := term.IsTerminal(int(os.Stdin.Fd()))
+	tty := flags.tty
+	if !cmd.Flags().Changed("tty") {
+		tty = stdinIsTerminal && term.IsTerminal(int(os.Stdout.Fd()))
+	}
+
+	execOpts := &runtimeTypes.ExecOpts{
+		TTY:    tty,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Env:    flags.env,
+	}
+	if flags.stdin {
+		execOpts.Stdin = os.Stdin
+	}
+
+	if tty {
+		if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			execOpts.Width, execOpts.Height = uint(width), uint(height)
+		}
+		if flags.stdin && stdinIsTerminal {
+			oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+			if err != nil {
+				l.Log().Fatalf("Failed to put the terminal into raw mode: %v", err)
+			}
+			defer func() {
+				if err := term.Restore(int(os.Stdin.Fd()), oldState); err != nil {
+					l.Log().Errorf("Failed to restore the terminal: %v", err)
+				}
+			}()
+		}
+	}
+
+	exitCode, err := runtimes.SelectedRuntime.ExecInNodeAttached(cmd.Context(), node, command, execOpts)
+	if err != nil {
+		l.Log().Errorln(err)
+		return 1
+	}
+	return exitCode
+}
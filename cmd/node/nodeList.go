@@ -82,14 +82,16 @@ func NewCmdNodeList() *cobra.Command {
 				headers = &[]string{"NAME", "ROLE", "CLUSTER", "STATUS"}
 			}
 
-			util.PrintNodes(existingNodes, nodeListFlags.output,
+			if err := util.PrintNodes(existingNodes, nodeListFlags.output,
 				headers, util.NodePrinterFunc(func(tabwriter *tabwriter.Writer, node *k3d.Node) {
 					fmt.Fprintf(tabwriter, "%s\t%s\t%s\t%s\n",
 						strings.TrimPrefix(node.Name, "/"),
 						string(node.Role),
 						node.RuntimeLabels[k3d.LabelClusterName],
-						node.State.Status)
-				}))
+						util.Colorize(util.StatusColor(node.State.Status), node.State.Status))
+				})); err != nil {
+				l.Log().Fatalln(err)
+			}
 		},
 	}
 	// add flags
@@ -0,0 +1,91 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package node
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+type nodeLogsFlags struct {
+	follow     bool
+	tail       string
+	since      time.Duration
+	timestamps bool
+}
+
+// NewCmdNodeLogs returns a new cobra command
+func NewCmdNodeLogs() *cobra.Command {
+
+	flags := nodeLogsFlags{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "logs NODE",
+		Short: "Show the logs of a node",
+		Long: `Show the logs of a node (i.e. of k3s or of the loadbalancer/registry running in it), also if the node stopped.
+
+Use '--follow' to keep streaming new log lines until interrupted.`,
+		Example: `  k3d node logs k3d-mycluster-server-0 --tail 100
+  k3d node logs k3d-mycluster-agent-0 -f --since 10m`,
+		ValidArgsFunction: util.ValidArgsAvailableNodes,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			node, err := client.NodeGet(cmd.Context(), runtimes.SelectedRuntime, &k3d.Node{Name: args[0]})
+			if err != nil {
+				l.Log().Fatalln(err)
+			}
+
+			var since time.Time
+			if flags.since > 0 {
+				since = time.Now().Add(-flags.since)
+			}
+
+			logsOpts := &runtimeTypes.NodeLogsOpts{
+				Follow:     flags.follow,
+				Tail:       flags.tail,
+				Timestamps: flags.timestamps,
+			}
+			if err := runtimes.SelectedRuntime.StreamNodeLogs(cmd.Context(), node, since, logsOpts, os.Stdout, os.Stderr); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().BoolVarP(&flags.follow, "follow", "f", false, "Follow the log output")
+	cmd.Flags().StringVar(&flags.tail, "tail", "all", "Number of lines to show from the end of the logs")
+	cmd.Flags().DurationVar(&flags.since, "since", 0, "Only show logs newer than this duration (e.g. 10m)")
+	cmd.Flags().BoolVar(&flags.timestamps, "log-timestamps", false, "Prefix each log line with its timestamp (as recorded by the runtime)")
+
+	// done
+	return cmd
+}
@@ -37,7 +37,7 @@ func NewCmdNodeStart() *cobra.Command {
 		Use:               "start NODE", // TODO: startNode: allow one or more names or --all
 		Short:             "Start an existing k3d node",
 		Long:              `Start an existing k3d node.`,
-		ValidArgsFunction: util.ValidArgsAvailableNodes,
+		ValidArgsFunction: util.ValidArgsStoppedNodes,
 		Run: func(cmd *cobra.Command, args []string) {
 			node := parseStartNodeCmd(cmd, args)
 			if err := runtimes.SelectedRuntime.StartNode(cmd.Context(), node); err != nil {
@@ -38,7 +38,7 @@ func NewCmdNodeStop() *cobra.Command {
 		Use:               "stop NAME", // TODO: stopNode: allow one or more names or --all",
 		Short:             "Stop an existing k3d node",
 		Long:              `Stop an existing k3d node.`,
-		ValidArgsFunction: util.ValidArgsAvailableNodes,
+		ValidArgsFunction: util.ValidArgsRunningNodes,
 		Run: func(cmd *cobra.Command, args []string) {
 			node := parseStopNodeCmd(cmd, args)
 			if err := runtimes.SelectedRuntime.StopNode(cmd.Context(), node); err != nil {
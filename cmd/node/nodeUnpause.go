@@ -0,0 +1,53 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package node
+
+import (
+	"github.com/rancher/k3d/v5/cmd/util"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdNodeUnpause returns a new cobra command
+func NewCmdNodeUnpause() *cobra.Command {
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:               "unpause NODE",
+		Short:             "Unpause a paused k3d node",
+		Long:              `Unpause a k3d node, which was paused using 'k3d node pause', so that its processes resume.`,
+		ValidArgsFunction: util.ValidArgsPausedNodes,
+		Args:              cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			node := &k3d.Node{Name: args[0]}
+			if err := runtimes.SelectedRuntime.UnpauseNode(cmd.Context(), node); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// done
+	return cmd
+}
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package registry
 
 import (
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/spf13/cobra"
 )
@@ -33,11 +34,11 @@ func NewCmdRegistry() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "registry",
 		Aliases: []string{"registries", "reg"},
-		Short:   "Manage registry/registries",
-		Long:    `Manage registry/registries`,
+		Short:   i18n.T("cmd.registry.short"),
+		Long:    i18n.T("cmd.registry.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := cmd.Help(); err != nil {
-				l.Log().Errorln("Couldn't get help text")
+				l.Log().Errorln(i18n.T("err.help"))
 				l.Log().Fatalln(err)
 			}
 		},
@@ -28,6 +28,7 @@ import (
 
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 
 	"github.com/rancher/k3d/v5/pkg/client"
 
@@ -81,7 +82,7 @@ func NewCmdRegistryCreate() *cobra.Command {
 			if err := client.RegistryConnectClusters(cmd.Context(), runtimes.SelectedRuntime, regNode, clusters); err != nil {
 				l.Log().Errorln(err)
 			}
-			l.Log().Infof("Successfully created registry '%s'", reg.Host)
+			l.Log().Infoln(cliutil.Success(fmt.Sprintf("Successfully created registry '%s'", reg.Host)))
 			regString := fmt.Sprintf("%s:%s", reg.Host, reg.ExposureOpts.Binding.HostPort)
 			if !flags.NoHelp {
 				fmt.Println(fmt.Sprintf(helptext, regString, regString, regString, regString))
@@ -131,11 +132,14 @@ func parseCreateRegistryCmd(cmd *cobra.Command, args []string, flags *regCreateF
 		l.Log().Errorln("Failed to parse registry port")
 		l.Log().Fatalln(err)
 	}
+	if exposePort.Binding.HostIP == "" {
+		exposePort.Binding.HostIP = util.DefaultBindAddress()
+	}
 
 	// set the name for the registry node
 	registryName := ""
 	if len(args) > 0 {
-		registryName = fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, args[0])
+		registryName = fmt.Sprintf("%s-%s", k3d.ObjectNamePrefix, args[0])
 	}
 
 	return &k3d.Registry{Host: registryName, Image: flags.Image, ExposureOpts: *exposePort, Network: flags.Network}, clusters
@@ -85,7 +85,7 @@ func NewCmdRegistryList() *cobra.Command {
 				headers = &[]string{"NAME", "ROLE", "CLUSTER", "STATUS"}
 			}
 
-			util.PrintNodes(existingNodes, registryListFlags.output,
+			if err := util.PrintNodes(existingNodes, registryListFlags.output,
 				headers, util.NodePrinterFunc(func(tabwriter *tabwriter.Writer, node *k3d.Node) {
 					cluster := "*"
 					if _, ok := node.RuntimeLabels[k3d.LabelClusterName]; ok {
@@ -95,10 +95,12 @@ func NewCmdRegistryList() *cobra.Command {
 						strings.TrimPrefix(node.Name, "/"),
 						string(node.Role),
 						cluster,
-						node.State.Status,
+						util.Colorize(util.StatusColor(node.State.Status), node.State.Status),
 					)
 				}),
-			)
+			); err != nil {
+				l.Log().Fatalln(err)
+			}
 		},
 	}
 
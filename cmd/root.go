@@ -28,23 +28,39 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
 	"sort"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/liggitt/tabwriter"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/rancher/k3d/v5/cmd/api"
+	"github.com/rancher/k3d/v5/cmd/bootstrap"
 	"github.com/rancher/k3d/v5/cmd/cluster"
+	"github.com/rancher/k3d/v5/cmd/compose"
 	cfg "github.com/rancher/k3d/v5/cmd/config"
 	"github.com/rancher/k3d/v5/cmd/debug"
+	"github.com/rancher/k3d/v5/cmd/env"
 	"github.com/rancher/k3d/v5/cmd/image"
 	"github.com/rancher/k3d/v5/cmd/kubeconfig"
 	"github.com/rancher/k3d/v5/cmd/node"
 	"github.com/rancher/k3d/v5/cmd/registry"
+	"github.com/rancher/k3d/v5/cmd/syncmd"
 	cliutil "github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/cmd/util/otlp"
+	"github.com/rancher/k3d/v5/cmd/watch"
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/i18n"
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
+	"github.com/rancher/k3d/v5/pkg/tracing"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/version"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/writer"
@@ -55,6 +71,10 @@ type RootFlags struct {
 	debugLogging       bool
 	traceLogging       bool
 	timestampedLogging bool
+	noColor            bool
+	progress           string
+	progressFile       string
+	namePrefix         string
 	version            bool
 }
 
@@ -65,11 +85,8 @@ func NewCmdK3d() *cobra.Command {
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd := &cobra.Command{
 		Use:   "k3d",
-		Short: "https://k3d.io/ -> Run k3s in Docker!",
-		Long: `https://k3d.io/
-k3d is a wrapper CLI that helps you to easily create k3s clusters inside docker.
-Nodes of a k3d cluster are docker containers running a k3s image.
-All Nodes of a k3d cluster are part of the same docker network.`,
+		Short: i18n.T("cmd.root.short"),
+		Long:  i18n.T("cmd.root.long"),
 		Run: func(cmd *cobra.Command, args []string) {
 			if flags.version {
 				printVersion()
@@ -84,6 +101,10 @@ All Nodes of a k3d cluster are part of the same docker network.`,
 	rootCmd.PersistentFlags().BoolVar(&flags.debugLogging, "verbose", false, "Enable verbose output (debug logging)")
 	rootCmd.PersistentFlags().BoolVar(&flags.traceLogging, "trace", false, "Enable super verbose output (trace logging)")
 	rootCmd.PersistentFlags().BoolVar(&flags.timestampedLogging, "timestamps", false, "Enable Log timestamps")
+	rootCmd.PersistentFlags().BoolVar(&flags.noColor, "no-color", false, "Disable colored output (also disabled if NO_COLOR is set or stdout is not a terminal)")
+	rootCmd.PersistentFlags().StringVar(&flags.progress, "progress", "", "Emit machine-readable progress events of long-running operations (cluster create, image import) on stderr (one of: json)")
+	rootCmd.PersistentFlags().StringVar(&flags.namePrefix, "name-prefix", "", fmt.Sprintf("Name prefix of the containers, networks, volumes and kubeconfig contexts created and looked up by k3d, e.g. to avoid name collisions with other tools (default: $%s or '%s')", k3d.K3dEnvNamePrefix, k3d.DefaultObjectNamePrefix))
+	rootCmd.PersistentFlags().StringVar(&flags.progressFile, "progress-file", "", "Write machine-readable progress events (JSON, one per line) to the given file instead of stderr (implies --progress json)")
 
 	// add local flags
 	rootCmd.Flags().BoolVar(&flags.version, "version", false, "Show k3d and default k3s version")
@@ -99,6 +120,15 @@ All Nodes of a k3d cluster are part of the same docker network.`,
 		cfg.NewCmdConfig(),
 		registry.NewCmdRegistry(),
 		debug.NewCmdDebug(),
+		syncmd.NewCmdSync(),
+		bootstrap.NewCmdBootstrap(),
+		watch.NewCmdWatch(),
+		compose.NewCmdCompose(),
+		env.NewCmdEnv(),
+		api.NewCmdAPI(),
+		api.NewCmdAPIInfo(),
+		cluster.NewCmdHistory(),
+		cluster.NewCmdRedo(),
 		&cobra.Command{
 			Use:   "runtime-info",
 			Short: "Show runtime information",
@@ -117,8 +147,11 @@ All Nodes of a k3d cluster are part of the same docker network.`,
 		},
 	)
 
+	// deprecated flags
+	cliutil.ApplyFlagAliases(rootCmd, cliutil.FlagAliases)
+
 	// Init
-	cobra.OnInitialize(initLogging, initRuntime)
+	cobra.OnInitialize(initLogging, cliutil.WarnDeprecatedFlags, initProgress, initNamePrefix, initRuntime)
 
 	return rootCmd
 }
@@ -126,12 +159,38 @@ All Nodes of a k3d cluster are part of the same docker network.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	// the first interrupt cancels the context of the command, so that running operations stop (and roll back) gracefully,
+	// while a second interrupt terminates k3d immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	cmd := NewCmdK3d()
+
+	// tracing is configured via the environment only, so that the whole command run can be covered by a span
+	tracerProvider, err := otlp.Init(version.GetVersion())
+	if err != nil {
+		l.Log().Warnf("Failed to initialize tracing: %v", err)
+	}
+	spanName := cmd.Name()
+	if c, _, err := cmd.Find(os.Args[1:]); err == nil {
+		spanName = c.CommandPath()
+	}
+	ctx, span := tracing.Start(ctx, spanName)
+	// make sure that spans are exported even if a command exits fatally
+	logrus.RegisterExitHandler(func() {
+		span.End(fmt.Errorf("%s exited with an error", spanName))
+		flushTracing(tracerProvider)
+	})
+
 	if len(os.Args) > 1 {
 		parts := os.Args[1:]
 		// Check if it's a built-in command, else try to execute it as a plugin
 		if _, _, err := cmd.Find(parts); err != nil {
-			pluginFound, err := cliutil.HandlePlugin(context.Background(), parts)
+			pluginFound, err := cliutil.HandlePlugin(ctx, parts)
 			if err != nil {
 				l.Log().Errorf("Failed to execute plugin '%+v'", parts)
 				l.Log().Fatalln(err)
@@ -140,12 +199,62 @@ func Execute() {
 			}
 		}
 	}
-	if err := cmd.Execute(); err != nil {
+	err = cmd.ExecuteContext(ctx)
+	span.End(err)
+	flushTracing(tracerProvider)
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+}
+
+// flushTracing exports the recorded spans (if tracing is enabled)
+func flushTracing(tracerProvider *otlp.TracerProvider) {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracerProvider.Flush(ctx); err != nil {
+		l.Log().Warnf("Failed to export traces: %v", err)
+	}
+}
+
+// initNamePrefix sets and validates the name prefix of the k3d objects
+func initNamePrefix() {
+	if flags.namePrefix != "" {
+		k3d.ObjectNamePrefix = flags.namePrefix
+	}
+	if err := client.CheckNamePrefix(k3d.ObjectNamePrefix); err != nil {
 		l.Log().Fatalln(err)
 	}
+	if k3d.ObjectNamePrefix != k3d.DefaultObjectNamePrefix {
+		l.Log().Debugf("Using name prefix '%s'", k3d.ObjectNamePrefix)
+	}
 }
 
 // initLogging initializes the logger
+func initProgress() {
+	switch flags.progress {
+	case "":
+		if flags.progressFile == "" {
+			return
+		}
+	case "json":
+	default:
+		l.Log().Fatalf("Unknown progress format '%s' (supported: json)", flags.progress)
+	}
+
+	if flags.progressFile == "" {
+		progress.SetOutput(os.Stderr)
+		return
+	}
+	f, err := os.OpenFile(flags.progressFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.Log().Fatalf("Failed to open progress file '%s': %v", flags.progressFile, err)
+	}
+	progress.SetOutput(f)
+}
+
 func initLogging() {
 	if flags.traceLogging {
 		l.Log().SetLevel(logrus.TraceLevel)
@@ -192,7 +301,11 @@ func initLogging() {
 		formatter.FullTimestamp = true
 	}
 
-	l.Log().SetFormatter(formatter)
+	if cliutil.InitColor(flags.noColor) {
+		l.Log().SetFormatter(formatter)
+	} else {
+		l.Log().SetFormatter(&l.NoColorFormatter{Formatter: formatter})
+	}
 
 }
 
@@ -210,8 +323,8 @@ func initRuntime() {
 func NewCmdVersion() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "version",
-		Short: "Show k3d and default k3s version",
-		Long:  "Show k3d and default k3s version",
+		Short: i18n.T("cmd.version.short"),
+		Long:  i18n.T("cmd.version.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			printVersion()
 		},
@@ -260,6 +373,7 @@ func NewCmdVersionLs() *cobra.Command {
 		format        string
 		sortMode      string
 		limit         int
+		channels      bool
 	}
 
 	flags := Flags{}
@@ -285,6 +399,14 @@ func NewCmdVersionLs() *cobra.Command {
 				sortMode = m
 			}
 
+			if flags.channels {
+				if args[0] != "k3s" {
+					l.Log().Fatalln("--channels is only supported for k3s")
+				}
+				printK3sChannels(string(format) == string(VersionLsOutputFormatRepo))
+				return
+			}
+
 			urlTpl := "https://registry.hub.docker.com/v1/repositories/%s/tags"
 			org := "rancher"
 			repo := fmt.Sprintf("%s/%s", org, args[0])
@@ -360,10 +482,29 @@ func NewCmdVersionLs() *cobra.Command {
 	cmd.Flags().StringVarP(&flags.format, "format", "f", string(VersionLsOutputFormatRaw), "Output Format")
 	cmd.Flags().StringVarP(&flags.sortMode, "sort", "s", string(VersionLsSortDesc), "Sort Mode (asc | desc | off)")
 	cmd.Flags().IntVarP(&flags.limit, "limit", "l", 0, "Limit number of tags in output (0 = unlimited)")
+	cmd.Flags().BoolVar(&flags.channels, "channels", false, "List the k3s release channels (usable as '--image rancher/k3s:+CHANNEL') with their latest version instead of the tags")
 
 	return cmd
 }
 
+// printK3sChannels prints the k3s release channels along with their latest version (or image)
+func printK3sChannels(asImage bool) {
+	channels, err := client.K3sChannelsGet()
+	if err != nil {
+		l.Log().Fatalln(err)
+	}
+	tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
+	defer tabwriter.Flush()
+	fmt.Fprintln(tabwriter, "CHANNEL\tVERSION")
+	for _, channel := range channels {
+		v := version.K3sChannelVersion(channel)
+		if asImage {
+			v = fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, v)
+		}
+		fmt.Fprintf(tabwriter, "%s\t%s\n", channel.Name, v)
+	}
+}
+
 // NewCmdCompletion creates a new completion command
 func NewCmdCompletion(rootCmd *cobra.Command) *cobra.Command {
 
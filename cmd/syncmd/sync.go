@@ -0,0 +1,103 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package syncmd
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdSync returns a new cobra command
+func NewCmdSync() *cobra.Command {
+
+	syncOpts := k3d.NodeSyncOpts{}
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "sync SRC NODE:DEST",
+		Short: "Keep a host directory synchronized into a node",
+		Long: `Keep a host directory synchronized into a node.
+
+Files are copied via the container runtime API, so this also works when bind mounts are not possible (e.g. remote docker daemons).
+Use this e.g. to sync a directory into a path that's used as a hostPath volume by your workloads.
+By default, k3d keeps watching for changes (polling) until interrupted: files that fail to copy are logged and retried in the next pass.
+Use '--once' to only do a single sync pass.`,
+		Example: `  k3d sync ./src k3d-mycluster-agent-0:/data/src
+  k3d sync --delete --exclude .git --exclude '*.swp' . k3d-mycluster-server-0:/workspace`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			return util.ValidArgsAvailableNodes(cmd, args, toComplete)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			src := args[0]
+			nodeRef, dest := parseSyncTarget(args[1])
+
+			node, err := runtimes.SelectedRuntime.GetNode(cmd.Context(), nodeRef)
+			if err != nil {
+				l.Log().Fatalf("Failed to get node '%s': %v", nodeRef.Name, err)
+			}
+
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			if err := client.NodeSync(ctx, runtimes.SelectedRuntime, node, src, dest, syncOpts); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	/*********
+	 * Flags *
+	 *********/
+	cmd.Flags().DurationVar(&syncOpts.Interval, "interval", k3d.DefaultNodeSyncInterval, "Polling interval for detecting changes on the host")
+	cmd.Flags().BoolVar(&syncOpts.Once, "once", false, "Only sync once and exit instead of watching for changes")
+	cmd.Flags().BoolVar(&syncOpts.Delete, "delete", false, "Delete files in the node that were removed from the host directory")
+	cmd.Flags().StringArrayVar(&syncOpts.Excludes, "exclude", nil, "Exclude files matching the given glob pattern (matched against the relative path and the file name)")
+
+	// done
+	return cmd
+}
+
+// parseSyncTarget splits the NODE:DEST argument into its parts
+func parseSyncTarget(target string) (*k3d.Node, string) {
+	split := strings.SplitN(target, ":", 2)
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		l.Log().Fatalf("Invalid sync target '%s': Format must be NODE:DEST", target)
+	}
+	if !strings.HasPrefix(split[1], "/") {
+		l.Log().Fatalf("Invalid sync target '%s': DEST must be an absolute path", target)
+	}
+	return &k3d.Node{Name: split[0]}, split[1]
+}
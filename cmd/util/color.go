@@ -0,0 +1,95 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// SGR color codes used by the output helpers.
+// They all have the same length, so table columns stay aligned as long as either all or none of the cells of a column are colorized.
+const (
+	ColorDefault = "39"
+	ColorRed     = "31"
+	ColorGreen   = "32"
+	ColorYellow  = "33"
+)
+
+var colorEnabled = false
+
+// InitColor decides whether human-readable output gets colorized:
+// not if disabled via flag or the NO_COLOR environment variable (https://no-color.org/), or if stdout is not a terminal (e.g. piped into a script).
+func InitColor(noColor bool) bool {
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+	colorEnabled = !noColor && !noColorEnv && term.IsTerminal(int(os.Stdout.Fd()))
+	return colorEnabled
+}
+
+// ColorEnabled returns whether human-readable output gets colorized
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
+// Colorize wraps the text in the given color (if colors are enabled)
+func Colorize(color string, text string) string {
+	if !colorEnabled {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", color, text)
+}
+
+// Success colorizes a text signaling success
+func Success(text string) string {
+	return Colorize(ColorGreen, text)
+}
+
+// StatusColor returns the color matching a node/container status
+func StatusColor(status string) string {
+	switch strings.ToLower(status) {
+	case "running":
+		return ColorGreen
+	case "created", "restarting", "paused":
+		return ColorYellow
+	case "exited", "dead", "removing":
+		return ColorRed
+	default:
+		return ColorDefault
+	}
+}
+
+// RunningColor returns the color matching a running/total count
+func RunningColor(running int, total int) string {
+	switch {
+	case total == 0:
+		return ColorDefault
+	case running == total:
+		return ColorGreen
+	case running == 0:
+		return ColorRed
+	default:
+		return ColorYellow
+	}
+}
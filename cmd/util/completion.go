@@ -22,7 +22,6 @@ THE SOFTWARE.
 package util
 
 import (
-	"context"
 	"strings"
 
 	k3dcluster "github.com/rancher/k3d/v5/pkg/client"
@@ -34,79 +33,126 @@ import (
 
 // ValidArgsAvailableClusters is used for shell completion: proposes the list of existing clusters
 func ValidArgsAvailableClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeClusters(cmd, args, toComplete, func(*k3d.Cluster) bool { return true })
+}
 
-	var completions []string
-	var clusters []*k3d.Cluster
-	clusters, err := k3dcluster.ClusterList(context.Background(), runtimes.SelectedRuntime)
-	if err != nil {
-		l.Log().Errorln("Failed to get list of clusters for shell completion")
-		return nil, cobra.ShellCompDirectiveError
-	}
-
-clusterLoop:
-	for _, cluster := range clusters {
-		for _, arg := range args {
-			if arg == cluster.Name { // only clusters, that are not in the args yet
-				continue clusterLoop
+// ValidArgsRunningClusters is used for shell completion: proposes the list of existing clusters with at least one running node (e.g. to stop them)
+func ValidArgsRunningClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeClusters(cmd, args, toComplete, func(cluster *k3d.Cluster) bool {
+		for _, node := range cluster.Nodes {
+			if node.State.Running {
+				return true
 			}
 		}
-		if strings.HasPrefix(cluster.Name, toComplete) {
-			completions = append(completions, cluster.Name)
+		return false
+	})
+}
+
+// ValidArgsStoppedClusters is used for shell completion: proposes the list of existing clusters with at least one stopped node (e.g. to start them)
+func ValidArgsStoppedClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeClusters(cmd, args, toComplete, func(cluster *k3d.Cluster) bool {
+		for _, node := range cluster.Nodes {
+			if !node.State.Running {
+				return true
+			}
 		}
-	}
-	return completions, cobra.ShellCompDirectiveDefault
+		return false
+	})
 }
 
 // ValidArgsAvailableNodes is used for shell completion: proposes the list of existing nodes
 func ValidArgsAvailableNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(*k3d.Node) bool { return true })
+}
 
-	var completions []string
-	var nodes []*k3d.Node
-	nodes, err := k3dcluster.NodeList(context.Background(), runtimes.SelectedRuntime)
+// ValidArgsRunningNodes is used for shell completion: proposes the list of running nodes (e.g. to stop them)
+func ValidArgsRunningNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(node *k3d.Node) bool { return node.State.Running })
+}
+
+// ValidArgsStoppedNodes is used for shell completion: proposes the list of existing, but stopped nodes (e.g. to start them)
+func ValidArgsStoppedNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(node *k3d.Node) bool { return !node.State.Running })
+}
+
+// ValidArgsUnpausedNodes is used for shell completion: proposes the list of running nodes, which are not paused (e.g. to pause them)
+func ValidArgsUnpausedNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(node *k3d.Node) bool {
+		return node.State.Running && node.State.Status != k3d.NodeStatusPaused
+	})
+}
+
+// ValidArgsPausedNodes is used for shell completion: proposes the list of paused nodes (e.g. to unpause them)
+func ValidArgsPausedNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(node *k3d.Node) bool { return node.State.Status == k3d.NodeStatusPaused })
+}
+
+// ValidArgsAvailableRegistries is used for shell completions: proposes the list of existing registries
+func ValidArgsAvailableRegistries(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeNodes(cmd, args, toComplete, func(node *k3d.Node) bool { return node.Role == k3d.RegistryRole })
+}
+
+// completeClusters proposes the names of the clusters queried from the runtime, which match the filter
+func completeClusters(cmd *cobra.Command, args []string, toComplete string, filter func(*k3d.Cluster) bool) ([]string, cobra.ShellCompDirective) {
+	clusters, err := k3dcluster.ClusterList(cmd.Context(), runtimes.SelectedRuntime)
 	if err != nil {
-		l.Log().Errorln("Failed to get list of nodes for shell completion")
+		l.Log().Errorln("Failed to get list of clusters for shell completion")
 		return nil, cobra.ShellCompDirectiveError
 	}
 
-nodeLoop:
-	for _, node := range nodes {
-		for _, arg := range args {
-			if arg == node.Name { // only nodes, that are not in the args yet
-				continue nodeLoop
-			}
-		}
-		if strings.HasPrefix(node.Name, toComplete) {
-			completions = append(completions, node.Name)
+	names := []string{}
+	for _, cluster := range clusters {
+		if filter(cluster) {
+			names = append(names, cluster.Name)
 		}
 	}
-	return completions, cobra.ShellCompDirectiveDefault
+	return completeNames(args, toComplete, names), cobra.ShellCompDirectiveNoFileComp
 }
 
-// ValidArgsAvailableRegistries is used for shell completions: proposes the list of existing registries
-func ValidArgsAvailableRegistries(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-
-	var completions []string
-	var nodes []*k3d.Node
-	nodes, err := k3dcluster.NodeList(context.Background(), runtimes.SelectedRuntime)
+// completeNodes proposes the names of the nodes queried from the runtime, which match the filter
+func completeNodes(cmd *cobra.Command, args []string, toComplete string, filter func(*k3d.Node) bool) ([]string, cobra.ShellCompDirective) {
+	nodes, err := k3dcluster.NodeList(cmd.Context(), runtimes.SelectedRuntime)
 	if err != nil {
 		l.Log().Errorln("Failed to get list of nodes for shell completion")
 		return nil, cobra.ShellCompDirectiveError
 	}
 
-	nodes = k3dcluster.NodeFilterByRoles(nodes, []k3d.Role{k3d.RegistryRole}, []k3d.Role{})
-
-nodeLoop:
+	names := []string{}
 	for _, node := range nodes {
+		if filter(node) {
+			names = append(names, node.Name)
+		}
+	}
+	return completeNames(args, toComplete, names), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNames returns the names starting with toComplete, which are not in the args yet
+func completeNames(args []string, toComplete string, names []string) []string {
+	var completions []string
+nameLoop:
+	for _, name := range names {
 		for _, arg := range args {
-			if arg == node.Name { // only nodes, that are not in the args yet
-				continue nodeLoop
+			if arg == name {
+				continue nameLoop
 			}
 		}
-		if strings.HasPrefix(node.Name, toComplete) {
-			completions = append(completions, node.Name)
+		if strings.HasPrefix(name, toComplete) {
+			completions = append(completions, name)
+		}
+	}
+	return completions
+}
+
+// ValidArgsCheckProfiles is used for shell completion: proposes the list of available preflight check profiles
+func ValidArgsCheckProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+
+	var completions []string
+	for _, profile := range k3d.CheckProfileNames() {
+		if strings.HasPrefix(profile, toComplete) {
+			completions = append(completions, profile)
 		}
 	}
-	return completions, cobra.ShellCompDirectiveDefault
+	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
 // ValidArgsNodeRoles is used for shell completion: proposes the list of possible node roles
@@ -120,5 +166,5 @@ func ValidArgsNodeRoles(cmd *cobra.Command, args []string, toComplete string) ([
 			completions = append(completions, role)
 		}
 	}
-	return completions, cobra.ShellCompDirectiveDefault
+	return completions, cobra.ShellCompDirectiveNoFileComp
 }
@@ -46,24 +46,24 @@ func InitViperWithConfigFile(cfgViper *viper.Viper, configFile string) error {
 	if configFile != "" {
 
 		if _, err := os.Stat(configFile); err != nil {
-			l.Log().Fatalf("Failed to stat config file %s: %+v", configFile, err)
+			return fmt.Errorf("Failed to stat config file %s: %w", configFile, err)
 		}
 
 		// create temporary file to expand environment variables in the config without writing that back to the original file
 		// we're doing it here, because this happens just before absolutely all other processing
 		tmpfile, err := os.CreateTemp(os.TempDir(), fmt.Sprintf("k3d-config-tmp-%s", filepath.Base(configFile)))
 		if err != nil {
-			l.Log().Fatalf("error creating temp copy of configfile %s for variable expansion: %v", configFile, err)
+			return fmt.Errorf("error creating temp copy of configfile %s for variable expansion: %w", configFile, err)
 		}
 		defer tmpfile.Close()
 
 		originalcontent, err := os.ReadFile(configFile)
 		if err != nil {
-			l.Log().Fatalf("error reading config file %s: %v", configFile, err)
+			return fmt.Errorf("error reading config file %s: %w", configFile, err)
 		}
 		expandedcontent := os.ExpandEnv(string(originalcontent))
 		if _, err := tmpfile.WriteString(expandedcontent); err != nil {
-			l.Log().Fatalf("error writing expanded config file contents to temp file %s: %v", tmpfile.Name(), err)
+			return fmt.Errorf("error writing expanded config file contents to temp file %s: %w", tmpfile.Name(), err)
 		}
 
 		// use temp file with expanded variables
@@ -72,19 +72,19 @@ func InitViperWithConfigFile(cfgViper *viper.Viper, configFile string) error {
 		// try to read config into memory (viper map structure)
 		if err := cfgViper.ReadInConfig(); err != nil {
 			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				l.Log().Fatalf("Config file %s not found: %+v", configFile, err)
+				return fmt.Errorf("Config file %s not found: %w", configFile, err)
 			}
 			// config file found but some other error happened
-			l.Log().Fatalf("Failed to read config file %s: %+v", configFile, err)
+			return fmt.Errorf("Failed to read config file %s: %w", configFile, err)
 		}
 
 		schema, err := config.GetSchemaByVersion(cfgViper.GetString("apiVersion"))
 		if err != nil {
-			l.Log().Fatalf("Cannot validate config file %s: %+v", configFile, err)
+			return fmt.Errorf("Cannot validate config file %s: %w", configFile, err)
 		}
 
 		if err := config.ValidateSchemaFile(tmpfile.Name(), schema); err != nil {
-			l.Log().Fatalf("Schema Validation failed for config file %s: %+v", configFile, err)
+			return fmt.Errorf("Schema Validation failed for config file %s: %w", configFile, err)
 		}
 
 		l.Log().Infof("Using config file %s (%s#%s)", configFile, strings.ToLower(cfgViper.GetString("apiVersion")), strings.ToLower(cfgViper.GetString("kind")))
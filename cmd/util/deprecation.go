@@ -0,0 +1,83 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+)
+
+// FlagAlias maps a deprecated flag name to its replacement.
+// The old flag keeps working (it's transparently renamed while parsing), but using it prints a warning.
+type FlagAlias struct {
+	Command string // full command path (e.g. "k3d cluster create"), empty for all commands
+	Old     string // deprecated flag name (without leading dashes)
+	New     string // replacement flag name (without leading dashes)
+	Since   string // k3d version that deprecated the old flag
+}
+
+// FlagAliases is the one place to register deprecated flags and their replacements
+var FlagAliases = []FlagAlias{
+	{Command: "k3d cluster create", Old: "update-default-kubeconfig", New: "kubeconfig-update-default", Since: "v4.0.0"},
+	{Command: "k3d cluster create", Old: "switch-context", New: "kubeconfig-switch-context", Since: "v4.0.0"},
+	{Command: "k3d cluster create", Old: "masters", New: "servers", Since: "v5.0.0"},
+	{Command: "k3d cluster create", Old: "workers", New: "agents", Since: "v5.0.0"},
+}
+
+// usedFlagAliases collects the deprecated flags used in the current invocation (so we warn only once per flag)
+var usedFlagAliases = map[string]FlagAlias{}
+
+// ApplyFlagAliases registers the deprecated flag aliases with the given command and all of its subcommands
+func ApplyFlagAliases(cmd *cobra.Command, aliases []FlagAlias) {
+	var cmdAliases = map[string]FlagAlias{}
+	for _, alias := range aliases {
+		if alias.Command == "" || alias.Command == cmd.CommandPath() {
+			cmdAliases[alias.Old] = alias
+		}
+	}
+
+	if len(cmdAliases) > 0 {
+		normalize := func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+			if alias, ok := cmdAliases[name]; ok {
+				usedFlagAliases[alias.Old] = alias
+				return pflag.NormalizedName(alias.New)
+			}
+			return pflag.NormalizedName(name)
+		}
+		cmd.Flags().SetNormalizeFunc(normalize)
+		cmd.PersistentFlags().SetNormalizeFunc(normalize)
+	}
+
+	for _, subCmd := range cmd.Commands() {
+		ApplyFlagAliases(subCmd, aliases)
+	}
+}
+
+// WarnDeprecatedFlags prints a warning for each deprecated flag used in the current invocation.
+// It has to run after the flags were parsed and the logger was initialized.
+func WarnDeprecatedFlags() {
+	for _, alias := range usedFlagAliases {
+		l.Log().Warnf("Flag '--%s' is deprecated since %s and will be removed in a future release: use '--%s' instead", alias.Old, alias.Since, alias.New)
+	}
+}
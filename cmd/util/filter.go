@@ -26,9 +26,11 @@ import (
 	"strings"
 
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/util"
 )
 
-// SplitFiltersFromFlag separates a flag's value from the node filter, if there is one
+// SplitFiltersFromFlag separates a flag's value from the node filter, if there is one.
+// Multiple node filters are separated by ';' or ',' (e.g. 'server[0];agent[1-3]' or 'all,!server[0]', see util.ParseNodeFilters)
 func SplitFiltersFromFlag(flag string) (string, []string, error) {
 
 	/* Case 1) no filter specified */
@@ -74,6 +76,12 @@ func SplitFiltersFromFlag(flag string) (string, []string, error) {
 		return "", nil, fmt.Errorf("Invalid flag '%s' includes unescaped '@' but is missing a node filter (Escape literal '@' with '\\')", flag)
 	}
 
-	return newsplit[0], strings.Split(newsplit[1], ";"), nil
+	// validate the node filters right away, so that malformed ones are reported along with the flag
+	filters := strings.Split(newsplit[1], ";")
+	if _, err := util.ParseNodeFilters(filters); err != nil {
+		return "", nil, fmt.Errorf("Invalid flag '%s': %w", flag, err)
+	}
+
+	return newsplit[0], filters, nil
 
 }
@@ -29,7 +29,6 @@ import (
 	"strings"
 
 	"github.com/liggitt/tabwriter"
-	l "github.com/rancher/k3d/v5/pkg/logger"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"gopkg.in/yaml.v2"
 )
@@ -45,7 +44,7 @@ func (npf NodePrinterFunc) Print(writter *tabwriter.Writer, node *k3d.Node) {
 }
 
 // PrintNodes prints a list of nodes, either as a table or as a JSON/YAML listing
-func PrintNodes(nodes []*k3d.Node, outputFormat string, headers *[]string, nodePrinter NodePrinter) {
+func PrintNodes(nodes []*k3d.Node, outputFormat string, headers *[]string, nodePrinter NodePrinter) error {
 	outputFormat = strings.ToLower(outputFormat)
 
 	tabwriter := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', tabwriter.RememberWidths)
@@ -55,7 +54,7 @@ func PrintNodes(nodes []*k3d.Node, outputFormat string, headers *[]string, nodeP
 		if headers != nil {
 			_, err := fmt.Fprintf(tabwriter, "%s\n", strings.Join(*headers, "\t"))
 			if err != nil {
-				l.Log().Fatalln("Failed to print headers")
+				return fmt.Errorf("failed to print headers: %w", err)
 			}
 		}
 	}
@@ -68,15 +67,20 @@ func PrintNodes(nodes []*k3d.Node, outputFormat string, headers *[]string, nodeP
 		var b []byte
 		var err error
 
+		// never leak the cluster token via node labels or environment
+		maskedNodes := make([]*k3d.Node, len(nodes))
+		for i, node := range nodes {
+			maskedNodes[i] = node.WithMaskedToken()
+		}
+
 		switch outputFormat {
 		case "json":
-			b, err = json.Marshal(nodes)
+			b, err = json.Marshal(maskedNodes)
 		case "yaml":
-			b, err = yaml.Marshal(nodes)
+			b, err = yaml.Marshal(maskedNodes)
 		}
 		if err != nil {
-			fmt.Println(err)
-			return
+			return fmt.Errorf("failed to marshal nodes as %s: %w", outputFormat, err)
 		}
 		fmt.Println(string(b))
 	} else {
@@ -86,4 +90,5 @@ func PrintNodes(nodes []*k3d.Node, outputFormat string, headers *[]string, nodeP
 			}
 		}
 	}
+	return nil
 }
@@ -0,0 +1,190 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// InstrumentationScope is the name of the instrumentation scope reported with all spans
+const InstrumentationScope = "github.com/rancher/k3d/v5"
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP with JSON encoding
+type OTLPExporter struct {
+	Endpoint       string // full URL, e.g. http://localhost:4318/v1/traces
+	Headers        map[string]string
+	ServiceName    string
+	ServiceVersion string
+	Client         *http.Client // defaults to http.DefaultClient
+}
+
+// ExportSpans implements Exporter
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(newOTLPRequest(spans, e.ServiceName, e.ServiceVersion))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request for '%s': %w", e.Endpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans to '%s': %w", e.Endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector '%s' returned %s: %s", e.Endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// WriterExporter writes spans as OTLP JSON document (one per flush) to a writer
+type WriterExporter struct {
+	Writer         io.Writer
+	ServiceName    string
+	ServiceVersion string
+}
+
+// ExportSpans implements Exporter
+func (e *WriterExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	return json.NewEncoder(e.Writer).Encode(newOTLPRequest(spans, e.ServiceName, e.ServiceVersion))
+}
+
+/*
+ * OTLP JSON encoding (https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding)
+ */
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOK     = 1
+	otlpStatusCodeError  = 2
+)
+
+func newOTLPRequest(spans []*Span, serviceName, serviceVersion string) otlpRequest {
+	resourceAttributes := []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: serviceName}}}
+	if serviceVersion != "" {
+		resourceAttributes = append(resourceAttributes, otlpAttribute{Key: "service.version", Value: otlpValue{StringValue: serviceVersion}})
+	}
+
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mutex.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Status:            otlpStatus{Code: otlpStatusCodeOK},
+		}
+		if s.ParentSpanID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.ParentSpanID[:])
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: a.Key, Value: otlpValue{StringValue: a.Value}})
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.Error}
+		}
+		s.mutex.Unlock()
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: resourceAttributes},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: InstrumentationScope, Version: serviceVersion},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
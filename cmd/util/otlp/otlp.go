@@ -0,0 +1,267 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+// Package otlp records the spans of k3d operations (see pkg/tracing) and exports them in the OpenTelemetry (OTLP) format,
+// configured via the standard OpenTelemetry environment variables (see Init). It's only used by the k3d CLI: programs embedding
+// k3d set up their own tracing.TracerProvider instead.
+// Note: it implements the subset of the OpenTelemetry SDK needed by k3d (OTLP/HTTP with JSON encoding), as the SDK is not a
+// dependency of k3d (yet). Once it is, it can be replaced by the SDK's TracerProvider and its env-configured OTLP exporter.
+package otlp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/k3d/v5/pkg/tracing"
+)
+
+// environment variables (as defined by the OpenTelemetry specification) used to configure tracing
+const (
+	EnvTracesExporter     = "OTEL_TRACES_EXPORTER"               // otlp, console or none (default)
+	EnvOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"        // base URL, "/v1/traces" gets appended
+	EnvOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT" // full URL, takes precedence over OTEL_EXPORTER_OTLP_ENDPOINT
+	EnvOTLPHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"         // comma separated key=value pairs, e.g. for authentication
+	EnvOTLPTracesHeaders  = "OTEL_EXPORTER_OTLP_TRACES_HEADERS"  // same as OTEL_EXPORTER_OTLP_HEADERS, for traces only
+	EnvOTLPProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"        // only http/json is supported
+	EnvOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL" // same as OTEL_EXPORTER_OTLP_PROTOCOL, for traces only
+	EnvServiceName        = "OTEL_SERVICE_NAME"                  // defaults to k3d
+	EnvTraceParent        = "TRACEPARENT"                        // W3C trace context of a parent span, e.g. of a surrounding pipeline
+)
+
+// DefaultServiceName is the service name reported, if OTEL_SERVICE_NAME is not set
+const DefaultServiceName = "k3d"
+
+// DefaultOTLPEndpoint is the OTLP/HTTP endpoint spans are sent to, if no endpoint is set
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+// Span is a recorded span, implementing tracing.Span
+type Span struct {
+	TraceID      [16]byte
+	SpanID       [8]byte
+	ParentSpanID [8]byte // zero for root spans
+	Name         string
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   []tracing.Attribute
+	Error        string // set if the operation failed
+	provider     *TracerProvider
+	mutex        sync.Mutex
+	ended        bool
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// TracerProvider records spans and exports them via its exporter on Flush, implementing tracing.TracerProvider
+type TracerProvider struct {
+	exporter Exporter
+	remote   *Span // parent span given via TRACEPARENT
+	mutex    sync.Mutex
+	pending  []*Span
+}
+
+// NewTracerProvider creates a new TracerProvider exporting spans via the given exporter (remote is the optional parent of root spans)
+func NewTracerProvider(exporter Exporter, remote *Span) *TracerProvider {
+	return &TracerProvider{exporter: exporter, remote: remote}
+}
+
+// Init configures a TracerProvider from the environment (OTEL_TRACES_EXPORTER, OTEL_EXPORTER_OTLP_*, OTEL_SERVICE_NAME)
+// with the parent span from TRACEPARENT and sets it as global TracerProvider. It returns nil, if no exporter is configured.
+func Init(serviceVersion string) (*TracerProvider, error) {
+	serviceName := os.Getenv(EnvServiceName)
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	var exp Exporter
+	switch name := strings.ToLower(os.Getenv(EnvTracesExporter)); name {
+	case "", "none":
+		return nil, nil
+	case "console":
+		exp = &WriterExporter{Writer: os.Stderr, ServiceName: serviceName, ServiceVersion: serviceVersion}
+	case "otlp":
+		protocol := firstEnv(EnvOTLPTracesProtocol, EnvOTLPProtocol)
+		if protocol != "" && protocol != "http/json" {
+			return nil, fmt.Errorf("unsupported OTLP protocol '%s' (supported: http/json)", protocol)
+		}
+		headers, err := parseHeaders(firstEnv(EnvOTLPTracesHeaders, EnvOTLPHeaders))
+		if err != nil {
+			return nil, err
+		}
+		exp = &OTLPExporter{
+			Endpoint:       otlpEndpoint(),
+			Headers:        headers,
+			ServiceName:    serviceName,
+			ServiceVersion: serviceVersion,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported traces exporter '%s' (supported: otlp, console, none)", name)
+	}
+
+	var remote *Span
+	if tp := os.Getenv(EnvTraceParent); tp != "" {
+		parent, err := ParseTraceParent(tp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvTraceParent, err)
+		}
+		remote = parent
+	}
+
+	provider := NewTracerProvider(exp, remote)
+	tracing.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// Start implements tracing.TracerProvider
+func (p *TracerProvider) Start(ctx context.Context, name string, attributes ...tracing.Attribute) (context.Context, tracing.Span) {
+	parent := p.remote
+	if s, ok := tracing.SpanFromContext(ctx).(*Span); ok {
+		parent = s
+	}
+
+	span := &Span{
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: attributes,
+		provider:   p,
+	}
+	if parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.TraceID[:])
+	}
+	_, _ = rand.Read(span.SpanID[:])
+
+	return tracing.ContextWithSpan(ctx, span), span
+}
+
+// Flush exports all finished spans
+func (p *TracerProvider) Flush(ctx context.Context) error {
+	p.mutex.Lock()
+	spans := p.pending
+	p.pending = nil
+	p.mutex.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := p.exporter.ExportSpans(ctx, spans); err != nil {
+		return fmt.Errorf("failed to export %d spans: %w", len(spans), err)
+	}
+	return nil
+}
+
+// SetAttributes implements tracing.Span
+func (s *Span) SetAttributes(attributes ...tracing.Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Attributes = append(s.Attributes, attributes...)
+}
+
+// End implements tracing.Span: it finishes the span and queues it for export
+func (s *Span) End(err error) {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.mutex.Unlock()
+
+	if s.provider == nil {
+		return // remote span
+	}
+	s.provider.mutex.Lock()
+	defer s.provider.mutex.Unlock()
+	s.provider.pending = append(s.provider.pending, s)
+}
+
+// TraceParent implements tracing.Span
+func (s *Span) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.TraceID[:]), hex.EncodeToString(s.SpanID[:]))
+}
+
+// ParseTraceParent parses a W3C trace context (version-traceid-parentid-flags) into a (remote) span
+func ParseTraceParent(traceParent string) (*Span, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, fmt.Errorf("'%s' is not of the format 'VERSION-TRACEID-PARENTID-FLAGS'", traceParent)
+	}
+	span := &Span{}
+	if _, err := hex.Decode(span.TraceID[:], []byte(parts[1])); err != nil {
+		return nil, fmt.Errorf("invalid trace ID '%s': %w", parts[1], err)
+	}
+	if _, err := hex.Decode(span.SpanID[:], []byte(parts[2])); err != nil {
+		return nil, fmt.Errorf("invalid parent ID '%s': %w", parts[2], err)
+	}
+	if span.TraceID == [16]byte{} || span.SpanID == [8]byte{} {
+		return nil, fmt.Errorf("trace ID and parent ID must not be all zeros")
+	}
+	return span, nil
+}
+
+func otlpEndpoint() string {
+	if endpoint := os.Getenv(EnvOTLPTracesEndpoint); endpoint != "" {
+		return endpoint
+	}
+	endpoint := os.Getenv(EnvOTLPEndpoint)
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+}
+
+func parseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid OTLP header '%s' (format: key=value)", pair)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
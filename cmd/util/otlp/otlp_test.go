@@ -0,0 +1,161 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/k3d/v5/pkg/tracing"
+)
+
+type recordingExporter struct {
+	spans []*Span
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestSpans(t *testing.T) {
+	exp := &recordingExporter{}
+	provider := NewTracerProvider(exp, nil)
+
+	ctx, parent := provider.Start(context.Background(), "parent", tracing.String("k3d.cluster.name", "test"))
+	_, child := provider.Start(ctx, "child")
+	child.End(errors.New("boom"))
+	parent.End(nil)
+
+	if err := provider.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exp.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(exp.spans))
+	}
+	p, c := parent.(*Span), child.(*Span)
+	if c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID {
+		t.Errorf("child span %+v is not a child of %+v", c, p)
+	}
+	if p.ParentSpanID != [8]byte{} {
+		t.Errorf("expected root span, got parent %x", p.ParentSpanID)
+	}
+	if c.Error != "boom" || p.Error != "" {
+		t.Errorf("unexpected errors: child '%s', parent '%s'", c.Error, p.Error)
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		traceParent string
+		wantErr     bool
+	}{
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", wantErr: true},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", wantErr: true},
+		{traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		span, err := ParseTraceParent(tt.traceParent)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTraceParent(%s) error = %v, wantErr %v", tt.traceParent, err, tt.wantErr)
+			continue
+		}
+		if err == nil && span.TraceParent() != tt.traceParent {
+			t.Errorf("ParseTraceParent(%s).TraceParent() = %s", tt.traceParent, span.TraceParent())
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvTracesExporter, "otlp")
+	t.Setenv(EnvOTLPEndpoint, server.URL)
+	t.Setenv(EnvOTLPHeaders, "Authorization=secret")
+	t.Setenv(EnvTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	provider, err := Init("v5.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tracing.SetTracerProvider(nil)
+
+	// the library's spans are recorded via the global TracerProvider
+	_, span := tracing.Start(context.Background(), "ClusterCreate")
+	span.End(errors.New("failed"))
+	if err := provider.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+	s := got.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if s.Name != "ClusterCreate" || s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected span: %+v", s)
+	}
+	if s.Status.Code != otlpStatusCodeError || s.Status.Message != "failed" {
+		t.Errorf("unexpected span status: %+v", s.Status)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "unknown exporter", env: map[string]string{EnvTracesExporter: "jaeger"}},
+		{name: "unsupported protocol", env: map[string]string{EnvTracesExporter: "otlp", EnvOTLPProtocol: "grpc"}},
+		{name: "invalid headers", env: map[string]string{EnvTracesExporter: "otlp", EnvOTLPHeaders: "novalue"}},
+		{name: "invalid traceparent", env: map[string]string{EnvTracesExporter: "console", EnvTraceParent: "foo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := Init(""); err == nil {
+				t.Errorf("expected an error")
+			}
+			if _, ok := tracing.GetTracerProvider().(*TracerProvider); ok {
+				t.Errorf("expected tracing to stay disabled")
+			}
+		})
+	}
+}
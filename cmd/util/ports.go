@@ -42,7 +42,7 @@ func ParsePortExposureSpec(exposedPortSpec, internalPort string) (*k3d.ExposureO
 	match := apiPortRegexp.FindStringSubmatch(exposedPortSpec)
 
 	if len(match) == 0 {
-		return nil, fmt.Errorf("Failed to parse Port Exposure specification '%s': Format must be [(HostIP|HostName):]HostPort", exposedPortSpec)
+		return nil, fmt.Errorf("Failed to parse Port Exposure specification '%s': Format must be [(HostIP|HostName):](HostPort|random|0)", exposedPortSpec)
 	}
 
 	submatches := util.MapSubexpNames(apiPortRegexp.SubexpNames(), match)
@@ -74,26 +74,27 @@ func ParsePortExposureSpec(exposedPortSpec, internalPort string) (*k3d.ExposureO
 
 	realPortString := ""
 
-	if submatches["hostip"] == "" {
-		submatches["hostip"] = k3d.DefaultAPIHost
-	}
-
+	// an empty host IP is kept, so that the default bind address can be applied later on
 	// start with the IP, if there is any
 	if submatches["hostip"] != "" {
 		realPortString += submatches["hostip"] + ":"
 	}
 
-	// port: get a free one if there's none defined or set to random
-	if submatches["port"] == "" || submatches["port"] == "random" {
+	// port: get a free one if there's none defined or set to random (or 0, like in `net.Listen`)
+	if submatches["port"] == "" || submatches["port"] == "random" || submatches["port"] == "0" {
 		l.Log().Debugf("Port Exposure Mapping didn't specify hostPort, choosing one randomly...")
-		freePort, err := GetFreePort()
+		freePort, err := util.GetFreePortOnHost(submatches["hostip"])
 		if err != nil || freePort == 0 {
 			l.Log().Warnf("Failed to get random free port: %+v", err)
 			l.Log().Warnf("Falling back to internal port %s (may be blocked though)...", internalPort)
 			submatches["port"] = internalPort
 		} else {
 			submatches["port"] = strconv.Itoa(freePort)
-			l.Log().Debugf("Got free port for Port Exposure: '%d'", freePort)
+			if submatches["hostip"] != "" {
+				l.Log().Infof("Using random free port %d on %s for port %s", freePort, submatches["hostip"], internalPort)
+			} else {
+				l.Log().Infof("Using random free port %d for port %s", freePort, internalPort)
+			}
 		}
 	}
 
@@ -0,0 +1,112 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Prompter asks the user questions on an interactive terminal.
+// Prompts wait for input without a timeout; an empty answer selects the default value.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewPrompter returns a new Prompter reading answers from in and writing questions to out
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// String asks for a string value, re-asking as long as validate (optional) returns an error
+func (p *Prompter) String(question string, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		answer, err := p.in.ReadString('\n')
+		if err != nil && !(err == io.EOF && answer != "") {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = def
+		}
+
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintf(p.out, "  %v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// Int asks for a non-negative integer value
+func (p *Prompter) Int(question string, def int) (int, error) {
+	answer, err := p.String(question, strconv.Itoa(def), func(s string) error {
+		if i, err := strconv.Atoi(s); err != nil || i < 0 {
+			return fmt.Errorf("please enter a number >= 0")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(answer)
+}
+
+// Bool asks a yes/no question
+func (p *Prompter) Bool(question string, def bool) (bool, error) {
+	defStr := "y/N"
+	if def {
+		defStr = "Y/n"
+	}
+	answer, err := p.String(fmt.Sprintf("%s (%s)", question, defStr), "", func(s string) error {
+		switch strings.ToLower(s) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("please answer 'y' or 'n'")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ProtectRollback keeps repeated interrupts from terminating k3d (while a failed creation gets rolled back), if the rollback policy is 'always'.
+// The first interrupt still cancels the command's context (see cmd.Execute), which makes the running creation fail and roll back.
+// The returned function has to be called once the creation (and its rollback) is done.
+func ProtectRollback(policy k3d.RollbackPolicy) (done func()) {
+	if policy != k3d.RollbackPolicyAlways {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		interrupted := false
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-signals:
+				if interrupted {
+					l.Log().Warnf("Ignoring %s until the rollback is done (--rollback=always)", sig)
+				}
+				interrupted = true
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(stop)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Shells supported by the shell-specific output helpers
+const (
+	ShellBash       = "bash"
+	ShellFish       = "fish"
+	ShellPowershell = "powershell"
+	ShellCmd        = "cmd"
+)
+
+// Shells lists all supported shells
+var Shells = []string{ShellBash, ShellFish, ShellPowershell, ShellCmd}
+
+// DetectShell makes an educated guess about the shell k3d is running in
+func DetectShell() string {
+	if runtime.GOOS == "windows" && os.Getenv("MSYSTEM") == "" {
+		return ShellPowershell
+	}
+	if filepath.Base(os.Getenv("SHELL")) == "fish" {
+		return ShellFish
+	}
+	return ShellBash
+}
+
+// ValidateShell checks if the given shell is supported
+func ValidateShell(shell string) error {
+	for _, s := range Shells {
+		if shell == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported shell '%s': must be one of %s", shell, strings.Join(Shells, ", "))
+}
+
+// ShellSetEnv returns the command that sets an environment variable in the given shell
+func ShellSetEnv(shell string, key string, value string) string {
+	switch shell {
+	case ShellFish:
+		return fmt.Sprintf("set -gx %s %s;", key, shellQuote(shell, value))
+	case ShellPowershell:
+		return fmt.Sprintf("$env:%s = %s", key, shellQuote(shell, value))
+	case ShellCmd:
+		return fmt.Sprintf("set %s=%s", key, value)
+	default:
+		return fmt.Sprintf("export %s=%s", key, shellQuote(shell, value))
+	}
+}
+
+// ShellUnsetEnv returns the command that unsets an environment variable in the given shell
+func ShellUnsetEnv(shell string, key string) string {
+	switch shell {
+	case ShellFish:
+		return fmt.Sprintf("set -e %s;", key)
+	case ShellPowershell:
+		return fmt.Sprintf("Remove-Item Env:\\%s -ErrorAction SilentlyContinue", key)
+	case ShellCmd:
+		return fmt.Sprintf("set %s=", key)
+	default:
+		return fmt.Sprintf("unset %s", key)
+	}
+}
+
+// ShellComment returns the given text as a comment in the given shell
+func ShellComment(shell string, text string) string {
+	if shell == ShellCmd {
+		return "REM " + text
+	}
+	return "# " + text
+}
+
+// ShellEval returns the command that evaluates the output of the given command in the given shell
+func ShellEval(shell string, command string) string {
+	switch shell {
+	case ShellFish:
+		return fmt.Sprintf("%s | source", command)
+	case ShellPowershell:
+		return fmt.Sprintf("%s | Invoke-Expression", command)
+	case ShellCmd:
+		return fmt.Sprintf("for /f \"delims=\" %%i in ('%s') do %%i", command)
+	default:
+		return fmt.Sprintf("eval \"$(%s)\"", command)
+	}
+}
+
+// shellQuote wraps a value in single quotes (a literal string in POSIX shells, fish and PowerShell), escaping single quotes as required by the shell
+func shellQuote(shell string, value string) string {
+	switch shell {
+	case ShellFish:
+		value = strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value)
+	case ShellPowershell:
+		value = strings.ReplaceAll(value, "'", "''")
+	default:
+		value = strings.ReplaceAll(value, "'", `'"'"'`)
+	}
+	return "'" + value + "'"
+}
@@ -21,7 +21,10 @@ THE SOFTWARE.
 */
 package util
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // SplitKV splits an '='-delimited string into a key-value-pair (if any)
 func SplitKV(kvstring string) (string, string) {
@@ -35,3 +38,18 @@ func SplitKV(kvstring string) (string, string) {
 	// defaults to key with empty value (like `docker run` do)
 	return kvstring, ""
 }
+
+// ParseSelector parses label selectors of the form 'KEY=VALUE[,KEY=VALUE...]' into a map of labels that all have to match
+func ParseSelector(selectors []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, selector := range selectors {
+		for _, kv := range strings.Split(selector, ",") {
+			key, value := SplitKV(kv)
+			if key == "" || !strings.Contains(kv, "=") {
+				return nil, fmt.Errorf("invalid selector '%s': expected format KEY=VALUE", kv)
+			}
+			labels[key] = value
+		}
+	}
+	return labels, nil
+}
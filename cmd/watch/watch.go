@@ -0,0 +1,106 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/k3d/v5/cmd/util"
+	"github.com/rancher/k3d/v5/pkg/client"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NewCmdWatch returns a new cobra command
+func NewCmdWatch() *cobra.Command {
+
+	opts := k3d.ClusterWatchOpts{}
+	var notify bool
+
+	// create new command
+	cmd := &cobra.Command{
+		Use:   "watch [NAME [NAME...]]",
+		Short: "Watch clusters and recover from failures",
+		Long: `Watch clusters and recover from failures (e.g. for long-lived local development clusters).
+
+The watcher restarts node containers that crashed (non-zero exit code) or were OOM-killed while the rest
+of the cluster is still running and refreshes the loadbalancer configuration afterwards. Nodes that were stopped
+(e.g. via 'k3d node stop') and clusters where all nodes are stopped (e.g. via 'k3d cluster stop') are left alone.
+Clusters created with a hibernation schedule ('--hibernation-schedule') are stopped outside and started
+inside their scheduled time windows.
+It runs in the foreground until interrupted, so run it in the background (e.g. 'k3d watch &') or as a service.`,
+		ValidArgsFunction: util.ValidArgsAvailableClusters,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			opts.Clusters = args
+			opts.OnEvent = func(event k3d.WatchEvent) {
+				if event.Failure {
+					l.Log().Errorln(event.Message)
+				} else {
+					l.Log().Infoln(event.Message)
+				}
+				if notify {
+					if err := notifyDesktop(ctx, fmt.Sprintf("k3d: %s", event.Cluster), event.Message); err != nil {
+						l.Log().Debugf("Failed to send desktop notification: %v", err)
+					}
+				}
+			}
+
+			if err := client.ClusterWatch(ctx, runtimes.SelectedRuntime, opts); err != nil {
+				l.Log().Fatalln(err)
+			}
+		},
+	}
+
+	// add flags
+	cmd.Flags().DurationVar(&opts.Interval, "interval", k3d.DefaultWatchInterval, "Time between two checks")
+	cmd.Flags().IntVar(&opts.MaxRestarts, "max-restarts", k3d.DefaultWatchMaxRestarts, "Maximum number of consecutive restart attempts per node (reset once a node stayed up for 3 intervals)")
+	cmd.Flags().BoolVar(&notify, "notify", false, "Send desktop notifications (uses 'notify-send' on Linux and 'osascript' on macOS)")
+
+	// done
+	return cmd
+}
+
+// notifyDesktop shows a desktop notification using the platform's tooling
+func notifyDesktop(ctx context.Context, title string, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(ctx, "notify-send", title, message)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
@@ -27,6 +27,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"sync"
 
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
@@ -143,3 +145,94 @@ func (act ExecAction) Run(ctx context.Context, node *k3d.Node) error {
 	}
 	return nil
 }
+
+// ApplyManifestAction applies a Kubernetes manifest using the kubectl inside the (server) node
+type ApplyManifestAction struct {
+	Runtime     runtimes.Runtime
+	Manifest    []byte
+	Description string
+}
+
+func (act ApplyManifestAction) Name() string {
+	return "ApplyManifestAction"
+}
+
+func (act ApplyManifestAction) Info() string {
+	if act.Description == "" {
+		act.Description = "<no description>"
+	}
+	return fmt.Sprintf("[%s] Applying %d bytes of manifests: %s", act.Name(), len(act.Manifest), act.Description)
+}
+
+func (act ApplyManifestAction) Run(ctx context.Context, node *k3d.Node) error {
+	if err := act.Runtime.WriteToNode(ctx, act.Manifest, k3d.DefaultHookManifestTempPath, 0644, node); err != nil {
+		return fmt.Errorf("failed to write manifest to node %s: %w", node.Name, err)
+	}
+	logreader, err := act.Runtime.ExecInNodeGetLogs(ctx, node, []string{"kubectl", "apply", "-f", k3d.DefaultHookManifestTempPath})
+	if err != nil {
+		if logreader != nil {
+			if logs, logerr := io.ReadAll(logreader); logerr == nil {
+				err = fmt.Errorf("%w: Logs from failed exec process below:\n%s", err, string(logs))
+			}
+		}
+		return fmt.Errorf("error applying manifest in node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// ImportArchiveAction imports tar archives (as exported via the runtime's ExportFromNode) into the node, e.g. to restore a backup before the node starts
+type ImportArchiveAction struct {
+	Runtime     runtimes.Runtime
+	Archives    map[string]map[string]string // node name -> path in the node -> archive file on the host
+	Description string
+
+	mu     sync.Mutex
+	failed []string
+}
+
+func (act *ImportArchiveAction) Name() string {
+	return "ImportArchiveAction"
+}
+
+func (act *ImportArchiveAction) Info() string {
+	if act.Description == "" {
+		act.Description = "<no description>"
+	}
+	return fmt.Sprintf("[%s] Importing archives into %d nodes: %s", act.Name(), len(act.Archives), act.Description)
+}
+
+func (act *ImportArchiveAction) Run(ctx context.Context, node *k3d.Node) error {
+	archives := act.Archives[node.Name]
+	paths := make([]string, 0, len(archives))
+	for path := range archives {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := act.importArchive(ctx, node, path, archives[path]); err != nil {
+			act.mu.Lock()
+			act.failed = append(act.failed, node.Name)
+			act.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+func (act *ImportArchiveAction) importArchive(ctx context.Context, node *k3d.Node, path string, file string) error {
+	l.Log().Debugf("Node %s: importing '%s' to %s", node.Name, file, path)
+	archive, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open archive for %s in node %s: %w", path, node.Name, err)
+	}
+	defer archive.Close()
+	return act.Runtime.ImportToNode(ctx, archive, path, node)
+}
+
+// Failed returns the names of the nodes that the archives couldn't be imported to, since failing pre-start actions don't stop nodes from starting
+func (act *ImportArchiveAction) Failed() []string {
+	act.mu.Lock()
+	defer act.mu.Unlock()
+	return append([]string{}, act.failed...)
+}
@@ -0,0 +1,717 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package api implements a small local HTTP API served on a unix socket.
+// It's meant for IDE integrations (e.g. the k3d VS Code extension), which can list clusters, create them from a config file and
+// stream their status without spawning the k3d CLI repeatedly.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+
+	"github.com/rancher/k3d/v5/pkg/client"
+	"github.com/rancher/k3d/v5/pkg/config"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// Version is the version of the local API, used as path prefix of all endpoints
+const Version = "v1"
+
+// DefaultSocketName is the name of the API socket in the k3d config directory
+const DefaultSocketName = "api.sock"
+
+// DefaultStatusInterval is the interval in which the cluster status is polled when streaming it
+const DefaultStatusInterval = 2 * time.Second
+
+// maxConfigSize limits the size of a cluster config sent to the API
+const maxConfigSize = 1024 * 1024
+
+// Endpoint describes an endpoint of the local API
+type Endpoint struct {
+	Method      string `yaml:"method" json:"method"`
+	Path        string `yaml:"path" json:"path"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// Endpoints lists all endpoints of the local API
+var Endpoints = []Endpoint{
+	{Method: http.MethodGet, Path: "/v1/version", Description: "k3d version and default k3s version"},
+	{Method: http.MethodGet, Path: "/v1/clusters", Description: "List all clusters including the status of their nodes"},
+	{Method: http.MethodPost, Path: "/v1/clusters", Description: "Create a cluster from a k3d config file (SimpleConfig as YAML or JSON in the request body) and stream progress events (NDJSON)"},
+	{Method: http.MethodPost, Path: "/v1/clusters?async=true", Description: "Create a cluster from a k3d config file as a background job and return the job"},
+	{Method: http.MethodDelete, Path: "/v1/clusters/{name}", Description: "Delete a cluster as a background job and return the job"},
+	{Method: http.MethodPost, Path: "/v1/clusters/{name}/images", Description: "Import images ({\"images\": [...], \"mode\": \"auto\"} in the request body) into a cluster as a background job and return the job"},
+	{Method: http.MethodGet, Path: "/v1/clusters/{name}/status", Description: "Stream the status of a cluster (NDJSON) every time it changes"},
+	{Method: http.MethodGet, Path: "/v1/jobs", Description: "List all background jobs (queued, running and recently finished)"},
+	{Method: http.MethodGet, Path: "/v1/jobs/{id}", Description: "Get a background job including its progress"},
+	{Method: http.MethodPost, Path: "/v1/jobs/{id}/cancel", Description: "Cancel a queued or running background job"},
+}
+
+// ImageImportRequest is the request body of the image import endpoint
+type ImageImportRequest struct {
+	Images []string `yaml:"images" json:"images"`
+	Mode   string   `yaml:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// VersionInfo is the response of the version endpoint
+type VersionInfo struct {
+	K3d string `yaml:"k3d" json:"k3d"`
+	K3s string `yaml:"k3s" json:"k3s"`
+}
+
+// NodeStatus is the status of a single node
+type NodeStatus struct {
+	Name    string   `yaml:"name" json:"name"`
+	Role    k3d.Role `yaml:"role" json:"role"`
+	Running bool     `yaml:"running" json:"running"`
+	Status  string   `yaml:"status" json:"status"`
+}
+
+// ClusterStatus is the status of a cluster as returned by the API
+type ClusterStatus struct {
+	Name           string       `yaml:"name" json:"name"`
+	ServersRunning int          `yaml:"serversRunning" json:"serversRunning"`
+	ServersCount   int          `yaml:"serversCount" json:"serversCount"`
+	AgentsRunning  int          `yaml:"agentsRunning" json:"agentsRunning"`
+	AgentsCount    int          `yaml:"agentsCount" json:"agentsCount"`
+	APIHost        string       `yaml:"apiHost,omitempty" json:"apiHost,omitempty"`
+	APIPort        string       `yaml:"apiPort,omitempty" json:"apiPort,omitempty"`
+	Nodes          []NodeStatus `yaml:"nodes" json:"nodes"`
+	Error          string       `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// GetDefaultSocketPath returns the path of the API socket in the k3d config directory
+func GetDefaultSocketPath() (string, error) {
+	configDir, err := util.GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, DefaultSocketName), nil
+}
+
+// IsRunning checks whether an API server is listening on the given socket
+func IsRunning(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ServeOpts are the options of Serve
+type ServeOpts struct {
+	SocketPath  string
+	StateStore  StateStore     // keeps the state (jobs), may be shared with other API servers
+	Auth        *Authenticator // if set, requests need a token and users only see and manage their own clusters (unless they're admins)
+	SocketGroup string         // if set, the members of this group (name or ID) may access the socket
+}
+
+// Serve serves the local API on the given unix socket until the context is cancelled
+func Serve(ctx context.Context, runtime runtimes.Runtime, opts ServeOpts) error {
+	socketPath := opts.SocketPath
+	if IsRunning(socketPath) {
+		return fmt.Errorf("the API is already being served on '%s'", socketPath)
+	}
+	// remove a stale socket left behind by a previous server
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket '%s': %w", socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on socket '%s': %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+	// only the current user may talk to the API, as it can create containers, unless a group is allowed to or access is controlled via tokens.
+	// Tokens don't make the socket world-accessible though, since k3s nodes are privileged containers and every user with a token can run workloads in them.
+	socketMode := os.FileMode(0600)
+	if opts.Auth != nil || opts.SocketGroup != "" {
+		socketMode = 0660
+	}
+	if err := os.Chmod(socketPath, socketMode); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set permissions of socket '%s': %w", socketPath, err)
+	}
+	if opts.SocketGroup != "" {
+		gid, err := lookupGroupID(opts.SocketGroup)
+		if err == nil {
+			err = os.Chown(socketPath, -1, gid)
+		}
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to set group '%s' of socket '%s': %w", opts.SocketGroup, socketPath, err)
+		}
+	}
+
+	apiServer := NewServerWithStateStore(runtime, opts.StateStore)
+	apiServer.auth = opts.Auth
+	defer apiServer.Close()
+
+	server := &http.Server{
+		Handler:     apiServer.Handler(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	l.Log().Infof("Serving the k3d API on '%s'", socketPath)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve API: %w", err)
+	}
+	return nil
+}
+
+// lookupGroupID returns the ID of a group given by name or ID
+func lookupGroupID(group string) (int, error) {
+	g, err := osuser.LookupGroup(group)
+	if err != nil {
+		if g, err = osuser.LookupGroupId(group); err != nil {
+			return 0, fmt.Errorf("unknown group '%s'", group)
+		}
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// Server handles requests to the local API
+type Server struct {
+	runtime runtimes.Runtime
+	store   StateStore // its lock serializes cluster creations and jobs, as progress reporting is global
+	jobs    *jobQueue
+	auth    *Authenticator // nil: no authentication, everyone may do everything
+}
+
+// NewServer returns a new API server using the given runtime, which keeps its state in memory
+func NewServer(runtime runtimes.Runtime) *Server {
+	return NewServerWithStateStore(runtime, newMemoryStateStore())
+}
+
+// NewServerWithStateStore returns a new API server using the given runtime and state store
+func NewServerWithStateStore(runtime runtimes.Runtime, store StateStore) *Server {
+	return &Server{
+		runtime: runtime,
+		store:   store,
+		jobs:    newJobQueue(store),
+	}
+}
+
+// Close cancels all background jobs of the server
+func (s *Server) Close() {
+	s.jobs.Close()
+}
+
+// Handler returns the HTTP handler serving all API endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/version", s.handleVersion)
+	mux.HandleFunc("/v1/clusters", s.handleClusters)
+	mux.HandleFunc("/v1/clusters/", s.handleCluster)
+	mux.HandleFunc("/v1/jobs", s.handleJobs)
+	mux.HandleFunc("/v1/jobs/", s.handleJob)
+	if s.auth == nil {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.auth.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+	})
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, VersionInfo{K3d: version.GetVersion(), K3s: version.K3sVersion})
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		clusters, err := client.ClusterList(r.Context(), s.runtime)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		user := userFromContext(r.Context())
+		statuses := []ClusterStatus{}
+		for _, cluster := range clusters {
+			if user.mayAccess(clusterOwner(cluster)) {
+				statuses = append(statuses, clusterStatus(cluster))
+			}
+		}
+		writeJSON(w, http.StatusOK, statuses)
+	case http.MethodPost:
+		s.handleClusterCreate(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *Server) handleClusterCreate(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(io.LimitReader(r.Body, maxConfigSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read config: %w", err))
+		return
+	}
+	simpleCfg, err := parseSimpleConfig(content)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// check the simple config before transforming it, as the transformation may already read files on the host
+	ctx := r.Context()
+	user := userFromContext(ctx)
+	if err := checkUserSimpleConfig(user, &simpleCfg); err != nil {
+		writeForbiddenError(w, err)
+		return
+	}
+	if err := config.ProcessSimpleConfig(&simpleCfg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("error processing/sanitizing simple config: %w", err))
+		return
+	}
+	clusterConfig, err := config.TransformSimpleToClusterConfig(ctx, s.runtime, simpleCfg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := config.ValidateClusterConfig(ctx, s.runtime, *clusterConfig); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed cluster configuration validation: %w", err))
+		return
+	}
+	owner := ""
+	if user != nil {
+		owner = user.Name
+		clusterConfig.ClusterCreateOpts.GlobalLabels[k3d.LabelClusterOwner] = owner
+	}
+	if err := s.checkUserConfig(ctx, user, clusterConfig); err != nil {
+		writeForbiddenError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		if _, err := client.ClusterGet(ctx, s.runtime, &clusterConfig.Cluster); err == nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("a cluster with the name '%s' already exists", clusterConfig.Cluster.Name))
+			return
+		}
+		if err := s.checkQuota(ctx, user, &clusterConfig.Cluster); err != nil {
+			writeForbiddenError(w, err)
+			return
+		}
+		s.submitJob(w, JobOperationClusterCreate, clusterConfig.Cluster.Name, owner, func(ctx context.Context) error {
+			if _, err := client.ClusterGet(ctx, s.runtime, &clusterConfig.Cluster); err == nil {
+				return fmt.Errorf("a cluster with the name '%s' already exists", clusterConfig.Cluster.Name)
+			}
+			// check again, as other jobs of the user may have created clusters meanwhile
+			if err := s.checkQuota(ctx, user, &clusterConfig.Cluster); err != nil {
+				return err
+			}
+			return s.createCluster(ctx, clusterConfig, clusterConfig.ClusterCreateOpts.Rollback == k3d.RollbackPolicyNever)
+		})
+		return
+	}
+
+	s.store.Lock()
+	defer s.store.Unlock()
+
+	if _, err := client.ClusterGet(ctx, s.runtime, &clusterConfig.Cluster); err == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("a cluster with the name '%s' already exists", clusterConfig.Cluster.Name))
+		return
+	}
+	if err := s.checkQuota(ctx, user, &clusterConfig.Cluster); err != nil {
+		writeForbiddenError(w, err)
+		return
+	}
+
+	// from here on, the result is reported as a stream of progress events
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	progress.SetOutput(&flushWriter{w: w})
+	defer progress.SetOutput(nil)
+
+	_ = s.createCluster(ctx, clusterConfig, clusterConfig.ClusterCreateOpts.Rollback == k3d.RollbackPolicyNever)
+}
+
+// createCluster creates a cluster (rolling back on failure) and writes its kubeconfig as configured
+func (s *Server) createCluster(ctx context.Context, clusterConfig *conf.ClusterConfig, noRollback bool) error {
+	if _, err := client.New(s.runtime).CreateCluster(ctx, clusterConfig, client.CreateClusterOpts{NoRollback: noRollback}); err != nil {
+		l.Log().Errorf("API: %v", err)
+		return err
+	}
+	return nil
+}
+
+// handleCluster routes the requests for a single cluster (/v1/clusters/{name}[/...])
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/clusters/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		if r.Method != http.MethodDelete {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.handleClusterDelete(w, r, parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "status":
+		s.handleClusterStatus(w, r, parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "images":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.handleImageImport(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint '%s'", r.URL.Path))
+	}
+}
+
+func (s *Server) handleClusterDelete(w http.ResponseWriter, r *http.Request, name string) {
+	owner, ok := s.getAccessibleCluster(w, r, name)
+	if !ok {
+		return
+	}
+	s.submitJob(w, JobOperationClusterDelete, name, owner, func(ctx context.Context) error {
+		cluster, err := client.ClusterGet(ctx, s.runtime, &k3d.Cluster{Name: name})
+		if err != nil {
+			return fmt.Errorf("failed to get cluster '%s': %w", name, err)
+		}
+		if err := client.ClusterDelete(ctx, s.runtime, cluster, k3d.ClusterDeleteOpts{SkipRegistryCheck: false}); err != nil {
+			return err
+		}
+		client.KubeconfigRemoveClusterFromAll(ctx, cluster)
+		return nil
+	})
+}
+
+func (s *Server) handleImageImport(w http.ResponseWriter, r *http.Request, name string) {
+	var request ImageImportRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxConfigSize)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+		return
+	}
+	if len(request.Images) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no images specified"))
+		return
+	}
+	// users (not admins) may only import images from the runtime, not read image archives (or stdin) on the host
+	user := userFromContext(r.Context())
+	noFiles := user != nil && user.Role != RoleAdmin
+	if noFiles {
+		for _, image := range request.Images {
+			if isImageArchive(image) {
+				writeForbiddenError(w, fmt.Errorf("%w: importing the image archive '%s'", ErrForbiddenConfig, image))
+				return
+			}
+		}
+	}
+	mode := k3d.ImportModeAutoDetect
+	if request.Mode != "" {
+		var ok bool
+		if mode, ok = k3d.ImportModes[request.Mode]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown import mode '%s'", request.Mode))
+			return
+		}
+	}
+	owner, ok := s.getAccessibleCluster(w, r, name)
+	if !ok {
+		return
+	}
+	s.submitJob(w, JobOperationImageImport, name, owner, func(ctx context.Context) error {
+		cluster, err := client.ClusterGet(ctx, s.runtime, &k3d.Cluster{Name: name})
+		if err != nil {
+			return fmt.Errorf("failed to get cluster '%s': %w", name, err)
+		}
+		return client.ImageImportIntoClusterMulti(ctx, s.runtime, request.Images, cluster, k3d.ImageImportOpts{Mode: mode, NoFiles: noFiles})
+	})
+}
+
+// getAccessibleCluster checks that the cluster exists and that the user of the request may access it and returns its owner.
+// Otherwise, it responds with an error.
+func (s *Server) getAccessibleCluster(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	cluster, err := client.ClusterGet(r.Context(), s.runtime, &k3d.Cluster{Name: name})
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("failed to get cluster '%s': %w", name, err))
+		return "", false
+	}
+	owner := clusterOwner(cluster)
+	if !userFromContext(r.Context()).mayAccess(owner) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("cluster '%s' belongs to another user", name))
+		return "", false
+	}
+	return owner, true
+}
+
+// submitJob queues a job (owned by the owner of the cluster) and responds with it
+func (s *Server) submitJob(w http.ResponseWriter, operation JobOperation, cluster string, owner string, run func(ctx context.Context) error) {
+	job, err := s.jobs.Submit(operation, cluster, owner, run)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	jobs, err := s.jobs.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	user := userFromContext(r.Context())
+	visible := []Job{}
+	for _, job := range jobs {
+		if user.mayAccess(job.Owner) {
+			visible = append(visible, job)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+// handleJob routes the requests for a single job (/v1/jobs/{id}[/cancel])
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/jobs/"), "/")
+	if len(parts) > 0 && parts[0] != "" {
+		job, err := s.jobs.Get(parts[0])
+		if err == nil && !userFromContext(r.Context()).mayAccess(job.Owner) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("job '%s' belongs to another user", parts[0]))
+			return
+		}
+	}
+	var job Job
+	var err error
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		job, err = s.jobs.Get(parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "cancel":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		job, err = s.jobs.Cancel(parts[0])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint '%s'", r.URL.Path))
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		writeError(w, http.StatusNotFound, fmt.Errorf("job '%s' not found", parts[0]))
+	case errors.Is(err, ErrJobDone):
+		writeError(w, http.StatusConflict, fmt.Errorf("job '%s' is %s already", parts[0], job.State))
+	case errors.Is(err, ErrJobRemote):
+		writeError(w, http.StatusConflict, fmt.Errorf("job '%s' is run by another API server sharing the state store, cancel it there", parts[0]))
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+func (s *Server) handleClusterStatus(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	cluster, err := client.ClusterGet(r.Context(), s.runtime, &k3d.Cluster{Name: name})
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("failed to get cluster '%s': %w", name, err))
+		return
+	}
+	if !userFromContext(r.Context()).mayAccess(clusterOwner(cluster)) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("cluster '%s' belongs to another user", name))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	out := &flushWriter{w: w}
+	encoder := json.NewEncoder(out)
+
+	last := clusterStatus(cluster)
+	if err := encoder.Encode(last); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(DefaultStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			var current ClusterStatus
+			cluster, err := client.ClusterGet(r.Context(), s.runtime, &k3d.Cluster{Name: name})
+			if err != nil {
+				current = ClusterStatus{Name: name, Error: err.Error()}
+			} else {
+				current = clusterStatus(cluster)
+			}
+			if reflect.DeepEqual(current, last) {
+				continue
+			}
+			if err := encoder.Encode(current); err != nil {
+				return
+			}
+			last = current
+		}
+	}
+}
+
+// parseSimpleConfig parses a k3d config file (YAML or JSON) and applies the same defaults as `k3d cluster create`.
+// Other than the CLI, it doesn't expand environment variables in the config, as they'd be the ones of the API server.
+func parseSimpleConfig(content []byte) (conf.SimpleConfig, error) {
+	v := viper.New()
+	v.SetConfigType("yaml") // JSON is valid YAML
+	v.SetDefault("apiversion", config.DefaultConfigApiVersion)
+	v.SetDefault("kind", "Simple")
+	v.SetDefault("servers", 1)
+	v.SetDefault("agents", 0)
+	v.SetDefault("image", fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, version.K3sVersion))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return conf.SimpleConfig{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	schema, err := config.GetSchemaByVersion(v.GetString("apiversion"))
+	if err != nil {
+		return conf.SimpleConfig{}, fmt.Errorf("cannot validate config: %w", err)
+	}
+	contentJSON, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return conf.SimpleConfig{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := config.ValidateSchemaJSON(contentJSON, schema); err != nil {
+		return conf.SimpleConfig{}, fmt.Errorf("schema validation failed: %w", err)
+	}
+
+	cfg, err := config.FromViper(v)
+	if err != nil {
+		return conf.SimpleConfig{}, err
+	}
+	if cfg.GetAPIVersion() != config.DefaultConfigApiVersion {
+		cfg, err = config.Migrate(cfg, config.DefaultConfigApiVersion)
+		if err != nil {
+			return conf.SimpleConfig{}, err
+		}
+	}
+	simpleCfg, ok := cfg.(conf.SimpleConfig)
+	if !ok {
+		return conf.SimpleConfig{}, fmt.Errorf("unsupported config kind '%s': only 'Simple' is supported", cfg.GetKind())
+	}
+	return simpleCfg, nil
+}
+
+// clusterStatus summarizes the status of the given cluster
+func clusterStatus(cluster *k3d.Cluster) ClusterStatus {
+	status := ClusterStatus{
+		Name:  cluster.Name,
+		Nodes: []NodeStatus{},
+	}
+	status.ServersRunning, status.ServersCount = cluster.ServerCountRunning()
+	status.AgentsRunning, status.AgentsCount = cluster.AgentCountRunning()
+	if host, port, ok := client.ClusterGetAPIEndpoint(cluster); ok {
+		status.APIHost, status.APIPort = host, port
+	}
+	for _, node := range cluster.Nodes {
+		status.Nodes = append(status.Nodes, NodeStatus{
+			Name:    node.Name,
+			Role:    node.Role,
+			Running: node.State.Running,
+			Status:  node.State.Status,
+		})
+	}
+	return status
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		l.Log().Debugf("API: failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeForbiddenError responds with 403 Forbidden if the quota is exceeded or the config is not allowed, otherwise with an internal error (failed to check it)
+func writeForbiddenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrForbiddenConfig) {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// flushWriter flushes every write, so that streamed events reach the client immediately
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/k3d/v5/version"
+)
+
+func TestParseSimpleConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		servers int
+		agents  int
+		wantErr bool
+	}{
+		{
+			name:    "full",
+			content: "apiVersion: k3d.io/v1alpha3\nkind: Simple\nname: test\nservers: 3\nagents: 2\n",
+			servers: 3,
+			agents:  2,
+		},
+		{
+			name:    "defaults",
+			content: "apiVersion: k3d.io/v1alpha3\nkind: Simple\nname: test\n",
+			servers: 1,
+			agents:  0,
+		},
+		{
+			name:    "unknown field",
+			content: "apiVersion: k3d.io/v1alpha3\nkind: Simple\nname: test\nnotAField: true\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown apiVersion",
+			content: "apiVersion: k3d.io/v0\nkind: Simple\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseSimpleConfig([]byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got config %+v", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Servers != tt.servers || cfg.Agents != tt.agents {
+				t.Errorf("expected %d servers and %d agents, got %d and %d", tt.servers, tt.agents, cfg.Servers, cfg.Agents)
+			}
+		})
+	}
+}
+
+func TestParseSimpleConfigWithoutEnvExpansion(t *testing.T) {
+	t.Setenv("K3D_API_TEST_SECRET", "leaked")
+	content := "apiVersion: k3d.io/v1alpha3\nkind: Simple\nname: test\nenv:\n  - envVar: SECRET=${K3D_API_TEST_SECRET}\n"
+	cfg, err := parseSimpleConfig([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Env) != 1 || cfg.Env[0].EnvVar != "SECRET=${K3D_API_TEST_SECRET}" {
+		t.Errorf("expected the environment variable of the API server not to be expanded, got %+v", cfg.Env)
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	handler := NewServer(nil).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var info VersionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if info.K3d != version.GetVersion() {
+		t.Errorf("expected k3d version %s, got %s", version.GetVersion(), info.K3d)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// Role is the role of a user of the API
+type Role string
+
+// Roles of API users
+const (
+	RoleUser  Role = "user"  // may only see and manage the clusters (and jobs) they created via the API
+	RoleAdmin Role = "admin" // may see and manage all clusters and jobs, e.g. for cleaning up
+)
+
+// User is a user of the API, authenticated by a bearer token
+type User struct {
+	Name        string `yaml:"name" json:"name"`
+	Role        Role   `yaml:"role,omitempty" json:"role,omitempty"`
+	TokenSHA256 string `yaml:"tokenSha256" json:"tokenSha256"`         // hex-encoded SHA-256 hash of the token (see HashToken)
+	Quota       *Quota `yaml:"quota,omitempty" json:"quota,omitempty"` // overrides the default quota of the auth config
+}
+
+// AuthConfig is the content of the auth file of the API server
+type AuthConfig struct {
+	Users        []User `yaml:"users" json:"users"`
+	DefaultQuota *Quota `yaml:"defaultQuota,omitempty" json:"defaultQuota,omitempty"` // applies to all users with the user role without their own quota
+}
+
+// ErrUnauthenticated is returned for requests without a known token
+var ErrUnauthenticated = errors.New("missing or unknown token")
+
+// Authenticator authenticates API requests by their bearer token
+type Authenticator struct {
+	users map[string]User // by token hash
+}
+
+// NewAuthenticator returns an Authenticator for the users of the given auth config
+func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
+	auth := &Authenticator{users: map[string]User{}}
+	if cfg.DefaultQuota != nil {
+		if err := cfg.DefaultQuota.validate(); err != nil {
+			return nil, fmt.Errorf("invalid default quota: %w", err)
+		}
+	}
+	names := map[string]bool{}
+	for _, user := range cfg.Users {
+		if user.Name == "" {
+			return nil, fmt.Errorf("user without name")
+		}
+		if names[user.Name] {
+			return nil, fmt.Errorf("duplicate user '%s'", user.Name)
+		}
+		names[user.Name] = true
+		switch user.Role {
+		case "":
+			user.Role = RoleUser
+		case RoleUser, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("invalid role '%s' of user '%s': must be one of %v", user.Role, user.Name, []Role{RoleUser, RoleAdmin})
+		}
+		if user.Quota == nil && user.Role == RoleUser {
+			user.Quota = cfg.DefaultQuota
+		}
+		if user.Quota != nil {
+			if err := user.Quota.validate(); err != nil {
+				return nil, fmt.Errorf("invalid quota of user '%s': %w", user.Name, err)
+			}
+		}
+		hash := strings.ToLower(user.TokenSHA256)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid token hash of user '%s': must be a hex-encoded SHA-256 hash", user.Name)
+		}
+		if _, exists := auth.users[hash]; exists {
+			return nil, fmt.Errorf("user '%s' uses the same token as another user", user.Name)
+		}
+		auth.users[hash] = user
+	}
+	if len(auth.users) == 0 {
+		return nil, fmt.Errorf("no users configured")
+	}
+	return auth, nil
+}
+
+// LoadAuthenticator reads the auth config (YAML or JSON) from the given file and returns an Authenticator for its users
+func LoadAuthenticator(path string) (*Authenticator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth file '%s': %w", path, err)
+	}
+	var cfg AuthConfig
+	if err := yaml.UnmarshalStrict(content, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse auth file '%s': %w", path, err)
+	}
+	auth, err := NewAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth file '%s': %w", path, err)
+	}
+	return auth, nil
+}
+
+// Authenticate returns the user of the request's bearer token (Authorization header)
+func (a *Authenticator) Authenticate(r *http.Request) (*User, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrUnauthenticated
+	}
+	user, ok := a.users[HashToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return &user, nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of a token, as stored in the auth file
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateToken returns a new random token
+func GenerateToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+type userContextKey struct{}
+
+// userFromContext returns the authenticated user of a request (nil, if authentication is disabled)
+func userFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(userContextKey{}).(*User)
+	return user
+}
+
+// mayAccess tells whether the user may see and manage a cluster or job of the given owner
+func (u *User) mayAccess(owner string) bool {
+	return u == nil || u.Role == RoleAdmin || (owner != "" && owner == u.Name)
+}
+
+// clusterOwner returns the API user who created the cluster (empty for clusters not created via an API server with authentication)
+func clusterOwner(cluster *k3d.Cluster) string {
+	for _, node := range cluster.Nodes {
+		if owner, ok := node.RuntimeLabels[k3d.LabelClusterOwner]; ok {
+			return owner
+		}
+	}
+	return ""
+}
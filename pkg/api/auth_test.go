@@ -0,0 +1,194 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	hash := HashToken("secret")
+	tests := []struct {
+		name    string
+		users   []User
+		wantErr bool
+	}{
+		{name: "valid", users: []User{{Name: "alice", TokenSHA256: hash}, {Name: "admin", Role: RoleAdmin, TokenSHA256: HashToken("other")}}},
+		{name: "no users", wantErr: true},
+		{name: "no name", users: []User{{TokenSHA256: hash}}, wantErr: true},
+		{name: "duplicate name", users: []User{{Name: "alice", TokenSHA256: hash}, {Name: "alice", TokenSHA256: HashToken("other")}}, wantErr: true},
+		{name: "duplicate token", users: []User{{Name: "alice", TokenSHA256: hash}, {Name: "bob", TokenSHA256: hash}}, wantErr: true},
+		{name: "invalid role", users: []User{{Name: "alice", Role: "root", TokenSHA256: hash}}, wantErr: true},
+		{name: "plain token", users: []User{{Name: "alice", TokenSHA256: "secret"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(AuthConfig{Users: tt.users}); (err != nil) != tt.wantErr {
+				t.Errorf("NewAuthenticator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	content := "users:\n  - name: alice\n    tokenSha256: " + HashToken("alice-token") + "\n  - name: admin\n    role: admin\n    tokenSha256: " + HashToken("admin-token") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := LoadAuthenticator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		header   string
+		expected *User
+	}{
+		{header: "Bearer alice-token", expected: &User{Name: "alice", Role: RoleUser}},
+		{header: "Bearer admin-token", expected: &User{Name: "admin", Role: RoleAdmin}},
+		{header: "Bearer unknown"},
+		{header: "alice-token"},
+		{header: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/version", nil)
+			r.Header.Set("Authorization", tt.header)
+			user, err := auth.Authenticate(r)
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got user %+v", user)
+				}
+				return
+			}
+			if err != nil || user.Name != tt.expected.Name || user.Role != tt.expected.Role {
+				t.Errorf("expected user %+v, got %+v (err: %v)", tt.expected, user, err)
+			}
+		})
+	}
+
+	if _, err := LoadAuthenticator(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("expected an error for a missing auth file")
+	}
+}
+
+func TestUserMayAccess(t *testing.T) {
+	var noAuth *User
+	alice := &User{Name: "alice", Role: RoleUser}
+	admin := &User{Name: "admin", Role: RoleAdmin}
+	tests := []struct {
+		name     string
+		user     *User
+		owner    string
+		expected bool
+	}{
+		{name: "no authentication", user: noAuth, owner: "", expected: true},
+		{name: "own", user: alice, owner: "alice", expected: true},
+		{name: "other user's", user: alice, owner: "bob", expected: false},
+		{name: "without owner", user: alice, owner: "", expected: false},
+		{name: "admin", user: admin, owner: "bob", expected: true},
+		{name: "admin without owner", user: admin, owner: "", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.user.mayAccess(tt.owner); actual != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHandleJobsWithAuth(t *testing.T) {
+	auth, err := NewAuthenticator(AuthConfig{Users: []User{
+		{Name: "alice", TokenSHA256: HashToken("alice-token")},
+		{Name: "bob", TokenSHA256: HashToken("bob-token")},
+		{Name: "admin", Role: RoleAdmin, TokenSHA256: HashToken("admin-token")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(nil)
+	server.auth = auth
+	defer server.Close()
+	handler := server.Handler()
+
+	alicesJob, err := server.jobs.Submit(JobOperationClusterDelete, "alices", "alice", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, server.jobs, alicesJob.ID)
+	bobsJob, err := server.jobs.Submit(JobOperationClusterDelete, "bobs", "bob", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, server.jobs, bobsJob.ID)
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "no token", method: http.MethodGet, path: "/v1/version", status: http.StatusUnauthorized},
+		{name: "unknown token", method: http.MethodGet, path: "/v1/jobs", token: "mallory-token", status: http.StatusUnauthorized},
+		{name: "version", method: http.MethodGet, path: "/v1/version", token: "alice-token", status: http.StatusOK},
+		{name: "own job", method: http.MethodGet, path: "/v1/jobs/" + alicesJob.ID, token: "alice-token", status: http.StatusOK},
+		{name: "other user's job", method: http.MethodGet, path: "/v1/jobs/" + bobsJob.ID, token: "alice-token", status: http.StatusForbidden},
+		{name: "cancel other user's job", method: http.MethodPost, path: "/v1/jobs/" + bobsJob.ID + "/cancel", token: "alice-token", status: http.StatusForbidden},
+		{name: "admin gets any job", method: http.MethodGet, path: "/v1/jobs/" + bobsJob.ID, token: "admin-token", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := request(tt.method, tt.path, tt.token); rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d (%s)", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	for token, expected := range map[string]int{"alice-token": 1, "bob-token": 1, "admin-token": 2} {
+		var jobs []Job
+		if err := json.Unmarshal(request(http.MethodGet, "/v1/jobs", token).Body.Bytes(), &jobs); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(jobs) != expected {
+			t.Errorf("expected %d visible jobs with token %s, got %+v", expected, token, jobs)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
+)
+
+// JobOperation is the operation run by a job of the local API
+type JobOperation string
+
+// Operations that can be run as jobs
+const (
+	JobOperationClusterCreate JobOperation = "cluster-create"
+	JobOperationClusterDelete JobOperation = "cluster-delete"
+	JobOperationImageImport   JobOperation = "image-import"
+)
+
+// JobState is the state of a job of the local API
+type JobState string
+
+// States of a job
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+	JobStateCanceled  JobState = "canceled"
+)
+
+// Job is a long-running operation that the local API runs in the background
+type Job struct {
+	ID        string       `yaml:"id" json:"id"`
+	Operation JobOperation `yaml:"operation" json:"operation"`
+	Cluster   string       `yaml:"cluster" json:"cluster"`
+	Owner     string       `yaml:"owner,omitempty" json:"owner,omitempty"`   // API user owning the cluster (if authentication is enabled)
+	Server    string       `yaml:"server,omitempty" json:"server,omitempty"` // API server running the job (see StateStore.Heartbeat)
+	State     JobState     `yaml:"state" json:"state"`
+	Phase     string       `yaml:"phase,omitempty" json:"phase,omitempty"`
+	Percent   int          `yaml:"percent" json:"percent"`
+	Message   string       `yaml:"message,omitempty" json:"message,omitempty"`
+	Error     string       `yaml:"error,omitempty" json:"error,omitempty"`
+	Created   time.Time    `yaml:"created" json:"created"`
+	Started   *time.Time   `yaml:"started,omitempty" json:"started,omitempty"`
+	Finished  *time.Time   `yaml:"finished,omitempty" json:"finished,omitempty"`
+}
+
+// Done returns true if the job won't change anymore
+func (j Job) Done() bool {
+	return j.State == JobStateSucceeded || j.State == JobStateFailed || j.State == JobStateCanceled
+}
+
+// maxQueuedJobs limits how many jobs may wait for execution
+const maxQueuedJobs = 256
+
+// maxFinishedJobs limits how many finished jobs are kept for querying their result
+const maxFinishedJobs = 100
+
+// jobHeartbeatInterval is the time between two heartbeats of an API server in its state store
+const jobHeartbeatInterval = 10 * time.Second
+
+// jobHeartbeatTimeout is the time after which the unfinished jobs of an API server without heartbeat are considered failed
+const jobHeartbeatTimeout = 6 * jobHeartbeatInterval
+
+// ErrJobNotFound is returned for unknown job IDs
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobDone is returned when trying to cancel a job that's done already
+var ErrJobDone = errors.New("job is done already")
+
+// ErrJobRemote is returned when trying to cancel a job of another API server sharing the state store
+var ErrJobRemote = errors.New("job is run by another API server")
+
+type jobEntry struct {
+	job      Job
+	run      func(ctx context.Context) error
+	ctx      context.Context
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// jobQueue runs jobs one after another (progress reporting is global) and keeps track of their state in the state store
+type jobQueue struct {
+	server string // ID of this API server, recorded in its jobs
+	mutex  sync.Mutex
+	jobs   map[string]*jobEntry // jobs of this API server, which are not done yet
+	queue  chan *jobEntry
+	store  StateStore // its lock is held while a job runs, shared with operations outside of the queue
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the worker stopped
+}
+
+func newJobQueue(store StateStore) *jobQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &jobQueue{
+		server: newServerID(),
+		jobs:   map[string]*jobEntry{},
+		queue:  make(chan *jobEntry, maxQueuedJobs),
+		store:  store,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	q.heartbeat()
+	go q.work()
+	go q.heartbeats()
+	return q
+}
+
+// newServerID returns a unique ID for an API server, recorded in the jobs it runs
+func newServerID() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// heartbeats sends heartbeats to the state store until the queue is closed, so that other API servers sharing it
+// can tell whether the jobs of this one are still running
+func (q *jobQueue) heartbeats() {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+			q.heartbeat()
+		}
+	}
+}
+
+func (q *jobQueue) heartbeat() {
+	if err := q.store.Heartbeat(q.server); err != nil {
+		l.Log().Warnf("Failed to send heartbeat to the state store: %v", err)
+	}
+}
+
+// Submit queues a new job for the cluster (owned by the given API user) and returns it
+func (q *jobQueue) Submit(operation JobOperation, cluster string, owner string, run func(ctx context.Context) error) (Job, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	id, err := q.store.NextJobID()
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to get job ID: %w", err)
+	}
+	ctx, cancel := context.WithCancel(q.ctx)
+	entry := &jobEntry{
+		job: Job{
+			ID:        id,
+			Operation: operation,
+			Cluster:   cluster,
+			Owner:     owner,
+			Server:    q.server,
+			State:     JobStateQueued,
+			Created:   time.Now(),
+		},
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if err := q.store.SaveJob(entry.job); err != nil {
+		cancel()
+		return Job{}, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	select {
+	case q.queue <- entry:
+	default:
+		cancel()
+		q.finish(entry, fmt.Errorf("too many queued jobs (max. %d)", maxQueuedJobs))
+		return Job{}, fmt.Errorf("too many queued jobs (max. %d)", maxQueuedJobs)
+	}
+	q.jobs[entry.job.ID] = entry
+	return entry.job, nil
+}
+
+// Get returns the job with the given ID
+func (q *jobQueue) Get(id string) (Job, error) {
+	return q.store.GetJob(id)
+}
+
+// List returns all known jobs in order of submission
+func (q *jobQueue) List() ([]Job, error) {
+	return q.store.ListJobs()
+}
+
+// Cancel cancels a queued or running job.
+// Running jobs are canceled via their context, so they may take a moment to (roll back and) finish.
+func (q *jobQueue) Cancel(id string) (Job, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	entry, ok := q.jobs[id]
+	if !ok {
+		job, err := q.store.GetJob(id)
+		if err != nil {
+			return Job{}, err
+		}
+		if job.Done() {
+			return job, ErrJobDone
+		}
+		return job, ErrJobRemote
+	}
+	entry.canceled = true
+	entry.cancel()
+	if entry.job.State == JobStateQueued {
+		q.finish(entry, nil)
+	}
+	return entry.job, nil
+}
+
+// Close cancels all jobs and stops the queue
+func (q *jobQueue) Close() {
+	q.cancel()
+	<-q.done
+
+	// jobs that didn't get to run won't ever run
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for _, entry := range q.jobs {
+		if entry.job.State == JobStateQueued {
+			entry.canceled = true
+			q.finish(entry, errors.New("the API server stopped"))
+		}
+	}
+}
+
+func (q *jobQueue) work() {
+	defer close(q.done)
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case entry := <-q.queue:
+			q.runJob(entry)
+		}
+	}
+}
+
+func (q *jobQueue) runJob(entry *jobEntry) {
+	q.store.Lock()
+	defer q.store.Unlock()
+
+	q.mutex.Lock()
+	if entry.job.State != JobStateQueued { // canceled while queued
+		q.mutex.Unlock()
+		return
+	}
+	now := time.Now()
+	entry.job.State = JobStateRunning
+	entry.job.Started = &now
+	q.save(entry)
+	q.mutex.Unlock()
+
+	progress.SetOutput(&jobProgressWriter{queue: q, entry: entry})
+	err := entry.run(entry.ctx)
+	progress.SetOutput(nil)
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.finish(entry, err)
+}
+
+// finish sets the final state of a job (expects the mutex to be held)
+func (q *jobQueue) finish(entry *jobEntry, err error) {
+	now := time.Now()
+	entry.job.Finished = &now
+	switch {
+	case entry.canceled:
+		entry.job.State = JobStateCanceled
+		if err != nil {
+			entry.job.Error = err.Error()
+		}
+	case err != nil:
+		entry.job.State = JobStateFailed
+		entry.job.Error = err.Error()
+	default:
+		entry.job.State = JobStateSucceeded
+		entry.job.Percent = 100
+	}
+	entry.cancel()
+	q.save(entry)
+	delete(q.jobs, entry.job.ID)
+}
+
+// save writes the job to the state store (expects the mutex to be held)
+func (q *jobQueue) save(entry *jobEntry) {
+	if err := q.store.SaveJob(entry.job); err != nil {
+		l.Log().Warnf("Failed to save job %s: %v", entry.job.ID, err)
+	}
+}
+
+// jobProgressWriter records the progress events emitted while a job runs in the job
+type jobProgressWriter struct {
+	queue *jobQueue
+	entry *jobEntry
+}
+
+func (w *jobProgressWriter) Write(p []byte) (int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(p))
+	for scanner.Scan() {
+		var event progress.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		w.queue.mutex.Lock()
+		w.entry.job.Phase = event.Phase
+		w.entry.job.Percent = event.Percent
+		w.entry.job.Message = event.Message
+		w.queue.save(w.entry)
+		w.queue.mutex.Unlock()
+	}
+	return len(p), nil
+}
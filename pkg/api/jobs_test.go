@@ -0,0 +1,202 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/k3d/v5/pkg/progress"
+)
+
+// waitForJob polls the queue until the job is done
+func waitForJob(t *testing.T, q *jobQueue, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s didn't finish in time", id)
+	return Job{}
+}
+
+func TestJobQueue(t *testing.T) {
+	q := newJobQueue(newMemoryStateStore())
+	defer q.Close()
+
+	var order []string
+	var orderMutex sync.Mutex
+	record := func(name string) {
+		orderMutex.Lock()
+		defer orderMutex.Unlock()
+		order = append(order, name)
+	}
+
+	succeeding, err := q.Submit(JobOperationClusterCreate, "one", "", func(ctx context.Context) error {
+		progress.Report(progress.OperationClusterCreate, "create", 20, "Creating node containers")
+		record("one")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failing, err := q.Submit(JobOperationClusterDelete, "two", "", func(ctx context.Context) error {
+		record("two")
+		return errors.New("boom")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	job := waitForJob(t, q, succeeding.ID)
+	if job.State != JobStateSucceeded || job.Percent != 100 || job.Phase != "create" || job.Started == nil || job.Finished == nil {
+		t.Errorf("unexpected succeeded job: %+v", job)
+	}
+	job = waitForJob(t, q, failing.ID)
+	if job.State != JobStateFailed || job.Error != "boom" {
+		t.Errorf("unexpected failed job: %+v", job)
+	}
+	if len(order) != 2 || order[0] != "one" || order[1] != "two" {
+		t.Errorf("expected jobs to run in order of submission, got %v", order)
+	}
+
+	jobs, err := q.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != succeeding.ID || jobs[1].ID != failing.ID {
+		t.Errorf("unexpected job list: %+v", jobs)
+	}
+	if _, err := q.Get("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+	if _, err := q.Cancel(succeeding.ID); !errors.Is(err, ErrJobDone) {
+		t.Errorf("expected ErrJobDone when canceling a finished job, got %v", err)
+	}
+}
+
+func TestJobQueueCancel(t *testing.T) {
+	q := newJobQueue(newMemoryStateStore())
+	defer q.Close()
+
+	started := make(chan struct{})
+	running, err := q.Submit(JobOperationClusterCreate, "running", "", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ran := false
+	queued, err := q.Submit(JobOperationImageImport, "queued", "", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	<-started
+	job, err := q.Cancel(queued.ID)
+	if err != nil || job.State != JobStateCanceled {
+		t.Fatalf("expected queued job to be canceled immediately, got %+v (err: %v)", job, err)
+	}
+	if _, err := q.Cancel(running.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job = waitForJob(t, q, running.ID)
+	if job.State != JobStateCanceled {
+		t.Errorf("expected running job to be canceled, got %+v", job)
+	}
+
+	// the queue keeps working after cancellations
+	next, err := q.Submit(JobOperationClusterDelete, "next", "", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job := waitForJob(t, q, next.ID); job.State != JobStateSucceeded {
+		t.Errorf("expected job after cancellations to succeed, got %+v", job)
+	}
+	if ran {
+		t.Errorf("canceled queued job was run")
+	}
+}
+
+func TestHandleJobs(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	handler := server.Handler()
+
+	submitted, err := server.jobs.Submit(JobOperationClusterDelete, "test", "", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForJob(t, server.jobs, submitted.ID)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "list", method: http.MethodGet, path: "/v1/jobs", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/v1/jobs/" + submitted.ID, status: http.StatusOK},
+		{name: "get unknown", method: http.MethodGet, path: "/v1/jobs/42", status: http.StatusNotFound},
+		{name: "cancel finished", method: http.MethodPost, path: "/v1/jobs/" + submitted.ID + "/cancel", status: http.StatusConflict},
+		{name: "cancel with wrong method", method: http.MethodGet, path: "/v1/jobs/" + submitted.ID + "/cancel", status: http.StatusMethodNotAllowed},
+		{name: "unknown endpoint", method: http.MethodGet, path: "/v1/jobs/" + submitted.ID + "/foo", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d (%s)", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/jobs/"+submitted.ID, nil))
+	var job Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if job.ID != submitted.ID || job.Operation != JobOperationClusterDelete || job.State != JobStateSucceeded {
+		t.Errorf("unexpected job: %+v", job)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ErrForbiddenConfig is returned for cluster configs of users without the admin role, that would give them access to the host or to the resources of other users
+var ErrForbiddenConfig = errors.New("not allowed for users without the admin role")
+
+// checkRestrictedSimpleConfig checks the parts of a simple config of a user without the admin role, that would make k3d access the host on behalf of the user
+// while transforming the config or creating the cluster: reading host files or URLs (e.g. manifests, registry configs, image archives),
+// writing to host paths (node data directories, kubeconfigs, credential stores) or connecting to arbitrary addresses (startup checks).
+// It has to run before the config gets transformed, as the transformation already reads those files.
+func checkRestrictedSimpleConfig(simpleCfg *conf.SimpleConfig) error {
+	forbidden := func(what string) error {
+		return fmt.Errorf("%w: %s", ErrForbiddenConfig, what)
+	}
+
+	for _, volume := range simpleCfg.Volumes {
+		if src := strings.SplitN(volume.Volume, ":", 2)[0]; strings.Contains(volume.Volume, ":") && isHostPath(src) {
+			return forbidden(fmt.Sprintf("mounting the host path '%s'", src))
+		}
+	}
+	runtimeOpts := simpleCfg.Options.Runtime
+	switch {
+	case len(runtimeOpts.Opts) > 0:
+		return forbidden("runtime options")
+	case runtimeOpts.ServersData != "" || runtimeOpts.AgentsData != "":
+		return forbidden("node data directories on the host")
+	}
+
+	k3sOpts := simpleCfg.Options.K3sOptions
+	switch {
+	case len(k3sOpts.Manifests) > 0:
+		return forbidden("manifests from host files or URLs")
+	case len(k3sOpts.ImagesArchives) > 0:
+		return forbidden("image archives from host files")
+	case k3sOpts.CoreDNSCustom != "" && !strings.Contains(k3sOpts.CoreDNSCustom, "\n"):
+		return forbidden("a custom CoreDNS config file (embed the config instead)")
+	case simpleCfg.Registries.Config != "" && !strings.Contains(simpleCfg.Registries.Config, "\n"):
+		return forbidden("a registries config file (embed the config instead)")
+	}
+	for _, hook := range simpleCfg.Hooks {
+		if hook.ApplyManifest != "" {
+			return forbidden(fmt.Sprintf("applying the manifest file '%s' in a hook", hook.ApplyManifest))
+		}
+	}
+	if len(simpleCfg.Options.K3dOptions.Startup.Checks) > 0 {
+		return forbidden("startup checks (they connect from the host running the API server)")
+	}
+
+	kubeconfigOpts := simpleCfg.Options.KubeconfigOptions
+	switch {
+	case kubeconfigOpts.Output != "":
+		return forbidden(fmt.Sprintf("writing the kubeconfig to the file '%s'", kubeconfigOpts.Output))
+	case kubeconfigOpts.UpdateDefaultKubeconfig:
+		return forbidden("updating the default kubeconfig of the API server")
+	case kubeconfigOpts.Encryption.Store != "":
+		return forbidden("storing the credentials in a credential store of the API server")
+	}
+	return nil
+}
+
+// isHostPath returns whether the source of a volume mount is a host path (instead of the name of a volume)
+func isHostPath(src string) bool {
+	return strings.ContainsAny(src, `/\`) || strings.HasPrefix(src, ".") || strings.HasPrefix(src, "~")
+}
+
+// isImageArchive returns whether an image to import refers to an archive on the host (or stdin) instead of an image in the runtime
+func isImageArchive(image string) bool {
+	if image == "-" || strings.Contains(image, `\`) || strings.HasPrefix(image, "/") || strings.HasPrefix(image, ".") || strings.HasPrefix(image, "~") {
+		return true
+	}
+	for _, ext := range []string{".tar", ".tgz", ".tar.gz"} {
+		if strings.HasSuffix(image, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRestrictedConfig checks the parts of a cluster config of a user without the admin role, that don't depend on the existing resources:
+// host paths mounted into nodes, raw runtime options (e.g. devices or capabilities), existing networks and registries.
+// It returns the named volumes used by the nodes, which must not exist yet.
+func checkRestrictedConfig(clusterConfig *conf.ClusterConfig) ([]string, error) {
+	cluster := &clusterConfig.Cluster
+	if cluster.Network.External {
+		return nil, fmt.Errorf("%w: using the existing network '%s'", ErrForbiddenConfig, cluster.Network.Name)
+	}
+	if len(clusterConfig.ClusterCreateOpts.Registries.Use) > 0 {
+		return nil, fmt.Errorf("%w: using the existing registry '%s'", ErrForbiddenConfig, clusterConfig.ClusterCreateOpts.Registries.Use[0].Host)
+	}
+
+	volumes := []string{}
+	if clusterConfig.ClusterCreateOpts.ImageVolume != "" {
+		volumes = append(volumes, clusterConfig.ClusterCreateOpts.ImageVolume)
+	}
+	for _, node := range cluster.Nodes {
+		if len(node.RuntimeOpts) > 0 {
+			return nil, fmt.Errorf("%w: runtime options of node '%s'", ErrForbiddenConfig, node.Name)
+		}
+		for _, volume := range node.Volumes {
+			parts := strings.SplitN(volume, ":", 2)
+			if len(parts) < 2 {
+				continue // anonymous volume
+			}
+			if src := parts[0]; isHostPath(src) {
+				return nil, fmt.Errorf("%w: mounting the host path '%s' into node '%s'", ErrForbiddenConfig, src, node.Name)
+			}
+			volumes = append(volumes, parts[0])
+		}
+	}
+	return volumes, nil
+}
+
+// checkUserSimpleConfig rejects simple configs of users without the admin role, that would make k3d access the host on their behalf (see checkRestrictedSimpleConfig)
+func checkUserSimpleConfig(user *User, simpleCfg *conf.SimpleConfig) error {
+	if user == nil || user.Role == RoleAdmin {
+		return nil
+	}
+	return checkRestrictedSimpleConfig(simpleCfg)
+}
+
+// checkUserConfig rejects cluster configs of users without the admin role, that would give them access to the host or to the resources of other users
+// (see checkRestrictedConfig). The cluster network and the named volumes must not exist yet, as they could belong to another user's cluster.
+func (s *Server) checkUserConfig(ctx context.Context, user *User, clusterConfig *conf.ClusterConfig) error {
+	if user == nil || user.Role == RoleAdmin {
+		return nil
+	}
+	volumes, err := checkRestrictedConfig(clusterConfig)
+	if err != nil {
+		return err
+	}
+
+	network := clusterConfig.Cluster.Network.Name
+	if _, err := s.runtime.GetNetwork(ctx, &k3d.ClusterNetwork{Name: network}); err == nil {
+		return fmt.Errorf("%w: using the existing network '%s'", ErrForbiddenConfig, network)
+	} else if !errors.Is(err, runtimeErr.ErrRuntimeNetworkNotExists) {
+		return fmt.Errorf("failed to check network '%s': %w", network, err)
+	}
+	for _, volume := range volumes {
+		if _, err := s.runtime.GetVolume(ctx, volume); err == nil {
+			return fmt.Errorf("%w: using the existing volume '%s'", ErrForbiddenConfig, volume)
+		} else if !errors.Is(err, runtimeErr.ErrRuntimeVolumeNotExists) {
+			return fmt.Errorf("failed to check volume '%s': %w", volume, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestCheckRestrictedConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(cfg *conf.ClusterConfig)
+		wantVolumes []string
+		forbidden   bool
+	}{
+		{
+			name:        "default",
+			modify:      func(cfg *conf.ClusterConfig) {},
+			wantVolumes: []string{},
+		},
+		{
+			name: "named and anonymous volumes",
+			modify: func(cfg *conf.ClusterConfig) {
+				cfg.Cluster.Nodes[0].Volumes = []string{"data:/data", "/cache"}
+				cfg.ClusterCreateOpts.ImageVolume = "images"
+			},
+			wantVolumes: []string{"images", "data"},
+		},
+		{
+			name:      "host path",
+			modify:    func(cfg *conf.ClusterConfig) { cfg.Cluster.Nodes[0].Volumes = []string{"/:/host"} },
+			forbidden: true,
+		},
+		{
+			name:      "relative host path",
+			modify:    func(cfg *conf.ClusterConfig) { cfg.Cluster.Nodes[0].Volumes = []string{"./src:/src:ro"} },
+			forbidden: true,
+		},
+		{
+			name:      "runtime options",
+			modify:    func(cfg *conf.ClusterConfig) { cfg.Cluster.Nodes[0].RuntimeOpts = []string{"cap-add=SYS_ADMIN"} },
+			forbidden: true,
+		},
+		{
+			name: "existing network",
+			modify: func(cfg *conf.ClusterConfig) {
+				cfg.Cluster.Network = k3d.ClusterNetwork{Name: "k3d-alice", External: true}
+			},
+			forbidden: true,
+		},
+		{
+			name: "existing registry",
+			modify: func(cfg *conf.ClusterConfig) {
+				cfg.ClusterCreateOpts.Registries.Use = []*k3d.Registry{{Host: "k3d-alice-registry"}}
+			},
+			forbidden: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &conf.ClusterConfig{
+				Cluster: k3d.Cluster{
+					Name:    "bob",
+					Network: k3d.ClusterNetwork{Name: "k3d-bob"},
+					Nodes:   []*k3d.Node{{Name: "k3d-bob-server-0", Role: k3d.ServerRole}},
+				},
+			}
+			tt.modify(cfg)
+			volumes, err := checkRestrictedConfig(cfg)
+			if forbidden := errors.Is(err, ErrForbiddenConfig); forbidden != tt.forbidden || (err != nil && !forbidden) {
+				t.Fatalf("expected forbidden: %t, got error %v", tt.forbidden, err)
+			}
+			if !tt.forbidden && !reflect.DeepEqual(volumes, tt.wantVolumes) {
+				t.Errorf("expected volumes %v, got %v", tt.wantVolumes, volumes)
+			}
+		})
+	}
+}
+
+func TestCheckRestrictedSimpleConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(cfg *conf.SimpleConfig)
+		forbidden bool
+	}{
+		{name: "default", modify: func(cfg *conf.SimpleConfig) {}},
+		{
+			name: "named volume and embedded configs",
+			modify: func(cfg *conf.SimpleConfig) {
+				cfg.Volumes = []conf.VolumeWithNodeFilters{{Volume: "data:/data"}, {Volume: "/cache"}}
+				cfg.Options.K3sOptions.CoreDNSCustom = "hosts:\n  10.0.0.1: git.internal\n"
+				cfg.Registries.Config = "mirrors:\n  docker.io:\n    endpoint: [http://cache:5000]\n"
+			},
+		},
+		{name: "host path", modify: func(cfg *conf.SimpleConfig) { cfg.Volumes = []conf.VolumeWithNodeFilters{{Volume: "/etc:/host-etc"}} }, forbidden: true},
+		{name: "runtime options", modify: func(cfg *conf.SimpleConfig) {
+			cfg.Options.Runtime.Opts = []conf.OptWithNodeFilters{{Opt: "privileged=true"}}
+		}, forbidden: true},
+		{name: "node data", modify: func(cfg *conf.SimpleConfig) { cfg.Options.Runtime.ServersData = "/var/lib/k3d" }, forbidden: true},
+		{name: "manifests", modify: func(cfg *conf.SimpleConfig) { cfg.Options.K3sOptions.Manifests = []string{"/root/.ssh/id_rsa"} }, forbidden: true},
+		{name: "images archives", modify: func(cfg *conf.SimpleConfig) { cfg.Options.K3sOptions.ImagesArchives = []string{"/tmp/images.tar"} }, forbidden: true},
+		{name: "CoreDNS config file", modify: func(cfg *conf.SimpleConfig) { cfg.Options.K3sOptions.CoreDNSCustom = "/etc/shadow" }, forbidden: true},
+		{name: "registries config file", modify: func(cfg *conf.SimpleConfig) { cfg.Registries.Config = "/etc/shadow" }, forbidden: true},
+		{
+			name: "hook manifest",
+			modify: func(cfg *conf.SimpleConfig) {
+				cfg.Hooks = []conf.SimpleConfigHook{{Stage: "postClusterReady", ApplyManifest: "/etc/shadow"}}
+			},
+			forbidden: true,
+		},
+		{
+			name: "startup checks",
+			modify: func(cfg *conf.SimpleConfig) {
+				cfg.Options.K3dOptions.Startup.Checks = []conf.SimpleConfigOptionsK3dStartupCheck{{Name: "meta", HTTP: "http://169.254.169.254/"}}
+			},
+			forbidden: true,
+		},
+		{name: "kubeconfig output", modify: func(cfg *conf.SimpleConfig) { cfg.Options.KubeconfigOptions.Output = "/root/.bashrc" }, forbidden: true},
+		{name: "default kubeconfig", modify: func(cfg *conf.SimpleConfig) { cfg.Options.KubeconfigOptions.UpdateDefaultKubeconfig = true }, forbidden: true},
+		{
+			name: "credential store",
+			modify: func(cfg *conf.SimpleConfig) {
+				cfg.Options.KubeconfigOptions.Encryption = k3d.KubeconfigEncryption{Store: k3d.KubeconfigCredentialStoreKeychain}
+			},
+			forbidden: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &conf.SimpleConfig{Name: "bob", Servers: 1}
+			tt.modify(cfg)
+			err := checkRestrictedSimpleConfig(cfg)
+			if forbidden := errors.Is(err, ErrForbiddenConfig); forbidden != tt.forbidden || (err != nil && !forbidden) {
+				t.Fatalf("expected forbidden: %t, got error %v", tt.forbidden, err)
+			}
+		})
+	}
+}
+
+func TestIsImageArchive(t *testing.T) {
+	tests := map[string]bool{
+		"nginx":                      false,
+		"library/nginx:1.21":         false,
+		"registry.local:5000/app:v1": false,
+		"-":                          true,
+		"/tmp/images.tar":            true,
+		"./images.tar":               true,
+		"~/images.tgz":               true,
+		`C:\images.tar`:              true,
+		"images/app.tar.gz":          true,
+	}
+	for image, want := range tests {
+		if got := isImageArchive(image); got != want {
+			t.Errorf("isImageArchive(%q) = %t, want %t", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	dockerunits "github.com/docker/go-units"
+
+	"github.com/rancher/k3d/v5/pkg/client"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// Quota limits the resources of the clusters a user creates via the API (zero values: unlimited)
+type Quota struct {
+	MaxClusters int    `yaml:"maxClusters,omitempty" json:"maxClusters,omitempty"`
+	MaxNodes    int    `yaml:"maxNodes,omitempty" json:"maxNodes,omitempty"`   // k3s nodes (servers and agents) of all clusters
+	MaxMemory   string `yaml:"maxMemory,omitempty" json:"maxMemory,omitempty"` // sum of the memory limits of all k3s nodes (e.g. 16g)
+}
+
+// ErrQuotaExceeded is returned if creating a cluster would exceed the quota of the user
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+func (q *Quota) validate() error {
+	if q.MaxClusters < 0 || q.MaxNodes < 0 {
+		return fmt.Errorf("maxClusters and maxNodes must not be negative")
+	}
+	if q.MaxMemory != "" {
+		if memory, err := dockerunits.RAMInBytes(q.MaxMemory); err != nil || memory <= 0 {
+			return fmt.Errorf("invalid maxMemory '%s': must be a positive amount of memory (e.g. 16g)", q.MaxMemory)
+		}
+	}
+	return nil
+}
+
+// check returns an error wrapping ErrQuotaExceeded, if creating the cluster would exceed the quota given the user's existing clusters
+func (q *Quota) check(existing []*k3d.Cluster, cluster *k3d.Cluster) error {
+	if q.MaxClusters > 0 && len(existing)+1 > q.MaxClusters {
+		return fmt.Errorf("%w: %d of max. %d clusters exist already", ErrQuotaExceeded, len(existing), q.MaxClusters)
+	}
+
+	var maxMemory int64
+	if q.MaxMemory != "" {
+		maxMemory, _ = dockerunits.RAMInBytes(q.MaxMemory) // validated when loading the quota
+	}
+
+	usedNodes, usedMemory := 0, int64(0)
+	for _, c := range existing {
+		nodes, memory, _ := k3sNodesResources(c.Nodes)
+		usedNodes += nodes
+		usedMemory += memory
+	}
+	newNodes, newMemory, unlimited := k3sNodesResources(cluster.Nodes)
+
+	if q.MaxNodes > 0 && usedNodes+newNodes > q.MaxNodes {
+		return fmt.Errorf("%w: the cluster has %d nodes, but only %d of max. %d nodes are left", ErrQuotaExceeded, newNodes, q.MaxNodes-usedNodes, q.MaxNodes)
+	}
+	if maxMemory > 0 {
+		if len(unlimited) > 0 {
+			return fmt.Errorf("%w: node '%s' has no memory limit, but the memory is limited to %s (set options.runtime.serversMemory and agentsMemory or clusterMemoryLimit)", ErrQuotaExceeded, unlimited[0], q.MaxMemory)
+		}
+		if usedMemory+newMemory > maxMemory {
+			return fmt.Errorf("%w: the cluster needs %s of memory, but only %s of max. %s are left", ErrQuotaExceeded,
+				dockerunits.BytesSize(float64(newMemory)), dockerunits.BytesSize(float64(maxMemory-usedMemory)), q.MaxMemory)
+		}
+	}
+	return nil
+}
+
+// k3sNodesResources returns the number and the total memory limit of the k3s nodes (servers and agents), as well as the names of those without memory limit
+func k3sNodesResources(nodes []*k3d.Node) (count int, memory int64, unlimited []string) {
+	for _, node := range nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
+		count++
+		if node.Memory == "" {
+			unlimited = append(unlimited, node.Name)
+			continue
+		}
+		if bytes, err := dockerunits.RAMInBytes(node.Memory); err == nil {
+			memory += bytes
+		}
+	}
+	return count, memory, unlimited
+}
+
+// checkQuota checks that the user may create the cluster without exceeding their quota (if any)
+func (s *Server) checkQuota(ctx context.Context, user *User, cluster *k3d.Cluster) error {
+	if user == nil || user.Quota == nil {
+		return nil
+	}
+	clusters, err := client.ClusterList(ctx, s.runtime)
+	if err != nil {
+		return fmt.Errorf("failed to list clusters to check the quota: %w", err)
+	}
+	owned := []*k3d.Cluster{}
+	for _, c := range clusters {
+		if clusterOwner(c) == user.Name {
+			owned = append(owned, c)
+		}
+	}
+	return user.Quota.check(owned, cluster)
+}
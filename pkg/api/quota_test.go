@@ -0,0 +1,96 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package api
+
+import (
+	"errors"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestQuotaCheck(t *testing.T) {
+	cluster := func(memory ...string) *k3d.Cluster {
+		c := &k3d.Cluster{Nodes: []*k3d.Node{{Name: "k3d-serverlb", Role: k3d.LoadBalancerRole}}}
+		for i, m := range memory {
+			role := k3d.AgentRole
+			if i == 0 {
+				role = k3d.ServerRole
+			}
+			c.Nodes = append(c.Nodes, &k3d.Node{Name: string(role), Role: role, Memory: m})
+		}
+		return c
+	}
+	existing := []*k3d.Cluster{cluster("1073741824", "1073741824"), cluster("2147483648")} // 3 nodes, 4GiB
+
+	tests := []struct {
+		name     string
+		quota    Quota
+		cluster  *k3d.Cluster
+		exceeded bool
+	}{
+		{name: "unlimited", quota: Quota{}, cluster: cluster("", "")},
+		{name: "clusters left", quota: Quota{MaxClusters: 3}, cluster: cluster("")},
+		{name: "too many clusters", quota: Quota{MaxClusters: 2}, cluster: cluster(""), exceeded: true},
+		{name: "nodes left", quota: Quota{MaxNodes: 5}, cluster: cluster("", "")},
+		{name: "too many nodes", quota: Quota{MaxNodes: 5}, cluster: cluster("", "", ""), exceeded: true},
+		{name: "memory left", quota: Quota{MaxMemory: "6g"}, cluster: cluster("1g", "1g")},
+		{name: "too much memory", quota: Quota{MaxMemory: "6g"}, cluster: cluster("2g", "1g"), exceeded: true},
+		{name: "memory not limited", quota: Quota{MaxMemory: "64g"}, cluster: cluster("1g", ""), exceeded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.check(existing, tt.cluster)
+			if exceeded := errors.Is(err, ErrQuotaExceeded); exceeded != tt.exceeded || (err != nil && !exceeded) {
+				t.Errorf("expected quota exceeded: %t, got error %v", tt.exceeded, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorQuotas(t *testing.T) {
+	defaultQuota := &Quota{MaxClusters: 2}
+	own := &Quota{MaxClusters: 10}
+	auth, err := NewAuthenticator(AuthConfig{
+		DefaultQuota: defaultQuota,
+		Users: []User{
+			{Name: "alice", TokenSHA256: HashToken("alice")},
+			{Name: "bob", TokenSHA256: HashToken("bob"), Quota: own},
+			{Name: "admin", Role: RoleAdmin, TokenSHA256: HashToken("admin")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]*Quota{"alice": defaultQuota, "bob": own, "admin": nil}
+	for _, user := range auth.users {
+		if user.Quota != expected[user.Name] {
+			t.Errorf("expected quota %+v for user %s, got %+v", expected[user.Name], user.Name, user.Quota)
+		}
+	}
+
+	for _, quota := range []*Quota{{MaxNodes: -1}, {MaxMemory: "lots"}} {
+		if _, err := NewAuthenticator(AuthConfig{DefaultQuota: quota, Users: []User{{Name: "alice", TokenSHA256: HashToken("alice")}}}); err == nil {
+			t.Errorf("expected an error for the invalid quota %+v", quota)
+		}
+	}
+}
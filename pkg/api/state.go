@@ -0,0 +1,205 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateStore keeps the jobs of the API server.
+// API servers sharing a store (e.g. all users of a shared host) see the same jobs and serialize their cluster operations via its lock.
+type StateStore interface {
+	sync.Locker                    // held while a cluster operation runs, shared by all API servers using the store
+	NextJobID() (string, error)    // returns a new, unique job ID
+	SaveJob(job Job) error         // creates or updates a job (and forgets the oldest finished jobs)
+	GetJob(id string) (Job, error) // returns ErrJobNotFound for unknown jobs
+	ListJobs() ([]Job, error)      // returns all jobs in order of creation
+	Heartbeat(server string) error // records that the API server is alive and fails the unfinished jobs of servers that stopped sending heartbeats
+	Close() error
+}
+
+// StateBackendMemory keeps the state in memory of a single API server (default)
+const StateBackendMemory = "memory"
+
+// StateBackendFile keeps the state in a file, which can be shared by multiple API servers on the same host
+const StateBackendFile = "file"
+
+// StateBackends lists all supported state backends.
+// Note: the API servers sharing a state store have to run on the same host (as they manage the clusters of its container runtime),
+// so a shared file is all they need. Backends for remote databases (e.g. SQLite on a network share, Postgres or Kubernetes CRDs)
+// are not supported, as k3d doesn't depend on their clients.
+var StateBackends = []string{StateBackendMemory, StateBackendFile}
+
+// NewStateStore returns the state store for the given spec: 'memory' (default) or 'file://PATH'
+func NewStateStore(spec string) (StateStore, error) {
+	if spec == "" || spec == StateBackendMemory {
+		return newMemoryStateStore(), nil
+	}
+	split := strings.SplitN(spec, "://", 2)
+	if len(split) != 2 || split[1] == "" {
+		return nil, fmt.Errorf("invalid state store '%s': must be %s or BACKEND://LOCATION", spec, StateBackendMemory)
+	}
+	switch split[0] {
+	case StateBackendFile:
+		return newFileStateStore(split[1])
+	default:
+		return nil, fmt.Errorf("unsupported state backend '%s' (supported: %s)", split[0], strings.Join(StateBackends, ", "))
+	}
+}
+
+// jobState is the state of all jobs, as kept by the state stores
+type jobState struct {
+	NextID  int                  `json:"nextID"`
+	Jobs    []Job                `json:"jobs"`              // in order of creation
+	Servers map[string]time.Time `json:"servers,omitempty"` // last heartbeat per API server
+}
+
+// heartbeat records the heartbeat of the server and fails the unfinished jobs of all servers, whose last heartbeat is older than timeout
+// (e.g. because they crashed), as nobody would ever finish them otherwise
+func (s *jobState) heartbeat(server string, now time.Time, timeout time.Duration) {
+	if s.Servers == nil {
+		s.Servers = map[string]time.Time{}
+	}
+	s.Servers[server] = now
+
+	for i := range s.Jobs {
+		job := &s.Jobs[i]
+		if job.Done() || job.Server == "" {
+			continue
+		}
+		if last, ok := s.Servers[job.Server]; ok && now.Sub(last) <= timeout {
+			continue
+		}
+		job.State = JobStateFailed
+		job.Error = fmt.Sprintf("the API server running the job (%s) stopped responding", job.Server)
+		job.Finished = &now
+	}
+	for name, last := range s.Servers {
+		if now.Sub(last) > timeout {
+			delete(s.Servers, name)
+		}
+	}
+	s.pruneJobs()
+}
+
+func (s *jobState) nextJobID() string {
+	s.NextID++
+	return strconv.Itoa(s.NextID)
+}
+
+// saveJob creates or updates a job and forgets the oldest finished jobs (see pruneJobs)
+func (s *jobState) saveJob(job Job) {
+	found := false
+	for i := range s.Jobs {
+		if s.Jobs[i].ID == job.ID {
+			s.Jobs[i] = job
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.Jobs = append(s.Jobs, job)
+		sort.SliceStable(s.Jobs, func(i, j int) bool { return s.Jobs[i].Created.Before(s.Jobs[j].Created) })
+	}
+	s.pruneJobs()
+}
+
+// pruneJobs forgets the oldest finished jobs
+func (s *jobState) pruneJobs() {
+	finished := 0
+	for _, j := range s.Jobs {
+		if j.Done() {
+			finished++
+		}
+	}
+	jobs := s.Jobs[:0]
+	for _, j := range s.Jobs {
+		if finished > maxFinishedJobs && j.Done() {
+			finished--
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+	s.Jobs = jobs
+}
+
+func (s *jobState) getJob(id string) (Job, error) {
+	for _, job := range s.Jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return Job{}, ErrJobNotFound
+}
+
+// memoryStateStore keeps the jobs in memory
+type memoryStateStore struct {
+	sync.Mutex            // operation lock
+	mutex      sync.Mutex // protects the state
+	state      jobState
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{}
+}
+
+func (m *memoryStateStore) NextJobID() (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.state.nextJobID(), nil
+}
+
+func (m *memoryStateStore) SaveJob(job Job) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.state.saveJob(job)
+	return nil
+}
+
+func (m *memoryStateStore) GetJob(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.state.getJob(id)
+}
+
+func (m *memoryStateStore) ListJobs() ([]Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]Job{}, m.state.Jobs...), nil
+}
+
+func (m *memoryStateStore) Heartbeat(server string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.state.heartbeat(server, time.Now(), jobHeartbeatTimeout)
+	return nil
+}
+
+func (m *memoryStateStore) Close() error {
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+)
+
+// fileStateStore keeps the jobs in a JSON file, which multiple API servers on the same host can share.
+// Access to the file and cluster operations are serialized via file locks next to it (PATH.lock and PATH.ops.lock):
+// the latter is a single lock for all clusters, so the servers never run cluster operations concurrently.
+// The file locks only work on local filesystems.
+type fileStateStore struct {
+	path     string
+	mutex    sync.Mutex // serializes the goroutines of this process, as file locks only serialize processes
+	opsMutex sync.Mutex
+	opsLock  *os.File
+}
+
+func newFileStateStore(path string) (*fileStateStore, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of state file '%s': %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create directory of state file '%s': %w", absPath, err)
+	}
+	opsLock, err := os.OpenFile(absPath+".ops.lock", os.O_CREATE|os.O_RDWR, 0660)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file of state file '%s': %w", absPath, err)
+	}
+	store := &fileStateStore{path: absPath, opsLock: opsLock}
+
+	// fail early on unreadable state files
+	if err := store.transaction(false, func(*jobState) error { return nil }); err != nil {
+		opsLock.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Lock waits until no other API server sharing the state file runs a cluster operation
+func (f *fileStateStore) Lock() {
+	f.opsMutex.Lock()
+	if err := lockFile(f.opsLock); err != nil {
+		l.Log().Warnf("Failed to lock '%s', cluster operations of other API servers may run concurrently: %v", f.opsLock.Name(), err)
+	}
+}
+
+func (f *fileStateStore) Unlock() {
+	if err := unlockFile(f.opsLock); err != nil {
+		l.Log().Warnf("Failed to unlock '%s': %v", f.opsLock.Name(), err)
+	}
+	f.opsMutex.Unlock()
+}
+
+func (f *fileStateStore) NextJobID() (string, error) {
+	var id string
+	err := f.transaction(true, func(state *jobState) error {
+		id = state.nextJobID()
+		return nil
+	})
+	return id, err
+}
+
+func (f *fileStateStore) SaveJob(job Job) error {
+	return f.transaction(true, func(state *jobState) error {
+		state.saveJob(job)
+		return nil
+	})
+}
+
+func (f *fileStateStore) GetJob(id string) (Job, error) {
+	var job Job
+	err := f.transaction(false, func(state *jobState) error {
+		var err error
+		job, err = state.getJob(id)
+		return err
+	})
+	return job, err
+}
+
+func (f *fileStateStore) ListJobs() ([]Job, error) {
+	var jobs []Job
+	err := f.transaction(false, func(state *jobState) error {
+		jobs = state.Jobs
+		return nil
+	})
+	return jobs, err
+}
+
+func (f *fileStateStore) Heartbeat(server string) error {
+	return f.transaction(true, func(state *jobState) error {
+		state.heartbeat(server, time.Now(), jobHeartbeatTimeout)
+		return nil
+	})
+}
+
+func (f *fileStateStore) Close() error {
+	f.opsMutex.Lock()
+	defer f.opsMutex.Unlock()
+	return f.opsLock.Close()
+}
+
+// transaction reads the state file while holding its lock, passes the state to fn and writes it back (if write is set and fn succeeded)
+func (f *fileStateStore) transaction(write bool, fn func(state *jobState) error) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	lock, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0660)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file of state file '%s': %w", f.path, err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock state file '%s': %w", f.path, err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	state := jobState{Jobs: []Job{}}
+	content, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read state file '%s': %w", f.path, err)
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &state); err != nil {
+			return fmt.Errorf("failed to parse state file '%s': %w", f.path, err)
+		}
+	}
+
+	if err := fn(&state); err != nil {
+		return err
+	}
+	if !write {
+		return nil
+	}
+
+	content, err = json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	// write atomically, so that readers never see a partially written file
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0660); err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("failed to replace state file '%s': %w", f.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewStateStore(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "memory"},
+		{spec: "file://" + filepath.Join(dir, "state", "api-state.json")},
+		{spec: "file://", wantErr: true},
+		{spec: "postgres://k3d@db/k3d", wantErr: true},
+		{spec: "/some/file", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			store, err := NewStateStore(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStateStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if store != nil {
+				store.Close()
+			}
+		})
+	}
+}
+
+func TestJobStatePruning(t *testing.T) {
+	state := jobState{}
+	start := time.Now()
+	for i := 0; i < maxFinishedJobs+10; i++ {
+		state.saveJob(Job{ID: state.nextJobID(), State: JobStateSucceeded, Created: start.Add(time.Duration(i) * time.Second)})
+	}
+	state.saveJob(Job{ID: state.nextJobID(), State: JobStateRunning, Created: start.Add(time.Hour)})
+
+	if len(state.Jobs) != maxFinishedJobs+1 {
+		t.Fatalf("expected %d jobs to be kept, got %d", maxFinishedJobs+1, len(state.Jobs))
+	}
+	if state.Jobs[0].ID != "11" || state.Jobs[len(state.Jobs)-1].State != JobStateRunning {
+		t.Errorf("expected the oldest finished jobs to be forgotten, got first job %s and last job %+v", state.Jobs[0].ID, state.Jobs[len(state.Jobs)-1])
+	}
+}
+
+func TestFileStateStoreShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-state.json")
+	first, err := newFileStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := newFileStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	// job IDs are unique across all stores sharing the file
+	firstID, err := first.NextJobID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondID, err := second.NextJobID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstID == secondID {
+		t.Errorf("expected unique job IDs, got %s twice", firstID)
+	}
+
+	if err := first.SaveJob(Job{ID: firstID, Operation: JobOperationClusterCreate, State: JobStateQueued, Created: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := second.GetJob(firstID)
+	if err != nil || job.Operation != JobOperationClusterCreate {
+		t.Errorf("expected job %s to be visible via the other store, got %+v (err: %v)", firstID, job, err)
+	}
+	if _, err := second.GetJob(secondID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+
+	// cluster operations are serialized across all stores sharing the file
+	first.Lock()
+	locked := make(chan struct{})
+	go func() {
+		second.Lock()
+		close(locked)
+		second.Unlock()
+	}()
+	select {
+	case <-locked:
+		t.Fatalf("expected the lock to be held by the other store")
+	case <-time.After(100 * time.Millisecond):
+	}
+	first.Unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the lock to be released")
+	}
+}
+
+func TestJobQueueSharedStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-state.json")
+	firstStore, err := newFileStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer firstStore.Close()
+	secondStore, err := newFileStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer secondStore.Close()
+
+	first := newJobQueue(firstStore)
+	defer first.Close()
+	second := newJobQueue(secondStore)
+	defer second.Close()
+
+	started := make(chan struct{})
+	running, err := first.Submit(JobOperationClusterCreate, "shared", "", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	jobs, err := second.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != running.ID || jobs[0].State != JobStateRunning {
+		t.Errorf("expected the running job of the other queue, got %+v", jobs)
+	}
+	if _, err := second.Cancel(running.ID); !errors.Is(err, ErrJobRemote) {
+		t.Errorf("expected ErrJobRemote when canceling a job of another queue, got %v", err)
+	}
+
+	// jobs of the other queue wait for the running one
+	next, err := second.Submit(JobOperationClusterDelete, "shared", "", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if job, err := second.Get(next.ID); err != nil || job.State != JobStateQueued {
+		t.Errorf("expected the job to wait for the running job of the other queue, got %+v (err: %v)", job, err)
+	}
+
+	if _, err := first.Cancel(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job := waitForJob(t, second, running.ID); job.State != JobStateCanceled {
+		t.Errorf("expected the job to be canceled, got %+v", job)
+	}
+	if job := waitForJob(t, first, next.ID); job.State != JobStateSucceeded {
+		t.Errorf("expected the job of the other queue to succeed, got %+v", job)
+	}
+}
+
+func TestJobStateHeartbeat(t *testing.T) {
+	now := time.Now()
+	state := jobState{}
+	state.heartbeat("crashed", now.Add(-time.Hour), jobHeartbeatTimeout)
+	state.heartbeat("alive", now.Add(-time.Second), jobHeartbeatTimeout)
+	state.saveJob(Job{ID: state.nextJobID(), State: JobStateRunning, Server: "crashed", Created: now.Add(-time.Hour)})
+	state.saveJob(Job{ID: state.nextJobID(), State: JobStateQueued, Server: "crashed", Created: now.Add(-time.Hour)})
+	state.saveJob(Job{ID: state.nextJobID(), State: JobStateRunning, Server: "alive", Created: now.Add(-time.Minute)})
+	state.saveJob(Job{ID: state.nextJobID(), State: JobStateRunning, Server: "unknown", Created: now.Add(-time.Minute)})
+
+	state.heartbeat("new", now, jobHeartbeatTimeout)
+
+	expected := map[string]JobState{"1": JobStateFailed, "2": JobStateFailed, "3": JobStateRunning, "4": JobStateFailed}
+	for _, job := range state.Jobs {
+		if job.State != expected[job.ID] {
+			t.Errorf("expected job %s to be %s, got %s", job.ID, expected[job.ID], job.State)
+		}
+		if job.State == JobStateFailed && (job.Finished == nil || job.Error == "") {
+			t.Errorf("expected failed job %s to be finished with an error, got %+v", job.ID, job)
+		}
+	}
+	if _, ok := state.Servers["crashed"]; ok || len(state.Servers) != 2 {
+		t.Errorf("expected the crashed server to be forgotten, got %v", state.Servers)
+	}
+}
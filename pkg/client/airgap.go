@@ -0,0 +1,114 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+)
+
+// airgapImagesArchiveExtensions are the file extensions of the image archives that k3s imports from its airgap images directory
+var airgapImagesArchiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz", ".tar.lz4", ".tar.zst", ".tzst"}
+
+// AirgapImagesArchiveVolumes returns the (read-only) volumes mounting the given image archives into the airgap images directory of k3s,
+// from where k3s imports them into containerd before starting anything else
+func AirgapImagesArchiveVolumes(archives []string) ([]string, error) {
+	volumes := []string{}
+	names := map[string]string{}
+	for _, archive := range archives {
+		supported := false
+		for _, ext := range airgapImagesArchiveExtensions {
+			supported = supported || strings.HasSuffix(archive, ext)
+		}
+		if !supported {
+			return nil, fmt.Errorf("unsupported images archive '%s': must be one of %s", archive, strings.Join(airgapImagesArchiveExtensions, ", "))
+		}
+
+		absPath, err := filepath.Abs(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path of images archive '%s': %w", archive, err)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read images archive: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("images archive '%s' is a directory", archive)
+		}
+
+		name := filepath.Base(absPath)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("images archives '%s' and '%s' have the same file name", other, archive)
+		}
+		names[name] = archive
+
+		volumes = append(volumes, fmt.Sprintf("%s:%s:ro", absPath, path.Join(k3s.K3sPathAirgapImages, name)))
+	}
+	return volumes, nil
+}
+
+// ClusterImages returns all images used by the containers of a cluster that's about to be created (nodes, loadbalancer, registry and tools)
+func ClusterImages(clusterConfig *config.ClusterConfig) []string {
+	images := map[string]struct{}{k3d.GetToolsImage(): {}}
+	for _, node := range clusterConfig.Cluster.Nodes {
+		images[node.Image] = struct{}{}
+	}
+	if clusterConfig.ClusterCreateOpts.Registries.Create != nil {
+		images[clusterConfig.ClusterCreateOpts.Registries.Create.Image] = struct{}{}
+	}
+
+	imageList := make([]string, 0, len(images))
+	for image := range images {
+		imageList = append(imageList, image)
+	}
+	sort.Strings(imageList)
+	return imageList
+}
+
+// ImagesCheckPresent makes sure that all given images exist in the runtime, e.g. before creating a cluster without pulling any image
+func ImagesCheckPresent(ctx context.Context, runtime runtimeImageGetter, images []string) error {
+	runtimeImages, err := runtime.GetImages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of existing images from runtime: %w", err)
+	}
+
+	missing := []string{}
+	for _, image := range images {
+		if _, found := findRuntimeImage(image, runtimeImages); !found {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("image(s) missing in the runtime, which must not be pulled: %s (load them e.g. via `docker load`)", strings.Join(missing, ", "))
+	}
+	return nil
+}
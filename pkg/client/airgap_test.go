@@ -0,0 +1,112 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestAirgapImagesArchiveVolumes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"k3s-airgap-images.tar", "apps.tar.zst", "images.zip"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "other"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.tar"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other", "apps.tar.zst"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		archives []string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "none", expected: []string{}},
+		{
+			name:     "multiple archives",
+			archives: []string{filepath.Join(dir, "k3s-airgap-images.tar"), filepath.Join(dir, "apps.tar.zst")},
+			expected: []string{
+				filepath.Join(dir, "k3s-airgap-images.tar") + ":/var/lib/rancher/k3s/agent/images/k3s-airgap-images.tar:ro",
+				filepath.Join(dir, "apps.tar.zst") + ":/var/lib/rancher/k3s/agent/images/apps.tar.zst:ro",
+			},
+		},
+		{name: "unsupported extension", archives: []string{filepath.Join(dir, "images.zip")}, wantErr: true},
+		{name: "missing file", archives: []string{filepath.Join(dir, "missing.tar")}, wantErr: true},
+		{name: "directory", archives: []string{filepath.Join(dir, "dir.tar")}, wantErr: true},
+		{name: "same file name", archives: []string{filepath.Join(dir, "apps.tar.zst"), filepath.Join(dir, "other", "apps.tar.zst")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volumes, err := AirgapImagesArchiveVolumes(tt.archives)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AirgapImagesArchiveVolumes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(volumes, tt.expected) {
+				t.Errorf("AirgapImagesArchiveVolumes() = %v, want %v", volumes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImagesCheckPresent(t *testing.T) {
+	clusterConfig := &config.ClusterConfig{
+		Cluster: k3d.Cluster{
+			Nodes: []*k3d.Node{
+				{Role: k3d.ServerRole, Image: "rancher/k3s:v1.21.7-k3s1"},
+				{Role: k3d.AgentRole, Image: "rancher/k3s:v1.21.7-k3s1"},
+				{Role: k3d.LoadBalancerRole, Image: "ghcr.io/k3d-io/k3d-proxy:5.0.0"},
+			},
+		},
+	}
+	clusterConfig.ClusterCreateOpts.Registries.Create = &k3d.Registry{Image: "docker.io/library/registry:2"}
+	images := ClusterImages(clusterConfig)
+	expected := []string{"docker.io/library/registry:2", "ghcr.io/k3d-io/k3d-proxy:5.0.0", k3d.GetToolsImage(), "rancher/k3s:v1.21.7-k3s1"}
+	if len(images) != len(expected) {
+		t.Fatalf("ClusterImages() = %v, want %v", images, expected)
+	}
+
+	runtime := &FakeRuntimeImageGetter{runtimeImages: []string{"rancher/k3s:v1.21.7-k3s1", "ghcr.io/k3d-io/k3d-proxy:5.0.0", "registry:2"}}
+	if err := ImagesCheckPresent(context.Background(), runtime, images); err == nil {
+		t.Errorf("expected an error for the missing tools image, got none")
+	}
+
+	runtime.runtimeImages = append(runtime.runtimeImages, k3d.GetToolsImage())
+	if err := ImagesCheckPresent(context.Background(), runtime, images); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/rancher/k3d/v5/pkg/actions"
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"gopkg.in/yaml.v2"
+)
+
+// ClusterApply reconciles an existing cluster towards the desired cluster config:
+// - nodes missing in the cluster are added and nodes missing in the config are removed
+// - agent nodes with changed fields (e.g. image, args or env) are recreated
+// - changed loadbalancer ports are applied by replacing the loadbalancer
+// - registries missing in the cluster are created (if managed by the cluster) and connected
+// Changes that would require recreating server nodes or (en/dis)abling the loadbalancer are rejected, since they'd reset the cluster.
+// The returned diff describes the applied changes.
+func ClusterApply(ctx context.Context, runtime runtimes.Runtime, existing *k3d.Cluster, desired *config.ClusterConfig) (*ClusterDiff, error) {
+	diff := ClusterGetDiff(existing, &desired.Cluster)
+
+	desiredNodes := diffableNodes(desired.Cluster.Nodes)
+	existingNodes := diffableNodes(existing.Nodes)
+
+	/*
+	 * Check if the changes can be applied at all, before changing anything
+	 */
+	remainingServers := 0
+	for _, node := range existingNodes {
+		if node.Role == k3d.ServerRole {
+			remainingServers++
+		}
+	}
+	for _, ref := range diff.NodesToRemove {
+		switch ref.Role {
+		case k3d.LoadBalancerRole:
+			return nil, fmt.Errorf("cannot disable the loadbalancer of an existing cluster: recreate the cluster instead")
+		case k3d.ServerRole:
+			remainingServers--
+		}
+	}
+	if remainingServers <= 0 {
+		return nil, fmt.Errorf("cannot remove all server nodes of an existing cluster: recreate the cluster instead")
+	}
+	for _, ref := range diff.NodesToAdd {
+		if ref.Role == k3d.LoadBalancerRole {
+			return nil, fmt.Errorf("cannot enable the loadbalancer of an existing cluster: recreate the cluster instead")
+		}
+	}
+	for _, change := range diff.NodeChanges {
+		if change.Role == k3d.ServerRole {
+			return nil, fmt.Errorf("cannot change server node '%s' without recreating it, which would reset the cluster: recreate the cluster instead", change.Name)
+		}
+	}
+
+	/*
+	 * Remove nodes
+	 */
+	for _, ref := range diff.NodesToRemove {
+		l.Log().Infof("Removing node '%s'...", ref.Name)
+		if err := NodeDelete(ctx, runtime, existingNodes[ref.Name], k3d.NodeDeleteOpts{}); err != nil {
+			return nil, fmt.Errorf("failed to remove node '%s': %w", ref.Name, err)
+		}
+	}
+
+	/*
+	 * Add nodes
+	 */
+	for _, ref := range diff.NodesToAdd {
+		l.Log().Infof("Adding node '%s'...", ref.Name)
+		if err := NodeAddToCluster(ctx, runtime, desiredNodes[ref.Name], &k3d.Cluster{Name: existing.Name}, k3d.NodeCreateOpts{Wait: true}); err != nil {
+			return nil, fmt.Errorf("failed to add node '%s': %w", ref.Name, err)
+		}
+	}
+
+	/*
+	 * Update changed nodes
+	 */
+	for _, change := range diff.NodeChanges {
+		existingNode := existingNodes[change.Name]
+		desiredNode := desiredNodes[change.Name]
+
+		switch change.Role {
+		case k3d.AgentRole:
+			l.Log().Infof("Recreating node '%s' to apply changes...", change.Name)
+			if err := NodeDelete(ctx, runtime, existingNode, k3d.NodeDeleteOpts{}); err != nil {
+				return nil, fmt.Errorf("failed to remove node '%s' for recreation: %w", change.Name, err)
+			}
+			if err := NodeAddToCluster(ctx, runtime, desiredNode, &k3d.Cluster{Name: existing.Name}, k3d.NodeCreateOpts{Wait: true}); err != nil {
+				return nil, fmt.Errorf("failed to recreate node '%s': %w", change.Name, err)
+			}
+		case k3d.LoadBalancerRole:
+			l.Log().Infof("Replacing loadbalancer '%s' to apply changes...", change.Name)
+			if err := loadbalancerApply(ctx, runtime, existingNode, desiredNode, desired.Cluster.ServerLoadBalancer.Config); err != nil {
+				return nil, fmt.Errorf("failed to replace loadbalancer '%s': %w", change.Name, err)
+			}
+		}
+	}
+
+	/*
+	 * Registries
+	 */
+	if err := clusterApplyRegistries(ctx, runtime, existing, desired); err != nil {
+		return nil, fmt.Errorf("failed to apply registries: %w", err)
+	}
+
+	return diff, nil
+}
+
+// loadbalancerApply replaces the existing loadbalancer node with a copy, that has the ports, image and labels of the desired one.
+// Existing port bindings matching the desired ones are kept, so that randomly chosen host ports don't change.
+func loadbalancerApply(ctx context.Context, runtime runtimes.Runtime, existing *k3d.Node, desired *k3d.Node, lbConfig *k3d.LoadbalancerConfig) error {
+	result, err := CopyNode(ctx, existing, CopyNodeOpts{keepState: false})
+	if err != nil {
+		return fmt.Errorf("error copying existing loadbalancer: %w", err)
+	}
+
+	if desired.Image != "" {
+		result.Image = desired.Image
+	}
+	for k, v := range desired.RuntimeLabels {
+		result.RuntimeLabels[k] = v
+	}
+
+	result.Ports = nat.PortMap{}
+	for port, desiredBindings := range desired.Ports {
+		for _, desiredBinding := range desiredBindings {
+			binding := desiredBinding
+			for _, existingBinding := range existing.Ports[port] {
+				if desiredBinding.HostPort == "" && portBindingHostIP(existingBinding) == portBindingHostIP(desiredBinding) {
+					binding = existingBinding
+					break
+				}
+			}
+			result.Ports[port] = append(result.Ports[port], binding)
+		}
+	}
+
+	configyaml, err := yaml.Marshal(lbConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal loadbalancer config: %w", err)
+	}
+	result.HookActions = append(result.HookActions, k3d.NodeHook{
+		Stage: k3d.LifecycleStagePreStart,
+		Action: actions.WriteFileAction{
+			Runtime:     runtime,
+			Dest:        k3d.DefaultLoadbalancerConfigPath,
+			Mode:        0744,
+			Content:     configyaml,
+			Description: "Write Loadbalancer Configuration",
+		},
+	})
+
+	return NodeReplace(ctx, runtime, existing, result)
+}
+
+// clusterApplyRegistries creates (if managed by the cluster) and connects registries that the cluster doesn't use yet.
+// If any registry was added, the registry configuration of all k3s nodes is updated and the nodes are restarted to pick it up.
+func clusterApplyRegistries(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, desired *config.ClusterConfig) error {
+	desiredRegistries := append([]*k3d.Registry{}, desired.ClusterCreateOpts.Registries.Use...)
+	if desired.ClusterCreateOpts.Registries.Create != nil {
+		desiredRegistries = append(desiredRegistries, desired.ClusterCreateOpts.Registries.Create)
+	}
+	if len(desiredRegistries) == 0 {
+		return nil
+	}
+
+	existingRegistries, err := ClusterGetRegistries(ctx, runtime, cluster)
+	if err != nil {
+		return err
+	}
+	connected := make(map[string]struct{}, len(existingRegistries))
+	for _, reg := range existingRegistries {
+		connected[reg.Host] = struct{}{}
+	}
+
+	registries := []*k3d.Registry{}
+	added := false
+	for _, reg := range desiredRegistries {
+		regNode, err := runtime.GetNode(ctx, &k3d.Node{Name: reg.Host})
+		if err != nil {
+			if reg != desired.ClusterCreateOpts.Registries.Create {
+				return fmt.Errorf("failed to find registry node '%s': %w", reg.Host, err)
+			}
+			l.Log().Infof("Creating registry '%s'...", reg.Host)
+			if regNode, err = RegistryRun(ctx, runtime, reg); err != nil {
+				return err
+			}
+		}
+		regFromNode, err := RegistryFromNode(regNode)
+		if err != nil {
+			return fmt.Errorf("failed to translate node to registry spec: %w", err)
+		}
+		registries = append(registries, regFromNode)
+
+		if _, ok := connected[regNode.Name]; ok {
+			continue
+		}
+		l.Log().Infof("Connecting registry '%s' to the cluster network...", regNode.Name)
+		if err := RegistryConnectNetworks(ctx, runtime, regNode, []string{cluster.Network.Name}); err != nil {
+			return fmt.Errorf("failed to connect registry node '%s' to cluster network: %w", regNode.Name, err)
+		}
+		added = true
+	}
+
+	if !added {
+		return nil
+	}
+
+	regConf, err := RegistryGenerateK3sConfig(ctx, registries)
+	if err != nil {
+		return fmt.Errorf("failed to generate registry config file for k3s: %w", err)
+	}
+	if desired.ClusterCreateOpts.Registries.Config != nil {
+		if err := RegistryMergeConfig(ctx, regConf, desired.ClusterCreateOpts.Registries.Config); err != nil {
+			return err
+		}
+	}
+	regConfBytes, err := yaml.Marshal(regConf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry configuration: %w", err)
+	}
+
+	// k3s only reads the registry configuration on startup
+	current, err := ClusterGet(ctx, runtime, &k3d.Cluster{Name: cluster.Name})
+	if err != nil {
+		return err
+	}
+	for _, node := range current.Nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
+		l.Log().Infof("Updating registry configuration of node '%s'...", node.Name)
+		if err := runtime.WriteToNode(ctx, regConfBytes, k3d.DefaultRegistriesFilePath, 0644, node); err != nil {
+			return fmt.Errorf("failed to write registry configuration to node '%s': %w", node.Name, err)
+		}
+		if err := runtime.StopNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to stop node '%s': %w", node.Name, err)
+		}
+		if err := NodeStart(ctx, runtime, node, &k3d.NodeStartOpts{Wait: true}); err != nil {
+			return fmt.Errorf("failed to start node '%s': %w", node.Name, err)
+		}
+	}
+
+	return nil
+}
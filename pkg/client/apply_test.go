@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterApplyRejectsUnsafeChanges(t *testing.T) {
+	existing := &k3d.Cluster{
+		Name: "test",
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole, Image: "rancher/k3s:v1.21.7-k3s1"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		desiredNodes  []*k3d.Node
+		expectedError string
+	}{
+		{
+			name:          "disable loadbalancer",
+			desiredNodes:  []*k3d.Node{{Name: "k3d-test-server-0", Role: k3d.ServerRole, Image: "rancher/k3s:v1.21.7-k3s1"}},
+			expectedError: "cannot disable the loadbalancer",
+		},
+		{
+			name: "remove all servers",
+			desiredNodes: []*k3d.Node{
+				{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+				{Name: "k3d-test-server-1", Role: k3d.ServerRole},
+			},
+			expectedError: "cannot remove all server nodes",
+		},
+		{
+			name: "change server image",
+			desiredNodes: []*k3d.Node{
+				{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+				{Name: "k3d-test-server-0", Role: k3d.ServerRole, Image: "rancher/k3s:v1.22.4-k3s1"},
+			},
+			expectedError: "cannot change server node 'k3d-test-server-0'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := &config.ClusterConfig{Cluster: k3d.Cluster{Name: "test", Nodes: tt.desiredNodes}}
+			_, err := ClusterApply(context.Background(), runtimes.Docker, existing, desired)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing '%s', got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/k3d/v5/pkg/actions"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+	"github.com/rancher/k3d/v5/version"
+	"gopkg.in/yaml.v2"
+	"inet.af/netaddr"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ClusterBackupCreate writes a backup of the cluster as a gzipped tar archive to w:
+// a manifest describing the cluster (token, network, exposed ports and nodes), its kubeconfig and the data of its server and agent nodes.
+// The nodes should be stopped, so that their data is consistent.
+func ClusterBackupCreate(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, w io.Writer) (*k3d.ClusterBackup, error) {
+	backup := clusterBackupManifest(cluster)
+
+	tmpDir, err := os.MkdirTemp("", "k3d-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// export the node data first, as the manifest references the resulting files
+	for i := range backup.Nodes {
+		backupNode := &backup.Nodes[i]
+		if backupNode.Role != k3d.ServerRole && backupNode.Role != k3d.AgentRole {
+			continue
+		}
+		for _, nodePath := range k3d.ClusterBackupNodePaths {
+			file := path.Join(k3d.ClusterBackupNodesDir, backupNode.Name, path.Base(nodePath)+".tar")
+			l.Log().Infof("Backing up %s of node '%s'...", nodePath, backupNode.Name)
+			exported, err := clusterBackupExportNodePath(ctx, runtime, &k3d.Node{Name: backupNode.Name}, nodePath, filepath.Join(tmpDir, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, err
+			}
+			if exported {
+				backupNode.Data = append(backupNode.Data, k3d.ClusterBackupNodeData{Path: nodePath, File: file})
+			}
+		}
+	}
+
+	manifest, err := yaml.Marshal(backup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	if err := tarWriteFile(tarWriter, k3d.ClusterBackupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+
+	if kubeconfig, err := KubeconfigGet(ctx, runtime, cluster); err != nil {
+		l.Log().Warnf("Failed to get kubeconfig of cluster '%s', so it's not part of the backup: %v", cluster.Name, err)
+	} else {
+		kubeconfigBytes, err := clientcmd.Write(*kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize kubeconfig: %w", err)
+		}
+		if err := tarWriteFile(tarWriter, k3d.ClusterBackupKubeconfigFile, kubeconfigBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, backupNode := range backup.Nodes {
+		for _, data := range backupNode.Data {
+			if err := tarAddFile(tarWriter, data.File, filepath.Join(tmpDir, filepath.FromSlash(data.File))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+	return backup, nil
+}
+
+// clusterBackupManifest describes the cluster and its nodes for a backup (without the node data)
+func clusterBackupManifest(cluster *k3d.Cluster) *k3d.ClusterBackup {
+	backup := &k3d.ClusterBackup{
+		Version:    k3d.ClusterBackupVersion,
+		Created:    time.Now().UTC(),
+		K3dVersion: version.GetVersion(),
+		Cluster:    cluster.Name,
+		Token:      cluster.Token,
+		Network: k3d.ClusterBackupNetwork{
+			Name:     cluster.Network.Name,
+			External: cluster.Network.External,
+		},
+	}
+
+	nodes := append([]*k3d.Node{}, cluster.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	for _, node := range nodes {
+		if backup.Network.Subnet == "" {
+			if subnet, err := netaddr.ParseIPPrefix(node.RuntimeLabels[k3d.LabelNetworkIPRange]); err == nil {
+				backup.Network.Subnet = subnet.String()
+			}
+		}
+
+		switch node.Role {
+		case k3d.LoadBalancerRole:
+			backup.Loadbalancer = true
+		case k3d.ServerRole:
+			if backup.KubeAPI.HostPort == "" && node.ServerOpts.KubeAPI != nil {
+				backup.KubeAPI = k3d.ClusterBackupKubeAPI{
+					Host:     node.ServerOpts.KubeAPI.Host,
+					HostIP:   node.ServerOpts.KubeAPI.Binding.HostIP,
+					HostPort: node.ServerOpts.KubeAPI.Binding.HostPort,
+				}
+			}
+		case k3d.AgentRole:
+		default:
+			continue
+		}
+
+		backupNode := k3d.ClusterBackupNode{
+			Name:  node.Name,
+			Role:  node.Role,
+			Image: node.Image,
+			Ports: clusterBackupPorts(node),
+		}
+
+		// the command starts with the role (e.g. `server`), followed by the k3s args
+		if len(node.Cmd) > 1 {
+			for _, arg := range node.Cmd[1:] {
+				if arg == "--cluster-init" { // set by k3d for multi-server clusters
+					continue
+				}
+				backupNode.Args = append(backupNode.Args, arg)
+			}
+		}
+
+		// the token, server URL and kubeconfig output are set by k3d, so they're not part of the backup
+		for _, env := range node.Env {
+			key := strings.SplitN(env, "=", 2)[0]
+			if key == k3s.EnvClusterToken || key == k3s.EnvClusterConnectURL || key == k3s.EnvKubeconfigOutput {
+				continue
+			}
+			backupNode.Env = append(backupNode.Env, env)
+		}
+
+		// the image volume is managed by k3d
+		for _, volume := range node.Volumes {
+			if cluster.ImageVolume != "" && strings.HasPrefix(volume, cluster.ImageVolume+":") {
+				continue
+			}
+			backupNode.Volumes = append(backupNode.Volumes, volume)
+		}
+
+		backup.Nodes = append(backup.Nodes, backupNode)
+	}
+
+	return backup
+}
+
+// clusterBackupPorts returns the host port mappings of a node as port specs ([HOSTIP:]HOSTPORT:CONTAINERPORT/PROTOCOL), except for the Kubernetes API
+func clusterBackupPorts(node *k3d.Node) []string {
+	var ports []string
+	for port, bindings := range node.Ports {
+		if port.Port() == k3d.DefaultAPIPort && (node.Role == k3d.LoadBalancerRole || node.Role == k3d.ServerRole) {
+			continue
+		}
+		for _, binding := range bindings {
+			spec := fmt.Sprintf("%s/%s", port.Port(), port.Proto())
+			if binding.HostPort != "" || binding.HostIP != "" {
+				spec = fmt.Sprintf("%s:%s", binding.HostPort, spec)
+			}
+			if binding.HostIP != "" {
+				spec = fmt.Sprintf("%s:%s", binding.HostIP, spec)
+			}
+			ports = append(ports, spec)
+		}
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+// clusterBackupExportNodePath writes the tar archive of a path in a node to a file and returns false, if the path doesn't exist in the node
+func clusterBackupExportNodePath(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, nodePath string, file string) (bool, error) {
+	reader, err := runtime.ExportFromNode(ctx, nodePath, node)
+	if err != nil {
+		if errors.Is(err, runtimeErrors.ErrRuntimeFileNotFound) {
+			l.Log().Debugf("Path %s doesn't exist in node '%s', skipping it", nodePath, node.Name)
+			return false, nil
+		}
+		return false, err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory for '%s': %w", file, err)
+	}
+	out, err := os.Create(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to create '%s': %w", file, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, reader); err != nil {
+		return false, fmt.Errorf("failed to export %s from node '%s': %w", nodePath, node.Name, err)
+	}
+	return true, out.Close()
+}
+
+// tarWriteFile adds a regular file with the given content to a tar archive
+func tarWriteFile(tarWriter *tar.Writer, name string, content []byte) error {
+	if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), ModTime: time.Now()}); err != nil {
+		return fmt.Errorf("failed to write tar header for '%s': %w", name, err)
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		return fmt.Errorf("failed to write '%s' to tar archive: %w", name, err)
+	}
+	return nil
+}
+
+// tarAddFile adds a file from the host to a tar archive under the given name
+func tarAddFile(tarWriter *tar.Writer, name string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open '%s': %w", file, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat '%s': %w", file, err)
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return fmt.Errorf("failed to write tar header for '%s': %w", name, err)
+	}
+	if _, err := io.Copy(tarWriter, f); err != nil {
+		return fmt.Errorf("failed to write '%s' to tar archive: %w", name, err)
+	}
+	return nil
+}
+
+// ClusterBackupExtract extracts a backup archive (as written by ClusterBackupCreate) to a directory and returns its manifest
+func ClusterBackupExtract(r io.Reader, dir string) (*k3d.ClusterBackup, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	var backup *k3d.ClusterBackup
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("invalid file '%s' in backup archive", header.Name)
+		}
+
+		if name == k3d.ClusterBackupManifestFile {
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+			}
+			backup = &k3d.ClusterBackup{}
+			if err := yaml.Unmarshal(content, backup); err != nil {
+				return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+			}
+			if backup.Version != k3d.ClusterBackupVersion {
+				return nil, fmt.Errorf("unsupported backup version '%s' (supported: %s)", backup.Version, k3d.ClusterBackupVersion)
+			}
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for '%s': %w", target, err)
+		}
+		if err := tarExtractFile(tarReader, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if backup == nil {
+		return nil, fmt.Errorf("backup archive doesn't contain a %s", k3d.ClusterBackupManifestFile)
+	}
+	return backup, nil
+}
+
+// tarExtractFile writes the current file of a tar archive to the target path
+func tarExtractFile(tarReader *tar.Reader, target string) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s': %w", target, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, tarReader); err != nil {
+		return fmt.Errorf("failed to extract '%s': %w", target, err)
+	}
+	return out.Close()
+}
+
+// ClusterBackupToSimpleConfig returns a config that recreates the backed up cluster under the given name.
+// Nodes are matched by role and position, so the node names change with the cluster name.
+func ClusterBackupToSimpleConfig(backup *k3d.ClusterBackup, name string) *conf.SimpleConfig {
+	cfg := &conf.SimpleConfig{
+		Name:         name,
+		ClusterToken: backup.Token,
+		ExposeAPI: conf.SimpleExposureOpts{
+			Host:     backup.KubeAPI.Host,
+			HostIP:   backup.KubeAPI.HostIP,
+			HostPort: backup.KubeAPI.HostPort,
+		},
+	}
+	if backup.Network.External {
+		cfg.Network = backup.Network.Name
+	} else {
+		cfg.Subnet = backup.Network.Subnet
+	}
+	cfg.Options.K3dOptions.DisableLoadbalancer = !backup.Loadbalancer
+	cfg.Options.K3dOptions.Wait = true
+
+	index := map[k3d.Role]int{}
+	for _, node := range backup.Nodes {
+		switch node.Role {
+		case k3d.ServerRole:
+			cfg.Servers++
+			if cfg.Image == "" {
+				cfg.Image = node.Image
+			}
+		case k3d.AgentRole:
+			cfg.Agents++
+		default:
+			continue
+		}
+		nodeFilter := fmt.Sprintf("%s:%d", node.Role, index[node.Role])
+		index[node.Role]++
+
+		for _, arg := range node.Args {
+			cfg.Options.K3sOptions.ExtraArgs = append(cfg.Options.K3sOptions.ExtraArgs, conf.K3sArgWithNodeFilters{Arg: arg, NodeFilters: []string{nodeFilter}})
+		}
+		for _, env := range node.Env {
+			cfg.Env = append(cfg.Env, conf.EnvVarWithNodeFilters{EnvVar: env, NodeFilters: []string{nodeFilter}})
+		}
+		for _, volume := range node.Volumes {
+			cfg.Volumes = append(cfg.Volumes, conf.VolumeWithNodeFilters{Volume: volume, NodeFilters: []string{nodeFilter}})
+		}
+		for _, port := range node.Ports {
+			cfg.Ports = append(cfg.Ports, conf.PortWithNodeFilters{Port: port, NodeFilters: []string{nodeFilter + ":direct"}})
+		}
+	}
+
+	// the loadbalancer forwards its ports to all server and agent nodes
+	for _, node := range backup.Nodes {
+		if node.Role == k3d.LoadBalancerRole {
+			for _, port := range node.Ports {
+				cfg.Ports = append(cfg.Ports, conf.PortWithNodeFilters{Port: port, NodeFilters: []string{"loadbalancer"}})
+			}
+		}
+	}
+
+	return cfg
+}
+
+// ClusterBackupRestoreAction returns a pre-start node action that imports the backed up node data (extracted to dir) into the nodes of the cluster recreated under the given name
+func ClusterBackupRestoreAction(runtime runtimes.Runtime, backup *k3d.ClusterBackup, dir string, name string) *actions.ImportArchiveAction {
+	action := &actions.ImportArchiveAction{
+		Runtime:     runtime,
+		Archives:    map[string]map[string]string{},
+		Description: fmt.Sprintf("Restore node data from backup of cluster '%s'", backup.Cluster),
+	}
+	index := map[k3d.Role]int{}
+	for _, node := range backup.Nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
+		nodeName := GenerateNodeName(name, node.Role, index[node.Role])
+		index[node.Role]++
+		for _, data := range node.Data {
+			if action.Archives[nodeName] == nil {
+				action.Archives[nodeName] = map[string]string{}
+			}
+			action.Archives[nodeName][data.Path] = filepath.Join(dir, filepath.FromSlash(data.File))
+		}
+	}
+	return action
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterBackupManifest(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Name:        "test",
+		Token:       "secret",
+		ImageVolume: "k3d-test-images",
+		Network:     k3d.ClusterNetwork{Name: "k3d-test"},
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Ports: nat.PortMap{
+				"6443/tcp": {{HostIP: "0.0.0.0", HostPort: "6550"}},
+				"80/tcp":   {{HostIP: "0.0.0.0", HostPort: "8080"}},
+			}},
+			{
+				Name:          "k3d-test-server-0",
+				Role:          k3d.ServerRole,
+				Image:         "rancher/k3s:v1.22.2-k3s1",
+				Cmd:           []string{"server", "--cluster-init", "--tls-san", "example.com"},
+				Env:           []string{"K3S_TOKEN=secret", "K3S_KUBECONFIG_OUTPUT=/output/kubeconfig.yaml", "FOO=bar"},
+				Volumes:       []string{"k3d-test-images:/k3d/images", "/tmp/data:/data"},
+				RuntimeLabels: map[string]string{k3d.LabelNetworkIPRange: "172.28.0.0/16"},
+				ServerOpts: k3d.ServerOpts{KubeAPI: &k3d.ExposureOpts{
+					PortMapping: nat.PortMapping{Binding: nat.PortBinding{HostIP: "0.0.0.0", HostPort: "6550"}},
+					Host:        "0.0.0.0",
+				}},
+			},
+			{
+				Name:  "k3d-test-agent-0",
+				Role:  k3d.AgentRole,
+				Image: "rancher/k3s:v1.22.2-k3s1",
+				Cmd:   []string{"agent"},
+				Env:   []string{"K3S_TOKEN=secret", "K3S_URL=https://k3d-test-server-0:6443"},
+				Ports: nat.PortMap{"30080/udp": {{HostPort: ""}}},
+			},
+			{Name: "k3d-test-tools", Role: k3d.NoRole},
+		},
+	}
+
+	backup := clusterBackupManifest(cluster)
+
+	expected := []k3d.ClusterBackupNode{
+		{Name: "k3d-test-agent-0", Role: k3d.AgentRole, Image: "rancher/k3s:v1.22.2-k3s1", Ports: []string{"30080/udp"}},
+		{Name: "k3d-test-server-0", Role: k3d.ServerRole, Image: "rancher/k3s:v1.22.2-k3s1", Args: []string{"--tls-san", "example.com"}, Env: []string{"FOO=bar"}, Volumes: []string{"/tmp/data:/data"}},
+		{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Ports: []string{"0.0.0.0:8080:80/tcp"}},
+	}
+	if !reflect.DeepEqual(backup.Nodes, expected) {
+		t.Errorf("expected nodes\n%+v\ngot\n%+v", expected, backup.Nodes)
+	}
+	if backup.Version != k3d.ClusterBackupVersion || backup.Cluster != "test" || backup.Token != "secret" {
+		t.Errorf("unexpected backup metadata: %+v", backup)
+	}
+	if expectedNetwork := (k3d.ClusterBackupNetwork{Name: "k3d-test", Subnet: "172.28.0.0/16"}); backup.Network != expectedNetwork {
+		t.Errorf("expected network %+v, got %+v", expectedNetwork, backup.Network)
+	}
+	if expectedAPI := (k3d.ClusterBackupKubeAPI{Host: "0.0.0.0", HostIP: "0.0.0.0", HostPort: "6550"}); backup.KubeAPI != expectedAPI {
+		t.Errorf("expected kube API %+v, got %+v", expectedAPI, backup.KubeAPI)
+	}
+	if !backup.Loadbalancer {
+		t.Errorf("expected loadbalancer to be set")
+	}
+}
+
+func TestClusterBackupToSimpleConfig(t *testing.T) {
+	backup := &k3d.ClusterBackup{
+		Cluster:      "test",
+		Token:        "secret",
+		Network:      k3d.ClusterBackupNetwork{Name: "k3d-test", Subnet: "172.28.0.0/16"},
+		KubeAPI:      k3d.ClusterBackupKubeAPI{Host: "0.0.0.0", HostIP: "0.0.0.0", HostPort: "6550"},
+		Loadbalancer: true,
+		Nodes: []k3d.ClusterBackupNode{
+			{Name: "k3d-test-agent-0", Role: k3d.AgentRole, Image: "rancher/k3s:v1.22.2-k3s1", Ports: []string{"30080/udp"}, Data: []k3d.ClusterBackupNodeData{{Path: "/var/lib/rancher/k3s", File: "nodes/k3d-test-agent-0/k3s.tar"}}},
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole, Image: "rancher/k3s:v1.22.2-k3s1", Args: []string{"--tls-san", "example.com"}, Env: []string{"FOO=bar"}, Volumes: []string{"/tmp/data:/data"}, Data: []k3d.ClusterBackupNodeData{{Path: "/var/lib/rancher/k3s", File: "nodes/k3d-test-server-0/k3s.tar"}}},
+			{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Ports: []string{"0.0.0.0:8080:80/tcp"}},
+		},
+	}
+
+	cfg := ClusterBackupToSimpleConfig(backup, "restored")
+
+	if cfg.Name != "restored" || cfg.Servers != 1 || cfg.Agents != 1 || cfg.Image != "rancher/k3s:v1.22.2-k3s1" || cfg.ClusterToken != "secret" || cfg.Subnet != "172.28.0.0/16" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Options.K3dOptions.DisableLoadbalancer {
+		t.Errorf("expected loadbalancer to be enabled")
+	}
+	expectedArgs := []conf.K3sArgWithNodeFilters{
+		{Arg: "--tls-san", NodeFilters: []string{"server:0"}},
+		{Arg: "example.com", NodeFilters: []string{"server:0"}},
+	}
+	if !reflect.DeepEqual(cfg.Options.K3sOptions.ExtraArgs, expectedArgs) {
+		t.Errorf("expected args %+v, got %+v", expectedArgs, cfg.Options.K3sOptions.ExtraArgs)
+	}
+	expectedPorts := []conf.PortWithNodeFilters{
+		{Port: "30080/udp", NodeFilters: []string{"agent:0:direct"}},
+		{Port: "0.0.0.0:8080:80/tcp", NodeFilters: []string{"loadbalancer"}},
+	}
+	if !reflect.DeepEqual(cfg.Ports, expectedPorts) {
+		t.Errorf("expected ports %+v, got %+v", expectedPorts, cfg.Ports)
+	}
+
+	action := ClusterBackupRestoreAction(nil, backup, "/backup", "restored")
+	expectedArchives := map[string]map[string]string{
+		"k3d-restored-agent-0":  {"/var/lib/rancher/k3s": filepath.Join("/backup", "nodes", "k3d-test-agent-0", "k3s.tar")},
+		"k3d-restored-server-0": {"/var/lib/rancher/k3s": filepath.Join("/backup", "nodes", "k3d-test-server-0", "k3s.tar")},
+	}
+	if !reflect.DeepEqual(action.Archives, expectedArchives) {
+		t.Errorf("expected archives %+v, got %+v", expectedArchives, action.Archives)
+	}
+}
+
+func TestClusterBackupExtract(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		expectError bool
+	}{
+		"valid": {
+			files: map[string]string{
+				k3d.ClusterBackupManifestFile:     "version: v1\ncluster: test\n",
+				"nodes/k3d-test-server-0/k3s.tar": "data",
+			},
+		},
+		"missing manifest": {
+			files:       map[string]string{"nodes/k3d-test-server-0/k3s.tar": "data"},
+			expectError: true,
+		},
+		"unsupported version": {
+			files:       map[string]string{k3d.ClusterBackupManifestFile: "version: v0\ncluster: test\n"},
+			expectError: true,
+		},
+		"path traversal": {
+			files: map[string]string{
+				k3d.ClusterBackupManifestFile: "version: v1\ncluster: test\n",
+				"../escape":                   "data",
+			},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			tarWriter := tar.NewWriter(gzipWriter)
+			for file, content := range tc.files {
+				if err := tarWriteFile(tarWriter, file, []byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tarWriter.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := gzipWriter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			backup, err := ClusterBackupExtract(&buf, dir)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if backup.Cluster != "test" {
+				t.Errorf("expected cluster 'test', got '%s'", backup.Cluster)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "nodes", "k3d-test-server-0", "k3s.tar"))
+			if err != nil || string(content) != "data" {
+				t.Errorf("expected extracted node data, got '%s' (%v)", content, err)
+			}
+		})
+	}
+}
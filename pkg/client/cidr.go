@@ -0,0 +1,101 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+	"inet.af/netaddr"
+)
+
+// ClusterGetCIDRs returns the cluster (pod) and service CIDRs passed on to the server nodes of the cluster.
+// If either of them is not set explicitly, the k3s default is returned and explicit is false for it.
+func ClusterGetCIDRs(cluster *k3d.Cluster) (clusterCIDR string, clusterCIDRExplicit bool, serviceCIDR string, serviceCIDRExplicit bool) {
+	clusterCIDR, serviceCIDR = k3s.DefaultClusterCIDR, k3s.DefaultServiceCIDR
+	for _, node := range cluster.Nodes {
+		if node.Role != k3d.ServerRole {
+			continue
+		}
+		if value, ok := K3sArgValue(node.Args, "cluster-cidr"); ok {
+			clusterCIDR, clusterCIDRExplicit = value, true
+		}
+		if value, ok := K3sArgValue(node.Args, "service-cidr"); ok {
+			serviceCIDR, serviceCIDRExplicit = value, true
+		}
+	}
+	return clusterCIDR, clusterCIDRExplicit, serviceCIDR, serviceCIDRExplicit
+}
+
+// ParseCIDRs parses a comma-separated list of CIDRs (dual-stack clusters use one per IP family)
+func ParseCIDRs(cidrs string) ([]netaddr.IPPrefix, error) {
+	prefixes := []netaddr.IPPrefix{}
+	for _, cidr := range strings.Split(cidrs, ",") {
+		prefix, err := netaddr.ParseIPPrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR '%s': %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ValidateClusterCIDRs makes sure, that the cluster (pod) and service CIDRs neither overlap each other
+// nor any of the given subnets (e.g. of the cluster network), as that breaks routing inside the cluster.
+func ValidateClusterCIDRs(clusterCIDR string, serviceCIDR string, subnets ...netaddr.IPPrefix) error {
+	clusterPrefixes, err := ParseCIDRs(clusterCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid cluster CIDR: %w", err)
+	}
+	servicePrefixes, err := ParseCIDRs(serviceCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid service CIDR: %w", err)
+	}
+
+	for _, c := range clusterPrefixes {
+		for _, s := range servicePrefixes {
+			if c.Overlaps(s) {
+				return fmt.Errorf("cluster CIDR %s overlaps service CIDR %s", c, s)
+			}
+		}
+	}
+
+	for _, subnet := range subnets {
+		if subnet.IsZero() {
+			continue
+		}
+		for _, c := range clusterPrefixes {
+			if c.Overlaps(subnet) {
+				return fmt.Errorf("cluster CIDR %s overlaps network subnet %s", c, subnet)
+			}
+		}
+		for _, s := range servicePrefixes {
+			if s.Overlaps(subnet) {
+				return fmt.Errorf("service CIDR %s overlaps network subnet %s", s, subnet)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"inet.af/netaddr"
+)
+
+func TestValidateClusterCIDRs(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterCIDR string
+		serviceCIDR string
+		subnets     []netaddr.IPPrefix
+		wantErr     bool
+	}{
+		{name: "defaults", clusterCIDR: "10.42.0.0/16", serviceCIDR: "10.43.0.0/16"},
+		{name: "defaults on docker subnet", clusterCIDR: "10.42.0.0/16", serviceCIDR: "10.43.0.0/16", subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("172.18.0.0/16")}},
+		{name: "zero subnet is ignored", clusterCIDR: "10.42.0.0/16", serviceCIDR: "10.43.0.0/16", subnets: []netaddr.IPPrefix{{}}},
+		{name: "dual-stack", clusterCIDR: "10.42.0.0/16,2001:cafe:42::/56", serviceCIDR: "10.43.0.0/16,2001:cafe:43::/112", subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("fd00:cafe::/64")}},
+		{name: "invalid cluster CIDR", clusterCIDR: "10.42.0.0", serviceCIDR: "10.43.0.0/16", wantErr: true},
+		{name: "invalid service CIDR", clusterCIDR: "10.42.0.0/16", serviceCIDR: "foo", wantErr: true},
+		{name: "overlapping each other", clusterCIDR: "10.0.0.0/8", serviceCIDR: "10.43.0.0/16", wantErr: true},
+		{name: "cluster CIDR overlaps subnet", clusterCIDR: "10.42.0.0/16", serviceCIDR: "10.43.0.0/16", subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("10.42.0.0/24")}, wantErr: true},
+		{name: "service CIDR overlaps IPv6 subnet", clusterCIDR: "10.42.0.0/16,2001:cafe:42::/56", serviceCIDR: "10.43.0.0/16,2001:cafe:43::/112", subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("2001:cafe:43::/64")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClusterCIDRs(tt.clusterCIDR, tt.serviceCIDR, tt.subnets...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateClusterCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClusterGetCIDRs(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Role: k3d.ServerRole, Args: []string{"--service-cidr", "10.53.0.0/16"}},
+			{Role: k3d.AgentRole, Args: []string{"--cluster-cidr=10.99.0.0/16"}},
+		},
+	}
+	clusterCIDR, clusterCIDRExplicit, serviceCIDR, serviceCIDRExplicit := ClusterGetCIDRs(cluster)
+	if clusterCIDR != "10.42.0.0/16" || clusterCIDRExplicit {
+		t.Errorf("expected default cluster CIDR, got %s (explicit: %t)", clusterCIDR, clusterCIDRExplicit)
+	}
+	if serviceCIDR != "10.53.0.0/16" || !serviceCIDRExplicit {
+		t.Errorf("expected explicit service CIDR 10.53.0.0/16, got %s (explicit: %t)", serviceCIDR, serviceCIDRExplicit)
+	}
+}
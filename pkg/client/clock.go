@@ -0,0 +1,153 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// NodeGetClockSkew returns the difference between the clock inside the node and the local clock (positive: node is ahead).
+// The precision is limited to roughly one second.
+func NodeGetClockSkew(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node) (time.Duration, error) {
+	before := time.Now()
+	logreader, err := runtime.ExecInNodeGetLogs(ctx, node, []string{"date", "-u", "+%s"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get time from node '%s': %w", node.Name, err)
+	}
+	after := time.Now()
+
+	if logreader == nil {
+		return 0, fmt.Errorf("failed to get time from node '%s': no output", node.Name)
+	}
+	output, err := io.ReadAll(logreader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read time from node '%s': %w", node.Name, err)
+	}
+	nodeUnix, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse time '%s' from node '%s': %w", strings.TrimSpace(string(output)), node.Name, err)
+	}
+
+	// compare against the middle of the exec roundtrip
+	local := before.Add(after.Sub(before) / 2)
+	return time.Unix(nodeUnix, 0).Sub(local.Truncate(time.Second)), nil
+}
+
+// NodeResyncTime sets the clock inside the node to the local time.
+// This requires a privileged node and affects the (VM) kernel running the node, so all nodes on that host are synchronized at once.
+func NodeResyncTime(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node) error {
+	now := time.Now().Unix()
+	if err := runtime.ExecInNode(ctx, node, []string{"date", "-u", "-s", fmt.Sprintf("@%d", now)}); err != nil {
+		return fmt.Errorf("failed to set time in node '%s': %w", node.Name, err)
+	}
+	return nil
+}
+
+// NodeClockResyncAllowed returns true if setting the clock inside the nodes only affects the VM running them (Docker Desktop),
+// as opposed to the clock of the (shared) docker host.
+func NodeClockResyncAllowed(runtime runtimes.Runtime) bool {
+	info, err := runtime.Info()
+	if err != nil {
+		l.Log().Debugf("Failed to get runtime info: %v", err)
+		return false
+	}
+	return info.DockerDesktop
+}
+
+// ClusterResyncTime detects clock skew between the local machine and the cluster's nodes and corrects it (unless opts.DryRun is set).
+// As all nodes of a cluster share the kernel (clock) of the runtime host, checking a single node is enough. If none of the cluster's k3s
+// nodes is running, a temporary k3d-tools node is used. It returns the detected skew.
+// The clock is only corrected if the nodes run in a VM (see NodeClockResyncAllowed) or opts.Force is set.
+func ClusterResyncTime(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, opts k3d.ClusterResyncTimeOpts) (time.Duration, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = k3d.DefaultClockSkewThreshold
+	}
+
+	var node *k3d.Node
+	for _, n := range cluster.Nodes {
+		if (n.Role == k3d.ServerRole || n.Role == k3d.AgentRole) && n.State.Running {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		toolsNode, err := EnsureToolsNode(ctx, runtime, cluster)
+		if err != nil {
+			return 0, fmt.Errorf("failed to ensure k3d-tools node to check the clock: %w", err)
+		}
+		node = toolsNode
+		defer func() {
+			if err := runtime.DeleteNode(ctx, toolsNode); err != nil {
+				l.Log().Errorf("failed to delete tools node '%s' (try to delete it manually): %v", toolsNode.Name, err)
+			}
+		}()
+	}
+
+	skew, err := NodeGetClockSkew(ctx, runtime, node)
+	if err != nil {
+		return 0, err
+	}
+
+	if absDuration(skew) <= opts.Threshold {
+		l.Log().Debugf("Clock of cluster '%s' is in sync (skew: %s)", cluster.Name, skew)
+		return skew, nil
+	}
+
+	l.Log().Warnf("Detected clock skew of %s between the nodes of cluster '%s' and this machine (e.g. after the host was suspended)", skew, cluster.Name)
+	if opts.DryRun {
+		return skew, nil
+	}
+	if !opts.Force && !NodeClockResyncAllowed(runtime) {
+		return skew, fmt.Errorf("not correcting the clock of cluster '%s', as its nodes don't run in a VM (e.g. Docker Desktop), so this would set the clock of the docker host: fix the host's time synchronization instead or use --force", cluster.Name)
+	}
+
+	if err := NodeResyncTime(ctx, runtime, node); err != nil {
+		return skew, err
+	}
+
+	newSkew, err := NodeGetClockSkew(ctx, runtime, node)
+	if err != nil {
+		return skew, err
+	}
+	if absDuration(newSkew) > opts.Threshold {
+		return skew, fmt.Errorf("clock of cluster '%s' is still skewed by %s after correction", cluster.Name, newSkew)
+	}
+	l.Log().Infof("Corrected clock of cluster '%s' (remaining skew: %s)", cluster.Name, newSkew)
+
+	return skew, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -28,10 +28,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/go-connections/nat"
@@ -40,9 +44,11 @@ import (
 	"github.com/rancher/k3d/v5/pkg/actions"
 	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
 	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	"github.com/rancher/k3d/v5/pkg/types"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/pkg/types/k3s"
@@ -53,20 +59,35 @@ import (
 )
 
 // ClusterRun orchestrates the steps of cluster creation, configuration and starting
-func ClusterRun(ctx context.Context, runtime k3drt.Runtime, clusterConfig *config.ClusterConfig) error {
+func ClusterRun(ctx context.Context, runtime k3drt.Runtime, clusterConfig *config.ClusterConfig) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterRun", tracing.String("k3d.cluster.name", clusterConfig.Cluster.Name))
+	defer func() { span.End(err) }()
+
+	defer func() {
+		if err != nil {
+			progress.Fail(progress.OperationClusterCreate, err)
+		}
+	}()
+
 	/*
 	 * Step 0: (Infrastructure) Preparation
 	 */
+	progress.Report(progress.OperationClusterCreate, "prepare", 0, fmt.Sprintf("Preparing cluster '%s'", clusterConfig.Cluster.Name))
 	if err := ClusterPrep(ctx, runtime, clusterConfig); err != nil {
 		return fmt.Errorf("Failed Cluster Preparation: %+v", err)
 	}
 
+	if err := ClusterRunHooks(ctx, &clusterConfig.Cluster, clusterConfig.ClusterCreateOpts.ClusterHooks, k3d.LifecycleStagePreCreate); err != nil {
+		return fmt.Errorf("Failed Cluster Preparation: %w", err)
+	}
+
 	// Create tools-node for later steps
 	go EnsureToolsNode(ctx, runtime, &clusterConfig.Cluster)
 
 	/*
 	 * Step 1: Create Containers
 	 */
+	progress.Report(progress.OperationClusterCreate, "create", 20, "Creating node containers")
 	if err := ClusterCreate(ctx, runtime, &clusterConfig.Cluster, &clusterConfig.ClusterCreateOpts); err != nil {
 		return fmt.Errorf("Failed Cluster Creation: %+v", err)
 	}
@@ -79,15 +100,30 @@ func ClusterRun(ctx context.Context, runtime k3drt.Runtime, clusterConfig *confi
 		return fmt.Errorf("failed to gather environment information used for cluster creation: %w", err)
 	}
 
+	postNodeStartHooks, err := clusterHooksAsNodeHooks(&clusterConfig.Cluster, clusterConfig.ClusterCreateOpts.ClusterHooks)
+	if err != nil {
+		return fmt.Errorf("failed to prepare cluster hooks: %w", err)
+	}
+	nodeHooks := append(append([]k3d.NodeHook{}, clusterConfig.ClusterCreateOpts.NodeHooks...), postNodeStartHooks...)
+
 	/*
 	 * Step 3: Start Containers
 	 */
+	// the kubeconfig can only be written once the server is ready
+	if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig || clusterConfig.KubeconfigOpts.Output != "" {
+		clusterConfig.ClusterCreateOpts.WaitForServer = true
+	}
+	progress.Report(progress.OperationClusterCreate, "start", 50, "Starting nodes")
 	if err := ClusterStart(ctx, runtime, &clusterConfig.Cluster, k3d.ClusterStartOpts{
-		WaitForServer:   clusterConfig.ClusterCreateOpts.WaitForServer,
-		Timeout:         clusterConfig.ClusterCreateOpts.Timeout, // TODO: here we should consider the time used so far
-		NodeHooks:       clusterConfig.ClusterCreateOpts.NodeHooks,
-		EnvironmentInfo: envInfo,
-		Intent:          k3d.IntentClusterCreate,
+		WaitForServer:      clusterConfig.ClusterCreateOpts.WaitForServer,
+		NoWaitForAgents:    clusterConfig.ClusterCreateOpts.NoWaitForAgents,
+		ReadyLogMessages:   clusterConfig.ClusterCreateOpts.ReadyLogMessages,
+		Timeout:            clusterConfig.ClusterCreateOpts.Timeout, // TODO: here we should consider the time used so far
+		NodeStartupTimeout: clusterConfig.ClusterCreateOpts.NodeStartupTimeout,
+		NodeHooks:          nodeHooks,
+		EnvironmentInfo:    envInfo,
+		Intent:             k3d.IntentClusterCreate,
+		OnNodeFailure:      clusterConfig.ClusterCreateOpts.OnNodeFailure,
 	}); err != nil {
 		return fmt.Errorf("Failed Cluster Start: %+v", err)
 	}
@@ -98,6 +134,7 @@ func ClusterRun(ctx context.Context, runtime k3drt.Runtime, clusterConfig *confi
 	/**********************************
 	 * Additional Cluster Preparation *
 	 **********************************/
+	progress.Report(progress.OperationClusterCreate, "finalize", 90, "Finalizing cluster configuration")
 
 	// create the registry hosting configmap
 	if len(clusterConfig.ClusterCreateOpts.Registries.Use) > 0 {
@@ -106,11 +143,43 @@ func ClusterRun(ctx context.Context, runtime k3drt.Runtime, clusterConfig *confi
 		}
 	}
 
+	if err := ClusterRunHooks(ctx, &clusterConfig.Cluster, clusterConfig.ClusterCreateOpts.ClusterHooks, k3d.LifecycleStagePostClusterReady); err != nil {
+		return fmt.Errorf("Failed Cluster Finalization: %w", err)
+	}
+
+	// write the kubeconfig as the final step, so that the cluster is ready to use once it's reported as created
+	if clusterConfig.KubeconfigOpts.UpdateDefaultKubeconfig || clusterConfig.KubeconfigOpts.Output != "" {
+		outputs, err := KubeconfigWriteForCluster(ctx, runtime, &clusterConfig.Cluster, clusterConfig.KubeconfigOpts)
+		if err != nil {
+			l.Log().Warnf("Failed to write the kubeconfig of cluster '%s': %v", clusterConfig.Cluster.Name, err)
+		} else {
+			progress.Milestone(progress.OperationClusterCreate, "finalize", 95, progress.EventKubeconfigWritten, "", fmt.Sprintf("Wrote the kubeconfig to %s", strings.Join(outputs, ", ")))
+		}
+	}
+
+	servers := NodeFilterByRoles(clusterConfig.Cluster.Nodes, []k3d.Role{k3d.ServerRole}, nil)
+	agents := NodeFilterByRoles(clusterConfig.Cluster.Nodes, []k3d.Role{k3d.AgentRole}, nil)
+	createdMsg := fmt.Sprintf("Cluster created with %d server(s) and %d agent(s)", len(servers), len(agents))
+	if len(servers) > 0 {
+		createdMsg += fmt.Sprintf(" using image %s", servers[0].Image)
+	}
+	ClusterEventRecord(clusterConfig.Cluster.Name, k3d.ClusterEventCreated, "", createdMsg)
+	progress.Done(progress.OperationClusterCreate, createdMsg)
+
+	if failed, err := ClusterFailedNodes(ctx, runtime, &clusterConfig.Cluster); err != nil {
+		l.Log().Debugf("Failed to check for failed agents: %v", err)
+	} else if len(failed) > 0 {
+		l.Log().Warnf("%d agent(s) failed and were left out of the cluster: %s - retry them via 'k3d cluster repair %s'", len(failed), strings.Join(failed, ", "), clusterConfig.Cluster.Name)
+	}
+
 	return nil
 }
 
 // ClusterPrep takes care of the steps required before creating/starting the cluster containers
-func ClusterPrep(ctx context.Context, runtime k3drt.Runtime, clusterConfig *config.ClusterConfig) error {
+func ClusterPrep(ctx context.Context, runtime k3drt.Runtime, clusterConfig *config.ClusterConfig) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterPrep", tracing.String("k3d.cluster.name", clusterConfig.Cluster.Name))
+	defer func() { span.End(err) }()
+
 	/*
 	 * Set up contexts
 	 * Used for (early) termination (across API boundaries)
@@ -123,8 +192,11 @@ func ClusterPrep(ctx context.Context, runtime k3drt.Runtime, clusterConfig *conf
 	}
 
 	/*
-	 * Step 0: Pre-Pull Images
+	 * Step 0: Preflight Checks & Pre-Pull Images
 	 */
+	if err := ClusterPreflight(clusterPrepCtx, runtime, clusterConfig); err != nil {
+		return fmt.Errorf("Failed Preflight Checks: %+v", err)
+	}
 	// TODO: ClusterPrep: add image pre-pulling step
 
 	/*
@@ -244,6 +316,47 @@ func ClusterPrep(ctx context.Context, runtime k3drt.Runtime, clusterConfig *conf
 		})
 	}
 
+	/*
+	 * Step 4: Manifests
+	 * -> written into the manifests directory of the servers, from where k3s auto-deploys them
+	 */
+	if len(clusterConfig.ClusterCreateOpts.Manifests) > 0 || clusterConfig.ClusterCreateOpts.CoreDNSCustom != nil {
+		manifests, err := ManifestsLoad(ctx, clusterConfig.ClusterCreateOpts.Manifests)
+		if err != nil {
+			return fmt.Errorf("Failed to load manifests: %w", err)
+		}
+		if clusterConfig.ClusterCreateOpts.CoreDNSCustom != nil {
+			corednsManifest, err := CoreDNSCustomManifest(clusterConfig.ClusterCreateOpts.CoreDNSCustom)
+			if err != nil {
+				return fmt.Errorf("Failed to prepare the custom CoreDNS config: %w", err)
+			}
+			for _, manifest := range manifests {
+				if manifest.Name == corednsManifest.Name {
+					return fmt.Errorf("manifest '%s' has the same file name as the custom CoreDNS config '%s'", manifest.Source, corednsManifest.Name)
+				}
+			}
+			manifests = append(manifests, *corednsManifest)
+		}
+		for _, node := range clusterConfig.Cluster.Nodes {
+			if node.Role != k3d.ServerRole {
+				continue
+			}
+			for _, manifest := range manifests {
+				node.HookActions = append(node.HookActions, k3d.NodeHook{
+					Stage: k3d.LifecycleStagePreStart,
+					Action: actions.WriteFileAction{
+						Runtime:     runtime,
+						Content:     manifest.Content,
+						Dest:        path.Join(k3s.K3sPathManifests, manifest.Name),
+						Mode:        0644,
+						Description: fmt.Sprintf("Write manifest %s", manifest.Source),
+					},
+				})
+			}
+		}
+		l.Log().Infof("Deploying %d manifest(s) on cluster creation", len(manifests))
+	}
+
 	return nil
 
 }
@@ -261,9 +374,13 @@ func ClusterPrepNetwork(ctx context.Context, runtime k3drt.Runtime, cluster *k3d
 		return fmt.Errorf("cannot specify subnet for exiting network")
 	}
 
+	if cluster.Network.Name != "" && cluster.Network.External && (cluster.Network.IPv6 || !cluster.Network.IPAM.Gateway.IsZero()) {
+		return fmt.Errorf("cannot specify gateway or enable IPv6 for existing network")
+	}
+
 	// generate cluster network name, if not set
 	if cluster.Network.Name == "" && !cluster.Network.External {
-		cluster.Network.Name = fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
+		cluster.Network.Name = fmt.Sprintf("%s-%s", k3d.ObjectNamePrefix, cluster.Name)
 	}
 
 	// handle hostnetwork
@@ -283,9 +400,21 @@ func ClusterPrepNetwork(ctx context.Context, runtime k3drt.Runtime, cluster *k3d
 	clusterCreateOpts.GlobalLabels[k3d.LabelNetwork] = cluster.Network.Name
 	clusterCreateOpts.GlobalLabels[k3d.LabelNetworkIPRange] = cluster.Network.IPAM.IPPrefix.String()
 	clusterCreateOpts.GlobalLabels[k3d.LabelNetworkExternal] = strconv.FormatBool(cluster.Network.External)
+	networkMsg := fmt.Sprintf("Created network '%s'", network.Name)
 	if networkExists {
 		l.Log().Infof("Re-using existing network '%s' (%s)", network.Name, network.ID)
 		clusterCreateOpts.GlobalLabels[k3d.LabelNetworkExternal] = "true" // if the network wasn't created, we say that it's managed externally (important for cluster deletion)
+		networkMsg = fmt.Sprintf("Re-using existing network '%s'", network.Name)
+	}
+	progress.Milestone(progress.OperationClusterCreate, "prepare", 10, progress.EventNetworkCreated, "", networkMsg)
+
+	// the cluster and service CIDRs must not overlap the (possibly auto-assigned) subnet of the cluster network
+	clusterCIDR, clusterCIDRExplicit, serviceCIDR, serviceCIDRExplicit := ClusterGetCIDRs(cluster)
+	if err := ValidateClusterCIDRs(clusterCIDR, serviceCIDR, cluster.Network.IPAM.IPPrefix, cluster.Network.IPAM.IPv6Prefix); err != nil {
+		if clusterCIDRExplicit || serviceCIDRExplicit {
+			return fmt.Errorf("invalid cluster network setup: %w", err)
+		}
+		l.Log().Warnf("Cluster network '%s' collides with the default k3s CIDRs (%v): set --cluster-cidr/--service-cidr or --subnet to avoid routing issues", cluster.Network.Name, err)
 	}
 
 	// just reserve some IPs for k3d (e.g. k3d-tools container), so we don't try to use them again
@@ -305,15 +434,34 @@ func ClusterPrepImageVolume(ctx context.Context, runtime k3drt.Runtime, cluster
 	 * Cluster-Wide volumes
 	 * - image volume (for importing images)
 	 */
-	imageVolumeName := fmt.Sprintf("%s-%s-images", k3d.DefaultObjectNamePrefix, cluster.Name)
-	if err := runtime.CreateVolume(ctx, imageVolumeName, map[string]string{k3d.LabelClusterName: cluster.Name}); err != nil {
-		return fmt.Errorf("failed to create image volume '%s' for cluster '%s': %w", imageVolumeName, cluster.Name, err)
+	imageVolumeName := clusterCreateOpts.ImageVolume
+	if imageVolumeName == "" {
+		imageVolumeName = fmt.Sprintf("%s-%s-images", k3d.ObjectNamePrefix, cluster.Name)
+	}
+
+	// reuse an existing volume (e.g. kept from a previous cluster), so that the images imported into it are still available
+	if _, err := runtime.GetVolume(ctx, imageVolumeName); err == nil {
+		l.Log().Infof("Reusing existing image volume %s", imageVolumeName)
+	} else if !errors.Is(err, runtimeErr.ErrRuntimeVolumeNotExists) {
+		return fmt.Errorf("failed to check for existing image volume '%s': %w", imageVolumeName, err)
+	} else {
+		volumeLabels := map[string]string{k3d.LabelClusterName: cluster.Name}
+		if clusterCreateOpts.KeepImageVolume {
+			volumeLabels[k3d.LabelImageVolumeKeep] = "true" // so that it's not pruned as an orphan once the cluster is deleted
+		}
+		if err := runtime.CreateVolume(ctx, imageVolumeName, volumeLabels); err != nil {
+			return fmt.Errorf("failed to create image volume '%s' for cluster '%s': %w", imageVolumeName, cluster.Name, err)
+		}
+		l.Log().Infof("Created image volume %s", imageVolumeName)
+		cluster.Volumes = append(cluster.Volumes, imageVolumeName)
 	}
-	l.Log().Infof("Created image volume %s", imageVolumeName)
 
 	clusterCreateOpts.GlobalLabels[k3d.LabelImageVolume] = imageVolumeName
 	cluster.ImageVolume = imageVolumeName
-	cluster.Volumes = append(cluster.Volumes, imageVolumeName)
+	if clusterCreateOpts.KeepImageVolume {
+		clusterCreateOpts.GlobalLabels[k3d.LabelImageVolumeKeep] = "true"
+		cluster.KeepImageVolume = true
+	}
 
 	// attach volume to nodes
 	for _, node := range cluster.Nodes {
@@ -325,7 +473,9 @@ func ClusterPrepImageVolume(ctx context.Context, runtime k3drt.Runtime, cluster
 // ClusterCreate creates a new cluster consisting of
 // - some containerized k3s nodes
 // - a docker network
-func ClusterCreate(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, clusterCreateOpts *k3d.ClusterCreateOpts) error {
+func ClusterCreate(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, clusterCreateOpts *k3d.ClusterCreateOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterCreate", tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
 
 	l.Log().Tracef(`
 ===== Creating Cluster =====
@@ -356,13 +506,12 @@ ClusterCreatOpts:
 	/*
 	 * Docker Machine Special Configuration
 	 */
-	if cluster.KubeAPI.Host == k3d.DefaultAPIHost && runtime == k3drt.Docker {
-		// If the runtime is docker, attempt to use the docker host
+	if apiHostIP := net.ParseIP(cluster.KubeAPI.Host); apiHostIP != nil && apiHostIP.IsUnspecified() && runtime == k3drt.Docker {
+		// If the runtime is docker, attempt to use the docker host (e.g. a remote daemon, docker-machine or Docker Desktop)
 		if runtime == runtimes.Docker {
 			dockerHost := runtime.GetHost()
 			if dockerHost != "" {
-				dockerHost = strings.Split(dockerHost, ":")[0] // remove the port
-				l.Log().Tracef("Using docker host %s", dockerHost)
+				l.Log().Infof("Using docker host %s as the Kubernetes API host (set it explicitly with --api-host)", dockerHost)
 				cluster.KubeAPI.Host = dockerHost
 			}
 		}
@@ -377,6 +526,15 @@ ClusterCreatOpts:
 	}
 	clusterCreateOpts.GlobalLabels[k3d.LabelClusterToken] = cluster.Token
 
+	/*
+	 * Creation Timestamp
+	 */
+
+	if cluster.Created == "" {
+		cluster.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	clusterCreateOpts.GlobalLabels[k3d.LabelClusterCreated] = cluster.Created
+
 	/*
 	 * Nodes
 	 */
@@ -389,6 +547,19 @@ ClusterCreatOpts:
 	clusterCreateOpts.GlobalLabels[k3d.LabelClusterURL] = connectionURL
 	clusterCreateOpts.GlobalEnv = append(clusterCreateOpts.GlobalEnv, fmt.Sprintf("%s=%s", k3s.EnvClusterToken, cluster.Token))
 
+	// forward the host's proxy settings (they come first, so that they can be overridden per node via --env)
+	var proxyEnv []string
+	if clusterCreateOpts.HTTPProxy {
+		proxyEnv = ClusterProxyEnv(cluster)
+	}
+
+	// used for progress reporting
+	k3sNodeCount := len(NodeFilterByRoles(cluster.Nodes, []k3d.Role{k3d.ServerRole, k3d.AgentRole}, nil))
+	createdCount := 0
+
+	// agents that failed with the NodeFailurePolicyContinue, which are dropped from the cluster
+	failedNodes := map[*k3d.Node]bool{}
+
 	nodeSetup := func(node *k3d.Node) error {
 		// cluster specific settings
 		if node.RuntimeLabels == nil {
@@ -401,13 +572,18 @@ ClusterCreatOpts:
 		}
 
 		// ensure global env
-		node.Env = append(node.Env, clusterCreateOpts.GlobalEnv...)
+		node.Env = append(append(append([]string{}, proxyEnv...), node.Env...), clusterCreateOpts.GlobalEnv...)
+
+		// the fix is enabled per node, so that nodes added later (copying the labels) get it as well
+		if clusterCreateOpts.FixInotify && (node.Role == k3d.ServerRole || node.Role == k3d.AgentRole) {
+			node.RuntimeLabels[k3d.LabelNodeFixInotify] = "true"
+		}
 
 		// node role specific settings
 		if node.Role == k3d.ServerRole {
 
 			if cluster.Network.IPAM.Managed {
-				ip, err := GetIP(ctx, runtime, &cluster.Network)
+				ip, err := GetIP(clusterCreateCtx, runtime, &cluster.Network)
 				if err != nil {
 					return fmt.Errorf("failed to find free IP in network %s: %w", cluster.Network.Name, err)
 				}
@@ -435,10 +611,26 @@ ClusterCreatOpts:
 
 		// create node
 		l.Log().Infof("Creating node '%s'", node.Name)
-		if err := NodeCreate(clusterCreateCtx, runtime, node, k3d.NodeCreateOpts{}); err != nil {
+		// NodeCreate extends the spec, so a retry has to start over from the original one
+		env, args, volumes := append([]string{}, node.Env...), append([]string{}, node.Args...), append([]string{}, node.Volumes...)
+		failed, err := nodeRunWithFailurePolicy(clusterCreateCtx, runtime, node, clusterCreateOpts.OnNodeFailure, "create", func() error {
+			return NodeCreate(clusterCreateCtx, runtime, node, k3d.NodeCreateOpts{})
+		}, func() {
+			if err := runtime.DeleteNode(clusterCreateCtx, node); err != nil {
+				l.Log().Debugf("Failed to delete partially created node '%s': %v", node.Name, err)
+			}
+			node.Env, node.Args, node.Volumes = append([]string{}, env...), append([]string{}, args...), append([]string{}, volumes...)
+		})
+		if err != nil {
 			return fmt.Errorf("failed to create node: %w", err)
 		}
+		if failed {
+			failedNodes[node] = true
+			return nil
+		}
 		l.Log().Debugf("Created node '%s'", node.Name)
+		createdCount++
+		progress.Milestone(progress.OperationClusterCreate, "create", progress.Scale(20, 45, createdCount, k3sNodeCount), progress.EventNodeCreated, node.Name, fmt.Sprintf("Created node '%s'", node.Name))
 		return nil
 	}
 
@@ -484,7 +676,10 @@ ClusterCreatOpts:
 				node.Ports[k3d.DefaultAPIPort] = []nat.PortBinding{cluster.KubeAPI.Binding}
 			}
 
-			time.Sleep(1 * time.Second) // FIXME: arbitrary wait for one second to avoid race conditions of servers registering
+			// FIXME: arbitrary wait for one second to avoid race conditions of servers registering
+			if err := util.SleepWithContext(clusterCreateCtx, 1*time.Second); err != nil {
+				return fmt.Errorf("stopped creating server nodes: %w", err)
+			}
 
 			serverCount++
 
@@ -496,6 +691,8 @@ ClusterCreatOpts:
 		}
 	}
 
+	clusterDropNodes(cluster, failedNodes)
+
 	// WARN, if there are exactly two server nodes: that means we're using etcd, but don't have fault tolerance
 	if serverCount == 2 {
 		l.Log().Warnln("You're creating 2 server nodes: Please consider creating at least 3 to achieve etcd quorum & fault tolerance")
@@ -508,7 +705,7 @@ ClusterCreatOpts:
 	if !clusterCreateOpts.DisableLoadBalancer {
 		if cluster.ServerLoadBalancer == nil {
 			l.Log().Infof("No loadbalancer specified, creating a default one...")
-			lbNode, err := LoadbalancerPrepare(ctx, runtime, cluster, &k3d.LoadbalancerCreateOpts{Labels: clusterCreateOpts.GlobalLabels})
+			lbNode, err := LoadbalancerPrepare(clusterCreateCtx, runtime, cluster, &k3d.LoadbalancerCreateOpts{Labels: clusterCreateOpts.GlobalLabels})
 			if err != nil {
 				return fmt.Errorf("failed to prepare loadbalancer: %w", err)
 			}
@@ -545,7 +742,7 @@ ClusterCreatOpts:
 		cluster.ServerLoadBalancer.Node.HookActions = append(cluster.ServerLoadBalancer.Node.HookActions, writeLbConfigAction)
 
 		l.Log().Infof("Creating LoadBalancer '%s'", cluster.ServerLoadBalancer.Node.Name)
-		if err := NodeCreate(ctx, runtime, cluster.ServerLoadBalancer.Node, k3d.NodeCreateOpts{}); err != nil {
+		if err := NodeCreate(clusterCreateCtx, runtime, cluster.ServerLoadBalancer.Node, k3d.NodeCreateOpts{}); err != nil {
 			return fmt.Errorf("error creating loadbalancer: %v", err)
 		}
 		l.Log().Debugf("Created loadbalancer '%s'", cluster.ServerLoadBalancer.Node.Name)
@@ -555,10 +752,12 @@ ClusterCreatOpts:
 }
 
 // ClusterDelete deletes an existing cluster
-func ClusterDelete(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, opts k3d.ClusterDeleteOpts) error {
+func ClusterDelete(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, opts k3d.ClusterDeleteOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterDelete", tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
 
 	l.Log().Infof("Deleting cluster '%s'", cluster.Name)
-	cluster, err := ClusterGet(ctx, runtime, cluster)
+	cluster, err = ClusterGet(ctx, runtime, cluster)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster: %w", err)
 	}
@@ -645,6 +844,10 @@ func ClusterDelete(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clus
 	// delete managed volumes attached to this cluster
 	l.Log().Infof("Deleting %d attached volumes...", len(cluster.Volumes))
 	for _, vol := range cluster.Volumes {
+		if cluster.KeepImageVolume && vol == cluster.ImageVolume {
+			l.Log().Infof("Keeping image volume %s", vol)
+			continue
+		}
 		l.Log().Debugf("Deleting volume %s...", vol)
 		if err := runtime.DeleteVolume(ctx, vol); err != nil {
 			l.Log().Warningf("Failed to delete volume '%s' of cluster '%s': %v -> Try to delete it manually", cluster.ImageVolume, err, cluster.Name)
@@ -655,6 +858,11 @@ func ClusterDelete(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clus
 	if failed > 0 {
 		return fmt.Errorf("Failed to delete %d nodes: Try to delete them manually", failed)
 	}
+
+	// delete the cluster's event log
+	if err := ClusterEventDelete(cluster.Name); err != nil {
+		l.Log().Warnf("Failed to delete event log of cluster '%s': %v", cluster.Name, err)
+	}
 	return nil
 }
 
@@ -713,6 +921,39 @@ func ClusterList(ctx context.Context, runtime k3drt.Runtime) ([]*k3d.Cluster, er
 	return clusters, nil
 }
 
+// ClusterListBySelector returns all clusters with at least one node carrying each of the given runtime labels (e.g. set via `--runtime-label`)
+func ClusterListBySelector(ctx context.Context, runtime k3drt.Runtime, selector map[string]string) ([]*k3d.Cluster, error) {
+	clusters, err := ClusterList(ctx, runtime)
+	if err != nil {
+		return nil, err
+	}
+	selected := []*k3d.Cluster{}
+	for _, cluster := range clusters {
+		if ClusterMatchesRuntimeLabels(cluster, selector) {
+			selected = append(selected, cluster)
+		}
+	}
+	l.Log().Debugf("Found %d clusters matching selector %v", len(selected), selector)
+	return selected, nil
+}
+
+// ClusterMatchesRuntimeLabels checks whether each of the given runtime labels is set on at least one node of the cluster
+func ClusterMatchesRuntimeLabels(cluster *k3d.Cluster, labels map[string]string) bool {
+	for key, value := range labels {
+		labelMatch := false
+		for _, node := range cluster.Nodes {
+			if v, ok := node.RuntimeLabels[key]; ok && v == value {
+				labelMatch = true
+				break
+			}
+		}
+		if !labelMatch {
+			return false
+		}
+	}
+	return true
+}
+
 // populateClusterFieldsFromLabels inspects labels attached to nodes and translates them to struct fields
 func populateClusterFieldsFromLabels(cluster *k3d.Cluster) error {
 	networkExternalSet := false
@@ -743,6 +984,11 @@ func populateClusterFieldsFromLabels(cluster *k3d.Cluster) error {
 				cluster.ImageVolume = imageVolumeName
 			}
 		}
+		if !cluster.KeepImageVolume {
+			if keep, ok := node.RuntimeLabels[k3d.LabelImageVolumeKeep]; ok {
+				cluster.KeepImageVolume, _ = strconv.ParseBool(keep)
+			}
+		}
 
 		// get k3s cluster's token
 		if cluster.Token == "" {
@@ -750,6 +996,52 @@ func populateClusterFieldsFromLabels(cluster *k3d.Cluster) error {
 				cluster.Token = token
 			}
 		}
+
+		// get the creation timestamp
+		if cluster.Created == "" {
+			if created, ok := node.RuntimeLabels[k3d.LabelClusterCreated]; ok {
+				cluster.Created = created
+			}
+		}
+
+		// get the custom cluster domain
+		if cluster.Domain == "" {
+			if domain, ok := node.RuntimeLabels[k3d.LabelClusterDomain]; ok {
+				cluster.Domain = domain
+			}
+		}
+
+		// get the hibernation schedule
+		if cluster.HibernationSchedule == "" {
+			if schedule, ok := node.RuntimeLabels[k3d.LabelHibernationSchedule]; ok {
+				cluster.HibernationSchedule = schedule
+			}
+		}
+
+		// get the ID of the external resource the cluster belongs to
+		if cluster.ExternalID == "" {
+			if externalID, ok := node.RuntimeLabels[k3d.LabelClusterExternalID]; ok {
+				cluster.ExternalID = externalID
+			}
+		}
+
+		// get the name prefix used when creating the cluster
+		if cluster.NamePrefix == "" {
+			if prefix, ok := node.RuntimeLabels[k3d.LabelClusterNamePrefix]; ok {
+				cluster.NamePrefix = prefix
+			}
+		}
+
+		// get the customized startup order
+		if cluster.Startup == nil {
+			if label, ok := node.RuntimeLabels[k3d.LabelClusterStartup]; ok {
+				startup, err := startupOptsFromLabel(label)
+				if err != nil {
+					return fmt.Errorf("failed to get startup order of cluster '%s' from node '%s': %w", cluster.Name, node.Name, err)
+				}
+				cluster.Startup = startup
+			}
+		}
 	}
 
 	return nil
@@ -828,11 +1120,14 @@ func GenerateClusterToken() string {
 }
 
 func GenerateNodeName(cluster string, role k3d.Role, suffix int) string {
-	return fmt.Sprintf("%s-%s-%s-%d", k3d.DefaultObjectNamePrefix, cluster, role, suffix)
+	return fmt.Sprintf("%s-%s-%s-%d", k3d.ObjectNamePrefix, cluster, role, suffix)
 }
 
 // ClusterStart starts a whole cluster (i.e. all nodes of the cluster)
-func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, clusterStartOpts types.ClusterStartOpts) error {
+func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, clusterStartOpts types.ClusterStartOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterStart", tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
+
 	l.Log().Infof("Starting cluster '%s'", cluster.Name)
 
 	if clusterStartOpts.Intent == "" {
@@ -849,18 +1144,25 @@ func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clust
 	var initNode *k3d.Node
 	var servers []*k3d.Node
 	var agents []*k3d.Node
+	var registries []*k3d.Node
+	var loadbalancers []*k3d.Node
 	var aux []*k3d.Node
 	for _, n := range cluster.Nodes {
 		if !n.State.Running {
-			if n.Role == k3d.ServerRole {
+			switch n.Role {
+			case k3d.ServerRole:
 				if n.ServerOpts.IsInit {
 					initNode = n
 					continue
 				}
 				servers = append(servers, n)
-			} else if n.Role == k3d.AgentRole {
+			case k3d.AgentRole:
 				agents = append(agents, n)
-			} else {
+			case k3d.RegistryRole:
+				registries = append(registries, n)
+			case k3d.LoadBalancerRole:
+				loadbalancers = append(loadbalancers, n)
+			default:
 				aux = append(aux, n)
 			}
 		} else {
@@ -873,91 +1175,182 @@ func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clust
 		return servers[i].Name < servers[j].Name
 	})
 
-	/*
-	 * Init Node
-	 */
-	if initNode != nil {
-		l.Log().Infoln("Starting the initializing server...")
-		if err := NodeStart(ctx, runtime, initNode, &k3d.NodeStartOpts{
-			Wait:            true, // always wait for the init node
-			NodeHooks:       clusterStartOpts.NodeHooks,
-			ReadyLogMessage: types.GetReadyLogMessage(initNode, clusterStartOpts.Intent), // initNode means, that we're using etcd -> this will need quorum, so "k3s is up and running" won't happen right now
-			EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
-		}); err != nil {
-			return fmt.Errorf("Failed to start initializing server node: %+v", err)
+	// progress is only reported as part of the cluster creation
+	reportProgress := func(percent int, message string) {
+		if clusterStartOpts.Intent == k3d.IntentClusterCreate {
+			progress.Report(progress.OperationClusterCreate, "start", percent, message)
+		}
+	}
+	reportMilestone := func(percent int, event string, node string, message string) {
+		if clusterStartOpts.Intent == k3d.IntentClusterCreate {
+			progress.Milestone(progress.OperationClusterCreate, "start", percent, event, node, message)
 		}
 	}
 
 	/*
-	 * Server Nodes
+	 * Startup Steps
+	 * -> the nodes are started in steps (by role), which are run in the order defined by their dependencies
 	 */
-	if len(servers) > 0 {
-		l.Log().Infoln("Starting servers...")
-		for _, serverNode := range servers {
-			if err := NodeStart(ctx, runtime, serverNode, &k3d.NodeStartOpts{
-				Wait:            true,
-				NodeHooks:       append(clusterStartOpts.NodeHooks, serverNode.HookActions...),
+	startupDependencies, err := StartupDependencies(cluster.Startup)
+	if err != nil {
+		return fmt.Errorf("invalid startup order of cluster '%s': %w", cluster.Name, err)
+	}
+
+	startupSteps := map[k3d.StartupStep]func(ctx context.Context) error{
+		/*
+		 * Init Node
+		 */
+		k3d.StartupStepInitServer: func(ctx context.Context) error {
+			if initNode == nil {
+				return nil
+			}
+			l.Log().Infoln("Starting the initializing server...")
+			if err := NodeStart(ctx, runtime, initNode, &k3d.NodeStartOpts{
+				Wait:            true, // always wait for the init node
+				NodeHooks:       append(clusterStartOpts.NodeHooks, initNode.HookActions...),
+				ReadyLogMessage: NodeGetReadyLogMessage(initNode, clusterStartOpts.ReadyLogMessages, clusterStartOpts.Intent), // initNode means, that we're using etcd -> this will need quorum, so "k3s is up and running" won't happen right now
 				EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
+				Timeout:         clusterStartOpts.NodeStartupTimeout,
 			}); err != nil {
-				return fmt.Errorf("Failed to start server %s: %+v", serverNode.Name, err)
+				return fmt.Errorf("Failed to start initializing server node: %+v", err)
 			}
-		}
-	} else {
-		l.Log().Infoln("All servers already running.")
-	}
+			reportMilestone(55, progress.EventNodeReady, initNode.Name, fmt.Sprintf("Initializing server '%s' is ready", initNode.Name))
+			reportProgress(55, "Started the initializing server")
+			return nil
+		},
 
-	/*
-	 * Agent Nodes
-	 */
-	if len(agents) > 0 {
-		agentWG, aCtx := errgroup.WithContext(ctx)
-
-		l.Log().Infoln("Starting agents...")
-		for _, agentNode := range agents {
-			currentAgentNode := agentNode
-			agentWG.Go(func() error {
-				return NodeStart(aCtx, runtime, currentAgentNode, &k3d.NodeStartOpts{
+		/*
+		 * Server Nodes
+		 */
+		k3d.StartupStepServers: func(ctx context.Context) error {
+			if len(servers) == 0 {
+				l.Log().Infoln("All servers already running.")
+				return nil
+			}
+			l.Log().Infoln("Starting servers...")
+			for i, serverNode := range servers {
+				if err := NodeStart(ctx, runtime, serverNode, &k3d.NodeStartOpts{
 					Wait:            true,
-					NodeHooks:       clusterStartOpts.NodeHooks,
+					NodeHooks:       append(clusterStartOpts.NodeHooks, serverNode.HookActions...),
+					ReadyLogMessage: NodeGetReadyLogMessage(serverNode, clusterStartOpts.ReadyLogMessages, clusterStartOpts.Intent),
 					EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
+					Timeout:         clusterStartOpts.NodeStartupTimeout,
+				}); err != nil {
+					return fmt.Errorf("Failed to start server %s: %+v", serverNode.Name, err)
+				}
+				reportMilestone(progress.Scale(55, 65, i+1, len(servers)), progress.EventNodeReady, serverNode.Name, fmt.Sprintf("Server '%s' is ready", serverNode.Name))
+			}
+			reportProgress(65, "Started servers")
+			return nil
+		},
+
+		/*
+		 * Agent Nodes
+		 */
+		k3d.StartupStepAgents: func(ctx context.Context) error {
+			if len(agents) == 0 {
+				l.Log().Infoln("All agents already running.")
+				return nil
+			}
+			agentWG, aCtx := errgroup.WithContext(ctx)
+			var failedMutex sync.Mutex
+			failedNodes := map[*k3d.Node]bool{}
+			var readyCount int32
+
+			l.Log().Infoln("Starting agents...")
+			for _, agentNode := range agents {
+				currentAgentNode := agentNode
+				agentWG.Go(func() error {
+					failed, err := nodeRunWithFailurePolicy(aCtx, runtime, currentAgentNode, clusterStartOpts.OnNodeFailure, "start", func() error {
+						return NodeStart(aCtx, runtime, currentAgentNode, &k3d.NodeStartOpts{
+							Wait:            !clusterStartOpts.NoWaitForAgents,
+							NodeHooks:       clusterStartOpts.NodeHooks,
+							ReadyLogMessage: NodeGetReadyLogMessage(currentAgentNode, clusterStartOpts.ReadyLogMessages, clusterStartOpts.Intent),
+							EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
+							Timeout:         clusterStartOpts.NodeStartupTimeout,
+						})
+					}, func() {
+						if err := runtime.StopNode(aCtx, currentAgentNode); err != nil {
+							l.Log().Debugf("Failed to stop agent '%s' before restarting it: %v", currentAgentNode.Name, err)
+						}
+						currentAgentNode.State.Running = false
+					})
+					if failed {
+						failedMutex.Lock()
+						failedNodes[currentAgentNode] = true
+						failedMutex.Unlock()
+					} else if err == nil && !clusterStartOpts.NoWaitForAgents {
+						reportMilestone(progress.Scale(65, 75, int(atomic.AddInt32(&readyCount, 1)), len(agents)), progress.EventNodeReady, currentAgentNode.Name, fmt.Sprintf("Agent '%s' is ready", currentAgentNode.Name))
+					}
+					return err
 				})
-			})
-		}
-		if err := agentWG.Wait(); err != nil {
-			return fmt.Errorf("Failed to add one or more agents: %w", err)
-		}
-	} else {
-		l.Log().Infoln("All agents already running.")
+			}
+			if err := agentWG.Wait(); err != nil {
+				return fmt.Errorf("Failed to add one or more agents: %w", err)
+			}
+			clusterDropNodes(cluster, failedNodes)
+			reportProgress(75, "Started agents")
+			return nil
+		},
+
+		/*
+		 * Auxiliary/Helper Nodes
+		 */
+		k3d.StartupStepRegistries: func(ctx context.Context) error {
+			if len(registries) == 0 {
+				return nil
+			}
+			l.Log().Infoln("Starting registries...")
+			if err := clusterStartHelperNodes(ctx, runtime, registries, &clusterStartOpts); err != nil {
+				return fmt.Errorf("Failed to start one or more registries: %w", err)
+			}
+			return nil
+		},
+		k3d.StartupStepLoadbalancer: func(ctx context.Context) error {
+			if len(loadbalancers) == 0 {
+				return nil
+			}
+			l.Log().Infoln("Starting the loadbalancer...")
+			if err := clusterStartHelperNodes(ctx, runtime, loadbalancers, &clusterStartOpts); err != nil {
+				return fmt.Errorf("Failed to start the loadbalancer: %w", err)
+			}
+			for _, lb := range loadbalancers {
+				reportMilestone(78, progress.EventLoadBalancerConfigured, lb.Name, fmt.Sprintf("Loadbalancer '%s' is routing to the cluster", lb.Name))
+			}
+			return nil
+		},
+		k3d.StartupStepHelpers: func(ctx context.Context) error {
+			if len(aux) == 0 {
+				l.Log().Infoln("All helpers already running.")
+				return nil
+			}
+			l.Log().Infoln("Starting helpers...")
+			if err := clusterStartHelperNodes(ctx, runtime, aux, &clusterStartOpts); err != nil {
+				return fmt.Errorf("Failed to add one or more helper nodes: %w", err)
+			}
+			reportProgress(80, "Started helpers")
+			return nil
+		},
 	}
 
 	/*
-	 * Auxiliary/Helper Nodes
+	 * Startup Checks (custom steps, waiting for external dependencies)
 	 */
-
-	if len(aux) > 0 {
-		helperWG, hCtx := errgroup.WithContext(ctx)
-		l.Log().Infoln("Starting helpers...")
-		for _, helperNode := range aux {
-			currentHelperNode := helperNode
-
-			helperWG.Go(func() error {
-				nodeStartOpts := &k3d.NodeStartOpts{
-					NodeHooks:       currentHelperNode.HookActions,
-					EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
-				}
-				if currentHelperNode.Role == k3d.LoadBalancerRole {
-					nodeStartOpts.Wait = true
-				}
-
-				return NodeStart(hCtx, runtime, currentHelperNode, nodeStartOpts)
-			})
+	if cluster.Startup != nil {
+		for _, check := range cluster.Startup.Checks {
+			check := check
+			startupSteps[check.Name] = func(ctx context.Context) error {
+				return startupCheckRun(ctx, check)
+			}
 		}
+	}
 
-		if err := helperWG.Wait(); err != nil {
-			return fmt.Errorf("Failed to add one or more helper nodes: %w", err)
-		}
-	} else {
-		l.Log().Infoln("All helpers already running.")
+	graph := map[k3d.StartupStep]*startupGraphStep{}
+	for step, dependsOn := range startupDependencies {
+		graph[step] = &startupGraphStep{dependsOn: dependsOn, run: startupSteps[step]}
+	}
+	if err := startupGraphRun(ctx, graph); err != nil {
+		return err
 	}
 
 	/*
@@ -992,6 +1385,7 @@ func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clust
 				for _, member := range net.Members {
 					hosts += fmt.Sprintf("%s %s\n", member.IP.String(), member.Name)
 				}
+				hosts += composeServiceHosts(ctx, runtime, cluster.Network.Name)
 
 				l.Log().Infof("Injecting records for host.k3d.internal and for %d network members into CoreDNS configmap...", len(net.Members))
 				act := actions.RewriteFileAction{
@@ -1049,11 +1443,40 @@ func ClusterStart(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clust
 		}
 	}
 
+	if clusterStartOpts.Intent != k3d.IntentClusterCreate {
+		ClusterEventRecord(cluster.Name, k3d.ClusterEventStarted, "", "Cluster started")
+	}
+
 	return nil
 }
 
+// clusterStartHelperNodes starts auxiliary nodes (e.g. registries or the loadbalancer) in parallel
+func clusterStartHelperNodes(ctx context.Context, runtime k3drt.Runtime, nodes []*k3d.Node, clusterStartOpts *k3d.ClusterStartOpts) error {
+	helperWG, hCtx := errgroup.WithContext(ctx)
+	for _, helperNode := range nodes {
+		currentHelperNode := helperNode
+
+		helperWG.Go(func() error {
+			nodeStartOpts := &k3d.NodeStartOpts{
+				NodeHooks:       currentHelperNode.HookActions,
+				EnvironmentInfo: clusterStartOpts.EnvironmentInfo,
+			}
+			if currentHelperNode.Role == k3d.LoadBalancerRole {
+				nodeStartOpts.Wait = true
+				nodeStartOpts.ReadyLogMessage = NodeGetReadyLogMessage(currentHelperNode, clusterStartOpts.ReadyLogMessages, clusterStartOpts.Intent)
+			}
+
+			return NodeStart(hCtx, runtime, currentHelperNode, nodeStartOpts)
+		})
+	}
+	return helperWG.Wait()
+}
+
 // ClusterStop stops a whole cluster (i.e. all nodes of the cluster)
-func ClusterStop(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster) error {
+func ClusterStop(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterStop", tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
+
 	l.Log().Infof("Stopping cluster '%s'", cluster.Name)
 
 	failed := 0
@@ -1070,6 +1493,7 @@ func ClusterStop(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluste
 	}
 
 	l.Log().Infof("Stopped cluster '%s'", cluster.Name)
+	ClusterEventRecord(cluster.Name, k3d.ClusterEventStopped, "", "Cluster stopped")
 	return nil
 }
 
@@ -1125,7 +1549,9 @@ func corednsAddHost(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Clu
 				l.Log().Debugf("(try %d/%d) error reading the logs from failed CoreDNS patch exec process in node %s: no logreader returned for exec process", i, retries, node.Name)
 			}
 			l.Log().Debugln(msg)
-			time.Sleep(1 * time.Second)
+			if err := util.SleepWithContext(ctx, 1*time.Second); err != nil {
+				return fmt.Errorf("stopped patching the CoreDNS ConfigMap: %w", err)
+			}
 		}
 	}
 	if !successInjectCoreDNSEntry {
@@ -1189,6 +1615,9 @@ func ClusterEditChangesetSimple(ctx context.Context, runtime k3drt.Runtime, clus
 	// === Ports ===
 
 	existingLB := cluster.ServerLoadBalancer
+	if existingLB == nil || existingLB.Node == nil {
+		return fmt.Errorf("cluster '%s' has no loadbalancer: ports can only be added via the loadbalancer", cluster.Name)
+	}
 	lbChangeset := &k3d.Loadbalancer{}
 
 	// copy existing loadbalancer
@@ -1228,7 +1657,11 @@ func ClusterEditChangesetSimple(ctx context.Context, runtime k3drt.Runtime, clus
 
 		// 2. transform
 		cluster.ServerLoadBalancer = lbChangeset // we're working with pointers, so let's point to the changeset here to not update the original that we keep as a reference
-		if err := TransformPorts(ctx, runtime, cluster, changeset.Ports); err != nil {
+		bindAddress := util.DefaultBindAddress()
+		if existingBindAddress, ok := existingLB.Node.RuntimeLabels[k3d.LabelClusterBindAddress]; ok && existingBindAddress != "" {
+			bindAddress = existingBindAddress
+		}
+		if err := TransformPorts(ctx, runtime, cluster, changeset.Ports, bindAddress); err != nil {
 			return fmt.Errorf("error transforming port config %s: %w", changeset.Ports, err)
 		}
 	}
@@ -1255,7 +1688,23 @@ func ClusterEditChangesetSimple(ctx context.Context, runtime k3drt.Runtime, clus
 	}
 	lbChangeset.Node.HookActions = append(lbChangeset.Node.HookActions, writeLbConfigAction)
 
-	NodeReplace(ctx, runtime, existingLB.Node, lbChangeset.Node)
+	if err := NodeReplace(ctx, runtime, existingLB.Node, lbChangeset.Node); err != nil {
+		return fmt.Errorf("failed to replace loadbalancer: %w", err)
+	}
+	ClusterEventRecord(cluster.Name, k3d.ClusterEventLoadbalancerUpdated, lbChangeset.Node.Name, fmt.Sprintf("Added ports %s via 'cluster edit'", formatPortsWithNodeFilters(changeset.Ports)))
 
 	return nil
 }
+
+// formatPortsWithNodeFilters formats port mappings the way they are passed via --port
+func formatPortsWithNodeFilters(ports []config.PortWithNodeFilters) string {
+	formatted := make([]string, 0, len(ports))
+	for _, port := range ports {
+		if len(port.NodeFilters) == 0 {
+			formatted = append(formatted, port.Port)
+			continue
+		}
+		formatted = append(formatted, fmt.Sprintf("%s@%s", port.Port, strings.Join(port.NodeFilters, ";")))
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -35,8 +35,27 @@ func CheckName(name string) error {
 	if err := ValidateHostname(name); err != nil {
 		return fmt.Errorf("Invalid cluster name. %+v", ValidateHostname(name))
 	}
-	if len(name) > types.DefaultClusterNameMaxLength {
-		return fmt.Errorf("Cluster name must be <= %d characters, but has %d", types.DefaultClusterNameMaxLength, len(name))
+	// a longer custom name prefix leaves less space for the cluster name
+	maxLength := types.DefaultClusterNameMaxLength
+	if len(types.ObjectNamePrefix) > len(types.DefaultObjectNamePrefix) {
+		maxLength -= len(types.ObjectNamePrefix) - len(types.DefaultObjectNamePrefix)
+	}
+	if len(name) > maxLength {
+		return fmt.Errorf("Cluster name must be <= %d characters, but has %d", maxLength, len(name))
+	}
+	return nil
+}
+
+// ObjectNamePrefixMaxLength is the maximal length of a custom object name prefix (see types.ObjectNamePrefix)
+const ObjectNamePrefixMaxLength = 16
+
+// CheckNamePrefix ensures that a custom object name prefix can be used in host names (RFC 1123) and is short enough to leave space for the cluster name
+func CheckNamePrefix(prefix string) error {
+	if err := ValidateHostname(prefix); err != nil {
+		return fmt.Errorf("Invalid name prefix. %+v", err)
+	}
+	if len(prefix) > ObjectNamePrefixMaxLength {
+		return fmt.Errorf("Name prefix must be <= %d characters, but has %d", ObjectNamePrefixMaxLength, len(prefix))
 	}
 	return nil
 }
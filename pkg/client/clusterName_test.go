@@ -0,0 +1,92 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCheckNameWithPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		cluster string
+		wantErr bool
+	}{
+		{name: "default prefix", prefix: k3d.DefaultObjectNamePrefix, cluster: strings.Repeat("a", 32)},
+		{name: "longer prefix shortens the cluster name", prefix: "k3d-fork", cluster: strings.Repeat("a", 32), wantErr: true},
+		{name: "longer prefix", prefix: "k3d-fork", cluster: strings.Repeat("a", 27)},
+		{name: "shorter prefix", prefix: "x", cluster: strings.Repeat("a", 32)},
+	}
+
+	defer func(prefix string) { k3d.ObjectNamePrefix = prefix }(k3d.ObjectNamePrefix)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k3d.ObjectNamePrefix = tt.prefix
+			if err := CheckName(tt.cluster); (err != nil) != tt.wantErr {
+				t.Errorf("CheckName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckNamePrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		wantErr bool
+	}{
+		{prefix: "k3d"},
+		{prefix: "my-k3d"},
+		{prefix: "", wantErr: true},
+		{prefix: "k3d_fork", wantErr: true},
+		{prefix: "-k3d", wantErr: true},
+		{prefix: strings.Repeat("k", 17), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if err := CheckNamePrefix(tt.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("CheckNamePrefix(%q) error = %v, wantErr %v", tt.prefix, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKubeconfigRemoveClusterWithRecordedPrefix(t *testing.T) {
+	defer func(prefix string) { k3d.ObjectNamePrefix = prefix }(k3d.ObjectNamePrefix)
+	k3d.ObjectNamePrefix = "other"
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["k3dfork-test"] = clientcmdapi.NewCluster()
+	kubeconfig.AuthInfos["admin@k3dfork-test"] = clientcmdapi.NewAuthInfo()
+	kubeconfig.Contexts["k3dfork-test"] = clientcmdapi.NewContext()
+	kubeconfig.CurrentContext = "k3dfork-test"
+
+	kubeconfig = KubeconfigRemoveCluster(context.Background(), &k3d.Cluster{Name: "test", NamePrefix: "k3dfork"}, kubeconfig)
+	if len(kubeconfig.Clusters) != 0 || len(kubeconfig.AuthInfos) != 0 || len(kubeconfig.Contexts) != 0 || kubeconfig.CurrentContext != "" {
+		t.Errorf("expected the cluster to be removed from the kubeconfig using its recorded prefix, got %+v", kubeconfig)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ClusterAttachCompose connects the containers of a docker compose project to the cluster network
+// and injects their service and container names into the cluster's CoreDNS, so that workloads
+// in the cluster and the compose services can reach each other.
+func ClusterAttachCompose(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, project string, opts k3d.ComposeAttachOpts) error {
+	name := cluster.Name // ClusterGet returns nil on errors
+	cluster, err := ClusterGet(ctx, runtime, cluster)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster '%s': %w", name, err)
+	}
+	if cluster.Network.Name == "" || cluster.Network.Name == "host" {
+		return fmt.Errorf("cannot attach compose services to cluster '%s' without a dedicated cluster network", cluster.Name)
+	}
+
+	containers, err := runtime.GetContainersByLabel(ctx, map[string]string{k3d.ComposeLabelProject: project})
+	if err != nil {
+		return fmt.Errorf("failed to get containers of compose project '%s': %w", project, err)
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("no containers found for compose project '%s'", project)
+	}
+
+	// connect the containers to the cluster network
+	for _, container := range containers {
+		if _, ok := container.Networks[cluster.Network.Name]; ok {
+			l.Log().Infof("Container '%s' is already connected to network '%s'", container.Name, cluster.Network.Name)
+			continue
+		}
+		l.Log().Infof("Connecting container '%s' to network '%s'...", container.Name, cluster.Network.Name)
+		if err := runtime.ConnectNodeToNetwork(ctx, &k3d.Node{Name: container.Name}, cluster.Network.Name); err != nil {
+			return fmt.Errorf("failed to connect container '%s' to network '%s': %w", container.Name, cluster.Network.Name, err)
+		}
+	}
+
+	if opts.SkipDNS {
+		return nil
+	}
+
+	if running, _ := cluster.ServerCountRunning(); running == 0 {
+		l.Log().Infof("Cluster '%s' is not running: the compose services will be injected into the cluster DNS when it starts", cluster.Name)
+		return nil
+	}
+
+	// re-fetch the containers to get their IPs in the cluster network
+	containers, err = runtime.GetContainersByLabel(ctx, map[string]string{k3d.ComposeLabelProject: project})
+	if err != nil {
+		return fmt.Errorf("failed to get containers of compose project '%s': %w", project, err)
+	}
+	for _, container := range containers {
+		ip := container.Networks[cluster.Network.Name]
+		if ip == "" {
+			l.Log().Warnf("Container '%s' has no IP in network '%s' (not running?): skipping DNS injection", container.Name, cluster.Network.Name)
+			continue
+		}
+		names := []string{container.Name}
+		if service, ok := container.Labels[k3d.ComposeLabelService]; ok && service != container.Name {
+			names = append(names, service)
+		}
+		for _, name := range names {
+			if err := corednsAddHost(ctx, runtime, cluster, ip, name); err != nil {
+				return fmt.Errorf("failed to inject '%s' into the cluster DNS: %w", name, err)
+			}
+			l.Log().Infof("Injected '%s -> %s' into the DNS of cluster '%s'", name, ip, cluster.Name)
+		}
+	}
+
+	return nil
+}
+
+// composeServiceHosts returns the host entries ("IP SERVICE") for the service names of all docker compose containers connected to the network
+// (see ClusterAttachCompose), so that they're injected into the cluster DNS again when the cluster starts (container names are injected
+// as network members anyway)
+func composeServiceHosts(ctx context.Context, runtime runtimes.Runtime, network string) string {
+	containers, err := runtime.GetContainersByLabel(ctx, map[string]string{k3d.ComposeLabelService: ""})
+	if err != nil {
+		l.Log().Debugf("Failed to get compose containers: %v", err)
+		return ""
+	}
+	hosts := ""
+	for _, container := range containers {
+		ip := container.Networks[network]
+		service := container.Labels[k3d.ComposeLabelService]
+		if ip == "" || service == container.Name {
+			continue
+		}
+		hosts += fmt.Sprintf("%s %s\n", ip, service)
+	}
+	return hosts
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// fakeComposeRuntime only knows the given containers (panics on anything else via the nil embedded interface)
+type fakeComposeRuntime struct {
+	k3drt.Runtime
+	containers []*runtimeTypes.Container
+}
+
+func (f *fakeComposeRuntime) GetContainersByLabel(_ context.Context, labels map[string]string) ([]*runtimeTypes.Container, error) {
+	var result []*runtimeTypes.Container
+	for _, container := range f.containers {
+		match := true
+		for k, v := range labels {
+			if value, ok := container.Labels[k]; !ok || (v != "" && value != v) {
+				match = false
+			}
+		}
+		if match {
+			result = append(result, container)
+		}
+	}
+	return result, nil
+}
+
+// GetNodesByLabel knows no nodes, i.e. no clusters
+func (f *fakeComposeRuntime) GetNodesByLabel(_ context.Context, _ map[string]string) ([]*k3d.Node, error) {
+	return nil, nil
+}
+
+func TestClusterAttachComposeMissingCluster(t *testing.T) {
+	err := ClusterAttachCompose(context.Background(), &fakeComposeRuntime{}, &k3d.Cluster{Name: "missing"}, "shop", k3d.ComposeAttachOpts{})
+	if err == nil || !strings.Contains(err.Error(), "'missing'") {
+		t.Errorf("expected an error naming the missing cluster, got %v", err)
+	}
+}
+
+func TestComposeServiceHosts(t *testing.T) {
+	runtime := &fakeComposeRuntime{containers: []*runtimeTypes.Container{
+		{Name: "shop-db-1", Labels: map[string]string{k3d.ComposeLabelProject: "shop", k3d.ComposeLabelService: "db"}, Networks: map[string]string{"k3d-test": "172.18.0.5", "shop_default": "172.19.0.2"}},
+		{Name: "cache", Labels: map[string]string{k3d.ComposeLabelProject: "shop", k3d.ComposeLabelService: "cache"}, Networks: map[string]string{"k3d-test": "172.18.0.6"}},
+		{Name: "other-web-1", Labels: map[string]string{k3d.ComposeLabelProject: "other", k3d.ComposeLabelService: "web"}, Networks: map[string]string{"other_default": "172.20.0.2"}},
+		{Name: "standalone", Labels: map[string]string{}, Networks: map[string]string{"k3d-test": "172.18.0.7"}},
+	}}
+
+	// container names are injected as network members already
+	if hosts := composeServiceHosts(context.Background(), runtime, "k3d-test"); hosts != "172.18.0.5 db\n" {
+		t.Errorf("unexpected hosts %q", hosts)
+	}
+}
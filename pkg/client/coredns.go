@@ -0,0 +1,164 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"gopkg.in/yaml.v2"
+	"inet.af/netaddr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CoreDNSCustomParse parses and validates a custom CoreDNS configuration
+func CoreDNSCustomParse(data []byte) (*k3d.CoreDNSCustom, error) {
+	custom := &k3d.CoreDNSCustom{}
+	if err := yaml.UnmarshalStrict(data, custom); err != nil {
+		return nil, fmt.Errorf("failed to parse custom CoreDNS config: %w", err)
+	}
+	if err := CoreDNSCustomValidate(custom); err != nil {
+		return nil, err
+	}
+	return custom, nil
+}
+
+// CoreDNSCustomValidate checks the records, domains and nameservers of a custom CoreDNS configuration
+func CoreDNSCustomValidate(custom *k3d.CoreDNSCustom) error {
+	if len(custom.Hosts) == 0 && len(custom.StubDomains) == 0 && len(custom.Rewrites) == 0 && custom.Override == "" && custom.Server == "" {
+		return fmt.Errorf("custom CoreDNS config is empty: set at least one of hosts, stubDomains, rewrites, override or server")
+	}
+
+	hostNames := map[string]bool{}
+	for _, entry := range custom.Hosts {
+		fields := strings.Fields(entry)
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid hosts entry '%s': must be 'IP HOSTNAME [HOSTNAME...]'", entry)
+		}
+		if _, err := netaddr.ParseIP(fields[0]); err != nil {
+			return fmt.Errorf("invalid IP in hosts entry '%s': %w", entry, err)
+		}
+		for _, name := range fields[1:] {
+			if errs := validation.IsDNS1123Subdomain(strings.ToLower(name)); len(errs) > 0 {
+				return fmt.Errorf("invalid hostname '%s' in hosts entry '%s': %s", name, entry, strings.Join(errs, ", "))
+			}
+			hostNames[strings.ToLower(name)] = true
+		}
+	}
+
+	for domain, nameservers := range custom.StubDomains {
+		if errs := validation.IsDNS1123Subdomain(strings.ToLower(domain)); len(errs) > 0 {
+			return fmt.Errorf("invalid stub domain '%s': %s", domain, strings.Join(errs, ", "))
+		}
+		if hostNames[strings.ToLower(domain)] {
+			return fmt.Errorf("stub domain '%s' is also a hostname of the hosts entries", domain)
+		}
+		if len(nameservers) == 0 {
+			return fmt.Errorf("stub domain '%s' has no nameservers", domain)
+		}
+		for _, nameserver := range nameservers {
+			if _, err := netaddr.ParseIP(nameserver); err == nil {
+				continue
+			}
+			if _, err := netaddr.ParseIPPort(nameserver); err != nil {
+				return fmt.Errorf("invalid nameserver '%s' of stub domain '%s': must be IP[:PORT]", nameserver, domain)
+			}
+		}
+	}
+
+	for _, rule := range custom.Rewrites {
+		if strings.TrimSpace(rule) == "" || strings.ContainsAny(rule, "\n{}") {
+			return fmt.Errorf("invalid rewrite rule '%s': must be a single line of arguments of the CoreDNS rewrite plugin", rule)
+		}
+	}
+	return nil
+}
+
+// CoreDNSCustomManifest renders the coredns-custom ConfigMap for a custom CoreDNS configuration:
+// rewrites and the raw override go into the default server block, hosts and stub domains get their own server blocks
+func CoreDNSCustomManifest(custom *k3d.CoreDNSCustom) (*Manifest, error) {
+	var override strings.Builder
+	for _, rule := range custom.Rewrites {
+		fmt.Fprintf(&override, "rewrite %s\n", strings.TrimSpace(rule))
+	}
+	if custom.Override != "" {
+		override.WriteString(strings.TrimSuffix(custom.Override, "\n") + "\n")
+	}
+
+	var server strings.Builder
+	if len(custom.Hosts) > 0 {
+		zones := []string{}
+		seen := map[string]bool{}
+		for _, entry := range custom.Hosts {
+			for _, name := range strings.Fields(entry)[1:] {
+				if zone := strings.ToLower(name); !seen[zone] {
+					seen[zone] = true
+					zones = append(zones, zone+":53")
+				}
+			}
+		}
+		fmt.Fprintf(&server, "%s {\n    errors\n    hosts {\n", strings.Join(zones, " "))
+		for _, entry := range custom.Hosts {
+			fmt.Fprintf(&server, "        %s\n", strings.Join(strings.Fields(entry), " "))
+		}
+		server.WriteString("        fallthrough\n    }\n    forward . /etc/resolv.conf\n}\n")
+	}
+	domains := make([]string, 0, len(custom.StubDomains))
+	for domain := range custom.StubDomains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		fmt.Fprintf(&server, "%s:53 {\n    errors\n    cache 30\n    forward . %s\n}\n", strings.ToLower(domain), strings.Join(custom.StubDomains[domain], " "))
+	}
+	if custom.Server != "" {
+		server.WriteString(strings.TrimSuffix(custom.Server, "\n") + "\n")
+	}
+
+	data := map[string]string{}
+	if override.Len() > 0 {
+		data["k3d.override"] = override.String()
+	}
+	if server.Len() > 0 {
+		data["k3d.server"] = server.String()
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("custom CoreDNS config is empty")
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]string{
+			"name":      k3d.DefaultCoreDNSCustomConfigMap,
+			"namespace": "kube-system",
+		},
+		"data": data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the %s ConfigMap: %w", k3d.DefaultCoreDNSCustomConfigMap, err)
+	}
+	return &Manifest{Name: k3d.DefaultCoreDNSCustomManifestName, Source: "custom CoreDNS config", Content: content}, nil
+}
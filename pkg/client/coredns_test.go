@@ -0,0 +1,121 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCoreDNSCustomParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "hosts", input: "hosts:\n  - 192.168.1.10 git.internal registry.internal\n"},
+		{name: "stub domains", input: "stubDomains:\n  corp.example.com: [10.0.0.53, \"10.0.0.54:5353\"]\n"},
+		{name: "rewrites", input: "rewrites:\n  - name git.example.com gitea.default.svc.cluster.local\n"},
+		{name: "raw override", input: "override: |\n  log\n"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "unknown key", input: "stubdomain:\n  corp.example.com: [10.0.0.53]\n", wantErr: true},
+		{name: "hosts entry without hostname", input: "hosts: [192.168.1.10]\n", wantErr: true},
+		{name: "hosts entry with invalid IP", input: "hosts: [\"192.168.1 git.internal\"]\n", wantErr: true},
+		{name: "hosts entry with invalid hostname", input: "hosts: [\"192.168.1.10 git_internal\"]\n", wantErr: true},
+		{name: "stub domain without nameservers", input: "stubDomains:\n  corp.example.com: []\n", wantErr: true},
+		{name: "stub domain with invalid nameserver", input: "stubDomains:\n  corp.example.com: [ns.example.com]\n", wantErr: true},
+		{name: "stub domain shadowing hostname", input: "hosts: [\"192.168.1.10 corp.example.com\"]\nstubDomains:\n  corp.example.com: [10.0.0.53]\n", wantErr: true},
+		{name: "multiline rewrite", input: "rewrites:\n  - \"name a b\\nlog\"\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CoreDNSCustomParse([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CoreDNSCustomParse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCoreDNSCustomManifest(t *testing.T) {
+	custom := &k3d.CoreDNSCustom{
+		Hosts: []string{"192.168.1.10  git.internal Registry.internal", "192.168.1.11 git.internal"},
+		StubDomains: map[string][]string{
+			"lab.example.com":  {"10.1.0.53"},
+			"corp.example.com": {"10.0.0.53", "10.0.0.54:5353"},
+		},
+		Rewrites: []string{"name git.example.com gitea.default.svc.cluster.local"},
+		Override: "log\n",
+	}
+
+	manifest, err := CoreDNSCustomManifest(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != k3d.DefaultCoreDNSCustomManifestName {
+		t.Errorf("expected manifest name %s, got %s", k3d.DefaultCoreDNSCustomManifestName, manifest.Name)
+	}
+
+	var configMap struct {
+		Kind     string            `yaml:"kind"`
+		Metadata map[string]string `yaml:"metadata"`
+		Data     map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(manifest.Content, &configMap); err != nil {
+		t.Fatalf("failed to parse the rendered manifest: %v\n%s", err, manifest.Content)
+	}
+	if configMap.Kind != "ConfigMap" || configMap.Metadata["name"] != k3d.DefaultCoreDNSCustomConfigMap || configMap.Metadata["namespace"] != "kube-system" {
+		t.Errorf("unexpected ConfigMap %s/%s of kind %s", configMap.Metadata["namespace"], configMap.Metadata["name"], configMap.Kind)
+	}
+
+	want := map[string]string{
+		"k3d.override": "rewrite name git.example.com gitea.default.svc.cluster.local\nlog\n",
+		"k3d.server": `git.internal:53 registry.internal:53 {
+    errors
+    hosts {
+        192.168.1.10 git.internal Registry.internal
+        192.168.1.11 git.internal
+        fallthrough
+    }
+    forward . /etc/resolv.conf
+}
+corp.example.com:53 {
+    errors
+    cache 30
+    forward . 10.0.0.53 10.0.0.54:5353
+}
+lab.example.com:53 {
+    errors
+    cache 30
+    forward . 10.1.0.53
+}
+`,
+	}
+	if !reflect.DeepEqual(configMap.Data, want) {
+		t.Errorf("CoreDNSCustomManifest() data = %#v, want %#v", configMap.Data, want)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ClusterDiff describes how a running cluster differs from a desired cluster configuration
+type ClusterDiff struct {
+	Cluster       string       `yaml:"cluster" json:"cluster"`
+	NodesToAdd    []NodeRef    `yaml:"nodesToAdd,omitempty" json:"nodesToAdd,omitempty"`
+	NodesToRemove []NodeRef    `yaml:"nodesToRemove,omitempty" json:"nodesToRemove,omitempty"`
+	NodeChanges   []NodeChange `yaml:"nodeChanges,omitempty" json:"nodeChanges,omitempty"`
+}
+
+// NodeRef identifies a node in a ClusterDiff
+type NodeRef struct {
+	Name string   `yaml:"name" json:"name"`
+	Role k3d.Role `yaml:"role" json:"role"`
+}
+
+// NodeChange lists the changed fields of a node that exists both in the running cluster and in the desired configuration
+type NodeChange struct {
+	NodeRef `yaml:",inline"`
+	Fields  []FieldChange `yaml:"fields" json:"fields"`
+}
+
+// FieldChange describes the change of a single node field.
+// Scalar fields (e.g. the image) use Current and Desired, list fields (e.g. ports) use Added and Removed.
+type FieldChange struct {
+	Field   string   `yaml:"field" json:"field"`
+	Current string   `yaml:"current,omitempty" json:"current,omitempty"`
+	Desired string   `yaml:"desired,omitempty" json:"desired,omitempty"`
+	Added   []string `yaml:"added,omitempty" json:"added,omitempty"`
+	Removed []string `yaml:"removed,omitempty" json:"removed,omitempty"`
+}
+
+// IsEmpty returns true if the running cluster matches the desired configuration
+func (d *ClusterDiff) IsEmpty() bool {
+	return len(d.NodesToAdd) == 0 && len(d.NodesToRemove) == 0 && len(d.NodeChanges) == 0
+}
+
+// ClusterGetDiff compares a running cluster (e.g. from ClusterGet) with a desired cluster (e.g. from a transformed config file).
+// Nodes are matched by name and only server, agent and loadbalancer nodes are considered.
+// Note: runtime-side defaults (e.g. args, environment variables and labels added by k3d or the node image) cannot be told apart
+// from user input, so for those fields only desired values that are missing from the running node are reported.
+func ClusterGetDiff(existing *k3d.Cluster, desired *k3d.Cluster) *ClusterDiff {
+	diff := &ClusterDiff{
+		Cluster: desired.Name,
+	}
+
+	existingNodes := diffableNodes(existing.Nodes)
+	desiredNodes := diffableNodes(desired.Nodes)
+
+	for name, desiredNode := range desiredNodes {
+		existingNode, ok := existingNodes[name]
+		if !ok {
+			diff.NodesToAdd = append(diff.NodesToAdd, NodeRef{Name: name, Role: desiredNode.Role})
+			continue
+		}
+		if fields := nodeGetDiff(existingNode, desiredNode); len(fields) > 0 {
+			diff.NodeChanges = append(diff.NodeChanges, NodeChange{
+				NodeRef: NodeRef{Name: name, Role: existingNode.Role},
+				Fields:  fields,
+			})
+		}
+	}
+
+	for name, existingNode := range existingNodes {
+		if _, ok := desiredNodes[name]; !ok {
+			diff.NodesToRemove = append(diff.NodesToRemove, NodeRef{Name: name, Role: existingNode.Role})
+		}
+	}
+
+	sort.Slice(diff.NodesToAdd, func(i, j int) bool { return diff.NodesToAdd[i].Name < diff.NodesToAdd[j].Name })
+	sort.Slice(diff.NodesToRemove, func(i, j int) bool { return diff.NodesToRemove[i].Name < diff.NodesToRemove[j].Name })
+	sort.Slice(diff.NodeChanges, func(i, j int) bool { return diff.NodeChanges[i].Name < diff.NodeChanges[j].Name })
+
+	return diff
+}
+
+// diffableNodes returns the server, agent and loadbalancer nodes mapped by name
+func diffableNodes(nodes []*k3d.Node) map[string]*k3d.Node {
+	result := make(map[string]*k3d.Node, len(nodes))
+	for _, node := range nodes {
+		if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole || node.Role == k3d.LoadBalancerRole {
+			result[node.Name] = node
+		}
+	}
+	return result
+}
+
+// nodeGetDiff returns the changed fields between an existing and a desired node
+func nodeGetDiff(existing *k3d.Node, desired *k3d.Node) []FieldChange {
+	fields := []FieldChange{}
+
+	if desired.Image != "" && desired.Image != existing.Image {
+		fields = append(fields, FieldChange{Field: "image", Current: existing.Image, Desired: desired.Image})
+	}
+
+	if added, removed := portsGetDiff(existing.Ports, desired.Ports); len(added) > 0 || len(removed) > 0 {
+		fields = append(fields, FieldChange{Field: "ports", Added: added, Removed: removed})
+	}
+
+	// args are part of the container command for existing nodes
+	existingArgs := append(append([]string{}, existing.Cmd...), existing.Args...)
+	if added := missingEntries(existingArgs, desired.Args); len(added) > 0 {
+		fields = append(fields, FieldChange{Field: "args", Added: added})
+	}
+
+	if added := missingEntries(existing.Env, desired.Env); len(added) > 0 {
+		fields = append(fields, FieldChange{Field: "env", Added: added})
+	}
+
+	if added := missingEntries(existing.Volumes, desired.Volumes); len(added) > 0 {
+		fields = append(fields, FieldChange{Field: "volumes", Added: added})
+	}
+
+	labels := []string{}
+	for k, v := range desired.RuntimeLabels {
+		if existingValue, ok := existing.RuntimeLabels[k]; !ok || existingValue != v {
+			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	if len(labels) > 0 {
+		sort.Strings(labels)
+		fields = append(fields, FieldChange{Field: "labels", Added: labels})
+	}
+
+	return fields
+}
+
+// missingEntries returns all desired entries that are not part of the existing ones
+func missingEntries(existing []string, desired []string) []string {
+	existingSet := make(map[string]struct{}, len(existing))
+	for _, e := range existing {
+		existingSet[e] = struct{}{}
+	}
+	missing := []string{}
+	for _, d := range desired {
+		if _, ok := existingSet[d]; !ok {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// portsGetDiff compares two port maps. A desired binding without a host port matches
+// any existing binding of the same container port and host IP, since it will be assigned randomly.
+func portsGetDiff(existing nat.PortMap, desired nat.PortMap) (added []string, removed []string) {
+	matched := map[nat.Port][]bool{}
+	for port, bindings := range existing {
+		matched[port] = make([]bool, len(bindings))
+	}
+
+	for port, desiredBindings := range desired {
+		for _, desiredBinding := range desiredBindings {
+			found := false
+			for i, existingBinding := range existing[port] {
+				if matched[port][i] || portBindingHostIP(existingBinding) != portBindingHostIP(desiredBinding) {
+					continue
+				}
+				if desiredBinding.HostPort == "" || desiredBinding.HostPort == existingBinding.HostPort {
+					matched[port][i] = true
+					found = true
+					break
+				}
+			}
+			if !found {
+				added = append(added, formatPortBinding(port, desiredBinding))
+			}
+		}
+	}
+
+	for port, bindings := range existing {
+		for i, binding := range bindings {
+			if !matched[port][i] {
+				removed = append(removed, formatPortBinding(port, binding))
+			}
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func portBindingHostIP(binding nat.PortBinding) string {
+	if binding.HostIP == "" {
+		return "0.0.0.0"
+	}
+	return binding.HostIP
+}
+
+func formatPortBinding(port nat.Port, binding nat.PortBinding) string {
+	hostPort := binding.HostPort
+	if hostPort == "" {
+		hostPort = "random"
+	}
+	return fmt.Sprintf("%s:%s->%s", portBindingHostIP(binding), hostPort, port)
+}
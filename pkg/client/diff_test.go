@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterGetDiff(t *testing.T) {
+	existing := &k3d.Cluster{
+		Name: "test",
+		Nodes: []*k3d.Node{
+			{
+				Name:  "k3d-test-serverlb",
+				Role:  k3d.LoadBalancerRole,
+				Image: "ghcr.io/k3d-io/k3d-proxy:5",
+				Ports: nat.PortMap{
+					"6443/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "45678"}},
+					"80/tcp":   []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}},
+				},
+			},
+			{
+				Name:  "k3d-test-server-0",
+				Role:  k3d.ServerRole,
+				Image: "rancher/k3s:v1.21.7-k3s1",
+				Cmd:   []string{"server", "--tls-san", "0.0.0.0"},
+				Env:   []string{"K3S_TOKEN=secret", "PATH=/bin"},
+			},
+			{
+				Name:  "k3d-test-agent-1",
+				Role:  k3d.AgentRole,
+				Image: "rancher/k3s:v1.21.7-k3s1",
+			},
+			{
+				Name: "k3d-test-tools",
+				Role: k3d.NoRole,
+			},
+		},
+	}
+
+	desired := &k3d.Cluster{
+		Name: "test",
+		Nodes: []*k3d.Node{
+			{
+				Name:  "k3d-test-serverlb",
+				Role:  k3d.LoadBalancerRole,
+				Image: "ghcr.io/k3d-io/k3d-proxy:5",
+				Ports: nat.PortMap{
+					"6443/tcp": []nat.PortBinding{{HostIP: "0.0.0.0"}},
+					"443/tcp":  []nat.PortBinding{{HostPort: "8443"}},
+				},
+			},
+			{
+				Name:  "k3d-test-server-0",
+				Role:  k3d.ServerRole,
+				Image: "rancher/k3s:v1.22.4-k3s1",
+				Args:  []string{"--tls-san", "--disable=traefik"},
+				Env:   []string{"K3S_TOKEN=secret"},
+			},
+			{
+				Name:  "k3d-test-agent-0",
+				Role:  k3d.AgentRole,
+				Image: "rancher/k3s:v1.22.4-k3s1",
+			},
+		},
+	}
+
+	expected := &ClusterDiff{
+		Cluster:       "test",
+		NodesToAdd:    []NodeRef{{Name: "k3d-test-agent-0", Role: k3d.AgentRole}},
+		NodesToRemove: []NodeRef{{Name: "k3d-test-agent-1", Role: k3d.AgentRole}},
+		NodeChanges: []NodeChange{
+			{
+				NodeRef: NodeRef{Name: "k3d-test-server-0", Role: k3d.ServerRole},
+				Fields: []FieldChange{
+					{Field: "image", Current: "rancher/k3s:v1.21.7-k3s1", Desired: "rancher/k3s:v1.22.4-k3s1"},
+					{Field: "args", Added: []string{"--disable=traefik"}},
+				},
+			},
+			{
+				NodeRef: NodeRef{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+				Fields: []FieldChange{
+					{Field: "ports", Added: []string{"0.0.0.0:8443->443/tcp"}, Removed: []string{"0.0.0.0:8080->80/tcp"}},
+				},
+			},
+		},
+	}
+
+	diff := ClusterGetDiff(existing, desired)
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected diff:\nexpected: %+v\ngot:      %+v", expected, diff)
+	}
+
+	if !ClusterGetDiff(existing, existing).IsEmpty() {
+		t.Errorf("expected empty diff when comparing a cluster with itself")
+	}
+}
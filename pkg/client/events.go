@@ -0,0 +1,115 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// ClusterEventRecord appends an event to the cluster's event log.
+// Recording events is best-effort: failures are only logged, so they never break the actual operation.
+func ClusterEventRecord(clusterName string, eventType k3d.ClusterEventType, node string, message string) {
+	if clusterName == "" {
+		return
+	}
+	if err := clusterEventAppend(clusterName, k3d.ClusterEvent{
+		Time:    time.Now().UTC(),
+		Type:    eventType,
+		Node:    node,
+		Message: message,
+	}); err != nil {
+		l.Log().Debugf("Failed to record event '%s' for cluster '%s': %v", eventType, clusterName, err)
+	}
+}
+
+func clusterEventAppend(clusterName string, event k3d.ClusterEvent) error {
+	eventsFile, err := util.GetClusterEventsFile(clusterName)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	// a single write with O_APPEND keeps concurrent writers (e.g. `k3d watch` and the CLI) from interleaving records
+	f, err := os.OpenFile(eventsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log '%s': %w", eventsFile, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write to event log '%s': %w", eventsFile, err)
+	}
+	return nil
+}
+
+// ClusterEventList returns all recorded events of the given cluster, oldest first
+func ClusterEventList(clusterName string) ([]k3d.ClusterEvent, error) {
+	eventsFile, err := util.GetClusterEventsFile(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(eventsFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []k3d.ClusterEvent{}, nil
+		}
+		return nil, fmt.Errorf("failed to open event log '%s': %w", eventsFile, err)
+	}
+	defer f.Close()
+
+	events := []k3d.ClusterEvent{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event k3d.ClusterEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			l.Log().Debugf("Skipping malformed record in event log '%s': %v", eventsFile, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log '%s': %w", eventsFile, err)
+	}
+	return events, nil
+}
+
+// ClusterEventDelete removes the event log of the given cluster
+func ClusterEventDelete(clusterName string) error {
+	eventsFile, err := util.GetClusterEventsFile(clusterName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(eventsFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete event log '%s': %w", eventsFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterEventRecordList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	events, err := ClusterEventList("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events for a cluster without event log, got %d", len(events))
+	}
+
+	ClusterEventRecord("test", k3d.ClusterEventCreated, "", "Cluster created")
+	ClusterEventRecord("test", k3d.ClusterEventNodeAdded, "k3d-test-agent-1", "Added agent node")
+	ClusterEventRecord("other", k3d.ClusterEventStopped, "", "Cluster stopped")
+
+	events, err = ClusterEventList("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != k3d.ClusterEventCreated || events[1].Type != k3d.ClusterEventNodeAdded || events[1].Node != "k3d-test-agent-1" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+	if events[0].Time.After(events[1].Time) {
+		t.Errorf("expected events to be ordered by time: %+v", events)
+	}
+
+	if err := ClusterEventDelete("test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, err = ClusterEventList("test")
+	if err != nil || len(events) != 0 {
+		t.Errorf("expected no events after deletion, got %d (err: %v)", len(events), err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ClusterProbeHealth sets the health of each of the given clusters (as returned by ClusterList or ClusterGet),
+// probing the Kubernetes APIs of the clusters concurrently, each with the given timeout
+func ClusterProbeHealth(ctx context.Context, clusters []*k3d.Cluster, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster *k3d.Cluster) {
+			defer wg.Done()
+			cluster.Health = ClusterGetHealth(ctx, cluster, timeout)
+		}(cluster)
+	}
+	wg.Wait()
+}
+
+// ClusterGetHealth returns the number of running and total nodes of the cluster, whether its Kubernetes API is reachable
+// and the k3s version detected from the image of its server nodes
+func ClusterGetHealth(ctx context.Context, cluster *k3d.Cluster, timeout time.Duration) *k3d.ClusterHealth {
+	health := &k3d.ClusterHealth{
+		NodesTotal: len(cluster.Nodes),
+		K3sVersion: ClusterGetK3sVersion(cluster),
+	}
+	for _, node := range cluster.Nodes {
+		if node.State.Running {
+			health.NodesRunning++
+		}
+	}
+
+	if err := ClusterProbeAPI(ctx, cluster, timeout); err != nil {
+		health.APIError = err.Error()
+	} else {
+		health.APIReachable = true
+	}
+	return health
+}
+
+// ClusterProbeAPI checks that the Kubernetes API of the cluster answers on the exposed port, doing a TLS handshake and an HTTP request.
+// Any HTTP response counts as success, since no credentials are sent (so the response usually is a 401).
+func ClusterProbeAPI(ctx context.Context, cluster *k3d.Cluster, timeout time.Duration) error {
+	serverCount, serversRunning := cluster.ServerCountRunning()
+	if serverCount > 0 && serversRunning == 0 {
+		return fmt.Errorf("no server running")
+	}
+	host, port, ok := ClusterGetAPIEndpoint(cluster)
+	if !ok {
+		return fmt.Errorf("Kubernetes API not exposed")
+	}
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	if timeout <= 0 {
+		timeout = k3d.DefaultClusterHealthProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("https://%s/livez", net.JoinHostPort(host, port))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // only probing reachability, no credentials are sent
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Kubernetes API at %s: %w", url, err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || !resp.TLS.HandshakeComplete {
+		return fmt.Errorf("Kubernetes API at %s did not complete the TLS handshake", url)
+	}
+	return nil
+}
+
+// ClusterGetK3sVersion returns the k3s version of the cluster, as detected from the image tag of its server nodes (empty if unknown)
+func ClusterGetK3sVersion(cluster *k3d.Cluster) string {
+	for _, node := range cluster.Nodes {
+		if node.Role != k3d.ServerRole {
+			continue
+		}
+		if version := imageTag(node.Image); version != "" {
+			return version
+		}
+	}
+	return ""
+}
+
+// imageTag returns the tag of an image reference (empty if it has none or is referenced by digest)
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
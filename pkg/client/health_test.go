@@ -0,0 +1,102 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterGetHealth(t *testing.T) {
+	api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+	_, apiPort, _ := net.SplitHostPort(api.Listener.Addr().String())
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	_, plainPort, _ := net.SplitHostPort(plain.Listener.Addr().String())
+
+	server := func(running bool, port string) *k3d.Node {
+		return &k3d.Node{
+			Role:          k3d.ServerRole,
+			Image:         "docker.io/rancher/k3s:v1.21.7-k3s1",
+			State:         k3d.NodeState{Running: running},
+			RuntimeLabels: map[string]string{k3d.LabelServerAPIPort: port, k3d.LabelServerAPIHostIP: "0.0.0.0"},
+		}
+	}
+	agent := &k3d.Node{Role: k3d.AgentRole, Image: "rancher/k3s:v1.21.7-k3s1"}
+
+	tests := []struct {
+		name          string
+		cluster       *k3d.Cluster
+		wantReachable bool
+		wantRunning   int
+		wantTotal     int
+	}{
+		{name: "reachable", cluster: &k3d.Cluster{Nodes: []*k3d.Node{server(true, apiPort), agent}}, wantReachable: true, wantRunning: 1, wantTotal: 2},
+		{name: "stopped", cluster: &k3d.Cluster{Nodes: []*k3d.Node{server(false, apiPort)}}, wantTotal: 1},
+		{name: "no TLS", cluster: &k3d.Cluster{Nodes: []*k3d.Node{server(true, plainPort)}}, wantRunning: 1, wantTotal: 1},
+		{name: "not exposed", cluster: &k3d.Cluster{Nodes: []*k3d.Node{{Role: k3d.ServerRole, Image: "rancher/k3s:v1.21.7-k3s1", State: k3d.NodeState{Running: true}}}}, wantRunning: 1, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := ClusterGetHealth(context.Background(), tt.cluster, 2*time.Second)
+			if health.APIReachable != tt.wantReachable {
+				t.Errorf("APIReachable = %t (error: %s), want %t", health.APIReachable, health.APIError, tt.wantReachable)
+			}
+			if !health.APIReachable && health.APIError == "" {
+				t.Errorf("expected an error for the unreachable API")
+			}
+			if health.NodesRunning != tt.wantRunning || health.NodesTotal != tt.wantTotal {
+				t.Errorf("nodes = %d/%d, want %d/%d", health.NodesRunning, health.NodesTotal, tt.wantRunning, tt.wantTotal)
+			}
+			if health.K3sVersion != "v1.21.7-k3s1" {
+				t.Errorf("K3sVersion = %s, want v1.21.7-k3s1", health.K3sVersion)
+			}
+		})
+	}
+}
+
+func TestImageTag(t *testing.T) {
+	tests := map[string]string{
+		"rancher/k3s:v1.21.7-k3s1":             "v1.21.7-k3s1",
+		"localhost:5000/k3s:latest":            "latest",
+		"localhost:5000/k3s":                   "",
+		"rancher/k3s":                          "",
+		"rancher/k3s@sha256:0123456789abcdef0": "",
+	}
+	for image, want := range tests {
+		if got := imageTag(image); got != want {
+			t.Errorf("imageTag(%s) = %s, want %s", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// HistoryRecord appends an entry to the k3d history.
+// Recording is best-effort: failures are only logged, so they never break the actual operation.
+func HistoryRecord(cluster string, command string, spec string) {
+	if err := historyAppend(k3d.HistoryEntry{
+		Time:    time.Now().UTC(),
+		Cluster: cluster,
+		Command: command,
+		Spec:    spec,
+	}); err != nil {
+		l.Log().Debugf("Failed to record history entry for cluster '%s': %v", cluster, err)
+	}
+}
+
+func historyAppend(entry k3d.HistoryEntry) error {
+	historyFile, err := util.GetHistoryFile()
+	if err != nil {
+		return err
+	}
+	// IDs are positions in the file, so they're never persisted
+	entry.ID = 0
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	f, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file '%s': %w", historyFile, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write to history file '%s': %w", historyFile, err)
+	}
+	return nil
+}
+
+// HistoryList returns all entries of the k3d history, oldest first, numbered starting at 1
+func HistoryList() ([]k3d.HistoryEntry, error) {
+	historyFile, err := util.GetHistoryFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(historyFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []k3d.HistoryEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open history file '%s': %w", historyFile, err)
+	}
+	defer f.Close()
+
+	entries := []k3d.HistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	// specs of larger clusters easily exceed the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var entry k3d.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			l.Log().Debugf("Skipping malformed record in history file '%s': %v", historyFile, err)
+			continue
+		}
+		// number by line, so that IDs stay stable even if a record is skipped
+		entry.ID = lineNo
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file '%s': %w", historyFile, err)
+	}
+	return entries, nil
+}
+
+// HistoryGet returns the history entry with the given ID
+func HistoryGet(id int) (*k3d.HistoryEntry, error) {
+	entries, err := HistoryList()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no history entry with ID %d", id)
+}
+
+// HistoryClear removes all entries from the k3d history
+func HistoryClear() error {
+	historyFile, err := util.GetHistoryFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(historyFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete history file '%s': %w", historyFile, err)
+	}
+	return nil
+}
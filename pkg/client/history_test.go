@@ -0,0 +1,75 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+func TestHistoryRecordList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	entries, err := HistoryList()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty history, got %d entries", len(entries))
+	}
+
+	HistoryRecord("one", "k3d cluster create one", "name: one\n")
+	HistoryRecord("two", "k3d cluster create two --agents 2", "name: two\nagents: 2\n")
+
+	entries, err = HistoryList()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].ID != 1 || entries[0].Cluster != "one" || entries[1].ID != 2 || entries[1].Spec != "name: two\nagents: 2\n" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	entry, err := HistoryGet(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Cluster != "two" || entry.Command != "k3d cluster create two --agents 2" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if _, err := HistoryGet(3); err == nil {
+		t.Errorf("expected error for unknown history ID")
+	}
+
+	if err := HistoryClear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err = HistoryList()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected empty history after clearing, got %d entries (err: %v)", len(entries), err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/tracing"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// clusterHookNodes returns the nodes of the cluster selected by the hook's node filters (default: all server and agent nodes)
+func clusterHookNodes(cluster *k3d.Cluster, hook k3d.ClusterHook) ([]*k3d.Node, error) {
+	if len(hook.NodeFilters) == 0 {
+		return NodeFilterByRoles(cluster.Nodes, []k3d.Role{k3d.ServerRole, k3d.AgentRole}, nil), nil
+	}
+	nodes, err := util.FilterNodes(cluster.Nodes, hook.NodeFilters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter nodes for hook at stage %s: %w", hook.Stage, err)
+	}
+	return nodes, nil
+}
+
+// ClusterRunHooks runs the actions of all cluster hooks bound to the given stage on the nodes they select
+func ClusterRunHooks(ctx context.Context, cluster *k3d.Cluster, hooks []k3d.ClusterHook, stage k3d.LifecycleStage) (err error) {
+	ctx, span := tracing.Start(ctx, "ClusterRunHooks", tracing.String("k3d.cluster.name", cluster.Name), tracing.String("k3d.hook.stage", string(stage)))
+	defer func() { span.End(err) }()
+
+	for _, hook := range hooks {
+		if hook.Stage != stage {
+			continue
+		}
+		nodes, err := clusterHookNodes(cluster, hook)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			l.Log().Debugf("Running %s hook on node %s: %s", stage, node.Name, hook.Action.Info())
+			if err := hook.Action.Run(ctx, node); err != nil {
+				return fmt.Errorf("%s hook %s failed on node %s: %w", stage, hook.Action.Name(), node.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// clusterHooksAsNodeHooks converts the postNodeStart cluster hooks to postStart node hooks, which only act on the selected nodes
+func clusterHooksAsNodeHooks(cluster *k3d.Cluster, hooks []k3d.ClusterHook) ([]k3d.NodeHook, error) {
+	nodeHooks := []k3d.NodeHook{}
+	for _, hook := range hooks {
+		if hook.Stage != k3d.LifecycleStagePostNodeStart {
+			continue
+		}
+		nodes, err := clusterHookNodes(cluster, hook)
+		if err != nil {
+			return nil, err
+		}
+		action := clusterHookNodeAction{nodes: map[string]bool{}, action: hook.Action}
+		for _, node := range nodes {
+			action.nodes[node.Name] = true
+		}
+		nodeHooks = append(nodeHooks, k3d.NodeHook{
+			Stage:  k3d.LifecycleStagePostStart,
+			Action: action,
+		})
+	}
+	return nodeHooks, nil
+}
+
+// clusterHookNodeAction wraps the action of a cluster hook, so that it's skipped on nodes not selected by the hook
+type clusterHookNodeAction struct {
+	nodes  map[string]bool
+	action k3d.NodeHookAction
+}
+
+func (act clusterHookNodeAction) Name() string {
+	return act.action.Name()
+}
+
+func (act clusterHookNodeAction) Info() string {
+	return act.action.Info()
+}
+
+func (act clusterHookNodeAction) Run(ctx context.Context, node *k3d.Node) error {
+	if !act.nodes[node.Name] {
+		return nil
+	}
+	return act.action.Run(ctx, node)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// recordAction records the names of the nodes it was run on
+type recordAction struct {
+	nodes *[]string
+}
+
+func (act recordAction) Name() string { return "recordAction" }
+func (act recordAction) Info() string { return "" }
+func (act recordAction) Run(ctx context.Context, node *k3d.Node) error {
+	*act.nodes = append(*act.nodes, node.Name)
+	return nil
+}
+
+func TestClusterHooks(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Name: "test",
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole},
+			{Name: "k3d-test-agent-0", Role: k3d.AgentRole},
+			{Name: "k3d-test-agent-1", Role: k3d.AgentRole},
+			{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		stage       k3d.LifecycleStage
+		nodeFilters []string
+		want        []string
+	}{
+		{
+			name:  "all k3s nodes by default",
+			stage: k3d.LifecycleStagePostClusterReady,
+			want:  []string{"k3d-test-server-0", "k3d-test-agent-0", "k3d-test-agent-1"},
+		},
+		{
+			name:        "node filters",
+			stage:       k3d.LifecycleStagePostClusterReady,
+			nodeFilters: []string{"agent:1"},
+			want:        []string{"k3d-test-agent-1"},
+		},
+		{
+			name:  "other stage",
+			stage: k3d.LifecycleStagePreCreate,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			hooks := []k3d.ClusterHook{{Stage: tt.stage, NodeFilters: tt.nodeFilters, Action: recordAction{nodes: &got}}}
+			if err := ClusterRunHooks(context.Background(), cluster, hooks, k3d.LifecycleStagePostClusterReady); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClusterRunHooks() ran on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterHooksAsNodeHooks(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Name: "test",
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole},
+			{Name: "k3d-test-agent-0", Role: k3d.AgentRole},
+		},
+	}
+
+	var got []string
+	nodeHooks, err := clusterHooksAsNodeHooks(cluster, []k3d.ClusterHook{
+		{Stage: k3d.LifecycleStagePostNodeStart, NodeFilters: []string{"agent:*"}, Action: recordAction{nodes: &got}},
+		{Stage: k3d.LifecycleStagePostClusterReady, Action: recordAction{nodes: &got}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodeHooks) != 1 || nodeHooks[0].Stage != k3d.LifecycleStagePostStart {
+		t.Fatalf("expected a single postStart node hook, got %+v", nodeHooks)
+	}
+	for _, node := range cluster.Nodes {
+		if err := nodeHooks[0].Action.Run(context.Background(), node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"k3d-test-agent-0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("node hook ran on %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// Firewalls for which ClusterHostPortsFirewallRules can generate rules
+const (
+	FirewallUFW       = "ufw"
+	FirewallFirewalld = "firewalld"
+)
+
+// Firewalls lists the supported firewalls
+var Firewalls = []string{FirewallUFW, FirewallFirewalld}
+
+// ClusterGetHostPorts returns all ports that k3d bound on the host for the cluster:
+// the Kubernetes API, the loadbalancer's and the nodes' port mappings and the ports of the registries connected to the cluster
+func ClusterGetHostPorts(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster) ([]k3d.HostPort, error) {
+	ports := clusterNodesHostPorts(cluster)
+
+	registries, err := ClusterGetRegistries(ctx, runtime, cluster)
+	if err != nil {
+		l.Log().Warnf("Failed to get registries of cluster '%s': %v", cluster.Name, err)
+	}
+	for _, reg := range registries {
+		ports = append(ports, k3d.HostPort{
+			Node:          reg.Host,
+			Role:          k3d.RegistryRole,
+			Purpose:       k3d.HostPortPurposeRegistry,
+			HostIP:        hostPortBindIP(reg.ExposureOpts.Binding.HostIP),
+			HostPort:      reg.ExposureOpts.Binding.HostPort,
+			ContainerPort: reg.ExposureOpts.Port.Port(),
+			Protocol:      reg.ExposureOpts.Port.Proto(),
+		})
+	}
+
+	sortHostPorts(ports)
+	return ports, nil
+}
+
+// clusterNodesHostPorts returns the host port mappings of the cluster's nodes
+func clusterNodesHostPorts(cluster *k3d.Cluster) []k3d.HostPort {
+	ports := []k3d.HostPort{}
+	for _, node := range cluster.Nodes {
+		for port, bindings := range node.Ports {
+			purpose := k3d.HostPortPurposeNode
+			if node.Role == k3d.LoadBalancerRole {
+				purpose = k3d.HostPortPurposeLoadbalancer
+			}
+			if port.Port() == k3d.DefaultAPIPort && (node.Role == k3d.LoadBalancerRole || node.Role == k3d.ServerRole) {
+				purpose = k3d.HostPortPurposeAPI
+			}
+			for _, binding := range bindings {
+				ports = append(ports, k3d.HostPort{
+					Node:          node.Name,
+					Role:          node.Role,
+					Purpose:       purpose,
+					HostIP:        hostPortBindIP(binding.HostIP),
+					HostPort:      binding.HostPort,
+					ContainerPort: port.Port(),
+					Protocol:      port.Proto(),
+				})
+			}
+		}
+	}
+	sortHostPorts(ports)
+	return ports
+}
+
+// hostPortBindIP returns the address a port is bound to, where an empty one means all interfaces
+func hostPortBindIP(hostIP string) string {
+	if hostIP == "" {
+		return "0.0.0.0"
+	}
+	return hostIP
+}
+
+// sortHostPorts sorts host ports by purpose (API first), node and port
+func sortHostPorts(ports []k3d.HostPort) {
+	rank := map[string]int{
+		k3d.HostPortPurposeAPI:          0,
+		k3d.HostPortPurposeLoadbalancer: 1,
+		k3d.HostPortPurposeNode:         2,
+		k3d.HostPortPurposeRegistry:     3,
+	}
+	sort.SliceStable(ports, func(i, j int) bool {
+		if rank[ports[i].Purpose] != rank[ports[j].Purpose] {
+			return rank[ports[i].Purpose] < rank[ports[j].Purpose]
+		}
+		if ports[i].Node != ports[j].Node {
+			return ports[i].Node < ports[j].Node
+		}
+		if ports[i].HostPort != ports[j].HostPort {
+			return ports[i].HostPort < ports[j].HostPort
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+}
+
+// ClusterHostPortsFirewallRules suggests commands for the given firewall to allow access to the host ports.
+// Ports bound to a loopback address don't need a rule and ports without a fixed host port can't get one, so they're only listed as comments.
+func ClusterHostPortsFirewallRules(ports []k3d.HostPort, firewall string) ([]string, error) {
+	if firewall != FirewallUFW && firewall != FirewallFirewalld {
+		return nil, fmt.Errorf("unsupported firewall '%s': must be one of %s", firewall, strings.Join(Firewalls, "|"))
+	}
+
+	rules := []string{}
+	needsReload := false
+	seen := map[string]struct{}{}
+	add := func(rule string) {
+		if _, ok := seen[rule]; ok {
+			return
+		}
+		seen[rule] = struct{}{}
+		rules = append(rules, rule)
+	}
+
+	for _, p := range ports {
+		ip := net.ParseIP(p.HostIP)
+		switch {
+		case p.HostPort == "" || p.HostPort == "0":
+			add(fmt.Sprintf("# %s %s/%s (%s): random host port assigned by the runtime, no rule generated", p.Node, p.ContainerPort, p.Protocol, p.Purpose))
+			continue
+		case ip != nil && ip.IsLoopback():
+			add(fmt.Sprintf("# %s %s:%s/%s (%s): only bound to loopback, no rule needed", p.Node, p.HostIP, p.HostPort, p.Protocol, p.Purpose))
+			continue
+		}
+
+		allInterfaces := ip == nil || ip.IsUnspecified()
+		needsReload = true
+		switch firewall {
+		case FirewallUFW:
+			if allInterfaces {
+				add(fmt.Sprintf("ufw allow %s/%s", p.HostPort, p.Protocol))
+			} else {
+				add(fmt.Sprintf("ufw allow proto %s to %s port %s", p.Protocol, p.HostIP, p.HostPort))
+			}
+		case FirewallFirewalld:
+			if allInterfaces {
+				add(fmt.Sprintf("firewall-cmd --permanent --add-port=%s/%s", p.HostPort, p.Protocol))
+			} else {
+				family := "ipv4"
+				if ip.To4() == nil {
+					family = "ipv6"
+				}
+				add(fmt.Sprintf(`firewall-cmd --permanent --add-rich-rule='rule family="%s" destination address="%s" port port="%s" protocol="%s" accept'`, family, p.HostIP, p.HostPort, p.Protocol))
+			}
+		}
+	}
+
+	if firewall == FirewallFirewalld && needsReload {
+		rules = append(rules, "firewall-cmd --reload")
+	}
+	return rules, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterNodesHostPorts(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Ports: nat.PortMap{
+				"6443/tcp": {{HostIP: "0.0.0.0", HostPort: "6550"}},
+				"80/tcp":   {{HostIP: "127.0.0.1", HostPort: "8080"}},
+			}},
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole},
+			{Name: "k3d-test-agent-0", Role: k3d.AgentRole, Ports: nat.PortMap{
+				"30080/udp": {{HostPort: ""}},
+			}},
+		},
+	}
+
+	expected := []k3d.HostPort{
+		{Node: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Purpose: k3d.HostPortPurposeAPI, HostIP: "0.0.0.0", HostPort: "6550", ContainerPort: "6443", Protocol: "tcp"},
+		{Node: "k3d-test-serverlb", Role: k3d.LoadBalancerRole, Purpose: k3d.HostPortPurposeLoadbalancer, HostIP: "127.0.0.1", HostPort: "8080", ContainerPort: "80", Protocol: "tcp"},
+		{Node: "k3d-test-agent-0", Role: k3d.AgentRole, Purpose: k3d.HostPortPurposeNode, HostIP: "0.0.0.0", HostPort: "", ContainerPort: "30080", Protocol: "udp"},
+	}
+
+	if ports := clusterNodesHostPorts(cluster); !reflect.DeepEqual(ports, expected) {
+		t.Errorf("expected\n%+v\ngot\n%+v", expected, ports)
+	}
+}
+
+func TestClusterHostPortsFirewallRules(t *testing.T) {
+	ports := []k3d.HostPort{
+		{Node: "k3d-test-serverlb", Purpose: k3d.HostPortPurposeAPI, HostIP: "0.0.0.0", HostPort: "6550", Protocol: "tcp"},
+		{Node: "k3d-test-serverlb", Purpose: k3d.HostPortPurposeLoadbalancer, HostIP: "192.168.1.10", HostPort: "8080", Protocol: "tcp"},
+		{Node: "k3d-test-serverlb", Purpose: k3d.HostPortPurposeLoadbalancer, HostIP: "192.168.1.10", HostPort: "8080", Protocol: "tcp"},
+		{Node: "k3d-registry", Purpose: k3d.HostPortPurposeRegistry, HostIP: "127.0.0.1", HostPort: "5000", Protocol: "tcp"},
+		{Node: "k3d-test-agent-0", Purpose: k3d.HostPortPurposeNode, HostIP: "0.0.0.0", HostPort: "", ContainerPort: "30080", Protocol: "udp"},
+	}
+
+	tests := map[string]struct {
+		firewall    string
+		expected    []string
+		expectError bool
+	}{
+		"ufw": {
+			firewall: FirewallUFW,
+			expected: []string{
+				"ufw allow 6550/tcp",
+				"ufw allow proto tcp to 192.168.1.10 port 8080",
+				"# k3d-registry 127.0.0.1:5000/tcp (registry): only bound to loopback, no rule needed",
+				"# k3d-test-agent-0 30080/udp (node): random host port assigned by the runtime, no rule generated",
+			},
+		},
+		"firewalld": {
+			firewall: FirewallFirewalld,
+			expected: []string{
+				"firewall-cmd --permanent --add-port=6550/tcp",
+				`firewall-cmd --permanent --add-rich-rule='rule family="ipv4" destination address="192.168.1.10" port port="8080" protocol="tcp" accept'`,
+				"# k3d-registry 127.0.0.1:5000/tcp (registry): only bound to loopback, no rule needed",
+				"# k3d-test-agent-0 30080/udp (node): random host port assigned by the runtime, no rule generated",
+				"firewall-cmd --reload",
+			},
+		},
+		"unsupported firewall": {
+			firewall:    "iptables",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules, err := ClusterHostPortsFirewallRules(ports, tc.firewall)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got rules %v", rules)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rules, tc.expected) {
+				t.Errorf("expected\n%v\ngot\n%v", tc.expected, rules)
+			}
+		})
+	}
+}
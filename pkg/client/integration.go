@@ -0,0 +1,243 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+	"github.com/rancher/k3d/v5/pkg/util"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+/*
+ * Helpers for external tools (e.g. Tilt, Skaffold) that need to detect
+ * whether they're talking to a k3d cluster and how to push images into it.
+ */
+
+// ClusterFromKubeContext returns the cluster, whose kubeconfig context (named after the cluster's name prefix) has the given name
+func ClusterFromKubeContext(contextName string, clusters []*k3d.Cluster) (*k3d.Cluster, bool) {
+	for _, cluster := range clusters {
+		if contextName == fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name) {
+			return cluster, true
+		}
+	}
+	return nil, false
+}
+
+// ClusterGetRegistries returns all k3d-managed registries connected to the cluster's network
+func ClusterGetRegistries(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster) ([]*k3d.Registry, error) {
+	if cluster.Network.Name == "" {
+		return nil, fmt.Errorf("cluster '%s' has no network set", cluster.Name)
+	}
+	nodes, err := runtime.GetNodesInNetwork(ctx, cluster.Network.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes in network '%s': %w", cluster.Network.Name, err)
+	}
+
+	registries := []*k3d.Registry{}
+	for _, regNode := range util.FilterNodesByRole(nodes, k3d.RegistryRole) {
+		reg, err := RegistryFromNode(regNode)
+		if err != nil {
+			l.Log().Warnf("Failed to get registry details from node '%s': %v", regNode.Name, err)
+			continue
+		}
+		if h, ok := regNode.RuntimeLabels[k3d.LabelRegistryHost]; ok && h != "" {
+			reg.ExposureOpts.Host = h
+		}
+		registries = append(registries, reg)
+	}
+	return registries, nil
+}
+
+// KubeconfigFindClusterPath returns the path of a kubeconfig file that contains the context of the given cluster.
+// It checks the default kubeconfig first and falls back to the standalone file in the k3d config directory.
+// An empty string is returned, if no kubeconfig was found.
+func KubeconfigFindClusterPath(cluster *k3d.Cluster) (string, error) {
+	contextName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
+
+	defaultPath, err := KubeconfigGetDefaultPath()
+	if err == nil {
+		if kubeconfig, err := clientcmd.LoadFromFile(defaultPath); err == nil {
+			if _, ok := kubeconfig.Contexts[contextName]; ok {
+				return defaultPath, nil
+			}
+		}
+	} else {
+		l.Log().Debugf("Not checking default kubeconfig: %v", err)
+	}
+
+	standalonePath, err := KubeconfigGetClusterFilePath(cluster)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(standalonePath); err == nil {
+		return standalonePath, nil
+	}
+
+	return "", nil
+}
+
+// ClusterGetAPIEndpoint returns the host and port on which the Kubernetes API of the cluster is exposed, as recorded on its server nodes.
+// If the API was exposed on all interfaces, the host is 0.0.0.0 (i.e. reachable via localhost).
+// It returns false, if no server node exposes the API.
+func ClusterGetAPIEndpoint(cluster *k3d.Cluster) (string, string, bool) {
+	for _, node := range cluster.Nodes {
+		if node.Role != k3d.ServerRole {
+			continue
+		}
+		port, ok := node.RuntimeLabels[k3d.LabelServerAPIPort]
+		if !ok || port == "" {
+			continue
+		}
+		host := node.RuntimeLabels[k3d.LabelServerAPIHost]
+		if host == "" {
+			host = node.RuntimeLabels[k3d.LabelServerAPIHostIP]
+		}
+		if host == "" {
+			host = k3d.DefaultAPIHost
+		}
+		return host, port, true
+	}
+	return "", "", false
+}
+
+// ClusterGetDomain returns the cluster domain used for in-cluster DNS names (e.g. SERVICE.NAMESPACE.svc.DOMAIN)
+func ClusterGetDomain(cluster *k3d.Cluster) string {
+	if cluster.Domain != "" {
+		return cluster.Domain
+	}
+	return k3s.DefaultClusterDomain
+}
+
+// ClusterGetEnv returns the environment variables describing how to reach the cluster (see `k3d env`),
+// with KUBECONFIG pointing to the given kubeconfig file. Variables without a value (e.g. no registry) are left out.
+func ClusterGetEnv(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, kubeconfigPath string) ([]k3d.EnvVar, error) {
+	contextName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
+
+	kubeconfig, err := KubeconfigGet(ctx, runtime, cluster)
+	if err != nil {
+		return nil, err
+	}
+	apiEndpoint := ""
+	if kubeconfigCluster, ok := kubeconfig.Clusters[contextName]; ok {
+		apiEndpoint = kubeconfigCluster.Server
+	}
+
+	registry := ""
+	registries, err := ClusterGetRegistries(ctx, runtime, cluster)
+	if err != nil {
+		l.Log().Warnf("Failed to find registries for cluster '%s': %v", cluster.Name, err)
+	} else if len(registries) > 0 {
+		registry = RegistryExternalAddress(registries[0])
+	}
+
+	env := []k3d.EnvVar{
+		{Key: k3d.EnvClusterKubeconfig, Value: kubeconfigPath},
+		{Key: k3d.EnvClusterName, Value: cluster.Name},
+		{Key: k3d.EnvClusterContext, Value: contextName},
+		{Key: k3d.EnvClusterAPIEndpoint, Value: apiEndpoint},
+		{Key: k3d.EnvClusterLoadbalancerPorts, Value: clusterLoadbalancerPorts(cluster)},
+		{Key: k3d.EnvClusterRegistry, Value: registry},
+		{Key: k3d.EnvClusterDomain, Value: ClusterGetDomain(cluster)},
+	}
+	result := make([]k3d.EnvVar, 0, len(env))
+	for _, v := range env {
+		if v.Value != "" {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// clusterLoadbalancerPorts returns the host port mappings of the cluster's loadbalancer as a comma-separated list of [HOSTIP:]HOSTPORT:CONTAINERPORT/PROTOCOL
+func clusterLoadbalancerPorts(cluster *k3d.Cluster) string {
+	lb := cluster.ServerLoadBalancer
+	if lb == nil || lb.Node == nil {
+		for _, node := range cluster.Nodes {
+			if node.Role == k3d.LoadBalancerRole {
+				lb = &k3d.Loadbalancer{Node: node}
+				break
+			}
+		}
+	}
+	if lb == nil || lb.Node == nil {
+		return ""
+	}
+
+	var mappings []string
+	for port, bindings := range lb.Node.Ports {
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				continue
+			}
+			mapping := fmt.Sprintf("%s:%s/%s", binding.HostPort, port.Port(), port.Proto())
+			if binding.HostIP != "" && binding.HostIP != "0.0.0.0" {
+				mapping = fmt.Sprintf("%s:%s", binding.HostIP, mapping)
+			}
+			mappings = append(mappings, mapping)
+		}
+	}
+	sort.Strings(mappings)
+	return strings.Join(mappings, ",")
+}
+
+// EnvFileWrite writes the given variables to a dotenv file (KEY=value per line), which can be sourced by shells and included in Makefiles.
+// Values are only quoted, if they contain characters that need it.
+func EnvFileWrite(path string, header string, env []k3d.EnvVar) error {
+	var b strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		if line != "" {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+	}
+	for _, v := range env {
+		fmt.Fprintf(&b, "%s=%s\n", v.Key, envFileQuote(v.Value))
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory for env file '%s': %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write env file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// envFileQuote double-quotes a dotenv value, if it contains whitespace or characters with a special meaning
+func envFileQuote(value string) string {
+	if !strings.ContainsAny(value, " \t\n\"'`$\\#;&|<>()") {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterFromKubeContext(t *testing.T) {
+	clusters := []*k3d.Cluster{
+		{Name: "mycluster"},
+		{Name: "my-cluster"},
+		{Name: "prefixed", NamePrefix: "dev"},
+	}
+	tests := map[string]struct {
+		context       string
+		expectedName  string
+		expectedFound bool
+	}{
+		"k3d context":           {context: "k3d-mycluster", expectedName: "mycluster", expectedFound: true},
+		"k3d context w/ dash":   {context: "k3d-my-cluster", expectedName: "my-cluster", expectedFound: true},
+		"custom name prefix":    {context: "dev-prefixed", expectedName: "prefixed", expectedFound: true},
+		"default name prefix":   {context: "k3d-prefixed", expectedName: "", expectedFound: false},
+		"unknown cluster":       {context: "k3d-other", expectedName: "", expectedFound: false},
+		"prefix only":           {context: "k3d-", expectedName: "", expectedFound: false},
+		"non-k3d context":       {context: "kind-kind", expectedName: "", expectedFound: false},
+		"empty current-context": {context: "", expectedName: "", expectedFound: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cluster, found := ClusterFromKubeContext(tc.context, clusters)
+			clusterName := ""
+			if cluster != nil {
+				clusterName = cluster.Name
+			}
+			if found != tc.expectedFound || clusterName != tc.expectedName {
+				t.Errorf("expected (%s, %t), got (%s, %t)", tc.expectedName, tc.expectedFound, clusterName, found)
+			}
+		})
+	}
+}
+
+func TestClusterGetAPIEndpoint(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Role: k3d.LoadBalancerRole},
+			{Role: k3d.ServerRole, RuntimeLabels: map[string]string{k3d.LabelServerAPIHostIP: "0.0.0.0", k3d.LabelServerAPIPort: "43517"}},
+		},
+	}
+	host, port, ok := ClusterGetAPIEndpoint(cluster)
+	if !ok || host != "0.0.0.0" || port != "43517" {
+		t.Errorf("expected (0.0.0.0, 43517, true), got (%s, %s, %t)", host, port, ok)
+	}
+
+	cluster.Nodes[1].RuntimeLabels[k3d.LabelServerAPIHost] = "k3d.example.com"
+	if host, _, _ := ClusterGetAPIEndpoint(cluster); host != "k3d.example.com" {
+		t.Errorf("expected host k3d.example.com, got %s", host)
+	}
+
+	if _, _, ok := ClusterGetAPIEndpoint(&k3d.Cluster{Nodes: []*k3d.Node{{Role: k3d.AgentRole}}}); ok {
+		t.Errorf("expected no endpoint for cluster without server nodes")
+	}
+}
+
+func TestClusterLoadbalancerPorts(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Role: k3d.ServerRole},
+			{Role: k3d.LoadBalancerRole, Ports: nat.PortMap{
+				"6443/tcp": {{HostIP: "0.0.0.0", HostPort: "43517"}},
+				"80/tcp":   {{HostIP: "127.0.0.1", HostPort: "8080"}},
+				"53/udp":   {{HostPort: ""}},
+			}},
+		},
+	}
+	expected := "127.0.0.1:8080:80/tcp,43517:6443/tcp"
+	if ports := clusterLoadbalancerPorts(cluster); ports != expected {
+		t.Errorf("expected '%s', got '%s'", expected, ports)
+	}
+
+	if ports := clusterLoadbalancerPorts(&k3d.Cluster{Nodes: []*k3d.Node{{Role: k3d.ServerRole}}}); ports != "" {
+		t.Errorf("expected no ports for cluster without loadbalancer, got '%s'", ports)
+	}
+}
+
+func TestEnvFileWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "k3d.env")
+	env := []k3d.EnvVar{
+		{Key: k3d.EnvClusterKubeconfig, Value: "/home/me/.config/k3d/kubeconfig-test.yaml"},
+		{Key: k3d.EnvClusterName, Value: "test"},
+		{Key: "WITH_SPACE", Value: `my "quoted" $value`},
+	}
+	if err := EnvFileWrite(path, "Environment of k3d cluster 'test'", env); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Environment of k3d cluster 'test'
+KUBECONFIG=/home/me/.config/k3d/kubeconfig-test.yaml
+K3D_CLUSTER=test
+WITH_SPACE="my \"quoted\" \$value"
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, string(content))
+	}
+}
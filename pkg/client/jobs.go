@@ -0,0 +1,155 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	goruntime "runtime"
+	"syscall"
+
+	"github.com/rancher/k3d/v5/pkg/progress"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// ClusterJobFiles returns the paths of the job record, log file and progress file of a background creation of the given cluster
+func ClusterJobFiles(clusterName string) (jobFile string, logFile string, progressFile string, err error) {
+	jobsDir, err := util.GetJobsDir()
+	if err != nil {
+		return "", "", "", err
+	}
+	return path.Join(jobsDir, fmt.Sprintf("%s.json", clusterName)),
+		path.Join(jobsDir, fmt.Sprintf("%s.log", clusterName)),
+		path.Join(jobsDir, fmt.Sprintf("%s.progress.jsonl", clusterName)),
+		nil
+}
+
+// ClusterJobSave records a background cluster creation
+func ClusterJobSave(job *k3d.ClusterJob) error {
+	jobFile, _, _, err := ClusterJobFiles(job.Cluster)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := os.WriteFile(jobFile, content, 0644); err != nil {
+		return fmt.Errorf("failed to write job file '%s': %w", jobFile, err)
+	}
+	return nil
+}
+
+// ClusterJobGet returns the recorded background creation of the given cluster or nil, if there is none
+func ClusterJobGet(clusterName string) (*k3d.ClusterJob, error) {
+	jobFile, _, _, err := ClusterJobFiles(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(jobFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read job file '%s': %w", jobFile, err)
+	}
+	job := &k3d.ClusterJob{}
+	if err := json.Unmarshal(content, job); err != nil {
+		return nil, fmt.Errorf("failed to parse job file '%s': %w", jobFile, err)
+	}
+	return job, nil
+}
+
+// ClusterJobDelete removes the job record, log file and progress file of a background creation of the given cluster
+func ClusterJobDelete(clusterName string) error {
+	jobFile, logFile, progressFile, err := ClusterJobFiles(clusterName)
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{jobFile, logFile, progressFile} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete job file '%s': %w", file, err)
+		}
+	}
+	return nil
+}
+
+// ClusterJobGetStatus derives the status of a background cluster creation from its last progress event.
+// A job whose process is gone without reporting a final event is considered failed.
+func ClusterJobGetStatus(job *k3d.ClusterJob) (*k3d.ClusterJobStatus, error) {
+	status := &k3d.ClusterJobStatus{State: k3d.ClusterJobStateRunning}
+
+	f, err := os.Open(job.ProgressFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open progress file '%s': %w", job.ProgressFile, err)
+	}
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var event progress.Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Operation != progress.OperationClusterCreate {
+				continue
+			}
+			status.Phase = event.Phase
+			status.Percent = event.Percent
+			status.Message = event.Message
+			status.Error = event.Error
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read progress file '%s': %w", job.ProgressFile, err)
+		}
+	}
+
+	switch status.Phase {
+	case progress.PhaseDone:
+		status.State = k3d.ClusterJobStateSucceeded
+	case progress.PhaseFailed:
+		status.State = k3d.ClusterJobStateFailed
+	default:
+		if !processRunning(job.PID) {
+			status.State = k3d.ClusterJobStateFailed
+			status.Error = fmt.Sprintf("process %d exited unexpectedly (see '%s')", job.PID, job.LogFile)
+		}
+	}
+
+	return status, nil
+}
+
+// processRunning checks if a process with the given PID exists
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// on Windows, FindProcess already fails for processes that don't exist and signals other than kill aren't supported
+	if goruntime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterJobGetStatus(t *testing.T) {
+	tests := map[string]struct {
+		progress string
+		pid      int
+		expected k3d.ClusterJobStatus
+	}{
+		"not started yet": {
+			pid:      os.Getpid(),
+			expected: k3d.ClusterJobStatus{State: k3d.ClusterJobStateRunning},
+		},
+		"running": {
+			progress: `{"operation":"cluster-create","phase":"prepare","percent":0,"message":"Preparing cluster 'test'"}
+{"operation":"image-import","phase":"import","percent":80,"message":"Importing images"}
+{"operation":"cluster-create","phase":"start","percent":50,"message":"Starting nodes"}
+`,
+			pid:      os.Getpid(),
+			expected: k3d.ClusterJobStatus{State: k3d.ClusterJobStateRunning, Phase: "start", Percent: 50, Message: "Starting nodes"},
+		},
+		"succeeded": {
+			progress: `{"operation":"cluster-create","phase":"start","percent":50,"message":"Starting nodes"}
+{"operation":"cluster-create","phase":"done","percent":100,"message":"Cluster 'test' created successfully"}
+`,
+			pid:      1 << 30,
+			expected: k3d.ClusterJobStatus{State: k3d.ClusterJobStateSucceeded, Phase: "done", Percent: 100, Message: "Cluster 'test' created successfully"},
+		},
+		"failed": {
+			progress: `{"operation":"cluster-create","phase":"failed","percent":20,"message":"failed","error":"boom"}
+`,
+			pid:      1 << 30,
+			expected: k3d.ClusterJobStatus{State: k3d.ClusterJobStateFailed, Phase: "failed", Percent: 20, Message: "failed", Error: "boom"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			job := &k3d.ClusterJob{Cluster: "test", PID: tc.pid, ProgressFile: filepath.Join(dir, "test.progress.jsonl"), LogFile: filepath.Join(dir, "test.log")}
+			if tc.progress != "" {
+				if err := os.WriteFile(job.ProgressFile, []byte(tc.progress), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			status, err := ClusterJobGetStatus(job)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *status != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, *status)
+			}
+		})
+	}
+}
+
+func TestClusterJobGetStatusProcessGone(t *testing.T) {
+	dir := t.TempDir()
+	job := &k3d.ClusterJob{Cluster: "test", PID: 1 << 30, ProgressFile: filepath.Join(dir, "test.progress.jsonl"), LogFile: filepath.Join(dir, "test.log")}
+	if err := os.WriteFile(job.ProgressFile, []byte(`{"operation":"cluster-create","phase":"start","percent":50,"message":"Starting nodes"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, err := ClusterJobGetStatus(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.State != k3d.ClusterJobStateFailed || status.Error == "" {
+		t.Errorf("expected job of a vanished process to be failed with an error, got %+v", *status)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+	"github.com/rancher/k3d/v5/pkg/util"
+	"github.com/rancher/k3d/v5/version"
+)
+
+// k3sChannelServerURL is the channel server queried for the k3s release channels (a variable to be replaceable in tests)
+var k3sChannelServerURL = k3s.K3sChannelServerURL
+
+// k3sChannelsCache is the on-disk cache of the k3s release channels
+type k3sChannelsCache struct {
+	Fetched  time.Time     `json:"fetched"`
+	Channels []k3s.Channel `json:"channels"`
+}
+
+// K3sChannelsGet returns the k3s release channels (e.g. stable, latest, v1.21), sorted by name.
+// They're served from the cache if it's younger than the DefaultK3sChannelCacheTTL and refreshed from the channel server otherwise.
+// If the channel server can't be reached, an outdated cache is used as well (with a warning).
+func K3sChannelsGet() ([]k3s.Channel, error) {
+	cacheFile, err := util.GetK3sChannelsCacheFile()
+	if err != nil {
+		l.Log().Debugf("Not caching k3s channels: %v", err)
+	}
+
+	var cache *k3sChannelsCache
+	if cacheFile != "" {
+		cache = k3sChannelsCacheRead(cacheFile)
+		if cache != nil && time.Since(cache.Fetched) < k3d.DefaultK3sChannelCacheTTL {
+			l.Log().Tracef("Using k3s channels cached at %s", cache.Fetched)
+			return cache.Channels, nil
+		}
+	}
+
+	channels, err := version.FetchK3sChannels(k3sChannelServerURL)
+	if err != nil {
+		if cache != nil {
+			l.Log().Warnf("Failed to fetch k3s channels (%v): using the ones cached at %s", err, cache.Fetched.Format(time.RFC3339))
+			return cache.Channels, nil
+		}
+		return nil, fmt.Errorf("failed to fetch k3s channels from %s: %w", k3sChannelServerURL, err)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+
+	if cacheFile != "" {
+		content, err := json.Marshal(k3sChannelsCache{Fetched: time.Now(), Channels: channels})
+		if err == nil {
+			err = os.WriteFile(cacheFile, content, 0644)
+		}
+		if err != nil {
+			l.Log().Debugf("Failed to cache k3s channels in '%s': %v", cacheFile, err)
+		}
+	}
+	return channels, nil
+}
+
+// k3sChannelsCacheRead reads the channel cache, returning nil if there's none (or it's unreadable)
+func k3sChannelsCacheRead(cacheFile string) *k3sChannelsCache {
+	content, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return nil
+	}
+	cache := &k3sChannelsCache{}
+	if err := json.Unmarshal(content, cache); err != nil {
+		l.Log().Debugf("Ignoring unreadable k3s channel cache '%s': %v", cacheFile, err)
+		return nil
+	}
+	return cache
+}
+
+// K3sChannelResolve returns the image tag of the latest k3s version in the given release channel (e.g. stable, latest, v1.21).
+// If the channels can't be fetched (e.g. offline), it falls back to the k3s version k3d was built with.
+func K3sChannelResolve(channel string) (string, error) {
+	channels, err := K3sChannelsGet()
+	if err != nil {
+		l.Log().Warnf("%v: falling back to the default k3s version %s instead of the latest one of channel '%s'", err, version.K3sVersion, channel)
+		return version.K3sVersion, nil
+	}
+	names := make([]string, 0, len(channels))
+	for _, c := range channels {
+		if c.Name == channel {
+			return version.K3sChannelVersion(c), nil
+		}
+		names = append(names, c.Name)
+	}
+	return "", fmt.Errorf("unknown k3s channel '%s': must be one of %s", channel, strings.Join(names, ", "))
+}
+
+// K3sImageChannel checks if the image references a k3s release channel instead of a version and returns the image repository and the channel.
+// Supported are "latest" and "stable", "+CHANNEL" (using the default k3s repository) and "REPO:+CHANNEL", e.g. "rancher/k3s:+v1.21".
+func K3sImageChannel(image string) (repo string, channel string, ok bool) {
+	if image == "latest" || image == "stable" {
+		return k3d.DefaultK3sImageRepo, image, true
+	}
+	if strings.HasPrefix(image, "+") && len(image) > 1 {
+		return k3d.DefaultK3sImageRepo, strings.TrimPrefix(image, "+"), true
+	}
+	if i := strings.LastIndex(image, ":+"); i > 0 && len(image) > i+2 && !strings.Contains(image[i:], "/") {
+		return image[:i], image[i+2:], true
+	}
+	return "", "", false
+}
+
+// K3sImageResolve replaces a k3s release channel in the image reference (see K3sImageChannel) with the latest k3s version of that channel.
+// Other images are returned as they are.
+func K3sImageResolve(image string) (string, error) {
+	repo, channel, ok := K3sImageChannel(image)
+	if !ok {
+		return image, nil
+	}
+	tag, err := K3sChannelResolve(channel)
+	if err != nil {
+		return "", err
+	}
+	l.Log().Debugf("Resolved k3s channel '%s' to version %s", channel, tag)
+	return fmt.Sprintf("%s:%s", repo, tag), nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+	"github.com/rancher/k3d/v5/version"
+)
+
+func TestK3sImageChannel(t *testing.T) {
+	tests := map[string]struct {
+		image           string
+		expectedRepo    string
+		expectedChannel string
+		expectedOK      bool
+	}{
+		"stable":               {image: "stable", expectedRepo: k3d.DefaultK3sImageRepo, expectedChannel: "stable", expectedOK: true},
+		"plus channel":         {image: "+v1.21", expectedRepo: k3d.DefaultK3sImageRepo, expectedChannel: "v1.21", expectedOK: true},
+		"repo with channel":    {image: "rancher/k3s:+latest", expectedRepo: "rancher/k3s", expectedChannel: "latest", expectedOK: true},
+		"registry w/ port":     {image: "localhost:5000/k3s:+stable", expectedRepo: "localhost:5000/k3s", expectedChannel: "stable", expectedOK: true},
+		"exact tag":            {image: "rancher/k3s:v1.21.7-k3s1", expectedOK: false},
+		"plus only":            {image: "rancher/k3s:+", expectedOK: false},
+		"registry without tag": {image: "localhost:5000/k3s", expectedOK: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo, channel, ok := K3sImageChannel(tc.image)
+			if ok != tc.expectedOK || repo != tc.expectedRepo || channel != tc.expectedChannel {
+				t.Errorf("expected (%s, %s, %t), got (%s, %s, %t)", tc.expectedRepo, tc.expectedChannel, tc.expectedOK, repo, channel, ok)
+			}
+		})
+	}
+}
+
+func TestK3sChannelResolve(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	homedir.DisableCache = true
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data": [{"id": "stable", "name": "stable", "latest": "v1.21.7+k3s1"}, {"id": "latest", "name": "latest", "latest": "v1.22.4+k3s1"}]}`)
+	}))
+	defer func(url string) { k3sChannelServerURL = url }(k3sChannelServerURL)
+	k3sChannelServerURL = server.URL
+
+	image, err := K3sImageResolve("rancher/k3s:+latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "rancher/k3s:v1.22.4-k3s1" {
+		t.Errorf("expected rancher/k3s:v1.22.4-k3s1, got %s", image)
+	}
+
+	// served from the cache
+	if v, err := K3sChannelResolve("stable"); err != nil || v != "v1.21.7-k3s1" {
+		t.Errorf("expected (v1.21.7-k3s1, nil), got (%s, %v)", v, err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the channels to be fetched once, got %d requests", requests)
+	}
+
+	if _, err := K3sChannelResolve("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown channel")
+	}
+
+	// an outdated cache is used if the channel server is unreachable
+	server.Close()
+	cacheFile, err := util.GetK3sChannelsCacheFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := k3sChannelsCacheRead(cacheFile)
+	if cache == nil {
+		t.Fatalf("expected the channels to be cached in %s", cacheFile)
+	}
+	cache.Fetched = time.Now().Add(-2 * k3d.DefaultK3sChannelCacheTTL)
+	content, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cacheFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := K3sChannelResolve("latest"); err != nil || v != "v1.22.4-k3s1" {
+		t.Errorf("expected (v1.22.4-k3s1, nil) from the outdated cache, got (%s, %v)", v, err)
+	}
+
+	// without a cache, it falls back to the default k3s version
+	if err := os.Remove(cacheFile); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := K3sChannelResolve("latest"); err != nil || v != version.K3sVersion {
+		t.Errorf("expected (%s, nil) as fallback, got (%s, %v)", version.K3sVersion, v, err)
+	}
+}
@@ -26,13 +26,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
 
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -42,6 +45,8 @@ type WriteKubeConfigOptions struct {
 	UpdateExisting       bool
 	UpdateCurrentContext bool
 	OverwriteExisting    bool
+	Encryption           *k3d.KubeconfigEncryption // if set, client credentials are stored encrypted instead of in the written kubeconfig
+	Role                 k3d.KubeconfigRole        // if set to a non-admin role, the kubeconfig uses a ServiceAccount bound to that role instead of cluster-admin
 }
 
 // KubeconfigGetWrite ...
@@ -64,6 +69,20 @@ func KubeconfigGetWrite(ctx context.Context, runtime runtimes.Runtime, cluster *
 		}
 	}
 
+	// replace cluster-admin credentials with the ones of a ServiceAccount of the requested role
+	if writeKubeConfigOptions.Role != "" {
+		if err := KubeconfigUseRole(ctx, runtime, cluster, kubeconfig, writeKubeConfigOptions.Role); err != nil {
+			return output, fmt.Errorf("failed to get kubeconfig with role '%s' for cluster '%s': %w", writeKubeConfigOptions.Role, cluster.Name, err)
+		}
+	}
+
+	// replace client credentials with the credential plugin (not when printing to stdout, where the credentials are shown decrypted on demand)
+	if writeKubeConfigOptions.Encryption != nil && writeKubeConfigOptions.Encryption.Store != "" && output != "-" {
+		if err := KubeconfigEncryptCredentials(ctx, cluster, kubeconfig, writeKubeConfigOptions.Encryption); err != nil {
+			return output, fmt.Errorf("failed to encrypt kubeconfig credentials for cluster '%s': %w", cluster.Name, err)
+		}
+	}
+
 	// simply write to the output, ignoring existing contents
 	if writeKubeConfigOptions.OverwriteExisting || output == "-" {
 		return output, KubeconfigWriteToPath(ctx, kubeconfig, output)
@@ -106,6 +125,32 @@ func KubeconfigGetWrite(ctx context.Context, runtime runtimes.Runtime, cluster *
 
 }
 
+// KubeconfigWriteForCluster writes the kubeconfig of a new cluster according to the kubeconfig options of its config:
+// into the default kubeconfig and/or into the given output file, switching the current-context in each of them, if requested.
+// It returns the paths of all written kubeconfig files.
+func KubeconfigWriteForCluster(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, opts config.SimpleConfigOptionsKubeconfig) ([]string, error) {
+	writeOpts := &WriteKubeConfigOptions{UpdateExisting: true, OverwriteExisting: false, UpdateCurrentContext: opts.SwitchCurrentContext, Encryption: &opts.Encryption, Role: opts.Role}
+
+	var outputs []string
+	if opts.UpdateDefaultKubeconfig {
+		l.Log().Debugf("Updating default kubeconfig with a new context for cluster %s", cluster.Name)
+		output, err := KubeconfigGetWrite(ctx, runtime, cluster, "", writeOpts)
+		if err != nil {
+			return outputs, fmt.Errorf("failed to update default kubeconfig: %w", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if opts.Output != "" {
+		l.Log().Debugf("Writing kubeconfig for cluster %s to '%s'", cluster.Name, opts.Output)
+		output, err := KubeconfigGetWrite(ctx, runtime, cluster, opts.Output, writeOpts)
+		if err != nil {
+			return outputs, fmt.Errorf("failed to write kubeconfig to '%s': %w", opts.Output, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
 // KubeconfigGet grabs the kubeconfig file from /output from a server node container,
 // modifies it by updating some fields with cluster-specific information
 // and returns a Config object for further processing
@@ -165,20 +210,20 @@ func KubeconfigGet(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.C
 	}
 
 	// update the server URL
-	kc.Clusters["default"].Server = fmt.Sprintf("https://%s:%s", APIHost, APIPort)
+	kc.Clusters["default"].Server = fmt.Sprintf("https://%s", net.JoinHostPort(APIHost, APIPort))
 
 	// rename user from default to admin
-	newAuthInfoName := fmt.Sprintf("admin@%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
+	newAuthInfoName := fmt.Sprintf("admin@%s-%s", cluster.GetNamePrefix(), cluster.Name)
 	kc.AuthInfos[newAuthInfoName] = kc.AuthInfos["default"]
 	delete(kc.AuthInfos, "default")
 
 	// rename cluster from default to clustername
-	newClusterName := fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
+	newClusterName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
 	kc.Clusters[newClusterName] = kc.Clusters["default"]
 	delete(kc.Clusters, "default")
 
 	// rename context from default to clustername
-	newContextName := fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
+	newContextName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
 	kc.Contexts[newContextName] = kc.Contexts["default"]
 	delete(kc.Contexts, "default")
 
@@ -307,6 +352,37 @@ func KubeconfigGetDefaultPath() (string, error) {
 	return defaultKubeConfigLoadingRules.GetDefaultFilename(), nil
 }
 
+// KubeconfigGetClusterFilePath returns the path of the standalone kubeconfig file of a cluster inside the k3d config directory
+func KubeconfigGetClusterFilePath(cluster *k3d.Cluster) (string, error) {
+	configDir, err := util.GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get k3d config dir: %w", err)
+	}
+	return filepath.Join(configDir, fmt.Sprintf("kubeconfig-%s.yaml", cluster.Name)), nil
+}
+
+// KubeconfigRemoveClusterFromAll removes a cluster's details from the default kubeconfig and deletes its standalone kubeconfig file (if there is one).
+// This is best-effort: failures are logged as warnings, since the cluster itself is usually already gone at this point.
+func KubeconfigRemoveClusterFromAll(ctx context.Context, cluster *k3d.Cluster) {
+	l.Log().Infoln("Removing cluster details from default kubeconfig...")
+	if err := KubeconfigRemoveClusterFromDefaultConfig(ctx, cluster); err != nil {
+		l.Log().Warnln("Failed to remove cluster details from default kubeconfig")
+		l.Log().Warnln(err)
+	}
+
+	l.Log().Infoln("Removing standalone kubeconfig file (if there is one)...")
+	kubeconfigFile, err := KubeconfigGetClusterFilePath(cluster)
+	if err != nil {
+		l.Log().Warnf("Failed to delete kubeconfig file: %+v", err)
+		return
+	}
+	if err := os.Remove(kubeconfigFile); err != nil && !os.IsNotExist(err) {
+		l.Log().Warnf("Failed to delete kubeconfig file '%s': %+v", kubeconfigFile, err)
+	}
+
+	KubeconfigCredentialsRemove(ctx, cluster)
+}
+
 // KubeconfigRemoveClusterFromDefaultConfig removes a cluster's details from the default kubeconfig
 func KubeconfigRemoveClusterFromDefaultConfig(ctx context.Context, cluster *k3d.Cluster) error {
 	defaultKubeConfigPath, err := KubeconfigGetDefaultPath()
@@ -323,14 +399,15 @@ func KubeconfigRemoveClusterFromDefaultConfig(ctx context.Context, cluster *k3d.
 
 // KubeconfigRemoveCluster removes a cluster's details from a given kubeconfig
 func KubeconfigRemoveCluster(ctx context.Context, cluster *k3d.Cluster, kubeconfig *clientcmdapi.Config) *clientcmdapi.Config {
-	clusterName := fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
-	contextName := fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
-	authInfoName := fmt.Sprintf("admin@%s-%s", k3d.DefaultObjectNamePrefix, cluster.Name)
+	clusterName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
+	contextName := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
 
 	// delete elements from kubeconfig if they're present
 	delete(kubeconfig.Contexts, contextName)
 	delete(kubeconfig.Clusters, clusterName)
-	delete(kubeconfig.AuthInfos, authInfoName)
+	for _, role := range k3d.KubeconfigRoles {
+		delete(kubeconfig.AuthInfos, fmt.Sprintf("%s@%s-%s", role, cluster.GetNamePrefix(), cluster.Name))
+	}
 
 	// set current-context to any other context, if it was set to the given cluster before
 	if kubeconfig.CurrentContext == contextName {
@@ -0,0 +1,281 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	gort "runtime"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// KubeconfigCredentialAPIVersion is the API version of the ExecCredential printed by `k3d kubeconfig credential`
+const KubeconfigCredentialAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// kubeconfigCredentialsOp is an operation on a credential store
+type kubeconfigCredentialsOp string
+
+const (
+	kubeconfigCredentialsOpStore  kubeconfigCredentialsOp = "store"
+	kubeconfigCredentialsOpLoad   kubeconfigCredentialsOp = "load"
+	kubeconfigCredentialsOpDelete kubeconfigCredentialsOp = "delete"
+)
+
+// kubeconfigCredentialsCommand is a command of an external tool that implements an operation on a credential store
+type kubeconfigCredentialsCommand struct {
+	name  string
+	args  []string
+	stdin []byte
+}
+
+// KubeconfigEncryptionValidate checks that the encryption options are complete for the chosen credential store
+func KubeconfigEncryptionValidate(encryption *k3d.KubeconfigEncryption) error {
+	switch encryption.Store {
+	case k3d.KubeconfigCredentialStoreKeychain:
+		if gort.GOOS != "darwin" && gort.GOOS != "linux" {
+			return fmt.Errorf("credential store '%s' is not supported on %s", encryption.Store, gort.GOOS)
+		}
+	case k3d.KubeconfigCredentialStoreAge:
+		if len(encryption.Recipients) == 0 {
+			return fmt.Errorf("credential store '%s' requires at least one recipient to encrypt for", encryption.Store)
+		}
+		if encryption.Identity == "" {
+			return fmt.Errorf("credential store '%s' requires an identity file to decrypt with", encryption.Store)
+		}
+	case k3d.KubeconfigCredentialStoreSops:
+	default:
+		return fmt.Errorf("unknown credential store '%s': must be one of %v", encryption.Store, k3d.KubeconfigCredentialStores)
+	}
+	return nil
+}
+
+// KubeconfigEncryptCredentials stores the client credentials of the cluster's kubeconfig encrypted in the credential store
+// and replaces them with a call to `k3d kubeconfig credential`, so that kubectl decrypts them on demand and they're never written to disk in plaintext
+func KubeconfigEncryptCredentials(ctx context.Context, cluster *k3d.Cluster, kubeconfig *clientcmdapi.Config, encryption *k3d.KubeconfigEncryption) error {
+	if err := KubeconfigEncryptionValidate(encryption); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get path of the k3d executable for the credential plugin: %w", err)
+	}
+
+	for name, authInfo := range kubeconfig.AuthInfos {
+		if len(authInfo.ClientKeyData) == 0 && authInfo.Token == "" {
+			continue
+		}
+		secret, err := json.Marshal(clientauthv1beta1.ExecCredentialStatus{
+			ClientCertificateData: string(authInfo.ClientCertificateData),
+			ClientKeyData:         string(authInfo.ClientKeyData),
+			Token:                 authInfo.Token,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal credentials of user '%s': %w", name, err)
+		}
+		if err := kubeconfigCredentialsRun(ctx, kubeconfigCredentialsOpStore, encryption, cluster, secret); err != nil {
+			return fmt.Errorf("failed to store credentials of cluster '%s' in %s: %w", cluster.Name, encryption.Store, err)
+		}
+		kubeconfigUseCredentialPlugin(authInfo, executable, cluster.Name, encryption)
+		l.Log().Debugf("Stored credentials of user '%s' in %s", name, encryption.Store)
+	}
+	return nil
+}
+
+// kubeconfigUseCredentialPlugin replaces the client credentials of a kubeconfig user with a call to `k3d kubeconfig credential`
+func kubeconfigUseCredentialPlugin(authInfo *clientcmdapi.AuthInfo, executable string, cluster string, encryption *k3d.KubeconfigEncryption) {
+	args := []string{"kubeconfig", "credential", cluster, "--store", string(encryption.Store)}
+	if encryption.Store == k3d.KubeconfigCredentialStoreAge {
+		args = append(args, "--identity", encryption.Identity)
+	}
+	authInfo.ClientCertificate = ""
+	authInfo.ClientCertificateData = nil
+	authInfo.ClientKey = ""
+	authInfo.ClientKeyData = nil
+	authInfo.Token = ""
+	authInfo.Exec = &clientcmdapi.ExecConfig{
+		APIVersion:  KubeconfigCredentialAPIVersion,
+		Command:     executable,
+		Args:        args,
+		InstallHint: fmt.Sprintf("The credentials of this cluster are stored encrypted (%s), get them with `k3d kubeconfig credential %s`", encryption.Store, cluster),
+	}
+}
+
+// KubeconfigCredentialGet decrypts the stored client credentials of a cluster and returns them as ExecCredential for kubectl
+func KubeconfigCredentialGet(ctx context.Context, cluster *k3d.Cluster, encryption *k3d.KubeconfigEncryption) (*clientauthv1beta1.ExecCredential, error) {
+	if encryption.Store == k3d.KubeconfigCredentialStoreAge && encryption.Identity == "" {
+		return nil, fmt.Errorf("credential store '%s' requires an identity file to decrypt with", encryption.Store)
+	}
+
+	secret, err := kubeconfigCredentialsOutput(ctx, kubeconfigCredentialsOpLoad, encryption, cluster, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials of cluster '%s' from %s: %w", cluster.Name, encryption.Store, err)
+	}
+
+	status := &clientauthv1beta1.ExecCredentialStatus{}
+	if err := json.Unmarshal(secret, status); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials of cluster '%s': %w", cluster.Name, err)
+	}
+
+	credential := &clientauthv1beta1.ExecCredential{Status: status}
+	credential.APIVersion = KubeconfigCredentialAPIVersion
+	credential.Kind = "ExecCredential"
+	return credential, nil
+}
+
+// KubeconfigCredentialsRemove removes the stored credentials of a cluster from all credential stores that were used for it.
+// This is best-effort: failures are only logged.
+func KubeconfigCredentialsRemove(ctx context.Context, cluster *k3d.Cluster) {
+	for _, store := range k3d.KubeconfigCredentialStores {
+		file, err := util.GetKubeconfigCredentialsFile(cluster.Name, string(store))
+		if err != nil {
+			l.Log().Debugf("Failed to get credentials file of cluster '%s': %v", cluster.Name, err)
+			return
+		}
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if store == k3d.KubeconfigCredentialStoreKeychain {
+			if err := kubeconfigCredentialsRun(ctx, kubeconfigCredentialsOpDelete, &k3d.KubeconfigEncryption{Store: store}, cluster, nil); err != nil {
+				l.Log().Warnf("Failed to remove credentials of cluster '%s' from the keychain: %v", cluster.Name, err)
+			}
+		}
+		l.Log().Infof("Removing stored kubeconfig credentials (%s)...", store)
+		if err := os.Remove(file); err != nil {
+			l.Log().Warnf("Failed to delete credentials file '%s': %v", file, err)
+		}
+	}
+}
+
+// kubeconfigCredentialsRun runs an operation on a credential store
+func kubeconfigCredentialsRun(ctx context.Context, op kubeconfigCredentialsOp, encryption *k3d.KubeconfigEncryption, cluster *k3d.Cluster, secret []byte) error {
+	_, err := kubeconfigCredentialsOutput(ctx, op, encryption, cluster, secret)
+	return err
+}
+
+// kubeconfigCredentialsOutput runs an operation on a credential store and returns the (decoded) secret for load operations
+func kubeconfigCredentialsOutput(ctx context.Context, op kubeconfigCredentialsOp, encryption *k3d.KubeconfigEncryption, cluster *k3d.Cluster, secret []byte) ([]byte, error) {
+	// files store the encrypted credentials (age/sops) or mark that the keychain holds them (to clean up on cluster deletion)
+	file, err := util.GetKubeconfigCredentialsFile(cluster.Name, string(encryption.Store))
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := getKubeconfigCredentialsCommand(op, gort.GOOS, encryption, cluster, file, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command.name, command.args...)
+	cmd.Stdin = bytes.NewReader(command.stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("'%s' is required for the '%s' credential store, but it's not in your PATH", command.name, encryption.Store)
+		}
+		return nil, fmt.Errorf("'%s %s' failed: %w: %s", command.name, command.args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	switch op {
+	case kubeconfigCredentialsOpStore:
+		if encryption.Store == k3d.KubeconfigCredentialStoreKeychain {
+			if err := os.WriteFile(file, nil, 0600); err != nil {
+				return nil, fmt.Errorf("failed to write '%s': %w", file, err)
+			}
+		}
+	case kubeconfigCredentialsOpLoad:
+		if encryption.Store == k3d.KubeconfigCredentialStoreKeychain {
+			return base64.StdEncoding.DecodeString(strings.TrimSpace(stdout.String()))
+		}
+		return stdout.Bytes(), nil
+	}
+	return nil, nil
+}
+
+// getKubeconfigCredentialsCommand returns the command of the external tool implementing an operation on a credential store.
+// The secret is always passed via stdin, so that it doesn't show up in the process list.
+func getKubeconfigCredentialsCommand(op kubeconfigCredentialsOp, goos string, encryption *k3d.KubeconfigEncryption, cluster *k3d.Cluster, file string, secret []byte) (*kubeconfigCredentialsCommand, error) {
+	switch encryption.Store {
+	case k3d.KubeconfigCredentialStoreKeychain:
+		account := fmt.Sprintf("%s-%s", cluster.GetNamePrefix(), cluster.Name)
+		encoded := base64.StdEncoding.EncodeToString(secret) // keychains store single line passwords
+		switch goos {
+		case "darwin":
+			switch op {
+			case kubeconfigCredentialsOpStore:
+				// `security -i` reads the command from stdin
+				return &kubeconfigCredentialsCommand{name: "security", args: []string{"-i"}, stdin: []byte(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", k3d.KubeconfigCredentialKeychainService, account, encoded))}, nil
+			case kubeconfigCredentialsOpLoad:
+				return &kubeconfigCredentialsCommand{name: "security", args: []string{"find-generic-password", "-s", k3d.KubeconfigCredentialKeychainService, "-a", account, "-w"}}, nil
+			case kubeconfigCredentialsOpDelete:
+				return &kubeconfigCredentialsCommand{name: "security", args: []string{"delete-generic-password", "-s", k3d.KubeconfigCredentialKeychainService, "-a", account}}, nil
+			}
+		case "linux":
+			switch op {
+			case kubeconfigCredentialsOpStore:
+				return &kubeconfigCredentialsCommand{name: "secret-tool", args: []string{"store", "--label", fmt.Sprintf("k3d kubeconfig credentials (%s)", cluster.Name), "service", k3d.KubeconfigCredentialKeychainService, "account", account}, stdin: []byte(encoded)}, nil
+			case kubeconfigCredentialsOpLoad:
+				return &kubeconfigCredentialsCommand{name: "secret-tool", args: []string{"lookup", "service", k3d.KubeconfigCredentialKeychainService, "account", account}}, nil
+			case kubeconfigCredentialsOpDelete:
+				return &kubeconfigCredentialsCommand{name: "secret-tool", args: []string{"clear", "service", k3d.KubeconfigCredentialKeychainService, "account", account}}, nil
+			}
+		}
+		return nil, fmt.Errorf("credential store '%s' is not supported on %s", encryption.Store, goos)
+	case k3d.KubeconfigCredentialStoreAge:
+		switch op {
+		case kubeconfigCredentialsOpStore:
+			args := []string{"--encrypt", "--armor", "--output", file}
+			for _, recipient := range encryption.Recipients {
+				args = append(args, "--recipient", recipient)
+			}
+			return &kubeconfigCredentialsCommand{name: "age", args: args, stdin: secret}, nil
+		case kubeconfigCredentialsOpLoad:
+			return &kubeconfigCredentialsCommand{name: "age", args: []string{"--decrypt", "--identity", encryption.Identity, file}}, nil
+		}
+	case k3d.KubeconfigCredentialStoreSops:
+		switch op {
+		case kubeconfigCredentialsOpStore:
+			args := []string{"--encrypt", "--input-type", "json", "--output-type", "json", "--output", file}
+			if len(encryption.Recipients) > 0 {
+				args = append(args, "--age", strings.Join(encryption.Recipients, ","))
+			}
+			return &kubeconfigCredentialsCommand{name: "sops", args: append(args, "/dev/stdin"), stdin: secret}, nil
+		case kubeconfigCredentialsOpLoad:
+			return &kubeconfigCredentialsCommand{name: "sops", args: []string{"--decrypt", "--input-type", "json", "--output-type", "json", file}}, nil
+		}
+	}
+	return nil, fmt.Errorf("operation '%s' is not supported by credential store '%s'", op, encryption.Store)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestGetKubeconfigCredentialsCommand(t *testing.T) {
+	secret := []byte(`{"clientKeyData":"key"}`)
+	age := &k3d.KubeconfigEncryption{Store: k3d.KubeconfigCredentialStoreAge, Recipients: []string{"age1a", "age1b"}, Identity: "/key.txt"}
+	sops := &k3d.KubeconfigEncryption{Store: k3d.KubeconfigCredentialStoreSops, Recipients: []string{"age1a", "age1b"}}
+	keychain := &k3d.KubeconfigEncryption{Store: k3d.KubeconfigCredentialStoreKeychain}
+
+	tests := []struct {
+		name       string
+		op         kubeconfigCredentialsOp
+		goos       string
+		encryption *k3d.KubeconfigEncryption
+		expected   *kubeconfigCredentialsCommand
+	}{
+		{
+			name: "age store", op: kubeconfigCredentialsOpStore, goos: "linux", encryption: age,
+			expected: &kubeconfigCredentialsCommand{name: "age", args: []string{"--encrypt", "--armor", "--output", "/creds", "--recipient", "age1a", "--recipient", "age1b"}, stdin: secret},
+		},
+		{
+			name: "age load", op: kubeconfigCredentialsOpLoad, goos: "linux", encryption: age,
+			expected: &kubeconfigCredentialsCommand{name: "age", args: []string{"--decrypt", "--identity", "/key.txt", "/creds"}},
+		},
+		{
+			name: "sops store", op: kubeconfigCredentialsOpStore, goos: "darwin", encryption: sops,
+			expected: &kubeconfigCredentialsCommand{name: "sops", args: []string{"--encrypt", "--input-type", "json", "--output-type", "json", "--output", "/creds", "--age", "age1a,age1b", "/dev/stdin"}, stdin: secret},
+		},
+		{
+			name: "sops load", op: kubeconfigCredentialsOpLoad, goos: "darwin", encryption: sops,
+			expected: &kubeconfigCredentialsCommand{name: "sops", args: []string{"--decrypt", "--input-type", "json", "--output-type", "json", "/creds"}},
+		},
+		{
+			name: "macOS keychain store", op: kubeconfigCredentialsOpStore, goos: "darwin", encryption: keychain,
+			expected: &kubeconfigCredentialsCommand{name: "security", args: []string{"-i"}, stdin: []byte("add-generic-password -U -s k3d-kubeconfig -a k3d-test -w eyJjbGllbnRLZXlEYXRhIjoia2V5In0=\n")},
+		},
+		{
+			name: "linux keychain store", op: kubeconfigCredentialsOpStore, goos: "linux", encryption: keychain,
+			expected: &kubeconfigCredentialsCommand{name: "secret-tool", args: []string{"store", "--label", "k3d kubeconfig credentials (test)", "service", "k3d-kubeconfig", "account", "k3d-test"}, stdin: []byte("eyJjbGllbnRLZXlEYXRhIjoia2V5In0=")},
+		},
+		{
+			name: "linux keychain delete", op: kubeconfigCredentialsOpDelete, goos: "linux", encryption: keychain,
+			expected: &kubeconfigCredentialsCommand{name: "secret-tool", args: []string{"clear", "service", "k3d-kubeconfig", "account", "k3d-test"}},
+		},
+		{name: "keychain on windows", op: kubeconfigCredentialsOpLoad, goos: "windows", encryption: keychain},
+		{name: "age delete", op: kubeconfigCredentialsOpDelete, goos: "linux", encryption: age},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdin []byte
+			if tc.op == kubeconfigCredentialsOpStore {
+				stdin = secret
+			}
+			actual, err := getKubeconfigCredentialsCommand(tc.op, tc.goos, tc.encryption, &k3d.Cluster{Name: "test"}, "/creds", stdin)
+			if tc.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestKubeconfigUseCredentialPlugin(t *testing.T) {
+	authInfo := &clientcmdapi.AuthInfo{ClientCertificateData: []byte("cert"), ClientKeyData: []byte("key")}
+	kubeconfigUseCredentialPlugin(authInfo, "/usr/local/bin/k3d", "test", &k3d.KubeconfigEncryption{Store: k3d.KubeconfigCredentialStoreAge, Identity: "/key.txt"})
+
+	if authInfo.ClientCertificateData != nil || authInfo.ClientKeyData != nil {
+		t.Errorf("expected client credentials to be removed from the kubeconfig")
+	}
+	if authInfo.Exec == nil {
+		t.Fatalf("expected exec credential plugin to be set")
+	}
+	expectedArgs := []string{"kubeconfig", "credential", "test", "--store", "age", "--identity", "/key.txt"}
+	if authInfo.Exec.Command != "/usr/local/bin/k3d" || !reflect.DeepEqual(authInfo.Exec.Args, expectedArgs) {
+		t.Errorf("expected '/usr/local/bin/k3d %v', got '%s %v'", expectedArgs, authInfo.Exec.Command, authInfo.Exec.Args)
+	}
+	if authInfo.Exec.APIVersion != KubeconfigCredentialAPIVersion {
+		t.Errorf("expected apiVersion %s, got %s", KubeconfigCredentialAPIVersion, authInfo.Exec.APIVersion)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeconfigRoleTokenTimeout is how long to wait for the token of a provisioned ServiceAccount to be populated
+const kubeconfigRoleTokenTimeout = 30 * time.Second
+
+// KubeconfigRoleValidate checks that the given role is a supported kubeconfig role
+func KubeconfigRoleValidate(role k3d.KubeconfigRole) error {
+	for _, r := range k3d.KubeconfigRoles {
+		if role == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown kubeconfig role '%s': must be one of %v", role, k3d.KubeconfigRoles)
+}
+
+// kubeconfigRoleServiceAccountName returns the name of the ServiceAccount (and its ClusterRoleBinding) provisioned for a non-admin kubeconfig role
+func kubeconfigRoleServiceAccountName(cluster *k3d.Cluster, role k3d.KubeconfigRole) string {
+	return fmt.Sprintf("%s-kubeconfig-%s", cluster.GetNamePrefix(), role)
+}
+
+// kubeconfigRoleManifest returns the manifest of the ServiceAccount, its long-lived token Secret and the ClusterRoleBinding to the
+// built-in ClusterRole of the same name for a non-admin kubeconfig role
+func kubeconfigRoleManifest(cluster *k3d.Cluster, role k3d.KubeconfigRole) string {
+	name := kubeconfigRoleServiceAccountName(cluster, role)
+	return fmt.Sprintf(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+  labels:
+    app.kubernetes.io/managed-by: k3d
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s-token
+  namespace: %[2]s
+  labels:
+    app.kubernetes.io/managed-by: k3d
+  annotations:
+    kubernetes.io/service-account.name: %[1]s
+type: kubernetes.io/service-account-token
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: %[1]s
+  labels:
+    app.kubernetes.io/managed-by: k3d
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: %[3]s
+subjects:
+- kind: ServiceAccount
+  name: %[1]s
+  namespace: %[2]s
+`, name, k3d.KubeconfigRoleServiceAccountNamespace, role)
+}
+
+// KubeconfigUseRole replaces the cluster-admin credentials of the cluster's kubeconfig with the token of a ServiceAccount
+// that is bound to the ClusterRole of the given role, provisioning it in the cluster if necessary
+func KubeconfigUseRole(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, kubeconfig *clientcmdapi.Config, role k3d.KubeconfigRole) error {
+	if err := KubeconfigRoleValidate(role); err != nil {
+		return err
+	}
+	if role == k3d.KubeconfigRoleAdmin {
+		return nil
+	}
+
+	serverNodes, err := runtime.GetNodesByLabel(ctx, map[string]string{k3d.LabelClusterName: cluster.Name, k3d.LabelRole: string(k3d.ServerRole)})
+	if err != nil {
+		return fmt.Errorf("runtime failed to get server nodes for cluster '%s': %w", cluster.Name, err)
+	}
+
+	var token string
+	for _, node := range serverNodes {
+		token, err = kubeconfigRoleProvisionToken(ctx, runtime, cluster, node, role)
+		if err == nil {
+			break
+		}
+		l.Log().Debugf("Failed to provision ServiceAccount for kubeconfig role '%s' via node '%s': %v", role, node.Name, err)
+	}
+	if token == "" {
+		return fmt.Errorf("failed to provision ServiceAccount for kubeconfig role '%s' in cluster '%s': %w", role, cluster.Name, err)
+	}
+
+	kubeconfigUseRoleToken(kubeconfig, cluster, role, token)
+	return nil
+}
+
+// kubeconfigRoleProvisionToken applies the ServiceAccount manifest for the role via kubectl in the given server node and returns its token
+func kubeconfigRoleProvisionToken(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, node *k3d.Node, role k3d.KubeconfigRole) (string, error) {
+	if err := runtime.WriteToNode(ctx, []byte(kubeconfigRoleManifest(cluster, role)), k3d.DefaultKubeconfigRoleManifestTempPath, 0644, node); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := runtime.ExecInNode(ctx, node, []string{"kubectl", "apply", "-f", k3d.DefaultKubeconfigRoleManifestTempPath}); err != nil {
+		return "", fmt.Errorf("failed to apply manifest: %w", err)
+	}
+
+	// the token controller populates the Secret asynchronously
+	getTokenCmd := []string{"kubectl", "get", "secret", "-n", k3d.KubeconfigRoleServiceAccountNamespace, fmt.Sprintf("%s-token", kubeconfigRoleServiceAccountName(cluster, role)), "-o", "jsonpath={.data.token}"}
+	deadline := time.Now().Add(kubeconfigRoleTokenTimeout)
+	for {
+		logreader, err := runtime.ExecInNodeGetLogs(ctx, node, getTokenCmd)
+		if err == nil && logreader != nil {
+			output, err := io.ReadAll(logreader)
+			if err != nil {
+				return "", fmt.Errorf("failed to read token: %w", err)
+			}
+			if encoded := strings.TrimSpace(string(output)); encoded != "" {
+				token, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return "", fmt.Errorf("failed to decode token: %w", err)
+				}
+				return string(token), nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for the token of ServiceAccount '%s'", kubeconfigRoleServiceAccountName(cluster, role))
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// kubeconfigUseRoleToken replaces the admin user of the cluster's kubeconfig with a user of the given role authenticating with the token
+func kubeconfigUseRoleToken(kubeconfig *clientcmdapi.Config, cluster *k3d.Cluster, role k3d.KubeconfigRole, token string) {
+	adminAuthInfoName := fmt.Sprintf("%s@%s-%s", k3d.KubeconfigRoleAdmin, cluster.GetNamePrefix(), cluster.Name)
+	authInfoName := fmt.Sprintf("%s@%s-%s", role, cluster.GetNamePrefix(), cluster.Name)
+
+	delete(kubeconfig.AuthInfos, adminAuthInfoName)
+	kubeconfig.AuthInfos[authInfoName] = &clientcmdapi.AuthInfo{Token: token}
+
+	for _, kubecontext := range kubeconfig.Contexts {
+		if kubecontext.AuthInfo == adminAuthInfoName {
+			kubecontext.AuthInfo = authInfoName
+		}
+	}
+}
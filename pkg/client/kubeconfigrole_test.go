@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"strings"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestKubeconfigUseRoleToken(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"admin@k3d-test": {ClientCertificateData: []byte("cert"), ClientKeyData: []byte("key")},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"k3d-test": {Cluster: "k3d-test", AuthInfo: "admin@k3d-test"},
+		},
+	}
+
+	kubeconfigUseRoleToken(kubeconfig, &k3d.Cluster{Name: "test"}, k3d.KubeconfigRoleView, "token")
+
+	if _, ok := kubeconfig.AuthInfos["admin@k3d-test"]; ok {
+		t.Errorf("expected admin user to be removed")
+	}
+	authInfo, ok := kubeconfig.AuthInfos["view@k3d-test"]
+	if !ok {
+		t.Fatalf("expected user 'view@k3d-test', got %+v", kubeconfig.AuthInfos)
+	}
+	if authInfo.Token != "token" || authInfo.ClientKeyData != nil {
+		t.Errorf("expected only the token to be set, got %+v", authInfo)
+	}
+	if kubeconfig.Contexts["k3d-test"].AuthInfo != "view@k3d-test" {
+		t.Errorf("expected context to use 'view@k3d-test', got '%s'", kubeconfig.Contexts["k3d-test"].AuthInfo)
+	}
+}
+
+func TestKubeconfigRoleManifest(t *testing.T) {
+	manifest := kubeconfigRoleManifest(&k3d.Cluster{Name: "test"}, k3d.KubeconfigRoleEdit)
+	for _, expected := range []string{"name: k3d-kubeconfig-edit\n", "kubernetes.io/service-account.name: k3d-kubeconfig-edit\n", "kind: ClusterRole\n  name: edit\n"} {
+		if !strings.Contains(manifest, expected) {
+			t.Errorf("expected manifest to contain '%s', got\n%s", expected, manifest)
+		}
+	}
+
+	if err := KubeconfigRoleValidate("owner"); err == nil {
+		t.Errorf("expected an error for an unknown role")
+	}
+}
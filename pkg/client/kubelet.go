@@ -0,0 +1,139 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// KubeletConfigArgs validates a kubelet configuration and returns the k3s args (--kubelet-arg=...) passing it to the kubelet
+func KubeletConfigArgs(config *k3d.KubeletConfig) ([]string, error) {
+	kubeletArgs := []string{}
+
+	if config.MaxPods < 0 {
+		return nil, fmt.Errorf("invalid kubelet config: maxPods must not be negative")
+	}
+	if config.MaxPods > 0 {
+		kubeletArgs = append(kubeletArgs, "max-pods="+strconv.Itoa(config.MaxPods))
+	}
+
+	evictionHard, err := kubeletEvictionThresholds(config.EvictionHard, "<")
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubelet config evictionHard: %w", err)
+	}
+	if evictionHard != "" {
+		kubeletArgs = append(kubeletArgs, "eviction-hard="+evictionHard)
+	}
+
+	evictionSoft, err := kubeletEvictionThresholds(config.EvictionSoft, "<")
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubelet config evictionSoft: %w", err)
+	}
+	for signal := range config.EvictionSoftGracePeriod {
+		if _, ok := config.EvictionSoft[signal]; !ok {
+			return nil, fmt.Errorf("invalid kubelet config evictionSoftGracePeriod: no evictionSoft threshold for signal '%s'", signal)
+		}
+		if _, err := time.ParseDuration(config.EvictionSoftGracePeriod[signal]); err != nil {
+			return nil, fmt.Errorf("invalid kubelet config evictionSoftGracePeriod for signal '%s': %w", signal, err)
+		}
+	}
+	for signal := range config.EvictionSoft {
+		if _, ok := config.EvictionSoftGracePeriod[signal]; !ok {
+			return nil, fmt.Errorf("invalid kubelet config evictionSoft: signal '%s' lacks a grace period in evictionSoftGracePeriod", signal)
+		}
+	}
+	if evictionSoft != "" {
+		gracePeriods, _ := kubeletEvictionThresholds(config.EvictionSoftGracePeriod, "=")
+		kubeletArgs = append(kubeletArgs, "eviction-soft="+evictionSoft, "eviction-soft-grace-period="+gracePeriods)
+	}
+
+	if len(config.FeatureGates) > 0 {
+		gates := []string{}
+		for gate, enabled := range config.FeatureGates {
+			gates = append(gates, fmt.Sprintf("%s=%t", gate, enabled))
+		}
+		sort.Strings(gates)
+		kubeletArgs = append(kubeletArgs, "feature-gates="+strings.Join(gates, ","))
+	}
+
+	for _, arg := range config.Args {
+		arg = strings.TrimPrefix(arg, "--")
+		if arg == "" || strings.HasPrefix(arg, "=") || strings.ContainsAny(arg, " \n") {
+			return nil, fmt.Errorf("invalid kubelet arg '%s': must be KEY[=VALUE]", arg)
+		}
+		kubeletArgs = append(kubeletArgs, arg)
+	}
+
+	args := make([]string, 0, len(kubeletArgs))
+	for _, arg := range kubeletArgs {
+		args = append(args, "--kubelet-arg="+arg)
+	}
+	return args, nil
+}
+
+// kubeletEvictionThresholds renders eviction thresholds (signal -> quantity or percentage) as the value of a kubelet eviction flag
+func kubeletEvictionThresholds(thresholds map[string]string, operator string) (string, error) {
+	rendered := []string{}
+	for signal, value := range thresholds {
+		known := false
+		for _, knownSignal := range k3d.KubeletEvictionSignals {
+			if signal == knownSignal {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("unknown eviction signal '%s' (supported: %s)", signal, strings.Join(k3d.KubeletEvictionSignals, ", "))
+		}
+		if operator == "<" {
+			if err := kubeletValidateEvictionThreshold(value); err != nil {
+				return "", fmt.Errorf("invalid threshold '%s' for signal '%s': %w", value, signal, err)
+			}
+		}
+		rendered = append(rendered, signal+operator+value)
+	}
+	sort.Strings(rendered)
+	return strings.Join(rendered, ","), nil
+}
+
+// kubeletValidateEvictionThreshold checks that an eviction threshold is a quantity (e.g. 100Mi) or a percentage (e.g. 10%)
+func kubeletValidateEvictionThreshold(value string) error {
+	if strings.HasSuffix(value, "%") {
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return fmt.Errorf("must be a percentage between 0%% and 100%%")
+		}
+		return nil
+	}
+	if _, err := resource.ParseQuantity(value); err != nil {
+		return fmt.Errorf("must be a quantity (e.g. 100Mi) or a percentage (e.g. 10%%)")
+	}
+	return nil
+}
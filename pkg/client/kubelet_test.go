@@ -0,0 +1,87 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestKubeletConfigArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   k3d.KubeletConfig
+		expected []string
+		wantErr  bool
+	}{
+		{name: "empty", config: k3d.KubeletConfig{}, expected: []string{}},
+		{
+			name: "all options",
+			config: k3d.KubeletConfig{
+				MaxPods:                 250,
+				EvictionHard:            map[string]string{"nodefs.available": "5%", "memory.available": "100Mi"},
+				EvictionSoft:            map[string]string{"memory.available": "500Mi"},
+				EvictionSoftGracePeriod: map[string]string{"memory.available": "1m30s"},
+				FeatureGates:            map[string]bool{"GracefulNodeShutdown": true, "CPUManager": false},
+				Args:                    []string{"--image-gc-high-threshold=70", "fail-swap-on=false"},
+			},
+			expected: []string{
+				"--kubelet-arg=max-pods=250",
+				"--kubelet-arg=eviction-hard=memory.available<100Mi,nodefs.available<5%",
+				"--kubelet-arg=eviction-soft=memory.available<500Mi",
+				"--kubelet-arg=eviction-soft-grace-period=memory.available=1m30s",
+				"--kubelet-arg=feature-gates=CPUManager=false,GracefulNodeShutdown=true",
+				"--kubelet-arg=image-gc-high-threshold=70",
+				"--kubelet-arg=fail-swap-on=false",
+			},
+		},
+		{name: "negative max pods", config: k3d.KubeletConfig{MaxPods: -1}, wantErr: true},
+		{name: "unknown signal", config: k3d.KubeletConfig{EvictionHard: map[string]string{"memory.free": "100Mi"}}, wantErr: true},
+		{name: "invalid quantity", config: k3d.KubeletConfig{EvictionHard: map[string]string{"memory.available": "lots"}}, wantErr: true},
+		{name: "invalid percentage", config: k3d.KubeletConfig{EvictionHard: map[string]string{"nodefs.available": "120%"}}, wantErr: true},
+		{name: "soft without grace period", config: k3d.KubeletConfig{EvictionSoft: map[string]string{"memory.available": "500Mi"}}, wantErr: true},
+		{name: "grace period without soft", config: k3d.KubeletConfig{EvictionSoftGracePeriod: map[string]string{"memory.available": "1m"}}, wantErr: true},
+		{
+			name: "invalid grace period",
+			config: k3d.KubeletConfig{
+				EvictionSoft:            map[string]string{"memory.available": "500Mi"},
+				EvictionSoftGracePeriod: map[string]string{"memory.available": "soon"},
+			},
+			wantErr: true,
+		},
+		{name: "invalid arg", config: k3d.KubeletConfig{Args: []string{"max-pods 250"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := KubeletConfigArgs(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("KubeletConfigArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("KubeletConfigArgs() = %v, want %v", args, tt.expected)
+			}
+		})
+	}
+}
@@ -22,11 +22,15 @@ THE SOFTWARE.
 package client
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,8 +39,10 @@ import (
 	"github.com/imdario/mergo"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
 	"github.com/rancher/k3d/v5/pkg/types"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 )
@@ -68,6 +74,7 @@ func UpdateLoadbalancerConfig(ctx context.Context, runtime runtimes.Runtime, clu
 	if err != nil {
 		return fmt.Errorf("error generating new loadbalancer config: %w", err)
 	}
+	newLBConfig.Settings.APIAccess = currentConfig.Settings.APIAccess // keep the API access restrictions
 	l.Log().Tracef("New loadbalancer config:\n%+v", currentConfig)
 
 	if diff := deep.Equal(currentConfig, newLBConfig); diff != nil {
@@ -105,10 +112,10 @@ func UpdateLoadbalancerConfig(ctx context.Context, runtime runtimes.Runtime, clu
 		}
 	}
 	l.Log().Infof("Successfully configured loadbalancer %s!", cluster.ServerLoadBalancer.Node.Name)
+	ClusterEventRecord(cluster.Name, k3d.ClusterEventLoadbalancerUpdated, cluster.ServerLoadBalancer.Node.Name, "Loadbalancer configuration regenerated")
 
-	time.Sleep(1 * time.Second) // waiting for a second, to avoid issues with too fast lb updates which would screw up the log waits
-
-	return nil
+	// waiting for a second, to avoid issues with too fast lb updates which would screw up the log waits
+	return util.SleepWithContext(ctx, 1*time.Second)
 }
 
 func GetLoadbalancerConfig(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster) (types.LoadbalancerConfig, error) {
@@ -166,14 +173,18 @@ func LoadbalancerGenerateConfig(cluster *k3d.Cluster) (k3d.LoadbalancerConfig, e
 	lbConfig.Ports[fmt.Sprintf("%s.tcp", k3d.DefaultAPIPort)] = servers
 
 	// generate comma-separated list of extra ports to forward // TODO: no default targets?
-	for exposedPort := range cluster.ServerLoadBalancer.Node.Ports {
-		// TODO: catch duplicates here?
-		lbConfig.Ports[fmt.Sprintf("%s.%s", exposedPort.Port(), exposedPort.Proto())] = servers
+	for _, portconfig := range loadbalancerPortConfigKeys(cluster.ServerLoadBalancer.Node.Ports) {
+		lbConfig.Ports[portconfig] = servers
 	}
 
 	// some additional nginx settings
 	lbConfig.Settings.WorkerConnections = k3d.DefaultLoadbalancerWorkerConnections + len(cluster.ServerLoadBalancer.Node.Ports)*len(servers)
 
+	// keep the API access restrictions, as they're not derived from the nodes
+	if cluster.ServerLoadBalancer.Config != nil {
+		lbConfig.Settings.APIAccess = cluster.ServerLoadBalancer.Config.Settings.APIAccess
+	}
+
 	return lbConfig, nil
 }
 
@@ -213,7 +224,7 @@ func LoadbalancerPrepare(ctx context.Context, runtime runtimes.Runtime, cluster
 
 	// Create LB as a modified node with loadbalancerRole
 	lbNode := &k3d.Node{
-		Name:          fmt.Sprintf("%s-%s-serverlb", k3d.DefaultObjectNamePrefix, cluster.Name),
+		Name:          fmt.Sprintf("%s-%s-serverlb", k3d.ObjectNamePrefix, cluster.Name),
 		Image:         k3d.GetLoadbalancerImage(),
 		Ports:         cluster.ServerLoadBalancer.Node.Ports,
 		Role:          k3d.LoadBalancerRole,
@@ -226,8 +237,11 @@ func LoadbalancerPrepare(ctx context.Context, runtime runtimes.Runtime, cluster
 
 }
 
-func loadbalancerAddPortConfigs(loadbalancer *k3d.Loadbalancer, portmapping nat.PortMapping, targetNodes []*k3d.Node) error {
-	portconfig := fmt.Sprintf("%s.%s", portmapping.Port.Port(), portmapping.Port.Proto())
+func loadbalancerAddPortConfigs(loadbalancer *k3d.Loadbalancer, portconfig string, targetNodes []*k3d.Node) error {
+	start, end, proto, err := LoadbalancerParsePortConfig(portconfig)
+	if err != nil {
+		return err
+	}
 	nodenames := []string{}
 	for _, node := range targetNodes {
 		if node.Role == k3d.LoadBalancerRole {
@@ -236,6 +250,20 @@ func loadbalancerAddPortConfigs(loadbalancer *k3d.Loadbalancer, portmapping nat.
 		nodenames = append(nodenames, node.Name)
 	}
 
+	// nginx can't listen on the same port twice, so port ranges must not overlap other port configs
+	for existing := range loadbalancer.Config.Ports {
+		if existing == portconfig {
+			continue
+		}
+		existingStart, existingEnd, existingProto, err := LoadbalancerParsePortConfig(existing)
+		if err != nil {
+			return err
+		}
+		if proto == existingProto && start <= existingEnd && existingStart <= end {
+			return fmt.Errorf("port config %s overlaps the existing port config %s of the loadbalancer", portconfig, existing)
+		}
+	}
+
 	// entry for that port doesn't exist yet, so we simply create it with the list of node names
 	if _, ok := loadbalancer.Config.Ports[portconfig]; !ok {
 		loadbalancer.Config.Ports[portconfig] = nodenames
@@ -244,13 +272,155 @@ func loadbalancerAddPortConfigs(loadbalancer *k3d.Loadbalancer, portmapping nat.
 
 nodenameLoop:
 	for _, nodename := range nodenames {
-		for _, existingNames := range loadbalancer.Config.Ports[portconfig] {
-			if nodename == existingNames {
+		for _, existingName := range loadbalancer.Config.Ports[portconfig] {
+			if nodename == existingName {
 				continue nodenameLoop
 			}
-			loadbalancer.Config.Ports[portconfig] = append(loadbalancer.Config.Ports[portconfig], nodename)
 		}
+		loadbalancer.Config.Ports[portconfig] = append(loadbalancer.Config.Ports[portconfig], nodename)
 	}
 
 	return nil
 }
+
+// loadbalancerPortConfig returns the loadbalancer port config (PORT[-PORT].PROTOCOL) for the port mappings parsed from a single port spec:
+// a range of container ports (e.g. 30000-32767 for all NodePorts) is proxied as a whole instead of creating a config for each port
+func loadbalancerPortConfig(portmappings []nat.PortMapping) string {
+	first, last := portmappings[0].Port, portmappings[len(portmappings)-1].Port
+	if first.Int() == last.Int() {
+		return fmt.Sprintf("%s.%s", first.Port(), first.Proto())
+	}
+	return fmt.Sprintf("%s-%s.%s", first.Port(), last.Port(), first.Proto())
+}
+
+// loadbalancerPortConfigKeys returns the loadbalancer port configs for the ports exposed by the loadbalancer (except for the API port),
+// merging consecutive ports into ranges, so that exposed port ranges are proxied as a whole again
+func loadbalancerPortConfigKeys(ports nat.PortMap) []string {
+	portsByProto := map[string][]int{}
+	for port := range ports {
+		if port.Proto() == "tcp" && port.Port() == k3d.DefaultAPIPort {
+			continue
+		}
+		portsByProto[port.Proto()] = append(portsByProto[port.Proto()], port.Int())
+	}
+
+	keys := []string{}
+	for proto, portNumbers := range portsByProto {
+		sort.Ints(portNumbers)
+		start := portNumbers[0]
+		for i, port := range portNumbers {
+			if i+1 < len(portNumbers) && portNumbers[i+1] == port+1 {
+				continue
+			}
+			if start == port {
+				keys = append(keys, fmt.Sprintf("%d.%s", port, proto))
+			} else {
+				keys = append(keys, fmt.Sprintf("%d-%d.%s", start, port, proto))
+			}
+			if i+1 < len(portNumbers) {
+				start = portNumbers[i+1]
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoadbalancerParsePortConfig parses a loadbalancer port config (PORT[-PORT].PROTOCOL) into its port range and protocol
+func LoadbalancerParsePortConfig(portconfig string) (int, int, string, error) {
+	split := strings.SplitN(portconfig, ".", 2)
+	if len(split) != 2 {
+		return 0, 0, "", fmt.Errorf("invalid loadbalancer port config '%s': must be PORT[-PORT].PROTOCOL", portconfig)
+	}
+	ports := strings.SplitN(split[0], "-", 2)
+	start, err := strconv.Atoi(ports[0])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid loadbalancer port config '%s': %w", portconfig, err)
+	}
+	end := start
+	if len(ports) == 2 {
+		if end, err = strconv.Atoi(ports[1]); err != nil {
+			return 0, 0, "", fmt.Errorf("invalid loadbalancer port config '%s': %w", portconfig, err)
+		}
+		if end < start {
+			return 0, 0, "", fmt.Errorf("invalid loadbalancer port config '%s': end of the port range is lower than its start", portconfig)
+		}
+	}
+	return start, end, split[1], nil
+}
+
+// loadbalancerPrepareAPIAccess copies the certificates required to verify client certificates on the API port (k3d.LoadBalancerAPIAccess)
+// from a running server node into the loadbalancer. It runs whenever the loadbalancer starts, so that it picks up renewed certificates.
+func loadbalancerPrepareAPIAccess(ctx context.Context, runtime runtimes.Runtime, lbNode *k3d.Node) error {
+	lbConfig, err := GetLoadbalancerConfig(ctx, runtime, &k3d.Cluster{ServerLoadBalancer: &k3d.Loadbalancer{Node: lbNode}})
+	if err != nil {
+		if errors.Is(err, runtimeErrors.ErrRuntimeFileNotFound) {
+			return nil // not configured (yet)
+		}
+		return err
+	}
+	if !lbConfig.Settings.APIAccess.ClientCertificates {
+		return nil
+	}
+
+	clusterName := lbNode.RuntimeLabels[k3d.LabelClusterName]
+	if clusterName == "" {
+		node, err := NodeGet(ctx, runtime, lbNode)
+		if err != nil {
+			return fmt.Errorf("failed to get loadbalancer node '%s': %w", lbNode.Name, err)
+		}
+		clusterName = node.RuntimeLabels[k3d.LabelClusterName]
+	}
+
+	servers, err := runtime.GetNodesByLabel(ctx, map[string]string{k3d.LabelClusterName: clusterName, k3d.LabelRole: string(k3d.ServerRole)})
+	if err != nil {
+		return fmt.Errorf("failed to get server nodes of cluster '%s': %w", clusterName, err)
+	}
+	var server *k3d.Node
+	for _, node := range servers {
+		if node.State.Running {
+			server = node
+			break
+		}
+	}
+	if server == nil {
+		return fmt.Errorf("no running server node in cluster '%s' to get the API certificates from", clusterName)
+	}
+
+	files := make([]string, 0, len(k3d.LoadbalancerAPICerts))
+	for file := range k3d.LoadbalancerAPICerts {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	l.Log().Debugf("Copying API certificates from server '%s' into loadbalancer '%s'...", server.Name, lbNode.Name)
+	for _, file := range files {
+		content, err := readFileFromNode(ctx, runtime, server, k3d.LoadbalancerAPICerts[file])
+		if err != nil {
+			return err
+		}
+		if err := runtime.WriteToNode(ctx, content, path.Join(k3d.DefaultLoadbalancerAPICertsDir, file), 0600, lbNode); err != nil {
+			return fmt.Errorf("failed to write '%s' to loadbalancer '%s': %w", file, lbNode.Name, err)
+		}
+	}
+	return nil
+}
+
+// readFileFromNode returns the content of a single file in a node
+func readFileFromNode(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, file string) ([]byte, error) {
+	reader, err := runtime.ReadFromNode(ctx, file, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s' from node '%s': %w", file, node.Name, err)
+	}
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+	if _, err := tarReader.Next(); err != nil {
+		return nil, fmt.Errorf("failed to read '%s' from node '%s': %w", file, node.Name, err)
+	}
+	content, err := io.ReadAll(tarReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s' from node '%s': %w", file, node.Name, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestLoadbalancerParsePortConfig(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantStart int
+		wantEnd   int
+		wantProto string
+		wantErr   bool
+	}{
+		{input: "80.tcp", wantStart: 80, wantEnd: 80, wantProto: "tcp"},
+		{input: "30000-30100.udp", wantStart: 30000, wantEnd: 30100, wantProto: "udp"},
+		{input: "80", wantErr: true},
+		{input: "http.tcp", wantErr: true},
+		{input: "30100-30000.tcp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			start, end, proto, err := LoadbalancerParsePortConfig(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadbalancerParsePortConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if start != tt.wantStart || end != tt.wantEnd || proto != tt.wantProto {
+				t.Errorf("LoadbalancerParsePortConfig() = %d, %d, %s, want %d, %d, %s", start, end, proto, tt.wantStart, tt.wantEnd, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestLoadbalancerPortConfigKeys(t *testing.T) {
+	ports := nat.PortMap{}
+	for _, port := range []string{"6443/tcp", "80/tcp", "30000/tcp", "30001/tcp", "30002/tcp", "30001/udp", "30005/tcp"} {
+		ports[nat.Port(port)] = nil
+	}
+	expected := []string{"30000-30002.tcp", "30001.udp", "30005.tcp", "80.tcp"}
+	if keys := loadbalancerPortConfigKeys(ports); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("loadbalancerPortConfigKeys() = %v, want %v", keys, expected)
+	}
+}
+
+func TestLoadbalancerAddPortConfigs(t *testing.T) {
+	server := &k3d.Node{Name: "k3d-test-server-0", Role: k3d.ServerRole}
+	agent := &k3d.Node{Name: "k3d-test-agent-0", Role: k3d.AgentRole}
+	lb := &k3d.Loadbalancer{Config: &k3d.LoadbalancerConfig{Ports: map[string][]string{}}}
+
+	if err := loadbalancerAddPortConfigs(lb, "30000-30100.tcp", []*k3d.Node{server}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loadbalancerAddPortConfigs(lb, "30000-30100.tcp", []*k3d.Node{server, agent}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loadbalancerAddPortConfigs(lb, "30050.udp", []*k3d.Node{agent}); err != nil {
+		t.Fatalf("unexpected error for the same port with another protocol: %v", err)
+	}
+	if err := loadbalancerAddPortConfigs(lb, "30050.tcp", []*k3d.Node{agent}); err == nil {
+		t.Errorf("expected an error for a port config overlapping an existing range")
+	}
+	if err := loadbalancerAddPortConfigs(lb, "30100-30200.tcp", []*k3d.Node{agent}); err == nil {
+		t.Errorf("expected an error for a port range overlapping an existing range")
+	}
+	if err := loadbalancerAddPortConfigs(lb, "8080.tcp", []*k3d.Node{{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole}}); err == nil {
+		t.Errorf("expected an error for a port config targeting the loadbalancer itself")
+	}
+
+	expected := map[string][]string{
+		"30000-30100.tcp": {server.Name, agent.Name},
+		"30050.udp":       {agent.Name},
+	}
+	if !reflect.DeepEqual(lb.Config.Ports, expected) {
+		t.Errorf("loadbalancer port configs = %v, want %v", lb.Config.Ports, expected)
+	}
+}
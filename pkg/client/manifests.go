@@ -0,0 +1,163 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+)
+
+// ManifestExtensions are the file extensions of manifests, which k3s auto-deploys from its manifests directory
+var ManifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// ManifestDownloadTimeout is the timeout for downloading a manifest referenced by URL
+var ManifestDownloadTimeout = 30 * time.Second
+
+// Manifest is a Kubernetes manifest file to be auto-deployed by k3s
+type Manifest struct {
+	Name    string // file name in the manifests directory
+	Source  string // path or URL it was loaded from
+	Content []byte
+}
+
+// ManifestsLoad loads the manifests from the given sources, which may be files, directories (all manifest files directly in it) or http(s) URLs
+func ManifestsLoad(ctx context.Context, sources []string) ([]Manifest, error) {
+	manifests := []Manifest{}
+	names := map[string]string{}
+	for _, source := range sources {
+		loaded, err := manifestsLoadSource(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		for _, manifest := range loaded {
+			if other, ok := names[manifest.Name]; ok {
+				return nil, fmt.Errorf("manifests '%s' and '%s' have the same file name '%s'", other, manifest.Source, manifest.Name)
+			}
+			names[manifest.Name] = manifest.Source
+			manifests = append(manifests, manifest)
+		}
+	}
+	return manifests, nil
+}
+
+// manifestsLoadSource loads the manifest(s) from a single file, directory or URL
+func manifestsLoadSource(ctx context.Context, source string) ([]Manifest, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		manifest, err := manifestDownload(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return []Manifest{*manifest}, nil
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find manifest '%s': %w", source, err)
+	}
+
+	files := []string{source}
+	if info.IsDir() {
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest directory '%s': %w", source, err)
+		}
+		files = []string{}
+		for _, entry := range entries {
+			if !entry.IsDir() && isManifestFile(entry.Name()) {
+				files = append(files, filepath.Join(source, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			l.Log().Warnf("No manifests (%s) found in directory '%s'", strings.Join(ManifestExtensions, ", "), source)
+		}
+		sort.Strings(files)
+	} else if !isManifestFile(source) {
+		return nil, fmt.Errorf("manifest '%s' must have one of the file extensions %s to be deployed by k3s", source, strings.Join(ManifestExtensions, ", "))
+	}
+
+	manifests := []Manifest{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest '%s': %w", file, err)
+		}
+		manifests = append(manifests, Manifest{Name: filepath.Base(file), Source: file, Content: content})
+	}
+	return manifests, nil
+}
+
+// manifestDownload downloads a manifest, which is named after the last element of the URL path (.yaml is appended, if it's no manifest file name)
+func manifestDownload(ctx context.Context, source string) (*Manifest, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest URL '%s': %w", source, err)
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = u.Hostname()
+	}
+	if !isManifestFile(name) {
+		name += ".yaml"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ManifestDownloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for manifest '%s': %w", source, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest '%s': %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to download manifest '%s': status %s", source, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest '%s': %w", source, err)
+	}
+	l.Log().Debugf("Downloaded manifest '%s' (%d bytes)", source, len(content))
+	return &Manifest{Name: name, Source: source, Content: content}, nil
+}
+
+func isManifestFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, manifestExt := range ManifestExtensions {
+		if ext == manifestExt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestsLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"crds.yaml":       "kind: CustomResourceDefinition",
+		"ingress.yml":     "kind: Deployment",
+		"README.md":       "not a manifest",
+		"other/skip.yaml": "kind: Namespace",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("kind: ConfigMap"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		sources []string
+		want    map[string]string // name -> content
+		wantErr bool
+	}{
+		{
+			name:    "directory",
+			sources: []string{dir},
+			want:    map[string]string{"crds.yaml": "kind: CustomResourceDefinition", "ingress.yml": "kind: Deployment"},
+		},
+		{
+			name:    "file and URLs",
+			sources: []string{filepath.Join(dir, "other", "skip.yaml"), server.URL + "/deploy/cm.json", server.URL + "/latest/download"},
+			want:    map[string]string{"skip.yaml": "kind: Namespace", "cm.json": "kind: ConfigMap", "download.yaml": "kind: ConfigMap"},
+		},
+		{name: "no manifest file", sources: []string{filepath.Join(dir, "README.md")}, wantErr: true},
+		{name: "not found", sources: []string{filepath.Join(dir, "nope.yaml")}, wantErr: true},
+		{name: "URL not found", sources: []string{server.URL + "/missing.yaml"}, wantErr: true},
+		{name: "duplicate name", sources: []string{dir, server.URL + "/crds.yaml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := ManifestsLoad(context.Background(), tt.sources)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, manifest := range manifests {
+				got[manifest.Name] = string(manifest.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ManifestsLoad() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
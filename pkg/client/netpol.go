@@ -0,0 +1,261 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// netpol test outcomes
+const (
+	netpolReachable = "reachable"
+	netpolBlocked   = "blocked"
+)
+
+// netpolTestCase is a single check of the network policy test suite: a client pod with the given role tries to reach the target via HTTP
+type netpolTestCase struct {
+	Name        string
+	Description string
+	Namespace   string
+	Role        string
+	Target      string
+	Expected    string
+}
+
+// netpolTestCases are the checks of the network policy test suite (see netpolTestManifestTemplate for the policies)
+var netpolTestCases = []netpolTestCase{
+	{Name: "baseline", Description: "pods without policies accept traffic from anywhere", Namespace: k3d.DefaultNetpolTestNamespace, Role: "denied", Target: "open", Expected: netpolReachable},
+	{Name: "ingress-allowed", Description: "ingress policy allows traffic from selected pods", Namespace: k3d.DefaultNetpolTestNamespace, Role: "allowed", Target: "protected", Expected: netpolReachable},
+	{Name: "ingress-denied", Description: "ingress policy blocks traffic from other pods", Namespace: k3d.DefaultNetpolTestNamespace, Role: "denied", Target: "protected", Expected: netpolBlocked},
+	{Name: "ingress-other-namespace", Description: "pod selectors of ingress policies only match pods in the same namespace", Namespace: k3d.DefaultNetpolTestOtherNamespace, Role: "allowed", Target: "protected", Expected: netpolBlocked},
+	{Name: "egress-denied", Description: "egress policy blocks traffic to other pods (except DNS)", Namespace: k3d.DefaultNetpolTestNamespace, Role: "egress-denied", Target: "open", Expected: netpolBlocked},
+}
+
+var netpolTestManifestTemplate = template.Must(template.New("netpol-test").Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .OtherNamespace }}
+{{- range $server := .Servers }}
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ $server }}
+  namespace: {{ $.Namespace }}
+  labels:
+    app: {{ $server }}
+spec:
+  containers:
+    - name: httpd
+      image: {{ $.Image }}
+      command: ["sh", "-c", "mkdir -p /www && echo ok > /www/index.html && exec httpd -f -p 8080 -h /www"]
+      ports:
+        - containerPort: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ $server }}
+  namespace: {{ $.Namespace }}
+spec:
+  selector:
+    app: {{ $server }}
+  ports:
+    - port: 80
+      targetPort: 8080
+{{- end }}
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: protected-allow-selected
+  namespace: {{ .Namespace }}
+spec:
+  podSelector:
+    matchLabels:
+      app: protected
+  policyTypes:
+    - Ingress
+  ingress:
+    - from:
+        - podSelector:
+            matchLabels:
+              role: allowed
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: egress-denied-dns-only
+  namespace: {{ .Namespace }}
+spec:
+  podSelector:
+    matchLabels:
+      role: egress-denied
+  policyTypes:
+    - Egress
+  egress:
+    - ports:
+        - port: 53
+          protocol: UDP
+        - port: 53
+          protocol: TCP
+{{- range $case := .Cases }}
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: netpol-{{ $case.Name }}
+  namespace: {{ $case.Namespace }}
+spec:
+  backoffLimit: 0
+  template:
+    metadata:
+      labels:
+        role: {{ $case.Role }}
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: check
+          image: {{ $.Image }}
+          env:
+            - name: TARGET
+              value: http://{{ $case.Target }}.{{ $.Namespace }}
+            - name: EXPECTED
+              value: {{ $case.Expected }}
+          # policies are enforced asynchronously, so retry until the expected outcome shows up (or give up)
+          command:
+            - sh
+            - -c
+            - |
+              for i in $(seq 1 {{ $.Attempts }}); do
+                if wget -q -T 3 -O /dev/null "$TARGET"; then observed={{ $.Reachable }}; else observed={{ $.Blocked }}; fi
+                [ "$observed" = "$EXPECTED" ] && break
+                sleep 5
+              done
+              echo "$observed"
+{{- end }}
+`))
+
+// netpolTestManifest returns the manifest of the network policy test suite: servers, policies and one job per check
+func netpolTestManifest(image string) (string, error) {
+	var buf bytes.Buffer
+	if err := netpolTestManifestTemplate.Execute(&buf, map[string]interface{}{
+		"Namespace":      k3d.DefaultNetpolTestNamespace,
+		"OtherNamespace": k3d.DefaultNetpolTestOtherNamespace,
+		"Servers":        []string{"open", "protected"},
+		"Image":          image,
+		"Cases":          netpolTestCases,
+		"Attempts":       12,
+		"Reachable":      netpolReachable,
+		"Blocked":        netpolBlocked,
+	}); err != nil {
+		return "", fmt.Errorf("failed to render network policy test manifest: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ClusterNetpolTest deploys the network policy test suite into the cluster, waits for all of its checks to report and returns their results.
+// It's run with kubectl inside a server node, so no kubeconfig is required. Previous runs get cleaned up first.
+func ClusterNetpolTest(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, opts k3d.NetpolTestOpts) ([]k3d.NetpolTestResult, error) {
+	if opts.Image == "" {
+		opts.Image = k3d.DefaultNetpolTestImage
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = k3d.DefaultNetpolTestTimeout
+	}
+
+	manifest, err := netpolTestManifest(opts.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := clusterRunningServer(ctx, runtime, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Log().Infof("Deploying network policy test suite to namespace '%s'...", k3d.DefaultNetpolTestNamespace)
+	if err := runtime.ExecInNode(ctx, node, []string{"kubectl", "delete", "namespace", k3d.DefaultNetpolTestNamespace, k3d.DefaultNetpolTestOtherNamespace, "--ignore-not-found", "--wait=true"}); err != nil {
+		return nil, fmt.Errorf("failed to clean up previous network policy test run: %w", err)
+	}
+	if err := runtime.WriteToNode(ctx, []byte(manifest), k3d.DefaultNetpolTestManifestTempPath, 0644, node); err != nil {
+		return nil, fmt.Errorf("failed to write network policy test manifest: %w", err)
+	}
+	if err := runtime.ExecInNode(ctx, node, []string{"kubectl", "apply", "-f", k3d.DefaultNetpolTestManifestTempPath}); err != nil {
+		return nil, fmt.Errorf("failed to apply network policy test manifest: %w", err)
+	}
+	if !opts.Keep {
+		defer func() {
+			// don't wait for the namespaces to be gone, the next run does that
+			if err := runtime.ExecInNode(context.Background(), node, []string{"kubectl", "delete", "namespace", k3d.DefaultNetpolTestNamespace, k3d.DefaultNetpolTestOtherNamespace, "--wait=false"}); err != nil {
+				l.Log().Warnf("Failed to clean up network policy test namespaces: %v", err)
+			}
+		}()
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	results := []k3d.NetpolTestResult{}
+	for _, c := range netpolTestCases {
+		l.Log().Infof("Waiting for network policy check '%s'...", c.Name)
+		result := k3d.NetpolTestResult{Name: c.Name, Description: c.Description, Expected: c.Expected}
+		if err := WaitForKubernetesCondition(waitCtx, runtime, cluster, c.Namespace, "job", "netpol-"+c.Name, "Complete"); err != nil {
+			l.Log().Warnf("Network policy check '%s' didn't complete: %v", c.Name, err)
+		} else {
+			output, err := clusterExecInServer(ctx, runtime, cluster, []string{"kubectl", "logs", "job/netpol-" + c.Name, "--namespace", c.Namespace})
+			if err != nil {
+				l.Log().Warnf("Failed to get the result of network policy check '%s': %v", c.Name, err)
+			}
+			result.Observed = netpolParseObserved(output)
+		}
+		result.Passed = result.Observed == result.Expected
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// netpolParseObserved returns the outcome reported in the last line of a check's logs
+func netpolParseObserved(logs string) string {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	switch last := strings.TrimSpace(lines[len(lines)-1]); last {
+	case netpolReachable, netpolBlocked:
+		return last
+	default:
+		return ""
+	}
+}
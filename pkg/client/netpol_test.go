@@ -0,0 +1,84 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestNetpolTestManifest(t *testing.T) {
+	manifest, err := netpolTestManifest("busybox:test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]int{}
+	jobs := map[string]bool{}
+	for _, doc := range strings.Split(manifest, "\n---\n") {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			t.Fatalf("invalid YAML document: %v\n%s", err, doc)
+		}
+		kind, _ := obj["kind"].(string)
+		kinds[kind]++
+		if kind == "Job" {
+			metadata := obj["metadata"].(map[string]interface{})
+			jobs[metadata["name"].(string)] = true
+		}
+	}
+
+	want := map[string]int{"Namespace": 2, "Pod": 2, "Service": 2, "NetworkPolicy": 2, "Job": len(netpolTestCases)}
+	for kind, count := range want {
+		if kinds[kind] != count {
+			t.Errorf("expected %d objects of kind %s, got %d", count, kind, kinds[kind])
+		}
+	}
+	for _, c := range netpolTestCases {
+		if !jobs["netpol-"+c.Name] {
+			t.Errorf("expected a job for check '%s'", c.Name)
+		}
+	}
+	if !strings.Contains(manifest, "image: busybox:test") {
+		t.Errorf("expected the manifest to use the given image")
+	}
+}
+
+func TestNetpolParseObserved(t *testing.T) {
+	tests := []struct {
+		logs string
+		want string
+	}{
+		{logs: "reachable\n", want: "reachable"},
+		{logs: "wget: download timed out\nblocked\n", want: "blocked"},
+		{logs: "", want: ""},
+		{logs: "something else", want: ""},
+	}
+	for _, tt := range tests {
+		if got := netpolParseObserved(tt.logs); got != tt.want {
+			t.Errorf("netpolParseObserved(%q) = %q, want %q", tt.logs, got, tt.want)
+		}
+	}
+}
@@ -30,7 +30,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -48,6 +51,7 @@ import (
 	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
 
 	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/pkg/types/fixes"
 	"github.com/rancher/k3d/v5/pkg/types/k3s"
@@ -56,9 +60,12 @@ import (
 )
 
 // NodeAddToCluster adds a node to an existing cluster
-func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, cluster *k3d.Cluster, createNodeOpts k3d.NodeCreateOpts) error {
+func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, cluster *k3d.Cluster, createNodeOpts k3d.NodeCreateOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "NodeAddToCluster", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
+
 	targetClusterName := cluster.Name
-	cluster, err := ClusterGet(ctx, runtime, cluster)
+	cluster, err = ClusterGet(ctx, runtime, cluster)
 	if err != nil {
 		return fmt.Errorf("Failed to find specified cluster '%s': %w", targetClusterName, err)
 	}
@@ -81,12 +88,14 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 			k3d.LabelRole: string(node.Role),
 		}
 	}
+	// env vars are copied from the source node below, additional ones specified for the new node are appended afterwards
+	extraEnv := node.Env
 	node.Env = []string{}
 
-	// copy labels and env vars from a similar node in the selected cluster
+	// copy labels and env vars from a similar node in the selected cluster (simulated nodes of another OS are no blueprint for regular nodes)
 	var srcNode *k3d.Node
 	for _, existingNode := range cluster.Nodes {
-		if existingNode.Role == node.Role {
+		if existingNode.Role == node.Role && !nodeIsSimulatedOS(existingNode) {
 			srcNode = existingNode
 			break
 		}
@@ -96,7 +105,7 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 		l.Log().Debugf("Didn't find node with role '%s' in cluster '%s'. Choosing any other node (and using defaults)...", node.Role, cluster.Name)
 		node.Cmd = k3d.DefaultRoleCmds[node.Role]
 		for _, existingNode := range cluster.Nodes {
-			if existingNode.Role == k3d.AgentRole || existingNode.Role == k3d.ServerRole { // only K3s nodes
+			if (existingNode.Role == k3d.AgentRole || existingNode.Role == k3d.ServerRole) && !nodeIsSimulatedOS(existingNode) { // only K3s nodes
 				srcNode = existingNode
 				break
 			}
@@ -117,7 +126,9 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 	// sanitize fields that mismatch between roles
 	if srcNode.Role != node.Role {
 		l.Log().Debugf("Dropping some fields from source node because it's not of the same role (%s != %s)...", srcNode.Role, node.Role)
-		srcNode.Memory = "" // memory settings are scoped per role (--servers-memory/--agents-memory)
+		srcNode.CPUs = ""         // same for cpu limits (e.g. divided cluster cpu limits)
+		srcNode.Memory = ""       // memory settings are scoped per role (--servers-memory/--agents-memory)
+		srcNode.RuntimeOpts = nil // e.g. cpusets, which pin the nodes of one role to specific CPUs
 	}
 
 	// TODO: I guess proper deduplication can be handled in a cleaner/better way or at the infofaker level at some point
@@ -175,12 +186,22 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 		registryConfigBytes = bytes.Trim(registryConfigBytes[512:], "\x00") // trim control characters, etc.
 	}
 
+	// fetch the timezone, if it was set on cluster creation
+	var timezoneBytes []byte
+	if timezone, ok := srcNode.RuntimeLabels[k3d.LabelClusterTimezone]; ok && (node.Role == k3d.ServerRole || node.Role == k3d.AgentRole) {
+		timezoneBytes, err = TimezoneReadFromNode(ctx, runtime, srcNode)
+		if err != nil {
+			l.Log().Warnf("Failed to copy the timezone %s to node %s: %v", timezone, node.Name, err)
+		}
+	}
+
 	// merge node config of new node into existing node config
 	if err := mergo.MergeWithOverwrite(srcNode, *node); err != nil {
 		return fmt.Errorf("failed to merge new node config into existing node config: %w", err)
 	}
 
 	node = srcNode
+	node.Env = append(node.Env, extraEnv...)
 
 	l.Log().Tracef("Resulting node %+v", node)
 
@@ -230,6 +251,10 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 		)
 	}
 
+	if len(timezoneBytes) != 0 {
+		createNodeOpts.NodeHooks = append(createNodeOpts.NodeHooks, TimezoneNodeHook(runtime, node.RuntimeLabels[k3d.LabelClusterTimezone], timezoneBytes))
+	}
+
 	if cluster.Network.Name != "host" {
 		// add host.k3d.internal to /etc/hosts
 		createNodeOpts.NodeHooks = append(createNodeOpts.NodeHooks,
@@ -255,6 +280,7 @@ func NodeAddToCluster(ctx context.Context, runtime runtimes.Runtime, node *k3d.N
 	if err := NodeRun(ctx, runtime, node, createNodeOpts); err != nil {
 		return fmt.Errorf("failed to run node '%s': %w", node.Name, err)
 	}
+	ClusterEventRecord(cluster.Name, k3d.ClusterEventNodeAdded, node.Name, fmt.Sprintf("Added %s node '%s'", node.Role, node.Name))
 
 	// if it's a server node, then update the loadbalancer configuration
 	if node.Role == k3d.ServerRole {
@@ -395,7 +421,9 @@ func NodeRun(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, node
 }
 
 // NodeStart starts an existing node
-func NodeStart(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, nodeStartOpts *k3d.NodeStartOpts) error {
+func NodeStart(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, nodeStartOpts *k3d.NodeStartOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "NodeStart", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.node.role", string(node.Role)))
+	defer func() { span.End(err) }()
 
 	// return early, if the node is already running
 	if node.State.Running {
@@ -403,6 +431,13 @@ func NodeStart(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, no
 		return nil
 	}
 
+	if nodeStartOpts.Timeout > 0*time.Second {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, nodeStartOpts.Timeout)
+		defer cancel()
+		l.Log().Debugf("Node %s has to start within %s", node.Name, nodeStartOpts.Timeout)
+	}
+
 	if err := enableFixes(ctx, runtime, node, nodeStartOpts); err != nil {
 		return fmt.Errorf("failed to enable k3d fixes: %w", err)
 	}
@@ -420,6 +455,13 @@ func NodeStart(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, no
 		}
 	}
 
+	// the loadbalancer needs the cluster's certificates to verify client certificates on the API port
+	if node.Role == k3d.LoadBalancerRole {
+		if err := loadbalancerPrepareAPIAccess(ctx, runtime, node); err != nil {
+			return fmt.Errorf("failed to prepare API access restrictions of loadbalancer '%s': %w", node.Name, err)
+		}
+	}
+
 	// start the node
 	l.Log().Tracef("Starting node '%s'", node.Name)
 
@@ -442,7 +484,7 @@ func NodeStart(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, no
 		}
 		if nodeStartOpts.ReadyLogMessage != "" {
 			l.Log().Debugf("Waiting for node %s to get ready (Log: '%s')", node.Name, nodeStartOpts.ReadyLogMessage)
-			if err := NodeWaitForLogMessage(ctx, runtime, node, nodeStartOpts.ReadyLogMessage, startTime); err != nil {
+			if err := NodeWaitForReady(ctx, runtime, node, nodeStartOpts.ReadyLogMessage, startTime); err != nil {
 				return fmt.Errorf("Node %s failed to get ready: %+v", node.Name, err)
 			}
 		} else {
@@ -472,7 +514,7 @@ func enableFixes(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node,
 		EnableCgroupV2FixIfNeeded(runtime)
 
 		// early exit if we don't need any fix
-		if !fixes.FixEnabledAny() {
+		if !fixes.FixEnabledAnyForNode(node) {
 			l.Log().Debugln("No fix enabled.")
 			return nil
 		}
@@ -522,6 +564,27 @@ func enableFixes(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node,
 			})
 		}
 
+		// Inotify Fix
+		if fixes.FixEnabledForNode(fixes.EnvFixInotify, node) {
+			l.Log().Debugln(">>> enabling inotify magic")
+
+			data := strings.NewReplacer(
+				"MAX_USER_WATCHES", strconv.Itoa(k3d.DefaultInotifyMaxUserWatches),
+				"MAX_USER_INSTANCES", strconv.Itoa(k3d.DefaultInotifyMaxUserInstances),
+			).Replace(string(fixes.InotifyEntrypoint))
+
+			nodeStartOpts.NodeHooks = append(nodeStartOpts.NodeHooks, k3d.NodeHook{
+				Stage: k3d.LifecycleStagePreStart,
+				Action: actions.WriteFileAction{
+					Runtime:     runtime,
+					Content:     []byte(data),
+					Dest:        "/bin/k3d-entrypoint-inotify.sh",
+					Mode:        0744,
+					Description: "Write entrypoint script for inotify fix",
+				},
+			})
+		}
+
 		// CGroupsV2Fix
 		if fixes.FixEnabled(fixes.EnvFixCgroupV2) {
 			l.Log().Debugf(">>> enabling cgroupsv2 magic")
@@ -546,7 +609,10 @@ func enableFixes(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node,
 }
 
 // NodeCreate creates a new containerized k3s node
-func NodeCreate(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, createNodeOpts k3d.NodeCreateOpts) error {
+func NodeCreate(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, createNodeOpts k3d.NodeCreateOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "NodeCreate", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.node.role", string(node.Role)))
+	defer func() { span.End(err) }()
+
 	// FIXME: FixCgroupV2 - to be removed when fixed upstream
 	EnableCgroupV2FixIfNeeded(runtime)
 	l.Log().Tracef("Creating node from spec\n%+v", node)
@@ -560,6 +626,10 @@ func NodeCreate(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, c
 	// ### Labels ###
 	node.FillRuntimeLabels()
 
+	if err := nodePrepareOS(node); err != nil {
+		return err
+	}
+
 	for k, v := range node.K3sNodeLabels {
 		node.Args = append(node.Args, "--node-label", fmt.Sprintf("%s=%s", k, v))
 	}
@@ -620,10 +690,16 @@ func NodeCreate(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, c
 }
 
 // NodeDelete deletes an existing node
-func NodeDelete(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, opts k3d.NodeDeleteOpts) error {
+func NodeDelete(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, opts k3d.NodeDeleteOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "NodeDelete", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.node.role", string(node.Role)))
+	defer func() { span.End(err) }()
+
 	// delete node
 	if err := runtime.DeleteNode(ctx, node); err != nil {
 		l.Log().Error(err)
+	} else if !opts.SkipLBUpdate && (node.Role == k3d.ServerRole || node.Role == k3d.AgentRole) {
+		// (SkipLBUpdate is set when the node is deleted as part of a bigger operation, e.g. cluster deletion or node replacement)
+		ClusterEventRecord(node.RuntimeLabels[k3d.LabelClusterName], k3d.ClusterEventNodeDeleted, node.Name, fmt.Sprintf("Deleted %s node '%s'", node.Role, node.Name))
 	}
 
 	// delete fake folder created for limits
@@ -707,6 +783,28 @@ func NodeGet(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node) (*k3
 
 // NodeWaitForLogMessage follows the logs of a node container and returns if it finds a specific line in there (or timeout is reached)
 func NodeWaitForLogMessage(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, message string, since time.Time) error {
+	return nodeWaitForLogLine(ctx, runtime, node, message, func(line string) bool { return strings.Contains(line, message) }, since)
+}
+
+// NodeWaitForLogPattern follows the logs of a node container and returns if it finds a line matching the pattern in there (or timeout is reached)
+func NodeWaitForLogPattern(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, pattern *regexp.Regexp, since time.Time) error {
+	return nodeWaitForLogLine(ctx, runtime, node, pattern.String(), pattern.MatchString, since)
+}
+
+// nodeWaitForLogLineBackOff returns the exponentially growing time to wait before the given retry of following the logs of a crash-looping node
+func nodeWaitForLogLineBackOff(retry int) time.Duration {
+	backOff := k3d.DefaultNodeWaitForLogMessageCrashLoopBackOffInitial
+	for i := 0; i < retry; i++ {
+		backOff *= 2
+		if backOff >= k3d.DefaultNodeWaitForLogMessageCrashLoopBackOffMax {
+			return k3d.DefaultNodeWaitForLogMessageCrashLoopBackOffMax
+		}
+	}
+	return backOff
+}
+
+// nodeWaitForLogLine follows the logs of a node container and returns if it finds a line matching the given function (described by message)
+func nodeWaitForLogLine(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, message string, matches func(line string) bool, since time.Time) error {
 	l.Log().Tracef("NodeWaitForLogMessage: Node '%s' waiting for log message '%s' since '%+v'", node.Name, message, since)
 
 	// specify max number of retries if container is in crashloop (as defined by last seen message being a fatal log)
@@ -722,20 +820,21 @@ func NodeWaitForLogMessage(ctx context.Context, runtime runtimes.Runtime, node *
 	donechan := make(chan struct{})
 	defer close(donechan)
 	go func(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, since time.Time, donechan chan struct{}) {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-donechan:
 				return
-			default:
+			case <-ticker.C:
 			}
 			// check if the container is restarting
 			running, status, _ := runtime.GetNodeStatus(ctx, node)
 			if running && status == k3d.NodeStatusRestarting && time.Now().Sub(since) > k3d.NodeWaitForLogMessageRestartWarnTime {
 				l.Log().Warnf("Node '%s' is restarting for more than %s now. Possibly it will recover soon (e.g. when it's waiting to join). Consider using a creation timeout to avoid waiting forever in a Restart Loop.", node.Name, k3d.NodeWaitForLogMessageRestartWarnTime)
 			}
-			time.Sleep(500 * time.Millisecond)
 		}
 
 	}(ctx, runtime, node, since, donechan)
@@ -780,7 +879,7 @@ func NodeWaitForLogMessage(ctx context.Context, runtime runtimes.Runtime, node *
 				l.Log().Tracef(">>> Parsing log line: `%s`", scanner.Text())
 			}
 			// check if we can find the specified line in the log
-			if strings.Contains(scanner.Text(), message) {
+			if matches(scanner.Text()) {
 				l.Log().Tracef("Found target message `%s` in log line `%s`", message, scanner.Text())
 				l.Log().Debugf("Finished waiting for log message '%s' from node '%s'", message, node.Name)
 				return nil
@@ -796,12 +895,19 @@ func NodeWaitForLogMessage(ctx context.Context, runtime runtimes.Runtime, node *
 
 		out.Close() // no more input on scanner, but target log not yet found -> close current logreader (precautionary)
 
+		// the logstream also ends when the context is canceled or its deadline is exceeded
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped waiting for log message '%s' of node %s: %w", message, node.Name, err)
+		}
+
 		// we got here, because the logstream ended (no more input on scanner), so we check if maybe the container crashed
 		if strings.Contains(previousline, "level=fatal") {
 			// case 1: last log line we saw contained a fatal error, so probably it crashed and we want to retry on restart
 			l.Log().Warnf("warning: encountered fatal log from node %s (retrying %d/%d): %s", node.Name, i, backOffLimit, previousline)
 			out.Close()
-			time.Sleep(500 * time.Millisecond)
+			if err := util.SleepWithContext(ctx, nodeWaitForLogLineBackOff(i)); err != nil {
+				return fmt.Errorf("stopped waiting for log message '%s' of node %s: %w", message, node.Name, err)
+			}
 			continue
 		} else {
 			// case 2: last log line we saw did not contain a fatal error, so we break the loop here and return a generic error
@@ -846,16 +952,145 @@ nodeLoop:
 	return resultList
 }
 
-// NodeEdit let's you update an existing node
+// NodeEditChange describes a single change applied by NodeEdit
+type NodeEditChange struct {
+	Description string
+	Live        bool // applied to the running node, otherwise the node container is recreated (preserving its volumes)
+}
+
+// NodeEditChanges lists the changes of the changeset compared to the existing node and whether they can be applied live
+func NodeEditChanges(existingNode, changeset *k3d.Node) []NodeEditChange {
+	changes := []NodeEditChange{}
+
+	ports := []string{}
+	for port, portbindings := range changeset.Ports {
+	loopPortbindings:
+		for _, portbinding := range portbindings {
+			for _, existingPB := range existingNode.Ports[port] {
+				if util.IsPortBindingEqual(portbinding, existingPB) {
+					continue loopPortbindings
+				}
+			}
+			ports = append(ports, fmt.Sprintf("add port mapping %s:%s -> %s", portbinding.HostIP, portbinding.HostPort, port))
+		}
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		changes = append(changes, NodeEditChange{Description: port})
+	}
+
+	if changeset.Memory != "" {
+		changes = append(changes, NodeEditChange{Description: fmt.Sprintf("set memory limit to %s", changeset.Memory)})
+	}
+
+loopEnv:
+	for _, env := range changeset.Env {
+		for _, existingEnv := range existingNode.Env {
+			if existingEnv == env {
+				continue loopEnv
+			}
+		}
+		changes = append(changes, NodeEditChange{Description: fmt.Sprintf("set environment variable %s", env)})
+	}
+
+	for _, key := range sortedKeys(changeset.RuntimeLabels) {
+		if value, ok := existingNode.RuntimeLabels[key]; !ok || value != changeset.RuntimeLabels[key] {
+			changes = append(changes, NodeEditChange{Description: fmt.Sprintf("set runtime label %s=%s", key, changeset.RuntimeLabels[key])})
+		}
+	}
+
+	for _, key := range sortedKeys(changeset.K3sNodeLabels) {
+		changes = append(changes, NodeEditChange{Description: fmt.Sprintf("set k3s node label %s=%s", key, changeset.K3sNodeLabels[key]), Live: true})
+	}
+
+	return changes
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NodeEdit let's you update an existing node:
+// - k3s node labels are applied live (via kubectl in a server node)
+// - other changes (ports, memory limit, env, runtime labels) are applied by recreating the node container, preserving its volumes
 func NodeEdit(ctx context.Context, runtime runtimes.Runtime, existingNode, changeset *k3d.Node) error {
 
+	/*
+	 * Validate changes
+	 */
+	if changeset.Memory != "" {
+		if memory, err := dockerunits.RAMInBytes(changeset.Memory); err != nil || memory <= 0 {
+			return fmt.Errorf("invalid memory limit '%s': must be a positive amount of memory (e.g. 2g)", changeset.Memory)
+		}
+	}
+	for _, env := range changeset.Env {
+		if !strings.Contains(env, "=") {
+			return fmt.Errorf("invalid environment variable '%s': must be KEY=VALUE", env)
+		}
+	}
+	for key := range changeset.RuntimeLabels {
+		if strings.HasPrefix(key, "k3d.") {
+			return fmt.Errorf("cannot set runtime label '%s': k3d.* labels are managed by k3d", key)
+		}
+	}
+	if len(changeset.K3sNodeLabels) > 0 && existingNode.Role != k3d.ServerRole && existingNode.Role != k3d.AgentRole {
+		return fmt.Errorf("cannot set k3s node labels on %s node '%s'", existingNode.Role, existingNode.Name)
+	}
+
+	changes := NodeEditChanges(existingNode, changeset)
+	if len(changes) == 0 {
+		l.Log().Infof("Node %s is already up to date", existingNode.Name)
+		return nil
+	}
+	recreate := false
+	for _, change := range changes {
+		if change.Live {
+			l.Log().Infof("Live update: %s", change.Description)
+		} else {
+			l.Log().Infof("Requires recreation: %s", change.Description)
+			recreate = true
+		}
+	}
+
+	if recreate {
+		if err := nodeEditRecreate(ctx, runtime, existingNode, changeset); err != nil {
+			return err
+		}
+	}
+
+	/*
+	 * Live changes
+	 */
+	if len(changeset.K3sNodeLabels) > 0 {
+		if err := nodeSetK3sNodeLabels(ctx, runtime, existingNode, changeset.K3sNodeLabels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodeEditRecreate replaces the existing node with a copy, that has the changes of the changeset applied
+func nodeEditRecreate(ctx context.Context, runtime runtimes.Runtime, existingNode, changeset *k3d.Node) error {
+
 	/*
 	 * Make a deep copy of the existing node
 	 */
 
-	result, err := CopyNode(ctx, existingNode, CopyNodeOpts{keepState: false})
+	tmpDir, err := os.MkdirTemp("", "k3d-node-edit-")
 	if err != nil {
-		return fmt.Errorf("failed to copy node %s: %w", existingNode.Name, err)
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	result, err := nodeCopyForRecreate(ctx, runtime, existingNode, tmpDir)
+	if err != nil {
+		return err
 	}
 
 	/*
@@ -882,6 +1117,31 @@ func NodeEdit(ctx context.Context, runtime runtimes.Runtime, existingNode, chang
 		}
 	}
 
+	// === Memory ===
+	if changeset.Memory != "" {
+		result.Memory = changeset.Memory
+	}
+
+	// === Env ===
+	for _, env := range changeset.Env {
+		key := strings.SplitN(env, "=", 2)[0]
+		replaced := false
+		for i, existingEnv := range result.Env {
+			if strings.HasPrefix(existingEnv, key+"=") {
+				result.Env[i] = env
+				replaced = true
+			}
+		}
+		if !replaced {
+			result.Env = append(result.Env, env)
+		}
+	}
+
+	// === Runtime Labels ===
+	for k, v := range changeset.RuntimeLabels {
+		result.RuntimeLabels[k] = v
+	}
+
 	// --- Loadbalancer specifics ---
 	if result.Role == k3d.LoadBalancerRole {
 		cluster, err := ClusterGet(ctx, runtime, &k3d.Cluster{Name: existingNode.RuntimeLabels[k3d.LabelClusterName]})
@@ -921,6 +1181,98 @@ func NodeEdit(ctx context.Context, runtime runtimes.Runtime, existingNode, chang
 	return NodeReplace(ctx, runtime, existingNode, result)
 }
 
+// nodeCopyForRecreate returns a copy of the existing node, that replaces it via NodeReplace with the same name, labels and state.
+// The node password is exported to dir, which has to exist until the copy got started.
+func nodeCopyForRecreate(ctx context.Context, runtime runtimes.Runtime, existingNode *k3d.Node, dir string) (*k3d.Node, error) {
+	result, err := CopyNode(ctx, existingNode, CopyNodeOpts{keepState: false})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy node %s: %w", existingNode.Name, err)
+	}
+
+	// the fake meminfo (and edac) files are mounted again for the memory limit when creating the node
+	volumes := []string{}
+	for _, volume := range result.Volumes {
+		if !strings.Contains(volume, ":"+util.MemInfoPath) && !strings.Contains(volume, ":"+util.EdacFolderPath) {
+			volumes = append(volumes, volume)
+		}
+	}
+	result.Volumes = volumes
+
+	// the node only carries the k3d.* labels, so we have to restore the other labels of the container
+	containers, err := runtime.GetContainersByLabel(ctx, map[string]string{k3d.LabelClusterName: existingNode.RuntimeLabels[k3d.LabelClusterName]})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels of node %s: %w", existingNode.Name, err)
+	}
+	for _, container := range containers {
+		if container.Name != existingNode.Name {
+			continue
+		}
+		for k, v := range container.Labels {
+			if _, ok := result.RuntimeLabels[k]; !ok {
+				result.RuntimeLabels[k] = v
+			}
+		}
+	}
+
+	// keep the state of the node by mounting its anonymous volumes into the new container
+	result.Volumes = append(result.Volumes, existingNode.AnonVolumes...)
+
+	// the node password is not part of a volume, but the servers expect it to be the same on re-registration
+	if existingNode.Role == k3d.ServerRole || existingNode.Role == k3d.AgentRole {
+		nodePath := k3d.DefaultNodeDataPaths["node"]
+		file := filepath.Join(dir, existingNode.Name+"-node.tar")
+		exported, err := clusterBackupExportNodePath(ctx, runtime, existingNode, nodePath, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s from node %s: %w", nodePath, existingNode.Name, err)
+		}
+		if exported {
+			result.HookActions = append(result.HookActions, k3d.NodeHook{
+				Stage: k3d.LifecycleStagePreStart,
+				Action: &actions.ImportArchiveAction{
+					Runtime:     runtime,
+					Archives:    map[string]map[string]string{result.Name: {nodePath: file}},
+					Description: fmt.Sprintf("Restore node password of node '%s'", existingNode.Name),
+				},
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// nodeSetK3sNodeLabels labels the Kubernetes node of a k3s node using kubectl in a server node of its cluster
+func nodeSetK3sNodeLabels(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, labels map[string]string) error {
+	servers, err := runtime.GetNodesByLabel(ctx, map[string]string{k3d.LabelClusterName: node.RuntimeLabels[k3d.LabelClusterName], k3d.LabelRole: string(k3d.ServerRole)})
+	if err != nil {
+		return fmt.Errorf("failed to get server nodes of node %s: %w", node.Name, err)
+	}
+	var server *k3d.Node
+	for _, s := range servers {
+		if s.State.Running {
+			server = s
+			break
+		}
+	}
+	if server == nil {
+		return fmt.Errorf("failed to set k3s node labels on node %s: no running server node found", node.Name)
+	}
+
+	cmd := []string{"kubectl", "label", "node", node.Name, "--overwrite"}
+	for _, key := range sortedKeys(labels) {
+		cmd = append(cmd, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	logreader, err := runtime.ExecInNodeGetLogs(ctx, server, cmd)
+	if err != nil {
+		if logreader != nil {
+			if logs, logerr := io.ReadAll(logreader); logerr == nil {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(logs)))
+			}
+		}
+		return fmt.Errorf("failed to set k3s node labels on node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
 func NodeReplace(ctx context.Context, runtime runtimes.Runtime, old, new *k3d.Node) error {
 
 	// rename existing node
@@ -968,6 +1320,7 @@ func NodeReplace(ctx context.Context, runtime runtimes.Runtime, old, new *k3d.No
 	if err := NodeDelete(ctx, runtime, old, k3d.NodeDeleteOpts{SkipLBUpdate: true}); err != nil {
 		return fmt.Errorf("failed to delete old node '%s': %w", old.Name, err)
 	}
+	ClusterEventRecord(new.RuntimeLabels[k3d.LabelClusterName], k3d.ClusterEventNodeReplaced, new.Name, fmt.Sprintf("Replaced node '%s' (image %s)", new.Name, new.Image))
 
 	// done
 	return nil
@@ -0,0 +1,99 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestNodeEditChanges(t *testing.T) {
+	existing := &k3d.Node{
+		Name:          "k3d-test-agent-0",
+		Role:          k3d.AgentRole,
+		Env:           []string{"FOO=bar"},
+		RuntimeLabels: map[string]string{"tier": "fast"},
+		Ports:         nat.PortMap{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}},
+	}
+
+	tests := []struct {
+		name      string
+		changeset *k3d.Node
+		want      []NodeEditChange
+	}{
+		{
+			name:      "nothing changed",
+			changeset: &k3d.Node{Env: []string{"FOO=bar"}, RuntimeLabels: map[string]string{"tier": "fast"}, Ports: nat.PortMap{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}}},
+			want:      []NodeEditChange{},
+		},
+		{
+			name: "recreation and live",
+			changeset: &k3d.Node{
+				Memory:        "2g",
+				Env:           []string{"FOO=baz"},
+				RuntimeLabels: map[string]string{"tier": "slow"},
+				K3sNodeLabels: map[string]string{"b": "2", "a": "1"},
+				Ports:         nat.PortMap{"443/tcp": {{HostIP: "0.0.0.0", HostPort: "8443"}}},
+			},
+			want: []NodeEditChange{
+				{Description: "add port mapping 0.0.0.0:8443 -> 443/tcp"},
+				{Description: "set memory limit to 2g"},
+				{Description: "set environment variable FOO=baz"},
+				{Description: "set runtime label tier=slow"},
+				{Description: "set k3s node label a=1", Live: true},
+				{Description: "set k3s node label b=2", Live: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeEditChanges(existing, tt.changeset); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeEditChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeWaitForLogLineBackOff(t *testing.T) {
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 0, want: 500 * time.Millisecond},
+		{retry: 1, want: 1 * time.Second},
+		{retry: 3, want: 4 * time.Second},
+		{retry: 5, want: 16 * time.Second},
+		{retry: 6, want: k3d.DefaultNodeWaitForLogMessageCrashLoopBackOffMax},
+		{retry: 100, want: k3d.DefaultNodeWaitForLogMessageCrashLoopBackOffMax},
+	}
+
+	for _, tt := range tests {
+		if got := nodeWaitForLogLineBackOff(tt.retry); got != tt.want {
+			t.Errorf("nodeWaitForLogLineBackOff(%d) = %s, want %s", tt.retry, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/fixes"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// NodeBakeOpts describes the provisioning steps used to bake a custom node image
+type NodeBakeOpts struct {
+	BaseImage    string         // k3s image to start from
+	Files        []NodeBakeFile // files/directories copied from the host into the image
+	CACerts      []string       // host paths of PEM encoded CA certificates added to the trust bundle
+	AirgapImages []string       // images (present in the runtime) that are preloaded by k3s when a node starts
+	Commands     []string       // shell commands run in order after all files were added
+}
+
+// NodeBakeFile describes a file or directory copied from the host into a baked node image
+type NodeBakeFile struct {
+	Source      string
+	Destination string
+}
+
+// nodeImageVolumePaths are declared as volumes in the k3s image, so changes below them are not part of a commit
+var nodeImageVolumePaths = []string{"/var/lib/kubelet", "/var/lib/rancher/k3s", "/var/lib/cni", "/var/log"}
+
+const (
+	nodeBakeCABundlePath  = "/etc/ssl/certs/ca-certificates.crt"
+	nodeBakeAirgapDir     = "/var/lib/rancher/k3d/airgap-images"
+	nodeBakeAirgapTarget  = "/var/lib/rancher/k3s/agent/images"
+	nodeBakeAirgapScript  = "/bin/k3d-entrypoint-airgap.sh"
+	nodeBakeEntrypoint    = "/bin/k3d-entrypoint.sh"
+	nodeBakeKeepaliveLoop = "trap 'exit 0' TERM INT; while true; do sleep 1; done"
+)
+
+// nodeBakeAirgapEntrypoint copies the baked airgap images into the k3s data dir (a volume, so it can't be baked directly)
+var nodeBakeAirgapEntrypoint = fmt.Sprintf(`#!/bin/sh
+
+set -o errexit
+set -o nounset
+
+mkdir -p %[2]s
+for image in %[1]s/*.tar ; do
+  [ -e "$image" ] || continue
+  echo "[$(date -Iseconds)] Preloading airgap images from $image"
+  target="%[2]s/$(basename "$image")"
+  [ -e "$target" ] || cp "$image" "$target"
+done
+`, nodeBakeAirgapDir, nodeBakeAirgapTarget)
+
+// ParseNodeBakeFile parses a SOURCE:DESTINATION copy specification (the destination is a path inside the node)
+func ParseNodeBakeFile(spec string) (NodeBakeFile, error) {
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 || i == len(spec)-1 {
+		return NodeBakeFile{}, fmt.Errorf("invalid copy spec '%s': must be SOURCE:DESTINATION", spec)
+	}
+	file := NodeBakeFile{Source: spec[:i], Destination: spec[i+1:]}
+	if !path.IsAbs(file.Destination) {
+		return NodeBakeFile{}, fmt.Errorf("invalid copy spec '%s': destination '%s' must be an absolute path", spec, file.Destination)
+	}
+	return file, nil
+}
+
+// ValidateNodeBakeOpts checks the provisioning steps before any container is created
+func ValidateNodeBakeOpts(opts *NodeBakeOpts) error {
+	if opts.BaseImage == "" {
+		return fmt.Errorf("no base image given")
+	}
+	for _, file := range opts.Files {
+		if _, err := os.Stat(file.Source); err != nil {
+			return fmt.Errorf("cannot copy '%s': %w", file.Source, err)
+		}
+		for _, volumePath := range nodeImageVolumePaths {
+			if dest := path.Clean(file.Destination); dest == volumePath || strings.HasPrefix(dest, volumePath+"/") {
+				return fmt.Errorf("cannot copy '%s' to '%s': '%s' is a volume in the k3s image, so its content can't be baked into an image", file.Source, file.Destination, volumePath)
+			}
+		}
+	}
+	for _, cert := range opts.CACerts {
+		if _, err := os.Stat(cert); err != nil {
+			return fmt.Errorf("cannot add CA certificate '%s': %w", cert, err)
+		}
+	}
+	return nil
+}
+
+// NodeBake runs the provisioning steps in a temporary node container and commits the result as a new node image,
+// which can then be used via `--image` when creating clusters or nodes
+func NodeBake(ctx context.Context, runtime runtimes.Runtime, image string, opts NodeBakeOpts) error {
+	if err := ValidateNodeBakeOpts(&opts); err != nil {
+		return fmt.Errorf("invalid bake options: %w", err)
+	}
+
+	labels := map[string]string{}
+	for k, v := range k3d.DefaultRuntimeLabels {
+		labels[k] = v
+	}
+	for k, v := range k3d.DefaultRuntimeLabelsVar {
+		labels[k] = v
+	}
+
+	node := &k3d.Node{
+		Name:          fmt.Sprintf("%s-bake-%s", k3d.ObjectNamePrefix, strings.ToLower(util.GenerateRandomString(5))),
+		Role:          k3d.NoRole,
+		Image:         opts.BaseImage,
+		Entrypoint:    []string{"/bin/sh", "-c"},
+		Cmd:           []string{nodeBakeKeepaliveLoop},
+		RuntimeLabels: labels,
+	}
+
+	l.Log().Infof("Creating temporary node '%s' from image '%s'...", node.Name, opts.BaseImage)
+	if err := runtime.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("failed to create temporary node: %w", err)
+	}
+	defer func() {
+		l.Log().Infof("Deleting temporary node '%s'...", node.Name)
+		if delErr := runtime.DeleteNode(ctx, node); delErr != nil {
+			l.Log().Warnf("Failed to delete temporary node '%s': %v", node.Name, delErr)
+		}
+	}()
+	if err := runtime.StartNode(ctx, node); err != nil {
+		return fmt.Errorf("failed to start temporary node: %w", err)
+	}
+
+	changes := []string{
+		fmt.Sprintf("LABEL %s=%s", k3d.LabelImageBakedFrom, opts.BaseImage),
+	}
+
+	// files
+	for _, file := range opts.Files {
+		l.Log().Infof("Copying '%s' to '%s'...", file.Source, file.Destination)
+		if err := runtime.ExecInNode(ctx, node, []string{"mkdir", "-p", path.Dir(file.Destination)}); err != nil {
+			return fmt.Errorf("failed to create parent directory of '%s': %w", file.Destination, err)
+		}
+		if err := runtime.CopyToNode(ctx, file.Source, file.Destination, node); err != nil {
+			return fmt.Errorf("failed to copy '%s' to '%s': %w", file.Source, file.Destination, err)
+		}
+	}
+
+	// CA certificates
+	for i, cert := range opts.CACerts {
+		l.Log().Infof("Adding CA certificate '%s'...", cert)
+		tmpPath := fmt.Sprintf("/tmp/k3d-bake-ca-%d.crt", i)
+		if err := runtime.CopyToNode(ctx, cert, tmpPath, node); err != nil {
+			return fmt.Errorf("failed to copy CA certificate '%s': %w", cert, err)
+		}
+		appendCmd := fmt.Sprintf("echo >> %[2]s && cat %[1]s >> %[2]s && rm %[1]s", tmpPath, nodeBakeCABundlePath)
+		if err := runtime.ExecInNode(ctx, node, []string{"sh", "-c", appendCmd}); err != nil {
+			return fmt.Errorf("failed to add CA certificate '%s' to the trust bundle: %w", cert, err)
+		}
+	}
+
+	// airgap images
+	if len(opts.AirgapImages) > 0 {
+		l.Log().Infof("Adding airgap images %+v...", opts.AirgapImages)
+		stream, err := runtime.GetImageStream(ctx, opts.AirgapImages)
+		if err != nil {
+			return fmt.Errorf("failed to export airgap images: %w", err)
+		}
+		saveCmd := fmt.Sprintf("mkdir -p %[1]s && cat > %[1]s/k3d-bake.tar", nodeBakeAirgapDir)
+		if err := runtime.ExecInNodeWithStdin(ctx, node, []string{"sh", "-c", saveCmd}, stream); err != nil {
+			return fmt.Errorf("failed to add airgap images: %w", err)
+		}
+		if err := runtime.WriteToNode(ctx, fixes.K3DEntrypoint, nodeBakeEntrypoint, 0744, node); err != nil {
+			return fmt.Errorf("failed to write entrypoint: %w", err)
+		}
+		if err := runtime.WriteToNode(ctx, []byte(nodeBakeAirgapEntrypoint), nodeBakeAirgapScript, 0744, node); err != nil {
+			return fmt.Errorf("failed to write airgap entrypoint script: %w", err)
+		}
+		changes = append(changes, fmt.Sprintf(`ENTRYPOINT ["%s"]`, nodeBakeEntrypoint))
+	}
+
+	// commands
+	for _, command := range opts.Commands {
+		l.Log().Infof("Running '%s'...", command)
+		logs, err := runtime.ExecInNodeGetLogs(ctx, node, []string{"sh", "-c", command})
+		var output []byte
+		if logs != nil {
+			output, _ = io.ReadAll(logs)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run '%s': %w: %s", command, err, output)
+		}
+		l.Log().Debugf("Output of '%s':\n%s", command, output)
+	}
+
+	l.Log().Infof("Committing temporary node '%s' as image '%s'...", node.Name, image)
+	if err := runtime.CommitNode(ctx, node, image, changes); err != nil {
+		return fmt.Errorf("failed to commit node image: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNodeBakeFile(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    NodeBakeFile
+		wantErr bool
+	}{
+		{spec: "./ca.pem:/etc/ssl/ca.pem", want: NodeBakeFile{Source: "./ca.pem", Destination: "/etc/ssl/ca.pem"}},
+		{spec: `C:\certs\ca.pem:/etc/ssl/ca.pem`, want: NodeBakeFile{Source: `C:\certs\ca.pem`, Destination: "/etc/ssl/ca.pem"}},
+		{spec: "./ca.pem", wantErr: true},
+		{spec: "./ca.pem:", wantErr: true},
+		{spec: ":/etc/ssl/ca.pem", wantErr: true},
+		{spec: "./ca.pem:etc/ssl/ca.pem", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseNodeBakeFile(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateNodeBakeOpts(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(source, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    NodeBakeOpts
+		wantErr bool
+	}{
+		{name: "valid", opts: NodeBakeOpts{BaseImage: "rancher/k3s", Files: []NodeBakeFile{{Source: source, Destination: "/opt/file"}}, CACerts: []string{source}}},
+		{name: "no base image", opts: NodeBakeOpts{}, wantErr: true},
+		{name: "missing source", opts: NodeBakeOpts{BaseImage: "rancher/k3s", Files: []NodeBakeFile{{Source: source + "-missing", Destination: "/opt/file"}}}, wantErr: true},
+		{name: "missing ca cert", opts: NodeBakeOpts{BaseImage: "rancher/k3s", CACerts: []string{source + "-missing"}}, wantErr: true},
+		{name: "volume destination", opts: NodeBakeOpts{BaseImage: "rancher/k3s", Files: []NodeBakeFile{{Source: source, Destination: "/var/lib/rancher/k3s/server/manifests/file.yaml"}}}, wantErr: true},
+		{name: "similar to volume", opts: NodeBakeOpts{BaseImage: "rancher/k3s", Files: []NodeBakeFile{{Source: source, Destination: "/var/lib/rancher/k3s-extra/file"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateNodeBakeOpts(&tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// DefaultNodeImagePackageBase is the image whose package manager is used to install packages into custom node images
+const DefaultNodeImagePackageBase = "docker.io/library/alpine:3.15"
+
+// DefaultNodeImageValidateTimeout is the maximum time a custom node image may take to boot during validation
+const DefaultNodeImageValidateTimeout = 3 * time.Minute
+
+// NodeImageBuildOpts describes a custom node image layered on top of a k3s image
+type NodeImageBuildOpts struct {
+	BaseImage       string         // k3s image to start from
+	PackageBase     string         // image (Alpine based) used to install packages
+	Packages        []string       // Alpine packages installed into the image
+	Files           []NodeBakeFile // files/directories copied from the host into the image
+	Commands        []string       // shell commands run (as RUN instructions) after all files were added
+	Validate        bool           // boot a temporary server node from the new image
+	ValidateTimeout time.Duration  // maximum time the server node may take to get ready
+}
+
+// nodeImageDockerfileTemplate generates the Dockerfile for custom node images.
+// The k3s image has no package manager, so packages are installed into an empty root in an Alpine stage and copied over.
+var nodeImageDockerfileTemplate = template.Must(template.New("Dockerfile").Funcs(template.FuncMap{
+	"json":        toJSON,
+	"join":        strings.Join,
+	"list":        func(s ...string) []string { return s },
+	"contextPath": nodeImageContextPath,
+}).Parse(`# generated by k3d
+{{- if .Packages }}
+FROM {{ .PackageBase }} AS packages
+RUN mkdir -p /rootfs/etc/apk && cp -r /etc/apk/keys /rootfs/etc/apk/ && \
+    apk add --no-cache --initdb --root /rootfs --keys-dir /etc/apk/keys --repositories-file /etc/apk/repositories \
+      {{ join .Packages " " }}
+{{ end }}
+FROM {{ .BaseImage }}
+{{- if .Packages }}
+COPY --from=packages /rootfs/ /
+{{- end }}
+{{- range $i, $file := .Files }}
+COPY {{ json (list (contextPath $i $file) $file.Destination) }}
+{{- end }}
+{{- range .Commands }}
+RUN {{ . }}
+{{- end }}
+LABEL {{ .Label }}={{ json .BaseImage }}
+`))
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// nodeImageContextPath is the path of a file in the build context
+func nodeImageContextPath(i int, file NodeBakeFile) string {
+	return path.Join("files", fmt.Sprint(i), filepath.Base(filepath.Clean(file.Source)))
+}
+
+// GenerateNodeImageDockerfile returns the Dockerfile used to build a custom node image
+func GenerateNodeImageDockerfile(opts *NodeImageBuildOpts) (string, error) {
+	if opts.PackageBase == "" {
+		opts.PackageBase = DefaultNodeImagePackageBase
+	}
+	for _, cmd := range opts.Commands {
+		if strings.Contains(cmd, "\n") {
+			return "", fmt.Errorf("invalid command '%s': must be a single line", cmd)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := nodeImageDockerfileTemplate.Execute(&buf, struct {
+		*NodeImageBuildOpts
+		Label string
+	}{opts, k3d.LabelImageBakedFrom}); err != nil {
+		return "", fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// nodeImageBuildContext creates the tar stream of the build context: the Dockerfile and all files to copy
+func nodeImageBuildContext(dockerfile string, files []NodeBakeFile) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	if err := tw.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile)), ModTime: time.Now()}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write([]byte(dockerfile)); err != nil {
+		return nil, err
+	}
+
+	for i, file := range files {
+		root := filepath.Clean(file.Source)
+		target := nodeImageContextPath(i, file)
+		if err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = path.Join(target, filepath.ToSlash(rel))
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add '%s' to the build context: %w", file.Source, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// NodeImageBuild builds a custom node image from a generated Dockerfile, tags it and (optionally) checks that k3s still boots
+func NodeImageBuild(ctx context.Context, runtime runtimes.Runtime, tag string, opts NodeImageBuildOpts) error {
+	if opts.BaseImage == "" {
+		return fmt.Errorf("no base image given")
+	}
+	for _, file := range opts.Files {
+		if _, err := os.Stat(file.Source); err != nil {
+			return fmt.Errorf("cannot copy '%s': %w", file.Source, err)
+		}
+	}
+
+	dockerfile, err := GenerateNodeImageDockerfile(&opts)
+	if err != nil {
+		return err
+	}
+	l.Log().Debugf("Generated Dockerfile:\n%s", dockerfile)
+
+	buildContext, err := nodeImageBuildContext(dockerfile, opts.Files)
+	if err != nil {
+		return fmt.Errorf("failed to create build context: %w", err)
+	}
+
+	l.Log().Infof("Building image '%s' from '%s'...", tag, opts.BaseImage)
+	if err := runtime.BuildImage(ctx, buildContext, tag); err != nil {
+		return fmt.Errorf("failed to build image: %w", err)
+	}
+
+	if !opts.Validate {
+		return nil
+	}
+	if err := NodeImageValidate(ctx, runtime, tag, opts.ValidateTimeout); err != nil {
+		return fmt.Errorf("image '%s' was built, but failed validation: %w", tag, err)
+	}
+
+	return nil
+}
+
+// NodeImageValidate boots a temporary server node from the given image and waits for k3s to be up and running
+func NodeImageValidate(ctx context.Context, runtime runtimes.Runtime, image string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultNodeImageValidateTimeout
+	}
+
+	node := &k3d.Node{
+		Name:  fmt.Sprintf("%s-validate-%s", k3d.ObjectNamePrefix, strings.ToLower(util.GenerateRandomString(5))),
+		Role:  k3d.ServerRole,
+		Image: image,
+		Args:  []string{"--disable=traefik,servicelb,metrics-server"},
+		ServerOpts: k3d.ServerOpts{
+			KubeAPI: &k3d.ExposureOpts{Host: k3d.DefaultAPIHost},
+		},
+	}
+
+	l.Log().Infof("Validating image '%s' by starting temporary node '%s'...", image, node.Name)
+	defer func() {
+		if err := runtime.DeleteNode(ctx, node); err != nil {
+			l.Log().Warnf("Failed to delete temporary node '%s': %v", node.Name, err)
+		}
+	}()
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := NodeRun(runCtx, runtime, node, k3d.NodeCreateOpts{Wait: true, Timeout: timeout}); err != nil {
+		return err
+	}
+
+	return nil
+}
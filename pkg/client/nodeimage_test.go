@@ -0,0 +1,130 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGenerateNodeImageDockerfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     NodeImageBuildOpts
+		contains []string
+		excludes []string
+		wantErr  bool
+	}{
+		{
+			name:     "base only",
+			opts:     NodeImageBuildOpts{BaseImage: "rancher/k3s:latest"},
+			contains: []string{"FROM rancher/k3s:latest\n", `LABEL k3d.image.bakedFrom="rancher/k3s:latest"`},
+			excludes: []string{"AS packages", "COPY", "RUN"},
+		},
+		{
+			name: "packages, files and commands",
+			opts: NodeImageBuildOpts{
+				BaseImage: "rancher/k3s:latest",
+				Packages:  []string{"open-iscsi", "nfs-utils"},
+				Files:     []NodeBakeFile{{Source: "./conf/registries.yaml", Destination: "/etc/rancher/k3s/registries.yaml"}},
+				Commands:  []string{"echo test > /etc/test"},
+			},
+			contains: []string{
+				"FROM " + DefaultNodeImagePackageBase + " AS packages\n",
+				" open-iscsi nfs-utils\n",
+				"COPY --from=packages /rootfs/ /\n",
+				`COPY ["files/0/registries.yaml","/etc/rancher/k3s/registries.yaml"]`,
+				"RUN echo test > /etc/test\n",
+			},
+		},
+		{
+			name:    "multi-line command",
+			opts:    NodeImageBuildOpts{BaseImage: "rancher/k3s:latest", Commands: []string{"echo a\necho b"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerfile, err := GenerateNodeImageDockerfile(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(dockerfile, s) {
+					t.Errorf("expected Dockerfile to contain %q, got:\n%s", s, dockerfile)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(dockerfile, s) {
+					t.Errorf("expected Dockerfile not to contain %q, got:\n%s", s, dockerfile)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeImageBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "manifests", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"ca.pem", "manifests/a.yaml", "manifests/sub/b.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buildContext, err := nodeImageBuildContext("FROM scratch\n", []NodeBakeFile{
+		{Source: filepath.Join(dir, "ca.pem"), Destination: "/etc/ca.pem"},
+		{Source: filepath.Join(dir, "manifests") + "/", Destination: "/opt/manifests"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	tr := tar.NewReader(buildContext)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			files = append(files, header.Name)
+		}
+	}
+	sort.Strings(files)
+
+	expected := []string{"Dockerfile", "files/0/ca.pem", "files/1/manifests/a.yaml", "files/1/manifests/sub/b.yaml"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected build context files %v, got %v", expected, files)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"fmt"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// nodePrepareOS validates the operating system of the node and configures simulated Windows nodes:
+// k3s agents with the node labels and taint of a Windows worker, so that scheduling (nodeSelectors, tolerations, ...) can be tested
+// without Windows hosts. The workloads scheduled onto such a node still run as Linux containers.
+func nodePrepareOS(node *k3d.Node) error {
+	switch node.OS {
+	case "", k3d.NodeOSLinux:
+		return nil
+	case k3d.NodeOSWindows:
+	default:
+		return fmt.Errorf("unsupported OS '%s' of node %s (supported: %s, %s)", node.OS, node.Name, k3d.NodeOSLinux, k3d.NodeOSWindows)
+	}
+
+	if node.Role != k3d.AgentRole {
+		return fmt.Errorf("only agent nodes can be simulated %s nodes, but node %s is a %s node", node.OS, node.Name, node.Role)
+	}
+
+	// copy the labels, as they may be shared between replicas
+	labels := make(map[string]string, len(node.K3sNodeLabels)+len(k3d.SimulatedWindowsNodeLabels))
+	for k, v := range k3d.SimulatedWindowsNodeLabels {
+		labels[k] = v
+	}
+	for k, v := range node.K3sNodeLabels {
+		labels[k] = v
+	}
+	node.K3sNodeLabels = labels
+	node.Args = append(node.Args, "--node-taint", k3d.SimulatedWindowsNodeTaint)
+
+	if node.RuntimeLabels == nil {
+		node.RuntimeLabels = map[string]string{}
+	}
+	node.RuntimeLabels[k3d.LabelNodeOS] = node.OS
+	return nil
+}
+
+// nodeIsSimulatedOS returns true if the node simulates another operating system than linux
+func nodeIsSimulatedOS(node *k3d.Node) bool {
+	os, ok := node.RuntimeLabels[k3d.LabelNodeOS]
+	return ok && os != "" && os != k3d.NodeOSLinux
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestNodePrepareOS(t *testing.T) {
+	tests := []struct {
+		name       string
+		node       *k3d.Node
+		wantErr    bool
+		wantArgs   []string
+		wantLabels map[string]string
+	}{
+		{
+			name: "linux",
+			node: &k3d.Node{Name: "agent", Role: k3d.AgentRole, OS: k3d.NodeOSLinux},
+		},
+		{
+			name:     "windows",
+			node:     &k3d.Node{Name: "agent", Role: k3d.AgentRole, OS: k3d.NodeOSWindows, K3sNodeLabels: map[string]string{"foo": "bar", "node.kubernetes.io/windows-build": "10.0.20348"}},
+			wantArgs: []string{"--node-taint", k3d.SimulatedWindowsNodeTaint},
+			wantLabels: map[string]string{
+				"foo":                              "bar",
+				"kubernetes.io/os":                 "windows",
+				"beta.kubernetes.io/os":            "windows",
+				"node.kubernetes.io/windows-build": "10.0.20348",
+			},
+		},
+		{
+			name:    "windows server",
+			node:    &k3d.Node{Name: "server", Role: k3d.ServerRole, OS: k3d.NodeOSWindows},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported",
+			node:    &k3d.Node{Name: "agent", Role: k3d.AgentRole, OS: "darwin"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := nodePrepareOS(tt.node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nodePrepareOS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tt.node.Args, tt.wantArgs) {
+				t.Errorf("Args = %v, want %v", tt.node.Args, tt.wantArgs)
+			}
+			if !reflect.DeepEqual(tt.node.K3sNodeLabels, tt.wantLabels) {
+				t.Errorf("K3sNodeLabels = %v, want %v", tt.node.K3sNodeLabels, tt.wantLabels)
+			}
+			if got := nodeIsSimulatedOS(tt.node); got != (tt.node.OS == k3d.NodeOSWindows) {
+				t.Errorf("nodeIsSimulatedOS() = %t", got)
+			}
+		})
+	}
+}
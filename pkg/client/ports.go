@@ -42,7 +42,9 @@ var (
 	ErrNodeAddPortsExists error = errors.New("port exists on target")
 )
 
-func TransformPorts(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, portsWithNodeFilters []config.PortWithNodeFilters) error {
+// TransformPorts adds the port mappings to the matching nodes or the loadbalancer.
+// Port mappings without an explicit host IP are bound to bindAddress (if not empty).
+func TransformPorts(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, portsWithNodeFilters []config.PortWithNodeFilters, bindAddress string) error {
 	nodeCount := len(cluster.Nodes)
 	nodeList := cluster.Nodes
 
@@ -75,6 +77,11 @@ func TransformPorts(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.
 			if err != nil {
 				return fmt.Errorf("error parsing port spec '%s': %+v", portWithNodeFilters.Port, err)
 			}
+			for i := range portmappings {
+				if portmappings[i].Binding.HostIP == "" {
+					portmappings[i].Binding.HostIP = bindAddress
+				}
+			}
 
 			if suffix == "proxy" || suffix == util.NodeFilterSuffixNone { // proxy is the default suffix for port mappings
 				if cluster.ServerLoadBalancer == nil {
@@ -83,10 +90,8 @@ func TransformPorts(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.
 				if err := addPortMappings(cluster.ServerLoadBalancer.Node, portmappings); err != nil {
 					return err
 				}
-				for _, pm := range portmappings {
-					if err := loadbalancerAddPortConfigs(cluster.ServerLoadBalancer, pm, nodes); err != nil {
-						return fmt.Errorf("error adding port config to loadbalancer: %w", err)
-					}
+				if err := loadbalancerAddPortConfigs(cluster.ServerLoadBalancer, loadbalancerPortConfig(portmappings), nodes); err != nil {
+					return fmt.Errorf("error adding port config to loadbalancer: %w", err)
 				}
 			} else if suffix == "direct" {
 				if len(nodes) > 1 {
@@ -0,0 +1,305 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/fixes"
+)
+
+// ClusterPreflight runs checks against the runtime environment before anything gets created.
+// Issues that may still work out (e.g. tight resources) only produce warnings.
+func ClusterPreflight(ctx context.Context, runtime k3drt.Runtime, clusterConfig *config.ClusterConfig) error {
+	l.Log().Infoln("Prep: Preflight checks")
+
+	if clusterConfig.ClusterCreateOpts.NoPull {
+		if err := ImagesCheckPresent(ctx, runtime, ClusterImages(clusterConfig)); err != nil {
+			return err
+		}
+	}
+
+	rtimeInfo, err := runtime.Info()
+	if err != nil {
+		l.Log().Warnf("Preflight: failed to get runtime info, skipping resource checks: %v", err)
+		return nil
+	}
+
+	warnings := preflightCheckResources(rtimeInfo, &clusterConfig.Cluster)
+	warnings = append(warnings, preflightCheckInotify(rtimeInfo, clusterConfig)...)
+	if len(warnings) > 0 && clusterConfig.ClusterCreateOpts.StrictPreflight {
+		return fmt.Errorf("%s\n\nFix the issues above or drop --strict-preflight to create the cluster anyway", strings.Join(warnings, "\n\n"))
+	}
+	for _, warning := range warnings {
+		l.Log().Warnln(warning)
+	}
+
+	if err := preflightCheckProfiles(rtimeInfo, clusterConfig.ClusterCreateOpts.CheckProfiles); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// estimateClusterResources estimates the memory (bytes) and CPUs that the cluster's nodes realistically need:
+// memory = base + servers * serverMemory + agents * agentMemory
+// cpus   = servers * serverCPUs + agents * agentCPUs
+func estimateClusterResources(cluster *k3d.Cluster) (memory int64, cpus float64) {
+	memory = k3d.DefaultResourceEstimateBaseMemory
+	for _, node := range cluster.Nodes {
+		switch node.Role {
+		case k3d.ServerRole:
+			memory += k3d.DefaultResourceEstimateServerMemory
+			cpus += k3d.DefaultResourceEstimateServerCPUs
+		case k3d.AgentRole:
+			memory += k3d.DefaultResourceEstimateAgentMemory
+			cpus += k3d.DefaultResourceEstimateAgentCPUs
+		}
+	}
+	return memory, cpus
+}
+
+// preflightCheckResources compares the runtime's memory/CPU allocation with the estimated requirements and returns warnings
+func preflightCheckResources(info *runtimeTypes.RuntimeInfo, cluster *k3d.Cluster) []string {
+	var warnings []string
+	memory, cpus := estimateClusterResources(cluster)
+
+	if info.Memory > 0 && info.Memory < memory {
+		warnings = append(warnings, fmt.Sprintf("Preflight: the %s runtime has %.1f GiB of memory, but the requested nodes will likely need about %.1f GiB: the cluster may never become ready. Consider increasing the memory limit (e.g. in Docker Desktop > Settings > Resources) or creating fewer nodes.", info.Name, bytesToGiB(info.Memory), bytesToGiB(memory)))
+	}
+
+	if info.CPUs > 0 && float64(info.CPUs) < cpus {
+		warnings = append(warnings, fmt.Sprintf("Preflight: the %s runtime has %d CPU(s), but the requested nodes will likely need about %d: the cluster may become ready very slowly or not at all. Consider increasing the CPU limit (e.g. in Docker Desktop > Settings > Resources) or creating fewer nodes.", info.Name, info.CPUs, int(math.Ceil(cpus))))
+	}
+
+	return warnings
+}
+
+func bytesToGiB(b int64) float64 {
+	return float64(b) / (1024 * 1024 * 1024)
+}
+
+// preflightCheckInotify checks that the inotify limits suffice for the requested nodes (too low limits cause e.g. pods in CrashLoopBackOff with "too many open files").
+// On Docker Desktop, we cannot change the VM's limits from the host, so we auto-enable the inotify fix for the nodes, which raises them from inside the nodes.
+// On a local Linux host, we only read the limits and warn with instructions, as we don't want to modify the host's kernel parameters without being asked to.
+func preflightCheckInotify(info *runtimeTypes.RuntimeInfo, clusterConfig *config.ClusterConfig) []string {
+	if clusterConfig.ClusterCreateOpts.FixInotify {
+		return nil
+	}
+	if _, isSet := os.LookupEnv(string(fixes.EnvFixInotify)); isSet {
+		return nil // user decided explicitly
+	}
+
+	if info.DockerDesktop {
+		l.Log().Debugf("Preflight: Detected Docker Desktop, enabling inotify fix (disable by setting %s=false)", fixes.EnvFixInotify)
+		clusterConfig.ClusterCreateOpts.FixInotify = true
+		return nil
+	}
+
+	// we can only check the limits if the runtime shares our kernel
+	if !runtimeSharesHostKernel(info) {
+		return nil
+	}
+
+	watches, err := readIntFromFile(k3d.DefaultInotifyMaxUserWatchesPath)
+	if err != nil {
+		l.Log().Debugf("Preflight: failed to read inotify max_user_watches: %v", err)
+		return nil
+	}
+	instances, err := readIntFromFile(k3d.DefaultInotifyMaxUserInstancesPath)
+	if err != nil {
+		l.Log().Debugf("Preflight: failed to read inotify max_user_instances: %v", err)
+		return nil
+	}
+
+	if err := checkInotifyLimits(watches, instances, &clusterConfig.Cluster); err != nil {
+		return []string{fmt.Sprintf("Preflight: %v", err)}
+	}
+	return nil
+}
+
+// checkInotifyLimits returns an error including remediation commands if the given limits are too low for the k3s nodes of the cluster
+func checkInotifyLimits(watches int, instances int, cluster *k3d.Cluster) error {
+	nodeCount := 0
+	for _, node := range cluster.Nodes {
+		if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+			nodeCount++
+		}
+	}
+
+	if watches >= nodeCount*k3d.DefaultInotifyMinWatchesPerNode && instances >= nodeCount*k3d.DefaultInotifyMinInstancesPerNode {
+		return nil
+	}
+
+	return fmt.Errorf(`the host's inotify limits (max_user_watches=%d, max_user_instances=%d) are too low for %d k3s node(s), which may leave pods crash-looping (e.g. "too many open files").
+Raise them on the host by running:
+
+    sudo sysctl -w fs.inotify.max_user_watches=%d
+    sudo sysctl -w fs.inotify.max_user_instances=%d
+
+To make this permanent, add the following lines to /etc/sysctl.d/99-k3d.conf:
+
+    fs.inotify.max_user_watches=%d
+    fs.inotify.max_user_instances=%d
+
+Alternatively, set %s=true (or use --fix-inotify) to let k3d raise the limits from inside the (privileged) nodes, or %s=false to skip this check`,
+		watches, instances, nodeCount,
+		k3d.DefaultInotifyMaxUserWatches, k3d.DefaultInotifyMaxUserInstances,
+		k3d.DefaultInotifyMaxUserWatches, k3d.DefaultInotifyMaxUserInstances,
+		fixes.EnvFixInotify, fixes.EnvFixInotify)
+}
+
+// runtimeSharesHostKernel returns true if the runtime runs containers on the kernel of the host that k3d is running on,
+// i.e. if we can check kernel settings by reading /proc and /sys locally
+func runtimeSharesHostKernel(info *runtimeTypes.RuntimeInfo) bool {
+	if goruntime.GOOS != "linux" || info.DockerDesktop {
+		return false
+	}
+	if dockerHost := os.Getenv("DOCKER_HOST"); dockerHost != "" && !strings.HasPrefix(dockerHost, "unix://") {
+		return false
+	}
+	return true
+}
+
+// preflightCheckProfiles verifies the host kernel requirements of the requested check profiles (--check-profile) and fails with remediation steps
+func preflightCheckProfiles(info *runtimeTypes.RuntimeInfo, profiles []string) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	if !runtimeSharesHostKernel(info) {
+		l.Log().Warnf("Preflight: cannot verify the kernel requirements of check profile(s) '%s', as the %s runtime does not share the host's kernel", strings.Join(profiles, ", "), info.Name)
+		return nil
+	}
+
+	var problems []string
+	for _, name := range profiles {
+		profile, ok := k3d.CheckProfiles[name]
+		if !ok {
+			return fmt.Errorf("unknown check profile '%s': must be one of %s", name, strings.Join(k3d.CheckProfileNames(), ", "))
+		}
+		problems = append(problems, checkProfileRequirements(name, profile, "/")...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("the host does not meet the kernel requirements of check profile(s) '%s':\n\n%s\n\nFix the issues above or drop --check-profile to create the cluster anyway", strings.Join(profiles, ", "), strings.Join(problems, "\n"))
+	}
+
+	l.Log().Infof("Preflight: host meets the kernel requirements of check profile(s) '%s'", strings.Join(profiles, ", "))
+	return nil
+}
+
+// checkProfileRequirements returns a description including remediation steps for each requirement of the profile that is not met on the host mounted at hostRoot
+func checkProfileRequirements(name string, profile k3d.CheckProfile, hostRoot string) []string {
+	var problems []string
+
+	modules := hostKernelModules(hostRoot)
+	for _, alternatives := range profile.Modules {
+		found := false
+		for _, module := range alternatives {
+			if modules[module] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("- [%s] kernel module '%s' is not available: load it with `sudo modprobe %s` (add it to /etc/modules-load.d/k3d.conf to load it on boot)", name, strings.Join(alternatives, "' or '"), alternatives[0]))
+		}
+	}
+
+	sysctls := make([]string, 0, len(profile.Sysctls))
+	for key := range profile.Sysctls {
+		sysctls = append(sysctls, key)
+	}
+	sort.Strings(sysctls)
+	for _, key := range sysctls {
+		required := profile.Sysctls[key]
+		content, err := os.ReadFile(filepath.Join(hostRoot, "proc", "sys", strings.ReplaceAll(key, ".", "/")))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("- [%s] sysctl '%s' is not available (is the kernel module providing it loaded?)", name, key))
+			continue
+		}
+		if value := strings.TrimSpace(string(content)); value != required {
+			problems = append(problems, fmt.Sprintf("- [%s] sysctl '%s' is '%s', but must be '%s': set it with `sudo sysctl -w %s=%s` (add it to /etc/sysctl.d/99-k3d.conf to make it permanent)", name, key, value, required, key, required))
+		}
+	}
+
+	paths := make([]string, 0, len(profile.Paths))
+	for path := range profile.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := os.Stat(filepath.Join(hostRoot, path)); err != nil {
+			problems = append(problems, fmt.Sprintf("- [%s] '%s' does not exist: %s", name, path, profile.Paths[path]))
+		}
+	}
+
+	return problems
+}
+
+// hostKernelModules returns the set of kernel modules that are loaded or built into the kernel of the host mounted at hostRoot
+func hostKernelModules(hostRoot string) map[string]bool {
+	modules := map[string]bool{}
+
+	if content, err := os.ReadFile(filepath.Join(hostRoot, "proc", "modules")); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				modules[fields[0]] = true
+			}
+		}
+	}
+
+	if release, err := os.ReadFile(filepath.Join(hostRoot, "proc", "sys", "kernel", "osrelease")); err == nil {
+		if content, err := os.ReadFile(filepath.Join(hostRoot, "lib", "modules", strings.TrimSpace(string(release)), "modules.builtin")); err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					module := strings.TrimSuffix(filepath.Base(line), ".ko")
+					modules[strings.ReplaceAll(module, "-", "_")] = true
+				}
+			}
+		}
+	}
+
+	return modules
+}
+
+func readIntFromFile(path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/fixes"
+)
+
+func Test_preflightCheckResources(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Role: k3d.ServerRole},
+			{Role: k3d.ServerRole},
+			{Role: k3d.ServerRole},
+			{Role: k3d.AgentRole},
+			{Role: k3d.AgentRole},
+			{Role: k3d.LoadBalancerRole},
+		},
+	}
+
+	memory, cpus := estimateClusterResources(cluster)
+	if memory != k3d.DefaultResourceEstimateBaseMemory+3*k3d.DefaultResourceEstimateServerMemory+2*k3d.DefaultResourceEstimateAgentMemory {
+		t.Errorf("unexpected memory estimate %d", memory)
+	}
+	if cpus != 4 {
+		t.Errorf("unexpected CPU estimate %f", cpus)
+	}
+
+	tests := []struct {
+		name     string
+		info     runtimeTypes.RuntimeInfo
+		warnings int
+	}{
+		{"enough resources", runtimeTypes.RuntimeInfo{Name: "docker", CPUs: 8, Memory: 16 * gib}, 0},
+		{"too little memory", runtimeTypes.RuntimeInfo{Name: "docker", CPUs: 8, Memory: 2 * gib}, 1},
+		{"too little memory and CPUs", runtimeTypes.RuntimeInfo{Name: "docker", CPUs: 2, Memory: 2 * gib}, 2},
+		{"unknown resources", runtimeTypes.RuntimeInfo{Name: "docker"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if warnings := preflightCheckResources(&tt.info, cluster); len(warnings) != tt.warnings {
+				t.Errorf("expected %d warnings, got %d: %v", tt.warnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_preflightCheckInotifyDockerDesktop(t *testing.T) {
+	if value, isSet := os.LookupEnv(string(fixes.EnvFixInotify)); isSet {
+		os.Unsetenv(string(fixes.EnvFixInotify))
+		defer os.Setenv(string(fixes.EnvFixInotify), value)
+	}
+	clusterConfig := &config.ClusterConfig{}
+	if warnings := preflightCheckInotify(&runtimeTypes.RuntimeInfo{Name: "docker", DockerDesktop: true}, clusterConfig); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	if !clusterConfig.ClusterCreateOpts.FixInotify {
+		t.Errorf("expected the inotify fix to be enabled on Docker Desktop")
+	}
+}
+
+func Test_checkInotifyLimits(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Nodes: []*k3d.Node{
+			{Role: k3d.ServerRole},
+			{Role: k3d.AgentRole},
+			{Role: k3d.LoadBalancerRole},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		watches   int
+		instances int
+		wantErr   bool
+	}{
+		{"recommended limits", k3d.DefaultInotifyMaxUserWatches, k3d.DefaultInotifyMaxUserInstances, false},
+		{"exactly enough", 2 * k3d.DefaultInotifyMinWatchesPerNode, 2 * k3d.DefaultInotifyMinInstancesPerNode, false},
+		{"too few instances", k3d.DefaultInotifyMaxUserWatches, k3d.DefaultInotifyMinInstancesPerNode, true},
+		{"too few watches", k3d.DefaultInotifyMinWatchesPerNode, k3d.DefaultInotifyMaxUserInstances, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInotifyLimits(tt.watches, tt.instances, cluster); (err != nil) != tt.wantErr {
+				t.Errorf("checkInotifyLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkProfileRequirements(t *testing.T) {
+	hostRoot := t.TempDir()
+	files := map[string]string{
+		"proc/modules":                            "kvm_amd 155648 0 - Live 0x0000000000000000\nkvm 1032192 1 kvm_amd, Live 0x0000000000000000\nip_vs 180224 0 - Live 0x0000000000000000\nnf_conntrack 172032 1 ip_vs, Live 0x0000000000000000\n",
+		"proc/sys/kernel/osrelease":               "5.15.0-test\n",
+		"lib/modules/5.15.0-test/modules.builtin": "kernel/drivers/net/tun.ko\nkernel/drivers/vhost/vhost-net.ko\n",
+		"proc/sys/net/core/bpf_jit_enable":        "0\n",
+		"dev/kvm":                                 "",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(hostRoot, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(hostRoot, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		profile  string
+		problems []string // substrings of the expected problems (in order)
+	}{
+		{profile: "kubevirt", problems: nil},
+		{profile: "ipvs", problems: []string{"'ip_vs_rr'", "'ip_vs_wrr'", "'ip_vs_sh'"}},
+		{profile: "cilium", problems: []string{"'cls_bpf'", "'sch_ingress'", "'vxlan'", "'xt_socket'", "`sudo sysctl -w net.core.bpf_jit_enable=1`", "'/sys/fs/bpf' does not exist"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			problems := checkProfileRequirements(tt.profile, k3d.CheckProfiles[tt.profile], hostRoot)
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tt.problems), len(problems), problems)
+			}
+			for i := range problems {
+				if !strings.Contains(problems[i], tt.problems[i]) {
+					t.Errorf("expected problem %d to contain %q, got %q", i, tt.problems[i], problems[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ProxyEnvVars are the proxy environment variables forwarded from the host into the nodes (--http-proxy)
+var ProxyEnvVars = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+// ClusterProxyEnv returns the environment variables to forward the host's proxy settings into the k3s nodes of the cluster.
+// Traffic within the cluster must not go through the proxy, so NO_PROXY is extended by the cluster network,
+// the pod and service CIDRs and the names of the cluster's nodes.
+// It returns nil, if no proxy is set on the host.
+func ClusterProxyEnv(cluster *k3d.Cluster) []string {
+	env := proxyEnv(os.LookupEnv, clusterNoProxy(cluster))
+	if env == nil {
+		l.Log().Warnf("Neither %s nor %s is set in the environment: not forwarding any proxy settings into the nodes", ProxyEnvVars[0], ProxyEnvVars[1])
+	}
+	return env
+}
+
+// proxyEnv builds the proxy environment variables (upper and lower case) from the given lookup function (e.g. os.LookupEnv),
+// appending the given entries to NO_PROXY
+func proxyEnv(lookup func(string) (string, bool), noProxy []string) []string {
+	values := map[string]string{}
+	for _, key := range ProxyEnvVars {
+		if value, ok := lookup(key); ok && value != "" {
+			values[key] = value
+		} else if value, ok := lookup(strings.ToLower(key)); ok && value != "" {
+			values[key] = value
+		}
+	}
+	if values["HTTP_PROXY"] == "" && values["HTTPS_PROXY"] == "" {
+		return nil
+	}
+
+	noProxyEntries := []string{}
+	seen := map[string]bool{}
+	for _, entry := range append(strings.Split(values["NO_PROXY"], ","), noProxy...) {
+		entry = strings.TrimSpace(entry)
+		if entry != "" && !seen[entry] {
+			seen[entry] = true
+			noProxyEntries = append(noProxyEntries, entry)
+		}
+	}
+	values["NO_PROXY"] = strings.Join(noProxyEntries, ",")
+
+	env := []string{}
+	for _, key := range ProxyEnvVars {
+		if values[key] == "" {
+			continue
+		}
+		// not all tools respect the upper case variables (e.g. curl ignores HTTP_PROXY), so set both
+		env = append(env, fmt.Sprintf("%s=%s", key, values[key]), fmt.Sprintf("%s=%s", strings.ToLower(key), values[key]))
+	}
+	return env
+}
+
+// clusterNoProxy returns the destinations within the cluster, which must not be reached via a proxy
+func clusterNoProxy(cluster *k3d.Cluster) []string {
+	noProxy := []string{"localhost", "127.0.0.1", "::1"}
+
+	if !cluster.Network.IPAM.IPPrefix.IsZero() {
+		noProxy = append(noProxy, cluster.Network.IPAM.IPPrefix.String())
+	}
+	if !cluster.Network.IPAM.IPv6Prefix.IsZero() {
+		noProxy = append(noProxy, cluster.Network.IPAM.IPv6Prefix.String())
+	}
+
+	clusterCIDR, _, serviceCIDR, _ := ClusterGetCIDRs(cluster)
+	// dual-stack clusters use comma-separated lists
+	noProxy = append(noProxy, strings.Split(clusterCIDR, ",")...)
+	noProxy = append(noProxy, strings.Split(serviceCIDR, ",")...)
+
+	// in-cluster DNS names
+	noProxy = append(noProxy, ".svc", "."+ClusterGetDomain(cluster), k3d.DefaultK3dInternalHostRecord)
+
+	// the nodes reach each other (e.g. agents reach the servers) by their container names
+	for _, node := range cluster.Nodes {
+		noProxy = append(noProxy, node.Name)
+	}
+	if cluster.ServerLoadBalancer != nil && cluster.ServerLoadBalancer.Node != nil {
+		noProxy = append(noProxy, cluster.ServerLoadBalancer.Node.Name)
+	}
+
+	return noProxy
+}
+
+// K3sArgValue returns the value of a k3s flag in the given args, supporting both `--flag=value` and `--flag value`
+func K3sArgValue(args []string, flag string) (string, bool) {
+	value, found := "", false
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--"+flag+"=") {
+			value, found = strings.TrimPrefix(arg, "--"+flag+"="), true
+		} else if arg == "--"+flag && i+1 < len(args) {
+			value, found = args[i+1], true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"inet.af/netaddr"
+)
+
+func TestProxyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		noProxy []string
+		want    []string
+	}{
+		{
+			name: "no proxy",
+			env:  map[string]string{"NO_PROXY": "example.com"},
+			want: nil,
+		},
+		{
+			name:    "upper case",
+			env:     map[string]string{"HTTPS_PROXY": "http://proxy:3128", "NO_PROXY": "example.com, localhost"},
+			noProxy: []string{"localhost", "10.43.0.0/16"},
+			want: []string{
+				"HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128",
+				"NO_PROXY=example.com,localhost,10.43.0.0/16", "no_proxy=example.com,localhost,10.43.0.0/16",
+			},
+		},
+		{
+			name:    "lower case",
+			env:     map[string]string{"http_proxy": "http://proxy:3128", "https_proxy": "http://proxy:3129"},
+			noProxy: []string{"localhost"},
+			want: []string{
+				"HTTP_PROXY=http://proxy:3128", "http_proxy=http://proxy:3128",
+				"HTTPS_PROXY=http://proxy:3129", "https_proxy=http://proxy:3129",
+				"NO_PROXY=localhost", "no_proxy=localhost",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				value, ok := tt.env[key]
+				return value, ok
+			}
+			if got := proxyEnv(lookup, tt.noProxy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("proxyEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterNoProxy(t *testing.T) {
+	cluster := &k3d.Cluster{
+		Network: k3d.ClusterNetwork{IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.28.0.0/16")}},
+		Nodes: []*k3d.Node{
+			{Name: "k3d-test-server-0", Role: k3d.ServerRole, Args: []string{"--service-cidr", "10.100.0.0/16", "--cluster-cidr=10.99.0.0/16"}},
+			{Name: "k3d-test-agent-0", Role: k3d.AgentRole},
+		},
+	}
+	want := []string{
+		"localhost", "127.0.0.1", "::1",
+		"172.28.0.0/16", "10.99.0.0/16", "10.100.0.0/16",
+		".svc", ".cluster.local", "host.k3d.internal",
+		"k3d-test-server-0", "k3d-test-agent-0",
+	}
+	if got := clusterNoProxy(cluster); !reflect.DeepEqual(got, want) {
+		t.Errorf("clusterNoProxy() = %v, want %v", got, want)
+	}
+}
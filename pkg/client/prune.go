@@ -0,0 +1,367 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ClusterCreatedTime returns the creation time of a cluster.
+// It prefers the recorded creation timestamp and falls back to the creation time of the oldest node (for clusters created by older k3d versions).
+func ClusterCreatedTime(cluster *k3d.Cluster) (time.Time, error) {
+	if cluster.Created != "" {
+		created, err := time.Parse(time.RFC3339, cluster.Created)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse creation timestamp '%s' of cluster '%s': %w", cluster.Created, cluster.Name, err)
+		}
+		return created, nil
+	}
+
+	var oldest time.Time
+	for _, node := range cluster.Nodes {
+		created, err := time.Parse(time.RFC3339Nano, node.Created)
+		if err != nil {
+			l.Log().Debugf("Failed to parse creation time '%s' of node '%s': %v", node.Created, node.Name, err)
+			continue
+		}
+		if oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	if oldest.IsZero() {
+		return oldest, fmt.Errorf("failed to determine creation time of cluster '%s'", cluster.Name)
+	}
+	return oldest, nil
+}
+
+// ClusterPruneList returns all clusters that match the given prune options
+func ClusterPruneList(ctx context.Context, runtime runtimes.Runtime, opts k3d.ClusterPruneOpts) ([]*k3d.Cluster, error) {
+	clusters, err := ClusterList(ctx, runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var matches []*k3d.Cluster
+	for _, cluster := range clusters {
+		match, err := clusterMatchesPruneOpts(cluster, opts, now)
+		if err != nil {
+			l.Log().Warnf("Not pruning cluster '%s': %v", cluster.Name, err)
+			continue
+		}
+		if !match {
+			continue
+		}
+		if opts.ExternalIDGone != "" {
+			if cluster.ExternalID == "" {
+				continue
+			}
+			gone, err := ClusterExternalIDGone(ctx, opts.ExternalIDGone, cluster)
+			if err != nil {
+				l.Log().Warnf("Not pruning cluster '%s': %v", cluster.Name, err)
+				continue
+			}
+			if !gone {
+				l.Log().Debugf("External resource '%s' of cluster '%s' still exists", cluster.ExternalID, cluster.Name)
+				continue
+			}
+		}
+		matches = append(matches, cluster)
+	}
+	return matches, nil
+}
+
+// ClusterExternalIDGone checks whether the external resource (e.g. a pull request or branch) the cluster belongs to is gone, using the given check:
+// - a http(s) URL is requested via GET: 2xx means that it exists, 404 and 410 that it's gone
+// - anything else is run as a shell command: exit code 0 means that it exists, 1 that it's gone
+// $K3D_CLUSTER and $K3D_EXTERNAL_ID are set in the environment of the command and replaced in the URL (escaped).
+// Any other result is returned as an error, so that clusters are never deleted because of a failing check.
+func ClusterExternalIDGone(ctx context.Context, check string, cluster *k3d.Cluster) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, k3d.DefaultExternalIDCheckTimeout)
+	defer cancel()
+
+	vars := map[string]string{
+		"K3D_CLUSTER":     cluster.Name,
+		"K3D_EXTERNAL_ID": cluster.ExternalID,
+	}
+
+	if strings.HasPrefix(check, "http://") || strings.HasPrefix(check, "https://") {
+		checkURL := os.Expand(check, func(key string) string {
+			if value, ok := vars[key]; ok {
+				return url.PathEscape(value)
+			}
+			return "$" + key
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkURL, nil)
+		if err != nil {
+			return false, fmt.Errorf("invalid external ID check URL '%s': %w", checkURL, err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false, fmt.Errorf("failed to check external ID '%s': %w", cluster.ExternalID, err)
+		}
+		resp.Body.Close()
+		return externalIDGoneFromStatusCode(cluster.ExternalID, resp.StatusCode)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", check)
+	cmd.Env = os.Environ()
+	for key, value := range vars {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to check external ID '%s': %w: %s", cluster.ExternalID, err, strings.TrimSpace(stderr.String()))
+	}
+}
+
+// externalIDGoneFromStatusCode interprets the status code of an external ID check URL
+func externalIDGoneFromStatusCode(externalID string, statusCode int) (bool, error) {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return false, nil
+	case statusCode == http.StatusNotFound || statusCode == http.StatusGone:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to check external ID '%s': unexpected status %d", externalID, statusCode)
+	}
+}
+
+func clusterMatchesPruneOpts(cluster *k3d.Cluster, opts k3d.ClusterPruneOpts, now time.Time) (bool, error) {
+	if len(opts.NamePatterns) > 0 {
+		nameMatch := false
+		for _, pattern := range opts.NamePatterns {
+			matched, err := path.Match(pattern, cluster.Name)
+			if err != nil {
+				return false, fmt.Errorf("invalid name pattern '%s': %w", pattern, err)
+			}
+			if matched {
+				nameMatch = true
+				break
+			}
+		}
+		if !nameMatch {
+			return false, nil
+		}
+	}
+
+	if !ClusterMatchesRuntimeLabels(cluster, opts.RuntimeLabels) {
+		return false, nil
+	}
+
+	if opts.OlderThan > 0 {
+		created, err := ClusterCreatedTime(cluster)
+		if err != nil {
+			return false, err
+		}
+		if now.Sub(created) < opts.OlderThan {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ClusterOrphans are k3d resources left behind by crashed cluster creations or killed k3d processes
+type ClusterOrphans struct {
+	Nodes    []*k3d.Node // containers of clusters without any server node
+	Networks []string    // k3d networks no k3d container is connected to
+	Volumes  []string    // volumes of clusters without any container (except image volumes that are meant to be kept)
+}
+
+// Empty returns true if there are no orphans
+func (o *ClusterOrphans) Empty() bool {
+	return len(o.Nodes) == 0 && len(o.Networks) == 0 && len(o.Volumes) == 0
+}
+
+// ClusterOrphansList returns the k3d resources that don't belong to a (complete) cluster anymore.
+// Containers, networks and volumes are only considered if they were created longer ago than olderThan, so that cluster creations in progress are left alone.
+func ClusterOrphansList(ctx context.Context, runtime runtimes.Runtime, olderThan time.Duration) (*ClusterOrphans, error) {
+	nodes, err := runtime.GetNodesByLabel(ctx, k3d.DefaultRuntimeLabels)
+	if err != nil {
+		return nil, fmt.Errorf("runtime failed to list nodes: %w", err)
+	}
+	networks, err := runtime.GetNetworksCreatedByLabel(ctx, map[string]string{})
+	if err != nil {
+		return nil, fmt.Errorf("runtime failed to list networks: %w", err)
+	}
+	volumes, err := runtime.GetVolumesCreatedByLabel(ctx, map[string]string{})
+	if err != nil {
+		return nil, fmt.Errorf("runtime failed to list volumes: %w", err)
+	}
+	keptVolumes, err := runtime.GetVolumesByLabel(ctx, map[string]string{k3d.LabelImageVolumeKeep: "true"})
+	if err != nil {
+		return nil, fmt.Errorf("runtime failed to list kept image volumes: %w", err)
+	}
+
+	// volumes of clusters that are still around
+	var clusterVolumes []string
+	for _, cluster := range clustersWithServers(nodes) {
+		vols, err := runtime.GetVolumesByLabel(ctx, map[string]string{k3d.LabelClusterName: cluster})
+		if err != nil {
+			return nil, fmt.Errorf("runtime failed to list volumes of cluster '%s': %w", cluster, err)
+		}
+		clusterVolumes = append(clusterVolumes, vols...)
+	}
+
+	return findClusterOrphans(nodes, networks, volumes, append(keptVolumes, clusterVolumes...), olderThan, time.Now()), nil
+}
+
+// clustersWithServers returns the names of all clusters with at least one server node
+func clustersWithServers(nodes []*k3d.Node) []string {
+	var clusters []string
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		cluster := node.RuntimeLabels[k3d.LabelClusterName]
+		if node.Role == k3d.ServerRole && cluster != "" && !seen[cluster] {
+			seen[cluster] = true
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
+}
+
+// findClusterOrphans determines the orphans among the given k3d nodes, networks and volumes (name -> creation time) (keptVolumes are never orphans)
+func findClusterOrphans(nodes []*k3d.Node, networks map[string]time.Time, volumes map[string]time.Time, keptVolumes []string, olderThan time.Duration, now time.Time) *ClusterOrphans {
+	orphans := &ClusterOrphans{}
+
+	alive := map[string]bool{}
+	for _, cluster := range clustersWithServers(nodes) {
+		alive[cluster] = true
+	}
+
+	// nodes of clusters without servers (standalone registries and nodes without a cluster are never orphans)
+	usedNetworks := map[string]bool{}
+	usedVolumes := map[string]bool{}
+	for _, volume := range keptVolumes {
+		usedVolumes[volume] = true
+	}
+	for _, node := range nodes {
+		cluster := node.RuntimeLabels[k3d.LabelClusterName]
+		orphan := cluster != "" && !alive[cluster]
+		if orphan {
+			created, err := time.Parse(time.RFC3339Nano, node.Created)
+			orphan = olderThanAt(created, err == nil, olderThan, now)
+		}
+		if orphan {
+			orphans.Nodes = append(orphans.Nodes, node)
+			continue
+		}
+		for _, network := range node.Networks {
+			usedNetworks[network] = true
+		}
+		for _, volume := range node.Volumes {
+			usedVolumes[strings.SplitN(volume, ":", 2)[0]] = true
+		}
+		for _, volume := range node.AnonVolumes {
+			usedVolumes[strings.SplitN(volume, ":", 2)[0]] = true
+		}
+	}
+
+	for _, network := range sortedTimeKeys(networks) {
+		if !usedNetworks[network] && olderThanAt(networks[network], !networks[network].IsZero(), olderThan, now) {
+			orphans.Networks = append(orphans.Networks, network)
+		}
+	}
+	for _, volume := range sortedTimeKeys(volumes) {
+		if !usedVolumes[volume] && olderThanAt(volumes[volume], !volumes[volume].IsZero(), olderThan, now) {
+			orphans.Volumes = append(orphans.Volumes, volume)
+		}
+	}
+	return orphans
+}
+
+// olderThanAt returns true if a resource created at the given time is older than olderThan (resources with unknown creation time are only old enough without a minimum age)
+func olderThanAt(created time.Time, known bool, olderThan time.Duration, now time.Time) bool {
+	if olderThan <= 0 {
+		return true
+	}
+	return known && now.Sub(created) >= olderThan
+}
+
+func sortedTimeKeys(m map[string]time.Time) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ClusterOrphansDelete deletes the given orphans (containers first, so that their networks and volumes can be deleted as well)
+// and returns the number of resources that failed to be deleted.
+func ClusterOrphansDelete(ctx context.Context, runtime runtimes.Runtime, orphans *ClusterOrphans) int {
+	failed := 0
+	for _, node := range orphans.Nodes {
+		if err := runtime.DeleteNode(ctx, node); err != nil {
+			l.Log().Errorf("Failed to delete orphaned container '%s': %v", node.Name, err)
+			failed++
+			continue
+		}
+		l.Log().Infof("Deleted orphaned container %s", node.Name)
+	}
+	for _, network := range orphans.Networks {
+		if err := runtime.DeleteNetwork(ctx, network); err != nil {
+			if errors.Is(err, runtimeErr.ErrRuntimeNetworkNotEmpty) {
+				l.Log().Warnf("Not deleting orphaned network '%s', as non-k3d containers are still connected to it", network)
+				continue
+			}
+			l.Log().Errorf("Failed to delete orphaned network '%s': %v", network, err)
+			failed++
+			continue
+		}
+		l.Log().Infof("Deleted orphaned network %s", network)
+	}
+	for _, volume := range orphans.Volumes {
+		if err := runtime.DeleteVolume(ctx, volume); err != nil {
+			l.Log().Errorf("Failed to delete orphaned volume '%s': %v", volume, err)
+			failed++
+			continue
+		}
+		l.Log().Infof("Deleted orphaned volume %s", volume)
+	}
+	return failed
+}
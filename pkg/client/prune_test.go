@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterMatchesPruneOpts(t *testing.T) {
+	now := time.Date(2021, 11, 2, 12, 0, 0, 0, time.UTC)
+	cluster := &k3d.Cluster{
+		Name:    "ci-1234",
+		Created: "2021-11-01T06:00:00Z",
+		Nodes: []*k3d.Node{
+			{Name: "k3d-ci-1234-server-0", RuntimeLabels: map[string]string{"ci.job": "1234"}},
+		},
+	}
+	legacyCluster := &k3d.Cluster{
+		Name: "legacy",
+		Nodes: []*k3d.Node{
+			{Name: "k3d-legacy-server-0", Created: "2021-11-02T11:00:00.123456789Z"},
+			{Name: "k3d-legacy-agent-0", Created: "2021-11-02T11:30:00.123456789Z"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		cluster  *k3d.Cluster
+		opts     k3d.ClusterPruneOpts
+		expected bool
+	}{
+		{name: "older", cluster: cluster, opts: k3d.ClusterPruneOpts{OlderThan: 24 * time.Hour}, expected: true},
+		{name: "not old enough", cluster: cluster, opts: k3d.ClusterPruneOpts{OlderThan: 48 * time.Hour}, expected: false},
+		{name: "name match", cluster: cluster, opts: k3d.ClusterPruneOpts{NamePatterns: []string{"dev-*", "ci-*"}}, expected: true},
+		{name: "name mismatch", cluster: cluster, opts: k3d.ClusterPruneOpts{NamePatterns: []string{"dev-*"}}, expected: false},
+		{name: "label match", cluster: cluster, opts: k3d.ClusterPruneOpts{RuntimeLabels: map[string]string{"ci.job": "1234"}}, expected: true},
+		{name: "label mismatch", cluster: cluster, opts: k3d.ClusterPruneOpts{RuntimeLabels: map[string]string{"ci.job": "42"}}, expected: false},
+		{name: "all filters", cluster: cluster, opts: k3d.ClusterPruneOpts{OlderThan: time.Hour, NamePatterns: []string{"ci-*"}, RuntimeLabels: map[string]string{"ci.job": "1234"}}, expected: true},
+		{name: "legacy cluster uses oldest node", cluster: legacyCluster, opts: k3d.ClusterPruneOpts{OlderThan: 45 * time.Minute}, expected: true},
+		{name: "legacy cluster not old enough", cluster: legacyCluster, opts: k3d.ClusterPruneOpts{OlderThan: 2 * time.Hour}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := clusterMatchesPruneOpts(tc.cluster, tc.opts, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindClusterOrphans(t *testing.T) {
+	now := time.Date(2021, 11, 2, 12, 0, 0, 0, time.UTC)
+	node := func(name string, role k3d.Role, cluster string, created string, network string, volumes ...string) *k3d.Node {
+		labels := map[string]string{}
+		if cluster != "" {
+			labels[k3d.LabelClusterName] = cluster
+		}
+		return &k3d.Node{Name: name, Role: role, RuntimeLabels: labels, Created: created, Networks: []string{network}, Volumes: volumes}
+	}
+	nodes := []*k3d.Node{
+		// complete cluster
+		node("k3d-ok-server-0", k3d.ServerRole, "ok", "2021-11-01T12:00:00Z", "k3d-ok", "k3d-ok-images:/k3d/images"),
+		node("k3d-ok-serverlb", k3d.LoadBalancerRole, "ok", "2021-11-01T12:00:00Z", "k3d-ok"),
+		// creation crashed after creating the agents, long ago
+		node("k3d-crashed-agent-0", k3d.AgentRole, "crashed", "2021-11-02T06:00:00Z", "k3d-crashed", "k3d-crashed-images:/k3d/images"),
+		node("k3d-crashed-tools", k3d.NoRole, "crashed", "2021-11-02T06:00:00Z", "k3d-crashed", "k3d-crashed-images:/k3d/images"),
+		// creation in progress
+		node("k3d-new-agent-0", k3d.AgentRole, "new", "2021-11-02T11:59:00Z", "k3d-new"),
+		// standalone registry
+		node("k3d-registry", k3d.RegistryRole, "", "2021-10-01T12:00:00Z", "k3d-shared"),
+	}
+	old := now.Add(-6 * time.Hour)
+	networks := map[string]time.Time{"k3d-ok": old, "k3d-crashed": old, "k3d-new": now.Add(-time.Minute), "k3d-shared": old, "k3d-leaked": old, "k3d-unknown-age": {}}
+	volumes := map[string]time.Time{"k3d-ok-images": old, "k3d-crashed-images": old, "k3d-deleted-images": old, "k3d-kept-images": old, "k3d-new-images": now.Add(-time.Minute)}
+	keptVolumes := []string{"k3d-kept-images"}
+
+	orphans := findClusterOrphans(nodes, networks, volumes, keptVolumes, time.Hour, now)
+
+	var orphanNodes []string
+	for _, n := range orphans.Nodes {
+		orphanNodes = append(orphanNodes, n.Name)
+	}
+	if !reflect.DeepEqual(orphanNodes, []string{"k3d-crashed-agent-0", "k3d-crashed-tools"}) {
+		t.Errorf("unexpected orphaned nodes %v", orphanNodes)
+	}
+	if !reflect.DeepEqual(orphans.Networks, []string{"k3d-crashed", "k3d-leaked"}) {
+		t.Errorf("unexpected orphaned networks %v", orphans.Networks)
+	}
+	if !reflect.DeepEqual(orphans.Volumes, []string{"k3d-crashed-images", "k3d-deleted-images"}) {
+		t.Errorf("unexpected orphaned volumes %v", orphans.Volumes)
+	}
+
+	// without minimum age, the creation in progress (and resources of unknown age) are considered orphaned as well
+	orphans = findClusterOrphans(nodes, networks, volumes, keptVolumes, 0, now)
+	if len(orphans.Nodes) != 3 || !reflect.DeepEqual(orphans.Networks, []string{"k3d-crashed", "k3d-leaked", "k3d-new", "k3d-unknown-age"}) {
+		t.Errorf("expected the nodes and network of the cluster without servers to be orphaned, got %+v", orphans)
+	}
+
+	if !(&ClusterOrphans{}).Empty() || orphans.Empty() {
+		t.Errorf("unexpected result of Empty()")
+	}
+}
+
+func TestClusterExternalIDGone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/pulls/42":
+			w.WriteHeader(http.StatusOK)
+		case "/pulls/feature%2Fgone":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		check      string
+		externalID string
+		wantGone   bool
+		wantErr    bool
+	}{
+		{name: "command: exists", check: `test "$K3D_EXTERNAL_ID" = 42 && test "$K3D_CLUSTER" = preview || exit 3`, externalID: "42"},
+		{name: "command: gone", check: "exit 1", externalID: "42", wantGone: true},
+		{name: "command: failed", check: "exit 2", externalID: "42", wantErr: true},
+		{name: "url: exists", check: server.URL + "/pulls/$K3D_EXTERNAL_ID", externalID: "42"},
+		{name: "url: gone", check: server.URL + "/pulls/$K3D_EXTERNAL_ID", externalID: "feature/gone", wantGone: true},
+		{name: "url: failed", check: server.URL + "/other/$K3D_EXTERNAL_ID", externalID: "42", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gone, err := ClusterExternalIDGone(context.Background(), tt.check, &k3d.Cluster{Name: "preview", ExternalID: tt.externalID})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClusterExternalIDGone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gone != tt.wantGone {
+				t.Errorf("ClusterExternalIDGone() = %v, want %v", gone, tt.wantGone)
+			}
+		})
+	}
+}
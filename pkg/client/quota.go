@@ -0,0 +1,121 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"fmt"
+	"strconv"
+
+	dockerunits "github.com/docker/go-units"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// ClusterDivideResourceLimits splits cluster-wide CPU and memory limits into equal shares for the k3s nodes (servers and agents).
+// Nodes that already have a CPU or memory limit set (e.g. via --cpus or --servers-memory) keep it and their share is subtracted from the cluster budget.
+func ClusterDivideResourceLimits(nodes []*k3d.Node, cpuLimit string, memoryLimit string) error {
+	k3sNodes := []*k3d.Node{}
+	for _, node := range nodes {
+		if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+			k3sNodes = append(k3sNodes, node)
+		}
+	}
+	if len(k3sNodes) == 0 {
+		return nil
+	}
+
+	// CPU
+	if cpuLimit != "" {
+		cpus, err := strconv.ParseFloat(cpuLimit, 64)
+		if err != nil || cpus <= 0 {
+			return fmt.Errorf("invalid cluster cpu limit '%s': must be a positive number of CPUs (e.g. 1.5)", cpuLimit)
+		}
+
+		unlimitedNodes := []*k3d.Node{}
+		for _, node := range k3sNodes {
+			if node.CPUs == "" {
+				unlimitedNodes = append(unlimitedNodes, node)
+				continue
+			}
+			nodeCPUs, err := strconv.ParseFloat(node.CPUs, 64)
+			if err != nil {
+				return fmt.Errorf("invalid cpu limit '%s' for node '%s': %w", node.CPUs, node.Name, err)
+			}
+			cpus -= nodeCPUs
+		}
+		if len(unlimitedNodes) == 0 {
+			if cpus < 0 {
+				return fmt.Errorf("the cpu limits of the nodes exceed the cluster cpu limit '%s'", cpuLimit)
+			}
+		} else {
+			if cpus <= 0 {
+				return fmt.Errorf("no cpus left for %d node(s) within the cluster cpu limit '%s' after subtracting the per-node cpu limits", len(unlimitedNodes), cpuLimit)
+			}
+			share := strconv.FormatFloat(cpus/float64(len(unlimitedNodes)), 'f', 3, 64)
+			l.Log().Debugf("Dividing cluster cpu limit of %s CPUs into %d shares of %s CPUs", cpuLimit, len(unlimitedNodes), share)
+			for _, node := range unlimitedNodes {
+				node.CPUs = share
+			}
+		}
+	}
+
+	// Memory
+	if memoryLimit != "" {
+		memory, err := dockerunits.RAMInBytes(memoryLimit)
+		if err != nil || memory <= 0 {
+			return fmt.Errorf("invalid cluster memory limit '%s': must be a positive amount of memory (e.g. 4g)", memoryLimit)
+		}
+
+		unlimitedNodes := []*k3d.Node{}
+		for _, node := range k3sNodes {
+			if node.Memory == "" {
+				unlimitedNodes = append(unlimitedNodes, node)
+				continue
+			}
+			nodeMemory, err := dockerunits.RAMInBytes(node.Memory)
+			if err != nil {
+				return fmt.Errorf("invalid memory limit '%s' for node '%s': %w", node.Memory, node.Name, err)
+			}
+			memory -= nodeMemory
+		}
+		if len(unlimitedNodes) == 0 {
+			if memory < 0 {
+				return fmt.Errorf("the memory limits of the nodes exceed the cluster memory limit '%s'", memoryLimit)
+			}
+			return nil
+		}
+
+		share := memory / int64(len(unlimitedNodes))
+		if share <= 0 {
+			return fmt.Errorf("no memory left for %d node(s) within the cluster memory limit '%s' after subtracting the per-role and per-node memory limits", len(unlimitedNodes), memoryLimit)
+		}
+		if share < k3d.DefaultResourceEstimateAgentMemory {
+			l.Log().Warnf("Cluster memory limit '%s' leaves only %s per node, which is likely not enough to run k3s", memoryLimit, dockerunits.BytesSize(float64(share)))
+		}
+		l.Log().Debugf("Dividing cluster memory limit of %s into %d shares of %d bytes", memoryLimit, len(unlimitedNodes), share)
+		for _, node := range unlimitedNodes {
+			node.Memory = strconv.FormatInt(share, 10)
+		}
+	}
+
+	return nil
+}
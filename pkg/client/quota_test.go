@@ -0,0 +1,111 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestClusterDivideResourceLimits(t *testing.T) {
+	newNodes := func(serverMemory string) []*k3d.Node {
+		return []*k3d.Node{
+			{Name: "server-0", Role: k3d.ServerRole, Memory: serverMemory},
+			{Name: "agent-0", Role: k3d.AgentRole},
+			{Name: "agent-1", Role: k3d.AgentRole},
+			{Name: "agent-2", Role: k3d.AgentRole},
+			{Name: "serverlb", Role: k3d.LoadBalancerRole},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		nodes          []*k3d.Node
+		cpuLimit       string
+		memoryLimit    string
+		expectedCPUs   []string
+		expectedMemory []string
+		expectError    bool
+	}{
+		{
+			name:           "no limits",
+			nodes:          newNodes(""),
+			expectedCPUs:   []string{"", "", "", "", ""},
+			expectedMemory: []string{"", "", "", "", ""},
+		},
+		{
+			name:           "equal shares",
+			nodes:          newNodes(""),
+			cpuLimit:       "2",
+			memoryLimit:    "4g",
+			expectedCPUs:   []string{"0.500", "0.500", "0.500", "0.500", ""},
+			expectedMemory: []string{"1073741824", "1073741824", "1073741824", "1073741824", ""},
+		},
+		{
+			name:           "role memory is subtracted",
+			nodes:          newNodes("1g"),
+			memoryLimit:    "4g",
+			expectedCPUs:   []string{"", "", "", "", ""},
+			expectedMemory: []string{"1g", "1073741824", "1073741824", "1073741824", ""},
+		},
+		{
+			name: "node cpus are subtracted",
+			nodes: []*k3d.Node{
+				{Name: "server-0", Role: k3d.ServerRole, CPUs: "1"},
+				{Name: "agent-0", Role: k3d.AgentRole},
+				{Name: "agent-1", Role: k3d.AgentRole},
+			},
+			cpuLimit:       "2",
+			expectedCPUs:   []string{"1", "0.500", "0.500"},
+			expectedMemory: []string{"", "", ""},
+		},
+		{name: "node cpus exceed limit", nodes: []*k3d.Node{{Name: "server-0", Role: k3d.ServerRole, CPUs: "3"}}, cpuLimit: "2", expectError: true},
+		{name: "role memory exceeds limit", nodes: newNodes("5g"), memoryLimit: "4g", expectError: true},
+		{name: "invalid cpu limit", nodes: newNodes(""), cpuLimit: "two", expectError: true},
+		{name: "negative cpu limit", nodes: newNodes(""), cpuLimit: "-1", expectError: true},
+		{name: "invalid memory limit", nodes: newNodes(""), memoryLimit: "lots", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClusterDivideResourceLimits(tt.nodes, tt.cpuLimit, tt.memoryLimit)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, node := range tt.nodes {
+				if node.CPUs != tt.expectedCPUs[i] {
+					t.Errorf("node %s: expected cpus '%s', got '%s'", node.Name, tt.expectedCPUs[i], node.CPUs)
+				}
+				if node.Memory != tt.expectedMemory[i] {
+					t.Errorf("node %s: expected memory '%s', got '%s'", node.Name, tt.expectedMemory[i], node.Memory)
+				}
+			}
+		})
+	}
+}
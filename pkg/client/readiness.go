@@ -0,0 +1,107 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	"github.com/rancher/k3d/v5/pkg/tracing"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// nodeExitedCheckInterval is the interval in which the container status is checked while waiting for a node to get ready
+const nodeExitedCheckInterval = time.Second
+
+// nodeExitedConfirmations is the number of consecutive checks that have to see a stopped container before giving up on it,
+// so that a container that's about to be restarted (restart policy) isn't mistaken for a failed one
+const nodeExitedConfirmations = 3
+
+// NodeWaitForReady waits for a node to get ready, i.e. until its role-specific ready marker shows up in its logs since the given time.
+// Unlike only waiting for the log message, it fails early if the node's container stops instead of waiting for the timeout.
+// Cancelling the context (e.g. via a shared timeout) stops waiting.
+func NodeWaitForReady(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, readyLogMessage string, since time.Time) (err error) {
+	ctx, span := tracing.Start(ctx, "NodeWaitForReady", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
+
+	if readyLogMessage == "" {
+		return fmt.Errorf("no ready log message defined for node '%s' (role %s)", node.Name, node.Role)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	waitGroup, waitCtx := errgroup.WithContext(ctx)
+	waitGroup.Go(func() error {
+		// the node is ready: stop watching its status
+		defer cancel()
+		return NodeWaitForLogMessage(waitCtx, runtime, node, readyLogMessage, since)
+	})
+	waitGroup.Go(func() error {
+		return nodeWatchForExit(waitCtx, runtime, node)
+	})
+	return waitGroup.Wait()
+}
+
+// NodeGetReadyLogMessage returns the log message signaling that the node is ready, preferring the override for the node's role
+func NodeGetReadyLogMessage(node *k3d.Node, readyLogMessages map[k3d.Role]string, intent k3d.Intent) string {
+	// the initializing server is special, as it's ready way before it has quorum
+	if !(node.Role == k3d.ServerRole && node.ServerOpts.IsInit) {
+		if message, ok := readyLogMessages[node.Role]; ok && message != "" {
+			return message
+		}
+	}
+	return k3d.GetReadyLogMessage(node, intent)
+}
+
+// nodeWatchForExit returns an error once the node's container stopped (and isn't restarting) and nil when the context is done
+func nodeWatchForExit(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node) error {
+	ticker := time.NewTicker(nodeExitedCheckInterval)
+	defer ticker.Stop()
+
+	stopped := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		running, status, err := runtime.GetNodeStatus(ctx, node)
+		if err != nil {
+			l.Log().Tracef("Failed to get status of node '%s' while waiting for it to get ready: %v", node.Name, err)
+			continue
+		}
+		if running || status == k3d.NodeStatusRestarting {
+			stopped = 0
+			continue
+		}
+		stopped++
+		if stopped >= nodeExitedConfirmations {
+			return fmt.Errorf("container of node '%s' stopped (status: %s) while waiting for it to get ready: check its logs", node.Name, status)
+		}
+	}
+}
@@ -55,7 +55,7 @@ func RegistryCreate(ctx context.Context, runtime runtimes.Runtime, reg *k3d.Regi
 
 	// registry name
 	if len(reg.Host) == 0 {
-		reg.Host = k3d.DefaultRegistryName
+		reg.Host = k3d.GetDefaultObjectName("registry")
 	}
 	// if err := ValidateHostname(reg.Host); err != nil {
 	// 	l.Log().Errorln("Invalid name for registry")
@@ -195,6 +195,17 @@ func RegistryGenerateK3sConfig(ctx context.Context, registries []*k3d.Registry)
 			},
 		}
 
+		// images pushed from the host (e.g. to localhost:<hostport>) can be pulled using the same reference
+		if hostPort := reg.ExposureOpts.Binding.HostPort; hostPort != "" {
+			for _, address := range []string{RegistryExternalAddress(reg), fmt.Sprintf("localhost:%s", hostPort)} {
+				regConf.Mirrors[address] = k3s.Mirror{
+					Endpoints: []string{
+						fmt.Sprintf("http://%s", internalAddress),
+					},
+				}
+			}
+		}
+
 		if reg.Options.Proxy.RemoteURL != "" {
 			regConf.Mirrors[reg.Options.Proxy.RemoteURL] = k3s.Mirror{
 				Endpoints: []string{fmt.Sprintf("http://%s", internalAddress)},
@@ -254,6 +265,18 @@ func RegistryFromNode(node *k3d.Node) (*k3d.Registry, error) {
 
 }
 
+// RegistryExternalAddress returns the HOST:PORT address under which the registry can be reached from the host
+func RegistryExternalAddress(reg *k3d.Registry) string {
+	host := reg.ExposureOpts.Host
+	if host == "" {
+		host = reg.ExposureOpts.Binding.HostIP
+	}
+	if host == "" || host == k3d.DefaultAPIHost {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s:%s", host, reg.ExposureOpts.Binding.HostPort)
+}
+
 // RegistryGenerateLocalRegistryHostingConfigMapYAML generates a ConfigMap used to advertise the registries in the cluster
 func RegistryGenerateLocalRegistryHostingConfigMapYAML(ctx context.Context, runtime runtimes.Runtime, registries []*k3d.Registry) ([]byte, error) {
 
@@ -66,3 +66,48 @@ data:
 	}
 
 }
+
+func TestRegistryExternalAddress(t *testing.T) {
+	tests := []struct {
+		host, hostIP, expected string
+	}{
+		{host: "registry.localhost", hostIP: "0.0.0.0", expected: "registry.localhost:5000"},
+		{hostIP: "127.0.0.1", expected: "127.0.0.1:5000"},
+		{hostIP: "0.0.0.0", expected: "localhost:5000"},
+		{expected: "localhost:5000"},
+	}
+	for _, tc := range tests {
+		reg := &k3d.Registry{}
+		reg.ExposureOpts.Host = tc.host
+		reg.ExposureOpts.Binding.HostIP = tc.hostIP
+		reg.ExposureOpts.Binding.HostPort = "5000"
+		if actual := RegistryExternalAddress(reg); actual != tc.expected {
+			t.Errorf("expected '%s', got '%s'", tc.expected, actual)
+		}
+	}
+}
+
+func TestRegistryGenerateK3sConfig(t *testing.T) {
+	reg := &k3d.Registry{
+		Host: "k3d-myregistry",
+	}
+	reg.ExposureOpts.Port = nat.Port("5000/tcp")
+	reg.ExposureOpts.Binding.HostIP = "0.0.0.0"
+	reg.ExposureOpts.Binding.HostPort = "12345"
+
+	regConf, err := RegistryGenerateK3sConfig(context.Background(), []*k3d.Registry{reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, address := range []string{"k3d-myregistry:5000", "k3d-myregistry:12345", "localhost:12345"} {
+		mirror, ok := regConf.Mirrors[address]
+		if !ok {
+			t.Errorf("missing mirror for '%s'", address)
+			continue
+		}
+		if len(mirror.Endpoints) != 1 || mirror.Endpoints[0] != "http://k3d-myregistry:5000" {
+			t.Errorf("unexpected endpoints for mirror '%s': %v", address, mirror.Endpoints)
+		}
+	}
+}
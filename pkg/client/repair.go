@@ -0,0 +1,158 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// nodeRunWithFailurePolicy runs a step of the cluster creation (e.g. creating or starting) for the given node.
+// Failures of agent nodes are handled according to the policy, while failures of any other node are returned as they are.
+// The returned bool is true, if the node failed, but the cluster creation should continue without it.
+func nodeRunWithFailurePolicy(ctx context.Context, runtime k3drt.Runtime, node *k3d.Node, policy k3d.NodeFailurePolicy, step string, run func() error, reset func()) (bool, error) {
+	err := run()
+	if err == nil || node.Role != k3d.AgentRole {
+		return false, err
+	}
+
+	switch policy {
+	case k3d.NodeFailurePolicyRetry:
+		for attempt := 1; attempt <= k3d.DefaultNodeFailureRetries; attempt++ {
+			l.Log().Warnf("Failed to %s agent '%s' (retry %d/%d): %v", step, node.Name, attempt, k3d.DefaultNodeFailureRetries, err)
+			reset()
+			if err := util.SleepWithContext(ctx, time.Duration(attempt)*time.Second); err != nil {
+				return false, fmt.Errorf("stopped retrying to %s agent '%s': %w", step, node.Name, err)
+			}
+			if err = run(); err == nil {
+				return false, nil
+			}
+		}
+		return false, fmt.Errorf("failed to %s agent '%s' after %d retries: %w", step, node.Name, k3d.DefaultNodeFailureRetries, err)
+	case k3d.NodeFailurePolicyContinue:
+		l.Log().Warnf("Failed to %s agent '%s', continuing without it: %v", step, node.Name, err)
+		// the event marks the node for `k3d cluster repair`
+		ClusterEventRecord(node.RuntimeLabels[k3d.LabelClusterName], k3d.ClusterEventNodeFailed, node.Name, fmt.Sprintf("Failed to %s agent node '%s': %v", step, node.Name, err))
+		if err := NodeDelete(ctx, runtime, node, k3d.NodeDeleteOpts{SkipLBUpdate: true}); err != nil {
+			l.Log().Warnf("Failed to clean up failed agent '%s': %v", node.Name, err)
+		}
+		return true, nil
+	}
+
+	return false, err
+}
+
+// ClusterFailedNodes returns the names of the agent nodes that failed during the creation of the given cluster
+// (with the NodeFailurePolicyContinue) and that were not added to the cluster again since.
+func ClusterFailedNodes(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster) ([]string, error) {
+	events, err := ClusterEventList(cluster.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log of cluster '%s': %w", cluster.Name, err)
+	}
+
+	existing := map[string]bool{}
+	for _, node := range cluster.Nodes {
+		existing[node.Name] = true
+	}
+
+	failed := []string{}
+	for _, name := range failedNodesFromEvents(events) {
+		if !existing[name] {
+			failed = append(failed, name)
+		}
+	}
+	return failed, nil
+}
+
+// failedNodesFromEvents returns the names of the nodes that failed and were neither added nor deleted afterwards, in the order they failed
+func failedNodesFromEvents(events []k3d.ClusterEvent) []string {
+	pending := map[string]bool{}
+	order := []string{}
+	for _, event := range events {
+		switch event.Type {
+		case k3d.ClusterEventNodeFailed:
+			if !pending[event.Node] {
+				pending[event.Node] = true
+				order = append(order, event.Node)
+			}
+		case k3d.ClusterEventNodeAdded, k3d.ClusterEventNodeDeleted:
+			delete(pending, event.Node)
+		}
+	}
+
+	failed := []string{}
+	for _, name := range order {
+		if pending[name] {
+			failed = append(failed, name)
+			delete(pending, name) // a node is listed twice, if it failed again after being added
+		}
+	}
+	return failed
+}
+
+// ClusterRepair re-creates the agent nodes that failed during the creation of the given cluster, based on its remaining nodes.
+// It returns the names of the repaired nodes.
+func ClusterRepair(ctx context.Context, runtime k3drt.Runtime, cluster *k3d.Cluster, opts k3d.ClusterRepairOpts) ([]string, error) {
+	cluster, err := ClusterGet(ctx, runtime, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	failed, err := ClusterFailedNodes(ctx, runtime, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := []string{}
+	for _, name := range failed {
+		l.Log().Infof("Re-creating failed agent '%s'...", name)
+		node := &k3d.Node{
+			Name: name,
+			Role: k3d.AgentRole,
+		}
+		if err := NodeAddToCluster(ctx, runtime, node, cluster, k3d.NodeCreateOpts{Wait: opts.Wait, Timeout: opts.Timeout}); err != nil {
+			return repaired, fmt.Errorf("failed to repair agent '%s': %w", name, err)
+		}
+		repaired = append(repaired, name)
+	}
+	return repaired, nil
+}
+
+// clusterDropNodes removes the given (failed) nodes from the cluster's list of nodes
+func clusterDropNodes(cluster *k3d.Cluster, drop map[*k3d.Node]bool) {
+	if len(drop) == 0 {
+		return
+	}
+	nodes := []*k3d.Node{}
+	for _, node := range cluster.Nodes {
+		if !drop[node] {
+			nodes = append(nodes, node)
+		}
+	}
+	cluster.Nodes = nodes
+}
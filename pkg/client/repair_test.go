@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestFailedNodesFromEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []k3d.ClusterEvent
+		want   []string
+	}{
+		{
+			name:   "no events",
+			events: nil,
+			want:   []string{},
+		},
+		{
+			name: "failed agents",
+			events: []k3d.ClusterEvent{
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-3"},
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-1"},
+				{Type: k3d.ClusterEventCreated},
+			},
+			want: []string{"k3d-test-agent-3", "k3d-test-agent-1"},
+		},
+		{
+			name: "repaired and deleted agents",
+			events: []k3d.ClusterEvent{
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-1"},
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-2"},
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-3"},
+				{Type: k3d.ClusterEventNodeAdded, Node: "k3d-test-agent-1"},
+				{Type: k3d.ClusterEventNodeDeleted, Node: "k3d-test-agent-3"},
+			},
+			want: []string{"k3d-test-agent-2"},
+		},
+		{
+			name: "agent failed again after repair",
+			events: []k3d.ClusterEvent{
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-1"},
+				{Type: k3d.ClusterEventNodeAdded, Node: "k3d-test-agent-1"},
+				{Type: k3d.ClusterEventNodeFailed, Node: "k3d-test-agent-1"},
+			},
+			want: []string{"k3d-test-agent-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failedNodesFromEvents(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failedNodesFromEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterDropNodes(t *testing.T) {
+	server := &k3d.Node{Name: "k3d-test-server-0", Role: k3d.ServerRole}
+	agent0 := &k3d.Node{Name: "k3d-test-agent-0", Role: k3d.AgentRole}
+	agent1 := &k3d.Node{Name: "k3d-test-agent-1", Role: k3d.AgentRole}
+	cluster := &k3d.Cluster{Nodes: []*k3d.Node{server, agent0, agent1}}
+
+	clusterDropNodes(cluster, map[*k3d.Node]bool{agent0: true})
+
+	if want := []*k3d.Node{server, agent1}; !reflect.DeepEqual(cluster.Nodes, want) {
+		t.Errorf("expected nodes %v, got %v", want, cluster.Nodes)
+	}
+}
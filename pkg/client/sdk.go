@@ -0,0 +1,266 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Errors returned by the Client, which can be checked using errors.Is
+var (
+	ErrClusterExists   = errors.New("cluster already exists")
+	ErrClusterNotFound = errors.New("cluster not found")
+	ErrNodeNotFound    = errors.New("node not found")
+)
+
+// ClusterCreateError is returned by Client.CreateCluster if the cluster could not be created.
+// It tells whether the partially created cluster got rolled back.
+type ClusterCreateError struct {
+	Cluster     string
+	Err         error
+	RolledBack  bool
+	RollbackErr error // set if rolling back failed as well
+}
+
+func (e *ClusterCreateError) Error() string {
+	switch {
+	case e.RollbackErr != nil:
+		return fmt.Sprintf("failed to create cluster '%s': %v (also failed to roll back: %v)", e.Cluster, e.Err, e.RollbackErr)
+	case e.RolledBack:
+		return fmt.Sprintf("failed to create cluster '%s' (rolled back): %v", e.Cluster, e.Err)
+	default:
+		return fmt.Sprintf("failed to create cluster '%s': %v", e.Cluster, e.Err)
+	}
+}
+
+func (e *ClusterCreateError) Unwrap() error {
+	return e.Err
+}
+
+// CreateClusterOpts are the options of Client.CreateCluster
+type CreateClusterOpts struct {
+	NoRollback bool                  // keep whatever got created if the creation fails (e.g. to debug it), instead of deleting it again
+	Events     chan<- progress.Event // receives the progress events of the creation (and of other operations running in this process meanwhile), see progress.Subscribe
+	Verify     func() error          // optional check once the cluster is up (e.g. whether the node hooks succeeded): an error fails the creation like any other
+}
+
+// Client is the library API of k3d for embedding it, e.g. in test frameworks spinning up clusters programmatically.
+// It wraps the functions of this package for a container runtime: all methods report failures as errors (see the Err* variables)
+// and never exit the process or prompt for input.
+//
+// A cluster spec is created from a SimpleConfig (the config file format) like the CLI does it:
+//
+//	simpleCfg := conf.SimpleConfig{Name: "test", Servers: 1, Image: "rancher/k3s:v1.22.4-k3s1"}
+//	_ = config.ProcessSimpleConfig(&simpleCfg)
+//	spec, err := config.TransformSimpleToClusterConfig(ctx, runtimes.Docker, simpleCfg)
+//	// optionally: config.ValidateClusterConfig(ctx, runtimes.Docker, *spec)
+//	cluster, err := client.New(runtimes.Docker).CreateCluster(ctx, spec, client.CreateClusterOpts{})
+type Client struct {
+	runtime k3drt.Runtime
+}
+
+// New returns a new Client for the given runtime (the selected runtime, if nil)
+func New(runtime k3drt.Runtime) *Client {
+	if runtime == nil {
+		runtime = k3drt.SelectedRuntime
+	}
+	return &Client{runtime: runtime}
+}
+
+// Runtime returns the container runtime used by the client
+func (c *Client) Runtime() k3drt.Runtime {
+	return c.runtime
+}
+
+// CreateCluster creates and starts the cluster described by the spec and returns it.
+// If the creation fails, everything created so far gets rolled back (unless disabled) and a *ClusterCreateError is returned.
+// The kubeconfig gets written as configured in spec.KubeconfigOpts (nowhere by default, see GetKubeconfig).
+func (c *Client) CreateCluster(ctx context.Context, spec *config.ClusterConfig, opts CreateClusterOpts) (*k3d.Cluster, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("no cluster spec given")
+	}
+	name := spec.Cluster.Name
+	if _, err := ClusterGet(ctx, c.runtime, &k3d.Cluster{Name: name}); err == nil {
+		return nil, fmt.Errorf("cluster '%s': %w", name, ErrClusterExists)
+	}
+
+	if opts.Events != nil {
+		unsubscribe := progress.Subscribe(opts.Events)
+		defer unsubscribe()
+	}
+
+	err := ClusterRun(ctx, c.runtime, spec)
+	if err == nil && opts.Verify != nil {
+		err = opts.Verify()
+	}
+	if err != nil {
+		createErr := &ClusterCreateError{Cluster: name, Err: err}
+		if !opts.NoRollback {
+			l.Log().Errorf("Failed to create cluster '%s' >>> Rolling Back", name)
+			// rollback with a fresh context, as the given one may have been canceled
+			if err := ClusterDelete(context.Background(), c.runtime, &spec.Cluster, k3d.ClusterDeleteOpts{SkipRegistryCheck: true}); err != nil {
+				createErr.RollbackErr = err
+			} else {
+				createErr.RolledBack = true
+			}
+		}
+		return nil, createErr
+	}
+
+	return c.GetCluster(ctx, name)
+}
+
+// GetCluster returns the cluster with the given name including all of its nodes
+func (c *Client) GetCluster(ctx context.Context, name string) (*k3d.Cluster, error) {
+	cluster, err := ClusterGet(ctx, c.runtime, &k3d.Cluster{Name: name})
+	if err != nil {
+		if errors.Is(err, ClusterGetNoNodesFoundError) {
+			return nil, fmt.Errorf("cluster '%s': %w", name, ErrClusterNotFound)
+		}
+		return nil, fmt.Errorf("failed to get cluster '%s': %w", name, err)
+	}
+	return cluster, nil
+}
+
+// ListClusters returns all existing clusters
+func (c *Client) ListClusters(ctx context.Context) ([]*k3d.Cluster, error) {
+	return ClusterList(ctx, c.runtime)
+}
+
+// DeleteCluster deletes the cluster with the given name and removes it from the kubeconfig files it was written to
+func (c *Client) DeleteCluster(ctx context.Context, name string, opts k3d.ClusterDeleteOpts) error {
+	cluster, err := c.GetCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := ClusterDelete(ctx, c.runtime, cluster, opts); err != nil {
+		return fmt.Errorf("failed to delete cluster '%s': %w", name, err)
+	}
+	KubeconfigRemoveClusterFromAll(ctx, cluster)
+	if err := ClusterJobDelete(name); err != nil {
+		l.Log().Warnf("Failed to delete background creation state of cluster '%s': %v", name, err)
+	}
+	return nil
+}
+
+// StartCluster starts all nodes of the cluster with the given name
+func (c *Client) StartCluster(ctx context.Context, name string, opts k3d.ClusterStartOpts) error {
+	cluster, err := c.GetCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := ClusterStart(ctx, c.runtime, cluster, opts); err != nil {
+		return fmt.Errorf("failed to start cluster '%s': %w", name, err)
+	}
+	return nil
+}
+
+// StopCluster stops all nodes of the cluster with the given name
+func (c *Client) StopCluster(ctx context.Context, name string) error {
+	cluster, err := c.GetCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := ClusterStop(ctx, c.runtime, cluster); err != nil {
+		return fmt.Errorf("failed to stop cluster '%s': %w", name, err)
+	}
+	return nil
+}
+
+// GetKubeconfig returns the kubeconfig to access the cluster with the given name (e.g. to be written using clientcmd.Write)
+func (c *Client) GetKubeconfig(ctx context.Context, name string) (*clientcmdapi.Config, error) {
+	cluster, err := c.GetCluster(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := KubeconfigGet(ctx, c.runtime, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig of cluster '%s': %w", name, err)
+	}
+	return kubeconfig, nil
+}
+
+// CreateNode creates the node in the cluster with the given name and starts it.
+// Unset fields (e.g. the image) are taken from the existing nodes of the cluster.
+func (c *Client) CreateNode(ctx context.Context, clusterName string, node *k3d.Node, opts k3d.NodeCreateOpts) error {
+	cluster, err := c.GetCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	if err := NodeAddToCluster(ctx, c.runtime, node, cluster, opts); err != nil {
+		return fmt.Errorf("failed to add node '%s' to cluster '%s': %w", node.Name, clusterName, err)
+	}
+	return nil
+}
+
+// GetNode returns the node with the given name
+func (c *Client) GetNode(ctx context.Context, name string) (*k3d.Node, error) {
+	node, err := NodeGet(ctx, c.runtime, &k3d.Node{Name: name})
+	if err != nil {
+		if errors.Is(err, runtimeErr.ErrRuntimeContainerNotExists) {
+			return nil, fmt.Errorf("node '%s': %w", name, ErrNodeNotFound)
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// DeleteNode deletes the node with the given name
+func (c *Client) DeleteNode(ctx context.Context, name string, opts k3d.NodeDeleteOpts) error {
+	node, err := c.GetNode(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := NodeDelete(ctx, c.runtime, node, opts); err != nil {
+		return fmt.Errorf("failed to delete node '%s': %w", name, err)
+	}
+	return nil
+}
+
+// StartNode starts the (stopped) node with the given name
+func (c *Client) StartNode(ctx context.Context, name string) error {
+	node, err := c.GetNode(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.runtime.StartNode(ctx, node)
+}
+
+// StopNode stops the node with the given name
+func (c *Client) StopNode(ctx context.Context, name string) error {
+	node, err := c.GetNode(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.runtime.StopNode(ctx, node)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	config "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// fakeSDKRuntime knows a single cluster with a single node and panics on anything else (via the nil embedded interface)
+type fakeSDKRuntime struct {
+	k3drt.Runtime
+	node *k3d.Node
+}
+
+func (f *fakeSDKRuntime) GetNodesByLabel(_ context.Context, labels map[string]string) ([]*k3d.Node, error) {
+	if labels[k3d.LabelClusterName] == f.node.RuntimeLabels[k3d.LabelClusterName] {
+		return []*k3d.Node{f.node}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeSDKRuntime) GetNode(_ context.Context, node *k3d.Node) (*k3d.Node, error) {
+	if node.Name == f.node.Name {
+		return f.node, nil
+	}
+	return node, fmt.Errorf("failed to get container for node '%s': %w", node.Name, runtimeErr.ErrRuntimeContainerNotExists)
+}
+
+func (f *fakeSDKRuntime) GetVolumesByLabel(_ context.Context, _ map[string]string) ([]string, error) {
+	return nil, nil
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	c := New(&fakeSDKRuntime{node: &k3d.Node{
+		Name:          "k3d-test-server-0",
+		Role:          k3d.ServerRole,
+		RuntimeLabels: map[string]string{k3d.LabelClusterName: "test"},
+	}})
+
+	cluster, err := c.GetCluster(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Name != "test" || len(cluster.Nodes) != 1 {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+
+	if _, err := c.GetCluster(ctx, "missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+	if err := c.StopCluster(ctx, "missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	if _, err := c.GetNode(ctx, "k3d-test-server-0"); err != nil {
+		t.Errorf("unexpected error getting node: %v", err)
+	}
+	if err := c.DeleteNode(ctx, "k3d-missing", k3d.NodeDeleteOpts{}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	spec := &config.ClusterConfig{Cluster: k3d.Cluster{Name: "test"}}
+	if _, err := c.CreateCluster(ctx, spec, CreateClusterOpts{}); !errors.Is(err, ErrClusterExists) {
+		t.Errorf("expected ErrClusterExists, got %v", err)
+	}
+}
+
+func TestClusterCreateError(t *testing.T) {
+	cause := errors.New("boom")
+	var err error = &ClusterCreateError{Cluster: "test", Err: cause, RolledBack: true}
+
+	if !errors.Is(err, cause) {
+		t.Errorf("expected the error to wrap its cause")
+	}
+	var createErr *ClusterCreateError
+	if !errors.As(err, &createErr) || !createErr.RolledBack {
+		t.Errorf("expected a rolled back *ClusterCreateError, got %v", err)
+	}
+	if want := "failed to create cluster 'test' (rolled back): boom"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// startupGraphStep is a step of the cluster startup, which is run once all the steps it depends on are done
+type startupGraphStep struct {
+	dependsOn []k3d.StartupStep
+	run       func(ctx context.Context) error
+}
+
+// StartupDependencies returns the dependencies between all startup steps (step -> steps it depends on):
+// the built-in ones, extended by the checks and dependencies of the given options.
+func StartupDependencies(opts *k3d.StartupOpts) (map[k3d.StartupStep][]k3d.StartupStep, error) {
+	deps := map[k3d.StartupStep][]k3d.StartupStep{}
+	for step, dependsOn := range k3d.DefaultStartupDependencies {
+		deps[step] = append([]k3d.StartupStep{}, dependsOn...)
+	}
+	if opts == nil {
+		return deps, nil
+	}
+
+	for _, check := range opts.Checks {
+		if check.Name == "" {
+			return nil, fmt.Errorf("startup check without a name")
+		}
+		if _, exists := deps[check.Name]; exists {
+			return nil, fmt.Errorf("startup check '%s': name is already used by another step", check.Name)
+		}
+		if (check.TCP == "") == (check.HTTP == "") {
+			return nil, fmt.Errorf("startup check '%s': exactly one of tcp and http has to be set", check.Name)
+		}
+		if check.TCP != "" {
+			if _, _, err := net.SplitHostPort(check.TCP); err != nil {
+				return nil, fmt.Errorf("startup check '%s': invalid tcp address '%s': %w", check.Name, check.TCP, err)
+			}
+		}
+		deps[check.Name] = []k3d.StartupStep{}
+	}
+
+	for step, dependsOn := range opts.Dependencies {
+		if _, exists := deps[step]; !exists {
+			return nil, fmt.Errorf("unknown startup step '%s'", step)
+		}
+		for _, dep := range dependsOn {
+			if _, exists := deps[dep]; !exists {
+				return nil, fmt.Errorf("startup step '%s' depends on unknown step '%s'", step, dep)
+			}
+			if !startupStepsContain(deps[step], dep) {
+				deps[step] = append(deps[step], dep)
+			}
+		}
+	}
+
+	if _, err := StartupOrder(deps); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// StartupOrder returns the steps in an order that satisfies all dependencies (alphabetical among independent steps)
+// or an error if the dependencies contain a cycle
+func StartupOrder(deps map[k3d.StartupStep][]k3d.StartupStep) ([]k3d.StartupStep, error) {
+	order := []k3d.StartupStep{}
+	done := map[k3d.StartupStep]bool{}
+	for len(order) < len(deps) {
+		ready := []k3d.StartupStep{}
+		for step, dependsOn := range deps {
+			if done[step] {
+				continue
+			}
+			pending := false
+			for _, dep := range dependsOn {
+				if !done[dep] {
+					pending = true
+					break
+				}
+			}
+			if !pending {
+				ready = append(ready, step)
+			}
+		}
+		if len(ready) == 0 {
+			cycle := []string{}
+			for step := range deps {
+				if !done[step] {
+					cycle = append(cycle, string(step))
+				}
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("startup steps %v depend on each other (cycle)", cycle)
+		}
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+		for _, step := range ready {
+			done[step] = true
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+func startupStepsContain(steps []k3d.StartupStep, step k3d.StartupStep) bool {
+	for _, s := range steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// startupGraphRun runs all steps in parallel, each one as soon as all the steps it depends on are done.
+// The first failing step cancels the remaining ones.
+func startupGraphRun(ctx context.Context, steps map[k3d.StartupStep]*startupGraphStep) error {
+	done := map[k3d.StartupStep]chan struct{}{}
+	for name := range steps {
+		done[name] = make(chan struct{})
+	}
+
+	graph, graphCtx := errgroup.WithContext(ctx)
+	for name, step := range steps {
+		name, step := name, step
+		graph.Go(func() error {
+			for _, dep := range step.dependsOn {
+				select {
+				case <-done[dep]:
+				case <-graphCtx.Done():
+					return graphCtx.Err()
+				}
+			}
+			l.Log().Tracef("Running startup step '%s'", name)
+			if err := step.run(graphCtx); err != nil {
+				return err
+			}
+			close(done[name])
+			return nil
+		})
+	}
+	return graph.Wait()
+}
+
+// startupCheckRun waits for the external dependency of a startup check to be reachable
+func startupCheckRun(ctx context.Context, check k3d.StartupCheck) error {
+	if check.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+
+	l.Log().Infof("Waiting for startup check '%s'...", check.Name)
+	if check.HTTP != "" {
+		if err := WaitForURLHealthy(ctx, check.HTTP, WaitForURLOpts{}); err != nil {
+			return fmt.Errorf("startup check '%s' failed: %w", check.Name, err)
+		}
+		return nil
+	}
+
+	dialer := &net.Dialer{}
+	if err := WaitPoll(ctx, fmt.Sprintf("'%s' to accept connections", check.TCP), func(ctx context.Context) (bool, error) {
+		conn, err := dialer.DialContext(ctx, "tcp", check.TCP)
+		if err != nil {
+			l.Log().Tracef("'%s' not reachable yet: %v", check.TCP, err)
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("startup check '%s' failed: %w", check.Name, err)
+	}
+	return nil
+}
+
+// startupOptsFromLabel parses the startup options stored in the LabelClusterStartup runtime label
+func startupOptsFromLabel(label string) (*k3d.StartupOpts, error) {
+	opts := &k3d.StartupOpts{}
+	if err := json.Unmarshal([]byte(label), opts); err != nil {
+		return nil, fmt.Errorf("failed to parse startup options: %w", err)
+	}
+	return opts, nil
+}
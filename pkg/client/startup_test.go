@@ -0,0 +1,169 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestStartupOrderDefault(t *testing.T) {
+	deps, err := StartupDependencies(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	order, err := StartupOrder(deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []k3d.StartupStep{k3d.StartupStepInitServer, k3d.StartupStepRegistries, k3d.StartupStepServers, k3d.StartupStepAgents, k3d.StartupStepHelpers, k3d.StartupStepLoadbalancer}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+}
+
+func TestStartupDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *k3d.StartupOpts
+		wantOrder []k3d.StartupStep
+		wantErr   bool
+	}{
+		{
+			name: "registries and datastore check before servers",
+			opts: &k3d.StartupOpts{
+				Dependencies: map[k3d.StartupStep][]k3d.StartupStep{
+					k3d.StartupStepInitServer: {k3d.StartupStepRegistries, "datastore"},
+				},
+				Checks: []k3d.StartupCheck{{Name: "datastore", TCP: "localhost:5432"}},
+			},
+			wantOrder: []k3d.StartupStep{"datastore", k3d.StartupStepRegistries, k3d.StartupStepInitServer, k3d.StartupStepServers, k3d.StartupStepAgents, k3d.StartupStepHelpers, k3d.StartupStepLoadbalancer},
+		},
+		{
+			name: "loadbalancer before agents",
+			opts: &k3d.StartupOpts{
+				Dependencies: map[k3d.StartupStep][]k3d.StartupStep{
+					k3d.StartupStepAgents: {k3d.StartupStepLoadbalancer},
+				},
+			},
+			wantErr: true, // the loadbalancer depends on the agents by default
+		},
+		{
+			name: "unknown step",
+			opts: &k3d.StartupOpts{
+				Dependencies: map[k3d.StartupStep][]k3d.StartupStep{
+					"masters": {k3d.StartupStepRegistries},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown dependency",
+			opts: &k3d.StartupOpts{
+				Dependencies: map[k3d.StartupStep][]k3d.StartupStep{
+					k3d.StartupStepServers: {"datastore"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "check with a built-in name",
+			opts:    &k3d.StartupOpts{Checks: []k3d.StartupCheck{{Name: k3d.StartupStepServers, TCP: "localhost:5432"}}},
+			wantErr: true,
+		},
+		{
+			name:    "check without target",
+			opts:    &k3d.StartupOpts{Checks: []k3d.StartupCheck{{Name: "datastore"}}},
+			wantErr: true,
+		},
+		{
+			name:    "check with invalid address",
+			opts:    &k3d.StartupOpts{Checks: []k3d.StartupCheck{{Name: "datastore", TCP: "localhost"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := StartupDependencies(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartupDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			order, err := StartupOrder(deps)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("expected order %v, got %v", tt.wantOrder, order)
+			}
+		})
+	}
+}
+
+func TestStartupGraphRun(t *testing.T) {
+	var mutex sync.Mutex
+	ran := []k3d.StartupStep{}
+	step := func(name k3d.StartupStep, dependsOn ...k3d.StartupStep) *startupGraphStep {
+		return &startupGraphStep{
+			dependsOn: dependsOn,
+			run: func(ctx context.Context) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				ran = append(ran, name)
+				return nil
+			},
+		}
+	}
+
+	if err := startupGraphRun(context.Background(), map[k3d.StartupStep]*startupGraphStep{
+		"c": step("c", "b"),
+		"b": step("b", "a"),
+		"a": step("a"),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []k3d.StartupStep{"a", "b", "c"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("expected steps to run in order %v, got %v", want, ran)
+	}
+
+	// a failing step stops the steps depending on it
+	ran = []k3d.StartupStep{}
+	failure := errors.New("failed")
+	err := startupGraphRun(context.Background(), map[k3d.StartupStep]*startupGraphStep{
+		"a": {run: func(ctx context.Context) error { return failure }},
+		"b": step("b", "a"),
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected error %v, got %v", failure, err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("expected no dependent steps to run, got %v", ran)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// syncFileInfo holds the bits of file metadata that we use to detect changes
+type syncFileInfo struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// NodeSync keeps a host directory synchronized into a path inside the given node.
+// It works purely via the runtime API (no bind mounts), so it also works with remote runtimes.
+// Unless opts.Once is set, it keeps polling for changes until the context is cancelled: failing files are logged and retried in the next pass.
+func NodeSync(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, src string, dest string, opts k3d.NodeSyncOpts) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat sync source '%s': %w", src, err)
+	}
+	if !srcInfo.IsDir() {
+		return fmt.Errorf("sync source '%s' is not a directory", src)
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = k3d.DefaultNodeSyncInterval
+	}
+
+	if err := runtime.ExecInNode(ctx, node, []string{"mkdir", "-p", dest}); err != nil {
+		return fmt.Errorf("failed to create sync destination '%s' in node '%s': %w", dest, node.Name, err)
+	}
+
+	l.Log().Infof("Syncing '%s' to '%s:%s'...", src, node.Name, dest)
+
+	current := map[string]syncFileInfo{}
+	for {
+		next, err := syncSnapshot(src, opts.Excludes)
+		if err != nil {
+			return fmt.Errorf("failed to scan sync source '%s': %w", src, err)
+		}
+
+		changed, removed := syncDiff(current, next)
+		failed, err := syncApply(ctx, runtime, node, src, dest, changed, removed, opts)
+		if err != nil {
+			if opts.Once {
+				return err
+			}
+			l.Log().Warnf("Sync: %v (retrying in the next pass)", err)
+		}
+		if len(changed)-len(failed)+len(removed) > 0 {
+			l.Log().Infof("Synced %d changed and %d removed file(s) to node '%s'", len(changed)-len(failed), len(removed), node.Name)
+		}
+
+		// forget the failed files, so that they show up as changed again in the next pass
+		for _, rel := range failed {
+			delete(next, rel)
+		}
+		current = next
+
+		if opts.Once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			l.Log().Infoln("Stopped syncing")
+			return nil
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// syncSnapshot walks the source directory and returns the metadata of all regular files, keyed by their slash-separated relative path
+func syncSnapshot(src string, excludes []string) (map[string]syncFileInfo, error) {
+	snapshot := map[string]syncFileInfo{}
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		for _, pattern := range excludes {
+			if matched, _ := path.Match(pattern, rel); matched {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if matched, _ := path.Match(pattern, path.Base(rel)); matched {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snapshot[rel] = syncFileInfo{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// syncDiff compares two snapshots and returns the (sorted) lists of changed/new and removed files
+func syncDiff(old, new map[string]syncFileInfo) (changed []string, removed []string) {
+	for rel, info := range new {
+		if oldInfo, ok := old[rel]; !ok || !oldInfo.ModTime.Equal(info.ModTime) || oldInfo.Size != info.Size {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range old {
+		if _, ok := new[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// syncApply transfers changed files to the node and removes deleted ones (if requested).
+// It copies as many of the changed files as possible and returns the ones that failed to copy along with the error.
+func syncApply(ctx context.Context, runtime runtimes.Runtime, node *k3d.Node, src string, dest string, changed []string, removed []string, opts k3d.NodeSyncOpts) ([]string, error) {
+	var failed []string
+	var copyErr error
+	if len(changed) > 0 {
+		// ensure that all parent directories exist in the node
+		dirs := map[string]struct{}{}
+		for _, rel := range changed {
+			dirs[path.Join(dest, path.Dir(rel))] = struct{}{}
+		}
+		mkdirCmd := []string{"mkdir", "-p"}
+		for dir := range dirs {
+			mkdirCmd = append(mkdirCmd, dir)
+		}
+		if err := runtime.ExecInNode(ctx, node, mkdirCmd); err != nil {
+			return changed, fmt.Errorf("failed to create directories in node '%s': %w", node.Name, err)
+		}
+
+		for _, rel := range changed {
+			l.Log().Debugf("Sync: copying %s", rel)
+			if err := runtime.CopyToNode(ctx, filepath.Join(src, filepath.FromSlash(rel)), path.Join(dest, rel), node); err != nil {
+				l.Log().Debugf("Sync: failed to copy %s: %v", rel, err)
+				failed = append(failed, rel)
+				if copyErr == nil {
+					copyErr = fmt.Errorf("failed to copy '%s' to node '%s': %w", rel, node.Name, err)
+				}
+			}
+		}
+		if copyErr != nil {
+			copyErr = fmt.Errorf("%d of %d file(s) failed, first error: %w", len(failed), len(changed), copyErr)
+		}
+	}
+
+	if opts.Delete && len(removed) > 0 {
+		rmCmd := []string{"rm", "-f"}
+		for _, rel := range removed {
+			l.Log().Debugf("Sync: removing %s", rel)
+			rmCmd = append(rmCmd, path.Join(dest, rel))
+		}
+		if err := runtime.ExecInNode(ctx, node, rmCmd); err != nil {
+			return failed, fmt.Errorf("failed to remove files from node '%s': %w", node.Name, err)
+		}
+	}
+
+	return failed, copyErr
+}
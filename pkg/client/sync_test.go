@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func Test_syncDiff(t *testing.T) {
+	now := time.Now()
+
+	old := map[string]syncFileInfo{
+		"a.txt":     {ModTime: now, Size: 1},
+		"b.txt":     {ModTime: now, Size: 1},
+		"dir/c.txt": {ModTime: now, Size: 1},
+	}
+	new := map[string]syncFileInfo{
+		"a.txt":     {ModTime: now, Size: 1},
+		"b.txt":     {ModTime: now.Add(time.Second), Size: 1},
+		"dir/d.txt": {ModTime: now, Size: 1},
+	}
+
+	changed, removed := syncDiff(old, new)
+
+	if diff := deep.Equal(changed, []string{"b.txt", "dir/d.txt"}); diff != nil {
+		t.Errorf("unexpected changed files: %+v", diff)
+	}
+	if diff := deep.Equal(removed, []string{"dir/c.txt"}); diff != nil {
+		t.Errorf("unexpected removed files: %+v", diff)
+	}
+}
+
+func Test_syncSnapshot(t *testing.T) {
+	src := t.TempDir()
+
+	for _, f := range []string{"keep.txt", "sub/keep.go", "skip.swp", ".git/HEAD"} {
+		p := filepath.Join(src, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := syncSnapshot(src, []string{"*.swp", ".git"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, _ := syncDiff(map[string]syncFileInfo{}, snapshot)
+
+	if diff := deep.Equal(changed, []string{"keep.txt", "sub/keep.go"}); diff != nil {
+		t.Errorf("unexpected snapshot %+v: %+v", snapshot, diff)
+	}
+}
+
+// fakeSyncRuntime fails to copy the given destinations and records the copied ones (panics on anything else via the nil embedded interface)
+type fakeSyncRuntime struct {
+	k3drt.Runtime
+	failing map[string]bool
+	copied  []string
+}
+
+func (f *fakeSyncRuntime) ExecInNode(_ context.Context, _ *k3d.Node, _ []string) error {
+	return nil
+}
+
+func (f *fakeSyncRuntime) CopyToNode(_ context.Context, _ string, dest string, _ *k3d.Node) error {
+	if f.failing[dest] {
+		return fmt.Errorf("copy failed")
+	}
+	f.copied = append(f.copied, dest)
+	return nil
+}
+
+func Test_syncApplyContinuesOnCopyFailures(t *testing.T) {
+	runtime := &fakeSyncRuntime{failing: map[string]bool{"/data/b.txt": true}}
+
+	failed, err := syncApply(context.Background(), runtime, &k3d.Node{Name: "test"}, "/src", "/data", []string{"a.txt", "b.txt", "dir/c.txt"}, nil, k3d.NodeSyncOpts{})
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if diff := deep.Equal(failed, []string{"b.txt"}); diff != nil {
+		t.Errorf("unexpected failed files: %+v", diff)
+	}
+	if diff := deep.Equal(runtime.copied, []string{"/data/a.txt", "/data/dir/c.txt"}); diff != nil {
+		t.Errorf("unexpected copied files: %+v", diff)
+	}
+}
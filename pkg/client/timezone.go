@@ -0,0 +1,125 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rancher/k3d/v5/pkg/actions"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// zoneinfoSources are the directories on the host that timezone files are looked up in (same as the Go runtime does on Unix)
+var zoneinfoSources = []string{
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+}
+
+// hostLocaltimePath is the local timezone of the host
+var hostLocaltimePath = k3d.DefaultLocaltimePath
+
+// TimezoneLoad returns the name and the TZif data of a timezone from the host's timezone database,
+// which is either an IANA timezone name (e.g. Europe/Berlin) or k3d.TimezoneHost for the host's local timezone ($TZ or /etc/localtime)
+func TimezoneLoad(tz string) (string, []byte, error) {
+	if tz == k3d.TimezoneHost {
+		if env := strings.TrimPrefix(os.Getenv("TZ"), ":"); env != "" && !filepath.IsAbs(env) {
+			return TimezoneLoad(env)
+		}
+		data, err := os.ReadFile(hostLocaltimePath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read the local timezone of the host (pass a timezone name like Europe/Berlin instead): %w", err)
+		}
+		if !isTZif(data) {
+			return "", nil, fmt.Errorf("local timezone of the host '%s' is no timezone file", hostLocaltimePath)
+		}
+		name := k3d.TimezoneHost
+		if target, err := filepath.EvalSymlinks(hostLocaltimePath); err == nil {
+			if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+				name = target[i+len("zoneinfo/"):]
+			}
+		}
+		return name, data, nil
+	}
+
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", nil, fmt.Errorf("invalid timezone '%s': %w", tz, err)
+	}
+	for _, source := range zoneinfoSources {
+		data, err := os.ReadFile(filepath.Join(source, tz))
+		if err == nil && isTZif(data) {
+			return tz, data, nil
+		}
+	}
+	return "", nil, fmt.Errorf("timezone '%s' not found in the timezone database of the host (%s)", tz, strings.Join(zoneinfoSources, ", "))
+}
+
+// TimezoneNodeHook returns the hook writing the timezone into a node, where the k3s processes (e.g. the CronJob controller) and logs pick it up
+func TimezoneNodeHook(runtime k3drt.Runtime, name string, data []byte) k3d.NodeHook {
+	return k3d.NodeHook{
+		Stage: k3d.LifecycleStagePreStart,
+		Action: actions.WriteFileAction{
+			Runtime:     runtime,
+			Content:     data,
+			Dest:        k3d.DefaultLocaltimePath,
+			Mode:        0644,
+			Description: fmt.Sprintf("Write Timezone %s", name),
+		},
+	}
+}
+
+// TimezoneReadFromNode returns the timezone (TZif data) of a node, e.g. to copy it to a new node
+func TimezoneReadFromNode(ctx context.Context, runtime k3drt.Runtime, node *k3d.Node) ([]byte, error) {
+	reader, err := runtime.ReadFromNode(ctx, k3d.DefaultLocaltimePath, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the timezone of node '%s': %w", node.Name, err)
+	}
+	defer reader.Close()
+
+	// the runtime returns a tar archive with the file as its only entry
+	tarReader := tar.NewReader(reader)
+	if _, err := tarReader.Next(); err != nil {
+		return nil, fmt.Errorf("failed to read the timezone of node '%s': %w", node.Name, err)
+	}
+	data, err := io.ReadAll(tarReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the timezone of node '%s': %w", node.Name, err)
+	}
+	if !isTZif(data) {
+		return nil, fmt.Errorf("timezone of node '%s' is no timezone file", node.Name)
+	}
+	return data, nil
+}
+
+func isTZif(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("TZif"))
+}
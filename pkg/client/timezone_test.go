@@ -0,0 +1,80 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTimezoneLoad(t *testing.T) {
+	dir := t.TempDir()
+	zoneinfo := filepath.Join(dir, "zoneinfo")
+	berlin := []byte("TZif2 Europe/Berlin")
+	if err := os.MkdirAll(filepath.Join(zoneinfo, "Europe"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(zoneinfo, "Europe", "Berlin"), berlin, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(zoneinfo, "UTC"), []byte("not a timezone file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(zoneinfo, "Europe", "Berlin"), filepath.Join(dir, "localtime")); err != nil {
+		t.Fatal(err)
+	}
+
+	origSources, origLocaltime := zoneinfoSources, hostLocaltimePath
+	zoneinfoSources, hostLocaltimePath = []string{filepath.Join(dir, "missing"), zoneinfo}, filepath.Join(dir, "localtime")
+	defer func() { zoneinfoSources, hostLocaltimePath = origSources, origLocaltime }()
+
+	tests := []struct {
+		name     string
+		tz       string
+		env      string
+		wantName string
+		wantData []byte
+		wantErr  bool
+	}{
+		{name: "name", tz: "Europe/Berlin", wantName: "Europe/Berlin", wantData: berlin},
+		{name: "host localtime", tz: "host", wantName: "Europe/Berlin", wantData: berlin},
+		{name: "host TZ env", tz: "host", env: ":Europe/Berlin", wantName: "Europe/Berlin", wantData: berlin},
+		{name: "unknown name", tz: "Europe/Atlantis", wantErr: true},
+		{name: "path traversal", tz: "../../etc/passwd", wantErr: true},
+		{name: "no timezone file", tz: "UTC", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TZ", tt.env)
+			name, data, err := TimezoneLoad(tt.tz)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TimezoneLoad() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || string(data) != string(tt.wantData) {
+				t.Errorf("TimezoneLoad() = %s, %q, want %s, %q", name, data, tt.wantName, tt.wantData)
+			}
+		})
+	}
+}
@@ -30,26 +30,39 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/progress"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
 
 // ImageImportIntoClusterMulti starts up a k3d tools container for the selected cluster and uses it to export
 // images from the runtime to import them into the nodes of the selected cluster
-func ImageImportIntoClusterMulti(ctx context.Context, runtime runtimes.Runtime, images []string, cluster *k3d.Cluster, opts k3d.ImageImportOpts) error {
+func ImageImportIntoClusterMulti(ctx context.Context, runtime runtimes.Runtime, images []string, cluster *k3d.Cluster, opts k3d.ImageImportOpts) (err error) {
+	ctx, span := tracing.Start(ctx, "ImageImportIntoClusterMulti", tracing.String("k3d.cluster.name", cluster.Name))
+	defer func() { span.End(err) }()
+
+	defer func() {
+		if err != nil {
+			progress.Fail(progress.OperationImageImport, err)
+		}
+	}()
+
+	progress.Report(progress.OperationImageImport, "find", 0, fmt.Sprintf("Looking up %d image(s)", len(images)))
 
 	// stdin case
-	if len(images) == 1 && images[0] == "-" {
+	if len(images) == 1 && images[0] == "-" && !opts.NoFiles {
 		err := loadImageFromStream(ctx, runtime, os.Stdin, cluster, []string{"stdin"})
 		return fmt.Errorf("failed to load image to cluster from stdin: %v", err)
 	}
 
-	imagesFromRuntime, imagesFromTar, err := findImages(ctx, runtime, images)
+	imagesFromRuntime, imagesFromTar, err := findImages(ctx, runtime, images, opts.NoFiles)
 	if err != nil {
 		return fmt.Errorf("failed to find images: %w", err)
 	}
@@ -95,6 +108,7 @@ func ImageImportIntoClusterMulti(ctx context.Context, runtime runtimes.Runtime,
 	}
 
 	l.Log().Infoln("Successfully imported image(s)")
+	progress.Done(progress.OperationImageImport, fmt.Sprintf("Imported %d image(s) into cluster '%s'", len(imagesFromRuntime)+len(imagesFromTar), cluster.Name))
 	return nil
 }
 
@@ -110,6 +124,7 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 	if len(imagesFromRuntime) > 0 {
 		// save image to tarfile in shared volume
 		l.Log().Infof("Saving %d image(s) from runtime...", len(imagesFromRuntime))
+		progress.Report(progress.OperationImageImport, "save", 10, fmt.Sprintf("Saving %d image(s) from runtime", len(imagesFromRuntime)))
 		tarName := fmt.Sprintf("%s/k3d-%s-images-%s.tar", k3d.DefaultImageVolumeMountPath, cluster.Name, time.Now().Format("20060102150405"))
 		if err := runtime.ExecInNode(ctx, toolsNode, append([]string{"./k3d-tools", "save-image", "-d", tarName}, imagesFromRuntime...)); err != nil {
 			return fmt.Errorf("failed to save image(s) in tools container for cluster '%s': %w", cluster.Name, err)
@@ -120,6 +135,7 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 	if len(imagesFromTar) > 0 {
 		// copy tarfiles to shared volume
 		l.Log().Infof("Saving %d tarball(s) to shared image volume...", len(imagesFromTar))
+		progress.Report(progress.OperationImageImport, "save", 25, fmt.Sprintf("Saving %d tarball(s) to shared image volume", len(imagesFromTar)))
 		for _, file := range imagesFromTar {
 			tarName := fmt.Sprintf("%s/k3d-%s-images-%s-file-%s", k3d.DefaultImageVolumeMountPath, cluster.Name, time.Now().Format("20060102150405"), path.Base(file))
 			if err := runtime.CopyToNode(ctx, file, tarName, toolsNode); err != nil {
@@ -132,6 +148,10 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 
 	// import image in each node
 	l.Log().Infoln("Importing images into nodes...")
+	importNodes := NodeFilterByRoles(cluster.Nodes, []k3d.Role{k3d.ServerRole, k3d.AgentRole}, nil)
+	importTotal := len(importTarNames) * len(importNodes)
+	var importedCount int32
+	progress.Report(progress.OperationImageImport, "import", 40, fmt.Sprintf("Importing images into %d node(s)", len(importNodes)))
 	var importWaitgroup sync.WaitGroup
 	for _, tarName := range importTarNames {
 		for _, node := range cluster.Nodes {
@@ -143,6 +163,7 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 					if err := runtime.ExecInNode(ctx, node, []string{"ctr", "image", "import", tarPath}); err != nil {
 						l.Log().Errorf("failed to import images in node '%s': %v", node.Name, err)
 					}
+					progress.Report(progress.OperationImageImport, "import", progress.Scale(40, 90, int(atomic.AddInt32(&importedCount, 1)), importTotal), fmt.Sprintf("Imported images into node '%s'", node.Name))
 					wg.Done()
 				}(node, &importWaitgroup, tarName)
 			}
@@ -153,6 +174,7 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 	// remove tarball
 	if !opts.KeepTar && len(importTarNames) > 0 {
 		l.Log().Infoln("Removing the tarball(s) from image volume...")
+		progress.Report(progress.OperationImageImport, "cleanup", 90, "Removing the tarball(s) from image volume")
 		if err := runtime.ExecInNode(ctx, toolsNode, []string{"rm", "-f", strings.Join(importTarNames, " ")}); err != nil {
 			l.Log().Errorf("failed to delete one or more tarballs from '%+v': %v", importTarNames, err)
 		}
@@ -171,6 +193,7 @@ func importWithToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster
 func importWithStream(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, imagesFromRuntime []string, imagesFromTar []string) error {
 	if len(imagesFromRuntime) > 0 {
 		l.Log().Infof("Loading %d image(s) from runtime into nodes...", len(imagesFromRuntime))
+		progress.Report(progress.OperationImageImport, "import", 10, fmt.Sprintf("Loading %d image(s) from runtime into nodes", len(imagesFromRuntime)))
 		// open a stream to all given images
 		stream, err := runtime.GetImageStream(ctx, imagesFromRuntime)
 		if err != nil {
@@ -187,6 +210,7 @@ func importWithStream(ctx context.Context, runtime runtimes.Runtime, cluster *k3
 	if len(imagesFromTar) > 0 {
 		// copy tarfiles to shared volume
 		l.Log().Infof("Importing images from %d tarball(s)...", len(imagesFromTar))
+		progress.Report(progress.OperationImageImport, "import", 50, fmt.Sprintf("Importing images from %d tarball(s)", len(imagesFromTar)))
 
 		for _, fileName := range imagesFromTar {
 			file, err := os.Open(fileName)
@@ -260,14 +284,14 @@ type runtimeImageGetter interface {
 	GetImages(context.Context) ([]string, error)
 }
 
-func findImages(ctx context.Context, runtime runtimeImageGetter, requestedImages []string) (imagesFromRuntime, imagesFromTar []string, err error) {
+func findImages(ctx context.Context, runtime runtimeImageGetter, requestedImages []string, noFiles bool) (imagesFromRuntime, imagesFromTar []string, err error) {
 	runtimeImages, err := runtime.GetImages(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to fetch list of existing images from runtime: %w", err)
 	}
 
 	for _, requestedImage := range requestedImages {
-		if isFile(requestedImage) {
+		if !noFiles && isFile(requestedImage) {
 			imagesFromTar = append(imagesFromTar, requestedImage)
 			l.Log().Debugf("Selected image '%s' is a file", requestedImage)
 			continue
@@ -364,7 +388,7 @@ func runToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cl
 		labels[k] = v
 	}
 	node := &k3d.Node{
-		Name:          fmt.Sprintf("%s-%s-tools", k3d.DefaultObjectNamePrefix, cluster.Name),
+		Name:          fmt.Sprintf("%s-%s-tools", k3d.ObjectNamePrefix, cluster.Name),
 		Image:         k3d.GetToolsImage(),
 		Role:          k3d.NoRole,
 		Volumes:       volumes,
@@ -385,7 +409,7 @@ func runToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cl
 func EnsureToolsNode(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster) (*k3d.Node, error) {
 
 	var toolsNode *k3d.Node
-	toolsNode, err := runtime.GetNode(ctx, &k3d.Node{Name: fmt.Sprintf("%s-%s-tools", k3d.DefaultObjectNamePrefix, cluster.Name)})
+	toolsNode, err := runtime.GetNode(ctx, &k3d.Node{Name: fmt.Sprintf("%s-%s-tools", k3d.ObjectNamePrefix, cluster.Name)})
 	if err != nil || toolsNode == nil {
 
 		// Get more info on the cluster, if required
@@ -193,7 +193,7 @@ func Test_findImages(t *testing.T) {
 	requestedImages := append(runtimeImages, tarImages...)
 
 	// when
-	foundRuntimeImages, foundTarImages, err := findImages(context.Background(), runtime, requestedImages)
+	foundRuntimeImages, foundTarImages, err := findImages(context.Background(), runtime, requestedImages, false)
 
 	// then
 	if err != nil {
@@ -206,6 +206,18 @@ func Test_findImages(t *testing.T) {
 	if diff := deep.Equal(foundTarImages, tarImages); diff != nil {
 		t.Errorf("Found tar images\n%+v\ndoes not match expected tar images\n%+v\nDiff:\n%+v", foundTarImages, runtimeImages, diff)
 	}
+
+	// without files, the tarball isn't even looked at
+	foundRuntimeImages, foundTarImages, err = findImages(context.Background(), runtime, requestedImages, true)
+	if err != nil {
+		t.Errorf("Got unexpected error %v", err)
+	}
+	if diff := deep.Equal(foundRuntimeImages, runtimeImages); diff != nil {
+		t.Errorf("Found runtime images\n%+v\ndoes not match expected runtime images\n%+v\nDiff:\n%+v", foundRuntimeImages, runtimeImages, diff)
+	}
+	if len(foundTarImages) > 0 {
+		t.Errorf("Expected no tar images without files, got %+v", foundTarImages)
+	}
 }
 
 type FakeRuntimeImageGetter struct {
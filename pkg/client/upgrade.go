@@ -0,0 +1,178 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// k3sImageTagRegexp matches the tags of k3s images, e.g. v1.21.5-k3s2, capturing major, minor and patch version and the k3s revision
+var k3sImageTagRegexp = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)-k3s(\d+)$`)
+
+// ClusterUpgrade replaces the k3s nodes of the cluster one at a time with nodes running the new image:
+// first the servers, then the agents, each one only after the previous one is ready again in Kubernetes.
+// The replacements keep the name, (static) IP, labels and volumes of the nodes, so the cluster state is preserved.
+// It returns the names of the upgraded nodes, which are also those that already run the new image if it fails halfway.
+func ClusterUpgrade(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, opts k3d.ClusterUpgradeOpts) ([]string, error) {
+	cluster, err := ClusterGet(ctx, runtime, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	image, err := K3sImageResolve(opts.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := upgradeCheckVersions(ClusterGetK3sVersion(cluster), imageTag(image)); err != nil {
+		return nil, err
+	}
+
+	upgraded := []string{}
+	for _, node := range upgradeOrder(cluster.Nodes) {
+		if node.Image == image {
+			l.Log().Infof("Node '%s' already runs image %s", node.Name, image)
+			continue
+		}
+		name := node.Name // renamed by NodeReplace while it's being replaced
+		l.Log().Infof("Upgrading node '%s' from %s to %s...", name, node.Image, image)
+		if err := nodeUpgrade(ctx, runtime, cluster, node, image, opts); err != nil {
+			return upgraded, fmt.Errorf("failed to upgrade node '%s' (upgraded so far: %d): %w", name, len(upgraded), err)
+		}
+		upgraded = append(upgraded, name)
+	}
+
+	if len(upgraded) > 0 {
+		ClusterEventRecord(cluster.Name, k3d.ClusterEventUpgraded, "", fmt.Sprintf("Upgraded %d node(s) to image %s", len(upgraded), image))
+	}
+	return upgraded, nil
+}
+
+// nodeUpgrade replaces a single node with a copy running the new image and waits for it to be ready in Kubernetes (with the new version, if known)
+func nodeUpgrade(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, node *k3d.Node, image string, opts k3d.ClusterUpgradeOpts) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	tmpDir, err := os.MkdirTemp("", "k3d-node-upgrade-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	result, err := nodeCopyForRecreate(ctx, runtime, node, tmpDir)
+	if err != nil {
+		return err
+	}
+	result.Image = image
+
+	if err := NodeReplace(ctx, runtime, node, result); err != nil {
+		return err
+	}
+
+	// the Ready condition may still be the one reported before the replacement, so wait for the new kubelet version first
+	if version := kubeletVersion(imageTag(image)); version != "" {
+		cmd := []string{"kubectl", "get", "node", result.Name, "-o", "jsonpath={.status.nodeInfo.kubeletVersion}"}
+		if err := WaitPoll(ctx, fmt.Sprintf("node '%s' to run kubelet %s", result.Name, version), func(ctx context.Context) (bool, error) {
+			output, err := clusterExecInServer(ctx, runtime, cluster, cmd)
+			if err != nil {
+				l.Log().Tracef("Failed to get kubelet version of node '%s': %v", result.Name, err)
+				return false, nil
+			}
+			return strings.TrimSpace(output) == version, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return WaitForKubernetesNodeReady(ctx, runtime, cluster, result)
+}
+
+// upgradeOrder returns the server and agent nodes in the order they're upgraded: servers first, each role sorted by name
+func upgradeOrder(nodes []*k3d.Node) []*k3d.Node {
+	servers := NodeFilterByRoles(nodes, []k3d.Role{k3d.ServerRole}, nil)
+	agents := NodeFilterByRoles(nodes, []k3d.Role{k3d.AgentRole}, nil)
+	for _, list := range [][]*k3d.Node{servers, agents} {
+		list := list
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return append(servers, agents...)
+}
+
+// upgradeCheckVersions rejects downgrades, which Kubernetes doesn't support, and warns about skipped minor versions.
+// Unknown versions (e.g. of custom images) aren't checked.
+func upgradeCheckVersions(currentTag, newTag string) error {
+	current, currentOK := parseK3sImageTag(currentTag)
+	next, nextOK := parseK3sImageTag(newTag)
+	if !currentOK || !nextOK {
+		l.Log().Debugf("Not checking the upgrade from '%s' to '%s', as at least one of them is not a k3s version", currentTag, newTag)
+		return nil
+	}
+	for i := range current {
+		if next[i] < current[i] {
+			return fmt.Errorf("cannot downgrade cluster from %s to %s: Kubernetes only supports upgrades", currentTag, newTag)
+		}
+		if next[i] > current[i] {
+			break
+		}
+	}
+	if next[0] == current[0] && next[1] > current[1]+1 {
+		l.Log().Warnf("Upgrading from %s to %s skips minor versions, which Kubernetes doesn't support: consider upgrading one minor version at a time", currentTag, newTag)
+	}
+	return nil
+}
+
+// parseK3sImageTag returns major, minor and patch version and the k3s revision of a k3s image tag
+func parseK3sImageTag(tag string) ([4]int, bool) {
+	version := [4]int{}
+	match := k3sImageTagRegexp.FindStringSubmatch(tag)
+	if match == nil {
+		return version, false
+	}
+	for i := range version {
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+// kubeletVersion returns the version reported by the kubelet of a node running the k3s image with the given tag (empty if unknown)
+func kubeletVersion(tag string) string {
+	if !k3sImageTagRegexp.MatchString(tag) {
+		return ""
+	}
+	return strings.Replace(tag, "-k3s", "+k3s", 1)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/rancher/k3d/v5/pkg/actions"
+	k3drt "github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestUpgradeCheckVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		next    string
+		wantErr bool
+	}{
+		{name: "patch upgrade", current: "v1.21.5-k3s2", next: "v1.21.7-k3s1"},
+		{name: "minor upgrade", current: "v1.21.5-k3s2", next: "v1.22.3-k3s1"},
+		{name: "skipping minor versions", current: "v1.20.11-k3s2", next: "v1.22.3-k3s1"},
+		{name: "k3s revision upgrade", current: "v1.21.5-k3s1", next: "v1.21.5-k3s2"},
+		{name: "same version", current: "v1.21.5-k3s2", next: "v1.21.5-k3s2"},
+		{name: "minor downgrade", current: "v1.22.3-k3s1", next: "v1.21.7-k3s1", wantErr: true},
+		{name: "patch downgrade", current: "v1.21.10-k3s1", next: "v1.21.9-k3s1", wantErr: true},
+		{name: "k3s revision downgrade", current: "v1.21.5-k3s2", next: "v1.21.5-k3s1", wantErr: true},
+		{name: "unknown current version", current: "", next: "v1.21.5-k3s1"},
+		{name: "custom image", current: "v1.22.3-k3s1", next: "dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := upgradeCheckVersions(tt.current, tt.next); (err != nil) != tt.wantErr {
+				t.Errorf("upgradeCheckVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKubeletVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.21.5-k3s2":       "v1.21.5+k3s2",
+		"v1.22.3-rc1-k3s1":   "",
+		"latest":             "",
+		"v1.21.5-k3s2-amd64": "",
+		"":                   "",
+	}
+	for tag, want := range tests {
+		if got := kubeletVersion(tag); got != want {
+			t.Errorf("kubeletVersion(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestUpgradeOrder(t *testing.T) {
+	nodes := []*k3d.Node{
+		{Name: "k3d-test-agent-1", Role: k3d.AgentRole},
+		{Name: "k3d-test-serverlb", Role: k3d.LoadBalancerRole},
+		{Name: "k3d-test-server-1", Role: k3d.ServerRole},
+		{Name: "k3d-test-agent-0", Role: k3d.AgentRole},
+		{Name: "k3d-test-server-0", Role: k3d.ServerRole},
+		{Name: "k3d-test-registry", Role: k3d.RegistryRole},
+	}
+	want := []string{"k3d-test-server-0", "k3d-test-server-1", "k3d-test-agent-0", "k3d-test-agent-1"}
+
+	got := []string{}
+	for _, node := range upgradeOrder(nodes) {
+		got = append(got, node.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgradeOrder() = %v, want %v", got, want)
+	}
+}
+
+// fakeRecreateRuntime serves the given node data as the exported archives and records the imported ones (panics on anything else via the nil embedded interface)
+type fakeRecreateRuntime struct {
+	k3drt.Runtime
+	data     map[string]string // node name + path -> archive content
+	imported map[string]string
+}
+
+func (f *fakeRecreateRuntime) GetContainersByLabel(_ context.Context, _ map[string]string) ([]*runtimeTypes.Container, error) {
+	return nil, nil
+}
+
+func (f *fakeRecreateRuntime) ExportFromNode(_ context.Context, path string, node *k3d.Node) (io.ReadCloser, error) {
+	content, ok := f.data[node.Name+path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, runtimeErrors.ErrRuntimeFileNotFound)
+	}
+	return io.NopCloser(bytes.NewBufferString(content)), nil
+}
+
+func (f *fakeRecreateRuntime) ImportToNode(_ context.Context, reader io.Reader, path string, node *k3d.Node) error {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	f.imported[node.Name+path] = string(content)
+	return nil
+}
+
+func TestNodeCopyForRecreateKeepsNodePassword(t *testing.T) {
+	tests := []struct {
+		name         string
+		role         k3d.Role
+		data         map[string]string
+		wantImported map[string]string
+	}{
+		{
+			name:         "server",
+			role:         k3d.ServerRole,
+			data:         map[string]string{"k3d-test-0/etc/rancher/node": "password-tar"},
+			wantImported: map[string]string{"k3d-test-0/etc/rancher/node": "password-tar"},
+		},
+		{
+			name:         "agent without node password",
+			role:         k3d.AgentRole,
+			data:         map[string]string{},
+			wantImported: map[string]string{},
+		},
+		{
+			name:         "loadbalancer",
+			role:         k3d.LoadBalancerRole,
+			data:         map[string]string{"k3d-test-0/etc/rancher/node": "password-tar"},
+			wantImported: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := &fakeRecreateRuntime{data: tt.data, imported: map[string]string{}}
+			node := &k3d.Node{Name: "k3d-test-0", Role: tt.role, Image: "rancher/k3s:v1.21.5-k3s2", RuntimeLabels: map[string]string{k3d.LabelClusterName: "test"}}
+
+			result, err := nodeCopyForRecreate(context.Background(), runtime, node, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the copy is started after the existing node is gone, so the hooks must not depend on it
+			delete(runtime.data, node.Name+k3d.DefaultNodeDataPaths["node"])
+			for _, hook := range result.HookActions {
+				if hook.Stage != k3d.LifecycleStagePreStart {
+					continue
+				}
+				if _, ok := hook.Action.(*actions.ImportArchiveAction); !ok {
+					continue
+				}
+				if err := hook.Action.Run(context.Background(), result); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(runtime.imported, tt.wantImported) {
+				t.Errorf("imported %v, want %v", runtime.imported, tt.wantImported)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+// WaitPollInterval is the interval in which the polling wait functions check their condition
+var WaitPollInterval = 2 * time.Second
+
+// WaitCondition is a readiness condition to wait for: it returns nil once the condition is met or an error if it can't be met (anymore).
+// All wait functions of this package can be turned into a WaitCondition with a closure, e.g.
+//
+//	func(ctx context.Context) error { return client.WaitForDeploymentAvailable(ctx, runtime, cluster, "kube-system", "coredns") }
+//
+// Use the context to limit the time to wait, e.g. via context.WithTimeout.
+type WaitCondition func(ctx context.Context) error
+
+// WaitAll waits for all conditions in parallel and returns the first error (cancelling the others)
+func WaitAll(ctx context.Context, conditions ...WaitCondition) error {
+	waitGroup, waitCtx := errgroup.WithContext(ctx)
+	for _, condition := range conditions {
+		condition := condition
+		waitGroup.Go(func() error {
+			return condition(waitCtx)
+		})
+	}
+	return waitGroup.Wait()
+}
+
+// WaitInOrder waits for the conditions one after another and returns the first error
+func WaitInOrder(ctx context.Context, conditions ...WaitCondition) error {
+	for _, condition := range conditions {
+		if err := condition(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitPoll checks the condition every WaitPollInterval until it's met (returns true), it fails (returns an error) or the context is done
+func WaitPoll(ctx context.Context, description string, check func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(WaitPollInterval)
+	defer ticker.Stop()
+	for {
+		done, err := check(ctx)
+		if err != nil {
+			return fmt.Errorf("failed waiting for %s: %w", description, err)
+		}
+		if done {
+			l.Log().Debugf("Finished waiting for %s", description)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for %s: %w", description, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForKubernetesNodeReady waits for the Kubernetes node of a k3d node to be registered and to report the Ready condition
+func WaitForKubernetesNodeReady(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, node *k3d.Node) error {
+	return WaitForKubernetesCondition(ctx, runtime, cluster, "", "node", node.Name, "Ready")
+}
+
+// WaitForDeploymentAvailable waits for a deployment to exist and to report the Available condition (i.e. the minimum number of its replicas is ready)
+func WaitForDeploymentAvailable(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, namespace string, name string) error {
+	return WaitForKubernetesCondition(ctx, runtime, cluster, namespace, "deployment", name, "Available")
+}
+
+// WaitForKubernetesCondition waits for a Kubernetes object to exist and to have the given status condition set to True.
+// It's checked with kubectl inside one of the cluster's running server nodes, so no kubeconfig is required.
+// Leave the namespace empty for cluster-scoped objects.
+func WaitForKubernetesCondition(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, namespace string, kind string, name string, condition string) error {
+	cmd := []string{"kubectl", "get", kind, name, "-o", fmt.Sprintf(`jsonpath={.status.conditions[?(@.type=="%s")].status}`, condition)}
+	if namespace != "" {
+		cmd = append(cmd, "--namespace", namespace)
+	}
+	return WaitPoll(ctx, fmt.Sprintf("condition %s of %s '%s'", condition, kind, name), func(ctx context.Context) (bool, error) {
+		output, err := clusterExecInServer(ctx, runtime, cluster, cmd)
+		if err != nil {
+			// the object may not exist yet or the API server may not be available yet
+			l.Log().Tracef("Condition %s of %s '%s' not met yet: %v", condition, kind, name, err)
+			return false, nil
+		}
+		return strings.TrimSpace(output) == "True", nil
+	})
+}
+
+// clusterExecInServer runs a command in the first running server node of the cluster and returns its output
+func clusterExecInServer(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster, cmd []string) (string, error) {
+	node, err := clusterRunningServer(ctx, runtime, cluster)
+	if err != nil {
+		return "", err
+	}
+	logreader, err := runtime.ExecInNodeGetLogs(ctx, node, cmd)
+	if err != nil {
+		return "", err
+	}
+	if logreader == nil {
+		return "", nil
+	}
+	output, err := io.ReadAll(logreader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read output of '%s' in node '%s': %w", strings.Join(cmd, " "), node.Name, err)
+	}
+	return string(output), nil
+}
+
+// clusterRunningServer returns the first running server node of the cluster
+func clusterRunningServer(ctx context.Context, runtime runtimes.Runtime, cluster *k3d.Cluster) (*k3d.Node, error) {
+	serverNodes, err := runtime.GetNodesByLabel(ctx, map[string]string{k3d.LabelClusterName: cluster.Name, k3d.LabelRole: string(k3d.ServerRole)})
+	if err != nil {
+		return nil, fmt.Errorf("runtime failed to get server nodes for cluster '%s': %w", cluster.Name, err)
+	}
+	for _, node := range serverNodes {
+		if node.State.Running {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("no running server node in cluster '%s'", cluster.Name)
+}
+
+// WaitForURLOpts are the options for WaitForURLHealthy
+type WaitForURLOpts struct {
+	StatusCodes        []int         // status codes considered healthy (default: any 2xx)
+	InsecureSkipVerify bool          // don't verify the server's TLS certificate, e.g. of the Kubernetes API
+	RequestTimeout     time.Duration // timeout of each single request (default: 5s)
+}
+
+// WaitForURLHealthy waits for a URL to respond to GET requests with a healthy status code
+func WaitForURLHealthy(ctx context.Context, url string, opts WaitForURLOpts) error {
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = 5 * time.Second
+	}
+	httpClient := &http.Client{
+		Timeout: opts.RequestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}, //nolint:gosec // opt-in, e.g. for self-signed certificates of local clusters
+		},
+	}
+
+	return WaitPoll(ctx, fmt.Sprintf("URL '%s' to be healthy", url), func(ctx context.Context) (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			l.Log().Tracef("URL '%s' not healthy yet: %v", url, err)
+			return false, nil
+		}
+		resp.Body.Close()
+		healthy := isHealthyStatusCode(resp.StatusCode, opts.StatusCodes)
+		if !healthy {
+			l.Log().Tracef("URL '%s' not healthy yet: status %d", url, resp.StatusCode)
+		}
+		return healthy, nil
+	})
+}
+
+// isHealthyStatusCode checks whether the status code is one of the expected ones (or 2xx, if none are expected)
+func isHealthyStatusCode(statusCode int, expected []int) bool {
+	if len(expected) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	for _, code := range expected {
+		if statusCode == code {
+			return true
+		}
+	}
+	return false
+}
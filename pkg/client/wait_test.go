@@ -0,0 +1,106 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForURLHealthy(t *testing.T) {
+	defer func(interval time.Duration) { WaitPollInterval = interval }(WaitPollInterval)
+	WaitPollInterval = 10 * time.Millisecond
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// unhealthy for the first two requests
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForURLHealthy(ctx, server.URL, WaitForURLOpts{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	// status code never expected
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := WaitForURLHealthy(ctx, server.URL, WaitForURLOpts{StatusCodes: []int{http.StatusOK}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWaitAllInOrder(t *testing.T) {
+	failure := errors.New("failure")
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return failure }
+	blocking := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	if err := WaitAll(context.Background(), ok, ok); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	// a failing condition cancels the others
+	if err := WaitAll(context.Background(), blocking, fail); !errors.Is(err, failure) {
+		t.Errorf("expected failure, got %v", err)
+	}
+
+	called := false
+	if err := WaitInOrder(context.Background(), ok, fail, func(ctx context.Context) error { called = true; return nil }); !errors.Is(err, failure) || called {
+		t.Errorf("expected failure without calling later conditions, got %v (called: %t)", err, called)
+	}
+}
+
+func TestIsHealthyStatusCode(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected []int
+		healthy  bool
+	}{
+		{code: 200, healthy: true},
+		{code: 204, healthy: true},
+		{code: 301, healthy: false},
+		{code: 401, expected: []int{200, 401}, healthy: true},
+		{code: 200, expected: []int{401}, healthy: false},
+	}
+	for _, tc := range tests {
+		if actual := isHealthyStatusCode(tc.code, tc.expected); actual != tc.healthy {
+			t.Errorf("expected %t for status %d (expected %v), got %t", tc.healthy, tc.code, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
+)
+
+// watchStableIntervals is the number of check intervals, that a restarted node has to stay up for until its restart attempts are reset,
+// so that crash-looping nodes, which are running during some checks, still reach the maximum number of restart attempts
+const watchStableIntervals = 3
+
+// clusterWatcher holds the state of ClusterWatch between two checks
+type clusterWatcher struct {
+	runtime     runtimes.Runtime
+	opts        k3d.ClusterWatchOpts
+	restarts    map[string]int       // consecutive restart attempts per node name
+	restartedAt map[string]time.Time // time of the last restart attempt per node name
+	schedule    map[string]bool      // last seen schedule state (active/inactive) per cluster name
+}
+
+// ClusterWatch monitors clusters until the context is cancelled: it restarts node containers that exited unexpectedly
+// (see nodeExitedUnexpectedly) while the rest of the cluster is still running and refreshes the loadbalancer config afterwards.
+// Clusters where all nodes are stopped and nodes that were stopped (e.g. via `k3d node stop` or a running `k3d cluster stop`)
+// are considered to be stopped on purpose and are left alone.
+// Additionally, it stops/starts clusters according to their hibernation schedule.
+func ClusterWatch(ctx context.Context, runtime runtimes.Runtime, opts k3d.ClusterWatchOpts) error {
+	if opts.Interval <= 0 {
+		opts.Interval = k3d.DefaultWatchInterval
+	}
+	if opts.MaxRestarts <= 0 {
+		opts.MaxRestarts = k3d.DefaultWatchMaxRestarts
+	}
+
+	w := &clusterWatcher{
+		runtime:     runtime,
+		opts:        opts,
+		restarts:    map[string]int{},
+		restartedAt: map[string]time.Time{},
+		schedule:    map[string]bool{},
+	}
+
+	l.Log().Infof("Watching clusters (interval: %s)...", opts.Interval)
+	for {
+		clusters, err := w.clusters(ctx)
+		if err != nil {
+			l.Log().Warnf("Watch: %v", err)
+		}
+		for _, cluster := range clusters {
+			if w.enforceSchedule(ctx, cluster) {
+				continue
+			}
+			w.checkCluster(ctx, cluster)
+		}
+
+		select {
+		case <-ctx.Done():
+			l.Log().Infoln("Stopped watching clusters")
+			return nil
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// clusters returns the (up-to-date) clusters to watch
+func (w *clusterWatcher) clusters(ctx context.Context) ([]*k3d.Cluster, error) {
+	clusters, err := ClusterList(ctx, w.runtime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	if len(w.opts.Clusters) == 0 {
+		return clusters, nil
+	}
+
+	var filtered []*k3d.Cluster
+	for _, cluster := range clusters {
+		for _, name := range w.opts.Clusters {
+			if cluster.Name == name {
+				filtered = append(filtered, cluster)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// checkCluster restarts exited nodes of a (partially) running cluster
+func (w *clusterWatcher) checkCluster(ctx context.Context, cluster *k3d.Cluster) {
+	var exited []*k3d.Node
+	running := 0
+	hasLB := false
+	for _, node := range cluster.Nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole && node.Role != k3d.LoadBalancerRole {
+			continue
+		}
+		if node.Role == k3d.LoadBalancerRole {
+			hasLB = true
+		}
+		if node.State.Running {
+			running++
+			w.nodeUp(node, time.Now())
+		} else if nodeExitedUnexpectedly(node) {
+			exited = append(exited, node)
+		} else {
+			l.Log().Tracef("Watch: node '%s' was stopped on purpose (exit code %d), ignoring it", node.Name, node.State.ExitCode)
+		}
+	}
+
+	if running == 0 {
+		l.Log().Tracef("Watch: cluster '%s' is stopped, ignoring it", cluster.Name)
+		return
+	}
+
+	updateLB := false
+	for _, node := range exited {
+		if w.restarts[node.Name] >= w.opts.MaxRestarts {
+			continue // we gave up on this one already
+		}
+		w.restarts[node.Name]++
+		w.restartedAt[node.Name] = time.Now()
+
+		if err := NodeStart(ctx, w.runtime, node, &k3d.NodeStartOpts{Intent: k3d.IntentNodeStart}); err != nil {
+			w.emit(k3d.WatchEvent{Type: k3d.WatchEventNodeRestartFailed, Cluster: cluster.Name, Node: node.Name, Failure: true,
+				Message: fmt.Sprintf("Failed to restart node '%s' (attempt %d/%d): %v", node.Name, w.restarts[node.Name], w.opts.MaxRestarts, err)})
+			if w.restarts[node.Name] >= w.opts.MaxRestarts {
+				w.emit(k3d.WatchEvent{Type: k3d.WatchEventNodeRestartGaveUp, Cluster: cluster.Name, Node: node.Name, Failure: true,
+					Message: fmt.Sprintf("Giving up on restarting node '%s' after %d attempts", node.Name, w.restarts[node.Name])})
+			}
+			continue
+		}
+		w.emit(k3d.WatchEvent{Type: k3d.WatchEventNodeRestarted, Cluster: cluster.Name, Node: node.Name,
+			Message: fmt.Sprintf("Restarted node '%s' (state was '%s')", node.Name, node.State.Status)})
+
+		if node.Role != k3d.LoadBalancerRole {
+			updateLB = true // node IPs may have changed
+		}
+	}
+
+	if updateLB && hasLB {
+		if err := UpdateLoadbalancerConfig(ctx, w.runtime, cluster); err != nil {
+			w.emit(k3d.WatchEvent{Type: k3d.WatchEventLoadbalancerUpdateFailed, Cluster: cluster.Name, Failure: true,
+				Message: fmt.Sprintf("Failed to refresh the loadbalancer config of cluster '%s': %v", cluster.Name, err)})
+			return
+		}
+		w.emit(k3d.WatchEvent{Type: k3d.WatchEventLoadbalancerUpdated, Cluster: cluster.Name,
+			Message: fmt.Sprintf("Refreshed the loadbalancer config of cluster '%s'", cluster.Name)})
+	}
+}
+
+// nodeUp resets the restart attempts of a running node, once it stayed up for watchStableIntervals since it was restarted
+func (w *clusterWatcher) nodeUp(node *k3d.Node, now time.Time) {
+	if restartedAt, ok := w.restartedAt[node.Name]; ok && now.Sub(restartedAt) < watchStableIntervals*w.opts.Interval {
+		return
+	}
+	delete(w.restarts, node.Name)
+	delete(w.restartedAt, node.Name)
+}
+
+// nodeExitedUnexpectedly returns true if the node's container crashed or was OOM-killed, as opposed to being stopped on purpose:
+// the runtime stops containers via SIGTERM (exit code 143, or 137 if it has to kill them after the grace period) and k3s exits
+// with 0 on a clean shutdown, while crashes exit with other non-zero codes.
+func nodeExitedUnexpectedly(node *k3d.Node) bool {
+	if node.State.Status != "exited" && node.State.Status != "dead" {
+		return false // e.g. created, paused or restarting
+	}
+	if node.State.OOMKilled {
+		return true
+	}
+	switch node.State.ExitCode {
+	case 0, 128 + 9, 128 + 15: // clean exit, SIGKILL, SIGTERM
+		return false
+	}
+	return true
+}
+
+// enforceSchedule stops/starts the cluster according to its hibernation schedule and returns true, if it took action.
+// The schedule is enforced when the watcher sees the cluster for the first time and whenever the schedule's state changes,
+// so that a cluster can still be started/stopped manually in between.
+func (w *clusterWatcher) enforceSchedule(ctx context.Context, cluster *k3d.Cluster) bool {
+	if cluster.HibernationSchedule == "" {
+		return false
+	}
+	schedule, err := util.ParseSchedule(cluster.HibernationSchedule)
+	if err != nil {
+		l.Log().Debugf("Watch: ignoring invalid hibernation schedule of cluster '%s': %v", cluster.Name, err)
+		return false
+	}
+
+	active := schedule.Active(time.Now())
+	if last, seen := w.schedule[cluster.Name]; seen && last == active {
+		return false
+	}
+	w.schedule[cluster.Name] = active
+
+	running, _ := cluster.ServerCountRunning()
+	if active && running == 0 {
+		envInfo, err := GatherEnvironmentInfo(ctx, w.runtime, cluster)
+		if err == nil {
+			err = ClusterStart(ctx, w.runtime, cluster, k3d.ClusterStartOpts{WaitForServer: true, EnvironmentInfo: envInfo, Intent: k3d.IntentClusterStart})
+		}
+		if err != nil {
+			w.emit(k3d.WatchEvent{Type: k3d.WatchEventScheduleFailed, Cluster: cluster.Name, Failure: true,
+				Message: fmt.Sprintf("Failed to start cluster '%s' according to its schedule: %v", cluster.Name, err)})
+			return true
+		}
+		w.emit(k3d.WatchEvent{Type: k3d.WatchEventClusterWokeUp, Cluster: cluster.Name,
+			Message: fmt.Sprintf("Started cluster '%s' according to its schedule '%s'", cluster.Name, cluster.HibernationSchedule)})
+		return true
+	}
+
+	if !active && running > 0 {
+		if err := ClusterStop(ctx, w.runtime, cluster); err != nil {
+			w.emit(k3d.WatchEvent{Type: k3d.WatchEventScheduleFailed, Cluster: cluster.Name, Failure: true,
+				Message: fmt.Sprintf("Failed to stop cluster '%s' according to its schedule: %v", cluster.Name, err)})
+			return true
+		}
+		w.emit(k3d.WatchEvent{Type: k3d.WatchEventClusterHibernated, Cluster: cluster.Name,
+			Message: fmt.Sprintf("Stopped cluster '%s' according to its schedule '%s'", cluster.Name, cluster.HibernationSchedule)})
+		return true
+	}
+
+	return false
+}
+
+func (w *clusterWatcher) emit(event k3d.WatchEvent) {
+	ClusterEventRecord(event.Cluster, k3d.ClusterEventType(event.Type), event.Node, event.Message)
+	if w.opts.OnEvent != nil {
+		w.opts.OnEvent(event)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package client
+
+import (
+	"testing"
+	"time"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestNodeExitedUnexpectedly(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    k3d.NodeState
+		expected bool
+	}{
+		{name: "crashed", state: k3d.NodeState{Status: "exited", ExitCode: 1}, expected: true},
+		{name: "oom-killed", state: k3d.NodeState{Status: "exited", ExitCode: 137, OOMKilled: true}, expected: true},
+		{name: "dead", state: k3d.NodeState{Status: "dead", ExitCode: 255}, expected: true},
+		{name: "stopped (SIGTERM)", state: k3d.NodeState{Status: "exited", ExitCode: 143}},
+		{name: "stopped (killed after grace period)", state: k3d.NodeState{Status: "exited", ExitCode: 137}},
+		{name: "clean shutdown", state: k3d.NodeState{Status: "exited", ExitCode: 0}},
+		{name: "never started", state: k3d.NodeState{Status: "created"}},
+		{name: "paused", state: k3d.NodeState{Status: "paused"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := nodeExitedUnexpectedly(&k3d.Node{Name: "test", State: tt.state}); actual != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestClusterWatcherNodeUp(t *testing.T) {
+	node := &k3d.Node{Name: "test"}
+	start := time.Now()
+	w := &clusterWatcher{
+		opts:        k3d.ClusterWatchOpts{Interval: time.Minute, MaxRestarts: 3},
+		restarts:    map[string]int{},
+		restartedAt: map[string]time.Time{},
+	}
+
+	// crash loop: the node is up during the check after each restart, but crashes before the next one
+	for i := 0; i < w.opts.MaxRestarts; i++ {
+		restartedAt := start.Add(time.Duration(2*i) * w.opts.Interval)
+		w.restarts[node.Name]++
+		w.restartedAt[node.Name] = restartedAt
+		w.nodeUp(node, restartedAt.Add(w.opts.Interval))
+	}
+	if w.restarts[node.Name] != w.opts.MaxRestarts {
+		t.Fatalf("expected %d restart attempts for a crash-looping node, got %d", w.opts.MaxRestarts, w.restarts[node.Name])
+	}
+
+	// stable: the node stayed up long enough after its last restart
+	w.nodeUp(node, w.restartedAt[node.Name].Add(watchStableIntervals*w.opts.Interval))
+	if w.restarts[node.Name] != 0 {
+		t.Errorf("expected the restart attempts of a stable node to be reset, got %d", w.restarts[node.Name])
+	}
+}
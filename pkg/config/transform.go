@@ -24,22 +24,29 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/docker/go-connections/nat"
+	dockerunits "github.com/docker/go-units"
 	cliutil "github.com/rancher/k3d/v5/cmd/util" // TODO: move parseapiport to pkg
+	"github.com/rancher/k3d/v5/pkg/actions"
 	"github.com/rancher/k3d/v5/pkg/client"
 	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/pkg/types/k3s"
 	"github.com/rancher/k3d/v5/pkg/util"
-	"github.com/rancher/k3d/v5/version"
 	"gopkg.in/yaml.v2"
 	"inet.af/netaddr"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	l "github.com/rancher/k3d/v5/pkg/logger"
 )
@@ -54,16 +61,18 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 
 	/* Special cases for Image:
 	 * - "latest" / "stable": get latest / stable channel image
-	 * - starts with "+": get channel following the "+"
+	 * - starts with "+" (or tag starts with "+"): get channel following the "+"
 	 */
-	if simpleConfig.Image == "latest" || simpleConfig.Image == "stable" || strings.HasPrefix(simpleConfig.Image, "+") {
-		searchChannel := strings.TrimPrefix(simpleConfig.Image, "+")
-		v, err := version.GetK3sVersion(searchChannel)
-		if err != nil {
-			return nil, err
-		}
-		l.Log().Debugf("Using fetched K3s version %s", v)
-		simpleConfig.Image = fmt.Sprintf("%s:%s", k3d.DefaultK3sImageRepo, v)
+	if _, channel, ok := client.K3sImageChannel(simpleConfig.Image); ok && simpleConfig.Options.K3dOptions.NoPull {
+		return nil, fmt.Errorf("cannot resolve the k3s release channel '%s' without pulling images: use an image that exists locally", channel)
+	}
+	image, err := client.K3sImageResolve(simpleConfig.Image)
+	if err != nil {
+		return nil, err
+	}
+	if image != simpleConfig.Image {
+		l.Log().Debugf("Using fetched K3s image %s", image)
+		simpleConfig.Image = image
 	}
 
 	clusterNetwork := k3d.ClusterNetwork{}
@@ -71,7 +80,7 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		clusterNetwork.Name = simpleConfig.Network
 		clusterNetwork.External = true
 	} else {
-		clusterNetwork.Name = fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, simpleConfig.Name)
+		clusterNetwork.Name = fmt.Sprintf("%s-%s", k3d.ObjectNamePrefix, simpleConfig.Name)
 		clusterNetwork.External = false
 	}
 
@@ -81,14 +90,64 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 			if err != nil {
 				return nil, fmt.Errorf("invalid subnet '%s': %w", simpleConfig.Subnet, err)
 			}
-			clusterNetwork.IPAM.IPPrefix = subnet
+			if !subnet.IP().Is4() {
+				return nil, fmt.Errorf("invalid subnet '%s': must be an IPv4 subnet (use ipv6/--ipv6 for an additional IPv6 subnet)", simpleConfig.Subnet)
+			}
+			clusterNetwork.IPAM.IPPrefix = subnet.Masked()
 		}
 		clusterNetwork.IPAM.Managed = true
 	}
 
+	if simpleConfig.Gateway != "" {
+		if clusterNetwork.IPAM.IPPrefix.IsZero() {
+			return nil, fmt.Errorf("gateway '%s' requires a subnet to be specified", simpleConfig.Gateway)
+		}
+		gateway, err := netaddr.ParseIP(simpleConfig.Gateway)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gateway '%s': %w", simpleConfig.Gateway, err)
+		}
+		subnetRange := clusterNetwork.IPAM.IPPrefix.Range()
+		if !clusterNetwork.IPAM.IPPrefix.Contains(gateway) || gateway == subnetRange.From() || gateway == subnetRange.To() {
+			return nil, fmt.Errorf("invalid gateway '%s': must be a host address in subnet %s", gateway, clusterNetwork.IPAM.IPPrefix)
+		}
+		clusterNetwork.IPAM.Gateway = gateway
+	}
+
+	if simpleConfig.IPv6 != "" {
+		if simpleConfig.IPv6 != "auto" {
+			subnet, err := netaddr.ParseIPPrefix(simpleConfig.IPv6)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IPv6 subnet '%s': %w", simpleConfig.IPv6, err)
+			}
+			if !subnet.IP().Is6() || subnet.IP().Is4in6() {
+				return nil, fmt.Errorf("invalid IPv6 subnet '%s': must be an IPv6 subnet", simpleConfig.IPv6)
+			}
+			clusterNetwork.IPAM.IPv6Prefix = subnet.Masked()
+		}
+		clusterNetwork.IPv6 = true
+	}
+
+	// -> BIND ADDRESS: used for all host port mappings without an explicit host IP
+	bindAddress := simpleConfig.Options.K3dOptions.DefaultBindAddress
+	if bindAddress == "" {
+		bindAddress = util.DefaultBindAddress()
+	}
+	if net.ParseIP(bindAddress) == nil {
+		return nil, fmt.Errorf("invalid default bind address '%s': must be an IP address", bindAddress)
+	}
+
 	// -> API
 	if simpleConfig.ExposeAPI.HostIP == "" {
-		simpleConfig.ExposeAPI.HostIP = k3d.DefaultAPIHost
+		simpleConfig.ExposeAPI.HostIP = bindAddress
+	}
+	// additional TLS SANs for the API certificate, the first one (that's not a wildcard) is used as the API host in the kubeconfig, unless that's set explicitly
+	for _, san := range simpleConfig.ExposeAPI.TLSSANs {
+		if err := validateTLSSAN(san); err != nil {
+			return nil, err
+		}
+		if simpleConfig.ExposeAPI.Host == "" && !strings.HasPrefix(san, "*.") {
+			simpleConfig.ExposeAPI.Host = san
+		}
 	}
 	if simpleConfig.ExposeAPI.Host == "" {
 		simpleConfig.ExposeAPI.Host = simpleConfig.ExposeAPI.HostIP
@@ -126,8 +185,25 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 			return nil, fmt.Errorf("error preparing the loadbalancer: %w", err)
 		}
 		newCluster.Nodes = append(newCluster.Nodes, newCluster.ServerLoadBalancer.Node)
+
+		// restrict access to the API port
+		apiAccess := simpleConfig.Options.K3dOptions.Loadbalancer.APIAccess
+		for _, source := range apiAccess.AllowedSources {
+			if _, _, err := net.ParseCIDR(source); err != nil && net.ParseIP(source) == nil {
+				return nil, fmt.Errorf("invalid allowed source '%s' for the API port: must be a CIDR or an IP address", source)
+			}
+		}
+		if len(apiAccess.AllowedSources) > 0 || apiAccess.ClientCertificates {
+			newCluster.ServerLoadBalancer.Config.Settings.APIAccess = k3d.LoadBalancerAPIAccess{
+				AllowedSources:     apiAccess.AllowedSources,
+				ClientCertificates: apiAccess.ClientCertificates,
+			}
+		}
 	} else {
 		l.Log().Debugln("Disabling the load balancer")
+		if len(simpleConfig.Options.K3dOptions.Loadbalancer.APIAccess.AllowedSources) > 0 || simpleConfig.Options.K3dOptions.Loadbalancer.APIAccess.ClientCertificates {
+			return nil, fmt.Errorf("restricting access to the API port requires the loadbalancer")
+		}
 	}
 
 	/*************
@@ -142,6 +218,11 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 			ServerOpts: k3d.ServerOpts{},
 			Memory:     simpleConfig.Options.Runtime.ServersMemory,
 		}
+		for _, san := range simpleConfig.ExposeAPI.TLSSANs {
+			if san != simpleConfig.ExposeAPI.Host { // the API host is added as a TLS SAN anyway
+				serverNode.Args = append(serverNode.Args, "--tls-san="+san)
+			}
+		}
 
 		// first server node will be init node if we have more than one server specified but no external datastore
 		if i == 0 && simpleConfig.Servers > 1 {
@@ -166,6 +247,108 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		newCluster.Nodes = append(newCluster.Nodes, &agentNode)
 	}
 
+	// -> MEMORY & CPU LIMITS (per node, applied before dividing the cluster-wide limits, so they're taken into account there)
+	for _, memoryWithNodeFilters := range simpleConfig.Options.Runtime.Memory {
+		if len(memoryWithNodeFilters.NodeFilters) == 0 && len(newCluster.Nodes) > 1 {
+			return nil, fmt.Errorf("memory limit '%s' lacks a node filter, but there's more than one node", memoryWithNodeFilters.Memory)
+		}
+		if memory, err := dockerunits.RAMInBytes(memoryWithNodeFilters.Memory); err != nil || memory <= 0 {
+			return nil, fmt.Errorf("invalid memory limit '%s': must be a positive amount of memory (e.g. 2g)", memoryWithNodeFilters.Memory)
+		}
+
+		nodes, err := util.FilterNodes(newCluster.Nodes, memoryWithNodeFilters.NodeFilters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter nodes for memory limit '%s': %w", memoryWithNodeFilters.Memory, err)
+		}
+
+		for _, node := range nodes {
+			node.Memory = memoryWithNodeFilters.Memory
+		}
+	}
+
+	for _, cpusWithNodeFilters := range simpleConfig.Options.Runtime.CPUs {
+		if len(cpusWithNodeFilters.NodeFilters) == 0 && len(newCluster.Nodes) > 1 {
+			return nil, fmt.Errorf("cpu limit '%s' lacks a node filter, but there's more than one node", cpusWithNodeFilters.CPUs)
+		}
+		if cpus, err := strconv.ParseFloat(cpusWithNodeFilters.CPUs, 64); err != nil || cpus <= 0 {
+			return nil, fmt.Errorf("invalid cpu limit '%s': must be a positive number of CPUs (e.g. 1.5)", cpusWithNodeFilters.CPUs)
+		}
+
+		nodes, err := util.FilterNodes(newCluster.Nodes, cpusWithNodeFilters.NodeFilters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter nodes for cpu limit '%s': %w", cpusWithNodeFilters.CPUs, err)
+		}
+
+		for _, node := range nodes {
+			node.CPUs = cpusWithNodeFilters.CPUs
+		}
+	}
+
+	// cluster-wide resource limits are divided into per-node limits
+	if err := client.ClusterDivideResourceLimits(newCluster.Nodes, simpleConfig.Options.Runtime.ClusterCPULimit, simpleConfig.Options.Runtime.ClusterMemoryLimit); err != nil {
+		return nil, fmt.Errorf("failed to apply cluster resource limits: %w", err)
+	}
+
+	// back the k3s data dir with tmpfs for fast, disposable clusters
+	if simpleConfig.Options.Runtime.NodeTmpfsRoot != "" {
+		size, err := dockerunits.RAMInBytes(simpleConfig.Options.Runtime.NodeTmpfsRoot)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid node tmpfs root size '%s': must be a positive amount of memory (e.g. 2g)", simpleConfig.Options.Runtime.NodeTmpfsRoot)
+		}
+		k3sNodeCount := 0
+		for _, node := range newCluster.Nodes {
+			if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+				continue
+			}
+			if node.Tmpfs == nil {
+				node.Tmpfs = map[string]string{}
+			}
+			// k3s executes binaries extracted to its data dir, so the tmpfs must not be mounted noexec
+			node.Tmpfs[k3d.DefaultNodeTmpfsRootPath] = fmt.Sprintf("rw,exec,size=%d", size)
+			k3sNodeCount++
+		}
+		l.Log().Warnf("Backing %s of %d node(s) with tmpfs: this may use up to %s of host memory (also counting against node memory limits) and all cluster data is lost when the nodes are stopped", k3d.DefaultNodeTmpfsRootPath, k3sNodeCount, dockerunits.BytesSize(float64(size*int64(k3sNodeCount))))
+	}
+
+	// persist the k3s data of the nodes in per-node host directories
+	if simpleConfig.Options.Runtime.ServersData != "" || simpleConfig.Options.Runtime.AgentsData != "" {
+		if simpleConfig.Options.Runtime.NodeTmpfsRoot != "" {
+			return nil, fmt.Errorf("cannot persist the node data in host directories when backing it with tmpfs")
+		}
+		if err := transformNodeData(&newCluster, k3d.ServerRole, simpleConfig.Options.Runtime.ServersData); err != nil {
+			return nil, err
+		}
+		if err := transformNodeData(&newCluster, k3d.AgentRole, simpleConfig.Options.Runtime.AgentsData); err != nil {
+			return nil, err
+		}
+		if simpleConfig.Options.Runtime.ServersData != "" {
+			token, err := transformNodeDataToken(simpleConfig.Options.Runtime.ServersData, newCluster.Token)
+			if err != nil {
+				return nil, err
+			}
+			newCluster.Token = token
+			if simpleConfig.Options.Runtime.AgentsData == "" && simpleConfig.Agents > 0 {
+				l.Log().Warnf("Persisting the data of the servers, but not of the agents: re-created agents will be rejected by the servers, as their node passwords changed (use --agents-data as well)")
+			}
+		}
+	}
+
+	// propagate the timezone into the k3s nodes
+	timezone := ""
+	if simpleConfig.Options.Runtime.Timezone != "" {
+		var timezoneData []byte
+		timezone, timezoneData, err = client.TimezoneLoad(simpleConfig.Options.Runtime.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range newCluster.Nodes {
+			if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+				node.HookActions = append(node.HookActions, client.TimezoneNodeHook(runtime, timezone, timezoneData))
+			}
+		}
+		l.Log().Debugf("Setting the timezone of the nodes to %s", timezone)
+	}
+
 	/****************************
 	 * Extra Node Configuration *
 	 ****************************/
@@ -184,8 +367,19 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		}
 	}
 
+	// -> IMAGES ARCHIVES (imported by k3s on startup, e.g. for air-gapped environments)
+	imagesArchiveVolumes, err := client.AirgapImagesArchiveVolumes(simpleConfig.Options.K3sOptions.ImagesArchives)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range newCluster.Nodes {
+		if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+			node.Volumes = append(node.Volumes, imagesArchiveVolumes...)
+		}
+	}
+
 	// -> PORTS
-	if err := client.TransformPorts(ctx, runtime, &newCluster, simpleConfig.Ports); err != nil {
+	if err := client.TransformPorts(ctx, runtime, &newCluster, simpleConfig.Ports, bindAddress); err != nil {
 		return nil, fmt.Errorf("failed to transform ports: %w", err)
 	}
 
@@ -227,12 +421,30 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 			}
 			k, v := util.SplitLabelKeyValue(runtimeLabelWithNodeFilters.Label)
 
-			cliutil.ValidateRuntimeLabelKey(k)
+			if err := util.ValidateRuntimeLabelKey(k); err != nil {
+				return nil, fmt.Errorf("invalid runtime label '%s': %w", runtimeLabelWithNodeFilters.Label, err)
+			}
 
 			node.RuntimeLabels[k] = v
 		}
 	}
 
+	// -> RUNTIME OPTS
+	for _, optWithNodeFilters := range simpleConfig.Options.Runtime.Opts {
+		if len(optWithNodeFilters.NodeFilters) == 0 && nodeCount > 1 {
+			return nil, fmt.Errorf("RuntimeOpt '%s' lacks a node filter, but there's more than one node", optWithNodeFilters.Opt)
+		}
+
+		nodes, err := util.FilterNodes(nodeList, optWithNodeFilters.NodeFilters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter nodes for runtime opt '%s': %w", optWithNodeFilters.Opt, err)
+		}
+
+		for _, node := range nodes {
+			node.RuntimeOpts = append(node.RuntimeOpts, optWithNodeFilters.Opt)
+		}
+	}
+
 	// -> ENV
 	for _, envVarWithNodeFilters := range simpleConfig.Env {
 		if len(envVarWithNodeFilters.NodeFilters) == 0 && nodeCount > 1 {
@@ -265,33 +477,187 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		}
 	}
 
+	// -> KUBELET
+	for i, kubeletWithNodeFilters := range simpleConfig.Options.K3sOptions.Kubelet {
+		if len(kubeletWithNodeFilters.NodeFilters) == 0 && nodeCount > 1 {
+			return nil, fmt.Errorf("kubelet config #%d lacks a node filter, but there's more than one node", i)
+		}
+
+		kubeletArgs, err := client.KubeletConfigArgs(&kubeletWithNodeFilters.KubeletConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubelet config #%d: %w", i, err)
+		}
+
+		nodes, err := util.FilterNodes(nodeList, kubeletWithNodeFilters.NodeFilters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter nodes for kubelet config #%d: %w", i, err)
+		}
+
+		for _, node := range nodes {
+			node.Args = append(node.Args, kubeletArgs...)
+		}
+	}
+
+	// the cluster domain is a server flag (agents get it from the servers)
+	clusterDomain, err := transformClusterDomain(&newCluster, simpleConfig.Options.K3sOptions.ClusterDomain)
+	if err != nil {
+		return nil, err
+	}
+	newCluster.Domain = clusterDomain
+
+	if err := transformClusterCIDRs(&newCluster, simpleConfig.Options.K3sOptions.ClusterCIDR, simpleConfig.Options.K3sOptions.ServiceCIDR); err != nil {
+		return nil, err
+	}
+
+	kubeProxyCheckProfile, err := transformKubeProxyMode(&newCluster, k3d.KubeProxyMode(simpleConfig.Options.K3sOptions.KubeProxyMode))
+	if err != nil {
+		return nil, err
+	}
+
 	/**************************
 	 * Cluster Create Options *
 	 **************************/
 
 	clusterCreateOpts := k3d.ClusterCreateOpts{
 		DisableImageVolume:  simpleConfig.Options.K3dOptions.DisableImageVolume,
+		ImageVolume:         simpleConfig.Options.K3dOptions.ImageVolume,
+		Manifests:           simpleConfig.Options.K3sOptions.Manifests,
+		KeepImageVolume:     simpleConfig.Options.K3dOptions.KeepImageVolume,
 		WaitForServer:       simpleConfig.Options.K3dOptions.Wait,
+		NoWaitForAgents:     simpleConfig.Options.K3dOptions.NoWaitForAgents,
 		Timeout:             simpleConfig.Options.K3dOptions.Timeout,
+		NodeStartupTimeout:  simpleConfig.Options.K3dOptions.NodeStartupTimeout,
 		DisableLoadBalancer: simpleConfig.Options.K3dOptions.DisableLoadbalancer,
 		GPURequest:          simpleConfig.Options.Runtime.GPURequest,
 		ServersMemory:       simpleConfig.Options.Runtime.ServersMemory,
 		AgentsMemory:        simpleConfig.Options.Runtime.AgentsMemory,
+		ClusterCPULimit:     simpleConfig.Options.Runtime.ClusterCPULimit,
+		ClusterMemoryLimit:  simpleConfig.Options.Runtime.ClusterMemoryLimit,
+		NodeTmpfsRoot:       simpleConfig.Options.Runtime.NodeTmpfsRoot,
+		HTTPProxy:           simpleConfig.Options.Runtime.HTTPProxy,
+		NoPull:              simpleConfig.Options.K3dOptions.NoPull,
+		FixInotify:          simpleConfig.Options.K3dOptions.FixInotify,
+		StrictPreflight:     simpleConfig.Options.K3dOptions.StrictPreflight,
+		ImagesArchives:      simpleConfig.Options.K3sOptions.ImagesArchives,
+		CheckProfiles:       simpleConfig.Options.K3dOptions.CheckProfiles,
+		OnNodeFailure:       k3d.NodeFailurePolicy(simpleConfig.Options.K3dOptions.OnNodeFailure),
+		Rollback:            k3d.RollbackPolicy(simpleConfig.Options.K3dOptions.Rollback),
 		GlobalLabels:        map[string]string{}, // empty init
 		GlobalEnv:           []string{},          // empty init
 	}
 
+	// the network policy test suite needs the network policy controller (enabled by default) and a ready server to report its results
+	if simpleConfig.Options.K3dOptions.NetworkPolicyTest {
+		for _, node := range newCluster.Nodes {
+			for _, arg := range node.Args {
+				if arg == "--disable-network-policy" || arg == "--flannel-backend=none" {
+					return nil, fmt.Errorf("cannot run the network policy test suite with k3s arg '%s' on node '%s', as it disables the network policy controller", arg, node.Name)
+				}
+			}
+		}
+		clusterCreateOpts.WaitForServer = true
+	}
+
+	// verify the host kernel requirements of the kube-proxy mode as well
+	if kubeProxyCheckProfile != "" {
+		found := false
+		for _, profile := range clusterCreateOpts.CheckProfiles {
+			found = found || profile == kubeProxyCheckProfile
+		}
+		if !found {
+			clusterCreateOpts.CheckProfiles = append(clusterCreateOpts.CheckProfiles, kubeProxyCheckProfile)
+		}
+	}
+
+	if clusterCreateOpts.DisableImageVolume && (clusterCreateOpts.ImageVolume != "" || clusterCreateOpts.KeepImageVolume) {
+		return nil, fmt.Errorf("cannot name or keep the image volume when disabling it")
+	}
+
+	switch clusterCreateOpts.OnNodeFailure {
+	case "", k3d.NodeFailurePolicyRollback, k3d.NodeFailurePolicyContinue, k3d.NodeFailurePolicyRetry:
+	default:
+		return nil, fmt.Errorf("invalid node failure policy '%s': must be one of %v", clusterCreateOpts.OnNodeFailure, k3d.NodeFailurePolicies)
+	}
+
+	switch clusterCreateOpts.Rollback {
+	case "":
+		clusterCreateOpts.Rollback = k3d.RollbackPolicyAuto
+	case k3d.RollbackPolicyAuto, k3d.RollbackPolicyNever, k3d.RollbackPolicyAlways:
+	default:
+		return nil, fmt.Errorf("invalid rollback policy '%s': must be one of %v", clusterCreateOpts.Rollback, k3d.RollbackPolicies)
+	}
+	if simpleConfig.Options.K3dOptions.NoRollback {
+		if clusterCreateOpts.Rollback != k3d.RollbackPolicyAuto && clusterCreateOpts.Rollback != k3d.RollbackPolicyNever {
+			return nil, fmt.Errorf("cannot use rollback policy '%s' when disabling the rollback", clusterCreateOpts.Rollback)
+		}
+		clusterCreateOpts.Rollback = k3d.RollbackPolicyNever
+	}
+
+	// custom readiness markers
+	if len(simpleConfig.Options.K3dOptions.ReadyLogMessages) > 0 {
+		clusterCreateOpts.ReadyLogMessages = map[k3d.Role]string{}
+		for role, message := range simpleConfig.Options.K3dOptions.ReadyLogMessages {
+			switch k3d.Role(role) {
+			case k3d.ServerRole, k3d.AgentRole, k3d.LoadBalancerRole:
+			default:
+				return nil, fmt.Errorf("invalid role '%s' for a ready log message: must be one of server, agent, loadbalancer", role)
+			}
+			if message == "" {
+				return nil, fmt.Errorf("ready log message for role '%s' must not be empty", role)
+			}
+			clusterCreateOpts.ReadyLogMessages[k3d.Role(role)] = message
+		}
+	}
+
 	// ensure, that we have the default object labels
 	for k, v := range k3d.DefaultRuntimeLabels {
 		clusterCreateOpts.GlobalLabels[k] = v
 	}
 
+	// the bind address is stored in the labels, so that ports added via `k3d cluster edit` are bound to it as well
+	clusterCreateOpts.GlobalLabels[k3d.LabelClusterBindAddress] = bindAddress
+
+	// the customized startup order is stored in the labels, so that it's respected by `k3d cluster start` as well
+	if startup := transformStartupOpts(simpleConfig.Options.K3dOptions.Startup); startup != nil {
+		newCluster.Startup = startup
+		startupJSON, err := json.Marshal(startup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal startup order: %w", err)
+		}
+		clusterCreateOpts.GlobalLabels[k3d.LabelClusterStartup] = string(startupJSON)
+	}
+
+	// the cluster domain is stored in the labels, so that it's known to `k3d env` and other tools
+	if newCluster.Domain != "" {
+		clusterCreateOpts.GlobalLabels[k3d.LabelClusterDomain] = newCluster.Domain
+	}
+
+	// the timezone is stored in the labels, so that nodes added later get it as well
+	if timezone != "" {
+		clusterCreateOpts.GlobalLabels[k3d.LabelClusterTimezone] = timezone
+	}
+
+	// the hibernation schedule is stored in the labels, so that it can be enforced by `k3d watch`
+	if simpleConfig.Options.K3dOptions.HibernationSchedule != "" {
+		clusterCreateOpts.GlobalLabels[k3d.LabelHibernationSchedule] = simpleConfig.Options.K3dOptions.HibernationSchedule
+	}
+
+	// the name prefix is stored in the labels, so that the cluster's objects can be found even if a different prefix is used later on
+	newCluster.NamePrefix = k3d.ObjectNamePrefix
+	clusterCreateOpts.GlobalLabels[k3d.LabelClusterNamePrefix] = k3d.ObjectNamePrefix
+
+	// the external ID is stored in the labels, so that `k3d cluster prune --external-id-gone` can check it
+	if simpleConfig.Options.K3dOptions.ExternalID != "" {
+		newCluster.ExternalID = simpleConfig.Options.K3dOptions.ExternalID
+		clusterCreateOpts.GlobalLabels[k3d.LabelClusterExternalID] = simpleConfig.Options.K3dOptions.ExternalID
+	}
+
 	/*
 	 * Registries
 	 */
 	if simpleConfig.Registries.Create != nil {
 
-		epSpecHost := "0.0.0.0"
+		epSpecHost := bindAddress
 		epSpecPort := "random"
 
 		if simpleConfig.Registries.Create.HostPort != "" {
@@ -306,7 +672,7 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 			return nil, fmt.Errorf("failed to get port for registry: %w", err)
 		}
 
-		regName := fmt.Sprintf("%s-%s-registry", k3d.DefaultObjectNamePrefix, newCluster.Name)
+		regName := fmt.Sprintf("%s-%s-registry", k3d.ObjectNamePrefix, newCluster.Name)
 		if simpleConfig.Registries.Create.Name != "" {
 			regName = simpleConfig.Registries.Create.Name
 		}
@@ -328,6 +694,23 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		clusterCreateOpts.Registries.Use = append(clusterCreateOpts.Registries.Use, reg)
 	}
 
+	if simpleConfig.Options.K3sOptions.CoreDNSCustom != "" {
+		var corednsCustomBytes []byte
+		if strings.Contains(simpleConfig.Options.K3sOptions.CoreDNSCustom, "\n") { // CASE 1: embedded config (multiline string)
+			corednsCustomBytes = []byte(simpleConfig.Options.K3sOptions.CoreDNSCustom)
+		} else { // CASE 2: config file referenced by path (single line)
+			corednsCustomBytes, err = os.ReadFile(simpleConfig.Options.K3sOptions.CoreDNSCustom)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read custom CoreDNS config file at %s: %w", simpleConfig.Options.K3sOptions.CoreDNSCustom, err)
+			}
+		}
+		corednsCustom, err := client.CoreDNSCustomParse(corednsCustomBytes)
+		if err != nil {
+			return nil, err
+		}
+		clusterCreateOpts.CoreDNSCustom = corednsCustom
+	}
+
 	if simpleConfig.Registries.Config != "" {
 		var k3sRegistry *k3s.Registry
 
@@ -355,6 +738,64 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 		clusterCreateOpts.Registries.Config = k3sRegistry
 	}
 
+	// registry mirrors are added to the registries config (after the endpoints configured there, if any)
+	for _, mirror := range simpleConfig.Registries.Mirrors {
+		registry, endpoints, err := util.ParseRegistryMirror(mirror)
+		if err != nil {
+			return nil, err
+		}
+		if clusterCreateOpts.Registries.Config == nil {
+			clusterCreateOpts.Registries.Config = &k3s.Registry{}
+		}
+		if clusterCreateOpts.Registries.Config.Mirrors == nil {
+			clusterCreateOpts.Registries.Config.Mirrors = map[string]k3s.Mirror{}
+		}
+		registryMirror := clusterCreateOpts.Registries.Config.Mirrors[registry]
+		registryMirror.Endpoints = append(registryMirror.Endpoints, endpoints...)
+		clusterCreateOpts.Registries.Config.Mirrors[registry] = registryMirror
+		l.Log().Tracef("Registry: mirroring '%s' via %v", registry, endpoints)
+	}
+
+	// registry rewrite rules redirect pulls to a mirror, which may serve the repositories of the registry below a path
+	for _, rule := range simpleConfig.Registries.Rewrites {
+		registry, endpoint, path, err := util.ParseRegistryRewrite(rule)
+		if err != nil {
+			return nil, err
+		}
+		if clusterCreateOpts.Registries.Config == nil {
+			clusterCreateOpts.Registries.Config = &k3s.Registry{}
+		}
+		if clusterCreateOpts.Registries.Config.Mirrors == nil {
+			clusterCreateOpts.Registries.Config.Mirrors = map[string]k3s.Mirror{}
+		}
+		registryMirror := clusterCreateOpts.Registries.Config.Mirrors[registry]
+		registryMirror.Endpoints = append(registryMirror.Endpoints, endpoint)
+		if path != "" {
+			// containerd applies the rewrites to all endpoints of the registry, so there can only be a single path per registry
+			rewrite := path + "/$1"
+			if existing, ok := registryMirror.Rewrites[k3d.DefaultRegistryRewritePattern]; ok && existing != rewrite {
+				return nil, fmt.Errorf("registry rewrite rule '%s' conflicts with the existing rewrite of '%s' to '%s'", rule, registry, existing)
+			}
+			if registryMirror.Rewrites == nil {
+				registryMirror.Rewrites = map[string]string{}
+			}
+			registryMirror.Rewrites[k3d.DefaultRegistryRewritePattern] = rewrite
+		}
+		clusterCreateOpts.Registries.Config.Mirrors[registry] = registryMirror
+		l.Log().Tracef("Registry: rewriting '%s' to '%s' (path: '%s')", registry, endpoint, path)
+	}
+
+	/*
+	 * Cluster Hooks
+	 */
+	for i, hook := range simpleConfig.Hooks {
+		clusterHook, err := transformHook(runtime, hook)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hook #%d: %w", i, err)
+		}
+		clusterCreateOpts.ClusterHooks = append(clusterCreateOpts.ClusterHooks, clusterHook)
+	}
+
 	/**********************
 	 * Kubeconfig Options *
 	 **********************/
@@ -373,3 +814,276 @@ func TransformSimpleToClusterConfig(ctx context.Context, runtime runtimes.Runtim
 
 	return clusterConfig, nil
 }
+
+// transformStartupOpts translates the startup order customization of the config file (nil, if there is none)
+func transformStartupOpts(startup conf.SimpleConfigOptionsK3dStartup) *k3d.StartupOpts {
+	if len(startup.Dependencies) == 0 && len(startup.Checks) == 0 {
+		return nil
+	}
+	opts := &k3d.StartupOpts{}
+	if len(startup.Dependencies) > 0 {
+		opts.Dependencies = map[k3d.StartupStep][]k3d.StartupStep{}
+		for step, dependsOn := range startup.Dependencies {
+			for _, dep := range dependsOn {
+				opts.Dependencies[k3d.StartupStep(step)] = append(opts.Dependencies[k3d.StartupStep(step)], k3d.StartupStep(dep))
+			}
+		}
+	}
+	for _, check := range startup.Checks {
+		opts.Checks = append(opts.Checks, k3d.StartupCheck{
+			Name:    k3d.StartupStep(check.Name),
+			TCP:     check.TCP,
+			HTTP:    check.HTTP,
+			Timeout: check.Timeout,
+		})
+	}
+	return opts
+}
+
+// transformHook translates a hook of the config file into a cluster hook.
+// The preCreate stage is reserved for library consumers, as there are no node containers to act on yet.
+func transformHook(runtime runtimes.Runtime, hook conf.SimpleConfigHook) (k3d.ClusterHook, error) {
+	clusterHook := k3d.ClusterHook{
+		Stage:       k3d.LifecycleStage(hook.Stage),
+		NodeFilters: hook.NodeFilters,
+	}
+	switch clusterHook.Stage {
+	case k3d.LifecycleStagePostNodeStart, k3d.LifecycleStagePostClusterReady:
+	default:
+		return clusterHook, fmt.Errorf("invalid stage '%s': must be one of %s, %s", hook.Stage, k3d.LifecycleStagePostNodeStart, k3d.LifecycleStagePostClusterReady)
+	}
+
+	numActions := 0
+	if len(hook.Exec) > 0 {
+		numActions++
+		clusterHook.Action = actions.ExecAction{
+			Runtime:     runtime,
+			Command:     hook.Exec,
+			Description: fmt.Sprintf("Running hook command '%s'", strings.Join(hook.Exec, " ")),
+		}
+	}
+	if hook.WriteFile != nil {
+		numActions++
+		if hook.WriteFile.Path == "" {
+			return clusterHook, fmt.Errorf("writeFile requires a path")
+		}
+		mode := int64(0644)
+		if hook.WriteFile.Mode != "" {
+			var err error
+			if mode, err = strconv.ParseInt(hook.WriteFile.Mode, 8, 32); err != nil {
+				return clusterHook, fmt.Errorf("invalid file mode '%s' for %s: %w", hook.WriteFile.Mode, hook.WriteFile.Path, err)
+			}
+		}
+		clusterHook.Action = actions.WriteFileAction{
+			Runtime:     runtime,
+			Content:     []byte(hook.WriteFile.Content),
+			Dest:        hook.WriteFile.Path,
+			Mode:        os.FileMode(mode),
+			Description: fmt.Sprintf("Writing hook file %s", hook.WriteFile.Path),
+		}
+	}
+	if hook.ApplyManifest != "" {
+		numActions++
+		manifest, err := os.ReadFile(hook.ApplyManifest)
+		if err != nil {
+			return clusterHook, fmt.Errorf("failed to read manifest %s: %w", hook.ApplyManifest, err)
+		}
+		clusterHook.Action = actions.ApplyManifestAction{
+			Runtime:     runtime,
+			Manifest:    manifest,
+			Description: fmt.Sprintf("Applying hook manifest %s", hook.ApplyManifest),
+		}
+		// applying it once is enough
+		if len(clusterHook.NodeFilters) == 0 {
+			clusterHook.NodeFilters = []string{"server:0"}
+		}
+	}
+	if numActions != 1 {
+		return clusterHook, fmt.Errorf("exactly one of exec, writeFile and applyManifest must be set, found %d", numActions)
+	}
+	return clusterHook, nil
+}
+
+// transformClusterDomain passes the cluster domain on to the server nodes (--cluster-domain) and returns it.
+// If it's not set, a cluster domain passed via the k3s extra args is picked up instead.
+func transformClusterDomain(cluster *k3d.Cluster, domain string) (string, error) {
+	argDomain := ""
+	for _, node := range cluster.Nodes {
+		for i, arg := range node.Args {
+			if strings.HasPrefix(arg, "--cluster-domain=") {
+				argDomain = strings.TrimPrefix(arg, "--cluster-domain=")
+			} else if arg == "--cluster-domain" && i+1 < len(node.Args) {
+				argDomain = node.Args[i+1]
+			}
+		}
+	}
+	if domain == "" {
+		return argDomain, nil
+	}
+	if argDomain != "" && argDomain != domain {
+		return "", fmt.Errorf("conflicting cluster domains '%s' and '%s' (k3s arg): set it only once", domain, argDomain)
+	}
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return "", fmt.Errorf("invalid cluster domain '%s': %s", domain, strings.Join(errs, ", "))
+	}
+
+	if argDomain == "" {
+		for _, node := range cluster.Nodes {
+			if node.Role == k3d.ServerRole {
+				node.Args = append(node.Args, fmt.Sprintf("--cluster-domain=%s", domain))
+			}
+		}
+	}
+	return domain, nil
+}
+
+// transformClusterCIDRs passes the cluster (pod) and service CIDRs on to the server nodes (--cluster-cidr, --service-cidr).
+// CIDRs set explicitly (here or via the k3s extra args) are checked for overlaps with each other and with the cluster network subnets.
+func transformClusterCIDRs(cluster *k3d.Cluster, clusterCIDR string, serviceCIDR string) error {
+	for _, cidr := range []struct {
+		flag  string
+		value string
+	}{
+		{"cluster-cidr", clusterCIDR},
+		{"service-cidr", serviceCIDR},
+	} {
+		if cidr.value == "" {
+			continue
+		}
+		if _, err := client.ParseCIDRs(cidr.value); err != nil {
+			return fmt.Errorf("invalid --%s '%s': %w", cidr.flag, cidr.value, err)
+		}
+		for _, node := range cluster.Nodes {
+			if node.Role != k3d.ServerRole {
+				continue
+			}
+			if argValue, ok := client.K3sArgValue(node.Args, cidr.flag); ok {
+				if argValue != cidr.value {
+					return fmt.Errorf("conflicting values for --%s '%s' and '%s' (k3s arg): set it only once", cidr.flag, cidr.value, argValue)
+				}
+				continue
+			}
+			node.Args = append(node.Args, fmt.Sprintf("--%s=%s", cidr.flag, cidr.value))
+		}
+	}
+
+	effectiveClusterCIDR, clusterCIDRExplicit, effectiveServiceCIDR, serviceCIDRExplicit := client.ClusterGetCIDRs(cluster)
+	if !clusterCIDRExplicit && !serviceCIDRExplicit {
+		return nil // the defaults get checked against the actual cluster network later on
+	}
+	if err := client.ValidateClusterCIDRs(effectiveClusterCIDR, effectiveServiceCIDR, cluster.Network.IPAM.IPPrefix, cluster.Network.IPAM.IPv6Prefix); err != nil {
+		return fmt.Errorf("invalid cluster network setup: %w", err)
+	}
+	return nil
+}
+
+// transformNodeData bind-mounts per-node subdirectories of the given host directory to the data paths of the nodes with the given role
+func transformNodeData(cluster *k3d.Cluster, role k3d.Role, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get the absolute path of the %s data directory '%s': %w", role, dir, err)
+	}
+	subdirs := []string{}
+	for subdir := range k3d.DefaultNodeDataPaths {
+		subdirs = append(subdirs, subdir)
+	}
+	sort.Strings(subdirs)
+
+	for _, node := range cluster.Nodes {
+		if node.Role != role {
+			continue
+		}
+		for _, subdir := range subdirs {
+			src := filepath.Join(dir, node.Name, subdir)
+			// create it upfront, so it's owned by the user and not by the runtime (which would create it as root)
+			if err := os.MkdirAll(src, 0755); err != nil {
+				return fmt.Errorf("failed to create data directory '%s' for node '%s': %w", src, node.Name, err)
+			}
+			node.Volumes = append(node.Volumes, fmt.Sprintf("%s:%s", src, k3d.DefaultNodeDataPaths[subdir]))
+		}
+	}
+	return nil
+}
+
+// transformNodeDataToken returns the cluster token persisted in the servers data directory, so that re-created servers can decrypt their data.
+// The given token is persisted there on first use (or a new one is generated, if it's empty).
+func transformNodeDataToken(dir string, token string) (string, error) {
+	tokenFile := filepath.Join(dir, k3d.DefaultNodeDataTokenFile)
+	persisted, err := os.ReadFile(tokenFile)
+	if err == nil {
+		persistedToken := strings.TrimSpace(string(persisted))
+		if token != "" && token != persistedToken {
+			return "", fmt.Errorf("cluster token doesn't match the one persisted with the server data in '%s'", tokenFile)
+		}
+		l.Log().Infof("Using the cluster token persisted with the server data in '%s'", tokenFile)
+		return persistedToken, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read the cluster token from '%s': %w", tokenFile, err)
+	}
+
+	if token == "" {
+		token = client.GenerateClusterToken()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create the servers data directory '%s': %w", dir, err)
+	}
+	if err := os.WriteFile(tokenFile, []byte(token), 0600); err != nil {
+		return "", fmt.Errorf("failed to persist the cluster token in '%s': %w", tokenFile, err)
+	}
+	return token, nil
+}
+
+// transformKubeProxyMode passes the kube-proxy mode on to the k3s nodes and returns the check profile verifying its host kernel requirements (if any).
+// As the kube-proxy config may also be passed via the k3s extra args, conflicting args are rejected.
+func transformKubeProxyMode(cluster *k3d.Cluster, mode k3d.KubeProxyMode) (string, error) {
+	if mode == "" {
+		return "", nil
+	}
+	valid := false
+	for _, m := range k3d.KubeProxyModes {
+		valid = valid || m == mode
+	}
+	if !valid {
+		return "", fmt.Errorf("invalid kube-proxy mode '%s': must be one of %v", mode, k3d.KubeProxyModes)
+	}
+
+	for _, node := range cluster.Nodes {
+		for _, arg := range node.Args {
+			if strings.HasPrefix(arg, "--disable-kube-proxy") || strings.Contains(arg, "proxy-mode=") {
+				return "", fmt.Errorf("conflicting kube-proxy mode '%s' and k3s arg '%s' on node '%s': set it only once", mode, arg, node.Name)
+			}
+		}
+	}
+
+	for _, node := range cluster.Nodes {
+		switch mode {
+		case k3d.KubeProxyModeIPVS:
+			// kube-proxy runs on every node
+			if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+				node.Args = append(node.Args, "--kube-proxy-arg=proxy-mode=ipvs")
+			}
+		case k3d.KubeProxyModeNone:
+			// the agents get this setting from the servers
+			if node.Role == k3d.ServerRole {
+				node.Args = append(node.Args, "--disable-kube-proxy")
+			}
+		}
+	}
+
+	return k3d.KubeProxyModeCheckProfiles[mode], nil
+}
+
+// validateTLSSAN checks that a TLS SAN for the API certificate is an IP address or a DNS name (optionally with a wildcard)
+func validateTLSSAN(san string) error {
+	if net.ParseIP(san) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(san, "*.")); len(errs) > 0 {
+		return fmt.Errorf("invalid TLS SAN '%s': must be an IP address or a DNS name: %s", san, strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -24,11 +24,20 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/docker/go-connections/nat"
 	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
 	"github.com/spf13/viper"
+	"inet.af/netaddr"
 )
 
 func TestTransformSimpleConfigToClusterConfig(t *testing.T) {
@@ -53,3 +62,918 @@ func TestTransformSimpleConfigToClusterConfig(t *testing.T) {
 	t.Logf("\n===== Resulting Cluster Config =====\n%+v\n===============\n", clusterCfg)
 
 }
+
+// transformTestConfig transforms a minimal simple config (a single server), adjusted by modify, into a cluster config.
+// If wantErr is set, it only checks that the transformation fails and returns nil.
+func transformTestConfig(t *testing.T, wantErr bool, modify func(*conf.SimpleConfig)) *conf.ClusterConfig {
+	t.Helper()
+	simpleCfg := conf.SimpleConfig{
+		Name:    "test",
+		Servers: 1,
+		Image:   "rancher/k3s:latest-test",
+	}
+	if modify != nil {
+		modify(&simpleCfg)
+	}
+	clusterCfg, err := TransformSimpleToClusterConfig(context.Background(), runtimes.Docker, simpleCfg)
+	if wantErr {
+		if err == nil {
+			t.Errorf("expected an error, got none")
+		}
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clusterCfg
+}
+
+func TestTransformSimpleConfigNodeResources(t *testing.T) {
+	resources := func(cpus string) func(*conf.SimpleConfig) {
+		return func(simpleCfg *conf.SimpleConfig) {
+			simpleCfg.Agents = 2
+			simpleCfg.Options.Runtime.Memory = []conf.MemoryWithNodeFilters{{Memory: "2g", NodeFilters: []string{"agent:0"}}}
+			simpleCfg.Options.Runtime.CPUs = []conf.CPUsWithNodeFilters{{CPUs: cpus, NodeFilters: []string{"agent:*"}}}
+		}
+	}
+
+	clusterCfg := transformTestConfig(t, false, resources("0.5"))
+	expected := map[string][2]string{ // name -> memory, cpus
+		"k3d-test-server-0": {"", ""},
+		"k3d-test-agent-0":  {"2g", "0.5"},
+		"k3d-test-agent-1":  {"", "0.5"},
+	}
+	for _, node := range clusterCfg.Cluster.Nodes {
+		exp, ok := expected[node.Name]
+		if !ok {
+			continue
+		}
+		if node.Memory != exp[0] || node.CPUs != exp[1] {
+			t.Errorf("node %s: expected memory '%s' and cpus '%s', got '%s' and '%s'", node.Name, exp[0], exp[1], node.Memory, node.CPUs)
+		}
+	}
+
+	// invalid cpu limit
+	transformTestConfig(t, true, resources("many"))
+}
+
+func TestTransformSimpleConfigReadyLogMessages(t *testing.T) {
+	readyLogMessages := func(messages map[string]string) func(*conf.SimpleConfig) {
+		return func(simpleCfg *conf.SimpleConfig) {
+			simpleCfg.Agents = 1
+			simpleCfg.Options.K3dOptions.ReadyLogMessages = messages
+		}
+	}
+
+	clusterCfg := transformTestConfig(t, false, readyLogMessages(map[string]string{"agent": "custom agent ready"}))
+	if clusterCfg.ClusterCreateOpts.NoWaitForAgents {
+		t.Errorf("expected the agents to be waited for by default")
+	}
+	if msg := clusterCfg.ClusterCreateOpts.ReadyLogMessages[k3d.AgentRole]; msg != "custom agent ready" {
+		t.Errorf("expected custom ready log message for agents, got '%s'", msg)
+	}
+
+	for _, invalid := range []map[string]string{{"registry": "ready"}, {"server": ""}} {
+		transformTestConfig(t, true, readyLogMessages(invalid))
+	}
+}
+
+func TestTransformSimpleConfigNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		subnet   string
+		gateway  string
+		ipv6     string
+		expected k3d.ClusterNetwork
+		wantErr  bool
+	}{
+		{
+			name:     "subnet and gateway",
+			subnet:   "172.28.0.0/16",
+			gateway:  "172.28.0.254",
+			expected: k3d.ClusterNetwork{IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.28.0.0/16"), Gateway: netaddr.MustParseIP("172.28.0.254"), Managed: true}},
+		},
+		{
+			name:     "auto IPv6",
+			ipv6:     "auto",
+			expected: k3d.ClusterNetwork{IPv6: true},
+		},
+		{
+			name:     "IPv6 subnet",
+			subnet:   "auto",
+			ipv6:     "fd00:28::1/64",
+			expected: k3d.ClusterNetwork{IPv6: true, IPAM: k3d.IPAM{IPv6Prefix: netaddr.MustParseIPPrefix("fd00:28::/64"), Managed: true}},
+		},
+		{name: "gateway without subnet", gateway: "172.28.0.254", wantErr: true},
+		{name: "gateway outside of subnet", subnet: "172.28.0.0/16", gateway: "172.29.0.1", wantErr: true},
+		{name: "gateway is network address", subnet: "172.28.0.0/16", gateway: "172.28.0.0", wantErr: true},
+		{name: "IPv6 subnet as subnet", subnet: "fd00:28::/64", wantErr: true},
+		{name: "IPv4 subnet as IPv6 subnet", ipv6: "172.28.0.0/16", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Subnet = tt.subnet
+				simpleCfg.Gateway = tt.gateway
+				simpleCfg.IPv6 = tt.ipv6
+			})
+			if clusterCfg == nil {
+				return
+			}
+			network := clusterCfg.Cluster.Network
+			if network.IPv6 != tt.expected.IPv6 || network.IPAM.IPPrefix != tt.expected.IPAM.IPPrefix || network.IPAM.IPv6Prefix != tt.expected.IPAM.IPv6Prefix ||
+				network.IPAM.Gateway != tt.expected.IPAM.Gateway || network.IPAM.Managed != tt.expected.IPAM.Managed {
+				t.Errorf("expected network %+v, got %+v", tt.expected, network)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigBindAddress(t *testing.T) {
+	tests := []struct {
+		name               string
+		bindAddress        string
+		env                string
+		apiHostIP          string
+		expectedAPIHostIP  string
+		expectedPortHostIP map[string]string
+		wantErr            bool
+	}{
+		{
+			name:               "default",
+			expectedAPIHostIP:  "0.0.0.0",
+			expectedPortHostIP: map[string]string{"80/tcp": "0.0.0.0", "90/tcp": "0.0.0.0"},
+		},
+		{
+			name:               "option",
+			bindAddress:        "127.0.0.1",
+			expectedAPIHostIP:  "127.0.0.1",
+			expectedPortHostIP: map[string]string{"80/tcp": "127.0.0.1", "90/tcp": "0.0.0.0"},
+		},
+		{
+			name:               "environment",
+			env:                "127.0.0.1",
+			expectedAPIHostIP:  "127.0.0.1",
+			expectedPortHostIP: map[string]string{"80/tcp": "127.0.0.1", "90/tcp": "0.0.0.0"},
+		},
+		{
+			name:               "option overrides environment",
+			bindAddress:        "0.0.0.0",
+			env:                "127.0.0.1",
+			expectedAPIHostIP:  "0.0.0.0",
+			expectedPortHostIP: map[string]string{"80/tcp": "0.0.0.0", "90/tcp": "0.0.0.0"},
+		},
+		{
+			name:               "explicit API host IP",
+			bindAddress:        "127.0.0.1",
+			apiHostIP:          "0.0.0.0",
+			expectedAPIHostIP:  "0.0.0.0",
+			expectedPortHostIP: map[string]string{"80/tcp": "127.0.0.1", "90/tcp": "0.0.0.0"},
+		},
+		{name: "invalid", bindAddress: "localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(k3d.K3dEnvDefaultBindAddress, tt.env)
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.ExposeAPI = conf.SimpleExposureOpts{HostIP: tt.apiHostIP, HostPort: "6550"}
+				simpleCfg.Ports = []conf.PortWithNodeFilters{
+					{Port: "8080:80", NodeFilters: []string{"loadbalancer"}},
+					{Port: "0.0.0.0:9090:90", NodeFilters: []string{"loadbalancer"}},
+				}
+				simpleCfg.Options.K3dOptions.DefaultBindAddress = tt.bindAddress
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if hostIP := clusterCfg.Cluster.KubeAPI.Binding.HostIP; hostIP != tt.expectedAPIHostIP {
+				t.Errorf("expected API host IP %s, got %s", tt.expectedAPIHostIP, hostIP)
+			}
+			for port, expectedHostIP := range tt.expectedPortHostIP {
+				bindings := clusterCfg.Cluster.ServerLoadBalancer.Node.Ports[nat.Port(port)]
+				if len(bindings) != 1 || bindings[0].HostIP != expectedHostIP {
+					t.Errorf("expected port %s to be bound to %s, got %+v", port, expectedHostIP, bindings)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigAPIAccess(t *testing.T) {
+	tests := []struct {
+		name                string
+		apiAccess           conf.SimpleConfigOptionsK3dLoadbalancerAPIAccess
+		disableLoadbalancer bool
+		expected            k3d.LoadBalancerAPIAccess
+		wantErr             bool
+	}{
+		{name: "unrestricted"},
+		{
+			name:      "allowed sources",
+			apiAccess: conf.SimpleConfigOptionsK3dLoadbalancerAPIAccess{AllowedSources: []string{"192.168.1.0/24", "10.0.0.1", "fd00::/64"}},
+			expected:  k3d.LoadBalancerAPIAccess{AllowedSources: []string{"192.168.1.0/24", "10.0.0.1", "fd00::/64"}},
+		},
+		{
+			name:      "client certificates",
+			apiAccess: conf.SimpleConfigOptionsK3dLoadbalancerAPIAccess{ClientCertificates: true},
+			expected:  k3d.LoadBalancerAPIAccess{ClientCertificates: true},
+		},
+		{
+			name:      "invalid source",
+			apiAccess: conf.SimpleConfigOptionsK3dLoadbalancerAPIAccess{AllowedSources: []string{"my.host"}},
+			wantErr:   true,
+		},
+		{
+			name:                "without loadbalancer",
+			apiAccess:           conf.SimpleConfigOptionsK3dLoadbalancerAPIAccess{ClientCertificates: true},
+			disableLoadbalancer: true,
+			wantErr:             true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.ExposeAPI = conf.SimpleExposureOpts{HostPort: "6550"}
+				simpleCfg.Options.K3dOptions.Loadbalancer.APIAccess = tt.apiAccess
+				simpleCfg.Options.K3dOptions.DisableLoadbalancer = tt.disableLoadbalancer
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if apiAccess := clusterCfg.Cluster.ServerLoadBalancer.Config.Settings.APIAccess; !reflect.DeepEqual(apiAccess, tt.expected) {
+				t.Errorf("expected API access %+v, got %+v", tt.expected, apiAccess)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigRegistryMirrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		mirrors  []string
+		expected map[string]k3s.Mirror
+		wantErr  bool
+	}{
+		{
+			name:     "docker hub by default",
+			mirrors:  []string{"https://mirror.gcr.io"},
+			expected: map[string]k3s.Mirror{"docker.io": {Endpoints: []string{"https://mirror.gcr.io"}}},
+		},
+		{
+			name:    "multiple registries and endpoints",
+			mirrors: []string{"quay.io=http://cache:5000,https://quay.io", "*=http://cache:5001", "quay.io=http://cache:5002"},
+			expected: map[string]k3s.Mirror{
+				"quay.io": {Endpoints: []string{"http://cache:5000", "https://quay.io", "http://cache:5002"}},
+				"*":       {Endpoints: []string{"http://cache:5001"}},
+			},
+		},
+		{
+			name:    "appended to registries config",
+			config:  "mirrors:\n  docker.io:\n    endpoint:\n      - http://first:5000\n",
+			mirrors: []string{"http://second:5000"},
+			expected: map[string]k3s.Mirror{
+				"docker.io": {Endpoints: []string{"http://first:5000", "http://second:5000"}},
+			},
+		},
+		{name: "endpoint without scheme", mirrors: []string{"docker.io=cache:5000"}, wantErr: true},
+		{name: "empty registry", mirrors: []string{"=http://cache:5000"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Registries.Config = tt.config
+				simpleCfg.Registries.Mirrors = tt.mirrors
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if mirrors := clusterCfg.ClusterCreateOpts.Registries.Config.Mirrors; !reflect.DeepEqual(mirrors, tt.expected) {
+				t.Errorf("expected mirrors %+v, got %+v", tt.expected, mirrors)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigRegistryRewrites(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		mirrors  []string
+		rewrites []string
+		expected map[string]k3s.Mirror
+		wantErr  bool
+	}{
+		{
+			name:     "mirror host",
+			rewrites: []string{"docker.io -> mirror.corp:5000"},
+			expected: map[string]k3s.Mirror{"docker.io": {Endpoints: []string{"https://mirror.corp:5000"}}},
+		},
+		{
+			name:     "mirror path",
+			rewrites: []string{"docker.io -> http://artifactory.corp/dockerhub-remote/", "ghcr.io->artifactory.corp/ghcr"},
+			expected: map[string]k3s.Mirror{
+				"docker.io": {Endpoints: []string{"http://artifactory.corp"}, Rewrites: map[string]string{"^(.*)$": "dockerhub-remote/$1"}},
+				"ghcr.io":   {Endpoints: []string{"https://artifactory.corp"}, Rewrites: map[string]string{"^(.*)$": "ghcr/$1"}},
+			},
+		},
+		{
+			name:     "appended to registries config and mirrors",
+			config:   "mirrors:\n  docker.io:\n    endpoint:\n      - http://first:5000\n",
+			mirrors:  []string{"http://second:5000"},
+			rewrites: []string{"docker.io -> third:5000/hub", "docker.io -> fourth:5000/hub"},
+			expected: map[string]k3s.Mirror{
+				"docker.io": {
+					Endpoints: []string{"http://first:5000", "http://second:5000", "https://third:5000", "https://fourth:5000"},
+					Rewrites:  map[string]string{"^(.*)$": "hub/$1"},
+				},
+			},
+		},
+		{name: "conflicting paths", rewrites: []string{"docker.io -> mirror.corp/hub", "docker.io -> mirror.corp/dockerhub"}, wantErr: true},
+		{name: "missing arrow", rewrites: []string{"docker.io=mirror.corp:5000"}, wantErr: true},
+		{name: "empty registry", rewrites: []string{" -> mirror.corp:5000"}, wantErr: true},
+		{name: "invalid scheme", rewrites: []string{"docker.io -> ftp://mirror.corp"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Registries.Config = tt.config
+				simpleCfg.Registries.Mirrors = tt.mirrors
+				simpleCfg.Registries.Rewrites = tt.rewrites
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if mirrors := clusterCfg.ClusterCreateOpts.Registries.Config.Mirrors; !reflect.DeepEqual(mirrors, tt.expected) {
+				t.Errorf("expected mirrors %+v, got %+v", tt.expected, mirrors)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigHooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		hook        conf.SimpleConfigHook
+		nodeFilters []string
+		action      string
+		wantErr     bool
+	}{
+		{
+			name:   "exec",
+			hook:   conf.SimpleConfigHook{Stage: "postNodeStart", Exec: []string{"touch", "/tmp/ready"}},
+			action: "ExecAction",
+		},
+		{
+			name:        "write file",
+			hook:        conf.SimpleConfigHook{Stage: "postClusterReady", NodeFilters: []string{"agent:*"}, WriteFile: &conf.SimpleConfigHookWriteFile{Path: "/etc/motd", Content: "hi", Mode: "0600"}},
+			nodeFilters: []string{"agent:*"},
+			action:      "WriteFileAction",
+		},
+		{name: "preCreate reserved", hook: conf.SimpleConfigHook{Stage: "preCreate", Exec: []string{"true"}}, wantErr: true},
+		{name: "unknown stage", hook: conf.SimpleConfigHook{Stage: "postStart", Exec: []string{"true"}}, wantErr: true},
+		{name: "no action", hook: conf.SimpleConfigHook{Stage: "postNodeStart"}, wantErr: true},
+		{
+			name:    "multiple actions",
+			hook:    conf.SimpleConfigHook{Stage: "postNodeStart", Exec: []string{"true"}, WriteFile: &conf.SimpleConfigHookWriteFile{Path: "/tmp/x"}},
+			wantErr: true,
+		},
+		{name: "invalid mode", hook: conf.SimpleConfigHook{Stage: "postNodeStart", WriteFile: &conf.SimpleConfigHookWriteFile{Path: "/tmp/x", Mode: "rw"}}, wantErr: true},
+		{name: "missing manifest", hook: conf.SimpleConfigHook{Stage: "postClusterReady", ApplyManifest: "./does-not-exist.yaml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Hooks = []conf.SimpleConfigHook{tt.hook}
+			})
+			if clusterCfg == nil {
+				return
+			}
+			hooks := clusterCfg.ClusterCreateOpts.ClusterHooks
+			if len(hooks) != 1 {
+				t.Fatalf("expected 1 hook, got %d", len(hooks))
+			}
+			if string(hooks[0].Stage) != tt.hook.Stage || hooks[0].Action.Name() != tt.action || !reflect.DeepEqual(hooks[0].NodeFilters, tt.nodeFilters) {
+				t.Errorf("expected %s hook %s on %v, got %s hook %s on %v", tt.hook.Stage, tt.action, tt.nodeFilters, hooks[0].Stage, hooks[0].Action.Name(), hooks[0].NodeFilters)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigImageVolume(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    conf.SimpleConfigOptionsK3d
+		wantErr bool
+	}{
+		{name: "named and kept", opts: conf.SimpleConfigOptionsK3d{ImageVolume: "ci-images", KeepImageVolume: true}},
+		{name: "disabled", opts: conf.SimpleConfigOptionsK3d{DisableImageVolume: true}},
+		{name: "named but disabled", opts: conf.SimpleConfigOptionsK3d{DisableImageVolume: true, ImageVolume: "ci-images"}, wantErr: true},
+		{name: "kept but disabled", opts: conf.SimpleConfigOptionsK3d{DisableImageVolume: true, KeepImageVolume: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Options.K3dOptions = tt.opts
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if clusterCfg.ClusterCreateOpts.ImageVolume != tt.opts.ImageVolume || clusterCfg.ClusterCreateOpts.KeepImageVolume != tt.opts.KeepImageVolume {
+				t.Errorf("expected image volume %q (keep: %t), got %q (keep: %t)", tt.opts.ImageVolume, tt.opts.KeepImageVolume, clusterCfg.ClusterCreateOpts.ImageVolume, clusterCfg.ClusterCreateOpts.KeepImageVolume)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigClusterDomain(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       conf.SimpleConfigOptionsK3s
+		wantDomain string
+		wantArgs   []string
+		wantErr    bool
+	}{
+		{name: "default", wantArgs: nil},
+		{name: "custom", opts: conf.SimpleConfigOptionsK3s{ClusterDomain: "my.local"}, wantDomain: "my.local", wantArgs: []string{"--cluster-domain=my.local"}},
+		{
+			name:       "via k3s arg",
+			opts:       conf.SimpleConfigOptionsK3s{ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--cluster-domain=my.local", NodeFilters: []string{"server:*"}}}},
+			wantDomain: "my.local",
+			wantArgs:   []string{"--cluster-domain=my.local"},
+		},
+		{
+			name:       "same as k3s arg",
+			opts:       conf.SimpleConfigOptionsK3s{ClusterDomain: "my.local", ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--cluster-domain=my.local", NodeFilters: []string{"server:*"}}}},
+			wantDomain: "my.local",
+			wantArgs:   []string{"--cluster-domain=my.local"},
+		},
+		{
+			name:    "conflicting k3s arg",
+			opts:    conf.SimpleConfigOptionsK3s{ClusterDomain: "my.local", ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--cluster-domain=other.local", NodeFilters: []string{"server:*"}}}},
+			wantErr: true,
+		},
+		{name: "invalid", opts: conf.SimpleConfigOptionsK3s{ClusterDomain: "My_Domain"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Options.K3sOptions = tt.opts
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if clusterCfg.Cluster.Domain != tt.wantDomain {
+				t.Errorf("expected cluster domain %q, got %q", tt.wantDomain, clusterCfg.Cluster.Domain)
+			}
+			if clusterCfg.ClusterCreateOpts.GlobalLabels[k3d.LabelClusterDomain] != tt.wantDomain {
+				t.Errorf("expected cluster domain label %q, got %q", tt.wantDomain, clusterCfg.ClusterCreateOpts.GlobalLabels[k3d.LabelClusterDomain])
+			}
+			for _, node := range clusterCfg.Cluster.Nodes {
+				if node.Role == k3d.ServerRole && !reflect.DeepEqual(node.Args, tt.wantArgs) {
+					t.Errorf("expected server args %v, got %v", tt.wantArgs, node.Args)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigClusterCIDRs(t *testing.T) {
+	tests := []struct {
+		name     string
+		subnet   string
+		opts     conf.SimpleConfigOptionsK3s
+		wantArgs []string
+		wantErr  bool
+	}{
+		{name: "default", wantArgs: nil},
+		{
+			name:     "custom",
+			opts:     conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.52.0.0/16", ServiceCIDR: "10.53.0.0/16"},
+			wantArgs: []string{"--cluster-cidr=10.52.0.0/16", "--service-cidr=10.53.0.0/16"},
+		},
+		{
+			name:     "dual-stack",
+			opts:     conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.42.0.0/16,2001:cafe:42::/56"},
+			wantArgs: []string{"--cluster-cidr=10.42.0.0/16,2001:cafe:42::/56"},
+		},
+		{
+			name:     "same as k3s arg",
+			opts:     conf.SimpleConfigOptionsK3s{ServiceCIDR: "10.53.0.0/16", ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--service-cidr=10.53.0.0/16", NodeFilters: []string{"server:*"}}}},
+			wantArgs: []string{"--service-cidr=10.53.0.0/16"},
+		},
+		{
+			name:    "conflicting k3s arg",
+			opts:    conf.SimpleConfigOptionsK3s{ServiceCIDR: "10.53.0.0/16", ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--service-cidr=10.54.0.0/16", NodeFilters: []string{"server:*"}}}},
+			wantErr: true,
+		},
+		{name: "invalid", opts: conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.52.0.0"}, wantErr: true},
+		{name: "overlapping each other", opts: conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.0.0.0/8", ServiceCIDR: "10.53.0.0/16"}, wantErr: true},
+		{name: "overlapping default service CIDR", opts: conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.43.128.0/17"}, wantErr: true},
+		{name: "overlapping subnet", subnet: "172.28.0.0/16", opts: conf.SimpleConfigOptionsK3s{ClusterCIDR: "172.28.128.0/17"}, wantErr: true},
+		{
+			name:    "overlapping k3s arg and subnet",
+			subnet:  "10.42.0.0/24",
+			opts:    conf.SimpleConfigOptionsK3s{ExtraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--cluster-cidr=10.42.0.0/16", NodeFilters: []string{"server:*"}}}},
+			wantErr: true,
+		},
+		{
+			name:     "subnet clear of the CIDRs",
+			subnet:   "172.28.0.0/16",
+			opts:     conf.SimpleConfigOptionsK3s{ClusterCIDR: "10.52.0.0/16"},
+			wantArgs: []string{"--cluster-cidr=10.52.0.0/16"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Agents = 1
+				simpleCfg.Subnet = tt.subnet
+				simpleCfg.Options.K3sOptions = tt.opts
+			})
+			if clusterCfg == nil {
+				return
+			}
+			for _, node := range clusterCfg.Cluster.Nodes {
+				switch node.Role {
+				case k3d.ServerRole:
+					if !reflect.DeepEqual(node.Args, tt.wantArgs) {
+						t.Errorf("expected server args %v, got %v", tt.wantArgs, node.Args)
+					}
+				case k3d.AgentRole:
+					if len(node.Args) != 0 {
+						t.Errorf("expected no agent args, got %v", node.Args)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigNodeData(t *testing.T) {
+	dir := t.TempDir()
+	nodeData := func(token string, tmpfs string) func(*conf.SimpleConfig) {
+		return func(simpleCfg *conf.SimpleConfig) {
+			simpleCfg.Agents = 1
+			simpleCfg.ClusterToken = token
+			simpleCfg.Options.Runtime.ServersData = dir
+			simpleCfg.Options.Runtime.AgentsData = dir
+			simpleCfg.Options.Runtime.NodeTmpfsRoot = tmpfs
+		}
+	}
+
+	clusterCfg := transformTestConfig(t, false, nodeData("", ""))
+	for _, node := range clusterCfg.Cluster.Nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
+		want := []string{
+			fmt.Sprintf("%s:/var/lib/rancher/k3s", filepath.Join(dir, node.Name, "k3s")),
+			fmt.Sprintf("%s:/etc/rancher/node", filepath.Join(dir, node.Name, "node")),
+		}
+		if !reflect.DeepEqual(node.Volumes, want) {
+			t.Errorf("expected volumes %v for node %s, got %v", want, node.Name, node.Volumes)
+		}
+		if _, err := os.Stat(filepath.Join(dir, node.Name, "k3s")); err != nil {
+			t.Errorf("expected data directory of node %s to be created: %v", node.Name, err)
+		}
+	}
+
+	// the generated token is persisted and re-used when re-creating the cluster
+	token := clusterCfg.Cluster.Token
+	if token == "" {
+		t.Fatalf("expected a cluster token to be generated")
+	}
+	clusterCfg = transformTestConfig(t, false, nodeData("", ""))
+	if clusterCfg.Cluster.Token != token {
+		t.Errorf("expected persisted cluster token %q, got %q", token, clusterCfg.Cluster.Token)
+	}
+
+	// a different token doesn't match the persisted data
+	transformTestConfig(t, true, nodeData("other", ""))
+
+	// persisting the data contradicts backing it with tmpfs
+	transformTestConfig(t, true, nodeData("", "1g"))
+}
+
+func TestTransformSimpleConfigKubeProxyMode(t *testing.T) {
+	tests := []struct {
+		name              string
+		mode              string
+		extraArgs         []conf.K3sArgWithNodeFilters
+		checkProfiles     []string
+		wantServerArgs    []string
+		wantAgentArgs     []string
+		wantCheckProfiles []string
+		wantErr           bool
+	}{
+		{name: "default"},
+		{name: "iptables", mode: "iptables"},
+		{
+			name:              "ipvs",
+			mode:              "ipvs",
+			wantServerArgs:    []string{"--kube-proxy-arg=proxy-mode=ipvs"},
+			wantAgentArgs:     []string{"--kube-proxy-arg=proxy-mode=ipvs"},
+			wantCheckProfiles: []string{"ipvs"},
+		},
+		{
+			name:              "none",
+			mode:              "none",
+			checkProfiles:     []string{"cilium"},
+			wantServerArgs:    []string{"--disable-kube-proxy"},
+			wantCheckProfiles: []string{"cilium"},
+		},
+		{name: "invalid", mode: "nftables", wantErr: true},
+		{
+			name:      "conflicting k3s arg",
+			mode:      "ipvs",
+			extraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--disable-kube-proxy", NodeFilters: []string{"server:*"}}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Agents = 1
+				simpleCfg.Options.K3sOptions.KubeProxyMode = tt.mode
+				simpleCfg.Options.K3sOptions.ExtraArgs = tt.extraArgs
+				simpleCfg.Options.K3dOptions.CheckProfiles = tt.checkProfiles
+			})
+			if clusterCfg == nil {
+				return
+			}
+			for _, node := range clusterCfg.Cluster.Nodes {
+				switch node.Role {
+				case k3d.ServerRole:
+					if !reflect.DeepEqual(node.Args, tt.wantServerArgs) {
+						t.Errorf("expected server args %v, got %v", tt.wantServerArgs, node.Args)
+					}
+				case k3d.AgentRole:
+					if !reflect.DeepEqual(node.Args, tt.wantAgentArgs) {
+						t.Errorf("expected agent args %v, got %v", tt.wantAgentArgs, node.Args)
+					}
+				}
+			}
+			if !reflect.DeepEqual(clusterCfg.ClusterCreateOpts.CheckProfiles, tt.wantCheckProfiles) {
+				t.Errorf("expected check profiles %v, got %v", tt.wantCheckProfiles, clusterCfg.ClusterCreateOpts.CheckProfiles)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigNetworkPolicyTest(t *testing.T) {
+	tests := []struct {
+		name      string
+		extraArgs []conf.K3sArgWithNodeFilters
+		wantErr   bool
+	}{
+		{name: "enabled"},
+		{
+			name:      "network policy controller disabled",
+			extraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--disable-network-policy", NodeFilters: []string{"server:*"}}},
+			wantErr:   true,
+		},
+		{
+			name:      "flannel disabled",
+			extraArgs: []conf.K3sArgWithNodeFilters{{Arg: "--flannel-backend=none", NodeFilters: []string{"server:*"}}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Options.K3dOptions.NetworkPolicyTest = true
+				simpleCfg.Options.K3sOptions.ExtraArgs = tt.extraArgs
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if !clusterCfg.ClusterCreateOpts.WaitForServer {
+				t.Errorf("expected the network policy test suite to enable wait-for-server")
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigCoreDNSCustom(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "coredns-custom.yaml")
+	if err := os.WriteFile(file, []byte("stubDomains:\n  corp.example.com: [10.0.0.53]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    *k3d.CoreDNSCustom
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "file", input: file, want: &k3d.CoreDNSCustom{StubDomains: map[string][]string{"corp.example.com": {"10.0.0.53"}}}},
+		{name: "embedded", input: "rewrites:\n  - name a.example.com b.default.svc.cluster.local\n", want: &k3d.CoreDNSCustom{Rewrites: []string{"name a.example.com b.default.svc.cluster.local"}}},
+		{name: "missing file", input: filepath.Join(t.TempDir(), "missing.yaml"), wantErr: true},
+		{name: "invalid", input: "hosts:\n  - git.internal\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Options.K3sOptions.CoreDNSCustom = tt.input
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if !reflect.DeepEqual(clusterCfg.ClusterCreateOpts.CoreDNSCustom, tt.want) {
+				t.Errorf("expected custom CoreDNS config %+v, got %+v", tt.want, clusterCfg.ClusterCreateOpts.CoreDNSCustom)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigKubelet(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubelet    []conf.KubeletConfigWithNodeFilters
+		serverArgs []string
+		agentArgs  []string
+		wantErr    bool
+	}{
+		{
+			name: "per role",
+			kubelet: []conf.KubeletConfigWithNodeFilters{
+				{KubeletConfig: k3d.KubeletConfig{MaxPods: 250}, NodeFilters: []string{"agent:*"}},
+				{KubeletConfig: k3d.KubeletConfig{Args: []string{"image-gc-high-threshold=70"}}, NodeFilters: []string{"server:*", "agent:*"}},
+			},
+			serverArgs: []string{"--kubelet-arg=image-gc-high-threshold=70"},
+			agentArgs:  []string{"--kubelet-arg=max-pods=250", "--kubelet-arg=image-gc-high-threshold=70"},
+		},
+		{name: "missing node filter", kubelet: []conf.KubeletConfigWithNodeFilters{{KubeletConfig: k3d.KubeletConfig{MaxPods: 250}}}, wantErr: true},
+		{name: "invalid config", kubelet: []conf.KubeletConfigWithNodeFilters{{KubeletConfig: k3d.KubeletConfig{MaxPods: -1}, NodeFilters: []string{"all"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Agents = 2
+				simpleCfg.Options.K3sOptions.Kubelet = tt.kubelet
+			})
+			if clusterCfg == nil {
+				return
+			}
+			for _, node := range clusterCfg.Cluster.Nodes {
+				expected := tt.agentArgs
+				if node.Role == k3d.ServerRole {
+					expected = tt.serverArgs
+				} else if node.Role != k3d.AgentRole {
+					continue
+				}
+				kubeletArgs := []string{}
+				for _, arg := range node.Args {
+					if strings.HasPrefix(arg, "--kubelet-arg=") {
+						kubeletArgs = append(kubeletArgs, arg)
+					}
+				}
+				if !reflect.DeepEqual(kubeletArgs, expected) {
+					t.Errorf("expected kubelet args %v for node %s, got %v", expected, node.Name, kubeletArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigAirgap(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "k3s-airgap-images.tar")
+	if err := os.WriteFile(archive, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	airgap := func(image string) func(*conf.SimpleConfig) {
+		return func(simpleCfg *conf.SimpleConfig) {
+			simpleCfg.Agents = 1
+			simpleCfg.Image = image
+			simpleCfg.Options.K3dOptions.NoPull = true
+			simpleCfg.Options.K3sOptions.ImagesArchives = []string{archive}
+		}
+	}
+
+	clusterCfg := transformTestConfig(t, false, airgap("rancher/k3s:latest-test"))
+	if !clusterCfg.ClusterCreateOpts.NoPull {
+		t.Errorf("expected no-pull to be set in the cluster create opts")
+	}
+	volume := archive + ":/var/lib/rancher/k3s/agent/images/k3s-airgap-images.tar:ro"
+	for _, node := range clusterCfg.Cluster.Nodes {
+		mounted := false
+		for _, v := range node.Volumes {
+			mounted = mounted || v == volume
+		}
+		if isK3sNode := node.Role == k3d.ServerRole || node.Role == k3d.AgentRole; mounted != isK3sNode {
+			t.Errorf("expected the images archive to be mounted only into server and agent nodes, but node %s (%s) has volumes %v", node.Name, node.Role, node.Volumes)
+		}
+	}
+
+	// a release channel can't be resolved without pulling images
+	transformTestConfig(t, true, airgap("rancher/k3s:+stable"))
+}
+
+func TestTransformSimpleConfigRollback(t *testing.T) {
+	tests := []struct {
+		name       string
+		rollback   string
+		noRollback bool
+		expected   k3d.RollbackPolicy
+		wantErr    bool
+	}{
+		{name: "default", expected: k3d.RollbackPolicyAuto},
+		{name: "always", rollback: "always", expected: k3d.RollbackPolicyAlways},
+		{name: "never", rollback: "never", expected: k3d.RollbackPolicyNever},
+		{name: "disabled", noRollback: true, expected: k3d.RollbackPolicyNever},
+		{name: "disabled with default", rollback: "auto", noRollback: true, expected: k3d.RollbackPolicyNever},
+		{name: "disabled but always", rollback: "always", noRollback: true, wantErr: true},
+		{name: "invalid", rollback: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Options.K3dOptions.Rollback = tt.rollback
+				simpleCfg.Options.K3dOptions.NoRollback = tt.noRollback
+			})
+			if clusterCfg != nil && clusterCfg.ClusterCreateOpts.Rollback != tt.expected {
+				t.Errorf("expected rollback policy %s, got %s", tt.expected, clusterCfg.ClusterCreateOpts.Rollback)
+			}
+		})
+	}
+}
+
+func TestTransformSimpleConfigTLSSANs(t *testing.T) {
+	tests := []struct {
+		name         string
+		host         string
+		sans         []string
+		expectedHost string
+		expectedArgs []string
+		wantErr      bool
+	}{
+		{
+			name:         "first SAN as API host",
+			sans:         []string{"*.example.com", "k3d.example.com", "192.168.178.55"},
+			expectedHost: "k3d.example.com",
+			expectedArgs: []string{"--tls-san=*.example.com", "--tls-san=192.168.178.55"},
+		},
+		{
+			name:         "explicit API host",
+			host:         "buildbox.lan",
+			sans:         []string{"k3d.example.com"},
+			expectedHost: "buildbox.lan",
+			expectedArgs: []string{"--tls-san=k3d.example.com"},
+		},
+		{
+			name:    "invalid SAN",
+			sans:    []string{"not a host"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterCfg := transformTestConfig(t, tt.wantErr, func(simpleCfg *conf.SimpleConfig) {
+				simpleCfg.Servers = 2
+				simpleCfg.Agents = 1
+				simpleCfg.ExposeAPI.Host = tt.host
+				simpleCfg.ExposeAPI.TLSSANs = tt.sans
+			})
+			if clusterCfg == nil {
+				return
+			}
+			if clusterCfg.Cluster.KubeAPI.Host != tt.expectedHost {
+				t.Errorf("expected API host %s, got %s", tt.expectedHost, clusterCfg.Cluster.KubeAPI.Host)
+			}
+			for _, node := range clusterCfg.Cluster.Nodes {
+				var sanArgs []string
+				for _, arg := range node.Args {
+					if strings.HasPrefix(arg, "--tls-san") {
+						sanArgs = append(sanArgs, arg)
+					}
+				}
+				switch node.Role {
+				case k3d.ServerRole:
+					if !reflect.DeepEqual(sanArgs, tt.expectedArgs) {
+						t.Errorf("expected TLS SAN args %v for node %s, got %v", tt.expectedArgs, node.Name, sanArgs)
+					}
+				default:
+					if len(sanArgs) > 0 {
+						t.Errorf("expected no TLS SAN args for node %s (%s), got %v", node.Name, node.Role, sanArgs)
+					}
+				}
+			}
+		})
+	}
+}
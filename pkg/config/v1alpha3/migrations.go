@@ -110,7 +110,7 @@ func MigrateV1Alpha2(input configtypes.Config) (configtypes.Config, error) {
 
 		if input.(v1alpha2.SimpleConfig).Registries.Create {
 			cfg.Registries.Create = &SimpleConfigRegistryCreateConfig{
-				Name:     fmt.Sprintf("%s-%s-registry", k3d.DefaultObjectNamePrefix, cfg.Name),
+				Name:     fmt.Sprintf("%s-%s-registry", k3d.ObjectNamePrefix, cfg.Name),
 				Host:     "0.0.0.0",
 				HostPort: "random",
 			}
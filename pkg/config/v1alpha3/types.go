@@ -71,16 +71,51 @@ type LabelWithNodeFilters struct {
 	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
 }
 
+type OptWithNodeFilters struct {
+	Opt         string   `mapstructure:"opt" yaml:"opt,omitempty" json:"opt,omitempty"`
+	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
+}
+
+type MemoryWithNodeFilters struct {
+	Memory      string   `mapstructure:"memory" yaml:"memory,omitempty" json:"memory,omitempty"`
+	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
+}
+
+type CPUsWithNodeFilters struct {
+	CPUs        string   `mapstructure:"cpus" yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
+}
+
 type EnvVarWithNodeFilters struct {
 	EnvVar      string   `mapstructure:"envVar" yaml:"envVar,omitempty" json:"envVar,omitempty"`
 	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
 }
 
+// SimpleConfigHook is an action run on the selected nodes at a stage of the cluster creation (exactly one of exec, writeFile and applyManifest)
+type SimpleConfigHook struct {
+	Stage         string                     `mapstructure:"stage" yaml:"stage,omitempty" json:"stage,omitempty"` // postNodeStart or postClusterReady
+	NodeFilters   []string                   `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
+	Exec          []string                   `mapstructure:"exec" yaml:"exec,omitempty" json:"exec,omitempty"`
+	WriteFile     *SimpleConfigHookWriteFile `mapstructure:"writeFile" yaml:"writeFile,omitempty" json:"writeFile,omitempty"`
+	ApplyManifest string                     `mapstructure:"applyManifest" yaml:"applyManifest,omitempty" json:"applyManifest,omitempty"` // path to the manifest on the host
+}
+
+type SimpleConfigHookWriteFile struct {
+	Path    string `mapstructure:"path" yaml:"path,omitempty" json:"path,omitempty"`
+	Content string `mapstructure:"content" yaml:"content,omitempty" json:"content,omitempty"`
+	Mode    string `mapstructure:"mode" yaml:"mode,omitempty" json:"mode,omitempty"` // octal, default: 0644
+}
+
 type K3sArgWithNodeFilters struct {
 	Arg         string   `mapstructure:"arg" yaml:"arg,omitempty" json:"arg,omitempty"`
 	NodeFilters []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
 }
 
+type KubeletConfigWithNodeFilters struct {
+	k3d.KubeletConfig `mapstructure:",squash" yaml:",inline"`
+	NodeFilters       []string `mapstructure:"nodeFilters" yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"`
+}
+
 type SimpleConfigRegistryCreateConfig struct {
 	Name     string `mapstructure:"name" yaml:"name,omitempty" json:"name,omitempty"`
 	Host     string `mapstructure:"host" yaml:"host,omitempty" json:"host,omitempty"`
@@ -89,8 +124,11 @@ type SimpleConfigRegistryCreateConfig struct {
 
 // SimpleConfigOptionsKubeconfig describes the set of options referring to the kubeconfig during cluster creation.
 type SimpleConfigOptionsKubeconfig struct {
-	UpdateDefaultKubeconfig bool `mapstructure:"updateDefaultKubeconfig" yaml:"updateDefaultKubeconfig" json:"updateDefaultKubeconfig,omitempty"` // default: true
-	SwitchCurrentContext    bool `mapstructure:"switchCurrentContext" yaml:"switchCurrentContext" json:"switchCurrentContext,omitempty"`          //nolint:lll    // default: true
+	UpdateDefaultKubeconfig bool                     `mapstructure:"updateDefaultKubeconfig" yaml:"updateDefaultKubeconfig" json:"updateDefaultKubeconfig,omitempty"` // default: true
+	SwitchCurrentContext    bool                     `mapstructure:"switchCurrentContext" yaml:"switchCurrentContext" json:"switchCurrentContext,omitempty"`          //nolint:lll    // default: true
+	Output                  string                   `mapstructure:"output" yaml:"output,omitempty" json:"output,omitempty"`                                          // additional kubeconfig file to write/merge the new cluster's kubeconfig into
+	Encryption              k3d.KubeconfigEncryption `mapstructure:"encryption" yaml:"encryption,omitempty" json:"encryption,omitempty"`                              // store client credentials encrypted instead of in the kubeconfig
+	Role                    k3d.KubeconfigRole       `mapstructure:"role" yaml:"role,omitempty" json:"role,omitempty"`                                                // admin (default), edit or view: non-admin roles use a scoped ServiceAccount
 }
 
 type SimpleConfigOptions struct {
@@ -103,33 +141,87 @@ type SimpleConfigOptions struct {
 type SimpleConfigOptionsRuntime struct {
 	GPURequest    string                 `mapstructure:"gpuRequest" yaml:"gpuRequest,omitempty" json:"gpuRequest,omitempty"`
 	ServersMemory string                 `mapstructure:"serversMemory" yaml:"serversMemory,omitempty" json:"serversMemory,omitempty"`
-	AgentsMemory  string                 `mapstructure:"agentsMemory" yaml:"agentsMemory,omitempty" json:"agentsMemory,omitempty"`
-	Labels        []LabelWithNodeFilters `mapstructure:"labels" yaml:"labels,omitempty" json:"labels,omitempty"`
+	AgentsMemory       string                 `mapstructure:"agentsMemory" yaml:"agentsMemory,omitempty" json:"agentsMemory,omitempty"`
+	ClusterCPULimit    string                 `mapstructure:"clusterCpuLimit" yaml:"clusterCpuLimit,omitempty" json:"clusterCpuLimit,omitempty"`
+	ClusterMemoryLimit string                 `mapstructure:"clusterMemoryLimit" yaml:"clusterMemoryLimit,omitempty" json:"clusterMemoryLimit,omitempty"`
+	NodeTmpfsRoot      string                 `mapstructure:"nodeTmpfsRoot" yaml:"nodeTmpfsRoot,omitempty" json:"nodeTmpfsRoot,omitempty"`
+	ServersData        string                 `mapstructure:"serversData" yaml:"serversData,omitempty" json:"serversData,omitempty"` // host directory, gets one subdirectory per node
+	AgentsData         string                 `mapstructure:"agentsData" yaml:"agentsData,omitempty" json:"agentsData,omitempty"`   // host directory, gets one subdirectory per node
+	Timezone           string                 `mapstructure:"timezone" yaml:"timezone,omitempty" json:"timezone,omitempty"`         // IANA timezone name or "host"
+	HTTPProxy          bool                   `mapstructure:"httpProxy" yaml:"httpProxy,omitempty" json:"httpProxy,omitempty"`
+	Memory             []MemoryWithNodeFilters `mapstructure:"memory" yaml:"memory,omitempty" json:"memory,omitempty"`
+	CPUs               []CPUsWithNodeFilters   `mapstructure:"cpus" yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	Labels             []LabelWithNodeFilters `mapstructure:"labels" yaml:"labels,omitempty" json:"labels,omitempty"`
+	Opts               []OptWithNodeFilters   `mapstructure:"opts" yaml:"opts,omitempty" json:"opts,omitempty"`
 }
 
 type SimpleConfigOptionsK3d struct {
 	Wait                bool                               `mapstructure:"wait" yaml:"wait" json:"wait"`
+	NoWaitForAgents     bool                               `mapstructure:"noWaitForAgents" yaml:"noWaitForAgents,omitempty" json:"noWaitForAgents,omitempty"`
+	ReadyLogMessages    map[string]string                  `mapstructure:"readyLogMessages" yaml:"readyLogMessages,omitempty" json:"readyLogMessages,omitempty"`
 	Timeout             time.Duration                      `mapstructure:"timeout" yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	NodeStartupTimeout  time.Duration                      `mapstructure:"nodeStartupTimeout" yaml:"nodeStartupTimeout,omitempty" json:"nodeStartupTimeout,omitempty"`
 	DisableLoadbalancer bool                               `mapstructure:"disableLoadbalancer" yaml:"disableLoadbalancer" json:"disableLoadbalancer"`
 	DisableImageVolume  bool                               `mapstructure:"disableImageVolume" yaml:"disableImageVolume" json:"disableImageVolume"`
+	ImageVolume         string                             `mapstructure:"imageVolume" yaml:"imageVolume,omitempty" json:"imageVolume,omitempty"`
+	KeepImageVolume     bool                               `mapstructure:"keepImageVolume" yaml:"keepImageVolume,omitempty" json:"keepImageVolume,omitempty"`
 	NoRollback          bool                               `mapstructure:"disableRollback" yaml:"disableRollback" json:"disableRollback"`
+	Rollback            string                             `mapstructure:"rollback" yaml:"rollback,omitempty" json:"rollback,omitempty"`
+	OnNodeFailure       string                             `mapstructure:"onNodeFailure" yaml:"onNodeFailure,omitempty" json:"onNodeFailure,omitempty"`
 	NodeHookActions     []k3d.NodeHookAction               `mapstructure:"nodeHookActions" yaml:"nodeHookActions,omitempty" json:"nodeHookActions,omitempty"`
 	Loadbalancer        SimpleConfigOptionsK3dLoadbalancer `mapstructure:"loadbalancer" yaml:"loadbalancer,omitempty" json:"loadbalancer,omitempty"`
+	Startup             SimpleConfigOptionsK3dStartup      `mapstructure:"startup" yaml:"startup,omitempty" json:"startup,omitempty"`
+	HibernationSchedule string                             `mapstructure:"hibernationSchedule" yaml:"hibernationSchedule,omitempty" json:"hibernationSchedule,omitempty"`
+	CheckProfiles       []string                           `mapstructure:"checkProfiles" yaml:"checkProfiles,omitempty" json:"checkProfiles,omitempty"`
+	DefaultBindAddress  string                             `mapstructure:"defaultBindAddress" yaml:"defaultBindAddress,omitempty" json:"defaultBindAddress,omitempty"`
+	NetworkPolicyTest   bool                               `mapstructure:"networkPolicyTest" yaml:"networkPolicyTest,omitempty" json:"networkPolicyTest,omitempty"`
+	NoPull              bool                               `mapstructure:"noPull" yaml:"noPull,omitempty" json:"noPull,omitempty"`
+	ExternalID          string                             `mapstructure:"externalID" yaml:"externalID,omitempty" json:"externalID,omitempty"`
+	FixInotify          bool                               `mapstructure:"fixInotify" yaml:"fixInotify,omitempty" json:"fixInotify,omitempty"`
+	StrictPreflight     bool                               `mapstructure:"strictPreflight" yaml:"strictPreflight,omitempty" json:"strictPreflight,omitempty"`
 }
 
 type SimpleConfigOptionsK3dLoadbalancer struct {
-	ConfigOverrides []string `mapstructure:"configOverrides" yaml:"configOverrides,omitempty" json:"configOverrides,omitempty"`
+	ConfigOverrides []string                                    `mapstructure:"configOverrides" yaml:"configOverrides,omitempty" json:"configOverrides,omitempty"`
+	APIAccess       SimpleConfigOptionsK3dLoadbalancerAPIAccess `mapstructure:"apiAccess" yaml:"apiAccess,omitempty" json:"apiAccess,omitempty"`
+}
+
+type SimpleConfigOptionsK3dLoadbalancerAPIAccess struct {
+	AllowedSources     []string `mapstructure:"allowedSources" yaml:"allowedSources,omitempty" json:"allowedSources,omitempty"`
+	ClientCertificates bool     `mapstructure:"clientCertificates" yaml:"clientCertificates,omitempty" json:"clientCertificates,omitempty"`
+}
+
+type SimpleConfigOptionsK3dStartup struct {
+	Dependencies map[string][]string                  `mapstructure:"dependencies" yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+	Checks       []SimpleConfigOptionsK3dStartupCheck `mapstructure:"checks" yaml:"checks,omitempty" json:"checks,omitempty"`
+}
+
+type SimpleConfigOptionsK3dStartupCheck struct {
+	Name    string        `mapstructure:"name" yaml:"name" json:"name"`
+	TCP     string        `mapstructure:"tcp" yaml:"tcp,omitempty" json:"tcp,omitempty"`
+	HTTP    string        `mapstructure:"http" yaml:"http,omitempty" json:"http,omitempty"`
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty" json:"timeout,omitempty"`
 }
 
 type SimpleConfigOptionsK3s struct {
-	ExtraArgs  []K3sArgWithNodeFilters `mapstructure:"extraArgs" yaml:"extraArgs,omitempty" json:"extraArgs,omitempty"`
-	NodeLabels []LabelWithNodeFilters  `mapstructure:"nodeLabels" yaml:"nodeLabels,omitempty" json:"nodeLabels,omitempty"`
+	ExtraArgs      []K3sArgWithNodeFilters        `mapstructure:"extraArgs" yaml:"extraArgs,omitempty" json:"extraArgs,omitempty"`
+	Kubelet        []KubeletConfigWithNodeFilters `mapstructure:"kubelet" yaml:"kubelet,omitempty" json:"kubelet,omitempty"`
+	NodeLabels     []LabelWithNodeFilters         `mapstructure:"nodeLabels" yaml:"nodeLabels,omitempty" json:"nodeLabels,omitempty"`
+	Manifests      []string                       `mapstructure:"manifests" yaml:"manifests,omitempty" json:"manifests,omitempty"`                // files, directories or URLs
+	ImagesArchives []string                       `mapstructure:"imagesArchives" yaml:"imagesArchives,omitempty" json:"imagesArchives,omitempty"` // image archives imported by k3s before it starts (e.g. k3s-airgap-images.tar)
+	ClusterDomain  string                         `mapstructure:"clusterDomain" yaml:"clusterDomain,omitempty" json:"clusterDomain,omitempty"`
+	KubeProxyMode  string                         `mapstructure:"kubeProxyMode" yaml:"kubeProxyMode,omitempty" json:"kubeProxyMode,omitempty"`
+	ClusterCIDR    string                         `mapstructure:"clusterCIDR" yaml:"clusterCIDR,omitempty" json:"clusterCIDR,omitempty"`       // comma-separated for dual-stack
+	ServiceCIDR    string                         `mapstructure:"serviceCIDR" yaml:"serviceCIDR,omitempty" json:"serviceCIDR,omitempty"`       // comma-separated for dual-stack
+	CoreDNSCustom  string                         `mapstructure:"corednsCustom" yaml:"corednsCustom,omitempty" json:"corednsCustom,omitempty"` // file or embedded (multiline) custom CoreDNS config
 }
 
 type SimpleConfigRegistries struct {
-	Use    []string                          `mapstructure:"use" yaml:"use,omitempty" json:"use,omitempty"`
-	Create *SimpleConfigRegistryCreateConfig `mapstructure:"create" yaml:"create,omitempty" json:"create,omitempty"`
-	Config string                            `mapstructure:"config" yaml:"config,omitempty" json:"config,omitempty"` // registries.yaml (k3s config for containerd registry override)
+	Use      []string                          `mapstructure:"use" yaml:"use,omitempty" json:"use,omitempty"`
+	Create   *SimpleConfigRegistryCreateConfig `mapstructure:"create" yaml:"create,omitempty" json:"create,omitempty"`
+	Config   string                            `mapstructure:"config" yaml:"config,omitempty" json:"config,omitempty"`       // registries.yaml (k3s config for containerd registry override)
+	Mirrors  []string                          `mapstructure:"mirrors" yaml:"mirrors,omitempty" json:"mirrors,omitempty"`    // registry mirrors ([REGISTRY=]ENDPOINT[,ENDPOINT...]) added to the registries.yaml
+	Rewrites []string                          `mapstructure:"rewrites" yaml:"rewrites,omitempty" json:"rewrites,omitempty"` // registry rewrite rules (REGISTRY -> MIRROR[/PATH]) added to the registries.yaml
 }
 
 type SimpleConfigRegistriesIntermediateV1alpha2 struct {
@@ -148,12 +240,15 @@ type SimpleConfig struct {
 	Image           string                  `mapstructure:"image" yaml:"image,omitempty" json:"image,omitempty"`
 	Network         string                  `mapstructure:"network" yaml:"network,omitempty" json:"network,omitempty"`
 	Subnet          string                  `mapstructure:"subnet" yaml:"subnet,omitempty" json:"subnet,omitempty"`
+	Gateway         string                  `mapstructure:"gateway" yaml:"gateway,omitempty" json:"gateway,omitempty"`
+	IPv6            string                  `mapstructure:"ipv6" yaml:"ipv6,omitempty" json:"ipv6,omitempty"` // "auto" or an IPv6 subnet
 	ClusterToken    string                  `mapstructure:"token" yaml:"clusterToken,omitempty" json:"clusterToken,omitempty"` // default: auto-generated
 	Volumes         []VolumeWithNodeFilters `mapstructure:"volumes" yaml:"volumes,omitempty" json:"volumes,omitempty"`
 	Ports           []PortWithNodeFilters   `mapstructure:"ports" yaml:"ports,omitempty" json:"ports,omitempty"`
 	Options         SimpleConfigOptions     `mapstructure:"options" yaml:"options,omitempty" json:"options,omitempty"`
 	Env             []EnvVarWithNodeFilters `mapstructure:"env" yaml:"env,omitempty" json:"env,omitempty"`
 	Registries      SimpleConfigRegistries  `mapstructure:"registries" yaml:"registries,omitempty" json:"registries,omitempty"`
+	Hooks           []SimpleConfigHook      `mapstructure:"hooks" yaml:"hooks,omitempty" json:"hooks,omitempty"`
 }
 
 type SimpleConfigIntermediateV1alpha2 struct {
@@ -175,9 +270,10 @@ type SimpleConfigIntermediateV1alpha2 struct {
 
 // SimpleExposureOpts provides a simplified syntax compared to the original k3d.ExposureOpts
 type SimpleExposureOpts struct {
-	Host     string `mapstructure:"host" yaml:"host,omitempty" json:"host,omitempty"`
-	HostIP   string `mapstructure:"hostIP" yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
-	HostPort string `mapstructure:"hostPort" yaml:"hostPort,omitempty" json:"hostPort,omitempty"`
+	Host     string   `mapstructure:"host" yaml:"host,omitempty" json:"host,omitempty"`
+	HostIP   string   `mapstructure:"hostIP" yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
+	HostPort string   `mapstructure:"hostPort" yaml:"hostPort,omitempty" json:"hostPort,omitempty"`
+	TLSSANs  []string `mapstructure:"tlsSANs" yaml:"tlsSANs,omitempty" json:"tlsSANs,omitempty"` // additional names and IPs for the API certificate
 }
 
 // GetKind implements Config.GetKind
@@ -28,6 +28,7 @@ import (
 
 	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
 	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/spf13/viper"
 )
 
@@ -47,3 +48,27 @@ func TestValidateClusterConfig(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestValidateK3sArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		role    k3d.Role
+		args    []string
+		wantErr bool
+	}{
+		{"valid server args", "rancher/k3s:v1.21.7-k3s1", k3d.ServerRole, []string{"--disable=traefik", "--tls-san", "my.host", "--kubelet-arg=eviction-hard=imagefs.available<1%"}, false},
+		{"valid agent args", "docker.io/rancher/k3s:v1.21.7-k3s1", k3d.AgentRole, []string{"--node-label=foo=bar", "-v=2"}, false},
+		{"typo", "rancher/k3s:v1.21.7-k3s1", k3d.ServerRole, []string{"--disabel=traefik"}, true},
+		{"server flag on agent", "rancher/k3s:v1.21.7-k3s1", k3d.AgentRole, []string{"--disable=traefik"}, true},
+		{"newer version only warns", "rancher/k3s:v1.99.0-k3s1", k3d.ServerRole, []string{"--some-new-flag"}, false},
+		{"unparseable tag only warns", "myregistry:5000/k3s:latest", k3d.ServerRole, []string{"--some-new-flag"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateK3sArgs(tt.image, tt.role, tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateK3sArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
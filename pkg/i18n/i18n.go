@@ -0,0 +1,146 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	l "github.com/rancher/k3d/v5/pkg/logger"
+)
+
+/*
+ * Message catalog for user-facing strings (help texts, common errors) - not for log messages.
+ * Catalogs are flat YAML maps of message keys to (fmt-style) messages, one file per language.
+ * The English catalog is the source of truth: every key has to exist there.
+ * Additional/overriding catalogs can be placed in $K3D_LOCALE_DIR/<lang>.yaml, e.g. by downstream distributions.
+ */
+
+// DefaultLanguage is the language of the built-in source strings and the fallback for missing translations
+const DefaultLanguage = "en"
+
+// EnvLocaleDir is the environment variable pointing to a directory with additional catalogs
+const EnvLocaleDir = "K3D_LOCALE_DIR"
+
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+var (
+	once     sync.Once
+	language string
+	catalogs map[string]map[string]string
+)
+
+// T returns the localized message for the given key, formatted with the given arguments.
+// It falls back to the English message and finally to the key itself.
+func T(key string, args ...interface{}) string {
+	once.Do(initCatalogs)
+	msg, ok := catalogs[language][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		l.Log().Debugf("i18n: no message for key '%s'", key)
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Language returns the language used for messages
+func Language() string {
+	once.Do(initCatalogs)
+	return language
+}
+
+func initCatalogs() {
+	catalogs = map[string]map[string]string{}
+
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		l.Log().Debugf("i18n: failed to read embedded catalogs: %v", err)
+	}
+	for _, entry := range entries {
+		content, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			l.Log().Debugf("i18n: failed to read embedded catalog '%s': %v", entry.Name(), err)
+			continue
+		}
+		loadCatalog(strings.TrimSuffix(entry.Name(), ".yaml"), content)
+	}
+
+	language = DetectLanguage()
+
+	if dir := os.Getenv(EnvLocaleDir); dir != "" {
+		content, err := os.ReadFile(filepath.Join(dir, language+".yaml"))
+		if err != nil {
+			l.Log().Debugf("i18n: no additional catalog for language '%s' in %s: %v", language, dir, err)
+		} else {
+			loadCatalog(language, content)
+		}
+	}
+}
+
+func loadCatalog(lang string, content []byte) {
+	messages := map[string]string{}
+	if err := yaml.Unmarshal(content, &messages); err != nil {
+		l.Log().Debugf("i18n: failed to parse catalog for language '%s': %v", lang, err)
+		return
+	}
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for key, msg := range messages {
+		catalogs[lang][key] = msg
+	}
+}
+
+// DetectLanguage determines the language from the environment (K3D_LANG, LC_ALL, LC_MESSAGES, LANG),
+// e.g. "de_DE.UTF-8" -> "de". It defaults to English.
+func DetectLanguage() string {
+	for _, env := range []string{"K3D_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := parseLocale(os.Getenv(env)); lang != "" {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// parseLocale extracts the language code from a POSIX locale string (language[_territory][.codeset][@modifier])
+func parseLocale(locale string) string {
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	if i := strings.IndexAny(locale, "_.@-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
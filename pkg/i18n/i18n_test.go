@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := map[string]string{
+		"de_DE.UTF-8":   "de",
+		"en_US":         "en",
+		"fr":            "fr",
+		"pt-BR":         "pt",
+		"sr_RS@latin":   "sr",
+		"C":             "",
+		"POSIX":         "",
+		"":              "",
+		"ZH_CN.GB18030": "zh",
+	}
+	for locale, expected := range tests {
+		if actual := parseLocale(locale); actual != expected {
+			t.Errorf("parseLocale(%q): expected %q, got %q", locale, expected, actual)
+		}
+	}
+}
+
+var formatVerbRegexp = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+// TestCatalogs ensures that all translations refer to existing messages and use the same format verbs as the source message
+func TestCatalogs(t *testing.T) {
+	once.Do(initCatalogs)
+
+	source, ok := catalogs[DefaultLanguage]
+	if !ok || len(source) == 0 {
+		t.Fatalf("no messages found for default language '%s'", DefaultLanguage)
+	}
+
+	for lang, messages := range catalogs {
+		for key, msg := range messages {
+			sourceMsg, ok := source[key]
+			if !ok {
+				t.Errorf("[%s] message '%s' does not exist in the default language", lang, key)
+				continue
+			}
+			expected := formatVerbRegexp.FindAllString(sourceMsg, -1)
+			actual := formatVerbRegexp.FindAllString(msg, -1)
+			if len(expected) != len(actual) {
+				t.Errorf("[%s] message '%s' has format verbs %v, but the source message has %v", lang, key, actual, expected)
+			}
+		}
+	}
+}
+
+func TestTFallback(t *testing.T) {
+	once.Do(initCatalogs)
+
+	if msg := T("does.not.exist"); msg != "does.not.exist" {
+		t.Errorf("expected unknown key to be returned as is, got '%s'", msg)
+	}
+	if msg := T("cmd.cluster.short"); msg == "cmd.cluster.short" || msg == "" {
+		t.Errorf("expected a message for key 'cmd.cluster.short', got '%s'", msg)
+	}
+}
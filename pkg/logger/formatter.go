@@ -0,0 +1,45 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package logger
+
+import (
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// NoColorFormatter wraps another formatter and strips all color codes from its output.
+// This keeps the output format of a colored formatter (e.g. `INFO[0000] message` of the logrus TextFormatter), but without the colors.
+type NoColorFormatter struct {
+	Formatter logrus.Formatter
+}
+
+// Format implements logrus.Formatter
+func (f *NoColorFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return ansiEscapeRegexp.ReplaceAll(b, nil), nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Operation is a long-running k3d operation that reports progress
+type Operation string
+
+const (
+	OperationClusterCreate Operation = "cluster-create"
+	OperationImageImport   Operation = "image-import"
+)
+
+// PhaseDone and PhaseFailed are the final phases of every operation
+const (
+	PhaseDone   = "done"
+	PhaseFailed = "failed"
+)
+
+// Milestones of the cluster creation, reported as the Event of a progress update
+const (
+	EventNetworkCreated         = "network-created" // also reported if an existing network is re-used
+	EventNodeCreated            = "node-created"
+	EventNodeReady              = "node-ready"
+	EventLoadBalancerConfigured = "loadbalancer-configured"
+	EventKubeconfigWritten      = "kubeconfig-written"
+)
+
+// Event is a single progress update, written as one line of JSON (NDJSON)
+type Event struct {
+	Time      time.Time `json:"time"`
+	Operation Operation `json:"operation"`
+	Phase     string    `json:"phase"`
+	Percent   int       `json:"percent"`
+	Event     string    `json:"event,omitempty"` // milestone reached with this update, if any
+	Node      string    `json:"node,omitempty"`  // node that the milestone refers to, if any
+	Message   string    `json:"message,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+var (
+	mutex       sync.Mutex
+	output      io.Writer
+	subscribers = map[int]chan<- Event{}
+	nextID      int
+	percent     = map[Operation]int{}
+)
+
+// SetOutput enables progress reporting to the given writer (nil disables it)
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	output = w
+}
+
+// Subscribe delivers all progress events to the given channel, until the returned function gets called.
+// Events are sent synchronously, so the channel has to be drained to not block the reporting operation.
+// Once unsubscribed, no more events are sent, so the channel may be closed.
+func Subscribe(ch chan<- Event) (unsubscribe func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	id := nextID
+	nextID++
+	subscribers[id] = ch
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// Enabled returns true if progress events are written or delivered somewhere
+func Enabled() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return output != nil || len(subscribers) > 0
+}
+
+// Report emits a progress event for the given operation.
+// The percentage never goes backwards, so concurrent steps can't make a progress bar jump back.
+func Report(operation Operation, phase string, pct int, message string) {
+	emit(Event{Operation: operation, Phase: phase, Percent: pct, Message: message})
+}
+
+// Milestone emits a progress event for the given operation, which marks that the milestone event was reached (for the node, if not empty)
+func Milestone(operation Operation, phase string, pct int, event string, node string, message string) {
+	emit(Event{Operation: operation, Phase: phase, Percent: pct, Event: event, Node: node, Message: message})
+}
+
+// Fail emits the final event for an operation that failed with the given error
+func Fail(operation Operation, err error) {
+	emit(Event{Operation: operation, Phase: PhaseFailed, Message: "failed", Error: err.Error()})
+}
+
+// Done emits the final event for an operation that succeeded
+func Done(operation Operation, message string) {
+	emit(Event{Operation: operation, Phase: PhaseDone, Percent: 100, Message: message})
+}
+
+// Scale maps step i (0-based) of n steps onto the percentage range [from, to]
+func Scale(from int, to int, i int, n int) int {
+	if n <= 0 {
+		return to
+	}
+	return from + (to-from)*i/n
+}
+
+func emit(event Event) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if output == nil && len(subscribers) == 0 {
+		return
+	}
+
+	if event.Phase == PhaseFailed || event.Phase == PhaseDone {
+		if event.Phase == PhaseFailed {
+			event.Percent = percent[event.Operation]
+		}
+		delete(percent, event.Operation)
+	} else if event.Percent < percent[event.Operation] {
+		event.Percent = percent[event.Operation]
+	} else {
+		percent[event.Operation] = event.Percent
+	}
+
+	event.Time = time.Now()
+	for _, ch := range subscribers {
+		ch <- event
+	}
+	if output == nil {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = output.Write(append(line, '\n'))
+}
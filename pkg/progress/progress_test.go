@@ -0,0 +1,128 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(nil)
+
+	Report(OperationImageImport, "find", 0, "Looking up images")
+	Report(OperationImageImport, "import", 60, "Imported images into node 'a'")
+	Report(OperationImageImport, "import", 50, "Imported images into node 'b'") // concurrent step finishing late
+	Fail(OperationImageImport, fmt.Errorf("boom"))
+	Done(OperationClusterCreate, "Cluster created")
+
+	expected := []Event{
+		{Operation: OperationImageImport, Phase: "find", Percent: 0, Message: "Looking up images"},
+		{Operation: OperationImageImport, Phase: "import", Percent: 60, Message: "Imported images into node 'a'"},
+		{Operation: OperationImageImport, Phase: "import", Percent: 60, Message: "Imported images into node 'b'"},
+		{Operation: OperationImageImport, Phase: PhaseFailed, Percent: 60, Message: "failed", Error: "boom"},
+		{Operation: OperationClusterCreate, Phase: PhaseDone, Percent: 100, Message: "Cluster created"},
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %s", len(expected), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("event %d is not valid JSON: %v", i, err)
+		}
+		if event.Time.IsZero() {
+			t.Errorf("event %d has no timestamp", i)
+		}
+		event.Time = expected[i].Time
+		if event != expected[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected[i], event)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	events := make(chan Event, 10)
+	unsubscribe := Subscribe(events)
+	if !Enabled() {
+		t.Fatal("expected progress reporting to be enabled for subscribers")
+	}
+
+	Report(OperationClusterCreate, "create", 20, "Creating node containers")
+	Milestone(OperationClusterCreate, "create", 30, EventNodeCreated, "k3d-test-server-0", "Created node 'k3d-test-server-0'")
+	Done(OperationClusterCreate, "Cluster created")
+	unsubscribe()
+	Report(OperationClusterCreate, "prepare", 0, "not delivered anymore")
+	close(events)
+
+	expected := []Event{
+		{Operation: OperationClusterCreate, Phase: "create", Percent: 20, Message: "Creating node containers"},
+		{Operation: OperationClusterCreate, Phase: "create", Percent: 30, Event: EventNodeCreated, Node: "k3d-test-server-0", Message: "Created node 'k3d-test-server-0'"},
+		{Operation: OperationClusterCreate, Phase: PhaseDone, Percent: 100, Message: "Cluster created"},
+	}
+	i := 0
+	for event := range events {
+		if i >= len(expected) {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		event.Time = expected[i].Time
+		if event != expected[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected[i], event)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("expected %d events, got %d", len(expected), i)
+	}
+	if Enabled() {
+		t.Error("expected progress reporting to be disabled after unsubscribing")
+	}
+}
+
+func TestReportDisabled(t *testing.T) {
+	SetOutput(nil)
+	if Enabled() {
+		t.Fatal("expected progress reporting to be disabled")
+	}
+	Report(OperationClusterCreate, "prepare", 0, "no output, no panic")
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct{ from, to, i, n, expected int }{
+		{20, 45, 0, 5, 20},
+		{20, 45, 5, 5, 45},
+		{40, 90, 1, 2, 65},
+		{40, 90, 1, 0, 90},
+	}
+	for _, tt := range tests {
+		if actual := Scale(tt.from, tt.to, tt.i, tt.n); actual != tt.expected {
+			t.Errorf("Scale(%d, %d, %d, %d): expected %d, got %d", tt.from, tt.to, tt.i, tt.n, tt.expected, actual)
+		}
+	}
+}
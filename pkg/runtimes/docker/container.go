@@ -27,12 +27,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	l "github.com/rancher/k3d/v5/pkg/logger"
+	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/sirupsen/logrus"
 )
@@ -107,7 +111,9 @@ func removeContainer(ctx context.Context, ID string) error {
 }
 
 // pullImage pulls a container image and outputs progress if --verbose flag is set
-func pullImage(ctx context.Context, docker client.APIClient, image string) error {
+func pullImage(ctx context.Context, docker client.APIClient, image string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.PullImage", tracing.String("k3d.image", image))
+	defer func() { span.End(err) }()
 
 	resp, err := docker.ImagePull(ctx, image, types.ImagePullOptions{})
 	if err != nil {
@@ -150,7 +156,7 @@ func getNodeContainer(ctx context.Context, node *k3d.Node) (*types.Container, er
 	// Assumptions:
 	// -> container names start with a / (see https://github.com/moby/moby/issues/29997)
 	// -> user input may or may not have the "k3d-" prefix
-	filters.Add("name", fmt.Sprintf("^/?(%s-)?%s$", k3d.DefaultObjectNamePrefix, node.Name))
+	filters.Add("name", fmt.Sprintf("^/?(%s-)?%s$", k3d.ObjectNamePrefix, node.Name))
 
 	containers, err := docker.ContainerList(ctx, types.ContainerListOptions{
 		Filters: filters,
@@ -165,7 +171,7 @@ func getNodeContainer(ctx context.Context, node *k3d.Node) (*types.Container, er
 	}
 
 	if len(containers) == 0 {
-		return nil, fmt.Errorf("Didn't find container for node '%s'", node.Name)
+		return nil, fmt.Errorf("Didn't find container for node '%s': %w", node.Name, runtimeErr.ErrRuntimeContainerNotExists)
 	}
 
 	return &containers[0], nil
@@ -233,3 +239,50 @@ func CheckIfDirectoryExists(ctx context.Context, image string, dir string) (bool
 	l.Log().Tracef("check dir container returned %d exit code", exitCode)
 	return exitCode == 0, err
 }
+
+// GetContainersByLabel returns all containers (not only k3d-managed ones) matching the given labels
+func (d Docker) GetContainersByLabel(ctx context.Context, labels map[string]string) ([]*runtimeTypes.Container, error) {
+	docker, err := GetDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	filters := filters.NewArgs()
+	for k, v := range labels {
+		if v == "" {
+			filters.Add("label", k) // any value
+			continue
+		}
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	containers, err := docker.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters,
+		All:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	result := []*runtimeTypes.Container{}
+	for _, c := range containers {
+		container := &runtimeTypes.Container{
+			ID:       c.ID,
+			Labels:   c.Labels,
+			Networks: map[string]string{},
+			Running:  c.State == "running",
+		}
+		if len(c.Names) > 0 {
+			container.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		if c.NetworkSettings != nil {
+			for name, net := range c.NetworkSettings.Networks {
+				container.Networks[name] = net.IPAddress
+			}
+		}
+		result = append(result, container)
+	}
+
+	return result, nil
+}
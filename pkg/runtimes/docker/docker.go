@@ -55,43 +55,65 @@ func (d Docker) GetHost() string {
 		l.Log().Traceln("[Docker] Not using docker-machine")
 	}
 
-	// b) DOCKER_HOST env var
-	dockerHost := os.Getenv("DOCKER_HOST")
-	if dockerHost == "" {
-		l.Log().Traceln("[Docker] GetHost: DOCKER_HOST empty/unset")
-		info, err := d.Info()
-		if err != nil {
-			l.Log().Errorf("[Docker] error getting runtime information: %v", err)
+	// b) remote docker daemon, set via DOCKER_HOST or the current docker context
+	endpoint, err := GetDockerEndpoint()
+	if err != nil {
+		l.Log().Debugf("[Docker] GetHost: failed to get docker endpoint, falling back to DOCKER_HOST: %v", err)
+		endpoint = os.Getenv("DOCKER_HOST")
+	}
+	if dockerHost := dockerEndpointRemoteHost(endpoint); dockerHost != "" {
+		l.Log().Debugf("[Docker] DockerHost: '%s' (endpoint %s)", dockerHost, endpoint)
+		return dockerHost
+	}
+	l.Log().Tracef("[Docker] GetHost: local docker endpoint '%s'", endpoint)
+
+	info, err := d.Info()
+	if err != nil {
+		l.Log().Errorf("[Docker] error getting runtime information: %v", err)
+		return ""
+	}
+	// c) Docker for Desktop (Win/Mac) and it's a local connection
+	if IsDockerDesktop(info.OS) && IsLocalConnection(info.Endpoint) {
+		// c.1) local DfD connection, but inside WSL, where host.docker.internal resolves to an IP, but it's not reachable
+		if _, ok := os.LookupEnv("WSL_DISTRO_NAME"); ok {
+			l.Log().Debugln("[Docker] wanted to use 'host.docker.internal' as docker host, but it's not reachable in WSL2")
 			return ""
 		}
-		// c) Docker for Desktop (Win/Mac) and it's a local connection
-		if IsDockerDesktop(info.OS) && IsLocalConnection(info.Endpoint) {
-			// c.1) local DfD connection, but inside WSL, where host.docker.internal resolves to an IP, but it's not reachable
-			if _, ok := os.LookupEnv("WSL_DISTRO_NAME"); ok {
-				l.Log().Debugln("[Docker] wanted to use 'host.docker.internal' as docker host, but it's not reachable in WSL2")
-				return ""
-			}
-			l.Log().Debugln("[Docker] Local DfD: using 'host.docker.internal'")
-			dockerHost = "host.docker.internal"
-			if _, err := net.LookupHost(dockerHost); err != nil {
-				l.Log().Debugf("[Docker] wanted to use 'host.docker.internal' as docker host, but it's not resolvable locally: %v", err)
-				return ""
-			}
+		l.Log().Debugln("[Docker] Local DfD: using 'host.docker.internal'")
+		if _, err := net.LookupHost("host.docker.internal"); err != nil {
+			l.Log().Debugf("[Docker] wanted to use 'host.docker.internal' as docker host, but it's not resolvable locally: %v", err)
+			return ""
 		}
+		return "host.docker.internal"
 	}
-	url, err := url.Parse(dockerHost)
+
+	return ""
+}
+
+// dockerEndpointRemoteHost returns the host name (without user and port) of a remote docker endpoint (e.g. tcp://host:2376 or ssh://user@host)
+// or an empty string for local endpoints (unix sockets, named pipes or loopback addresses)
+func dockerEndpointRemoteHost(endpoint string) string {
+	if endpoint == "" {
+		return ""
+	}
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		l.Log().Debugf("[Docker] GetHost: error parsing '%s' as URL: %#v", dockerHost, url)
+		l.Log().Debugf("[Docker] error parsing docker endpoint '%s' as URL: %v", endpoint, err)
 		return ""
 	}
-	dockerHost = url.Host
-	// apparently, host.docker.internal is not parsed as host but
-	if dockerHost == "" && url.String() != "" {
-		dockerHost = url.String()
+	switch u.Scheme {
+	case "tcp", "ssh", "http", "https":
+	default:
+		return ""
 	}
-	l.Log().Debugf("[Docker] DockerHost: '%s' (%+v)", dockerHost, url)
-
-	return dockerHost
+	host := u.Hostname()
+	if host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return ""
+	}
+	return host
 }
 
 // GetRuntimePath returns the path of the docker socket
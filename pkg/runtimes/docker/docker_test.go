@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package docker
+
+import "testing"
+
+func TestDockerEndpointRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "",
+		"unix:///var/run/docker.sock":          "",
+		"unix:///Users/me/.colima/docker.sock": "",
+		"npipe:////./pipe/docker_engine":       "",
+		"tcp://localhost:2375":                 "",
+		"tcp://127.0.0.1:2375":                 "",
+		"tcp://192.168.99.100:2376":            "192.168.99.100",
+		"tcp://buildbox.lan:2376":              "buildbox.lan",
+		"ssh://me@buildbox.lan":                "buildbox.lan",
+		"ssh://me@buildbox.lan:2222":           "buildbox.lan",
+		"tcp://[fd00::10]:2376":                "fd00::10",
+	}
+
+	for endpoint, expected := range tests {
+		t.Run(endpoint, func(t *testing.T) {
+			if host := dockerEndpointRemoteHost(endpoint); host != expected {
+				t.Errorf("expected host '%s' for endpoint '%s', got '%s'", expected, endpoint, host)
+			}
+		})
+	}
+}
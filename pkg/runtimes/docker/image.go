@@ -23,9 +23,15 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/sirupsen/logrus"
 )
 
 // GetImages returns a list of images present in the runtime
@@ -49,3 +55,83 @@ func (d Docker) GetImages(ctx context.Context) ([]string, error) {
 
 	return images, nil
 }
+
+// CommitNode commits the filesystem of a node's container as a new image with the given reference.
+// The entrypoint and command of the node's image are kept, so that temporary overrides (e.g. a sleep loop
+// used for provisioning) don't end up in the new image. Additional Dockerfile instructions can be passed via changes.
+func (d Docker) CommitNode(ctx context.Context, node *k3d.Node, reference string, changes []string) error {
+	// create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer docker.Close()
+
+	container, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to get container for node '%s': %w", node.Name, err)
+	}
+
+	image, _, err := docker.ImageInspectWithRaw(ctx, container.ImageID)
+	if err != nil {
+		return fmt.Errorf("docker failed to inspect image of node '%s': %w", node.Name, err)
+	}
+
+	// Dockerfile instructions are applied in order, so explicit changes override the ones restored from the image
+	instructions := []string{}
+	if image.Config != nil {
+		for instruction, value := range map[string][]string{"ENTRYPOINT": image.Config.Entrypoint, "CMD": image.Config.Cmd} {
+			if len(value) == 0 {
+				continue
+			}
+			valueJSON, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s of image '%s': %w", instruction, node.Image, err)
+			}
+			instructions = append(instructions, fmt.Sprintf("%s %s", instruction, valueJSON))
+		}
+	}
+	instructions = append(instructions, changes...)
+
+	l.Log().Debugf("Committing node '%s' as image '%s' with changes %+v", node.Name, reference, instructions)
+	if _, err := docker.ContainerCommit(ctx, container.ID, types.ContainerCommitOptions{
+		Reference: reference,
+		Comment:   fmt.Sprintf("Created by k3d from node '%s' (base image '%s')", node.Name, node.Image),
+		Changes:   instructions,
+		Pause:     true,
+	}); err != nil {
+		return fmt.Errorf("docker failed to commit node '%s' as image '%s': %w", node.Name, reference, err)
+	}
+
+	return nil
+}
+
+// BuildImage builds an image from a tar stream of a build context (containing a Dockerfile at its root) and tags it
+func (d Docker) BuildImage(ctx context.Context, buildContext io.Reader, tag string) error {
+	// create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer docker.Close()
+
+	resp, err := docker.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        []string{tag},
+		Dockerfile:  "Dockerfile",
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return fmt.Errorf("docker failed to build image '%s': %w", tag, err)
+	}
+	defer resp.Body.Close()
+
+	// the response is a stream of JSON messages, which also carries errors of the build steps
+	out := l.Log().WriterLevel(logrus.DebugLevel)
+	defer out.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, out, 0, false, nil); err != nil {
+		return fmt.Errorf("docker failed to build image '%s': %w", tag, err)
+	}
+
+	return nil
+}
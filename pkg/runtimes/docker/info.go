@@ -53,6 +53,9 @@ func (d Docker) Info() (*runtimeTypes.RuntimeInfo, error) {
 		CgroupVersion: info.CgroupVersion,
 		CgroupDriver:  info.CgroupDriver,
 		Filesystem:    "UNKNOWN",
+		CPUs:          info.NCPU,
+		Memory:        info.MemTotal,
+		DockerDesktop: IsDockerDesktop(info.OperatingSystem),
 	}
 
 	// Get the backing filesystem for the storage driver
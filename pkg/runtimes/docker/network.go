@@ -23,9 +23,11 @@ package docker
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -34,6 +36,7 @@ import (
 
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/pkg/util"
 )
@@ -83,11 +86,17 @@ func (d Docker) GetNetwork(ctx context.Context, searchNet *k3d.ClusterNetwork) (
 	}
 
 	// for networks that have an IPAM config, we inspect that as well (e.g. "host" network doesn't have it)
-	if len(targetNetwork.IPAM.Config) > 0 {
-		network.IPAM, err = d.parseIPAM(targetNetwork.IPAM.Config[0])
+	if ipv4Config, ipv6Config := splitIPAMConfigs(targetNetwork.IPAM.Config); ipv4Config != nil {
+		network.IPAM, err = d.parseIPAM(*ipv4Config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse IPAM config: %w", err)
 		}
+		if ipv6Config != nil {
+			network.IPv6 = targetNetwork.EnableIPv6
+			if network.IPAM.IPv6Prefix, err = netaddr.ParseIPPrefix(ipv6Config.Subnet); err != nil {
+				return nil, fmt.Errorf("failed to parse IPv6 subnet of network %s: %w", network.Name, err)
+			}
+		}
 
 		for _, container := range targetNetwork.Containers {
 			if container.IPv4Address != "" {
@@ -131,7 +140,9 @@ func (d Docker) GetNetwork(ctx context.Context, searchNet *k3d.ClusterNetwork) (
 
 // CreateNetworkIfNotPresent creates a new docker network
 // @return: network, exists, error
-func (d Docker) CreateNetworkIfNotPresent(ctx context.Context, inNet *k3d.ClusterNetwork) (*k3d.ClusterNetwork, bool, error) {
+func (d Docker) CreateNetworkIfNotPresent(ctx context.Context, inNet *k3d.ClusterNetwork) (outNet *k3d.ClusterNetwork, existed bool, err error) {
+	ctx, span := tracing.Start(ctx, "docker.CreateNetworkIfNotPresent", tracing.String("k3d.network.name", inNet.Name))
+	defer func() { span.End(err) }()
 
 	// (0) create new docker client
 	docker, err := GetDockerClient()
@@ -184,16 +195,36 @@ func (d Docker) CreateNetworkIfNotPresent(ctx context.Context, inNet *k3d.Cluste
 
 	// use user-defined subnet, if given
 	if !inNet.IPAM.IPPrefix.IsZero() {
+		gateway := inNet.IPAM.IPPrefix.Range().From().Next() // second IP in subnet will be the Gateway (Next, so we don't hit x.x.x.0)
+		if !inNet.IPAM.Gateway.IsZero() {
+			gateway = inNet.IPAM.Gateway
+		}
 		netCreateOpts.IPAM = &network.IPAM{
 			Config: []network.IPAMConfig{
 				{
 					Subnet:  inNet.IPAM.IPPrefix.String(),
-					Gateway: inNet.IPAM.IPPrefix.Range().From().Next().String(), // second IP in subnet will be the Gateway (Next, so we don't hit x.x.x.0)
+					Gateway: gateway.String(),
 				},
 			},
 		}
 	}
 
+	// enable IPv6 (dual-stack) with the given or a random unique local subnet
+	if inNet.IPv6 {
+		if inNet.IPAM.IPv6Prefix.IsZero() {
+			inNet.IPAM.IPv6Prefix, err = generateULAPrefix()
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to generate IPv6 subnet: %w", err)
+			}
+			l.Log().Debugf("Using generated IPv6 subnet %s for network '%s'", inNet.IPAM.IPv6Prefix, inNet.Name)
+		}
+		netCreateOpts.EnableIPv6 = true
+		if netCreateOpts.IPAM == nil {
+			netCreateOpts.IPAM = &network.IPAM{}
+		}
+		netCreateOpts.IPAM.Config = append(netCreateOpts.IPAM.Config, network.IPAMConfig{Subnet: inNet.IPAM.IPv6Prefix.String()})
+	}
+
 	newNet, err := docker.NetworkCreate(ctx, inNet.Name, netCreateOpts)
 	if err != nil {
 		return nil, false, fmt.Errorf("docker failed to create new network '%s': %w", inNet.Name, err)
@@ -205,12 +236,16 @@ func (d Docker) CreateNetworkIfNotPresent(ctx context.Context, inNet *k3d.Cluste
 	}
 
 	l.Log().Infof("Created network '%s'", inNet.Name)
-	prefix, err := netaddr.ParseIPPrefix(networkDetails.IPAM.Config[0].Subnet)
+	ipv4Config, _ := splitIPAMConfigs(networkDetails.IPAM.Config)
+	if ipv4Config == nil {
+		return nil, false, fmt.Errorf("newly created network '%s' has no IPv4 subnet", newNet.ID)
+	}
+	prefix, err := netaddr.ParseIPPrefix(ipv4Config.Subnet)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to parse IP Prefix of newly created network '%s': %w", newNet.ID, err)
 	}
 
-	newClusterNet := &k3d.ClusterNetwork{Name: inNet.Name, ID: networkDetails.ID, IPAM: k3d.IPAM{IPPrefix: prefix}}
+	newClusterNet := &k3d.ClusterNetwork{Name: inNet.Name, ID: networkDetails.ID, IPv6: inNet.IPv6, IPAM: k3d.IPAM{IPPrefix: prefix, IPv6Prefix: inNet.IPAM.IPv6Prefix, Gateway: inNet.IPAM.Gateway}}
 
 	if !inNet.IPAM.IPPrefix.IsZero() {
 		newClusterNet.IPAM.Managed = true
@@ -220,7 +255,10 @@ func (d Docker) CreateNetworkIfNotPresent(ctx context.Context, inNet *k3d.Cluste
 }
 
 // DeleteNetwork deletes a network
-func (d Docker) DeleteNetwork(ctx context.Context, ID string) error {
+func (d Docker) DeleteNetwork(ctx context.Context, ID string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.DeleteNetwork", tracing.String("k3d.network.id", ID))
+	defer func() { span.End(err) }()
+
 	// (0) create new docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -238,6 +276,63 @@ func (d Docker) DeleteNetwork(ctx context.Context, ID string) error {
 	return nil
 }
 
+// GetNetworksByLabel returns the names of all k3d-managed networks carrying the given labels
+func (d Docker) GetNetworksByLabel(ctx context.Context, labels map[string]string) ([]string, error) {
+	// (0) create new docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	// (1) list networks which have the default k3d labels attached
+	filters := filters.NewArgs()
+	for k, v := range k3d.DefaultRuntimeLabels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range labels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	networkList, err := docker.NetworkList(ctx, types.NetworkListOptions{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("docker failed to list networks: %w", err)
+	}
+
+	var networks []string
+	for _, n := range networkList {
+		networks = append(networks, n.Name)
+	}
+	return networks, nil
+}
+
+func (d Docker) GetNetworksCreatedByLabel(ctx context.Context, labels map[string]string) (map[string]time.Time, error) {
+	docker, err := GetDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	filters := filters.NewArgs()
+	for k, v := range k3d.DefaultRuntimeLabels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range labels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	networkList, err := docker.NetworkList(ctx, types.NetworkListOptions{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("docker failed to list networks: %w", err)
+	}
+
+	networks := make(map[string]time.Time, len(networkList))
+	for _, n := range networkList {
+		networks[n.Name] = n.Created
+	}
+	return networks, nil
+}
+
 // GetNetwork gets information about a network by its ID
 func GetNetwork(ctx context.Context, ID string) (types.NetworkResource, error) {
 	docker, err := GetDockerClient()
@@ -329,7 +424,7 @@ func (d Docker) getFreeSubnetPrefix(ctx context.Context) (netaddr.IPPrefix, erro
 	defer docker.Close()
 
 	// 1. Create a fake network to get auto-generated subnet prefix
-	fakenetName := fmt.Sprintf("%s-fakenet-%s", k3d.DefaultObjectNamePrefix, util.GenerateRandomString(10))
+	fakenetName := fmt.Sprintf("%s-fakenet-%s", k3d.ObjectNamePrefix, util.GenerateRandomString(10))
 	fakenetResp, err := docker.NetworkCreate(ctx, fakenetName, types.NetworkCreate{})
 	if err != nil {
 		return netaddr.IPPrefix{}, fmt.Errorf("failed to create fake network: %w", err)
@@ -350,6 +445,59 @@ func (d Docker) getFreeSubnetPrefix(ctx context.Context) (netaddr.IPPrefix, erro
 
 }
 
+// GetNetworkPrefixes returns the subnet prefixes of all docker networks, e.g. to check new subnets for overlaps
+func (d Docker) GetNetworkPrefixes(ctx context.Context) (map[string][]netaddr.IPPrefix, error) {
+	docker, err := GetDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer docker.Close()
+
+	networkList, err := docker.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("docker failed to list networks: %w", err)
+	}
+
+	prefixes := make(map[string][]netaddr.IPPrefix, len(networkList))
+	for _, existingNet := range networkList {
+		for _, config := range existingNet.IPAM.Config {
+			prefix, err := netaddr.ParseIPPrefix(config.Subnet)
+			if err != nil {
+				l.Log().Debugf("Ignoring unparseable subnet '%s' of network %s: %v", config.Subnet, existingNet.Name, err)
+				continue
+			}
+			prefixes[existingNet.Name] = append(prefixes[existingNet.Name], prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+// splitIPAMConfigs returns the first IPv4 and the first IPv6 IPAM config (nil if there's none)
+func splitIPAMConfigs(configs []network.IPAMConfig) (ipv4Config *network.IPAMConfig, ipv6Config *network.IPAMConfig) {
+	for i := range configs {
+		prefix, err := netaddr.ParseIPPrefix(configs[i].Subnet)
+		if err != nil {
+			continue
+		}
+		if prefix.IP().Is6() && ipv6Config == nil {
+			ipv6Config = &configs[i]
+		} else if prefix.IP().Is4() && ipv4Config == nil {
+			ipv4Config = &configs[i]
+		}
+	}
+	return ipv4Config, ipv6Config
+}
+
+// generateULAPrefix returns a random IPv6 unique local address (ULA) /64 prefix (fdXX:XXXX:XXXX:XXXX::/64, see RFC 4193)
+func generateULAPrefix() (netaddr.IPPrefix, error) {
+	var ip [16]byte
+	ip[0] = 0xfd
+	if _, err := rand.Read(ip[1:8]); err != nil {
+		return netaddr.IPPrefix{}, err
+	}
+	return netaddr.IPPrefixFrom(netaddr.IPFrom16(ip), 64), nil
+}
+
 // parseIPAM Returns an IPAM structure with the subnet and gateway filled in. If some of the values
 // cannot be parsed, an error is returned. If gateway is empty, the function calculates the default gateway.
 func (d Docker) parseIPAM(config network.IPAMConfig) (ipam k3d.IPAM, err error) {
@@ -366,6 +514,7 @@ func (d Docker) parseIPAM(config network.IPAMConfig) (ipam k3d.IPAM, err error)
 	} else {
 		gateway, err = netaddr.ParseIP(config.Gateway)
 	}
+	ipam.Gateway = gateway
 	ipam.IPsUsed = append(ipam.IPsUsed, gateway)
 
 	return
@@ -29,22 +29,28 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
 	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
 
 // CreateNode creates a new container
-func (d Docker) CreateNode(ctx context.Context, node *k3d.Node) error {
+func (d Docker) CreateNode(ctx context.Context, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.CreateNode", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
 
 	// translate node spec to docker container specs
-	dockerNode, err := TranslateNodeToContainer(node)
+	dockerNode, err := TranslateNodeToContainer(ctx, node)
 	if err != nil {
 		return fmt.Errorf("failed to translate k3d node spec to docker container spec: %w", err)
 	}
@@ -59,7 +65,10 @@ func (d Docker) CreateNode(ctx context.Context, node *k3d.Node) error {
 }
 
 // DeleteNode deletes a node
-func (d Docker) DeleteNode(ctx context.Context, nodeSpec *k3d.Node) error {
+func (d Docker) DeleteNode(ctx context.Context, nodeSpec *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.DeleteNode", tracing.String("k3d.node.name", nodeSpec.Name))
+	defer func() { span.End(err) }()
+
 	l.Log().Debugf("Deleting node %s ...", nodeSpec.Name)
 	return removeContainer(ctx, nodeSpec.Name)
 }
@@ -100,7 +109,10 @@ func (d Docker) GetNodesByLabel(ctx context.Context, labels map[string]string) (
 }
 
 // StartNode starts an existing node
-func (d Docker) StartNode(ctx context.Context, node *k3d.Node) error {
+func (d Docker) StartNode(ctx context.Context, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.StartNode", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
+
 	// (0) create docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -139,7 +151,10 @@ func (d Docker) StartNode(ctx context.Context, node *k3d.Node) error {
 }
 
 // StopNode stops an existing node
-func (d Docker) StopNode(ctx context.Context, node *k3d.Node) error {
+func (d Docker) StopNode(ctx context.Context, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.StopNode", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
+
 	// (0) create docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -166,6 +181,70 @@ func (d Docker) StopNode(ctx context.Context, node *k3d.Node) error {
 	return nil
 }
 
+// PauseNode pauses an existing node, i.e. freezes all of its processes without touching the container state
+func (d Docker) PauseNode(ctx context.Context, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.PauseNode", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
+
+	// (0) create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to create docker client. %w", err)
+	}
+	defer docker.Close()
+
+	// get container which represents the node
+	nodeContainer, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to get container for node '%s': %w", node.Name, err)
+	}
+
+	// check if the container is actually managed by
+	if v, ok := nodeContainer.Labels["app"]; !ok || v != "k3d" {
+		return fmt.Errorf("Failed to determine if container '%s' is managed by k3d (needs label 'app=k3d')", nodeContainer.ID)
+	}
+
+	// actually pause the container
+	l.Log().Infof("Pausing Node '%s'", node.Name)
+	if err := docker.ContainerPause(ctx, nodeContainer.ID); err != nil {
+		return fmt.Errorf("docker failed to pause the container for node '%s': %w", node.Name, err)
+	}
+
+	return nil
+}
+
+// UnpauseNode resumes a paused node
+func (d Docker) UnpauseNode(ctx context.Context, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.UnpauseNode", tracing.String("k3d.node.name", node.Name))
+	defer func() { span.End(err) }()
+
+	// (0) create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to create docker client. %w", err)
+	}
+	defer docker.Close()
+
+	// get container which represents the node
+	nodeContainer, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to get container for node '%s': %w", node.Name, err)
+	}
+
+	// check if the container is actually managed by
+	if v, ok := nodeContainer.Labels["app"]; !ok || v != "k3d" {
+		return fmt.Errorf("Failed to determine if container '%s' is managed by k3d (needs label 'app=k3d')", nodeContainer.ID)
+	}
+
+	// actually unpause the container
+	l.Log().Infof("Unpausing Node '%s'", node.Name)
+	if err := docker.ContainerUnpause(ctx, nodeContainer.ID); err != nil {
+		return fmt.Errorf("docker failed to unpause the container for node '%s': %w", node.Name, err)
+	}
+
+	return nil
+}
+
 func getContainersByLabel(ctx context.Context, labels map[string]string) ([]types.Container, error) {
 	// (0) create docker client
 	docker, err := GetDockerClient()
@@ -296,11 +375,7 @@ func (d Docker) GetNodeLogs(ctx context.Context, node *k3d.Node, since time.Time
 		return nil, fmt.Errorf("node '%s' (container '%s') not running", node.Name, containerInspectResponse.ID)
 	}
 
-	sinceStr := ""
-	if !since.IsZero() {
-		sinceStr = since.Format("2006-01-02T15:04:05.999999999Z")
-	}
-	logreader, err := docker.ContainerLogs(ctx, container.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Since: sinceStr, Follow: opts.Follow})
+	logreader, err := docker.ContainerLogs(ctx, container.ID, containerLogsOptions(since, opts))
 	if err != nil {
 		return nil, fmt.Errorf("docker failed to get logs from node '%s' (container '%s'): %w", node.Name, container.ID, err)
 	}
@@ -308,6 +383,59 @@ func (d Docker) GetNodeLogs(ctx context.Context, node *k3d.Node, since time.Time
 	return logreader, nil
 }
 
+// StreamNodeLogs writes the logs of a given node to stdout and stderr until they're done or, when following them, until the context is cancelled.
+// Other than GetNodeLogs, it also works for stopped nodes, e.g. to check why they exited.
+func (d Docker) StreamNodeLogs(ctx context.Context, node *k3d.Node, since time.Time, opts *runtimeTypes.NodeLogsOpts, stdout io.Writer, stderr io.Writer) error {
+	// get the container for the given node
+	container, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to get container for node '%s': %w", node.Name, err)
+	}
+
+	// create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to get docker client; %w", err)
+	}
+	defer docker.Close()
+
+	containerInspectResponse, err := docker.ContainerInspect(ctx, container.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container '%s': %w", container.ID, err)
+	}
+
+	logreader, err := docker.ContainerLogs(ctx, container.ID, containerLogsOptions(since, opts))
+	if err != nil {
+		return fmt.Errorf("docker failed to get logs from node '%s' (container '%s'): %w", node.Name, container.ID, err)
+	}
+	defer logreader.Close()
+
+	// without a TTY, docker multiplexes stdout and stderr into a single stream
+	if containerInspectResponse.Config != nil && containerInspectResponse.Config.Tty {
+		_, err = io.Copy(stdout, logreader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, logreader)
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs from node '%s': %w", node.Name, err)
+	}
+	return nil
+}
+
+// containerLogsOptions translates the node logs options to docker's options
+func containerLogsOptions(since time.Time, opts *runtimeTypes.NodeLogsOpts) types.ContainerLogsOptions {
+	logsOpts := types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}
+	if !since.IsZero() {
+		logsOpts.Since = since.Format("2006-01-02T15:04:05.999999999Z")
+	}
+	if opts != nil {
+		logsOpts.Follow = opts.Follow
+		logsOpts.Tail = opts.Tail
+		logsOpts.Timestamps = opts.Timestamps
+	}
+	return logsOpts
+}
+
 // ExecInNodeGetLogs executes a command inside a node and returns the logs to the caller, e.g. to parse them
 func (d Docker) ExecInNodeGetLogs(ctx context.Context, node *k3d.Node, cmd []string) (*bufio.Reader, error) {
 	resp, err := executeInNode(ctx, node, cmd, nil)
@@ -366,7 +494,101 @@ func (d Docker) ExecInNodeWithStdin(ctx context.Context, node *k3d.Node, cmd []s
 	return execInNode(ctx, node, cmd, stdin)
 }
 
-func executeInNode(ctx context.Context, node *k3d.Node, cmd []string, stdin io.ReadCloser) (*types.HijackedResponse, error) {
+// ExecInNodeAttached execs a command inside a node with its streams connected to the ones given in the options (e.g. for an interactive shell)
+// and returns the exit code of the command once it's done
+func (d Docker) ExecInNodeAttached(ctx context.Context, node *k3d.Node, cmd []string, opts *runtimeTypes.ExecOpts) (int, error) {
+	l.Log().Debugf("Executing command '%+v' attached in node '%s'", cmd, node.Name)
+
+	if opts == nil {
+		opts = &runtimeTypes.ExecOpts{}
+	}
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	// get the container for the given node
+	container, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return -1, fmt.Errorf("failed to get container for node '%s': %w", node.Name, err)
+	}
+
+	// create docker client
+	docker, err := GetDockerClient()
+	if err != nil {
+		return -1, fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	exec, err := docker.ContainerExecCreate(ctx, container.ID, types.ExecConfig{
+		Privileged:   true,
+		Tty:          opts.TTY,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          opts.Env,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("docker failed to create exec config for node '%s': %w", node.Name, err)
+	}
+
+	execConnection, err := docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: opts.TTY})
+	if err != nil {
+		return -1, fmt.Errorf("docker failed to attach to exec process in node '%s': %w", node.Name, err)
+	}
+	defer execConnection.Close()
+
+	if opts.TTY && opts.Width > 0 && opts.Height > 0 {
+		if err := docker.ContainerExecResize(ctx, exec.ID, types.ResizeOptions{Width: opts.Width, Height: opts.Height}); err != nil {
+			l.Log().Debugf("Failed to resize TTY of exec process in node '%s': %v", node.Name, err)
+		}
+	}
+
+	outputDone := make(chan error, 1)
+	go func() {
+		var err error
+		if opts.TTY {
+			_, err = io.Copy(stdout, execConnection.Reader)
+		} else {
+			_, err = stdcopy.StdCopy(stdout, stderr, execConnection.Reader)
+		}
+		outputDone <- err
+	}()
+
+	if opts.Stdin != nil {
+		go func() {
+			if _, err := io.Copy(execConnection.Conn, opts.Stdin); err != nil {
+				l.Log().Debugf("Failed to copy stdin to exec process in node '%s': %v", node.Name, err)
+			}
+			if err := execConnection.CloseWrite(); err != nil {
+				l.Log().Debugf("Failed to close stdin of exec process in node '%s': %v", node.Name, err)
+			}
+		}()
+	}
+
+	select {
+	case err := <-outputDone:
+		if err != nil {
+			return -1, fmt.Errorf("failed to read output of exec process in node '%s': %w", node.Name, err)
+		}
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+
+	execInfo, err := docker.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return -1, fmt.Errorf("docker failed to inspect exec process in node '%s': %w", node.Name, err)
+	}
+	return execInfo.ExitCode, nil
+}
+
+func executeInNode(ctx context.Context, node *k3d.Node, cmd []string, stdin io.ReadCloser) (resp *types.HijackedResponse, err error) {
+	ctx, span := tracing.Start(ctx, "docker.ExecInNode", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.exec.command", strings.Join(cmd, " ")))
+	defer func() { span.End(err) }()
 
 	l.Log().Debugf("Executing command '%+v' in node '%s'", cmd, node.Name)
 
@@ -434,7 +656,9 @@ func executeInNode(ctx context.Context, node *k3d.Node, cmd []string, stdin io.R
 		// if still running, continue loop
 		if execInfo.Running {
 			l.Log().Tracef("Exec process '%+v' still running in node '%s'.. sleeping for 1 second...", cmd, node.Name)
-			time.Sleep(1 * time.Second)
+			if err := util.SleepWithContext(ctx, 1*time.Second); err != nil {
+				return &execConnection, fmt.Errorf("stopped waiting for exec process in node '%s': %w", node.Name, err)
+			}
 			continue
 		}
 
@@ -0,0 +1,62 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/go-test/deep"
+	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
+)
+
+func TestContainerLogsOptions(t *testing.T) {
+	since := time.Date(2021, 11, 3, 10, 15, 30, 500, time.UTC)
+
+	tests := map[string]struct {
+		since    time.Time
+		opts     *runtimeTypes.NodeLogsOpts
+		expected types.ContainerLogsOptions
+	}{
+		"no options": {
+			expected: types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true},
+		},
+		"since": {
+			since:    since,
+			opts:     &runtimeTypes.NodeLogsOpts{Follow: true},
+			expected: types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true, Since: "2021-11-03T10:15:30.0000005Z"},
+		},
+		"tail and timestamps": {
+			opts:     &runtimeTypes.NodeLogsOpts{Tail: "100", Timestamps: true},
+			expected: types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Tail: "100", Timestamps: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if diff := deep.Equal(containerLogsOptions(tc.since, tc.opts), tc.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
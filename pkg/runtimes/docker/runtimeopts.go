@@ -0,0 +1,207 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dockerunits "github.com/docker/go-units"
+)
+
+// runtimeOptHandlers maps the supported --runtime-opt keys to functions applying their value to the container's host config
+var runtimeOptHandlers = map[string]func(hostConfig *container.HostConfig, value string) error{
+	"shm-size": func(hostConfig *container.HostConfig, value string) error {
+		size, err := dockerunits.RAMInBytes(value)
+		if err != nil {
+			return err
+		}
+		hostConfig.ShmSize = size
+		return nil
+	},
+	"pids-limit": func(hostConfig *container.HostConfig, value string) error {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		hostConfig.PidsLimit = &limit
+		return nil
+	},
+	"device": func(hostConfig *container.HostConfig, value string) error {
+		device, err := parseDeviceMapping(value)
+		if err != nil {
+			return err
+		}
+		hostConfig.Devices = append(hostConfig.Devices, device)
+		return nil
+	},
+	"cap-add": func(hostConfig *container.HostConfig, value string) error {
+		hostConfig.CapAdd = append(hostConfig.CapAdd, value)
+		return nil
+	},
+	"cap-drop": func(hostConfig *container.HostConfig, value string) error {
+		hostConfig.CapDrop = append(hostConfig.CapDrop, value)
+		return nil
+	},
+	"security-opt": func(hostConfig *container.HostConfig, value string) error {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, value)
+		return nil
+	},
+	"sysctl": func(hostConfig *container.HostConfig, value string) error {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("expected format KEY=VALUE")
+		}
+		if hostConfig.Sysctls == nil {
+			hostConfig.Sysctls = map[string]string{}
+		}
+		hostConfig.Sysctls[kv[0]] = kv[1]
+		return nil
+	},
+	"ulimit": func(hostConfig *container.HostConfig, value string) error {
+		ulimit, err := dockerunits.ParseUlimit(value)
+		if err != nil {
+			return err
+		}
+		hostConfig.Ulimits = append(hostConfig.Ulimits, ulimit)
+		return nil
+	},
+	"cpuset-cpus": func(hostConfig *container.HostConfig, value string) error {
+		if err := validateCPUSet(value); err != nil {
+			return err
+		}
+		hostConfig.CpusetCpus = value
+		return nil
+	},
+	"cpuset-mems": func(hostConfig *container.HostConfig, value string) error {
+		if err := validateCPUSet(value); err != nil {
+			return err
+		}
+		hostConfig.CpusetMems = value
+		return nil
+	},
+	"oom-score-adj": func(hostConfig *container.HostConfig, value string) error {
+		score, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		hostConfig.OomScoreAdj = score
+		return nil
+	},
+}
+
+// RuntimeOptKeys returns the sorted list of supported --runtime-opt keys
+func RuntimeOptKeys() []string {
+	keys := make([]string, 0, len(runtimeOptHandlers))
+	for key := range runtimeOptHandlers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateRuntimeOpts checks that all runtime opts (format `KEY=VALUE`) are supported and have valid values
+func (d Docker) ValidateRuntimeOpts(opts []string) error {
+	return applyRuntimeOpts(&container.HostConfig{}, opts)
+}
+
+// applyRuntimeOpts applies the runtime opts (format `KEY=VALUE`) to the container's host config
+func applyRuntimeOpts(hostConfig *container.HostConfig, opts []string) error {
+	for _, opt := range opts {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid runtime opt '%s': expected format KEY=VALUE", opt)
+		}
+		handler, ok := runtimeOptHandlers[kv[0]]
+		if !ok {
+			return fmt.Errorf("unsupported runtime opt '%s': must be one of %s", kv[0], strings.Join(RuntimeOptKeys(), ", "))
+		}
+		if err := handler(hostConfig, kv[1]); err != nil {
+			return fmt.Errorf("invalid value for runtime opt '%s': %w", kv[0], err)
+		}
+	}
+	return nil
+}
+
+// parseDeviceMapping parses a device mapping in the format `HOSTPATH[:CONTAINERPATH[:PERMISSIONS]]` (like `docker run --device`)
+func parseDeviceMapping(value string) (container.DeviceMapping, error) {
+	device := container.DeviceMapping{
+		CgroupPermissions: "rwm",
+	}
+	split := strings.Split(value, ":")
+	if split[0] == "" || len(split) > 3 {
+		return device, fmt.Errorf("expected format HOSTPATH[:CONTAINERPATH[:PERMISSIONS]], got '%s'", value)
+	}
+	device.PathOnHost = split[0]
+	device.PathInContainer = split[0]
+	if len(split) > 1 && split[1] != "" {
+		device.PathInContainer = split[1]
+	}
+	if len(split) > 2 {
+		for _, c := range split[2] {
+			if c != 'r' && c != 'w' && c != 'm' {
+				return device, fmt.Errorf("invalid device permissions '%s': must be a combination of r, w and m", split[2])
+			}
+		}
+		device.CgroupPermissions = split[2]
+	}
+	return device, nil
+}
+
+// validateCPUSet checks that the value is a list of CPUs or memory (NUMA) nodes in the format of `docker run --cpuset-cpus`, e.g. `0-3,6`
+func validateCPUSet(value string) error {
+	if value == "" {
+		return fmt.Errorf("expected a list of CPUs or memory nodes (e.g. '0-3,6'), got an empty value")
+	}
+	for _, part := range strings.Split(value, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		var ids []int
+		for _, bound := range bounds {
+			id, err := strconv.Atoi(bound)
+			if err != nil || id < 0 {
+				return fmt.Errorf("invalid cpuset '%s': expected a comma-separated list of numbers or ranges (e.g. '0-3,6')", value)
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 2 && ids[0] > ids[1] {
+			return fmt.Errorf("invalid cpuset '%s': range '%s' ends before it starts", value, part)
+		}
+	}
+	return nil
+}
+
+// runtimeOptsFromHostConfig returns the runtime opts that can be recovered from an existing container's host config,
+// so that they're kept when the node is copied or replaced
+func runtimeOptsFromHostConfig(hostConfig *container.HostConfig) []string {
+	var opts []string
+	if hostConfig.CpusetCpus != "" {
+		opts = append(opts, fmt.Sprintf("cpuset-cpus=%s", hostConfig.CpusetCpus))
+	}
+	if hostConfig.CpusetMems != "" {
+		opts = append(opts, fmt.Sprintf("cpuset-mems=%s", hostConfig.CpusetMems))
+	}
+	return opts
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/go-test/deep"
+)
+
+func TestApplyRuntimeOpts(t *testing.T) {
+	pidsLimit := int64(4096)
+
+	tests := []struct {
+		name        string
+		opts        []string
+		expected    *container.HostConfig
+		expectError bool
+	}{
+		{
+			name: "resources",
+			opts: []string{"shm-size=1g", "pids-limit=4096", "cpuset-cpus=0-1", "oom-score-adj=-500"},
+			expected: &container.HostConfig{
+				ShmSize:     1024 * 1024 * 1024,
+				OomScoreAdj: -500,
+				Resources: container.Resources{
+					PidsLimit:  &pidsLimit,
+					CpusetCpus: "0-1",
+				},
+			},
+		},
+		{
+			name: "devices and capabilities",
+			opts: []string{"device=/dev/fuse", "device=/dev/kvm:/dev/kvm0:rw", "cap-add=SYS_ADMIN", "cap-drop=NET_RAW", "security-opt=apparmor=unconfined", "sysctl=net.ipv4.ip_forward=1"},
+			expected: &container.HostConfig{
+				CapAdd:      []string{"SYS_ADMIN"},
+				CapDrop:     []string{"NET_RAW"},
+				SecurityOpt: []string{"apparmor=unconfined"},
+				Sysctls:     map[string]string{"net.ipv4.ip_forward": "1"},
+				Resources: container.Resources{
+					Devices: []container.DeviceMapping{
+						{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"},
+						{PathOnHost: "/dev/kvm", PathInContainer: "/dev/kvm0", CgroupPermissions: "rw"},
+					},
+				},
+			},
+		},
+		{
+			name: "cpusets",
+			opts: []string{"cpuset-cpus=0-3,8", "cpuset-mems=1"},
+			expected: &container.HostConfig{
+				Resources: container.Resources{
+					CpusetCpus: "0-3,8",
+					CpusetMems: "1",
+				},
+			},
+		},
+		{name: "missing value", opts: []string{"shm-size"}, expectError: true},
+		{name: "unsupported key", opts: []string{"privileged=false"}, expectError: true},
+		{name: "invalid size", opts: []string{"shm-size=huge"}, expectError: true},
+		{name: "invalid cpuset", opts: []string{"cpuset-cpus=0-a"}, expectError: true},
+		{name: "invalid cpuset range", opts: []string{"cpuset-mems=3-1"}, expectError: true},
+		{name: "invalid device permissions", opts: []string{"device=/dev/fuse:/dev/fuse:rwx"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostConfig := &container.HostConfig{}
+			err := applyRuntimeOpts(hostConfig, tt.opts)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(hostConfig, tt.expected); diff != nil {
+				t.Errorf("unexpected host config: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestRuntimeOptsFromHostConfig(t *testing.T) {
+	hostConfig := &container.HostConfig{Resources: container.Resources{CpusetCpus: "0-3", CpusetMems: "0"}}
+	opts := runtimeOptsFromHostConfig(hostConfig)
+	if diff := deep.Equal(opts, []string{"cpuset-cpus=0-3", "cpuset-mems=0"}); diff != nil {
+		t.Errorf("unexpected runtime opts: %+v", diff)
+	}
+
+	// the recovered opts have to produce the same host config again
+	recovered := &container.HostConfig{}
+	if err := applyRuntimeOpts(recovered, opts); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(recovered, hostConfig); diff != nil {
+		t.Errorf("unexpected host config: %+v", diff)
+	}
+
+	if opts := runtimeOptsFromHostConfig(&container.HostConfig{}); opts != nil {
+		t.Errorf("expected no runtime opts, got %v", opts)
+	}
+}
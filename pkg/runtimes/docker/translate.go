@@ -26,6 +26,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	goruntime "runtime"
 	"strconv"
 	"strings"
 
@@ -38,6 +39,7 @@ import (
 	runtimeErr "github.com/rancher/k3d/v5/pkg/runtimes/errors"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/rancher/k3d/v5/pkg/types/fixes"
+	"github.com/rancher/k3d/v5/pkg/util"
 	"inet.af/netaddr"
 
 	dockercliopts "github.com/docker/cli/opts"
@@ -45,7 +47,7 @@ import (
 )
 
 // TranslateNodeToContainer translates a k3d node specification to a docker container representation
-func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
+func TranslateNodeToContainer(ctx context.Context, node *k3d.Node) (*NodeInDocker, error) {
 	init := true
 	if disableInit, err := strconv.ParseBool(os.Getenv(k3d.K3dEnvDebugDisableDockerInit)); err == nil && disableInit {
 		l.Log().Traceln("docker-init disabled for all containers")
@@ -66,7 +68,7 @@ func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
 
 	/* Command & Arguments */
 	// FIXME: FixCgroupV2 - to be removed when fixed upstream
-	if fixes.FixEnabledAny() {
+	if fixes.FixEnabledAnyForNode(node) {
 		if node.Role == k3d.AgentRole || node.Role == k3d.ServerRole {
 			containerConfig.Entrypoint = []string{
 				"/bin/k3d-entrypoint.sh",
@@ -74,6 +76,10 @@ func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
 		}
 	}
 
+	if len(node.Entrypoint) > 0 {
+		containerConfig.Entrypoint = node.Entrypoint
+	}
+
 	containerConfig.Cmd = []string{}
 
 	containerConfig.Cmd = append(containerConfig.Cmd, node.Cmd...)  // contains k3s command and role-specific required flags/args
@@ -97,6 +103,9 @@ func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
 	for _, mnt := range k3d.DefaultTmpfsMounts {
 		hostConfig.Tmpfs[mnt] = ""
 	}
+	for mnt, opts := range node.Tmpfs {
+		hostConfig.Tmpfs[mnt] = opts
+	}
 
 	if node.GPURequest != "" {
 		gpuopts := dockercliopts.GpuOpts{}
@@ -116,12 +125,33 @@ func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
 		hostConfig.Memory = memory
 	}
 
+	// cpu limits
+	if node.CPUs != "" {
+		cpus, err := strconv.ParseFloat(node.CPUs, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to set cpu limit: %+v", err)
+		}
+		hostConfig.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	/* Raw runtime options (escape hatch for settings not wrapped by dedicated flags) */
+	if err := applyRuntimeOpts(&hostConfig, node.RuntimeOpts); err != nil {
+		return nil, fmt.Errorf("Failed to apply runtime opts: %+v", err)
+	}
+
 	/* They have to run in privileged mode */
 	// TODO: can we replace this by a reduced set of capabilities?
 	hostConfig.Privileged = true
 
 	/* Volumes */
 	hostConfig.Binds = node.Volumes
+	if goruntime.GOOS == "windows" {
+		// Docker Desktop expects Windows host paths in their slash-separated form (C:/Users/..., //server/share/...)
+		hostConfig.Binds = make([]string, len(node.Volumes))
+		for i, volume := range node.Volumes {
+			hostConfig.Binds[i] = util.TranslateWindowsVolumeMount(volume)
+		}
+	}
 	// containerConfig.Volumes = map[string]struct{}{} // TODO: do we need this? We only used binds before
 
 	/* Ports */
@@ -153,7 +183,7 @@ func TranslateNodeToContainer(node *k3d.Node) (*NodeInDocker, error) {
 	}
 
 	if len(node.Networks) > 0 {
-		netInfo, err := GetNetwork(context.Background(), node.Networks[0]) // FIXME: only considering first network here, as that's the one k3d creates for a cluster
+		netInfo, err := GetNetwork(ctx, node.Networks[0]) // FIXME: only considering first network here, as that's the one k3d creates for a cluster
 		if err != nil {
 			l.Log().Warnf("Failed to get network information: %v", err)
 		} else if netInfo.Driver == "host" {
@@ -206,10 +236,14 @@ func TranslateContainerDetailsToNode(containerDetails types.ContainerJSON) (*k3d
 	}
 
 	// get networks and ensure that the cluster network is first in list
+	namePrefix, ok := containerDetails.Config.Labels[k3d.LabelClusterNamePrefix]
+	if !ok {
+		namePrefix = k3d.ObjectNamePrefix // created by an older k3d version
+	}
 	orderedNetworks := []string{}
 	otherNetworks := []string{}
 	for networkName := range containerDetails.NetworkSettings.Networks {
-		if strings.HasPrefix(networkName, fmt.Sprintf("%s-%s", k3d.DefaultObjectNamePrefix, containerDetails.Config.Labels[k3d.LabelClusterName])) { // FIXME: catch error if label 'k3d.cluster' does not exist, but this should also never be the case
+		if strings.HasPrefix(networkName, fmt.Sprintf("%s-%s", namePrefix, containerDetails.Config.Labels[k3d.LabelClusterName])) { // FIXME: catch error if label 'k3d.cluster' does not exist, but this should also never be the case
 			orderedNetworks = append(orderedNetworks, networkName)
 			continue
 		}
@@ -262,15 +296,58 @@ func TranslateContainerDetailsToNode(containerDetails types.ContainerJSON) (*k3d
 
 	// status
 	nodeState := k3d.NodeState{
-		Running: containerDetails.ContainerJSONBase.State.Running,
-		Status:  containerDetails.ContainerJSONBase.State.Status,
+		Running:   containerDetails.ContainerJSONBase.State.Running,
+		Status:    containerDetails.ContainerJSONBase.State.Status,
+		ExitCode:  containerDetails.ContainerJSONBase.State.ExitCode,
+		OOMKilled: containerDetails.ContainerJSONBase.State.OOMKilled,
 	}
 
-	// memory limit
-	memoryStr := dockerunits.HumanSize(float64(containerDetails.HostConfig.Memory))
-	// no-limit is returned as 0B, filter this out
-	if memoryStr == "0B" {
-		memoryStr = ""
+	// anonymous volumes (not referenced by name in the binds), e.g. holding the k3s state
+	var anonVolumes []string
+	for _, mount := range containerDetails.Mounts {
+		if mount.Type != "volume" {
+			continue
+		}
+		named := false
+		for _, bind := range containerDetails.HostConfig.Binds {
+			if strings.HasPrefix(bind, mount.Name+":") {
+				named = true
+				break
+			}
+		}
+		if !named {
+			anonVolumes = append(anonVolumes, fmt.Sprintf("%s:%s", mount.Name, mount.Destination))
+		}
+	}
+
+	// memory limit (in bytes, so that it's kept exactly when copying the node)
+	memoryStr := ""
+	if containerDetails.HostConfig.Memory > 0 {
+		memoryStr = strconv.FormatInt(containerDetails.HostConfig.Memory, 10)
+	}
+
+	// cpu limit
+	cpuStr := ""
+	if containerDetails.HostConfig.NanoCPUs > 0 {
+		cpuStr = strconv.FormatFloat(float64(containerDetails.HostConfig.NanoCPUs)/1e9, 'f', -1, 64)
+	}
+
+	// additional tmpfs mounts
+	var tmpfs map[string]string
+	for mnt, opts := range containerDetails.HostConfig.Tmpfs {
+		isDefault := false
+		for _, defaultMnt := range k3d.DefaultTmpfsMounts {
+			if mnt == defaultMnt {
+				isDefault = true
+				break
+			}
+		}
+		if !isDefault {
+			if tmpfs == nil {
+				tmpfs = map[string]string{}
+			}
+			tmpfs[mnt] = opts
+		}
 	}
 
 	// IP
@@ -311,7 +388,7 @@ func TranslateContainerDetailsToNode(containerDetails types.ContainerJSON) (*k3d
 	node := &k3d.Node{
 		Name:          strings.TrimPrefix(containerDetails.Name, "/"), // container name with leading '/' cut off
 		Role:          k3d.NodeRoles[containerDetails.Config.Labels[k3d.LabelRole]],
-		Image:         containerDetails.Image,
+		Image:         containerDetails.Config.Image,
 		Volumes:       containerDetails.HostConfig.Binds,
 		Env:           containerDetails.Config.Env,
 		Cmd:           containerDetails.Config.Cmd,
@@ -320,12 +397,17 @@ func TranslateContainerDetailsToNode(containerDetails types.ContainerJSON) (*k3d
 		Restart:       restart,
 		Created:       containerDetails.Created,
 		RuntimeLabels: labels,
+		OS:            labels[k3d.LabelNodeOS],
 		Networks:      orderedNetworks,
 		ServerOpts:    serverOpts,
 		AgentOpts:     k3d.AgentOpts{},
 		State:         nodeState,
 		Memory:        memoryStr,
+		CPUs:          cpuStr,
+		RuntimeOpts:   runtimeOptsFromHostConfig(containerDetails.HostConfig),
+		Tmpfs:         tmpfs,
 		IP:            nodeIP, // only valid for the cluster network
+		AnonVolumes:   anonVolumes,
 	}
 	return node, nil
 }
@@ -23,6 +23,7 @@ THE SOFTWARE.
 package docker
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"testing"
@@ -104,7 +105,7 @@ func TestTranslateNodeToContainer(t *testing.T) {
 		expectedRepresentation.ContainerConfig.Entrypoint = []string{"/bin/k3d-entrypoint.sh"}
 	}
 
-	actualRepresentation, err := TranslateNodeToContainer(inputNode)
+	actualRepresentation, err := TranslateNodeToContainer(context.Background(), inputNode)
 	if err != nil {
 		t.Error(err)
 	}
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"regexp"
 	"strings"
 
@@ -40,6 +41,7 @@ import (
 	"github.com/pkg/errors"
 	l "github.com/rancher/k3d/v5/pkg/logger"
 	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 	"github.com/spf13/pflag"
 )
@@ -71,7 +73,10 @@ func GetDefaultObjectLabelsFilter(clusterName string) filters.Args {
 }
 
 // CopyToNode copies a file from the local FS to the selected node
-func (d Docker) CopyToNode(ctx context.Context, src string, dest string, node *k3d.Node) error {
+func (d Docker) CopyToNode(ctx context.Context, src string, dest string, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.CopyToNode", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.copy.dest", dest))
+	defer func() { span.End(err) }()
+
 	// create docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -112,7 +117,9 @@ func (d Docker) CopyToNode(ctx context.Context, src string, dest string, node *k
 }
 
 // WriteToNode writes a byte array to the selected node
-func (d Docker) WriteToNode(ctx context.Context, content []byte, dest string, mode os.FileMode, node *k3d.Node) error {
+func (d Docker) WriteToNode(ctx context.Context, content []byte, dest string, mode os.FileMode, node *k3d.Node) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.WriteToNode", tracing.String("k3d.node.name", node.Name), tracing.String("k3d.copy.dest", dest))
+	defer func() { span.End(err) }()
 
 	nodeContainer, err := getNodeContainer(ctx, node)
 	if err != nil {
@@ -180,8 +187,61 @@ func (d Docker) ReadFromNode(ctx context.Context, path string, node *k3d.Node) (
 	return reader, err
 }
 
+// ExportFromNode returns a tar stream of a path (e.g. a volume mount) in a node, which also works while the node is stopped.
+// The archive's entries are relative to the parent directory of the path, i.e. they start with the path's base name.
+func (d Docker) ExportFromNode(ctx context.Context, path string, node *k3d.Node) (io.ReadCloser, error) {
+	l.Log().Tracef("Exporting path %s from node %s...", path, node.Name)
+	reader, err := d.ReadFromNode(ctx, path, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export path '%s' from node '%s': %w", path, node.Name, err)
+	}
+	return reader, nil
+}
+
+// ImportToNode extracts a tar stream created by ExportFromNode to the same path in a node, which also works for created, but not yet started nodes
+func (d Docker) ImportToNode(ctx context.Context, content io.Reader, nodePath string, node *k3d.Node) error {
+	l.Log().Tracef("Importing path %s into node %s...", nodePath, node.Name)
+	nodeContainer, err := getNodeContainer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to find container for node '%s': %w", node.Name, err)
+	}
+
+	docker, err := GetDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	if err := docker.CopyToContainer(ctx, nodeContainer.ID, path.Dir(nodePath), content, types.CopyToContainerOptions{AllowOverwriteDirWithFile: true}); err != nil {
+		return fmt.Errorf("failed to import path '%s' into node '%s': %w", nodePath, node.Name, err)
+	}
+	return nil
+}
+
 // GetDockerClient returns a docker client
 func GetDockerClient() (client.APIClient, error) {
+	dockerCli, err := newDockerCli()
+	if err != nil {
+		return nil, err
+	}
+
+	return dockerCli.Client(), nil
+}
+
+// GetDockerEndpoint returns the effective docker daemon endpoint (e.g. unix:///var/run/docker.sock or ssh://user@host),
+// which is either set via DOCKER_HOST or by the current docker context
+func GetDockerEndpoint() (string, error) {
+	dockerCli, err := newDockerCli()
+	if err != nil {
+		return "", err
+	}
+	defer dockerCli.Client().Close()
+
+	return dockerCli.DockerEndpoint().Host, nil
+}
+
+// newDockerCli returns an initialized docker CLI, respecting the environment and the current docker context
+func newDockerCli() (*command.DockerCli, error) {
 	dockerCli, err := command.NewDockerCli(command.WithStandardStreams())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new docker CLI with standard streams: %w", err)
@@ -199,7 +259,7 @@ func GetDockerClient() (client.APIClient, error) {
 		return nil, fmt.Errorf("failed to initialize docker CLI: %w", err)
 	}
 
-	return dockerCli.Client(), nil
+	return dockerCli, nil
 }
 
 // isAttachedToNetwork return true if node is attached to network
@@ -24,15 +24,21 @@ package docker
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/volume"
+	l "github.com/rancher/k3d/v5/pkg/logger"
 	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
+	"github.com/rancher/k3d/v5/pkg/tracing"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
 
 // CreateVolume creates a new named volume
-func (d Docker) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+func (d Docker) CreateVolume(ctx context.Context, name string, labels map[string]string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.CreateVolume", tracing.String("k3d.volume.name", name))
+	defer func() { span.End(err) }()
+
 	// (0) create new docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -63,7 +69,10 @@ func (d Docker) CreateVolume(ctx context.Context, name string, labels map[string
 }
 
 // DeleteVolume creates a new named volume
-func (d Docker) DeleteVolume(ctx context.Context, name string) error {
+func (d Docker) DeleteVolume(ctx context.Context, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.DeleteVolume", tracing.String("k3d.volume.name", name))
+	defer func() { span.End(err) }()
+
 	// (0) create new docker client
 	docker, err := GetDockerClient()
 	if err != nil {
@@ -93,9 +102,8 @@ func (d Docker) DeleteVolume(ctx context.Context, name string) error {
 }
 
 // GetVolume tries to get a named volume
-func (d Docker) GetVolume(name string) (string, error) {
+func (d Docker) GetVolume(ctx context.Context, name string) (string, error) {
 	// (0) create new docker client
-	ctx := context.Background()
 	docker, err := GetDockerClient()
 	if err != nil {
 		return "", fmt.Errorf("failed to get docker client: %w", err)
@@ -146,3 +154,34 @@ func (d Docker) GetVolumesByLabel(ctx context.Context, labels map[string]string)
 	return volumes, nil
 
 }
+
+func (d Docker) GetVolumesCreatedByLabel(ctx context.Context, labels map[string]string) (map[string]time.Time, error) {
+	docker, err := GetDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker client: %w", err)
+	}
+	defer docker.Close()
+
+	filters := filters.NewArgs()
+	for k, v := range k3d.DefaultRuntimeLabels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range labels {
+		filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	volumeList, err := docker.VolumeList(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("docker failed to list volumes: %w", err)
+	}
+
+	volumes := make(map[string]time.Time, len(volumeList.Volumes))
+	for _, v := range volumeList.Volumes {
+		created, err := time.Parse(time.RFC3339, v.CreatedAt)
+		if err != nil {
+			l.Log().Debugf("Failed to parse creation time '%s' of volume '%s': %v", v.CreatedAt, v.Name, err)
+		}
+		volumes[v.Name] = created
+	}
+	return volumes, nil
+}
@@ -29,6 +29,9 @@ var ErrRuntimeNetworkNotEmpty = errors.New("network not empty")
 // ErrRuntimeContainerUnknown describes the situation, where we're inspecting a container that's not obviously managed by k3d
 var ErrRuntimeContainerUnknown = errors.New("container not managed by k3d: missing default label(s)")
 
+// ErrRuntimeContainerNotExists describes the situation, where no container exists for the requested node
+var ErrRuntimeContainerNotExists = errors.New("container does not exist")
+
 // Runtime Network Errors
 var (
 	ErrRuntimeNetworkNotExists     = errors.New("network does not exist")
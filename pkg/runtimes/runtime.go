@@ -33,6 +33,7 @@ import (
 	"github.com/rancher/k3d/v5/pkg/runtimes/docker"
 	runtimeTypes "github.com/rancher/k3d/v5/pkg/runtimes/types"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"inet.af/netaddr"
 )
 
 // SelectedRuntime is a runtime (pun intended) variable determining the selected runtime
@@ -57,30 +58,44 @@ type Runtime interface {
 	GetNode(context.Context, *k3d.Node) (*k3d.Node, error)
 	GetNodeStatus(context.Context, *k3d.Node) (bool, string, error)
 	GetNodesInNetwork(context.Context, string) ([]*k3d.Node, error)
+	GetContainersByLabel(context.Context, map[string]string) ([]*runtimeTypes.Container, error)        // @param context, labels (empty value: any value) - @return containers (not necessarily k3d-managed), error
 	CreateNetworkIfNotPresent(context.Context, *k3d.ClusterNetwork) (*k3d.ClusterNetwork, bool, error) // @param context, name - @return NETWORK, EXISTS, ERROR
 	GetKubeconfig(context.Context, *k3d.Node) (io.ReadCloser, error)
 	DeleteNetwork(context.Context, string) error
-	StartNode(context.Context, *k3d.Node) error // starts an existing container
+	GetNetworksByLabel(context.Context, map[string]string) ([]string, error)                    // @param context, labels - @return network names, error
+	GetNetworksCreatedByLabel(context.Context, map[string]string) (map[string]time.Time, error) // @param context, labels - @return network name -> creation time, error
+	StartNode(context.Context, *k3d.Node) error                                                 // starts an existing container
 	StopNode(context.Context, *k3d.Node) error
+	PauseNode(context.Context, *k3d.Node) error   // freezes all processes in the container, keeping its state
+	UnpauseNode(context.Context, *k3d.Node) error // resumes a paused container
 	CreateVolume(context.Context, string, map[string]string) error
 	DeleteVolume(context.Context, string) error
-	GetVolume(string) (string, error)
-	GetVolumesByLabel(context.Context, map[string]string) ([]string, error) // @param context, labels - @return volumes, error
+	GetVolume(context.Context, string) (string, error)                                         // @param context, name - @return volume name, error
+	GetVolumesByLabel(context.Context, map[string]string) ([]string, error)                    // @param context, labels - @return volumes, error
+	GetVolumesCreatedByLabel(context.Context, map[string]string) (map[string]time.Time, error) // @param context, labels - @return volume name -> creation time (zero if unknown), error
 	GetImageStream(context.Context, []string) (io.ReadCloser, error)
-	GetRuntimePath() string // returns e.g. '/var/run/docker.sock' for a default docker setup
+	GetRuntimePath() string             // returns e.g. '/var/run/docker.sock' for a default docker setup
+	ValidateRuntimeOpts([]string) error // @param raw runtime opts (format `KEY=VALUE`) - @return error, if any of them isn't supported by the runtime
 	ExecInNode(context.Context, *k3d.Node, []string) error
 	ExecInNodeWithStdin(context.Context, *k3d.Node, []string, io.ReadCloser) error
 	ExecInNodeGetLogs(context.Context, *k3d.Node, []string) (*bufio.Reader, error)
+	ExecInNodeAttached(context.Context, *k3d.Node, []string, *runtimeTypes.ExecOpts) (int, error) // @param context, node, command, streams - @return exit code, error
 	GetNodeLogs(context.Context, *k3d.Node, time.Time, *runtimeTypes.NodeLogsOpts) (io.ReadCloser, error)
+	StreamNodeLogs(context.Context, *k3d.Node, time.Time, *runtimeTypes.NodeLogsOpts, io.Writer, io.Writer) error // @param context, node, since, opts, stdout, stderr
 	GetImages(context.Context) ([]string, error)
+	CommitNode(context.Context, *k3d.Node, string, []string) error             // @param context, node, image reference, changes (Dockerfile instructions)
+	BuildImage(context.Context, io.Reader, string) error                       // @param context, build context (tar), image tag
 	CopyToNode(context.Context, string, string, *k3d.Node) error               // @param context, source, destination, node
 	WriteToNode(context.Context, []byte, string, os.FileMode, *k3d.Node) error // @param context, content, destination, filemode, node
 	ReadFromNode(context.Context, string, *k3d.Node) (io.ReadCloser, error)    // @param context, filepath, node
+	ExportFromNode(context.Context, string, *k3d.Node) (io.ReadCloser, error)  // @param context, path (e.g. of a volume), node - @return tar stream of the path's content
+	ImportToNode(context.Context, io.Reader, string, *k3d.Node) error          // @param context, tar stream (as returned by ExportFromNode), path, node
 	GetHostIP(context.Context, string) (net.IP, error)
 	ConnectNodeToNetwork(context.Context, *k3d.Node, string) error      // @param context, node, network name
 	DisconnectNodeFromNetwork(context.Context, *k3d.Node, string) error // @param context, node, network name
 	Info() (*runtimeTypes.RuntimeInfo, error)
 	GetNetwork(context.Context, *k3d.ClusterNetwork) (*k3d.ClusterNetwork, error) // @param context, network (so we can filter by name or by id)
+	GetNetworkPrefixes(context.Context) (map[string][]netaddr.IPPrefix, error)    // @return network name -> subnet prefixes of all networks
 }
 
 // GetRuntime checks, if a given name is represented by an implemented k3d runtime and returns it
@@ -21,6 +21,8 @@ THE SOFTWARE.
 */
 package types
 
+import "io"
+
 type RuntimeInfo struct {
 	Name          string
 	Endpoint      string `yaml:",omitempty" json:",omitempty"`
@@ -31,8 +33,33 @@ type RuntimeInfo struct {
 	CgroupVersion string `yaml:",omitempty" json:",omitempty"`
 	CgroupDriver  string `yaml:",omitempty" json:",omitempty"`
 	Filesystem    string `yaml:",omitempty" json:",omitempty"`
+	CPUs          int    `yaml:",omitempty" json:",omitempty"`
+	Memory        int64  `yaml:",omitempty" json:",omitempty"` // total memory available to the runtime in bytes
+	DockerDesktop bool   `yaml:",omitempty" json:",omitempty"` // the runtime runs the containers in the VM of Docker Desktop
+}
+
+// Container describes a container that is not necessarily managed by k3d
+type Container struct {
+	ID       string
+	Name     string
+	Labels   map[string]string
+	Networks map[string]string // network name -> IP
+	Running  bool
 }
 
 type NodeLogsOpts struct {
-	Follow bool
+	Follow     bool
+	Tail       string // number of lines to show from the end of the logs ("all" or empty for all lines)
+	Timestamps bool
+}
+
+// ExecOpts describes how an exec process in a node is connected to the caller's streams
+type ExecOpts struct {
+	TTY    bool      // allocate a pseudo-TTY (stderr is merged into stdout then)
+	Width  uint      // initial width of the pseudo-TTY
+	Height uint      // initial height of the pseudo-TTY
+	Stdin  io.Reader // attached to the process' stdin, if not nil
+	Stdout io.Writer
+	Stderr io.Writer
+	Env    []string // additional environment variables (KEY=VALUE)
 }
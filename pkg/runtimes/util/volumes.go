@@ -23,15 +23,18 @@ package util
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	rt "runtime"
 	"strings"
 
 	"github.com/rancher/k3d/v5/pkg/runtimes"
 	runtimeErrors "github.com/rancher/k3d/v5/pkg/runtimes/errors"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/util"
 
 	l "github.com/rancher/k3d/v5/pkg/logger"
 )
@@ -52,12 +55,17 @@ func ValidateVolumeMount(ctx context.Context, runtime runtimes.Runtime, volumeMo
 			if _, err := os.Stat(src); err != nil {
 				l.Log().Warnf("failed to stat file/directory '%s' volume mount '%s': please make sure it exists", src, volumeMount)
 			}
+			if runtime.ID() == "docker" && (util.IsWindowsDrivePath(src) || util.IsUNCPath(src)) {
+				if err := checkDockerDesktopFileSharing(src); err != nil {
+					return fmt.Errorf("invalid volume mount '%s': %w", volumeMount, err)
+				}
+			}
 		} else {
-			err := verifyNamedVolume(runtime, src)
+			err := verifyNamedVolume(ctx, runtime, src)
 			if err != nil {
 				l.Log().Traceln(err)
 				if errors.Is(err, runtimeErrors.ErrRuntimeVolumeNotExists) {
-					if strings.HasPrefix(src, "k3d-") {
+					if strings.HasPrefix(src, k3d.ObjectNamePrefix+"-") {
 						if err := runtime.CreateVolume(ctx, src, map[string]string{k3d.LabelClusterName: cluster.Name}); err != nil {
 							return fmt.Errorf("failed to create named volume '%s': %v", src, err)
 						}
@@ -81,7 +89,12 @@ func ValidateVolumeMount(ctx context.Context, runtime runtimes.Runtime, volumeMo
 	return nil
 }
 
+// ReadVolumeMount splits a volume mount into its source and destination parts
 func ReadVolumeMount(volumeMount string) (string, string, error) {
+	return readVolumeMount(volumeMount, rt.GOOS)
+}
+
+func readVolumeMount(volumeMount string, goos string) (string, string, error) {
 	src := ""
 	dest := ""
 
@@ -89,10 +102,11 @@ func ReadVolumeMount(volumeMount string) (string, string, error) {
 	split := strings.Split(volumeMount, ":")
 	// a volume mapping can have 3 parts seperated by a ':' followed by a node filter
 	// [SOURCE:]DEST[:OPT[,OPT]][@NODEFILTER[;NODEFILTER...]]
-	// On Windows the source path needs to be an absolute path which means the path starts with
+	// On Windows the source path may be an absolute path which starts with
 	// a drive designator and will also have a ':' in it. So for Windows the maxParts is increased by one.
+	windowsDrive := goos == "windows" && len(split) >= 2 && util.IsWindowsDrivePath(split[0]+":"+split[1])
 	maxParts := 3
-	if rt.GOOS == "windows" {
+	if windowsDrive {
 		maxParts++
 	}
 	if len(split) < 1 {
@@ -103,13 +117,15 @@ func ReadVolumeMount(volumeMount string) (string, string, error) {
 	}
 
 	// we only have SRC specified -> DEST = SRC
-	// On windows the first part of the SRC is the drive letter, so we need to concat the first and second parts to get the path.
+	// On windows the first part of the SRC may be the drive letter, so we need to concat the first and second parts to get the path.
 	if len(split) == 1 {
 		src = split[0]
 		dest = src
-	} else if rt.GOOS == "windows" && len(split) >= 3 {
+	} else if windowsDrive {
 		src = split[0] + ":" + split[1]
-		dest = split[2]
+		if len(split) >= 3 {
+			dest = split[2]
+		}
 	} else {
 		src = split[0]
 		dest = split[1]
@@ -118,10 +134,64 @@ func ReadVolumeMount(volumeMount string) (string, string, error) {
 }
 
 // verifyNamedVolume checks whether a named volume exists in the runtime
-func verifyNamedVolume(runtime runtimes.Runtime, volumeName string) error {
-	_, err := runtime.GetVolume(volumeName)
+func verifyNamedVolume(ctx context.Context, runtime runtimes.Runtime, volumeName string) error {
+	_, err := runtime.GetVolume(ctx, volumeName)
 	if err != nil {
 		return fmt.Errorf("runtime failed to get volume '%s': %w", volumeName, err)
 	}
 	return nil
 }
+
+// dockerDesktopSettings holds the file sharing related parts of Docker Desktop's settings.json on Windows
+type dockerDesktopSettings struct {
+	WSLEngineEnabled       bool            `json:"wslEngineEnabled"`
+	SharedDrives           map[string]bool `json:"sharedDrives"`
+	FilesharingDirectories []string        `json:"filesharingDirectories"`
+}
+
+// checkDockerDesktopFileSharing verifies that a Windows host path can be mounted by Docker Desktop:
+// UNC paths are not supported at all and, with the Hyper-V backend, the drive (or directory) has to be shared in the settings.
+// If the Docker Desktop settings cannot be found (e.g. when not running on Windows), the check is skipped.
+func checkDockerDesktopFileSharing(src string) error {
+	if util.IsUNCPath(src) {
+		return fmt.Errorf("network (UNC) path '%s' cannot be mounted by Docker Desktop: map the share to a drive letter or copy the files to a local drive", src)
+	}
+
+	appData := os.Getenv("APPDATA")
+	if appData == "" {
+		return nil
+	}
+	settingsFile := filepath.Join(appData, "Docker", "settings.json")
+	content, err := os.ReadFile(settingsFile)
+	if err != nil {
+		l.Log().Tracef("Not checking Docker Desktop file sharing: failed to read %s: %v", settingsFile, err)
+		return nil
+	}
+	var settings dockerDesktopSettings
+	if err := json.Unmarshal(content, &settings); err != nil {
+		l.Log().Debugf("Not checking Docker Desktop file sharing: failed to parse %s: %v", settingsFile, err)
+		return nil
+	}
+
+	// the WSL 2 backend can access all local drives
+	if settings.WSLEngineEnabled {
+		return nil
+	}
+
+	drive := strings.ToUpper(src[:1])
+	if settings.SharedDrives[drive] {
+		return nil
+	}
+	normalizedSrc := strings.ToLower(util.TranslateWindowsPath(src))
+	for _, dir := range settings.FilesharingDirectories {
+		dir = strings.TrimSuffix(strings.ToLower(util.TranslateWindowsPath(dir)), "/")
+		if normalizedSrc == dir || strings.HasPrefix(normalizedSrc, dir+"/") {
+			return nil
+		}
+	}
+	if settings.SharedDrives == nil && settings.FilesharingDirectories == nil {
+		// nothing configured that we know of, so don't block the user
+		return nil
+	}
+	return fmt.Errorf("drive '%s:' is not shared with Docker Desktop: enable it in Docker Desktop > Settings > Resources > File Sharing", drive)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"testing"
+)
+
+func TestReadVolumeMount(t *testing.T) {
+	tests := []struct {
+		name   string
+		volume string
+		goos   string
+		src    string
+		dest   string
+		err    bool
+	}{
+		{name: "unix path", volume: "/tmp/data:/data", goos: "linux", src: "/tmp/data", dest: "/data"},
+		{name: "unix path with options", volume: "/tmp/data:/data:ro", goos: "linux", src: "/tmp/data", dest: "/data"},
+		{name: "dest only", volume: "/data", goos: "linux", src: "/data", dest: "/data"},
+		{name: "named volume single letter", volume: "c:/data", goos: "linux", src: "c", dest: "/data"},
+		{name: "windows drive path", volume: `C:\Users\me:/data`, goos: "windows", src: `C:\Users\me`, dest: "/data"},
+		{name: "windows drive path with options", volume: `C:\Users\me:/data:ro`, goos: "windows", src: `C:\Users\me`, dest: "/data"},
+		{name: "windows forward slashes", volume: "C:/Users/me:/data", goos: "windows", src: "C:/Users/me", dest: "/data"},
+		{name: "windows UNC path", volume: `\\server\share:/data`, goos: "windows", src: `\\server\share`, dest: "/data"},
+		{name: "windows named volume", volume: "k3d-vol:/data:ro", goos: "windows", src: "k3d-vol", dest: "/data"},
+		{name: "too many parts", volume: "/a:/b:ro:x", goos: "linux", err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src, dest, err := readVolumeMount(tc.volume, tc.goos)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error for '%s', got none", tc.volume)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if src != tc.src || dest != tc.dest {
+				t.Errorf("expected (%s, %s), got (%s, %s)", tc.src, tc.dest, src, dest)
+			}
+		})
+	}
+}
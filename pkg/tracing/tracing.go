@@ -0,0 +1,111 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+// Package tracing instruments k3d operations with spans. Like the OpenTelemetry API, it only describes the operations:
+// spans are recorded by the global TracerProvider, which is a no-op unless the program using k3d sets one up
+// (e.g. the k3d CLI, which exports spans via OTLP, or a program bridging them into its own OpenTelemetry setup).
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Attribute is a key-value pair describing a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// String creates a new attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a single timed operation, part of a trace
+type Span interface {
+	// SetAttributes adds attributes to the span
+	SetAttributes(attributes ...Attribute)
+	// End finishes the span, marking it as failed, if err is not nil
+	End(err error)
+	// TraceParent returns the W3C trace context of the span (empty if it's not recorded), e.g. to pass it on to other tools
+	TraceParent() string
+}
+
+// TracerProvider starts spans as children of the span in the given context
+type TracerProvider interface {
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+type spanContextKey struct{}
+
+var (
+	mutex    sync.RWMutex
+	provider TracerProvider = noopTracerProvider{}
+)
+
+// SetTracerProvider sets the global TracerProvider used by Start (nil disables tracing)
+func SetTracerProvider(tp TracerProvider) {
+	if tp == nil {
+		tp = noopTracerProvider{}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	provider = tp
+}
+
+// GetTracerProvider returns the global TracerProvider
+func GetTracerProvider() TracerProvider {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return provider
+}
+
+// Start starts a new span via the global TracerProvider as child of the span in the context.
+// The returned span is never nil, so it's always safe to use.
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	return GetTracerProvider().Start(ctx, name, attributes...)
+}
+
+// ContextWithSpan returns a copy of the context holding the span, so that spans started with it become its children
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span held by the context (a no-op span, if there is none)
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanContextKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+type noopTracerProvider struct{}
+
+func (noopTracerProvider) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) End(error)                  {}
+func (noopSpan) TraceParent() string        { return "" }
@@ -0,0 +1,74 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+type testSpan struct {
+	name  string
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(...Attribute) {}
+func (s *testSpan) End(error)                  { s.ended = true }
+func (s *testSpan) TraceParent() string        { return "" }
+
+type testTracerProvider struct {
+	spans []*testSpan
+}
+
+func (p *testTracerProvider) Start(ctx context.Context, name string, _ ...Attribute) (context.Context, Span) {
+	span := &testSpan{name: name}
+	p.spans = append(p.spans, span)
+	return ContextWithSpan(ctx, span), span
+}
+
+func TestStartNoop(t *testing.T) {
+	SetTracerProvider(nil)
+	ctx, span := Start(context.Background(), "noop")
+	span.SetAttributes(String("a", "b"))
+	span.End(nil)
+	if span.TraceParent() != "" {
+		t.Errorf("expected no trace context without TracerProvider, got '%s'", span.TraceParent())
+	}
+	if _, ok := SpanFromContext(ctx).(noopSpan); !ok {
+		t.Errorf("expected no span in the context, got %+v", SpanFromContext(ctx))
+	}
+}
+
+func TestStartGlobalTracerProvider(t *testing.T) {
+	provider := &testTracerProvider{}
+	SetTracerProvider(provider)
+	defer SetTracerProvider(nil)
+
+	ctx, span := Start(context.Background(), "ClusterCreate")
+	if SpanFromContext(ctx) != span {
+		t.Errorf("expected the span to be held by the context")
+	}
+	span.End(nil)
+	if len(provider.spans) != 1 || provider.spans[0].name != "ClusterCreate" || !provider.spans[0].ended {
+		t.Errorf("unexpected spans: %+v", provider.spans)
+	}
+}
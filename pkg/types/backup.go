@@ -0,0 +1,83 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package types
+
+import "time"
+
+// ClusterBackupVersion is the version of the cluster backup format
+const ClusterBackupVersion = "v1"
+
+// Files and directories in a cluster backup archive
+const (
+	ClusterBackupManifestFile   = "manifest.yaml"
+	ClusterBackupKubeconfigFile = "kubeconfig.yaml"
+	ClusterBackupNodesDir       = "nodes"
+)
+
+// ClusterBackupNodePaths are the paths in server and agent nodes that hold their state:
+// the k3s data volume and the node password that the node registered with
+var ClusterBackupNodePaths = []string{"/var/lib/rancher/k3s", "/etc/rancher/node"}
+
+// ClusterBackup is the manifest of a cluster backup, describing the cluster so that it can be reconstructed
+type ClusterBackup struct {
+	Version      string               `yaml:"version" json:"version"`
+	Created      time.Time            `yaml:"created" json:"created"`
+	K3dVersion   string               `yaml:"k3dVersion" json:"k3dVersion"`
+	Cluster      string               `yaml:"cluster" json:"cluster"`
+	Token        string               `yaml:"token" json:"token"`
+	Network      ClusterBackupNetwork `yaml:"network" json:"network"`
+	KubeAPI      ClusterBackupKubeAPI `yaml:"kubeAPI" json:"kubeAPI"`
+	Loadbalancer bool                 `yaml:"loadbalancer" json:"loadbalancer"`
+	Nodes        []ClusterBackupNode  `yaml:"nodes" json:"nodes"`
+}
+
+// ClusterBackupNetwork describes the cluster network in a backup
+type ClusterBackupNetwork struct {
+	Name     string `yaml:"name" json:"name"`
+	External bool   `yaml:"external,omitempty" json:"external,omitempty"`
+	Subnet   string `yaml:"subnet,omitempty" json:"subnet,omitempty"`
+}
+
+// ClusterBackupKubeAPI describes how the Kubernetes API was exposed on the host
+type ClusterBackupKubeAPI struct {
+	Host     string `yaml:"host,omitempty" json:"host,omitempty"`
+	HostIP   string `yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
+	HostPort string `yaml:"hostPort,omitempty" json:"hostPort,omitempty"`
+}
+
+// ClusterBackupNode describes a node in a backup
+type ClusterBackupNode struct {
+	Name    string                  `yaml:"name" json:"name"`
+	Role    Role                    `yaml:"role" json:"role"`
+	Image   string                  `yaml:"image" json:"image"`
+	Args    []string                `yaml:"args,omitempty" json:"args,omitempty"`       // k3s args
+	Env     []string                `yaml:"env,omitempty" json:"env,omitempty"`         // KEY=VALUE
+	Volumes []string                `yaml:"volumes,omitempty" json:"volumes,omitempty"` // SRC:DEST[:OPTS]
+	Ports   []string                `yaml:"ports,omitempty" json:"ports,omitempty"`     // [HOSTIP:]HOSTPORT:CONTAINERPORT/PROTOCOL
+	Data    []ClusterBackupNodeData `yaml:"data,omitempty" json:"data,omitempty"`
+}
+
+// ClusterBackupNodeData references the archived content of a path in a node
+type ClusterBackupNodeData struct {
+	Path string `yaml:"path" json:"path"` // path in the node
+	File string `yaml:"file" json:"file"` // tar archive in the backup
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package types
+
+import "sort"
+
+// CheckProfile describes the host kernel requirements of a workload stack that are verified in the preflight checks (--check-profile)
+type CheckProfile struct {
+	Description string
+	Modules     [][]string        // kernel modules (loaded or built-in), each entry is a list of alternatives of which one must be present
+	Sysctls     map[string]string // sysctl key (dotted) -> required value
+	Paths       map[string]string // host path that must exist -> remediation hint
+}
+
+// CheckProfiles defines the available preflight check profiles
+var CheckProfiles = map[string]CheckProfile{
+	"istio": {
+		Description: "Istio sidecar traffic redirection (iptables)",
+		Modules: [][]string{
+			{"br_netfilter"},
+			{"ip_tables"},
+			{"iptable_nat"},
+			{"iptable_mangle"},
+			{"xt_REDIRECT"},
+			{"xt_owner"},
+			{"xt_conntrack"},
+			{"xt_tcpudp"},
+		},
+		Sysctls: map[string]string{
+			"net.bridge.bridge-nf-call-iptables": "1",
+		},
+	},
+	"cilium": {
+		Description: "Cilium eBPF networking",
+		Modules: [][]string{
+			{"cls_bpf"},
+			{"sch_ingress"},
+			{"vxlan"},
+			{"xt_socket"},
+		},
+		Sysctls: map[string]string{
+			"net.core.bpf_jit_enable": "1",
+		},
+		Paths: map[string]string{
+			"/sys/fs/bpf": "mount the BPF filesystem: `sudo mount bpffs /sys/fs/bpf -t bpf`",
+		},
+	},
+	"ipvs": {
+		Description: "kube-proxy in IPVS mode",
+		Modules: [][]string{
+			{"ip_vs"},
+			{"ip_vs_rr"},
+			{"ip_vs_wrr"},
+			{"ip_vs_sh"},
+			{"nf_conntrack", "nf_conntrack_ipv4"},
+		},
+	},
+	"kubevirt": {
+		Description: "KubeVirt hardware virtualization",
+		Modules: [][]string{
+			{"kvm"},
+			{"kvm_intel", "kvm_amd"},
+			{"vhost_net"},
+			{"tun"},
+		},
+		Paths: map[string]string{
+			"/dev/kvm": "enable hardware virtualization (VT-x/AMD-V) in the firmware settings or nested virtualization on your VM",
+		},
+	},
+}
+
+// CheckProfileNames returns the sorted names of the available preflight check profiles
+func CheckProfileNames() []string {
+	names := make([]string, 0, len(CheckProfiles))
+	for name := range CheckProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package types
+
+// DefaultCoreDNSCustomConfigMap is the name of the optional ConfigMap in kube-system, whose *.server and *.override keys the CoreDNS deployed by k3s imports
+const DefaultCoreDNSCustomConfigMap = "coredns-custom"
+
+// DefaultCoreDNSCustomManifestName is the file name of the coredns-custom ConfigMap manifest in the manifests directory of the servers
+// (sorts before k3s' coredns.yaml, so the ConfigMap exists before CoreDNS starts)
+const DefaultCoreDNSCustomManifestName = "coredns-custom.yaml"
+
+// CoreDNSCustom is a custom CoreDNS configuration, which is injected into the cluster on creation
+type CoreDNSCustom struct {
+	Hosts       []string            `yaml:"hosts,omitempty" json:"hosts,omitempty"`             // static records in /etc/hosts format (IP HOSTNAME [HOSTNAME...]), e.g. for services on the host
+	StubDomains map[string][]string `yaml:"stubDomains,omitempty" json:"stubDomains,omitempty"` // domains forwarded to dedicated nameservers (IP[:PORT])
+	Rewrites    []string            `yaml:"rewrites,omitempty" json:"rewrites,omitempty"`       // rules of the CoreDNS rewrite plugin, e.g. 'name git.example.com gitea.default.svc.cluster.local'
+	Override    string              `yaml:"override,omitempty" json:"override,omitempty"`       // raw plugin configuration added to the default server block
+	Server      string              `yaml:"server,omitempty" json:"server,omitempty"`           // raw additional server blocks
+}
@@ -23,6 +23,8 @@ package types
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/rancher/k3d/v5/pkg/types/k3s"
 	"github.com/rancher/k3d/v5/version"
@@ -33,13 +35,25 @@ const DefaultClusterName = "k3s-default"
 
 // DefaultClusterNameMaxLength specifies the maximal length of a passed in cluster name
 // This restriction allows us to construct an name consisting of
-// <DefaultObjectNamePrefix[3]>-<ClusterName>-<TypeSuffix[5-10]>-<Counter[1-3]>
+// <ObjectNamePrefix[3]>-<ClusterName>-<TypeSuffix[5-10]>-<Counter[1-3]>
 // ... and still stay within the 64 character limit (e.g. of docker)
 const DefaultClusterNameMaxLength = 32
 
-// DefaultObjectNamePrefix defines the name prefix for every object created by k3d
+// DefaultObjectNamePrefix defines the default name prefix for every object created by k3d
 const DefaultObjectNamePrefix = "k3d"
 
+// ObjectNamePrefix is the name prefix used for the objects (containers, networks, volumes) and kubeconfig contexts created by k3d.
+// It defaults to DefaultObjectNamePrefix and can be changed via $K3D_NAME_PREFIX (or --name-prefix), e.g. to avoid name collisions
+// with other tools or forks running side-by-side. Library consumers may set it directly, before creating or looking up any objects.
+var ObjectNamePrefix = objectNamePrefixFromEnv()
+
+func objectNamePrefixFromEnv() string {
+	if prefix := os.Getenv(K3dEnvNamePrefix); prefix != "" {
+		return prefix
+	}
+	return DefaultObjectNamePrefix
+}
+
 // DefaultRuntimeLabels specifies a set of labels that will be attached to k3d runtime objects by default
 var DefaultRuntimeLabels = map[string]string{
 	"app": "k3d",
@@ -62,6 +76,19 @@ var DefaultTmpfsMounts = []string{
 	"/var/run",
 }
 
+// DefaultNodeTmpfsRootPath is the path inside k3s nodes that gets backed by tmpfs when using --node-tmpfs-root
+const DefaultNodeTmpfsRootPath = "/var/lib/rancher"
+
+// DefaultNodeDataPaths are the paths inside k3s nodes that get bind-mounted from per-node host directories when using --servers-data/--agents-data,
+// mapped to the names of the subdirectories: the k3s data dir and the node password, which the servers expect to be the same on re-registration
+var DefaultNodeDataPaths = map[string]string{
+	"k3s":  "/var/lib/rancher/k3s",
+	"node": "/etc/rancher/node",
+}
+
+// DefaultNodeDataTokenFile is the file in the --servers-data directory, which the cluster token is persisted in, as the persisted server data is encrypted with it
+const DefaultNodeDataTokenFile = "token"
+
 // DefaultNodeEnv defines some default environment variables that should be set on every node
 var DefaultNodeEnv = []string{
 	fmt.Sprintf("%s=/output/kubeconfig.yaml", k3s.EnvKubeconfigOutput),
@@ -76,18 +103,38 @@ const DefaultImageVolumeMountPath = "/k3d/images"
 // DefaultConfigDirName defines the name of the config directory (where we'll e.g. put the kubeconfigs)
 const DefaultConfigDirName = ".k3d" // should end up in $HOME/
 
-// DefaultKubeconfigPrefix defines the default prefix for kubeconfig files
-const DefaultKubeconfigPrefix = DefaultObjectNamePrefix + "-kubeconfig"
-
 // DefaultAPIPort defines the default Kubernetes API Port
 const DefaultAPIPort = "6443"
 
+// DefaultClusterHealthProbeTimeout is the maximum time to wait for the Kubernetes API of a cluster to answer a health probe
+const DefaultClusterHealthProbeTimeout = 2 * time.Second
+
+// DefaultExternalIDCheckTimeout is the maximum time a command or URL may take to report whether the external resource of a cluster is gone
+const DefaultExternalIDCheckTimeout = 30 * time.Second
+
+// NodeOSLinux and NodeOSWindows are the operating systems a node can have: linux nodes are regular k3s nodes,
+// while windows nodes are simulated by k3s agents labeled and tainted like Windows workers, e.g. for scheduling tests
+const (
+	NodeOSLinux   = "linux"
+	NodeOSWindows = "windows"
+)
+
+// SimulatedWindowsNodeLabels are the k3s node labels of a simulated Windows node, as set by the kubelet on real Windows workers
+var SimulatedWindowsNodeLabels = map[string]string{
+	"kubernetes.io/os":                 NodeOSWindows,
+	"beta.kubernetes.io/os":            NodeOSWindows,
+	"node.kubernetes.io/windows-build": "10.0.17763", // Windows Server 2019 (LTSC)
+}
+
+// SimulatedWindowsNodeTaint is the taint of a simulated Windows node, as commonly used to keep Linux workloads off Windows workers
+const SimulatedWindowsNodeTaint = "os=windows:NoSchedule"
+
 // DefaultAPIHost defines the default host (IP) for the Kubernetes API
 const DefaultAPIHost = "0.0.0.0"
 
 // GetDefaultObjectName prefixes the passed name with the default prefix
 func GetDefaultObjectName(name string) string {
-	return fmt.Sprintf("%s-%s", DefaultObjectNamePrefix, name)
+	return fmt.Sprintf("%s-%s", ObjectNamePrefix, name)
 }
 
 // DefaultNodeWaitForLogMessageCrashLoopBackOffLimit defines the maximum number of retries to find the target log message, if the
@@ -95,5 +142,67 @@ func GetDefaultObjectName(name string) string {
 // This makes sense e.g. when a new server is waiting to join an existing cluster and has to wait for other learners to finish.
 const DefaultNodeWaitForLogMessageCrashLoopBackOffLimit = 10
 
+// DefaultNodeWaitForLogMessageCrashLoopBackOffInitial is the time to wait before following the logs of a crash-looping node again.
+// It doubles with every retry, up to DefaultNodeWaitForLogMessageCrashLoopBackOffMax, to give slow environments (e.g. NFS or
+// overlayfs on slow disks) more time to recover.
+const DefaultNodeWaitForLogMessageCrashLoopBackOffInitial = 500 * time.Millisecond
+
+// DefaultNodeWaitForLogMessageCrashLoopBackOffMax caps the time to wait between retries to follow the logs of a crash-looping node
+const DefaultNodeWaitForLogMessageCrashLoopBackOffMax = 30 * time.Second
+
 // DefaultNetwork defines the default (Docker) runtime network
 const DefaultRuntimeNetwork = "bridge"
+
+// DefaultNodeSyncInterval defines the default polling interval used when synchronizing host directories into nodes
+const DefaultNodeSyncInterval = 2 * time.Second
+
+// Rough estimates of the resources that k3d nodes need to become ready, used to warn about undersized runtimes (e.g. Docker Desktop VMs)
+const (
+	DefaultResourceEstimateBaseMemory   int64   = 256 * 1024 * 1024 // loadbalancer, tools node & runtime overhead
+	DefaultResourceEstimateServerMemory int64   = 768 * 1024 * 1024
+	DefaultResourceEstimateAgentMemory  int64   = 384 * 1024 * 1024
+	DefaultResourceEstimateServerCPUs   float64 = 1
+	DefaultResourceEstimateAgentCPUs    float64 = 0.5
+)
+
+// Inotify limits: k3d checks the host limits against the per-node minimums and
+// raises them to the recommended values from inside the nodes if the inotify fix is enabled
+const (
+	DefaultInotifyMaxUserWatches       = 524288
+	DefaultInotifyMaxUserInstances     = 512
+	DefaultInotifyMinWatchesPerNode    = 8192
+	DefaultInotifyMinInstancesPerNode  = 64
+	DefaultInotifyMaxUserWatchesPath   = "/proc/sys/fs/inotify/max_user_watches"
+	DefaultInotifyMaxUserInstancesPath = "/proc/sys/fs/inotify/max_user_instances"
+)
+
+// DefaultClockSkewThreshold defines the maximum tolerated difference between the local clock and the clock inside the nodes
+// (e.g. after the Docker Desktop VM was suspended) before we consider it skewed, as certificates may appear not-yet-valid then
+const DefaultClockSkewThreshold = 5 * time.Second
+
+// DefaultWatchInterval defines the default time between two checks of the cluster watcher
+const DefaultWatchInterval = 10 * time.Second
+
+// DefaultK3sChannelCacheTTL defines how long the release channels fetched from the k3s channel server are cached
+const DefaultK3sChannelCacheTTL = time.Hour
+
+// DefaultWatchMaxRestarts defines how often the cluster watcher tries to restart a node in a row before giving up on it
+const DefaultWatchMaxRestarts = 5
+
+// Labels set by docker compose on the containers of a compose project
+const (
+	ComposeLabelProject = "com.docker.compose.project"
+	ComposeLabelService = "com.docker.compose.service"
+)
+
+// DefaultHookManifestTempPath is the temporary path of a manifest applied by a cluster hook in the server node
+const DefaultHookManifestTempPath = "/tmp/k3d-hook-manifest.yaml"
+
+// TimezoneHost is the value of --timezone that propagates the timezone of the host into the nodes
+const TimezoneHost = "host"
+
+// DefaultLocaltimePath is the path of the local timezone (TZif file), both on the host and in the nodes
+const DefaultLocaltimePath = "/etc/localtime"
+
+// DefaultOrphansMinAge is the minimum age of resources considered orphaned by `k3d cluster prune --orphans`, so that cluster creations in progress are left alone
+const DefaultOrphansMinAge = time.Hour
@@ -36,7 +36,31 @@ const (
 	K3dEnvDebugDisableDockerInit    = "K3D_DEBUG_DISABLE_DOCKER_INIT"
 	K3dEnvDebugNodeWaitBackOffLimit = "K3D_DEBUG_NODE_WAIT_BACKOFF_LIMIT"
 
+	// Networking
+	K3dEnvDefaultBindAddress = "K3D_DEFAULT_BIND_ADDRESS"
+
+	// Naming
+	K3dEnvNamePrefix = "K3D_NAME_PREFIX"
+
 	// Fixes
 	K3dEnvFixCgroupV2 = "K3D_FIX_CGROUPV2"
 	K3dEnvFixDNS      = "K3D_FIX_DNS"
+	K3dEnvFixInotify  = "K3D_FIX_INOTIFY"
+)
+
+// Environment variables describing a cluster, as printed by `k3d env` and written by `k3d cluster create --env-file`
+const (
+	EnvClusterKubeconfig        = "KUBECONFIG"
+	EnvClusterName              = "K3D_CLUSTER"
+	EnvClusterContext           = "K3D_CONTEXT"
+	EnvClusterAPIEndpoint       = "K3D_API_ENDPOINT"
+	EnvClusterLoadbalancerPorts = "K3D_LB_PORTS"
+	EnvClusterRegistry          = "K3D_REGISTRY"
+	EnvClusterDomain            = "K3D_CLUSTER_DOMAIN"
 )
+
+// EnvVar is a single environment variable
+type EnvVar struct {
+	Key   string
+	Value string
+}
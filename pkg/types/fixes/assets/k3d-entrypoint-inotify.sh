@@ -0,0 +1,23 @@
+#!/bin/sh
+
+set -o errexit
+set -o nounset
+
+# Nodes are privileged containers and fs.inotify.* is not namespaced,
+# so this raises the limits of the (VM) kernel that's running the containers
+raise() {
+  file="$1"
+  target="$2"
+  current="$(cat "$file")"
+  if [ "$current" -lt "$target" ]; then
+    echo "[$(date -Iseconds)] [Inotify Fix] > Raising $file from $current to $target"
+    echo "$target" > "$file"
+  fi
+}
+
+echo "[$(date -Iseconds)] [Inotify Fix] Ensuring inotify limits"
+
+raise /proc/sys/fs/inotify/max_user_watches MAX_USER_WATCHES # replaced within k3d Go code
+raise /proc/sys/fs/inotify/max_user_instances MAX_USER_INSTANCES # replaced within k3d Go code
+
+echo "[$(date -Iseconds)] [Inotify Fix] Done"
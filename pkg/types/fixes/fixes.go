@@ -44,11 +44,13 @@ type K3DFixEnv string
 const (
 	EnvFixCgroupV2 K3DFixEnv = k3d.K3dEnvFixCgroupV2 // EnvFixCgroupV2 is the environment variable that k3d will check for to enable/disable the cgroupv2 workaround
 	EnvFixDNS      K3DFixEnv = k3d.K3dEnvFixDNS      // EnvFixDNS is the environment variable that check for to enable/disable the application of network magic related to DNS
+	EnvFixInotify  K3DFixEnv = k3d.K3dEnvFixInotify  // EnvFixInotify is the environment variable that k3d will check for to enable/disable raising the inotify limits from inside the (privileged) nodes
 )
 
 var FixEnvs []K3DFixEnv = []K3DFixEnv{
 	EnvFixCgroupV2,
 	EnvFixDNS,
+	EnvFixInotify,
 }
 
 //go:embed assets/k3d-entrypoint-cgroupv2.sh
@@ -57,6 +59,9 @@ var CgroupV2Entrypoint []byte
 //go:embed assets/k3d-entrypoint-dns.sh
 var DNSMagicEntrypoint []byte
 
+//go:embed assets/k3d-entrypoint-inotify.sh
+var InotifyEntrypoint []byte
+
 //go:embed assets/k3d-entrypoint.sh
 var K3DEntrypoint []byte
 
@@ -68,6 +73,30 @@ func FixEnabled(fixenv K3DFixEnv) bool {
 	return enabled
 }
 
+// fixNodeLabels are the runtime labels enabling a fix for single nodes, e.g. via the cluster create options, in addition to its environment variable
+var fixNodeLabels = map[K3DFixEnv]string{
+	EnvFixInotify: k3d.LabelNodeFixInotify,
+}
+
+// FixEnabledForNode checks if the fix is enabled via its environment variable or the node's runtime labels
+func FixEnabledForNode(fixenv K3DFixEnv, node *k3d.Node) bool {
+	if FixEnabled(fixenv) {
+		return true
+	}
+	label, ok := fixNodeLabels[fixenv]
+	return ok && node.RuntimeLabels[label] == "true"
+}
+
+// FixEnabledAnyForNode checks if any fix is enabled for the node (see FixEnabledForNode)
+func FixEnabledAnyForNode(node *k3d.Node) bool {
+	for _, fixenv := range FixEnvs {
+		if FixEnabledForNode(fixenv, node) {
+			return true
+		}
+	}
+	return false
+}
+
 func FixEnabledAny() bool {
 	for _, fixenv := range FixEnvs {
 		if FixEnabled(fixenv) {
@@ -0,0 +1,150 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package k3s
+
+// FlagsKnownMinorVersion is the latest Kubernetes/k3s minor version (v1.<minor>) that the embedded flag metadata below covers
+const FlagsKnownMinorVersion = 22
+
+// FlagsCommon lists the (long) names of flags accepted by both `k3s server` and `k3s agent`, including short aliases and deprecated/hidden flags.
+// Source: `k3s server --help` and `k3s agent --help` up to FlagsKnownMinorVersion
+var FlagsCommon = []string{
+	// global
+	"config", "c",
+	"debug",
+	"v", "vmodule", "log", "l", "alsologtostderr",
+	// cluster
+	"token", "t",
+	"token-file",
+	"server", "s",
+	"data-dir", "d",
+	// agent/node
+	"node-name",
+	"with-node-id",
+	"node-label",
+	"node-taint",
+	"image-credential-provider-bin-dir",
+	"image-credential-provider-config",
+	// agent/runtime
+	"docker",
+	"container-runtime-endpoint",
+	"pause-image",
+	"snapshotter",
+	"private-registry",
+	// agent/networking
+	"node-ip", "i",
+	"node-external-ip",
+	"resolv-conf",
+	"flannel-iface",
+	"flannel-conf",
+	// agent/flags
+	"kubelet-arg",
+	"kube-proxy-arg",
+	"protect-kernel-defaults",
+	// experimental
+	"rootless",
+	"selinux",
+	"lb-server-port",
+	// deprecated/hidden
+	"no-flannel",
+	"cluster-secret",
+	"disable-selinux",
+}
+
+// FlagsServerOnly lists the (long) names of flags accepted only by `k3s server`
+var FlagsServerOnly = []string{
+	// listener
+	"bind-address",
+	"https-listen-port",
+	"advertise-address",
+	"advertise-port",
+	"tls-san",
+	// networking
+	"cluster-cidr",
+	"service-cidr",
+	"service-node-port-range",
+	"cluster-dns",
+	"cluster-domain",
+	"flannel-backend",
+	"egress-selector-mode",
+	// client
+	"write-kubeconfig", "o",
+	"write-kubeconfig-mode",
+	// cluster
+	"agent-token",
+	"agent-token-file",
+	"cluster-init",
+	"cluster-reset",
+	"cluster-reset-restore-path",
+	// flags
+	"kube-apiserver-arg",
+	"etcd-arg",
+	"kube-controller-manager-arg",
+	"kube-scheduler-arg",
+	"kube-cloud-controller-manager-arg",
+	// database
+	"datastore-endpoint",
+	"datastore-cafile",
+	"datastore-certfile",
+	"datastore-keyfile",
+	"etcd-expose-metrics",
+	"etcd-disable-snapshots",
+	"etcd-snapshot-name",
+	"etcd-snapshot-schedule-cron",
+	"etcd-snapshot-retention",
+	"etcd-snapshot-dir",
+	"etcd-s3",
+	"etcd-s3-endpoint",
+	"etcd-s3-endpoint-ca",
+	"etcd-s3-skip-ssl-verify",
+	"etcd-s3-access-key",
+	"etcd-s3-secret-key",
+	"etcd-s3-bucket",
+	"etcd-s3-region",
+	"etcd-s3-folder",
+	// storage
+	"default-local-storage-path",
+	// components
+	"disable",
+	"disable-scheduler",
+	"disable-cloud-controller",
+	"disable-kube-proxy",
+	"disable-network-policy",
+	"disable-helm-controller",
+	"disable-apiserver",
+	"disable-controller-manager",
+	"disable-etcd",
+	// security
+	"secrets-encryption",
+	"system-default-registry",
+	// experimental/deprecated/hidden
+	"no-deploy",
+	"disable-agent",
+	"enable-pprof",
+	"airgap-extra-registry",
+}
+
+// defaults of k3s' networking flags
+const (
+	DefaultClusterCIDR   string = "10.42.0.0/16"  // --cluster-cidr (pod IPs)
+	DefaultServiceCIDR   string = "10.43.0.0/16"  // --service-cidr (service IPs)
+	DefaultClusterDomain string = "cluster.local" // --cluster-domain
+)
@@ -28,6 +28,7 @@ const (
 	K3sPathContainerdConfig     = "/var/lib/rancher/k3s/agent/etc/containerd/config.toml"
 	K3sPathContainerdConfigTmpl = "/var/lib/rancher/k3s/agent/etc/containerd/config.toml.tmpl"
 	K3sPathRegistryConfig       = "/etc/rancher/k3s/registries.yaml"
+	K3sPathAirgapImages         = "/var/lib/rancher/k3s/agent/images" // image archives imported by k3s on startup
 )
 
 var K3sPathShortcuts = map[string]string{
@@ -37,4 +38,5 @@ var K3sPathShortcuts = map[string]string{
 	"k3s-containerd":       K3sPathContainerdConfig,
 	"k3s-containerd-tmpl":  K3sPathContainerdConfigTmpl,
 	"k3s-registry-config":  K3sPathRegistryConfig,
+	"k3s-airgap-images":    K3sPathAirgapImages,
 }
@@ -0,0 +1,72 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package types
+
+// KubeconfigCredentialStore is where the credentials of a kubeconfig are stored encrypted instead of in the kubeconfig file itself
+type KubeconfigCredentialStore string
+
+// Supported kubeconfig credential stores
+const (
+	KubeconfigCredentialStoreKeychain KubeconfigCredentialStore = "keychain" // OS keychain (macOS Keychain via `security`, Secret Service via `secret-tool` on Linux)
+	KubeconfigCredentialStoreAge      KubeconfigCredentialStore = "age"      // file encrypted via `age`
+	KubeconfigCredentialStoreSops     KubeconfigCredentialStore = "sops"     // file encrypted via `sops`
+)
+
+// KubeconfigCredentialStores are all supported kubeconfig credential stores
+var KubeconfigCredentialStores = []KubeconfigCredentialStore{
+	KubeconfigCredentialStoreKeychain,
+	KubeconfigCredentialStoreAge,
+	KubeconfigCredentialStoreSops,
+}
+
+// KubeconfigEncryption describes how to store the credentials of a kubeconfig encrypted
+type KubeconfigEncryption struct {
+	Store      KubeconfigCredentialStore `mapstructure:"store" yaml:"store,omitempty" json:"store,omitempty"`
+	Recipients []string                  `mapstructure:"recipients" yaml:"recipients,omitempty" json:"recipients,omitempty"` // age recipients (age, sops)
+	Identity   string                    `mapstructure:"identity" yaml:"identity,omitempty" json:"identity,omitempty"`       // age identity file used for decryption (age)
+}
+
+// KubeconfigCredentialKeychainService is the service name of kubeconfig credentials in the OS keychain
+const KubeconfigCredentialKeychainService = "k3d-kubeconfig"
+
+// KubeconfigRole is the level of access granted by a kubeconfig written by k3d
+type KubeconfigRole string
+
+// Supported kubeconfig roles
+const (
+	KubeconfigRoleAdmin KubeconfigRole = "admin" // cluster-admin (k3s' admin client certificate)
+	KubeconfigRoleEdit  KubeconfigRole = "edit"  // ServiceAccount bound to the 'edit' ClusterRole
+	KubeconfigRoleView  KubeconfigRole = "view"  // ServiceAccount bound to the 'view' ClusterRole
+)
+
+// KubeconfigRoles are all supported kubeconfig roles
+var KubeconfigRoles = []KubeconfigRole{
+	KubeconfigRoleAdmin,
+	KubeconfigRoleEdit,
+	KubeconfigRoleView,
+}
+
+// KubeconfigRoleServiceAccountNamespace is the namespace of the ServiceAccounts provisioned for non-admin kubeconfigs
+const KubeconfigRoleServiceAccountNamespace = "kube-system"
+
+// DefaultKubeconfigRoleManifestTempPath is the temporary path of the manifest provisioning the ServiceAccount for a non-admin kubeconfig in the server node
+const DefaultKubeconfigRoleManifestTempPath = "/tmp/k3d-kubeconfig-role.yaml"
@@ -0,0 +1,37 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package types
+
+// KubeletEvictionSignals are the eviction signals supported by the kubelet on Linux nodes
+var KubeletEvictionSignals = []string{"memory.available", "nodefs.available", "nodefs.inodesFree", "imagefs.available", "imagefs.inodesFree", "pid.available"}
+
+// KubeletConfig is a kubelet configuration, which k3d passes to the kubelet of the matching nodes as kubelet args
+// (k3s sets some kubelet flags itself, e.g. eviction-hard, which would take precedence over a kubelet config file)
+type KubeletConfig struct {
+	MaxPods                 int               `mapstructure:"maxPods" yaml:"maxPods,omitempty" json:"maxPods,omitempty"`
+	EvictionHard            map[string]string `mapstructure:"evictionHard" yaml:"evictionHard,omitempty" json:"evictionHard,omitempty"`                                  // signal -> threshold, e.g. memory.available: 100Mi
+	EvictionSoft            map[string]string `mapstructure:"evictionSoft" yaml:"evictionSoft,omitempty" json:"evictionSoft,omitempty"`                                  // signal -> threshold, requires a grace period per signal
+	EvictionSoftGracePeriod map[string]string `mapstructure:"evictionSoftGracePeriod" yaml:"evictionSoftGracePeriod,omitempty" json:"evictionSoftGracePeriod,omitempty"` // signal -> duration, e.g. memory.available: 1m30s
+	FeatureGates            map[string]bool   `mapstructure:"featureGates" yaml:"featureGates,omitempty" json:"featureGates,omitempty"`
+	Args                    []string          `mapstructure:"args" yaml:"args,omitempty" json:"args,omitempty"` // additional kubelet args (KEY[=VALUE]), e.g. image-gc-high-threshold=70
+}
@@ -73,8 +73,15 @@ type LoadbalancerConfig struct {
 }
 
 type LoadBalancerSettings struct {
-	WorkerConnections   int `yaml:"workerConnections"`
-	DefaultProxyTimeout int `yaml:"defaultProxyTimeout,omitempty"`
+	WorkerConnections   int                   `yaml:"workerConnections"`
+	DefaultProxyTimeout int                   `yaml:"defaultProxyTimeout,omitempty"`
+	APIAccess           LoadBalancerAPIAccess `yaml:"apiAccess,omitempty"`
+}
+
+// LoadBalancerAPIAccess restricts access to the Kubernetes API port exposed via the loadbalancer
+type LoadBalancerAPIAccess struct {
+	AllowedSources     []string `yaml:"allowedSources,omitempty"`     // CIDRs or IPs that may connect, all others are denied (if set)
+	ClientCertificates bool     `yaml:"clientCertificates,omitempty"` // require client certificates signed by the cluster's client CA
 }
 
 const (
@@ -82,6 +89,21 @@ const (
 	DefaultLoadbalancerWorkerConnections = 1024
 )
 
+// DefaultLoadbalancerAPICertsDir is where the certificates for LoadBalancerAPIAccess.ClientCertificates are stored in the loadbalancer
+const DefaultLoadbalancerAPICertsDir = "/etc/nginx/k3d-api"
+
+// LoadbalancerAPICerts maps the files in DefaultLoadbalancerAPICertsDir to the k3s server files they're copied from:
+// the loadbalancer terminates TLS with the API server's serving certificate, verifies client certificates against the client CA
+// and authenticates to the API server as the k3s auth proxy, passing on the client's identity via request headers.
+var LoadbalancerAPICerts = map[string]string{
+	"serving.crt":    "/var/lib/rancher/k3s/server/tls/serving-kube-apiserver.crt",
+	"serving.key":    "/var/lib/rancher/k3s/server/tls/serving-kube-apiserver.key",
+	"client-ca.crt":  "/var/lib/rancher/k3s/server/tls/client-ca.crt",
+	"server-ca.crt":  "/var/lib/rancher/k3s/server/tls/server-ca.crt",
+	"auth-proxy.crt": "/var/lib/rancher/k3s/server/tls/client-auth-proxy.crt",
+	"auth-proxy.key": "/var/lib/rancher/k3s/server/tls/client-auth-proxy.key",
+}
+
 type LoadbalancerCreateOpts struct {
 	Labels          map[string]string
 	ConfigOverrides []string
@@ -0,0 +1,56 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package types
+
+import "time"
+
+// DefaultNetpolTestNamespace is the namespace that the network policy test suite gets deployed to
+const DefaultNetpolTestNamespace = "k3d-netpol-test"
+
+// DefaultNetpolTestOtherNamespace is the second namespace of the network policy test suite, used to check cross-namespace traffic
+const DefaultNetpolTestOtherNamespace = "k3d-netpol-test-other"
+
+// DefaultNetpolTestImage is the image used for the servers and clients of the network policy test suite
+const DefaultNetpolTestImage = "docker.io/library/busybox:1.34.1"
+
+// DefaultNetpolTestTimeout is the time that the network policy test suite may take to report its results
+const DefaultNetpolTestTimeout = 3 * time.Minute
+
+// DefaultNetpolTestManifestTempPath is the temporary path of the network policy test suite manifest in the server node
+const DefaultNetpolTestManifestTempPath = "/tmp/k3d-netpol-test.yaml"
+
+// NetpolTestOpts are the options of running the network policy test suite
+type NetpolTestOpts struct {
+	Image   string        // image of the test pods (default: DefaultNetpolTestImage)
+	Timeout time.Duration // timeout for all checks to report their results (default: DefaultNetpolTestTimeout)
+	Keep    bool          // keep the test namespaces after the run, e.g. to inspect them
+}
+
+// NetpolTestResult is the result of a single check of the network policy test suite
+type NetpolTestResult struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Expected    string `yaml:"expected" json:"expected"`                     // reachable or blocked
+	Observed    string `yaml:"observed,omitempty" json:"observed,omitempty"` // reachable, blocked or empty if the check didn't report
+	Passed      bool   `yaml:"passed" json:"passed"`
+}
@@ -21,6 +21,16 @@ THE SOFTWARE.
 */
 package types
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+)
+
+// MaskedValue replaces secrets (like the cluster token) in printed output
+const MaskedValue = "***"
+
 func (node *Node) FillRuntimeLabels() {
 	if node.RuntimeLabels == nil {
 		node.RuntimeLabels = make(map[string]string)
@@ -38,3 +48,72 @@ func (node *Node) FillRuntimeLabels() {
 	node.RuntimeLabels[LabelRole] = string(node.Role)
 
 }
+
+// WithMaskedToken returns a copy of the node with the cluster token (runtime label and environment variable) masked, e.g. for printing
+func (node *Node) WithMaskedToken() *Node {
+	if node == nil {
+		return nil
+	}
+	masked := *node
+
+	if node.RuntimeLabels != nil {
+		masked.RuntimeLabels = make(map[string]string, len(node.RuntimeLabels))
+		for k, v := range node.RuntimeLabels {
+			if k == LabelClusterToken {
+				v = MaskedValue
+			}
+			masked.RuntimeLabels[k] = v
+		}
+	}
+
+	if node.Env != nil {
+		tokenPrefix := fmt.Sprintf("%s=", k3s.EnvClusterToken)
+		masked.Env = make([]string, len(node.Env))
+		for i, env := range node.Env {
+			if strings.HasPrefix(env, tokenPrefix) {
+				env = tokenPrefix + MaskedValue
+			}
+			masked.Env[i] = env
+		}
+	}
+
+	return &masked
+}
+
+// WithMaskedToken returns a copy of the cluster with the cluster token masked in the cluster itself and in all of its nodes, e.g. for printing
+func (c *Cluster) WithMaskedToken() *Cluster {
+	if c == nil {
+		return nil
+	}
+	masked := *c
+	if c.Token != "" {
+		masked.Token = MaskedValue
+	}
+
+	maskedNodes := make(map[*Node]*Node, len(c.Nodes))
+	maskNode := func(node *Node) *Node {
+		if node == nil {
+			return nil
+		}
+		if m, ok := maskedNodes[node]; ok {
+			return m
+		}
+		maskedNodes[node] = node.WithMaskedToken()
+		return maskedNodes[node]
+	}
+
+	if c.Nodes != nil {
+		masked.Nodes = make([]*Node, len(c.Nodes))
+		for i, node := range c.Nodes {
+			masked.Nodes[i] = maskNode(node)
+		}
+	}
+	masked.InitNode = maskNode(c.InitNode)
+	if c.ServerLoadBalancer != nil {
+		lb := *c.ServerLoadBalancer
+		lb.Node = maskNode(lb.Node)
+		masked.ServerLoadBalancer = &lb
+	}
+
+	return &masked
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package types
+
+import (
+	"testing"
+)
+
+func TestNodeWithMaskedToken(t *testing.T) {
+	node := &Node{
+		Name:          "k3d-test-server-0",
+		Env:           []string{"K3S_TOKEN=secret", "FOO=bar"},
+		RuntimeLabels: map[string]string{LabelClusterToken: "secret", LabelClusterName: "test"},
+	}
+
+	masked := node.WithMaskedToken()
+
+	if masked.Env[0] != "K3S_TOKEN="+MaskedValue || masked.Env[1] != "FOO=bar" {
+		t.Errorf("unexpected env of masked node: %v", masked.Env)
+	}
+	if masked.RuntimeLabels[LabelClusterToken] != MaskedValue || masked.RuntimeLabels[LabelClusterName] != "test" {
+		t.Errorf("unexpected runtime labels of masked node: %v", masked.RuntimeLabels)
+	}
+
+	// the original node must not be modified
+	if node.Env[0] != "K3S_TOKEN=secret" || node.RuntimeLabels[LabelClusterToken] != "secret" {
+		t.Errorf("original node was modified: %+v", node)
+	}
+}
+
+func TestClusterWithMaskedToken(t *testing.T) {
+	server := &Node{Name: "k3d-test-server-0", Env: []string{"K3S_TOKEN=secret"}}
+	cluster := &Cluster{
+		Name:     "test",
+		Token:    "secret",
+		Nodes:    []*Node{server},
+		InitNode: server,
+	}
+
+	masked := cluster.WithMaskedToken()
+
+	if masked.Token != MaskedValue {
+		t.Errorf("cluster token not masked: %s", masked.Token)
+	}
+	if masked.Nodes[0].Env[0] != "K3S_TOKEN="+MaskedValue {
+		t.Errorf("node token not masked: %v", masked.Nodes[0].Env)
+	}
+	if masked.InitNode != masked.Nodes[0] {
+		t.Errorf("init node should point to the masked node in the node list")
+	}
+	if cluster.Token != "secret" || server.Env[0] != "K3S_TOKEN=secret" {
+		t.Errorf("original cluster was modified")
+	}
+}
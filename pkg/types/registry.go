@@ -24,12 +24,13 @@ package types
 // Registry Defaults
 const (
 	DefaultRegistryPort       = "5000"
-	DefaultRegistryName       = DefaultObjectNamePrefix + "-registry"
 	DefaultRegistriesFilePath = "/etc/rancher/k3s/registries.yaml"
 	DefaultRegistryMountPath  = "/var/lib/registry"
 	DefaultDockerHubAddress   = "registry-1.docker.io"
 	// Default temporary path for the LocalRegistryHosting configmap, from where it will be applied via kubectl
 	DefaultLocalRegistryHostingConfigmapTempPath = "/tmp/localRegistryHostingCM.yaml"
+	// Rewrite pattern matching the whole repository, used to serve the repositories of a registry below a path of a mirror
+	DefaultRegistryRewritePattern = "^(.*)$"
 )
 
 // Registry describes a k3d-managed registry
@@ -0,0 +1,61 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package types
+
+import "time"
+
+// StartupStep names a step of the cluster startup: the built-in steps start the nodes of a role, custom steps are StartupChecks
+type StartupStep string
+
+// built-in startup steps
+const (
+	StartupStepInitServer   StartupStep = "init-server"  // the initializing server (etcd)
+	StartupStepServers      StartupStep = "servers"      // all other server nodes, one after another
+	StartupStepAgents       StartupStep = "agents"       // all agent nodes in parallel
+	StartupStepRegistries   StartupStep = "registries"   // the registry created with the cluster
+	StartupStepLoadbalancer StartupStep = "loadbalancer" // the server loadbalancer
+	StartupStepHelpers      StartupStep = "helpers"      // any other auxiliary node (e.g. the tools node)
+)
+
+// DefaultStartupDependencies define the order in which the built-in startup steps are run (step -> steps it depends on)
+var DefaultStartupDependencies = map[StartupStep][]StartupStep{
+	StartupStepInitServer:   {},
+	StartupStepServers:      {StartupStepInitServer},
+	StartupStepAgents:       {StartupStepServers},
+	StartupStepRegistries:   {},
+	StartupStepLoadbalancer: {StartupStepAgents},
+	StartupStepHelpers:      {StartupStepAgents},
+}
+
+// StartupOpts customize the startup order of a cluster's nodes
+type StartupOpts struct {
+	Dependencies map[StartupStep][]StartupStep `yaml:"dependencies,omitempty" json:"dependencies,omitempty"` // additional dependencies (step -> steps it depends on)
+	Checks       []StartupCheck                `yaml:"checks,omitempty" json:"checks,omitempty"`             // additional steps, which other steps can depend on
+}
+
+// StartupCheck is a custom startup step, waiting for an external dependency (e.g. a datastore) to be reachable from the host
+type StartupCheck struct {
+	Name    StartupStep   `yaml:"name" json:"name"`
+	TCP     string        `yaml:"tcp,omitempty" json:"tcp,omitempty"`         // address (HOST:PORT) that has to accept connections
+	HTTP    string        `yaml:"http,omitempty" json:"http,omitempty"`       // URL that has to respond with a 2xx status code
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"` // maximum waiting time (default: the one of the whole startup)
+}
@@ -35,6 +35,9 @@ import (
 // NodeStatusRestarting defines the status string that signals the node container is restarting
 const NodeStatusRestarting = "restarting"
 
+// NodeStatusPaused defines the status string that signals the node container is paused (frozen, but still running)
+const NodeStatusPaused = "paused"
+
 // Role defines a k3d node role
 type Role string
 
@@ -61,6 +64,12 @@ var NodeRoles = map[string]Role{
 	string(RegistryRole):     RegistryRole,
 }
 
+// DeprecatedNodeRoles maps the legacy role names (pre-k3s v1.0 terminology) to the current roles
+var DeprecatedNodeRoles = map[string]Role{
+	"master": ServerRole,
+	"worker": AgentRole,
+}
+
 // ClusterInternalNodeRoles is a list of roles for nodes that belong to a cluster
 var ClusterInternalNodeRoles = []Role{
 	ServerRole,
@@ -80,6 +89,7 @@ const (
 	LabelClusterToken         string = "k3d.cluster.token"
 	LabelClusterExternal      string = "k3d.cluster.external"
 	LabelImageVolume          string = "k3d.cluster.imageVolume"
+	LabelImageVolumeKeep      string = "k3d.cluster.imageVolume.keep"
 	LabelNetworkExternal      string = "k3d.cluster.network.external"
 	LabelNetwork              string = "k3d.cluster.network"
 	LabelNetworkID            string = "k3d.cluster.network.id"
@@ -94,6 +104,18 @@ const (
 	LabelRegistryPortExternal string = "k3s.registry.port.external"
 	LabelRegistryPortInternal string = "k3s.registry.port.internal"
 	LabelNodeStaticIP         string = "k3d.node.staticIP"
+	LabelNodeOS               string = "k3d.node.os"
+	LabelNodeFixInotify       string = "k3d.node.fix.inotify"
+	LabelHibernationSchedule  string = "k3d.cluster.hibernation.schedule"
+	LabelClusterCreated       string = "k3d.cluster.created"
+	LabelImageBakedFrom       string = "k3d.image.bakedFrom"
+	LabelClusterBindAddress   string = "k3d.cluster.bindAddress"
+	LabelClusterStartup       string = "k3d.cluster.startup"
+	LabelClusterDomain        string = "k3d.cluster.domain"
+	LabelClusterTimezone      string = "k3d.cluster.timezone"
+	LabelClusterOwner         string = "k3d.cluster.owner"
+	LabelClusterExternalID    string = "k3d.cluster.externalID"
+	LabelClusterNamePrefix    string = "k3d.cluster.namePrefix"
 )
 
 // DoNotCopyServerFlags defines a list of commands/args that shouldn't be copied from an existing node when adding a similar node to a cluster
@@ -104,13 +126,32 @@ var DoNotCopyServerFlags = []string{
 // ClusterCreateOpts describe a set of options one can set when creating a cluster
 type ClusterCreateOpts struct {
 	DisableImageVolume  bool              `yaml:"disableImageVolume" json:"disableImageVolume,omitempty"`
+	ImageVolume         string            `yaml:"imageVolume,omitempty" json:"imageVolume,omitempty"`         // name of the image volume, reused if it exists (default: k3d-CLUSTER-images)
+	KeepImageVolume     bool              `yaml:"keepImageVolume,omitempty" json:"keepImageVolume,omitempty"` // retain the image volume when deleting the cluster
 	WaitForServer       bool              `yaml:"waitForServer" json:"waitForServer,omitempty"`
+	NoWaitForAgents     bool              `yaml:"noWaitForAgents,omitempty" json:"noWaitForAgents,omitempty"`   // don't wait for the agents to register with the server(s)
+	ReadyLogMessages    map[Role]string   `yaml:"readyLogMessages,omitempty" json:"readyLogMessages,omitempty"` // overrides the log messages signaling that a node of a role is ready
 	Timeout             time.Duration     `yaml:"timeout" json:"timeout,omitempty"`
+	NodeStartupTimeout  time.Duration     `yaml:"nodeStartupTimeout,omitempty" json:"nodeStartupTimeout,omitempty"` // maximum time for each node to start and get ready
 	DisableLoadBalancer bool              `yaml:"disableLoadbalancer" json:"disableLoadbalancer,omitempty"`
 	GPURequest          string            `yaml:"gpuRequest" json:"gpuRequest,omitempty"`
 	ServersMemory       string            `yaml:"serversMemory" json:"serversMemory,omitempty"`
 	AgentsMemory        string            `yaml:"agentsMemory" json:"agentsMemory,omitempty"`
+	ClusterCPULimit     string            `yaml:"clusterCpuLimit" json:"clusterCpuLimit,omitempty"`
+	ClusterMemoryLimit  string            `yaml:"clusterMemoryLimit" json:"clusterMemoryLimit,omitempty"`
+	NodeTmpfsRoot       string            `yaml:"nodeTmpfsRoot" json:"nodeTmpfsRoot,omitempty"`
+	CheckProfiles       []string          `yaml:"checkProfiles,omitempty" json:"checkProfiles,omitempty"`
+	OnNodeFailure       NodeFailurePolicy `yaml:"onNodeFailure,omitempty" json:"onNodeFailure,omitempty"`
+	Rollback            RollbackPolicy    `yaml:"rollback,omitempty" json:"rollback,omitempty"`               // what to roll back if the creation fails (default: auto)
+	HTTPProxy           bool              `yaml:"httpProxy,omitempty" json:"httpProxy,omitempty"`             // forward the host's proxy environment variables into the k3s nodes
+	NoPull              bool              `yaml:"noPull,omitempty" json:"noPull,omitempty"`                   // never pull images: all images used by the cluster have to exist in the runtime
+	FixInotify          bool              `yaml:"fixInotify,omitempty" json:"fixInotify,omitempty"`           // raise the inotify limits from inside the (privileged) nodes (auto-enabled on Docker Desktop)
+	StrictPreflight     bool              `yaml:"strictPreflight,omitempty" json:"strictPreflight,omitempty"` // fail instead of warning if a preflight check finds an issue (e.g. low inotify limits)
+	ImagesArchives      []string          `yaml:"imagesArchives,omitempty" json:"imagesArchives,omitempty"`   // image archives (e.g. k3s-airgap-images.tar) imported by k3s in all nodes before it starts
 	NodeHooks           []NodeHook        `yaml:"nodeHooks,omitempty" json:"nodeHooks,omitempty"`
+	ClusterHooks        []ClusterHook     `yaml:"clusterHooks,omitempty" json:"clusterHooks,omitempty"`
+	Manifests           []string          `yaml:"manifests,omitempty" json:"manifests,omitempty"`         // files, directories or URLs of manifests auto-deployed by k3s
+	CoreDNSCustom       *CoreDNSCustom    `yaml:"corednsCustom,omitempty" json:"corednsCustom,omitempty"` // custom CoreDNS config, deployed as the coredns-custom ConfigMap
 	GlobalLabels        map[string]string `yaml:"globalLabels,omitempty" json:"globalLabels,omitempty"`
 	GlobalEnv           []string          `yaml:"globalEnv,omitempty" json:"globalEnv,omitempty"`
 	Registries          struct {
@@ -120,6 +161,54 @@ type ClusterCreateOpts struct {
 	} `yaml:"registries,omitempty" json:"registries,omitempty"`
 }
 
+// NodeFailurePolicy describes how a cluster creation deals with agent nodes that fail to be created or started
+type NodeFailurePolicy string
+
+// all supported node failure policies
+const (
+	NodeFailurePolicyRollback NodeFailurePolicy = "rollback" // fail the creation (and roll back the whole cluster, unless disabled)
+	NodeFailurePolicyContinue NodeFailurePolicy = "continue" // drop the failed agents and finish the creation without them
+	NodeFailurePolicyRetry    NodeFailurePolicy = "retry"    // retry the failed agents a few times, before failing the creation
+)
+
+// NodeFailurePolicies lists all supported node failure policies
+var NodeFailurePolicies = []NodeFailurePolicy{NodeFailurePolicyRollback, NodeFailurePolicyContinue, NodeFailurePolicyRetry}
+
+// RollbackPolicy describes whether a failed cluster creation gets rolled back
+type RollbackPolicy string
+
+// all supported rollback policies
+const (
+	RollbackPolicyAuto   RollbackPolicy = "auto"   // roll back if the creation fails, a second interrupt aborts the rollback
+	RollbackPolicyNever  RollbackPolicy = "never"  // keep whatever got created (e.g. to debug it)
+	RollbackPolicyAlways RollbackPolicy = "always" // roll back if the creation fails, ignoring further interrupts until the rollback is done
+)
+
+// RollbackPolicies lists all supported rollback policies
+var RollbackPolicies = []RollbackPolicy{RollbackPolicyAuto, RollbackPolicyNever, RollbackPolicyAlways}
+
+// DefaultNodeFailureRetries is the number of times a failed agent is retried with the NodeFailurePolicyRetry
+const DefaultNodeFailureRetries = 3
+
+// KubeProxyMode describes how service traffic gets routed inside the cluster
+type KubeProxyMode string
+
+// all supported kube-proxy modes
+const (
+	KubeProxyModeIPTables KubeProxyMode = "iptables" // kube-proxy using iptables rules (k3s default)
+	KubeProxyModeIPVS     KubeProxyMode = "ipvs"     // kube-proxy using the kernel's IP virtual server
+	KubeProxyModeNone     KubeProxyMode = "none"     // no kube-proxy at all, e.g. to replace it with Cilium's eBPF implementation
+)
+
+// KubeProxyModes lists all supported kube-proxy modes
+var KubeProxyModes = []KubeProxyMode{KubeProxyModeIPTables, KubeProxyModeIPVS, KubeProxyModeNone}
+
+// KubeProxyModeCheckProfiles maps the kube-proxy modes to the check profiles verifying their host kernel requirements
+var KubeProxyModeCheckProfiles = map[KubeProxyMode]string{
+	KubeProxyModeIPVS: "ipvs",
+	KubeProxyModeNone: "cilium",
+}
+
 // NodeHook is an action that is bound to a specifc stage of a node lifecycle
 type NodeHook struct {
 	Stage  LifecycleStage `yaml:"stage,omitempty" json:"stage,omitempty"`
@@ -135,13 +224,160 @@ const (
 	LifecycleStagePostStart LifecycleStage = "postStart"
 )
 
+// stages of the cluster creation, which ClusterHooks can be bound to
+const (
+	LifecycleStagePreCreate        LifecycleStage = "preCreate"        // before the node containers are created (the actions get the node specs, which they may modify)
+	LifecycleStagePostNodeStart    LifecycleStage = "postNodeStart"    // right after each selected node was started
+	LifecycleStagePostClusterReady LifecycleStage = "postClusterReady" // after all nodes were started and the servers are ready
+)
+
+// ClusterHookStages lists the stages of the cluster creation that ClusterHooks can be bound to
+var ClusterHookStages = []LifecycleStage{LifecycleStagePreCreate, LifecycleStagePostNodeStart, LifecycleStagePostClusterReady}
+
+// ClusterHook is an action that is bound to a specific stage of the cluster creation and runs on the selected server and agent nodes
+type ClusterHook struct {
+	Stage       LifecycleStage `yaml:"stage,omitempty" json:"stage,omitempty"`
+	NodeFilters []string       `yaml:"nodeFilters,omitempty" json:"nodeFilters,omitempty"` // default: all server and agent nodes
+	Action      NodeHookAction `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
 // ClusterStartOpts describe a set of options one can set when (re-)starting a cluster
 type ClusterStartOpts struct {
-	WaitForServer   bool
-	Timeout         time.Duration
-	NodeHooks       []NodeHook `yaml:"nodeHooks,omitempty" json:"nodeHooks,omitempty"`
-	EnvironmentInfo *EnvironmentInfo
-	Intent          Intent
+	WaitForServer      bool
+	NoWaitForAgents    bool            // don't wait for the agents to register with the server(s)
+	ReadyLogMessages   map[Role]string // overrides the log messages signaling that a node of a role is ready
+	Timeout            time.Duration
+	NodeStartupTimeout time.Duration // maximum time for each node to start and get ready (independent of Timeout)
+	NodeHooks          []NodeHook    `yaml:"nodeHooks,omitempty" json:"nodeHooks,omitempty"`
+	EnvironmentInfo    *EnvironmentInfo
+	Intent             Intent
+	OnNodeFailure      NodeFailurePolicy // how to deal with agents failing to start (default: fail)
+}
+
+// ClusterRepairOpts describe a set of options one can set when repairing a cluster
+type ClusterRepairOpts struct {
+	Wait    bool          // wait for the re-created nodes to be ready
+	Timeout time.Duration // maximum waiting time per node
+}
+
+// ClusterUpgradeOpts describe a set of options one can set when upgrading a cluster to a new k3s image
+type ClusterUpgradeOpts struct {
+	Image   string        // the new k3s image (or release channel) of the server and agent nodes
+	Timeout time.Duration // maximum time per node to be replaced and healthy again
+}
+
+// ClusterResyncTimeOpts describe a set of options one can set when checking/correcting the clocks of a cluster's nodes
+type ClusterResyncTimeOpts struct {
+	Threshold time.Duration // maximum tolerated clock skew
+	DryRun    bool          // only detect, but don't correct the clock skew
+	Force     bool          // correct the clock even if the nodes don't run in a VM (e.g. Docker Desktop), i.e. set the clock of the runtime host
+}
+
+// ClusterWatchOpts describe a set of options one can set when watching clusters
+type ClusterWatchOpts struct {
+	Clusters    []string         // names of the clusters to watch (empty: all clusters)
+	Interval    time.Duration    // time between two checks
+	MaxRestarts int              // maximum number of consecutive restart attempts per node
+	OnEvent     func(WatchEvent) // called for every event (e.g. for notifications)
+}
+
+// WatchEventType describes the type of an event emitted by the cluster watcher
+type WatchEventType string
+
+// Events emitted by the cluster watcher
+const (
+	WatchEventNodeRestarted            WatchEventType = "node-restarted"
+	WatchEventNodeRestartFailed        WatchEventType = "node-restart-failed"
+	WatchEventNodeRestartGaveUp        WatchEventType = "node-restart-gave-up"
+	WatchEventLoadbalancerUpdated      WatchEventType = "loadbalancer-updated"
+	WatchEventLoadbalancerUpdateFailed WatchEventType = "loadbalancer-update-failed"
+	WatchEventClusterHibernated        WatchEventType = "cluster-hibernated"
+	WatchEventClusterWokeUp            WatchEventType = "cluster-woke-up"
+	WatchEventScheduleFailed           WatchEventType = "schedule-failed"
+)
+
+// WatchEvent is emitted by the cluster watcher whenever it took (or failed to take) an action
+type WatchEvent struct {
+	Type    WatchEventType
+	Cluster string
+	Node    string
+	Message string
+	Failure bool
+}
+
+// ClusterEventType describes the type of a recorded cluster event
+type ClusterEventType string
+
+// Events recorded in the per-cluster event log (the cluster watcher's WatchEventTypes are recorded as well)
+const (
+	ClusterEventCreated             ClusterEventType = "created"
+	ClusterEventStarted             ClusterEventType = "started"
+	ClusterEventStopped             ClusterEventType = "stopped"
+	ClusterEventNodeAdded           ClusterEventType = "node-added"
+	ClusterEventNodeDeleted         ClusterEventType = "node-deleted"
+	ClusterEventNodeReplaced        ClusterEventType = "node-replaced"
+	ClusterEventNodeFailed          ClusterEventType = "node-failed"
+	ClusterEventUpgraded            ClusterEventType = "upgraded"
+	ClusterEventLoadbalancerUpdated ClusterEventType = "loadbalancer-updated"
+)
+
+// ClusterEvent is a timestamped record of something significant that happened to a cluster
+type ClusterEvent struct {
+	Time    time.Time        `yaml:"time" json:"time"`
+	Type    ClusterEventType `yaml:"type" json:"type"`
+	Node    string           `yaml:"node,omitempty" json:"node,omitempty"`
+	Message string           `yaml:"message" json:"message"`
+}
+
+// HistoryEntry is a record of a cluster creation in the k3d history, holding the resolved config to re-run it
+type HistoryEntry struct {
+	ID      int       `yaml:"id" json:"id,omitempty"` // position in the history (starting at 1), assigned when reading it
+	Time    time.Time `yaml:"time" json:"time"`
+	Cluster string    `yaml:"cluster" json:"cluster"`
+	Command string    `yaml:"command" json:"command"`
+	Spec    string    `yaml:"spec" json:"spec"` // the effective SimpleConfig (YAML), with flags applied and the image resolved
+}
+
+// ClusterJob describes a cluster creation running in the background (`k3d cluster create --async`)
+type ClusterJob struct {
+	Cluster      string    `yaml:"cluster" json:"cluster"`
+	PID          int       `yaml:"pid" json:"pid"`
+	Started      time.Time `yaml:"started" json:"started"`
+	Command      string    `yaml:"command" json:"command"`
+	LogFile      string    `yaml:"logFile" json:"logFile"`
+	ProgressFile string    `yaml:"progressFile" json:"progressFile"`
+}
+
+// ClusterJobState describes the state of a background cluster creation
+type ClusterJobState string
+
+// States of a background cluster creation
+const (
+	ClusterJobStateRunning   ClusterJobState = "running"
+	ClusterJobStateSucceeded ClusterJobState = "succeeded"
+	ClusterJobStateFailed    ClusterJobState = "failed"
+)
+
+// ClusterJobStatus is the current status of a background cluster creation, as derived from its progress events
+type ClusterJobStatus struct {
+	State   ClusterJobState `yaml:"state" json:"state"`
+	Phase   string          `yaml:"phase,omitempty" json:"phase,omitempty"`
+	Percent int             `yaml:"percent" json:"percent"`
+	Message string          `yaml:"message,omitempty" json:"message,omitempty"`
+	Error   string          `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// ComposeAttachOpts describe a set of options one can set when attaching a docker compose project to a cluster
+type ComposeAttachOpts struct {
+	SkipDNS bool // don't inject the services' names into the cluster DNS
+}
+
+// ClusterPruneOpts describe a set of options one can set when selecting clusters for pruning
+type ClusterPruneOpts struct {
+	OlderThan      time.Duration     // only prune clusters created longer ago than this (0: any age)
+	NamePatterns   []string          // only prune clusters whose name matches one of these glob patterns (empty: any name)
+	RuntimeLabels  map[string]string // only prune clusters whose nodes carry all of these runtime labels
+	ExternalIDGone string            // only prune clusters with an external ID, for which this command or URL reports that it's gone (see client.ClusterExternalIDGone)
 }
 
 // ClusterDeleteOpts describe a set of options one can set when deleting a cluster
@@ -201,12 +437,23 @@ type ImageImportOpts struct {
 	KeepTar       bool
 	KeepToolsNode bool
 	Mode          ImportMode
+	NoFiles       bool // only import images from the runtime, never read (tar) files or stdin on the host
+}
+
+// NodeSyncOpts describes a set of options one can set for synchronizing a host directory into a node
+type NodeSyncOpts struct {
+	Interval time.Duration // polling interval for detecting changes on the host
+	Once     bool          // only do a single sync pass and return
+	Delete   bool          // delete files in the node that were removed on the host
+	Excludes []string      // glob patterns (matched against the relative path) that should not be synced
 }
 
 type IPAM struct {
-	IPPrefix netaddr.IPPrefix `yaml:"ipPrefix" json:"ipPrefix,omitempty"`
-	IPsUsed  []netaddr.IP     `yaml:"ipsUsed" json:"ipsUsed,omitempty"`
-	Managed  bool             // IPAM is done by k3d
+	IPPrefix   netaddr.IPPrefix `yaml:"ipPrefix" json:"ipPrefix,omitempty"`
+	IPv6Prefix netaddr.IPPrefix `yaml:"ipv6Prefix,omitempty" json:"ipv6Prefix,omitempty"` // only set for IPv6-enabled (dual-stack) networks
+	Gateway    netaddr.IP       `yaml:"gateway,omitempty" json:"gateway,omitempty"`       // default: second address of the IPv4 subnet
+	IPsUsed    []netaddr.IP     `yaml:"ipsUsed" json:"ipsUsed,omitempty"`
+	Managed    bool             // IPAM is done by k3d
 }
 
 type NetworkMember struct {
@@ -219,22 +466,49 @@ type ClusterNetwork struct {
 	Name     string `yaml:"name" json:"name,omitempty"`
 	ID       string `yaml:"id" json:"id"` // may be the same as name, but e.g. docker only differentiates by random ID, not by name
 	External bool   `yaml:"external" json:"isExternal,omitempty"`
+	IPv6     bool   `yaml:"ipv6,omitempty" json:"ipv6,omitempty"` // enable IPv6 (dual-stack) on the network
 	IPAM     IPAM   `yaml:"ipam" json:"ipam,omitempty"`
 	Members  []*NetworkMember
 }
 
 // Cluster describes a k3d cluster
 type Cluster struct {
-	Name               string             `yaml:"name" json:"name,omitempty"`
-	Network            ClusterNetwork     `yaml:"network" json:"network,omitempty"`
-	Token              string             `yaml:"clusterToken" json:"clusterToken,omitempty"`
-	Nodes              []*Node            `yaml:"nodes" json:"nodes,omitempty"`
-	InitNode           *Node              // init server node
-	ExternalDatastore  *ExternalDatastore `yaml:"externalDatastore,omitempty" json:"externalDatastore,omitempty"`
-	KubeAPI            *ExposureOpts      `yaml:"kubeAPI" json:"kubeAPI,omitempty"`
-	ServerLoadBalancer *Loadbalancer      `yaml:"serverLoadbalancer,omitempty" json:"serverLoadBalancer,omitempty"`
-	ImageVolume        string             `yaml:"imageVolume" json:"imageVolume,omitempty"`
-	Volumes            []string           `yaml:"volumes,omitempty" json:"volumes,omitempty"` // k3d-managed volumes attached to this cluster
+	Name                string             `yaml:"name" json:"name,omitempty"`
+	Network             ClusterNetwork     `yaml:"network" json:"network,omitempty"`
+	Token               string             `yaml:"clusterToken" json:"clusterToken,omitempty"`
+	Nodes               []*Node            `yaml:"nodes" json:"nodes,omitempty"`
+	InitNode            *Node              // init server node
+	ExternalDatastore   *ExternalDatastore `yaml:"externalDatastore,omitempty" json:"externalDatastore,omitempty"`
+	KubeAPI             *ExposureOpts      `yaml:"kubeAPI" json:"kubeAPI,omitempty"`
+	ServerLoadBalancer  *Loadbalancer      `yaml:"serverLoadbalancer,omitempty" json:"serverLoadBalancer,omitempty"`
+	ImageVolume         string             `yaml:"imageVolume" json:"imageVolume,omitempty"`
+	KeepImageVolume     bool               `yaml:"keepImageVolume,omitempty" json:"keepImageVolume,omitempty"`         // the image volume is retained when deleting the cluster
+	Volumes             []string           `yaml:"volumes,omitempty" json:"volumes,omitempty"`                         // k3d-managed volumes attached to this cluster
+	HibernationSchedule string             `yaml:"hibernationSchedule,omitempty" json:"hibernationSchedule,omitempty"` // time windows during which the cluster should be running (see util.ParseSchedule)
+	Created             string             `yaml:"created,omitempty" json:"created,omitempty"`                         // creation timestamp (RFC3339)
+	Startup             *StartupOpts       `yaml:"startup,omitempty" json:"startup,omitempty"`                         // customized startup order of the nodes
+	Health              *ClusterHealth     `yaml:"health,omitempty" json:"health,omitempty"`                           // only set if probed (see client.ClusterProbeHealth)
+	Domain              string             `yaml:"domain,omitempty" json:"domain,omitempty"`                           // custom cluster domain (k3s --cluster-domain), cluster.local if empty
+	ExternalID          string             `yaml:"externalID,omitempty" json:"externalID,omitempty"`                   // ID of an external resource the cluster belongs to (e.g. a pull request), see ClusterPruneOpts.ExternalIDGone
+	NamePrefix          string             `yaml:"namePrefix,omitempty" json:"namePrefix,omitempty"`                   // name prefix of the cluster's objects and kubeconfig entries, as used when creating it (see GetNamePrefix)
+}
+
+// ClusterHealth describes the health of a cluster, as probed from the host
+type ClusterHealth struct {
+	NodesRunning int    `yaml:"nodesRunning" json:"nodesRunning"`
+	NodesTotal   int    `yaml:"nodesTotal" json:"nodesTotal"`
+	APIReachable bool   `yaml:"apiReachable" json:"apiReachable"`
+	APIError     string `yaml:"apiError,omitempty" json:"apiError,omitempty"`     // why the Kubernetes API is not reachable
+	K3sVersion   string `yaml:"k3sVersion,omitempty" json:"k3sVersion,omitempty"` // as detected from the image of the server nodes
+}
+
+// GetNamePrefix returns the name prefix used for the cluster's objects and kubeconfig entries:
+// the one recorded when creating the cluster or the current ObjectNamePrefix (e.g. for clusters created by older k3d versions)
+func (c *Cluster) GetNamePrefix() string {
+	if c.NamePrefix != "" {
+		return c.NamePrefix
+	}
+	return ObjectNamePrefix
 }
 
 // ServerCountRunning returns the number of server nodes running in the cluster and the total number
@@ -279,21 +553,27 @@ type Node struct {
 	Image         string            `yaml:"image" json:"image,omitempty"`
 	Volumes       []string          `yaml:"volumes" json:"volumes,omitempty"`
 	Env           []string          `yaml:"env" json:"env,omitempty"`
+	Entrypoint    []string          // overrides the entrypoint of the image (only used for helper nodes)
 	Cmd           []string          // filled automatically based on role
 	Args          []string          `yaml:"extraArgs" json:"extraArgs,omitempty"`
 	Ports         nat.PortMap       `yaml:"portMappings" json:"portMappings,omitempty"`
+	Tmpfs         map[string]string `yaml:"tmpfs" json:"tmpfs,omitempty"`             // additional tmpfs mounts (path -> mount options)
+	RuntimeOpts   []string          `yaml:"runtimeOpts" json:"runtimeOpts,omitempty"` // raw runtime options (KEY=VALUE) passed on to container creation
 	Restart       bool              `yaml:"restart" json:"restart,omitempty"`
 	Created       string            `yaml:"created" json:"created,omitempty"`
 	RuntimeLabels map[string]string `yaml:"runtimeLabels" json:"runtimeLabels,omitempty"`
 	K3sNodeLabels map[string]string `yaml:"k3sNodeLabels" json:"k3sNodeLabels,omitempty"`
+	OS            string            `yaml:"os,omitempty" json:"os,omitempty"` // simulated operating system of the node (see NodeOSWindows), linux if empty
 	Networks      []string          // filled automatically
 	ExtraHosts    []string          // filled automatically
 	ServerOpts    ServerOpts        `yaml:"serverOpts" json:"serverOpts,omitempty"`
 	AgentOpts     AgentOpts         `yaml:"agentOpts" json:"agentOpts,omitempty"`
 	GPURequest    string            // filled automatically
 	Memory        string            // filled automatically
+	CPUs          string            // filled automatically
 	State         NodeState         // filled automatically
 	IP            NodeIP            // filled automatically -> refers solely to the cluster network
+	AnonVolumes   []string          // filled automatically -> anonymous volumes (NAME:DEST), created for the paths declared as volumes in the image
 	HookActions   []NodeHook        `yaml:"hooks" json:"hooks,omitempty"`
 }
 
@@ -309,6 +589,25 @@ type ExposureOpts struct {
 	Host            string `yaml:"host,omitempty" json:"host,omitempty"`
 }
 
+// HostPort purposes, describing why k3d bound a port on the host
+const (
+	HostPortPurposeAPI          = "api"
+	HostPortPurposeLoadbalancer = "loadbalancer"
+	HostPortPurposeNode         = "node"
+	HostPortPurposeRegistry     = "registry"
+)
+
+// HostPort describes a port that k3d bound on the host for one of its containers
+type HostPort struct {
+	Node          string `yaml:"node" json:"node"`
+	Role          Role   `yaml:"role" json:"role"`
+	Purpose       string `yaml:"purpose" json:"purpose"`
+	HostIP        string `yaml:"hostIP" json:"hostIP"`
+	HostPort      string `yaml:"hostPort" json:"hostPort"` // empty, if the runtime picks a random port
+	ContainerPort string `yaml:"containerPort" json:"containerPort"`
+	Protocol      string `yaml:"protocol" json:"protocol"`
+}
+
 // ExternalDatastore describes an external datastore used for HA/multi-server clusters
 type ExternalDatastore struct {
 	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty"`
@@ -323,9 +622,11 @@ type AgentOpts struct{}
 
 // NodeState describes the current state of a node
 type NodeState struct {
-	Running bool
-	Status  string
-	Started string
+	Running   bool
+	Status    string
+	Started   string
+	ExitCode  int  // exit code of the last run (if the node is not running)
+	OOMKilled bool // the last run was killed because it ran out of memory
 }
 
 type EnvironmentInfo struct {
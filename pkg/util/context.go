@@ -1,5 +1,5 @@
 /*
-Copyright © 2020 The k3d Author(s)
+Copyright © 2020-2021 The k3d Author(s)
 
 Permission is hereby granted, free of charge, to any person obtaining a copy
 of this software and associated documentation files (the "Software"), to deal
@@ -22,14 +22,18 @@ THE SOFTWARE.
 package util
 
 import (
-	"strings"
-
-	l "github.com/rancher/k3d/v5/pkg/logger"
+	"context"
+	"time"
 )
 
-// validateRuntimeLabelKey validates a given label key is not reserved for internal k3d usage
-func ValidateRuntimeLabelKey(labelKey string) {
-	if strings.HasPrefix(labelKey, "k3s.") || strings.HasPrefix(labelKey, "k3d.") || labelKey == "app" {
-		l.Log().Fatalf("runtime label \"%s\" is reserved for internal usage", labelKey)
+// SleepWithContext pauses for the given duration, but returns early with the context's error if it's canceled or its deadline is exceeded
+func SleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
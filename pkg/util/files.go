@@ -49,6 +49,71 @@ func GetConfigDirOrCreate() (string, error) {
 
 }
 
+// GetClusterEventsFile returns the path of the event log file of the given cluster (creating its parent directory if necessary)
+// The event logs are kept in $HOME/.k3d/events/<cluster>.jsonl
+func GetClusterEventsFile(cluster string) (string, error) {
+	configDir, err := GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	eventsDir := path.Join(configDir, "events")
+	if err := createDirIfNotExists(eventsDir); err != nil {
+		return "", fmt.Errorf("failed to create events directory '%s': %w", eventsDir, err)
+	}
+	return path.Join(eventsDir, fmt.Sprintf("%s.jsonl", cluster)), nil
+}
+
+// GetHistoryFile returns the path of the k3d history file, which is kept in $HOME/.k3d/history.jsonl
+func GetHistoryFile() (string, error) {
+	configDir, err := GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return path.Join(configDir, "history.jsonl"), nil
+}
+
+// GetJobsDir returns the directory holding the state of background cluster creations (creating it if necessary)
+// The jobs are kept in $HOME/.k3d/jobs/
+func GetJobsDir() (string, error) {
+	configDir, err := GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	jobsDir := path.Join(configDir, "jobs")
+	if err := createDirIfNotExists(jobsDir); err != nil {
+		return "", fmt.Errorf("failed to create jobs directory '%s': %w", jobsDir, err)
+	}
+	return jobsDir, nil
+}
+
+// GetK3sChannelsCacheFile returns the path of the cache for the k3s release channels (creating its parent directory if necessary)
+// The cache is kept in $HOME/.k3d/cache/k3s-channels.json
+func GetK3sChannelsCacheFile() (string, error) {
+	configDir, err := GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	cacheDir := path.Join(configDir, "cache")
+	if err := createDirIfNotExists(cacheDir); err != nil {
+		return "", fmt.Errorf("failed to create cache directory '%s': %w", cacheDir, err)
+	}
+	return path.Join(cacheDir, "k3s-channels.json"), nil
+}
+
+// GetKubeconfigCredentialsFile returns the path of the encrypted kubeconfig credentials of the given cluster in the given credential store (creating its parent directory if necessary)
+// The credentials are kept in $HOME/.k3d/credentials/<cluster>.<store>, which is only accessible by the user
+func GetKubeconfigCredentialsFile(cluster string, store string) (string, error) {
+	configDir, err := GetConfigDirOrCreate()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	credentialsDir := path.Join(configDir, "credentials")
+	if err := os.MkdirAll(credentialsDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create credentials directory '%s': %w", credentialsDir, err)
+	}
+	return path.Join(credentialsDir, fmt.Sprintf("%s.%s", cluster, store)), nil
+}
+
 // createDirIfNotExists checks for the existence of a directory and creates it along with all required parents if not.
 // It returns an error if the directory (or parents) couldn't be created and nil if it worked fine or if the path already exists.
 func createDirIfNotExists(path string) error {
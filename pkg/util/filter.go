@@ -43,11 +43,176 @@ var (
 		"agent":        k3d.AgentRole,
 		"agents":       k3d.AgentRole,
 		"loadbalancer": k3d.LoadBalancerRole,
+		// deprecated identifiers
+		"master":  k3d.ServerRole,
+		"masters": k3d.ServerRole,
+		"worker":  k3d.AgentRole,
+		"workers": k3d.AgentRole,
 	}
 )
 
-// Regexp pattern to match node filters
-var NodeFilterRegexp = regexp.MustCompile(`^(?P<group>server|servers|agent|agents|loadbalancer|all)(?P<subsetSpec>:(?P<subset>(?P<subsetList>(\d+,?)+)|(?P<subsetRange>\d*-\d*)|(?P<subsetWildcard>\*)))?(?P<suffixSpec>:(?P<suffix>[[:alpha:]]+))?$`)
+// NodeFilterRegexp matches a single node filter: an optional negation, the node group, an optional subset
+// (either 'group:subset' or 'group[subset]') and an optional suffix, e.g. 'agent:0,1', 'agent[1-3]:direct' or '!server[0]'
+var NodeFilterRegexp = regexp.MustCompile(`^(?P<negation>!)?(?P<group>server|servers|agent|agents|master|masters|worker|workers|loadbalancer|all)(?:(?P<subsetSpec>:(?P<subset>[\d,*-]+))|(?P<bracketSpec>\[(?P<bracketSubset>[^\[\]]*)\]))?(?P<suffixSpec>:(?P<suffix>[[:alpha:]]+))?$`)
+
+var (
+	nodeFilterSubsetListRegexp  = regexp.MustCompile(`^\d+(,\d+)*,?$`)
+	nodeFilterSubsetRangeRegexp = regexp.MustCompile(`^(\d*)-(\d*)$`)
+)
+
+// NodeFilterSubsetType is the way a node filter selects nodes out of its group
+type NodeFilterSubsetType string
+
+const (
+	NodeFilterSubsetNone     NodeFilterSubsetType = ""         // e.g. 'loadbalancer' or 'all'
+	NodeFilterSubsetList     NodeFilterSubsetType = "list"     // e.g. 'agent[0,2]'
+	NodeFilterSubsetRange    NodeFilterSubsetType = "range"    // e.g. 'agent[1-3]', 'agent[1-]' or 'agent[-2]'
+	NodeFilterSubsetWildcard NodeFilterSubsetType = "wildcard" // e.g. 'agent[*]'
+)
+
+// NodeFilter is a single parsed node filter
+type NodeFilter struct {
+	Raw        string
+	Negated    bool   // '!' prefix: exclude the matched nodes from the nodes matched by the other filters
+	Group      string // node role identifier or 'all'
+	SubsetType NodeFilterSubsetType
+	Indices    []int // subset list
+	RangeStart int   // subset range start, -1 if open
+	RangeEnd   int   // subset range end, -1 if open
+	Suffix     string
+}
+
+// ParseNodeFilters parses node filters, where each one may consist of multiple comma-separated filters, e.g. 'all,!server[0]'
+func ParseNodeFilters(filters []string) ([]NodeFilter, error) {
+	parsed := []NodeFilter{}
+	for _, filter := range filters {
+		parts, err := splitNodeFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			nf, err := ParseNodeFilter(part)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, nf)
+		}
+	}
+	return parsed, nil
+}
+
+// splitNodeFilter splits a node filter at commas that separate filters, i.e. outside of brackets and not in a subset list like 'agent:0,1'
+func splitNodeFilter(filter string) ([]string, error) {
+	parts := []string{}
+	depth := 0
+	last := 0
+	for i, c := range filter {
+		switch c {
+		case '[':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("Failed to parse node filter '%s': nested '['", filter)
+			}
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("Failed to parse node filter '%s': ']' without matching '['", filter)
+			}
+		case ',':
+			// a comma only starts a new filter if it's followed by a group or a negation, else it's part of a subset list
+			if depth == 0 && i+1 < len(filter) && (filter[i+1] == '!' || (filter[i+1] >= 'a' && filter[i+1] <= 'z')) {
+				parts = append(parts, filter[last:i])
+				last = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("Failed to parse node filter '%s': unclosed '['", filter)
+	}
+	return append(parts, filter[last:]), nil
+}
+
+// ParseNodeFilter parses a single node filter like 'agent[1-3]', 'server:0,1:direct', '!agent[*]' or 'all'
+func ParseNodeFilter(filter string) (NodeFilter, error) {
+	nf := NodeFilter{Raw: filter, RangeStart: -1, RangeEnd: -1}
+
+	if filter == "" {
+		return nf, fmt.Errorf("Failed to parse node filter: empty filter")
+	}
+
+	// match regex with capturing groups
+	match := NodeFilterRegexp.FindStringSubmatch(filter)
+	if len(match) == 0 {
+		return nf, fmt.Errorf("Failed to parse node filter '%s': invalid format (expected e.g. 'all', 'loadbalancer', 'server[0]', 'agent[1-3]', 'agent[*]' or '!agent[0]')", filter)
+	}
+
+	// map capturing group names to submatches
+	submatches := MapSubexpNames(NodeFilterRegexp.SubexpNames(), match)
+
+	nf.Negated = submatches["negation"] != ""
+	nf.Group = submatches["group"]
+	nf.Suffix = submatches["suffix"]
+
+	subset := submatches["subset"]
+	if submatches["bracketSpec"] != "" {
+		subset = submatches["bracketSubset"]
+		if subset == "" {
+			return nf, fmt.Errorf("Failed to parse node filter '%s': empty subset '[]'", filter)
+		}
+	}
+
+	switch {
+	case subset == "":
+		nf.SubsetType = NodeFilterSubsetNone
+	case subset == "*":
+		nf.SubsetType = NodeFilterSubsetWildcard
+	case nodeFilterSubsetListRegexp.MatchString(subset):
+		nf.SubsetType = NodeFilterSubsetList
+		for _, index := range strings.Split(subset, ",") {
+			if index == "" {
+				continue
+			}
+			num, err := strconv.Atoi(index)
+			if err != nil {
+				return nf, fmt.Errorf("Failed to convert subset number to integer in '%s'", filter)
+			}
+			nf.Indices = append(nf.Indices, num)
+		}
+	case nodeFilterSubsetRangeRegexp.MatchString(subset):
+		nf.SubsetType = NodeFilterSubsetRange
+		bounds := nodeFilterSubsetRangeRegexp.FindStringSubmatch(subset)
+		var err error
+		if bounds[1] != "" {
+			if nf.RangeStart, err = strconv.Atoi(bounds[1]); err != nil {
+				return nf, fmt.Errorf("Failed to convert subset range start to integer in '%s'", filter)
+			}
+		}
+		if bounds[2] != "" {
+			if nf.RangeEnd, err = strconv.Atoi(bounds[2]); err != nil {
+				return nf, fmt.Errorf("Failed to convert subset range end to integer in '%s'", filter)
+			}
+		}
+		if nf.RangeStart >= 0 && nf.RangeEnd >= 0 && nf.RangeEnd < nf.RangeStart {
+			return nf, fmt.Errorf("Invalid subset range in '%s': end < start", filter)
+		}
+	default:
+		return nf, fmt.Errorf("Failed to parse node filter '%s': invalid subset '%s' (expected a list like '0,2', a range like '1-3' or the wildcard '*')", filter, subset)
+	}
+
+	switch nf.Group {
+	case "all":
+		if nf.SubsetType != NodeFilterSubsetNone && nf.SubsetType != NodeFilterSubsetWildcard {
+			return nf, fmt.Errorf("Failed to parse node filter '%s': 'all' doesn't take a subset", filter)
+		}
+	case "loadbalancer":
+	default:
+		if nf.SubsetType == NodeFilterSubsetNone {
+			return nf, fmt.Errorf("Failed to parse node filter '%s': missing subset (e.g. '%s[0]' or '%s[*]')", filter, nf.Group, nf.Group)
+		}
+	}
+
+	return nf, nil
+}
 
 // FilterNodesBySuffix properly interprets NodeFilters with suffix
 func FilterNodesWithSuffix(nodes []*k3d.Node, nodefilters []string, allowedSuffices ...string) (map[string][]*k3d.Node, error) {
@@ -63,39 +228,44 @@ func FilterNodesWithSuffix(nodes []*k3d.Node, nodefilters []string, allowedSuffi
 		result[s] = make([]*k3d.Node, 0) // init map for this suffix, if not exists
 	}
 
-	for _, nf := range nodefilters {
-		suffix := NodeFilterSuffixNone
-
-		// match regex with capturing groups
-		match := NodeFilterRegexp.FindStringSubmatch(nf)
-
-		if len(match) == 0 {
-			return nil, fmt.Errorf("Failed to parse node filters (with suffix): invalid format or empty subset in '%s'", nf)
-		}
-
-		// map capturing group names to submatches
-		submatches := MapSubexpNames(NodeFilterRegexp.SubexpNames(), match)
+	parsed, err := ParseNodeFilters(nodefilters)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse node filters (with suffix): %w", err)
+	}
 
-		// get suffix
-		cleanedNf := nf
-		if sf, ok := submatches["suffix"]; ok && sf != "" {
-			suffix = sf
-			cleanedNf = strings.TrimSuffix(nf, submatches["suffixSpec"])
+	// group filters by suffix, where negated filters without a suffix apply to all of them
+	filtersBySuffix := map[string][]NodeFilter{}
+	negated := []NodeFilter{}
+	for _, nf := range parsed {
+		suffix := NodeFilterSuffixNone
+		if nf.Suffix != "" {
+			suffix = nf.Suffix
 		}
 
 		// suffix not in result map, meaning, that it's also not allowed
 		if _, ok := result[suffix]; !ok {
-			return nil, fmt.Errorf("error filtering nodes: unallowed suffix '%s' in nodefilter '%s'", suffix, nf)
+			return nil, fmt.Errorf("error filtering nodes: unallowed suffix '%s' in nodefilter '%s'", suffix, nf.Raw)
 		}
 
-		filteredNodes, err := FilterNodes(nodes, []string{cleanedNf})
+		if nf.Negated && nf.Suffix == "" {
+			negated = append(negated, nf)
+			continue
+		}
+		filtersBySuffix[suffix] = append(filtersBySuffix[suffix], nf)
+	}
+	if len(filtersBySuffix) == 0 {
+		filtersBySuffix[NodeFilterSuffixNone] = []NodeFilter{}
+	}
+
+	for suffix, filters := range filtersBySuffix {
+		filteredNodes, err := filterNodes(nodes, append(filters, negated...))
 		if err != nil {
-			return nil, fmt.Errorf("failed to filter nodes by filter '%s': %w", nf, err)
+			return nil, fmt.Errorf("failed to filter nodes by filters '%s': %w", nodefilters, err)
 		}
 
-		l.Log().Tracef("Filtered %d nodes for suffix '%s' (filter: %s)", len(filteredNodes), suffix, nf)
+		l.Log().Tracef("Filtered %d nodes for suffix '%s' (filters: %s)", len(filteredNodes), suffix, nodefilters)
 
-		result[suffix] = append(result[suffix], filteredNodes...)
+		result[suffix] = filteredNodes
 	}
 
 	return result, nil
@@ -111,153 +281,124 @@ func FilterNodes(nodes []*k3d.Node, filters []string) ([]*k3d.Node, error) {
 		return nodes, nil
 	}
 
-	// map roles to subsets
-	serverNodes := []*k3d.Node{}
-	agentNodes := []*k3d.Node{}
-	var serverlb *k3d.Node
-	for _, node := range nodes {
-		if node.Role == k3d.ServerRole {
-			serverNodes = append(serverNodes, node)
-		} else if node.Role == k3d.AgentRole {
-			agentNodes = append(agentNodes, node)
-		} else if node.Role == k3d.LoadBalancerRole {
-			serverlb = node
-		}
+	parsed, err := ParseNodeFilters(filters)
+	if err != nil {
+		return nil, err
 	}
 
-	filteredNodes := []*k3d.Node{}
-	set := make(map[*k3d.Node]struct{})
+	// error out if suffix is specified (should only work in FilterNodesWithSuffix)
+	for _, nf := range parsed {
+		if nf.Suffix != "" {
+			return nil, fmt.Errorf("error filtering with '%s': no suffix allowed in simple filter", nf.Raw)
+		}
+	}
 
-	// range over all instances of group[subset] specs
-	for _, filter := range filters {
+	filteredNodes, err := filterNodes(nodes, parsed)
+	if err != nil {
+		return nil, err
+	}
 
-		// match regex with capturing groups
-		match := NodeFilterRegexp.FindStringSubmatch(filter)
+	l.Log().Tracef("Filtered %d nodes (filter: %s)", len(filteredNodes), filters)
 
-		if len(match) == 0 {
-			return nil, fmt.Errorf("Failed to parse node filters: invalid format or empty subset in '%s'", filter)
-		}
+	return filteredNodes, nil
+}
 
-		// map capturing group names to submatches
-		submatches := MapSubexpNames(NodeFilterRegexp.SubexpNames(), match)
+// filterNodes returns the union of the nodes matched by all non-negated filters (or all nodes, if there are only negated filters)
+// without the nodes matched by any of the negated filters
+func filterNodes(nodes []*k3d.Node, filters []NodeFilter) ([]*k3d.Node, error) {
+	selected := []*k3d.Node{}
+	set := make(map[*k3d.Node]struct{})
+	excluded := make(map[*k3d.Node]struct{})
+	onlyNegated := true
 
-		// error out if filter is specified (should only work in FilterNodesWithSuffix)
-		if sf, ok := submatches["suffix"]; ok && sf != "" {
-			return nil, fmt.Errorf("error filtering with '%s': no suffix allowed in simple filter", filter)
+	for _, nf := range filters {
+		matched, err := nf.match(nodes)
+		if err != nil {
+			return nil, err
 		}
-
-		// if one of the filters is 'all', we only return this and drop all others
-		if submatches["group"] == "all" {
-			if len(filters) > 1 {
-				l.Log().Warnf("Node filter 'all' set, but more were specified in '%+v'", filters)
+		if nf.Negated {
+			for _, node := range matched {
+				excluded[node] = struct{}{}
 			}
-			return nodes, nil
+			continue
 		}
-
-		// Choose the group of nodes to operate on
-		groupNodes := []*k3d.Node{}
-		if role, ok := rolesByIdentifier[submatches["group"]]; ok {
-			switch role {
-			case k3d.ServerRole:
-				groupNodes = serverNodes
-				break
-			case k3d.AgentRole:
-				groupNodes = agentNodes
-				break
-			case k3d.LoadBalancerRole:
-				if serverlb == nil {
-					return nil, fmt.Errorf("Node filter '%s' targets a node that does not exist (disabled?)", filter)
-				}
-				filteredNodes = append(filteredNodes, serverlb)
-				return filteredNodes, nil // early exit if filtered group is the loadbalancer
+		onlyNegated = false
+		for _, node := range matched {
+			if _, exists := set[node]; !exists {
+				selected = append(selected, node)
+				set[node] = struct{}{}
 			}
 		}
+	}
 
-		/* Option 1) subset defined by list */
-		if submatches["subsetList"] != "" {
-			for _, index := range strings.Split(submatches["subsetList"], ",") {
-				if index != "" {
-					num, err := strconv.Atoi(index)
-					if err != nil {
-						return nil, fmt.Errorf("Failed to convert subset number to integer in '%s'", filter)
-					}
-					if num < 0 || num >= len(groupNodes) {
-						return nil, fmt.Errorf("Index out of range: index '%d' < 0 or > number of available nodes in filter '%s'", num, filter)
-					}
-					if _, exists := set[groupNodes[num]]; !exists {
-						filteredNodes = append(filteredNodes, groupNodes[num])
-						set[groupNodes[num]] = struct{}{}
-					}
-				}
-			}
-
-			/* Option 2) subset defined by range */
-		} else if submatches["subsetRange"] != "" {
-
-			/*
-			 * subset specified by a range 'START-END', where each side is optional
-			 */
+	if onlyNegated {
+		selected = nodes
+	}
 
-			split := strings.Split(submatches["subsetRange"], "-")
-			if len(split) != 2 {
-				return nil, fmt.Errorf("Failed to parse subset range in '%s'", filter)
-			}
+	filteredNodes := []*k3d.Node{}
+	for _, node := range selected {
+		if _, exclude := excluded[node]; !exclude {
+			filteredNodes = append(filteredNodes, node)
+		}
+	}
+	return filteredNodes, nil
+}
 
-			start := 0
-			end := len(groupNodes) - 1
+// match returns the nodes matched by the filter (ignoring its negation)
+func (nf NodeFilter) match(nodes []*k3d.Node) ([]*k3d.Node, error) {
+	if nf.Group == "all" {
+		return nodes, nil
+	}
 
-			var err error
+	role := rolesByIdentifier[nf.Group]
+	if _, deprecated := k3d.DeprecatedNodeRoles[strings.TrimSuffix(nf.Group, "s")]; deprecated {
+		l.Log().Warnf("Node filter group '%s' is deprecated and will be removed in a future release: use '%ss' instead", nf.Group, role)
+	}
 
-			if split[0] != "" {
-				start, err = strconv.Atoi(split[0])
-				if err != nil {
-					return nil, fmt.Errorf("Failed to convert subset range start to integer in '%s'", filter)
-				}
-				if start < 0 || start >= len(groupNodes) {
-					return nil, fmt.Errorf("Invalid subset range: start < 0 or > number of available nodes in '%s'", filter)
-				}
+	// Choose the group of nodes to operate on
+	groupNodes := FilterNodesByRole(nodes, role)
 
+	// the loadbalancer is a single node, so the subset doesn't matter
+	if role == k3d.LoadBalancerRole {
+		if len(groupNodes) == 0 {
+			if nf.Negated {
+				return nil, nil // nothing to exclude
 			}
+			return nil, fmt.Errorf("Node filter '%s' targets a node that does not exist (disabled?)", nf.Raw)
+		}
+		return groupNodes[:1], nil
+	}
 
-			if split[1] != "" {
-				end, err = strconv.Atoi(split[1])
-				if err != nil {
-					return nil, fmt.Errorf("Failed to convert subset range start to integer in '%s'", filter)
-				}
-				if end < start || end >= len(groupNodes) {
-					return nil, fmt.Errorf("Invalid subset range: end < start or > number of available nodes in '%s'", filter)
-				}
+	switch nf.SubsetType {
+	case NodeFilterSubsetList:
+		matched := []*k3d.Node{}
+		for _, num := range nf.Indices {
+			if num < 0 || num >= len(groupNodes) {
+				return nil, fmt.Errorf("Index out of range: index '%d' < 0 or > number of available nodes in filter '%s'", num, nf.Raw)
 			}
-
-			for i := start; i <= end; i++ {
-				if _, exists := set[groupNodes[i]]; !exists {
-					filteredNodes = append(filteredNodes, groupNodes[i])
-					set[groupNodes[i]] = struct{}{}
-				}
+			matched = append(matched, groupNodes[num])
+		}
+		return matched, nil
+	case NodeFilterSubsetRange:
+		start := 0
+		end := len(groupNodes) - 1
+		if nf.RangeStart >= 0 {
+			start = nf.RangeStart
+			if start >= len(groupNodes) {
+				return nil, fmt.Errorf("Invalid subset range: start < 0 or > number of available nodes in '%s'", nf.Raw)
 			}
-
-			/* Option 3) subset defined by wildcard */
-		} else if submatches["subsetWildcard"] == "*" {
-			/*
-			 * '*' = all nodes
-			 */
-			for _, node := range groupNodes {
-				if _, exists := set[node]; !exists {
-					filteredNodes = append(filteredNodes, node)
-					set[node] = struct{}{}
-				}
+		}
+		if nf.RangeEnd >= 0 {
+			end = nf.RangeEnd
+			if end < start || end >= len(groupNodes) {
+				return nil, fmt.Errorf("Invalid subset range: end < start or > number of available nodes in '%s'", nf.Raw)
 			}
-
-			/* Option X) invalid/unknown subset */
-		} else {
-			return nil, fmt.Errorf("Failed to parse node specifiers: unknown subset in '%s'", filter)
 		}
-
+		return groupNodes[start : end+1], nil
+	case NodeFilterSubsetWildcard:
+		return groupNodes, nil
 	}
-
-	l.Log().Tracef("Filtered %d nodes (filter: %s)", len(filteredNodes), filters)
-
-	return filteredNodes, nil
+	return nil, fmt.Errorf("Failed to parse node specifiers: unknown subset in '%s'", nf.Raw)
 }
 
 // FilterNodesByRole returns a stripped list of nodes which do match the given role
@@ -0,0 +1,191 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+)
+
+func TestParseNodeFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []string
+		expected []NodeFilter
+		wantErr  bool
+	}{
+		{
+			name:     "all",
+			filters:  []string{"all"},
+			expected: []NodeFilter{{Raw: "all", Group: "all", RangeStart: -1, RangeEnd: -1}},
+		},
+		{
+			name:     "legacy list",
+			filters:  []string{"agent:0,1"},
+			expected: []NodeFilter{{Raw: "agent:0,1", Group: "agent", SubsetType: NodeFilterSubsetList, Indices: []int{0, 1}, RangeStart: -1, RangeEnd: -1}},
+		},
+		{
+			name:     "bracket range",
+			filters:  []string{"worker[1-3]"},
+			expected: []NodeFilter{{Raw: "worker[1-3]", Group: "worker", SubsetType: NodeFilterSubsetRange, RangeStart: 1, RangeEnd: 3}},
+		},
+		{
+			name:     "bracket list with suffix",
+			filters:  []string{"agent[0,2]:direct"},
+			expected: []NodeFilter{{Raw: "agent[0,2]:direct", Group: "agent", SubsetType: NodeFilterSubsetList, Indices: []int{0, 2}, RangeStart: -1, RangeEnd: -1, Suffix: "direct"}},
+		},
+		{
+			name:     "open range",
+			filters:  []string{"server[1-]"},
+			expected: []NodeFilter{{Raw: "server[1-]", Group: "server", SubsetType: NodeFilterSubsetRange, RangeStart: 1, RangeEnd: -1}},
+		},
+		{
+			name:    "negation",
+			filters: []string{"all,!master[0]"},
+			expected: []NodeFilter{
+				{Raw: "all", Group: "all", RangeStart: -1, RangeEnd: -1},
+				{Raw: "!master[0]", Negated: true, Group: "master", SubsetType: NodeFilterSubsetList, Indices: []int{0}, RangeStart: -1, RangeEnd: -1},
+			},
+		},
+		{
+			name:    "multiple flags and commas",
+			filters: []string{"server:0,1,agent[*]", "loadbalancer"},
+			expected: []NodeFilter{
+				{Raw: "server:0,1", Group: "server", SubsetType: NodeFilterSubsetList, Indices: []int{0, 1}, RangeStart: -1, RangeEnd: -1},
+				{Raw: "agent[*]", Group: "agent", SubsetType: NodeFilterSubsetWildcard, RangeStart: -1, RangeEnd: -1},
+				{Raw: "loadbalancer", Group: "loadbalancer", RangeStart: -1, RangeEnd: -1},
+			},
+		},
+		{name: "unknown group", filters: []string{"node[0]"}, wantErr: true},
+		{name: "missing subset", filters: []string{"agent"}, wantErr: true},
+		{name: "empty subset", filters: []string{"agent[]"}, wantErr: true},
+		{name: "unclosed bracket", filters: []string{"agent[0"}, wantErr: true},
+		{name: "unopened bracket", filters: []string{"agent0]"}, wantErr: true},
+		{name: "invalid subset", filters: []string{"agent[a-b]"}, wantErr: true},
+		{name: "descending range", filters: []string{"agent[3-1]"}, wantErr: true},
+		{name: "subset for all", filters: []string{"all[0]"}, wantErr: true},
+		{name: "empty filter", filters: []string{"all,,agent[0]"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseNodeFilters(tc.filters)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilterNodes(t *testing.T) {
+	nodes := []*k3d.Node{
+		{Name: "server-0", Role: k3d.ServerRole},
+		{Name: "server-1", Role: k3d.ServerRole},
+		{Name: "agent-0", Role: k3d.AgentRole},
+		{Name: "agent-1", Role: k3d.AgentRole},
+		{Name: "agent-2", Role: k3d.AgentRole},
+		{Name: "serverlb", Role: k3d.LoadBalancerRole},
+	}
+
+	tests := []struct {
+		name     string
+		filters  []string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "all", filters: []string{"all"}, expected: []string{"server-0", "server-1", "agent-0", "agent-1", "agent-2", "serverlb"}},
+		{name: "legacy subsets", filters: []string{"server:0", "agent:1-"}, expected: []string{"server-0", "agent-1", "agent-2"}},
+		{name: "range", filters: []string{"agent[1-2]"}, expected: []string{"agent-1", "agent-2"}},
+		{name: "wildcard", filters: []string{"servers[*]"}, expected: []string{"server-0", "server-1"}},
+		{name: "deduplicated union", filters: []string{"agent[0,1],agent[1-2]"}, expected: []string{"agent-0", "agent-1", "agent-2"}},
+		{name: "negation", filters: []string{"all,!server[0],!loadbalancer"}, expected: []string{"server-1", "agent-0", "agent-1", "agent-2"}},
+		{name: "only negations", filters: []string{"!agent[*]"}, expected: []string{"server-0", "server-1", "serverlb"}},
+		{name: "loadbalancer and more", filters: []string{"loadbalancer", "agent[0]"}, expected: []string{"serverlb", "agent-0"}},
+		{name: "index out of range", filters: []string{"agent[3]"}, wantErr: true},
+		{name: "range out of range", filters: []string{"server[1-2]"}, wantErr: true},
+		{name: "suffix", filters: []string{"agent[0]:direct"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := FilterNodes(nodes, tc.filters)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %d nodes", len(filtered))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := []string{}
+			for _, node := range filtered {
+				actual = append(actual, node.Name)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilterNodesWithSuffix(t *testing.T) {
+	nodes := []*k3d.Node{
+		{Name: "server-0", Role: k3d.ServerRole},
+		{Name: "agent-0", Role: k3d.AgentRole},
+		{Name: "agent-1", Role: k3d.AgentRole},
+	}
+
+	result, err := FilterNodesWithSuffix(nodes, []string{"agent[*]:proxy,!agent[0],server[0]:direct"}, "proxy", "direct")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]string{
+		"proxy":              {"agent-1"},
+		"direct":             {"server-0"},
+		NodeFilterSuffixNone: {},
+	}
+	for suffix, names := range expected {
+		actual := []string{}
+		for _, node := range result[suffix] {
+			actual = append(actual, node.Name)
+		}
+		if !reflect.DeepEqual(actual, names) {
+			t.Errorf("expected %v for suffix '%s', got %v", names, suffix, actual)
+		}
+	}
+
+	if _, err := FilterNodesWithSuffix(nodes, []string{"agent[0]:unknown"}, "proxy"); err == nil {
+		t.Errorf("expected an error for an unallowed suffix")
+	}
+}
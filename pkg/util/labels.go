@@ -22,6 +22,7 @@ THE SOFTWARE.
 package util
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -37,3 +38,11 @@ func SplitLabelKeyValue(label string) (string, string) {
 	// defaults to label key with empty value (like `docker run` do)
 	return label, ""
 }
+
+// ValidateRuntimeLabelKey validates that a given label key is not reserved for internal k3d usage
+func ValidateRuntimeLabelKey(labelKey string) error {
+	if strings.HasPrefix(labelKey, "k3s.") || strings.HasPrefix(labelKey, "k3d.") || labelKey == "app" {
+		return fmt.Errorf("runtime label \"%s\" is reserved for internal usage", labelKey)
+	}
+	return nil
+}
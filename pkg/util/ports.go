@@ -25,8 +25,10 @@ package util
 import (
 	"fmt"
 	"net"
+	"os"
 
 	"github.com/docker/go-connections/nat"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
 )
 
 // GetFreePort tries to fetch an open port from the OS-Kernel
@@ -45,6 +47,31 @@ func GetFreePort() (int, error) {
 	return tcpListener.Addr().(*net.TCPAddr).Port, nil
 }
 
+// GetFreePortOnHost tries to fetch an open port from the OS-Kernel on the given host IP, i.e. the IP that the port will be bound to later on.
+// An empty host IP or 0.0.0.0 checks all interfaces.
+// Note: this can only reduce, not eliminate the chance that another process grabs the port before the runtime binds it.
+func GetFreePortOnHost(hostIP string) (int, error) {
+	if hostIP == "0.0.0.0" {
+		hostIP = ""
+	}
+	tcpListener, err := net.Listen("tcp", net.JoinHostPort(hostIP, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tcp listener on '%s': %w", hostIP, err)
+	}
+	defer tcpListener.Close()
+
+	return tcpListener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// DefaultBindAddress returns the host IP that port mappings without an explicit one are bound to:
+// the value of K3D_DEFAULT_BIND_ADDRESS, if set, or all interfaces otherwise
+func DefaultBindAddress() string {
+	if bindAddress := os.Getenv(k3d.K3dEnvDefaultBindAddress); bindAddress != "" {
+		return bindAddress
+	}
+	return k3d.DefaultAPIHost
+}
+
 var equalHostIPs = map[string]interface{}{
 	"":          nil,
 	"127.0.0.1": nil,
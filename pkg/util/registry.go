@@ -23,7 +23,9 @@ package util
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
+	"strings"
 
 	"github.com/docker/go-connections/nat"
 	k3d "github.com/rancher/k3d/v5/pkg/types"
@@ -58,3 +60,46 @@ func ParseRegistryRef(registryRef string) (*k3d.Registry, error) {
 	}
 	return registry, nil
 }
+
+// ParseRegistryMirror parses a registry mirror definition of the form [REGISTRY=]ENDPOINT[,ENDPOINT...] into the mirrored registry
+// and the mirror endpoints. The registry defaults to docker.io (Docker Hub), '*' mirrors all registries.
+func ParseRegistryMirror(mirror string) (string, []string, error) {
+	registry := "docker.io"
+	endpointList := mirror
+	if split := strings.SplitN(mirror, "=", 2); len(split) == 2 {
+		registry, endpointList = split[0], split[1]
+	}
+	if registry == "" || endpointList == "" {
+		return "", nil, fmt.Errorf("Failed to parse registry mirror '%s': Must be [REGISTRY=]ENDPOINT[,ENDPOINT...]", mirror)
+	}
+
+	endpoints := strings.Split(endpointList, ",")
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", nil, fmt.Errorf("Failed to parse registry mirror '%s': endpoint '%s' must be a URL like http(s)://host[:port]", mirror, endpoint)
+		}
+	}
+	return registry, endpoints, nil
+}
+
+// ParseRegistryRewrite parses a registry rewrite rule of the form REGISTRY -> [SCHEME://]HOST[:PORT][/PATH] into the rewritten registry,
+// the mirror endpoint (SCHEME defaults to https) and the path below which the mirror serves the repositories of the registry (if any).
+func ParseRegistryRewrite(rule string) (string, string, string, error) {
+	split := strings.SplitN(rule, "->", 2)
+	if len(split) != 2 {
+		return "", "", "", fmt.Errorf("Failed to parse registry rewrite rule '%s': Must be REGISTRY -> [SCHEME://]HOST[:PORT][/PATH]", rule)
+	}
+	registry, target := strings.TrimSpace(split[0]), strings.TrimSpace(split[1])
+	if registry == "" || strings.ContainsAny(registry, " /") {
+		return "", "", "", fmt.Errorf("Failed to parse registry rewrite rule '%s': invalid registry '%s'", rule, registry)
+	}
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", "", fmt.Errorf("Failed to parse registry rewrite rule '%s': mirror '%s' must be [SCHEME://]HOST[:PORT][/PATH] with SCHEME http or https", rule, strings.TrimSpace(split[1]))
+	}
+	return registry, fmt.Sprintf("%s://%s", u.Scheme, u.Host), strings.Trim(u.Path, "/"), nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule describes weekly time windows (in local time), e.g. during which a cluster should be running
+type Schedule struct {
+	Windows []ScheduleWindow
+}
+
+// ScheduleWindow is a time window on a set of weekdays. If End is before Start, the window ends on the next day.
+type ScheduleWindow struct {
+	Days  map[time.Weekday]bool
+	Start time.Duration // offset from midnight
+	End   time.Duration // offset from midnight
+}
+
+var scheduleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSchedule parses a schedule of the format `[DAYS ]HH:MM-HH:MM[;[DAYS ]HH:MM-HH:MM...]`,
+// where DAYS is a comma-separated list of weekdays or weekday ranges (e.g. `Mon-Fri`, `Sat,Sun`, `*`)
+// - Example: `Mon-Fri 08:00-19:00` (running on weekdays during working hours)
+// - Example: `Mon-Thu 07:00-22:00;Fri 07:00-16:00`
+func ParseSchedule(spec string) (*Schedule, error) {
+	schedule := &Schedule{}
+	for _, windowSpec := range strings.Split(spec, ";") {
+		windowSpec = strings.TrimSpace(windowSpec)
+		if windowSpec == "" {
+			continue
+		}
+		window, err := parseScheduleWindow(windowSpec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule window '%s': %w", windowSpec, err)
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	if len(schedule.Windows) == 0 {
+		return nil, fmt.Errorf("schedule '%s' doesn't contain any time window", spec)
+	}
+	return schedule, nil
+}
+
+func parseScheduleWindow(spec string) (ScheduleWindow, error) {
+	window := ScheduleWindow{Days: map[time.Weekday]bool{}}
+
+	fields := strings.Fields(spec)
+	var daysSpec, timeSpec string
+	switch len(fields) {
+	case 1:
+		daysSpec, timeSpec = "*", fields[0]
+	case 2:
+		daysSpec, timeSpec = fields[0], fields[1]
+	default:
+		return window, fmt.Errorf("expected format `[DAYS ]HH:MM-HH:MM`")
+	}
+
+	// days
+	for _, daySpec := range strings.Split(daysSpec, ",") {
+		if daySpec == "*" {
+			for _, d := range scheduleWeekdays {
+				window.Days[d] = true
+			}
+			continue
+		}
+		bounds := strings.SplitN(daySpec, "-", 2)
+		from, ok := scheduleWeekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return window, fmt.Errorf("unknown weekday '%s'", bounds[0])
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = scheduleWeekdays[strings.ToLower(bounds[1])]; !ok {
+				return window, fmt.Errorf("unknown weekday '%s'", bounds[1])
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			window.Days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+
+	// times
+	times := strings.SplitN(timeSpec, "-", 2)
+	if len(times) != 2 {
+		return window, fmt.Errorf("expected time range `HH:MM-HH:MM`, got '%s'", timeSpec)
+	}
+	var err error
+	if window.Start, err = parseScheduleTime(times[0]); err != nil {
+		return window, err
+	}
+	if window.End, err = parseScheduleTime(times[1]); err != nil {
+		return window, err
+	}
+	if window.Start == window.End {
+		return window, fmt.Errorf("time range '%s' is empty", timeSpec)
+	}
+
+	return window, nil
+}
+
+func parseScheduleTime(spec string) (time.Duration, error) {
+	t, err := time.Parse("15:04", spec)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s' (expected HH:MM)", spec)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active checks whether the given point in time is covered by any window of the schedule
+func (s *Schedule) Active(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	day := t.Weekday()
+	previousDay := (day + 6) % 7
+
+	for _, w := range s.Windows {
+		if w.Start < w.End {
+			if w.Days[day] && offset >= w.Start && offset < w.End {
+				return true
+			}
+			continue
+		}
+		// window wraps around midnight
+		if (w.Days[day] && offset >= w.Start) || (w.Days[previousDay] && offset < w.End) {
+			return true
+		}
+	}
+	return false
+}
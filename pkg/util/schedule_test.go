@@ -0,0 +1,70 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleActive(t *testing.T) {
+	// 2021-11-01 is a Monday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2021, time.November, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		spec   string
+		t      time.Time
+		active bool
+	}{
+		{"Mon-Fri 08:00-19:00", at(1, 8, 0), true},
+		{"Mon-Fri 08:00-19:00", at(1, 19, 0), false},
+		{"Mon-Fri 08:00-19:00", at(5, 12, 0), true},
+		{"Mon-Fri 08:00-19:00", at(6, 12, 0), false},
+		{"Sat,Sun 10:00-12:00", at(7, 11, 0), true},
+		{"Fri-Mon 10:00-12:00", at(7, 11, 0), true},
+		{"Fri-Mon 10:00-12:00", at(2, 11, 0), false},
+		{"22:00-02:00", at(3, 1, 0), true},
+		{"Mon 22:00-02:00", at(2, 1, 59), true},
+		{"Mon 22:00-02:00", at(3, 1, 0), false},
+		{"Mon-Thu 07:00-22:00;Fri 07:00-16:00", at(5, 17, 0), false},
+		{"Mon-Thu 07:00-22:00;Fri 07:00-16:00", at(4, 21, 0), true},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("failed to parse schedule '%s': %v", tt.spec, err)
+		}
+		if active := schedule.Active(tt.t); active != tt.active {
+			t.Errorf("schedule '%s' at %s: expected active=%t, got %t", tt.spec, tt.t.Format(time.RFC1123), tt.active, active)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"", ";", "Mon-Fri", "Foo 08:00-10:00", "Mon 8-10", "Mon 10:00-10:00", "Mon Tue 08:00-10:00"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected error for schedule '%s'", spec)
+		}
+	}
+}
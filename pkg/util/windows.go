@@ -0,0 +1,69 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"strings"
+)
+
+// IsWindowsDrivePath checks whether the given path is an absolute Windows path starting with a drive letter, e.g. C:\Users or C:/Users
+func IsWindowsDrivePath(p string) bool {
+	if len(p) < 3 || p[1] != ':' || (p[2] != '\\' && p[2] != '/') {
+		return false
+	}
+	c := p[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// IsUNCPath checks whether the given path is a Windows UNC path, e.g. \\server\share\dir
+func IsUNCPath(p string) bool {
+	return len(p) > 2 && strings.HasPrefix(p, `\\`) && p[2] != '\\'
+}
+
+// TranslateWindowsPath converts a Windows path to the slash-separated form understood by Docker Desktop,
+// e.g. C:\Users\me -> C:/Users/me and \\server\share -> //server/share. Other paths are returned unchanged.
+func TranslateWindowsPath(p string) string {
+	if !IsWindowsDrivePath(p) && !IsUNCPath(p) {
+		return p
+	}
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// TranslateWindowsVolumeMount translates the source of a volume mount (SRC:DEST[:OPT]) if it is a Windows path
+func TranslateWindowsVolumeMount(volume string) string {
+	var srcEnd int
+	switch {
+	case IsWindowsDrivePath(volume):
+		srcEnd = strings.Index(volume[2:], ":")
+		if srcEnd >= 0 {
+			srcEnd += 2
+		}
+	case IsUNCPath(volume):
+		srcEnd = strings.Index(volume, ":")
+	default:
+		return volume
+	}
+	if srcEnd < 0 {
+		return TranslateWindowsPath(volume)
+	}
+	return TranslateWindowsPath(volume[:srcEnd]) + volume[srcEnd:]
+}
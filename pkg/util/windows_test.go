@@ -0,0 +1,41 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import "testing"
+
+func TestTranslateWindowsVolumeMount(t *testing.T) {
+	tests := map[string]string{
+		`C:\Users\me\data:/data`:          `C:/Users/me/data:/data`,
+		`c:/Users/me/data:/data:ro`:       `c:/Users/me/data:/data:ro`,
+		`D:\data`:                         `D:/data`,
+		`\\server\share\dir:/data`:        `//server/share/dir:/data`,
+		`/home/me/data:/data`:             `/home/me/data:/data`,
+		`k3d-volume:/var/lib/rancher/k3s`: `k3d-volume:/var/lib/rancher/k3s`,
+		`c:/data`:                         `c:/data`,
+	}
+	for input, expected := range tests {
+		if actual := TranslateWindowsVolumeMount(input); actual != expected {
+			t.Errorf("TranslateWindowsVolumeMount(%q): expected %q, got %q", input, expected, actual)
+		}
+	}
+}
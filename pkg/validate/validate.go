@@ -0,0 +1,145 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package validate provides the validation pipeline that is applied to fully-resolved cluster configs before creation.
+// It is shared by all entry points (CLI flags, config files, the local API and SDK users), which can extend or trim
+// the default pipeline with their own validators.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+)
+
+// Validator checks one aspect of a fully-resolved cluster config
+type Validator interface {
+	Name() string
+	Validate(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error
+}
+
+// ValidatorFunc is the signature of functions that can be turned into a Validator via New
+type ValidatorFunc func(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error
+
+type namedValidator struct {
+	name string
+	fn   ValidatorFunc
+}
+
+func (v namedValidator) Name() string { return v.name }
+
+func (v namedValidator) Validate(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error {
+	return v.fn(ctx, runtime, config)
+}
+
+// New creates a named Validator from a function
+func New(name string, fn ValidatorFunc) Validator {
+	return namedValidator{name: name, fn: fn}
+}
+
+// Error describes the failure of a single validator
+type Error struct {
+	Validator string
+	Err       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Validator, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects the failures of all validators of a pipeline
+type Errors []*Error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d validation errors:\n - %s", len(e), strings.Join(msgs, "\n - "))
+}
+
+// Pipeline is an ordered list of validators
+type Pipeline []Validator
+
+// With returns a copy of the pipeline with the given validators appended
+func (p Pipeline) With(validators ...Validator) Pipeline {
+	result := make(Pipeline, 0, len(p)+len(validators))
+	result = append(result, p...)
+	return append(result, validators...)
+}
+
+// Without returns a copy of the pipeline without the validators of the given names
+func (p Pipeline) Without(names ...string) Pipeline {
+	result := make(Pipeline, 0, len(p))
+pipelineLoop:
+	for _, v := range p {
+		for _, name := range names {
+			if v.Name() == name {
+				continue pipelineLoop
+			}
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// Validate runs all validators of the pipeline and returns all failures as Errors (or nil if all of them passed)
+func (p Pipeline) Validate(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error {
+	var errs Errors
+	for _, v := range p {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := v.Validate(ctx, runtime, config); err != nil {
+			errs = append(errs, &Error{Validator: v.Name(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Default returns the pipeline of built-in validators
+func Default() Pipeline {
+	return Pipeline{
+		New(NameClusterName, ValidateName),
+		New(NameNetwork, ValidateNetworkMode),
+		New(NameSubnets, ValidateSubnets),
+		New(NamePorts, ValidatePorts),
+		New(NameVolumes, ValidateVolumes),
+		New(NameRoles, ValidateRoleCounts),
+		New(NameResources, ValidateResources),
+		New(NameArgs, ValidateArgs),
+		New(NameKubeconfig, ValidateKubeconfig),
+	}
+}
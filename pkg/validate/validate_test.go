@@ -0,0 +1,204 @@
+/*
+Copyright © 2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package validate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"inet.af/netaddr"
+)
+
+func TestPipeline(t *testing.T) {
+	errTest := errors.New("test")
+	var called []string
+	validator := func(name string, err error) Validator {
+		return New(name, func(_ context.Context, _ runtimes.Runtime, _ *conf.ClusterConfig) error {
+			called = append(called, name)
+			return err
+		})
+	}
+
+	pipeline := Pipeline{validator("a", nil), validator("b", errTest)}.With(validator("c", errTest), validator("d", nil))
+
+	err := pipeline.Validate(context.Background(), nil, &conf.ClusterConfig{})
+	if strings.Join(called, ",") != "a,b,c,d" {
+		t.Errorf("expected all validators to be called in order, got %v", called)
+	}
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Validator != "b" || errs[1].Validator != "c" {
+		t.Fatalf("expected errors of validators b and c, got %v", err)
+	}
+	if !errors.Is(errs[0], errTest) {
+		t.Errorf("expected validator error to wrap the original error")
+	}
+
+	called = nil
+	if err := pipeline.Without("b", "c").Validate(context.Background(), nil, &conf.ClusterConfig{}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if strings.Join(called, ",") != "a,d" {
+		t.Errorf("expected only validators a and d to be called, got %v", called)
+	}
+	if len(pipeline) != 4 {
+		t.Errorf("expected With/Without not to modify the original pipeline")
+	}
+}
+
+func TestValidatePorts(t *testing.T) {
+	node := func(name string, ports ...string) *k3d.Node {
+		_, bindings, err := nat.ParsePortSpecs(ports)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &k3d.Node{Name: name, Ports: bindings}
+	}
+
+	tests := []struct {
+		name    string
+		nodes   []*k3d.Node
+		wantErr bool
+	}{
+		{name: "no collision", nodes: []*k3d.Node{node("a", "8080:80"), node("b", "8081:80", "8080:80/udp")}},
+		{name: "random host ports", nodes: []*k3d.Node{node("a", "80"), node("b", "80")}},
+		{name: "different host IPs", nodes: []*k3d.Node{node("a", "127.0.0.1:8080:80"), node("b", "127.0.0.2:8080:80")}},
+		{name: "same host port", nodes: []*k3d.Node{node("a", "8080:80"), node("b", "8080:443")}, wantErr: true},
+		{name: "wildcard and specific IP", nodes: []*k3d.Node{node("a", "127.0.0.1:8080:80"), node("b", "8080:80")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &conf.ClusterConfig{Cluster: k3d.Cluster{Nodes: tt.nodes, ServerLoadBalancer: k3d.NewLoadbalancer()}}
+			if err := ValidatePorts(context.Background(), nil, config); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRoleCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []*k3d.Node
+		wantErr bool
+	}{
+		{name: "single server", nodes: []*k3d.Node{{Role: k3d.ServerRole}}},
+		{name: "servers, agents and loadbalancer", nodes: []*k3d.Node{{Role: k3d.ServerRole, ServerOpts: k3d.ServerOpts{IsInit: true}}, {Role: k3d.ServerRole}, {Role: k3d.AgentRole}, {Role: k3d.LoadBalancerRole}}},
+		{name: "no server", nodes: []*k3d.Node{{Role: k3d.AgentRole}}, wantErr: true},
+		{name: "two loadbalancers", nodes: []*k3d.Node{{Role: k3d.ServerRole}, {Role: k3d.LoadBalancerRole}, {Role: k3d.LoadBalancerRole}}, wantErr: true},
+		{name: "two init servers", nodes: []*k3d.Node{{Role: k3d.ServerRole, ServerOpts: k3d.ServerOpts{IsInit: true}}, {Role: k3d.ServerRole, ServerOpts: k3d.ServerOpts{IsInit: true}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &conf.ClusterConfig{Cluster: k3d.Cluster{Nodes: tt.nodes}}
+			if err := ValidateRoleCounts(context.Background(), nil, config); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// networkPrefixesRuntime fakes the runtime's view on the subnets of existing networks
+type networkPrefixesRuntime struct {
+	runtimes.Runtime
+	prefixes map[string][]netaddr.IPPrefix
+}
+
+func (r networkPrefixesRuntime) GetNetworkPrefixes(_ context.Context) (map[string][]netaddr.IPPrefix, error) {
+	return r.prefixes, nil
+}
+
+func TestValidateSubnets(t *testing.T) {
+	runtime := networkPrefixesRuntime{prefixes: map[string][]netaddr.IPPrefix{
+		"bridge":      {netaddr.MustParseIPPrefix("172.17.0.0/16")},
+		"k3d-other":   {netaddr.MustParseIPPrefix("172.28.0.0/16"), netaddr.MustParseIPPrefix("fd00:28::/64")},
+		"k3d-reused":  {netaddr.MustParseIPPrefix("172.30.0.0/16")},
+		"no-ipam-net": nil,
+	}}
+
+	tests := []struct {
+		name    string
+		network k3d.ClusterNetwork
+		wantErr bool
+	}{
+		{name: "no subnet", network: k3d.ClusterNetwork{Name: "k3d-test"}},
+		{name: "free subnet", network: k3d.ClusterNetwork{Name: "k3d-test", IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.29.0.0/16")}}},
+		{name: "overlapping subnet", network: k3d.ClusterNetwork{Name: "k3d-test", IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.28.10.0/24")}}, wantErr: true},
+		{name: "overlapping IPv6 subnet", network: k3d.ClusterNetwork{Name: "k3d-test", IPv6: true, IPAM: k3d.IPAM{IPv6Prefix: netaddr.MustParseIPPrefix("fd00:28::/48")}}, wantErr: true},
+		{name: "re-used network", network: k3d.ClusterNetwork{Name: "k3d-reused", IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.30.0.0/16")}}},
+		{name: "external network", network: k3d.ClusterNetwork{Name: "bridge", External: true, IPAM: k3d.IPAM{IPPrefix: netaddr.MustParseIPPrefix("172.17.0.0/16")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &conf.ClusterConfig{Cluster: k3d.Cluster{Network: tt.network}}
+			if err := ValidateSubnets(context.Background(), runtime, config); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+type runtimeOptsRuntime struct {
+	runtimes.Runtime
+	supported string
+}
+
+func (r runtimeOptsRuntime) ValidateRuntimeOpts(opts []string) error {
+	for _, opt := range opts {
+		if !strings.HasPrefix(opt, r.supported+"=") {
+			return errors.New("unsupported runtime opt")
+		}
+	}
+	return nil
+}
+
+func TestValidateArgsRuntimeOpts(t *testing.T) {
+	runtime := runtimeOptsRuntime{supported: "cpuset-cpus"}
+
+	tests := []struct {
+		name        string
+		runtimeOpts []string
+		wantErr     bool
+	}{
+		{name: "no runtime opts"},
+		{name: "supported runtime opt", runtimeOpts: []string{"cpuset-cpus=0-1"}},
+		{name: "unsupported runtime opt", runtimeOpts: []string{"cpuset-cpus=0-1", "unknown=true"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &conf.ClusterConfig{Cluster: k3d.Cluster{Nodes: []*k3d.Node{{Name: "lb", Role: k3d.LoadBalancerRole, RuntimeOpts: tt.runtimeOpts}}}}
+			if err := ValidateArgs(context.Background(), runtime, config); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
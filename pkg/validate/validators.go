@@ -0,0 +1,344 @@
+/*
+Copyright © 2020-2021 The k3d Author(s)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package validate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	dockerunits "github.com/docker/go-units"
+
+	k3dc "github.com/rancher/k3d/v5/pkg/client"
+	conf "github.com/rancher/k3d/v5/pkg/config/v1alpha3"
+	l "github.com/rancher/k3d/v5/pkg/logger"
+	"github.com/rancher/k3d/v5/pkg/runtimes"
+	runtimeutil "github.com/rancher/k3d/v5/pkg/runtimes/util"
+	k3d "github.com/rancher/k3d/v5/pkg/types"
+	"github.com/rancher/k3d/v5/pkg/types/k3s"
+	"github.com/rancher/k3d/v5/pkg/util"
+	"inet.af/netaddr"
+)
+
+// Names of the built-in validators
+const (
+	NameClusterName = "name"
+	NameNetwork     = "network"
+	NameSubnets     = "subnets"
+	NamePorts       = "ports"
+	NameVolumes     = "volumes"
+	NameRoles       = "roles"
+	NameResources   = "resources"
+	NameArgs        = "args"
+	NameKubeconfig  = "kubeconfig"
+)
+
+// ValidateName checks that the cluster name is a valid host name
+func ValidateName(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	if err := k3dc.CheckName(config.Cluster.Name); err != nil {
+		return fmt.Errorf("provided cluster name '%s' does not match requirements: %w", config.Cluster.Name, err)
+	}
+	return nil
+}
+
+// ValidateNetworkMode checks the constraints of the host network mode
+func ValidateNetworkMode(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	if config.Cluster.Network.Name != "host" {
+		return nil
+	}
+
+	// only a single node (to avoid port collisions)
+	if len(config.Cluster.Nodes) > 1 {
+		return fmt.Errorf("can only use hostnetwork mode with a single node (port collisions, etc.)")
+	}
+
+	// in hostNetwork mode, we're not going to map a hostport. Here it should always use 6443.
+	// Note that hostNetwork mode is super inflexible and since we don't change the backend port (on the container), it will only be one hostmode cluster allowed.
+	if config.Cluster.KubeAPI != nil && config.Cluster.KubeAPI.Port.Port() != k3d.DefaultAPIPort {
+		return fmt.Errorf("the API Port can not be changed when using 'host' network")
+	}
+
+	return nil
+}
+
+// ValidateSubnets checks that the subnets requested for a new cluster network don't overlap with the ones of existing runtime networks,
+// as the network creation (or the routing between the networks) would fail otherwise
+func ValidateSubnets(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error {
+	network := config.Cluster.Network
+	if network.External {
+		return nil
+	}
+
+	var requested []netaddr.IPPrefix
+	for _, prefix := range []netaddr.IPPrefix{network.IPAM.IPPrefix, network.IPAM.IPv6Prefix} {
+		if !prefix.IsZero() {
+			requested = append(requested, prefix)
+		}
+	}
+	if len(requested) == 0 {
+		return nil
+	}
+
+	existing, err := runtime.GetNetworkPrefixes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the subnets of existing networks: %w", err)
+	}
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		if name != network.Name { // re-used by the cluster
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var overlaps []string
+	for _, prefix := range requested {
+		for _, name := range names {
+			for _, existingPrefix := range existing[name] {
+				if prefix.Overlaps(existingPrefix) {
+					overlaps = append(overlaps, fmt.Sprintf("subnet %s overlaps with subnet %s of network '%s'", prefix, existingPrefix, name))
+				}
+			}
+		}
+	}
+	if len(overlaps) > 0 {
+		return fmt.Errorf("%s", strings.Join(overlaps, "; "))
+	}
+	return nil
+}
+
+// ValidatePorts checks that no host port is mapped twice (on overlapping host IPs) across all nodes of the cluster
+func ValidatePorts(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	type binding struct {
+		hostIP string
+		owner  string
+	}
+	bindings := map[string][]binding{} // "hostPort/proto" -> bindings
+
+	add := func(owner string, port nat.Port, pb nat.PortBinding) error {
+		if pb.HostPort == "" {
+			return nil // random host port
+		}
+		if _, err := nat.ParsePort(pb.HostPort); err != nil {
+			return fmt.Errorf("invalid host port '%s' for port '%s' of %s: %w", pb.HostPort, port, owner, err)
+		}
+		key := fmt.Sprintf("%s/%s", pb.HostPort, port.Proto())
+		for _, existing := range bindings[key] {
+			if existing.hostIP == pb.HostIP || isWildcardIP(existing.hostIP) || isWildcardIP(pb.HostIP) {
+				return fmt.Errorf("host port %s is mapped twice (by %s and %s)", key, existing.owner, owner)
+			}
+		}
+		bindings[key] = append(bindings[key], binding{hostIP: pb.HostIP, owner: owner})
+		return nil
+	}
+
+	for _, node := range config.Cluster.Nodes {
+		for port, pbs := range node.Ports {
+			for _, pb := range pbs {
+				if err := add(fmt.Sprintf("node '%s'", node.Name), port, pb); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// without a loadbalancer, the API port is mapped on a server node only during creation
+	if config.Cluster.ServerLoadBalancer == nil && config.Cluster.KubeAPI != nil && config.Cluster.Network.Name != "host" {
+		if err := add("the Kubernetes API", nat.Port(k3d.DefaultAPIPort+"/tcp"), config.Cluster.KubeAPI.Binding); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
+// ValidateVolumes checks that volumes are either existing paths on the host or named runtime volumes
+func ValidateVolumes(ctx context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error {
+	for _, node := range config.Cluster.Nodes {
+		for _, volume := range node.Volumes {
+			if err := runtimeutil.ValidateVolumeMount(ctx, runtime, volume, &config.Cluster); err != nil {
+				return fmt.Errorf("failed to validate volume mount '%s': %w", volume, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRoleCounts checks that the cluster has at least one server and at most one loadbalancer and init node
+func ValidateRoleCounts(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	counts := map[k3d.Role]int{}
+	initNodes := 0
+	for _, node := range config.Cluster.Nodes {
+		counts[node.Role]++
+		if node.Role == k3d.ServerRole && node.ServerOpts.IsInit {
+			initNodes++
+		}
+	}
+
+	if counts[k3d.ServerRole] < 1 {
+		return fmt.Errorf("a cluster needs at least one server node")
+	}
+	if counts[k3d.LoadBalancerRole] > 1 {
+		return fmt.Errorf("a cluster can have at most one loadbalancer, but has %d", counts[k3d.LoadBalancerRole])
+	}
+	if initNodes > 1 {
+		return fmt.Errorf("a cluster can have at most one initializing server, but has %d", initNodes)
+	}
+
+	return nil
+}
+
+// ValidateResources checks timeouts, resource limits, check profiles and schedules
+func ValidateResources(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	// timeout can't be negative
+	if config.ClusterCreateOpts.Timeout < 0*time.Second {
+		return fmt.Errorf("timeout may not be negative (is '%s')", config.ClusterCreateOpts.Timeout)
+	}
+
+	// memory limits must have proper format
+	// if empty we don't care about errors in parsing
+	if config.ClusterCreateOpts.ServersMemory != "" {
+		if _, err := dockerunits.RAMInBytes(config.ClusterCreateOpts.ServersMemory); err != nil {
+			return fmt.Errorf("provided servers memory limit value is invalid: %w", err)
+		}
+	}
+	if config.ClusterCreateOpts.AgentsMemory != "" {
+		if _, err := dockerunits.RAMInBytes(config.ClusterCreateOpts.AgentsMemory); err != nil {
+			return fmt.Errorf("provided agents memory limit value is invalid: %w", err)
+		}
+	}
+
+	// check profiles must be known
+	for _, profile := range config.ClusterCreateOpts.CheckProfiles {
+		if _, ok := k3d.CheckProfiles[profile]; !ok {
+			return fmt.Errorf("unknown check profile '%s': must be one of %s", profile, strings.Join(k3d.CheckProfileNames(), ", "))
+		}
+	}
+
+	// customized startup order must only reference known steps and must not contain cycles
+	if _, err := k3dc.StartupDependencies(config.Cluster.Startup); err != nil {
+		return fmt.Errorf("provided startup order is invalid: %w", err)
+	}
+
+	// hibernation schedule must be parseable
+	if schedule, ok := config.ClusterCreateOpts.GlobalLabels[k3d.LabelHibernationSchedule]; ok {
+		if _, err := util.ParseSchedule(schedule); err != nil {
+			return fmt.Errorf("provided hibernation schedule is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateArgs checks the raw runtime opts and the k3s args of all nodes
+func ValidateArgs(_ context.Context, runtime runtimes.Runtime, config *conf.ClusterConfig) error {
+	for _, node := range config.Cluster.Nodes {
+		// raw runtime opts are specific to the runtime
+		if len(node.RuntimeOpts) > 0 {
+			if err := runtime.ValidateRuntimeOpts(node.RuntimeOpts); err != nil {
+				return fmt.Errorf("invalid runtime opts for node '%s': %w", node.Name, err)
+			}
+		}
+
+		// k3s args have to be known to the k3s version and role of the node, as typos only show up as crash-looping nodes otherwise
+		if node.Role == k3d.ServerRole || node.Role == k3d.AgentRole {
+			if err := K3sArgs(node.Image, node.Role, node.Args); err != nil {
+				return fmt.Errorf("invalid k3s arg for node '%s': %w", node.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateKubeconfig checks the kubeconfig options, e.g. that the role is known and the credential store for encrypted credentials is complete
+func ValidateKubeconfig(_ context.Context, _ runtimes.Runtime, config *conf.ClusterConfig) error {
+	if config.KubeconfigOpts.Role != "" {
+		if err := k3dc.KubeconfigRoleValidate(config.KubeconfigOpts.Role); err != nil {
+			return err
+		}
+	}
+	if config.KubeconfigOpts.Encryption.Store == "" {
+		return nil
+	}
+	if !config.KubeconfigOpts.UpdateDefaultKubeconfig && config.KubeconfigOpts.Output == "" {
+		return fmt.Errorf("kubeconfig encryption requires a kubeconfig to be written (updateDefaultKubeconfig or output)")
+	}
+	return k3dc.KubeconfigEncryptionValidate(&config.KubeconfigOpts.Encryption)
+}
+
+var k3sImageMinorVersionRegexp = regexp.MustCompile(`^v?1\.(\d+)`)
+
+// K3sArgs checks the given k3s args against the flags known to `k3s server`/`k3s agent` (see k3s.FlagsCommon/k3s.FlagsServerOnly).
+// For images newer than the embedded flag metadata (or images without a parseable version tag), unknown flags only produce a warning.
+func K3sArgs(image string, role k3d.Role, args []string) error {
+	known := map[string]bool{}
+	for _, flag := range k3s.FlagsCommon {
+		known[flag] = true
+	}
+	serverOnly := map[string]bool{}
+	for _, flag := range k3s.FlagsServerOnly {
+		serverOnly[flag] = true
+	}
+
+	strict := false
+	tag := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(tag, ":"); i >= 0 {
+		if match := k3sImageMinorVersionRegexp.FindStringSubmatch(tag[i+1:]); match != nil {
+			if minor, err := strconv.Atoi(match[1]); err == nil && minor <= k3s.FlagsKnownMinorVersion {
+				strict = true
+			}
+		}
+	}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue // flag value
+		}
+		flag := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+
+		var err error
+		if serverOnly[flag] && role != k3d.ServerRole {
+			err = fmt.Errorf("flag '--%s' (from '%s') is only supported by k3s servers, not by %ss", flag, arg, role)
+		} else if !known[flag] && !serverOnly[flag] {
+			err = fmt.Errorf("flag '--%s' (from '%s') is not known to 'k3s %s'", flag, arg, role)
+		}
+
+		if err != nil {
+			if !strict {
+				l.Log().Warnf("%v (image '%s' is newer than or not comparable to the known k3s v1.%d flags, so you may ignore this)", err, image, k3s.FlagsKnownMinorVersion)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
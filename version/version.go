@@ -27,6 +27,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/rancher/k3d/v5/pkg/types/k3s"
 )
@@ -60,32 +61,57 @@ func GetK3sVersion(channel string) (string, error) {
 	return K3sVersion, nil
 }
 
-// fetchLatestK3sVersion tries to fetch the latest version of k3s from DockerHub
+// fetchLatestK3sVersion tries to fetch the latest version of k3s from the k3s channel server
 func fetchLatestK3sVersion(channel string) (string, error) {
 
-	resp, err := http.Get(k3s.K3sChannelServerURL)
+	channels, err := FetchK3sChannels(k3s.K3sChannelServerURL)
 	if err != nil {
 		return "", err
 	}
+
+	for _, c := range channels {
+		if c.Name == channel {
+			return K3sChannelVersion(c), nil
+		}
+	}
+
+	return "", fmt.Errorf("no latest version found for channel %s (%s)", channel, k3s.K3sChannelServerURL)
+
+}
+
+// FetchK3sChannels fetches all release channels (e.g. stable, latest, v1.21) from the given k3s channel server
+func FetchK3sChannels(url string) ([]k3s.Channel, error) {
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("channelserver %s returned status %s", url, resp.Status)
+	}
+
 	out := k3s.ChannelServerResponse{}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("error reading channelserver response body: %w", err)
+		return nil, fmt.Errorf("error reading channelserver response body: %w", err)
 	}
 
 	if err := json.Unmarshal(body, &out); err != nil {
-		return "", fmt.Errorf("error unmarshalling channelserver response: %w", err)
+		return nil, fmt.Errorf("error unmarshalling channelserver response: %w", err)
 	}
 
+	channels := make([]k3s.Channel, 0, len(out.Channels))
 	for _, c := range out.Channels {
-		if c.Name == channel {
-			return strings.ReplaceAll(c.Latest, "+", "-"), err
-		}
+		channels = append(channels, c.Channel)
 	}
+	return channels, nil
+}
 
-	return "", fmt.Errorf("no latest version found for channel %s (%s)", channel, k3s.K3sChannelServerURL)
-
+// K3sChannelVersion returns the latest version of the channel as k3s image tag (i.e. with '+' replaced by '-')
+func K3sChannelVersion(channel k3s.Channel) string {
+	return strings.ReplaceAll(channel.Latest, "+", "-")
 }